@@ -0,0 +1,80 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Attributes tagged readonly, such as timestamps set by the server, are
+// written by Marshal but may not be sent by clients; attributes tagged
+// writeonly, such as passwords, are read by UnmarshalPayload but never
+// written:
+//
+//	CreatedAt time.Time `jsonapi:"attr,created-at,readonly"`
+//	Password  string    `jsonapi:"attr,password,writeonly"`
+
+// ErrReadOnly is the error of an AttributeError for a readonly attribute
+// present in a document being unmarshaled.
+var ErrReadOnly = errors.New("attribute is read-only")
+
+// AttributeError reports an attribute of a resource of Type that could not
+// be unmarshaled, because it is readonly or because its value does not fit
+// the field, e.g. with Err ErrInvalidType.
+type AttributeError struct {
+	Type      string
+	Attribute string
+	Err       error
+}
+
+func (e *AttributeError) Error() string {
+	return fmt.Sprintf("jsonapi: attribute %q of resource of type %q: %v", e.Attribute, e.Type, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *AttributeError) Unwrap() error {
+	return e.Err
+}
+
+// Pointer returns a JSON pointer to the offending attribute, relative to
+// the resource object, e.g. /attributes/created-at.
+func (e *AttributeError) Pointer() string {
+	return jsonPointer("attributes", e.Attribute)
+}
+
+// ErrorObject converts e into an error object pointing at the primary data
+// of a single-resource document: 403, the status the specification gives
+// for unsupported updates, for readonly attributes and 400 otherwise.
+func (e *AttributeError) ErrorObject() *ErrorObject {
+	obj := &ErrorObject{
+		Title:  "Invalid attribute",
+		Detail: e.Err.Error(),
+		Status: strconv.Itoa(http.StatusBadRequest),
+		Source: &ErrorSource{Pointer: "/data" + e.Pointer()},
+	}
+	if errors.Is(e.Err, ErrReadOnly) {
+		obj.Title = "Read-only attribute"
+		obj.Status = strconv.Itoa(http.StatusForbidden)
+	}
+	return obj
+}
+
+// IgnoreReadOnly makes unmarshaling skip readonly attributes instead of
+// failing with an *AttributeError, for clients that send back the
+// resources they were given.
+func IgnoreReadOnly() UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.ignoreReadOnly = true
+	}
+}
+
+// hasOption reports whether the tag args carry the option opt.
+func hasOption(args []string, opt string) bool {
+	for i := 2; i < len(args); i++ {
+		if args[i] == opt {
+			return true
+		}
+	}
+	return false
+}
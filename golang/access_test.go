@@ -0,0 +1,125 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type accessUser struct {
+	ID       string `jsonapi:"primary,users"`
+	Name     string `jsonapi:"attr,name"`
+	Created  string `jsonapi:"attr,created-at,readonly"`
+	Password string `jsonapi:"attr,password,writeonly"`
+}
+
+func TestReadOnlyWriteOnlyMarshal(t *testing.T) {
+	attrs := marshalDoc(t, &accessUser{ID: "1", Name: "Ann", Created: "today", Password: "secret"})["data"].(map[string]interface{})["attributes"]
+	if want := map[string]interface{}{"name": "Ann", "created-at": "today"}; !reflect.DeepEqual(attrs, want) {
+		t.Errorf("attributes %v, want %v", attrs, want)
+	}
+}
+
+func TestReadOnlyWriteOnlyUnmarshal(t *testing.T) {
+	doc := `{"data": {"type": "users", "id": "1", "attributes": {"name": "Ann", "password": "secret"}}}`
+	out := new(accessUser)
+	if err := UnmarshalPayload(strings.NewReader(doc), out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Password != "secret" {
+		t.Errorf("unmarshaled %+v, want the write-only password", out)
+	}
+
+	doc = `{"data": {"type": "users", "id": "1", "attributes": {"name": "Ann", "created-at": "today"}}}`
+	err := UnmarshalPayload(strings.NewReader(doc), new(accessUser))
+	var attrErr *AttributeError
+	if !errors.As(err, &attrErr) || !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("error %v, want an *AttributeError for ErrReadOnly", err)
+	}
+	if attrErr.Type != "users" || attrErr.Attribute != "created-at" || attrErr.Pointer() != "/attributes/created-at" {
+		t.Errorf("attribute error %+v", attrErr)
+	}
+	obj := attrErr.ErrorObject()
+	if obj.Status != "403" || obj.Title != "Read-only attribute" || obj.Source.Pointer != "/data/attributes/created-at" {
+		t.Errorf("error object %+v", obj)
+	}
+
+	out = new(accessUser)
+	if err := UnmarshalPayload(strings.NewReader(doc), out, IgnoreReadOnly()); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "Ann" || out.Created != "" {
+		t.Errorf("unmarshaled %+v, want the read-only attribute skipped", out)
+	}
+}
+
+func TestAttributeErrorObject(t *testing.T) {
+	e := &AttributeError{Type: "users", Attribute: "a/b", Err: ErrInvalidType}
+	obj := e.ErrorObject()
+	if obj.Status != "400" || obj.Title != "Invalid attribute" || obj.Source.Pointer != "/data/attributes/a~1b" {
+		t.Errorf("error object %+v", obj)
+	}
+	if !strings.Contains(e.Error(), `attribute "a/b" of resource of type "users"`) {
+		t.Errorf("message %q", e.Error())
+	}
+}
+
+func TestReadOnlyLenient(t *testing.T) {
+	doc := `{"data": [
+		{"type": "users", "id": "1", "attributes": {"name": "Ann"}},
+		{"type": "users", "id": "2", "attributes": {"created-at": "today"}}
+	]}`
+	models, errs, err := UnmarshalManyLenient(strings.NewReader(doc), reflect.TypeOf(new(accessUser)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 2 || models[0] == nil || models[1] != nil {
+		t.Fatalf("models %v", models)
+	}
+	if len(errs) != 1 || errs[0].Pointer != "/data/1/attributes/created-at" {
+		t.Errorf("errors %v", errs)
+	}
+}
+
+func TestReadOnlyWriteOnlySchema(t *testing.T) {
+	b, err := ExportJSONSchema(new(accessUser))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Properties struct {
+			Attributes struct {
+				Properties map[string]map[string]interface{} `json:"properties"`
+				Required   []string                          `json:"required"`
+			} `json:"attributes"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatal(err)
+	}
+	attrs := schema.Properties.Attributes
+	if attrs.Properties["created-at"]["readOnly"] != true || attrs.Properties["password"]["writeOnly"] != true {
+		t.Errorf("attribute schemas %v", attrs.Properties)
+	}
+	for _, name := range attrs.Required {
+		if name == "password" {
+			t.Errorf("required %v lists the write-only password", attrs.Required)
+		}
+	}
+}
+
+func TestReadOnlyWriteOnlyCheckModel(t *testing.T) {
+	type both struct {
+		ID string `jsonapi:"primary,things"`
+		X  string `jsonapi:"attr,x,readonly,writeonly"`
+	}
+	if errs := CheckModel(&accessUser{}); errs != nil {
+		t.Errorf("CheckModel(accessUser) = %v", errs)
+	}
+	errs := CheckModel(&both{})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "mutually exclusive") {
+		t.Errorf("CheckModel(both) = %v", errs)
+	}
+}
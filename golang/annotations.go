@@ -0,0 +1,84 @@
+package jsonapi
+
+// Tag options added on top of the core annotations.
+const (
+	// annotationDeprecated marks an attribute as deprecated; see
+	// KeyDeprecatedMeta.
+	annotationDeprecated = "deprecated"
+
+	// annotationCount adds the size of a to-many relationship to its
+	// meta; see KeyCountMeta.
+	annotationCount = "count"
+
+	// annotationIDs makes a relation field hold the ids of the related
+	// resources rather than the resources themselves; see
+	// idsRelationType.
+	annotationIDs = "ids"
+
+	// annotationSince and annotationUntil restrict an attribute or
+	// relation to a range of API versions; see WithAPIVersion.
+	annotationSince = "since"
+	annotationUntil = "until"
+
+	// annotationReadOnly and annotationWriteOnly limit an attribute to
+	// one direction; see AttributeError and IgnoreReadOnly.
+	annotationReadOnly  = "readonly"
+	annotationWriteOnly = "writeonly"
+
+	// annotationDefault gives the value of an attribute missing from a
+	// document being unmarshaled; see defaultOption.
+	annotationDefault = "default"
+
+	// annotationNumber makes a decimal attribute render as a JSON number
+	// rather than a string; see RegisterDecimal.
+	annotationNumber = "number"
+
+	// annotationBase64URL makes a byte attribute use the URL-safe base64
+	// alphabet; see isBytesType.
+	annotationBase64URL = "base64url"
+
+	// annotationAddrSpec makes a mail.Address attribute hold the bare
+	// address, without the display name; see stringTypes.
+	annotationAddrSpec = "addrspec"
+
+	// annotationIgnore, as the whole tag `jsonapi:"-"`, leaves a field out
+	// even where an untagged one would be used, as with
+	// WithJSONTagFallback; see fieldTag.
+	annotationIgnore = "-"
+)
+
+// KeyDeprecatedMeta is the resource meta member listing the deprecated
+// attributes, tagged with the deprecated option, that a resource object
+// contains, e.g. `jsonapi:"attr,full-name,deprecated"`.
+const KeyDeprecatedMeta = "deprecated"
+
+// KeyCountMeta is the relationship meta member holding the number of
+// related resources of a to-many relation tagged with the count option,
+// e.g. `jsonapi:"relation,comments,count"`.
+const KeyCountMeta = "count"
+
+// RelationshipCounter lets a model report the size of a to-many
+// relationship tagged with the count option, for instance the total from a
+// COUNT query when the relation field holds only a page or is not loaded.
+// Returning false falls back to the length of the field.
+type RelationshipCounter interface {
+	JSONAPIRelationshipCount(relation string) (int, bool)
+}
+
+// withCount returns a copy of meta with the count of relation added.
+func withCount(model interface{}, relation string, n int, meta *Meta) *Meta {
+	if counter, ok := model.(RelationshipCounter); ok {
+		if c, ok := counter.JSONAPIRelationshipCount(relation); ok {
+			n = c
+		}
+	}
+
+	m := Meta{}
+	if meta != nil {
+		for k, v := range *meta {
+			m[k] = v
+		}
+	}
+	m[KeyCountMeta] = n
+	return &m
+}
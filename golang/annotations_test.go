@@ -0,0 +1,55 @@
+package jsonapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+type deprecatedUser struct {
+	ID       string `jsonapi:"primary,users"`
+	Name     string `jsonapi:"attr,name"`
+	FullName string `jsonapi:"attr,full-name,deprecated"`
+	Nick     string `jsonapi:"attr,nick,omitempty,deprecated"`
+}
+
+func TestDeprecatedMeta(t *testing.T) {
+	tests := []struct {
+		user deprecatedUser
+		want interface{}
+	}{
+		{deprecatedUser{ID: "1", FullName: "Ann A"}, []interface{}{"full-name"}},
+		{deprecatedUser{ID: "1", Nick: "an"}, []interface{}{"full-name", "nick"}},
+	}
+	for _, tt := range tests {
+		data := marshalDoc(t, &tt.user)["data"].(map[string]interface{})
+		meta, _ := data["meta"].(map[string]interface{})
+		if got := meta[KeyDeprecatedMeta]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v: deprecated %v, want %v", tt.user, got, tt.want)
+		}
+	}
+}
+
+type countedPost struct {
+	ID       string          `jsonapi:"primary,posts"`
+	Comments []*orderComment `jsonapi:"relation,comments,count"`
+	Tags     []*orderPerson  `jsonapi:"relation,tags,count"`
+}
+
+func (p *countedPost) JSONAPIRelationshipCount(relation string) (int, bool) {
+	if relation == "tags" {
+		return 40, true
+	}
+	return 0, false
+}
+
+func TestCountMeta(t *testing.T) {
+	post := &countedPost{ID: "1", Comments: []*orderComment{{ID: "a"}, {ID: "b"}}}
+	rels := marshalDoc(t, post)["data"].(map[string]interface{})["relationships"].(map[string]interface{})
+
+	for name, want := range map[string]float64{"comments": 2, "tags": 40} {
+		meta, _ := rels[name].(map[string]interface{})["meta"].(map[string]interface{})
+		if meta[KeyCountMeta] != want {
+			t.Errorf("%s: meta %v, want count %v", name, meta, want)
+		}
+	}
+}
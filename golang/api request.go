@@ -1,153 +0,0 @@
-package jsonapi
-
-import (
-	"encoding/json"
-	"errors"
-	"fmt"
-	"io"
-	"reflect"
-	"strconv"
-	"strings"
-	"time"
-)
-
-var (
-	
-	ErrBadJSONAPIStructTag = errors.New("Bad jsonapi struct tag format")
-
-func MarshalPayload(w io.Writer, models interface{}) error {
-	payload, err := Marshal(models)
-	if err != nil {
-		return err
-	}
-
-	return json.NewEncoder(w).Encode(payload)
-}
-
-
-func Marshal(models interface{}) (Payloader, error) {
-	switch vals := reflect.ValueOf(models); vals.Kind() {
-	case reflect.Slice:
-		m, err := convertToSliceInterface(&models)
-		if err != nil {
-			return nil, err
-		}
-
-		payload, err := marshalMany(m)
-		if err != nil {
-			return nil, err
-		}
-
-		if linkableModels, isLinkable := models.(Linkable); isLinkable {
-			jl := linkableModels.JSONAPILinks()
-			if er := jl.validate(); er != nil {
-				return nil, er
-			}
-			payload.Links = linkableModels.JSONAPILinks()
-		}
-
-		if metableModels, ok := models.(Metable); ok {
-			payload.Meta = metableModels.JSONAPIMeta()
-		}
-
-		return payload, nil
-	case reflect.Ptr:
-		// Check that the pointer was to a struct
-		if reflect.Indirect(vals).Kind() != reflect.Struct {
-			return nil, ErrUnexpectedType
-		}
-		return marshalOne(models)
-	default:
-		return nil, ErrUnexpectedType
-	}
-}
-
-
-func MarshalPayloadWithoutIncluded(w io.Writer, model interface{}) error {
-	payload, err := Marshal(model)
-	if err != nil {
-		return err
-	}
-	payload.clearIncluded()
-
-	return json.NewEncoder(w).Encode(payload)
-}
-
-
-func marshalOne(model interface{}) (*OnePayload, error) {
-	included := make(map[string]*Node)
-
-	rootNode, err := visitModelNode(model, &included, true)
-	if err != nil {
-		return nil, err
-	}
-	payload := &OnePayload{Data: rootNode}
-
-	payload.Included = nodeMapValues(&included)
-
-	return payload, nil
-}
-
-
-func marshalMany(models []interface{}) (*ManyPayload, error) {
-	payload := &ManyPayload{
-		Data: []*Node{},
-	}
-	included := map[string]*Node{}
-
-	for _, model := range models {
-		node, err := visitModelNode(model, &included, true)
-		if err != nil {
-			return nil, err
-		}
-		payload.Data = append(payload.Data, node)
-	}
-	payload.Included = nodeMapValues(&included)
-
-	return payload, nil
-}
-
-func MarshalOnePayloadEmbedded(w io.Writer, model interface{}) error {
-	rootNode, err := visitModelNode(model, nil, false)
-	if err != nil {
-		return err
-	}
-
-	payload := &OnePayload{Data: rootNode}
-
-	return json.NewEncoder(w).Encode(payload)
-}
-
-func visitModelNode(model interface{}, included *map[string]*Node,
-	sideload bool) (*Node, error) {
-	node := new(Node)
-
-	var er error
-	value := reflect.ValueOf(model)
-	if value.IsNil() {
-		return nil, nil
-	}
-
-	modelValue := value.Elem()
-	modelType := value.Type().Elem()
-
-	for i := 0; i < modelValue.NumField(); i++ {
-		structField := modelValue.Type().Field(i)
-		tag := structField.Tag.Get(annotationJSONAPI)
-		if tag == "" {
-			continue
-		}
-
-		fieldValue := modelValue.Field(i)
-		fieldType := modelType.Field(i)
-
-		args := strings.Split(tag, annotationSeperator)
-
-		if len(args) < 1 {
-			er = ErrBadJSONAPIStructTag
-			break
-		}
-
-		annotation := args[0]
-
-		
\ No newline at end of file
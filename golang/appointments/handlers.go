@@ -0,0 +1,247 @@
+package appointments
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	jsonapi "test3"
+	"test3/render"
+)
+
+// includePaths are the relationship paths clients may include.
+var includePaths = map[string]bool{
+	"customer":      true,
+	"slot":          true,
+	"slot.provider": true,
+}
+
+// Handler serves the appointments API:
+//
+//	GET   /appointments       list appointments
+//	POST  /appointments       book a slot
+//	GET   /appointments/{id}  fetch an appointment
+//	PATCH /appointments/{id}  reschedule to another slot, or cancel by
+//	                          setting the status to "cancelled"
+//
+// GET requests accept the include and fields[TYPE] query parameters.
+type Handler struct {
+	Store *Store
+}
+
+// NewHandler returns a Handler serving store.
+func NewHandler(store *Store) *Handler {
+	return &Handler{Store: store}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	if parts[0] != "appointments" || len(parts) > 2 {
+		writeError(w, http.StatusNotFound, "", nil)
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		h.list(w, r)
+	case len(parts) == 1 && r.Method == http.MethodPost:
+		h.create(w, r)
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		h.get(w, r, parts[1])
+	case len(parts) == 2 && r.Method == http.MethodPatch:
+		h.update(w, r, parts[1])
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "", nil)
+	}
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	q, ok := parseQuery(w, r)
+	if !ok {
+		return
+	}
+	writeDocument(w, http.StatusOK, h.Store.List(), q)
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request, id string) {
+	q, ok := parseQuery(w, r)
+	if !ok {
+		return
+	}
+
+	a, err := h.Store.Get(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeDocument(w, http.StatusOK, a, q)
+}
+
+func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
+	in, ok := decode(w, r)
+	if !ok {
+		return
+	}
+	if in.ID != "" {
+		writeError(w, http.StatusForbidden, "Client-generated IDs are not supported",
+			&jsonapi.ErrorSource{Pointer: "/data/id"})
+		return
+	}
+	if in.Customer == nil {
+		writeError(w, http.StatusUnprocessableEntity, "An appointment needs a customer",
+			&jsonapi.ErrorSource{Pointer: "/data/relationships/customer"})
+		return
+	}
+	if in.Slot == nil {
+		writeError(w, http.StatusUnprocessableEntity, "An appointment needs a slot",
+			&jsonapi.ErrorSource{Pointer: "/data/relationships/slot"})
+		return
+	}
+
+	a, err := h.Store.Book(in.Customer.ID, in.Slot.ID, in.Notes)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/appointments/"+a.ID)
+	writeDocument(w, http.StatusCreated, a, nil)
+}
+
+func (h *Handler) update(w http.ResponseWriter, r *http.Request, id string) {
+	in, ok := decode(w, r)
+	if !ok {
+		return
+	}
+	if in.ID != id {
+		writeError(w, http.StatusConflict, "The resource id does not match the URL",
+			&jsonapi.ErrorSource{Pointer: "/data/id"})
+		return
+	}
+
+	var a *Appointment
+	var err error
+	switch {
+	case in.Status == StatusCancelled:
+		a, err = h.Store.Cancel(id)
+	case in.Status != "" && in.Status != StatusBooked:
+		writeError(w, http.StatusUnprocessableEntity,
+			fmt.Sprintf("The status can only be changed to %q", StatusCancelled),
+			&jsonapi.ErrorSource{Pointer: "/data/attributes/status"})
+		return
+	case in.Slot == nil:
+		writeError(w, http.StatusUnprocessableEntity, "Rescheduling needs a new slot",
+			&jsonapi.ErrorSource{Pointer: "/data/relationships/slot"})
+		return
+	default:
+		a, err = h.Store.Reschedule(id, in.Slot.ID)
+	}
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeDocument(w, http.StatusOK, a, nil)
+}
+
+// parseQuery parses the query parameters of r, writing an errors document
+// and returning false if they are invalid.
+func parseQuery(w http.ResponseWriter, r *http.Request) (*jsonapi.Query, bool) {
+	q, err := jsonapi.ParseQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error(), nil)
+		return nil, false
+	}
+
+	for _, path := range q.Include {
+		if !includePaths[path] {
+			writeError(w, http.StatusBadRequest,
+				fmt.Sprintf("Cannot include %q", path),
+				&jsonapi.ErrorSource{Parameter: "include"})
+			return nil, false
+		}
+	}
+
+	return q, true
+}
+
+// decode reads the appointment document in the body of r, writing an
+// errors document and returning false if it cannot.
+func decode(w http.ResponseWriter, r *http.Request) (*Appointment, bool) {
+	if mt, params, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil ||
+		mt != jsonapi.MediaType || len(params) > 0 {
+		writeError(w, http.StatusUnsupportedMediaType, "", nil)
+		return nil, false
+	}
+
+	in := new(Appointment)
+	if err := jsonapi.UnmarshalPayload(r.Body, in, jsonapi.DisallowUnknownFields()); err != nil {
+		var source *jsonapi.ErrorSource
+		if unknown, ok := err.(*jsonapi.UnknownFieldsError); ok {
+			switch {
+			case len(unknown.Attributes) > 0:
+				source = &jsonapi.ErrorSource{Pointer: "/data/attributes/" + unknown.Attributes[0]}
+			case len(unknown.Relationships) > 0:
+				source = &jsonapi.ErrorSource{Pointer: "/data/relationships/" + unknown.Relationships[0]}
+			}
+		}
+		writeError(w, http.StatusBadRequest, err.Error(), source)
+		return nil, false
+	}
+
+	return in, true
+}
+
+// writeDocument writes models as a document, honouring the include and
+// fields parameters of q, which may be nil.
+func writeDocument(w http.ResponseWriter, status int, models interface{}, q *jsonapi.Query) {
+	payload, err := jsonapi.Marshal(models, q.MarshalOptions()...)
+	if err != nil {
+		_ = render.Write(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if q != nil && len(q.Fields) > 0 {
+		switch p := payload.(type) {
+		case *jsonapi.OnePayload:
+			p.FilterFields(q.Fields)
+		case *jsonapi.ManyPayload:
+			p.FilterFields(q.Fields)
+		}
+	}
+
+	w.Header().Set("Content-Type", jsonapi.MediaType)
+	w.WriteHeader(status)
+	_ = jsonapi.EncodePayload(w, payload, q.MarshalOptions()...)
+}
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch err {
+	case ErrNotFound:
+		writeError(w, http.StatusNotFound, "", nil)
+	case ErrNoCustomer:
+		writeError(w, http.StatusNotFound, "The customer does not exist",
+			&jsonapi.ErrorSource{Pointer: "/data/relationships/customer"})
+	case ErrNoSlot:
+		writeError(w, http.StatusNotFound, "The slot does not exist",
+			&jsonapi.ErrorSource{Pointer: "/data/relationships/slot"})
+	case ErrSlotTaken:
+		writeError(w, http.StatusConflict, "The slot is already booked",
+			&jsonapi.ErrorSource{Pointer: "/data/relationships/slot"})
+	case ErrCancelled:
+		writeError(w, http.StatusConflict, "The appointment is cancelled", nil)
+	default:
+		_ = render.Write(w, http.StatusInternalServerError, err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, detail string, source *jsonapi.ErrorSource) {
+	_ = render.Write(w, status, &jsonapi.ErrorObject{
+		Title:  http.StatusText(status),
+		Detail: detail,
+		Status: strconv.Itoa(status),
+		Source: source,
+	})
+}
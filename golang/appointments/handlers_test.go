@@ -0,0 +1,119 @@
+package appointments
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	jsonapi "test3"
+)
+
+// serve sends a request with body, if any, to a Handler of s and returns
+// the response status and decoded document.
+func serve(t *testing.T, s *Store, method, target, body string) (int, map[string]interface{}) {
+	t.Helper()
+	var r *http.Request
+	if body == "" {
+		r = httptest.NewRequest(method, target, nil)
+	} else {
+		r = httptest.NewRequest(method, target, strings.NewReader(body))
+		r.Header.Set("Content-Type", jsonapi.MediaType)
+	}
+	w := httptest.NewRecorder()
+	NewHandler(s).ServeHTTP(w, r)
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("%s %s: %v in %q", method, target, err, w.Body.String())
+	}
+	return w.Code, doc
+}
+
+func TestHandlerBookAndGet(t *testing.T) {
+	s := newTestStore()
+
+	code, doc := serve(t, s, http.MethodPost, "/appointments", `{"data": {"type": "appointments",
+		"attributes": {"notes": "hi"},
+		"relationships": {
+			"customer": {"data": {"type": "users", "id": "c"}},
+			"slot": {"data": {"type": "slots", "id": "s1"}}}}}`)
+	if code != http.StatusCreated {
+		t.Fatalf("create status %d: %v", code, doc)
+	}
+	id := doc["data"].(map[string]interface{})["id"].(string)
+
+	code, doc = serve(t, s, http.MethodGet, "/appointments/"+id+"?include=slot.provider", "")
+	if code != http.StatusOK {
+		t.Fatalf("get status %d: %v", code, doc)
+	}
+	if included, _ := doc["included"].([]interface{}); len(included) != 2 {
+		t.Errorf("included %v, want the slot and its provider", included)
+	}
+
+	code, doc = serve(t, s, http.MethodGet, "/appointments?include=customer.email", "")
+	if code != http.StatusBadRequest {
+		t.Errorf("bad include status %d: %v", code, doc)
+	}
+}
+
+func TestHandlerUpdate(t *testing.T) {
+	s := newTestStore()
+	a, _ := s.Book("c", "s1", "")
+
+	update := func(attributes, relationships string) string {
+		return `{"data": {"type": "appointments", "id": "` + a.ID + `",
+			"attributes": {` + attributes + `}, "relationships": {` + relationships + `}}}`
+	}
+	tests := []struct {
+		name, body string
+		status     int
+		slot       string
+		state      string
+	}{
+		{"reschedule", update(``, `"slot": {"data": {"type": "slots", "id": "s2"}}`), http.StatusOK, "s2", StatusBooked},
+		{"no slot", update(``, ``), http.StatusUnprocessableEntity, "", ""},
+		{"bad status", update(`"status": "done"`, ``), http.StatusUnprocessableEntity, "", ""},
+		{"cancel", update(`"status": "cancelled"`, ``), http.StatusOK, "s2", StatusCancelled},
+		{"reschedule cancelled", update(``, `"slot": {"data": {"type": "slots", "id": "s3"}}`), http.StatusConflict, "", ""},
+	}
+	for _, tt := range tests {
+		code, doc := serve(t, s, http.MethodPatch, "/appointments/"+a.ID, tt.body)
+		if code != tt.status {
+			t.Errorf("%s: status %d, want %d: %v", tt.name, code, tt.status, doc)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		data := doc["data"].(map[string]interface{})
+		status := data["attributes"].(map[string]interface{})["status"]
+		slot := data["relationships"].(map[string]interface{})["slot"].(map[string]interface{})
+		if status != tt.state || slot["data"].(map[string]interface{})["id"] != tt.slot {
+			t.Errorf("%s: status %v, slot %v", tt.name, status, slot)
+		}
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	s := newTestStore()
+	tests := []struct {
+		method, target string
+		status         int
+	}{
+		{http.MethodGet, "/users", http.StatusNotFound},
+		{http.MethodGet, "/appointments/x", http.StatusNotFound},
+		{http.MethodDelete, "/appointments/x", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/appointments", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		code, doc := serve(t, s, tt.method, tt.target, "")
+		if code != tt.status {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.target, code, tt.status)
+		}
+		if errs, _ := doc["errors"].([]interface{}); len(errs) != 1 {
+			t.Errorf("%s %s: document %v, want one error", tt.method, tt.target, doc)
+		}
+	}
+}
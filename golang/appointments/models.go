@@ -0,0 +1,45 @@
+// Package appointments is a small booking API built on the jsonapi
+// serializer. Customers book an Appointment for a free Slot offered by a
+// provider, list their appointments, move them to another slot and cancel
+// them.
+//
+// The handlers exercise the serializer end to end: include and
+// fields[TYPE] query parameters, request documents with relationship
+// linkage, and errors documents with source pointers.
+//
+//	store := appointments.NewStore()
+//	http.Handle("/", appointments.NewHandler(store))
+package appointments
+
+import "time"
+
+// Appointment statuses.
+const (
+	StatusBooked    = "booked"
+	StatusCancelled = "cancelled"
+)
+
+// User is a customer or a provider.
+type User struct {
+	ID    string `jsonapi:"primary,users"`
+	Name  string `jsonapi:"attr,name"`
+	Email string `jsonapi:"attr,email,omitempty"`
+}
+
+// Slot is a period of time a provider can be booked for.
+type Slot struct {
+	ID       string    `jsonapi:"primary,slots"`
+	Start    time.Time `jsonapi:"attr,start,iso8601"`
+	End      time.Time `jsonapi:"attr,end,iso8601"`
+	Provider *User     `jsonapi:"relation,provider"`
+}
+
+// Appointment is a booking of Slot by Customer.
+type Appointment struct {
+	ID        string    `jsonapi:"primary,appointments"`
+	Status    string    `jsonapi:"attr,status"`
+	Notes     string    `jsonapi:"attr,notes,omitempty"`
+	CreatedAt time.Time `jsonapi:"attr,created-at,iso8601"`
+	Customer  *User     `jsonapi:"relation,customer"`
+	Slot      *Slot     `jsonapi:"relation,slot"`
+}
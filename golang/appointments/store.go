@@ -0,0 +1,203 @@
+package appointments
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Errors returned by Store.
+var (
+	ErrNotFound   = errors.New("appointments: not found")
+	ErrSlotTaken  = errors.New("appointments: slot is already booked")
+	ErrNoCustomer = errors.New("appointments: unknown customer")
+	ErrNoSlot     = errors.New("appointments: unknown slot")
+	ErrCancelled  = errors.New("appointments: appointment is cancelled")
+)
+
+// Store keeps users, slots and appointments in memory. It is safe for
+// concurrent use: the appointments it returns are copies, which callers may
+// read and modify freely.
+type Store struct {
+	mu           sync.Mutex
+	users        map[string]*User
+	slots        map[string]*Slot
+	appointments map[string]*Appointment
+	bookings     map[string]string // slot ID -> appointment ID
+	nextID       int
+	now          func() time.Time
+}
+
+// NewStore returns an empty Store.
+func NewStore() *Store {
+	return &Store{
+		users:        map[string]*User{},
+		slots:        map[string]*Slot{},
+		appointments: map[string]*Appointment{},
+		bookings:     map[string]string{},
+		now:          time.Now,
+	}
+}
+
+// AddUser stores a copy of u, assigning an ID if it has none.
+func (s *Store) AddUser(u *User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u.ID == "" {
+		u.ID = s.newID()
+	}
+	stored := *u
+	s.users[u.ID] = &stored
+}
+
+// AddSlot stores a copy of slot, assigning an ID if it has none. The provider must
+// have been added with AddUser.
+func (s *Store) AddSlot(slot *Slot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slot.ID == "" {
+		slot.ID = s.newID()
+	}
+	s.slots[slot.ID] = slot.clone()
+}
+
+// Book creates an appointment of customerID for slotID.
+func (s *Store) Book(customerID, slotID, notes string) (*Appointment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	customer, ok := s.users[customerID]
+	if !ok {
+		return nil, ErrNoCustomer
+	}
+	slot, err := s.freeSlot(slotID)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Appointment{
+		ID:        s.newID(),
+		Status:    StatusBooked,
+		Notes:     notes,
+		CreatedAt: s.now().UTC(),
+		Customer:  customer,
+		Slot:      slot,
+	}
+	s.appointments[a.ID] = a
+	s.bookings[slot.ID] = a.ID
+	return a.clone(), nil
+}
+
+// Reschedule moves the appointment id to slotID, releasing its old slot.
+func (s *Store) Reschedule(id, slotID string) (*Appointment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.appointments[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if a.Status == StatusCancelled {
+		return nil, ErrCancelled
+	}
+	if a.Slot.ID == slotID {
+		return a.clone(), nil
+	}
+	slot, err := s.freeSlot(slotID)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(s.bookings, a.Slot.ID)
+	a.Slot = slot
+	s.bookings[slot.ID] = a.ID
+	return a.clone(), nil
+}
+
+// Cancel cancels the appointment id, releasing its slot. Cancelling a
+// cancelled appointment has no effect.
+func (s *Store) Cancel(id string) (*Appointment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.appointments[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if a.Status != StatusCancelled {
+		a.Status = StatusCancelled
+		delete(s.bookings, a.Slot.ID)
+	}
+	return a.clone(), nil
+}
+
+// Get returns the appointment id.
+func (s *Store) Get(id string) (*Appointment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.appointments[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return a.clone(), nil
+}
+
+// List returns all appointments ordered by slot start time.
+func (s *Store) List() []*Appointment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]*Appointment, 0, len(s.appointments))
+	for _, a := range s.appointments {
+		list = append(list, a.clone())
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].Slot.Start.Equal(list[j].Slot.Start) {
+			return list[i].Slot.Start.Before(list[j].Slot.Start)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+func (s *Store) freeSlot(id string) (*Slot, error) {
+	slot, ok := s.slots[id]
+	if !ok {
+		return nil, ErrNoSlot
+	}
+	if _, taken := s.bookings[id]; taken {
+		return nil, ErrSlotTaken
+	}
+	return slot, nil
+}
+
+func (s *Store) newID() string {
+	s.nextID++
+	return strconv.Itoa(s.nextID)
+}
+
+// clone returns a deep copy of a, so that it can be handed out while the
+// store keeps changing a under its lock.
+func (a *Appointment) clone() *Appointment {
+	c := *a
+	if a.Customer != nil {
+		customer := *a.Customer
+		c.Customer = &customer
+	}
+	if a.Slot != nil {
+		c.Slot = a.Slot.clone()
+	}
+	return &c
+}
+
+func (slot *Slot) clone() *Slot {
+	c := *slot
+	if slot.Provider != nil {
+		provider := *slot.Provider
+		c.Provider = &provider
+	}
+	return &c
+}
@@ -0,0 +1,158 @@
+package appointments
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestStore returns a store with a customer "c", a provider "p" and
+// slots "s1" to "s3", an hour apart.
+func newTestStore() *Store {
+	s := NewStore()
+	s.now = func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+	s.AddUser(&User{ID: "c", Name: "Customer"})
+	provider := &User{ID: "p", Name: "Provider"}
+	s.AddUser(provider)
+
+	start := time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)
+	for i, id := range []string{"s1", "s2", "s3"} {
+		at := start.Add(time.Duration(i) * time.Hour)
+		s.AddSlot(&Slot{ID: id, Start: at, End: at.Add(time.Hour), Provider: provider})
+	}
+	return s
+}
+
+func TestBookAndReschedule(t *testing.T) {
+	s := newTestStore()
+
+	a, err := s.Book("c", "s2", "first visit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Status != StatusBooked || a.Slot.ID != "s2" || a.Customer.ID != "c" {
+		t.Errorf("booked %+v", a)
+	}
+	if _, err := s.Book("c", "s2", ""); err != ErrSlotTaken {
+		t.Errorf("double booking error %v, want ErrSlotTaken", err)
+	}
+
+	if a, err = s.Reschedule(a.ID, "s1"); err != nil || a.Slot.ID != "s1" {
+		t.Fatalf("rescheduled %+v, error %v", a, err)
+	}
+	// The old slot is free again.
+	if _, err := s.Book("c", "s2", ""); err != nil {
+		t.Errorf("booking the released slot: %v", err)
+	}
+
+	list := s.List()
+	if len(list) != 2 || list[0].Slot.ID != "s1" || list[1].Slot.ID != "s2" {
+		t.Errorf("list not ordered by start: %+v", list)
+	}
+}
+
+func TestStoreErrors(t *testing.T) {
+	s := newTestStore()
+	a, _ := s.Book("c", "s1", "")
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"unknown customer", bookErr(s, "x", "s2"), ErrNoCustomer},
+		{"unknown slot", bookErr(s, "c", "x"), ErrNoSlot},
+		{"get unknown", getErr(s.Get("x")), ErrNotFound},
+		{"reschedule unknown", getErr(s.Reschedule("x", "s2")), ErrNotFound},
+		{"reschedule to its own slot", getErr(s.Reschedule(a.ID, "s1")), nil},
+		{"cancel unknown", getErr(s.Cancel("x")), ErrNotFound},
+	}
+	for _, tt := range tests {
+		if tt.err != tt.want {
+			t.Errorf("%s: error %v, want %v", tt.name, tt.err, tt.want)
+		}
+	}
+}
+
+func bookErr(s *Store, customerID, slotID string) error {
+	_, err := s.Book(customerID, slotID, "")
+	return err
+}
+
+func getErr(_ *Appointment, err error) error {
+	return err
+}
+
+func TestCancel(t *testing.T) {
+	s := newTestStore()
+	a, _ := s.Book("c", "s1", "")
+
+	for i := 0; i < 2; i++ {
+		c, err := s.Cancel(a.ID)
+		if err != nil || c.Status != StatusCancelled {
+			t.Fatalf("cancelled %+v, error %v", c, err)
+		}
+	}
+	if _, err := s.Reschedule(a.ID, "s2"); err != ErrCancelled {
+		t.Errorf("rescheduling a cancelled appointment: error %v, want ErrCancelled", err)
+	}
+	if _, err := s.Book("c", "s1", ""); err != nil {
+		t.Errorf("booking the slot of a cancelled appointment: %v", err)
+	}
+}
+
+func TestStoreReturnsCopies(t *testing.T) {
+	s := newTestStore()
+	a, _ := s.Book("c", "s1", "")
+
+	a.Slot.ID = "changed"
+	a.Customer.Name = "changed"
+	got, _ := s.Get(a.ID)
+	got.Status = "changed"
+
+	got, _ = s.Get(a.ID)
+	if got.Slot.ID != "s1" || got.Customer.Name != "Customer" || got.Status != StatusBooked {
+		t.Errorf("stored appointment changed through a returned copy: %+v", got)
+	}
+}
+
+// TestConcurrentAccess is meant for go test -race: readers use the
+// appointments they get while another goroutine reschedules them.
+func TestConcurrentAccess(t *testing.T) {
+	s := newTestStore()
+	a, _ := s.Book("c", "s1", "")
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			slot := []string{"s2", "s3"}[i%2]
+			if _, err := s.Reschedule(a.ID, slot); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			if got, err := s.Get(a.ID); err != nil || got.Slot.ID == "" {
+				t.Errorf("get %+v, error %v", got, err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			for _, got := range s.List() {
+				if got.Slot.Start.IsZero() {
+					t.Errorf("listed %+v", got)
+					return
+				}
+			}
+		}
+	}()
+	wg.Wait()
+}
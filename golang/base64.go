@@ -0,0 +1,80 @@
+package jsonapi
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+)
+
+// Byte slices and arrays, such as []byte and [32]byte, and pointers to
+// them are written as base64 strings: padded with the standard alphabet,
+// as encoding/json writes []byte, or unpadded with the URL-safe alphabet
+// for attributes tagged with the base64url option, e.g.
+// `jsonapi:"attr,signature,base64url"`. Either padding is accepted on
+// unmarshal.
+
+// isBytesType reports whether t, or the element type of the pointer t, is
+// a slice or array of bytes. Types with their own text or JSON form, such
+// as net.IP, are not.
+func isBytesType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Implements(textMarshalerType) || t.Implements(jsonMarshalerType) {
+		return false
+	}
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem() == reflect.TypeOf(byte(0))
+}
+
+// base64Encoding returns the encoding used for an attribute with the tag
+// args: the unpadded form of the one it is written with.
+func base64Encoding(args []string) *base64.Encoding {
+	if hasOption(args, annotationBase64URL) {
+		return base64.RawURLEncoding
+	}
+	return base64.RawStdEncoding
+}
+
+// marshalBytes returns the attribute value of v, whose type satisfies
+// isBytesType: a base64 string, or nil for a nil pointer or slice.
+func marshalBytes(v reflect.Value, args []string) interface{} {
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Slice {
+		if v.IsNil() {
+			return nil
+		}
+		v = reflect.Indirect(v)
+	}
+
+	b := make([]byte, v.Len())
+	reflect.Copy(reflect.ValueOf(b), v)
+
+	if hasOption(args, annotationBase64URL) {
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// unmarshalBytes decodes the base64 attribute value of a field of type t,
+// not a pointer, into a pointer to a value of t. Arrays take exactly as
+// many bytes as they hold.
+func unmarshalBytes(attribute interface{}, t reflect.Type, args []string) (reflect.Value, error) {
+	s, ok := attribute.(string)
+	if !ok {
+		return reflect.Value{}, ErrInvalidType
+	}
+	b, err := base64Encoding(args).DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return reflect.Value{}, ErrInvalidType
+	}
+
+	ptr := reflect.New(t)
+	if t.Kind() == reflect.Array {
+		if len(b) != t.Len() {
+			return reflect.Value{}, ErrInvalidType
+		}
+		reflect.Copy(ptr.Elem(), reflect.ValueOf(b))
+		return ptr, nil
+	}
+	ptr.Elem().Set(reflect.ValueOf(b).Convert(t))
+	return ptr, nil
+}
@@ -0,0 +1,107 @@
+package jsonapi
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type base64Digest []byte
+
+type base64File struct {
+	ID        string       `jsonapi:"primary,files"`
+	Content   []byte       `jsonapi:"attr,content"`
+	Signature []byte       `jsonapi:"attr,signature,base64url"`
+	Sum       [4]byte      `jsonapi:"attr,sum"`
+	Thumb     *[]byte      `jsonapi:"attr,thumb"`
+	Digest    base64Digest `jsonapi:"attr,digest"`
+}
+
+func TestIsBytesType(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want bool
+	}{
+		{[]byte(nil), true},
+		{[2]byte{}, true},
+		{new([]byte), true},
+		{base64Digest(nil), true},
+		{net.IP(nil), false},
+		{[]int8(nil), false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isBytesType(reflect.TypeOf(tt.v)); got != tt.want {
+			t.Errorf("isBytesType(%T) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestMarshalByteAttributes(t *testing.T) {
+	in := &base64File{
+		ID:        "1",
+		Content:   []byte("hi?"),
+		Signature: []byte{0xfb, 0xff},
+		Sum:       [4]byte{1, 2, 3, 4},
+		Digest:    base64Digest("a"),
+	}
+	attrs := marshalDoc(t, in)["data"].(map[string]interface{})["attributes"]
+	want := map[string]interface{}{
+		"content":   "aGk/",
+		"signature": "-_8",
+		"sum":       "AQIDBA==",
+		"thumb":     nil,
+		"digest":    "YQ==",
+	}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("attributes %v, want %v", attrs, want)
+	}
+}
+
+func TestUnmarshalByteAttributes(t *testing.T) {
+	doc := `{"data": {"type": "files", "id": "1", "attributes": {
+		"content": "aGk/", "signature": "-_8=", "sum": "AQIDBA", "thumb": "YQ==", "digest": "YQ"}}}`
+	out := new(base64File)
+	if err := UnmarshalPayload(strings.NewReader(doc), out); err != nil {
+		t.Fatal(err)
+	}
+	want := &base64File{
+		ID:        "1",
+		Content:   []byte("hi?"),
+		Signature: []byte{0xfb, 0xff},
+		Sum:       [4]byte{1, 2, 3, 4},
+		Thumb:     &[]byte{'a'},
+		Digest:    base64Digest("a"),
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("unmarshaled %+v, want %+v", out, want)
+	}
+
+	for _, attrs := range []string{
+		`{"content": 1}`,
+		`{"content": "-_8"}`,
+		`{"signature": "+/8"}`,
+		`{"sum": "AQID"}`,
+	} {
+		doc := `{"data": {"type": "files", "id": "1", "attributes": ` + attrs + `}}`
+		if err := UnmarshalPayload(strings.NewReader(doc), new(base64File)); !errors.Is(err, ErrInvalidType) {
+			t.Errorf("%s: error %v, want ErrInvalidType", attrs, err)
+		}
+	}
+}
+
+func TestBase64URLCheckModel(t *testing.T) {
+	type bad struct {
+		ID   string `jsonapi:"primary,bad"`
+		Name string `jsonapi:"attr,name,base64url"`
+	}
+	if errs := CheckModel(&base64File{}); errs != nil {
+		t.Errorf("CheckModel(base64File) = %v", errs)
+	}
+	errs := CheckModel(&bad{})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "base64url option used on non-byte field") {
+		t.Errorf("CheckModel(bad) = %v", errs)
+	}
+}
@@ -0,0 +1,100 @@
+package jsonapi
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"sync"
+	"testing"
+
+	"test3/internal/benchmodels"
+)
+
+// The benchmarks mirror those of cmd/jsonapi-bench, on the same models from
+// internal/benchmodels, so that the usual go test -bench . -count 10 |
+// benchstat workflow works as well.
+
+// benchDoc builds a model and its document on first use; the framework
+// calls each benchmark function several times while sizing b.N.
+type benchDoc struct {
+	once  sync.Once
+	model interface{}
+	doc   []byte
+}
+
+func (d *benchDoc) get(b *testing.B, build func() interface{}) (interface{}, []byte) {
+	d.once.Do(func() {
+		d.model = build()
+		var buf bytes.Buffer
+		if err := MarshalPayload(&buf, d.model); err != nil {
+			b.Fatal(err)
+		}
+		d.doc = buf.Bytes()
+	})
+	b.ReportAllocs()
+	b.ResetTimer()
+	return d.model, d.doc
+}
+
+func benchMarshal(b *testing.B, d *benchDoc, build func() interface{}) {
+	model, _ := d.get(b, build)
+	for i := 0; i < b.N; i++ {
+		if err := MarshalPayload(ioutil.Discard, model); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchUnmarshal(b *testing.B, d *benchDoc, build func() interface{}, newModel func() interface{}) {
+	_, doc := d.get(b, build)
+	for i := 0; i < b.N; i++ {
+		if err := UnmarshalPayload(bytes.NewReader(doc), newModel()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+var benchSmall, benchSlice10k, benchDeep, benchWide benchDoc
+
+func newBenchPost() interface{}  { return benchmodels.NewPost(0) }
+func newBenchPosts() interface{} { return benchmodels.NewPosts(10000) }
+func newBenchChain() interface{} { return benchmodels.NewChain(20) }
+
+func BenchmarkMarshalSmall(b *testing.B) {
+	benchMarshal(b, &benchSmall, newBenchPost)
+}
+
+func BenchmarkUnmarshalSmall(b *testing.B) {
+	benchUnmarshal(b, &benchSmall, newBenchPost, func() interface{} { return new(benchmodels.Post) })
+}
+
+func BenchmarkMarshalSlice10k(b *testing.B) {
+	benchMarshal(b, &benchSlice10k, newBenchPosts)
+}
+
+func BenchmarkUnmarshalSlice10k(b *testing.B) {
+	_, doc := benchSlice10k.get(b, newBenchPosts)
+	t := reflect.TypeOf(new(benchmodels.Post))
+	for i := 0; i < b.N; i++ {
+		if _, err := UnmarshalManyPayload(bytes.NewReader(doc), t); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalDeepRelationships(b *testing.B) {
+	benchMarshal(b, &benchDeep, newBenchChain)
+}
+
+func BenchmarkUnmarshalDeepRelationships(b *testing.B) {
+	benchUnmarshal(b, &benchDeep, newBenchChain, func() interface{} { return new(benchmodels.Link) })
+}
+
+func BenchmarkMarshalWideAttributes(b *testing.B) {
+	benchMarshal(b, &benchWide, benchmodels.NewWide)
+}
+
+func BenchmarkUnmarshalWideAttributes(b *testing.B) {
+	benchUnmarshal(b, &benchWide, benchmodels.NewWide,
+		func() interface{} { return reflect.New(benchmodels.WideType()).Interface() })
+}
@@ -0,0 +1,167 @@
+package jsonapi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// ErrBinaryFormat is returned when a MessagePack or CBOR document is
+// malformed or uses a feature the JSON data model has no equivalent for,
+// such as a map with non-string keys.
+var ErrBinaryFormat = errors.New("jsonapi: malformed binary document")
+
+// Format is a binary encoding of the JSON data model, used by
+// MarshalPayloadAs and UnmarshalPayloadAs to exchange documents with the
+// same structure as JSON:API ones in less space. MsgPack and CBOR are
+// provided.
+//
+// Values passed to EncodeValue and returned by DecodeValue are nil, bool,
+// string, int64, uint64, float64, []interface{} and map[string]interface{}.
+type Format interface {
+	// MediaType is the Content-Type of documents in this format.
+	MediaType() string
+	EncodeValue(w io.Writer, v interface{}) error
+	DecodeValue(r io.Reader) (interface{}, error)
+}
+
+// maxBinaryNesting bounds the depth of arrays and maps read by DecodeValue.
+const maxBinaryNesting = 1000
+
+// MarshalPayloadAs is like MarshalPayload but writes the document in the
+// binary format f.
+func MarshalPayloadAs(w io.Writer, models interface{}, f Format, opts ...MarshalOption) error {
+	b, err := MarshalBytes(models, opts...)
+	if err != nil {
+		return err
+	}
+	v, err := genericValue(b)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	if err := f.EncodeValue(bw, v); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// UnmarshalPayloadAs is like UnmarshalPayload for a document in the binary
+// format f. MaxBodyBytes bounds the binary document, not its JSON form.
+func UnmarshalPayloadAs(in io.Reader, model interface{}, f Format, opts ...UnmarshalOption) error {
+	b, err := decodeBinary(in, f, opts)
+	if err != nil {
+		return err
+	}
+	return UnmarshalPayload(bytes.NewReader(b), model, jsonOptions(opts)...)
+}
+
+// UnmarshalManyPayloadAs is like UnmarshalManyPayload for a document in the
+// binary format f.
+func UnmarshalManyPayloadAs(in io.Reader, t reflect.Type, f Format,
+	opts ...UnmarshalOption) ([]interface{}, error) {
+	b, err := decodeBinary(in, f, opts)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalManyPayload(bytes.NewReader(b), t, jsonOptions(opts)...)
+}
+
+// decodeBinary reads a document in the format f, bounded by MaxBodyBytes,
+// and returns it as JSON.
+func decodeBinary(in io.Reader, f Format, opts []UnmarshalOption) ([]byte, error) {
+	body := newUnmarshalOptions(opts).body(in)
+	v, err := f.DecodeValue(bufio.NewReader(body))
+	// Formats report a short read as ErrBinaryFormat, and a document
+	// ending just past the limit may decode before the error is seen.
+	if l, ok := body.(*limitedReader); ok && l.n < 0 {
+		return nil, ErrBodyTooLarge
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// jsonOptions returns opts for unmarshaling the JSON form of a binary
+// document, whose size decodeBinary has already checked against
+// MaxBodyBytes.
+func jsonOptions(opts []UnmarshalOption) []UnmarshalOption {
+	return append(opts[:len(opts):len(opts)], MaxBodyBytes(0))
+}
+
+// genericValue decodes the JSON document b into the values accepted by
+// Format.EncodeValue, keeping integers exact.
+func genericValue(b []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return convertNumbers(v), nil
+}
+
+func convertNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			return n
+		}
+		if n, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i := range v {
+			v[i] = convertNumbers(v[i])
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = convertNumbers(v[k])
+		}
+	}
+	return v
+}
+
+// sortedKeys returns the keys of m in order, so that encoding is
+// deterministic.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// readBytes reads n bytes without trusting n for the allocation, since it
+// comes from the document.
+func readBytes(r io.Reader, n uint64) ([]byte, error) {
+	if n > math.MaxInt32 {
+		return nil, ErrBinaryFormat
+	}
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r, int64(n)); err != nil {
+		return nil, ErrBinaryFormat
+	}
+	return buf.Bytes(), nil
+}
+
+// capHint bounds the capacity preallocated for n elements read from a
+// document.
+func capHint(n uint64) int {
+	if n > 1024 {
+		return 1024
+	}
+	return int(n)
+}
@@ -0,0 +1,132 @@
+package jsonapi
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// binaryValues are the values every Format must round-trip.
+var binaryValues = []interface{}{
+	nil, true, false,
+	int64(0), int64(23), int64(24), int64(-1), int64(-33), int64(-129), int64(1 << 40), int64(math.MinInt64),
+	uint64(math.MaxUint64), 1.5, -0.25, math.MaxFloat64,
+	"", "a", strings.Repeat("x", 300), strings.Repeat("y", 70000),
+	[]interface{}{}, []interface{}{int64(1), "two", nil, []interface{}{false}},
+	map[string]interface{}{}, map[string]interface{}{"a": int64(1), "b": map[string]interface{}{"c": "d"}},
+	make([]interface{}, 20), map[string]interface{}{"0": nil, "1": nil, "2": nil, "3": nil, "4": nil,
+		"5": nil, "6": nil, "7": nil, "8": nil, "9": nil, "10": nil, "11": nil, "12": nil, "13": nil,
+		"14": nil, "15": nil, "16": nil},
+}
+
+func TestFormatValues(t *testing.T) {
+	for _, f := range []Format{MsgPack, CBOR} {
+		for _, v := range binaryValues {
+			var buf bytes.Buffer
+			if err := f.EncodeValue(&buf, v); err != nil {
+				t.Errorf("%s: encode %v: %v", f.MediaType(), v, err)
+				continue
+			}
+			got, err := f.DecodeValue(&buf)
+			if err != nil {
+				t.Errorf("%s: decode %v: %v", f.MediaType(), v, err)
+				continue
+			}
+			if !reflect.DeepEqual(got, v) {
+				t.Errorf("%s: round trip of %.40v gave %.40v", f.MediaType(), v, got)
+			}
+		}
+
+		if err := f.EncodeValue(new(bytes.Buffer), int(1)); err == nil {
+			t.Errorf("%s: encoded an int, which is not a JSON data model value", f.MediaType())
+		}
+	}
+}
+
+func TestMarshalPayloadAs(t *testing.T) {
+	for _, f := range []Format{MsgPack, CBOR} {
+		in := &reqArticle{ID: "1", Title: "Hello", Views: 3, Score: 4.5, Published: true,
+			Author: &reqAuthor{ID: "9", Name: "Ann"}, Comments: []*reqComment{{ID: 5, Body: "First"}}}
+		var buf bytes.Buffer
+		if err := MarshalPayloadAs(&buf, in, f); err != nil {
+			t.Fatal(err)
+		}
+		if bytes.HasPrefix(buf.Bytes(), []byte("{")) {
+			t.Errorf("%s: document written as JSON", f.MediaType())
+		}
+
+		out := new(reqArticle)
+		if err := UnmarshalPayloadAs(bytes.NewReader(buf.Bytes()), out, f); err != nil {
+			t.Fatal(err)
+		}
+		if out.Title != "Hello" || out.Views != 3 || out.Score != 4.5 || !out.Published ||
+			out.Author == nil || out.Author.Name != "Ann" || len(out.Comments) != 1 || out.Comments[0].Body != "First" {
+			t.Errorf("%s: unmarshaled %+v", f.MediaType(), out)
+		}
+
+		buf.Reset()
+		if err := MarshalPayloadAs(&buf, []*reqAuthor{{ID: "1"}, {ID: "2"}}, f); err != nil {
+			t.Fatal(err)
+		}
+		models, err := UnmarshalManyPayloadAs(&buf, reflect.TypeOf(new(reqAuthor)), f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(models) != 2 || models[1].(*reqAuthor).ID != "2" {
+			t.Errorf("%s: unmarshaled %v", f.MediaType(), models)
+		}
+	}
+}
+
+func TestUnmarshalPayloadAsMaxBodyBytes(t *testing.T) {
+	for _, f := range []Format{MsgPack, CBOR} {
+		var buf bytes.Buffer
+		if err := MarshalPayloadAs(&buf, &reqAuthor{ID: "1", Name: strings.Repeat("a", 100)}, f); err != nil {
+			t.Fatal(err)
+		}
+		doc := buf.Bytes()
+
+		err := UnmarshalPayloadAs(bytes.NewReader(doc), new(reqAuthor), f, MaxBodyBytes(int64(len(doc)-1)))
+		if !errors.Is(err, ErrBodyTooLarge) {
+			t.Errorf("%s: error %v, want ErrBodyTooLarge", f.MediaType(), err)
+		}
+		_, err = UnmarshalManyPayloadAs(bytes.NewReader(doc), reflect.TypeOf(new(reqAuthor)), f, MaxBodyBytes(10))
+		if !errors.Is(err, ErrBodyTooLarge) {
+			t.Errorf("%s: many: error %v, want ErrBodyTooLarge", f.MediaType(), err)
+		}
+
+		// The limit applies to the binary document, not to its larger JSON
+		// form.
+		if err := UnmarshalPayloadAs(bytes.NewReader(doc), new(reqAuthor), f, MaxBodyBytes(int64(len(doc)))); err != nil {
+			t.Errorf("%s: document at the limit: %v", f.MediaType(), err)
+		}
+	}
+}
+
+func TestConvertNumbers(t *testing.T) {
+	v, err := genericValue([]byte(`{"a": [1, -2, 18446744073709551615, 1.5, 99999999999999999999]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"a": []interface{}{
+		int64(1), int64(-2), uint64(math.MaxUint64), 1.5, 1e20,
+	}}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("value %v, want %v", v, want)
+	}
+}
+
+func TestReadBytesLimits(t *testing.T) {
+	if _, err := readBytes(strings.NewReader("abc"), math.MaxInt32+1); err != ErrBinaryFormat {
+		t.Errorf("oversized length: %v", err)
+	}
+	if _, err := readBytes(strings.NewReader("abc"), 4); err != ErrBinaryFormat {
+		t.Errorf("truncated input: %v", err)
+	}
+	if capHint(1<<40) != 1024 || capHint(3) != 3 {
+		t.Error("capHint does not bound the capacity")
+	}
+}
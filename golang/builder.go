@@ -0,0 +1,171 @@
+package jsonapi
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrBuilderPosition is returned by Builder.AddAt for a position beyond
+// the end of the primary data.
+var ErrBuilderPosition = errors.New("jsonapi: builder position out of range")
+
+// Marshal, MarshalPayload and the other marshal functions keep all their
+// state, including the map of included resources, local to a call, so they
+// may be used from many goroutines at once as long as the models are not
+// modified meanwhile. Builder is for the case where a single document is
+// assembled from resources produced by several goroutines.
+
+// Builder assembles a collection document from models added concurrently,
+// e.g. by the goroutines of a fan-out handler. All methods are safe for
+// concurrent use. The zero value is not usable; call NewBuilder.
+type Builder struct {
+	o *marshalOptions
+
+	mu       sync.Mutex
+	data     []*Node
+	included map[string]*Node
+	links    *Links
+	meta     *Meta
+}
+
+// NewBuilder returns an empty Builder. opts apply to every model added, as
+// they would to Marshal.
+func NewBuilder(opts ...MarshalOption) *Builder {
+	return &Builder{
+		o:        newMarshalOptions(opts),
+		included: map[string]*Node{},
+	}
+}
+
+// Add appends model, a pointer to a tagged struct, to the primary data,
+// with its related resources. Data is kept in the order of the Add calls;
+// use Reserve and AddAt when the order must not depend on goroutine
+// scheduling.
+func (b *Builder) Add(model interface{}) error {
+	return b.add(-1, model)
+}
+
+// AddAt places model at position i of the primary data, replacing the
+// model there, if any. i may be at most the number of positions so far,
+// which is counted by Add, AddAt and Reserve; AddAt returns
+// ErrBuilderPosition, without adding model, for any other i.
+func (b *Builder) AddAt(i int, model interface{}) error {
+	if i < 0 {
+		return ErrBuilderPosition
+	}
+	return b.add(i, model)
+}
+
+// Reserve adds n empty positions to the primary data, for goroutines to
+// fill with AddAt in any order. Positions left empty are dropped by
+// Payload.
+func (b *Builder) Reserve(n int) {
+	b.mu.Lock()
+	b.data = append(b.data, make([]*Node, n)...)
+	b.mu.Unlock()
+}
+
+// AddIncluded adds model, with its related resources, to the included
+// section only.
+func (b *Builder) AddIncluded(model interface{}) error {
+	node, included, err := b.visit(model)
+	if err != nil || node == nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	appendIncluded(&b.included, node)
+	appendIncluded(&b.included, included...)
+	return nil
+}
+
+// SetLinks sets the top-level links of the document.
+func (b *Builder) SetLinks(links *Links) error {
+	if links != nil {
+		if err := links.validate(); err != nil {
+			return err
+		}
+	}
+
+	b.mu.Lock()
+	b.links = links
+	b.mu.Unlock()
+	return nil
+}
+
+// SetMeta sets the top-level meta object of the document.
+func (b *Builder) SetMeta(meta *Meta) {
+	b.mu.Lock()
+	b.meta = meta
+	b.mu.Unlock()
+}
+
+// Payload returns the document built so far. Resources that are also
+// primary data are not repeated in included.
+func (b *Builder) Payload() *ManyPayload {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	payload := &ManyPayload{Data: []*Node{}, Links: b.links, Meta: b.meta}
+	primary := map[string]bool{}
+	for _, n := range b.data {
+		if n != nil {
+			payload.Data = append(payload.Data, n)
+			primary[nodeKey(n)] = true
+		}
+	}
+
+	included := make(map[string]*Node, len(b.included))
+	for key, n := range b.included {
+		if !primary[key] {
+			included[key] = n
+		}
+	}
+	payload.Included = orderIncluded(included, b.o, payload.Data...)
+
+	return payload
+}
+
+func (b *Builder) add(i int, model interface{}) error {
+	node, included, err := b.visit(model)
+	if err != nil || node == nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case i < 0 || i == len(b.data):
+		b.data = append(b.data, node)
+	case i < len(b.data):
+		b.data[i] = node
+	default:
+		return ErrBuilderPosition
+	}
+	appendIncluded(&b.included, included...)
+	return nil
+}
+
+// visit marshals model without touching the shared state of b: the
+// traversal has its own marshalState and b.o is only read.
+func (b *Builder) visit(model interface{}) (*Node, []*Node, error) {
+	included := map[string]*Node{}
+	node, err := visitModelNode(model, &included, true, b.o.newState(), "")
+	if err != nil || node == nil {
+		return nil, nil, err
+	}
+	nodes := nodeMapValues(&included)
+
+	if b.o.links != nil {
+		applyLinks(b.o.links, node)
+		applyLinks(b.o.links, nodes...)
+	}
+	if b.o.deriveLinks {
+		deriveRelationshipLinks(node)
+		deriveRelationshipLinks(nodes...)
+	}
+
+	return node, nodes, nil
+}
@@ -0,0 +1,102 @@
+package jsonapi
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	b := NewBuilder(WithIncludedOrder(IncludedSorted))
+	shared := &orderPerson{ID: "9"}
+
+	b.Reserve(2)
+	if err := b.AddAt(1, &orderPost{ID: "4", Author: &orderPerson{ID: "3"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AddAt(0, &orderPost{ID: "2", Author: shared}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AddAt(2, &orderPost{ID: "5"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AddIncluded(&orderComment{ID: "c", Author: shared}); err != nil {
+		t.Fatal(err)
+	}
+	for _, i := range []int{-1, 4} {
+		if err := b.AddAt(i, &orderPost{ID: "x", Author: &orderPerson{ID: "x"}}); err != ErrBuilderPosition {
+			t.Errorf("AddAt(%d) error %v, want ErrBuilderPosition", i, err)
+		}
+	}
+	// An empty position is dropped.
+	b.Reserve(1)
+	b.SetMeta(&Meta{"total": 3})
+
+	p := b.Payload()
+	var ids []string
+	for _, n := range p.Data {
+		ids = append(ids, n.ID)
+	}
+	if !equalStrings(ids, []string{"2", "4", "5"}) {
+		t.Errorf("data %v, want the posts in position order", ids)
+	}
+	var included []string
+	for _, n := range p.Included {
+		included = append(included, n.Type+"/"+n.ID)
+	}
+	if want := []string{"comments/c", "people/3", "people/9"}; !equalStrings(included, want) {
+		t.Errorf("included %v, want %v", included, want)
+	}
+	if p.Meta == nil || (*p.Meta)["total"] != 3 {
+		t.Errorf("meta %v", p.Meta)
+	}
+}
+
+// TestBuilderConcurrentAddAt is meant for go test -race: every AddAt
+// traverses models sharing a related resource while others do the same.
+func TestBuilderConcurrentAddAt(t *testing.T) {
+	b := NewBuilder(
+		WithAttributeOrder(AttributeDeclarationOrder),
+		WithMemberNameWarnings(nil),
+		WithBaseURL("/api"),
+	)
+	shared := &orderPerson{ID: "shared"}
+
+	const n = 50
+	b.Reserve(n)
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			post := &orderPost{
+				ID:       fmt.Sprint(i),
+				Author:   shared,
+				Comments: []*orderComment{{ID: fmt.Sprint("c", i), Author: shared}},
+			}
+			errs <- b.AddAt(i, post)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := b.Payload()
+	if len(p.Data) != n {
+		t.Fatalf("got %d resources, want %d", len(p.Data), n)
+	}
+	for i, node := range p.Data {
+		if node.ID != fmt.Sprint(i) {
+			t.Fatalf("data[%d] is %s", i, node.ID)
+		}
+	}
+	// Every comment, and the shared author once.
+	if len(p.Included) != n+1 {
+		t.Errorf("got %d included resources, want %d", len(p.Included), n+1)
+	}
+}
@@ -0,0 +1,159 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// KeyBulkResultsMeta is the top-level meta member holding the per-item
+// results of a bulk response.
+const KeyBulkResultsMeta = "results"
+
+// BulkFunc stores model, decoded from the resource at index i of the data
+// of a bulk request r, and returns the model to send back, usually model
+// itself once stored.
+type BulkFunc func(r *http.Request, i int, model interface{}) (interface{}, error)
+
+// Bulk is an http.Handler for bulk create or update requests: documents
+// whose primary data is an array of resources. Each resource is decoded
+// and saved on its own, so that a malformed or rejected one does not fail
+// the others, and the response is a 207 Multi-Status document listing one
+// result per resource in its meta:
+//
+//	{"meta": {"results": [
+//	  {"status": 201, "document": {"data": {"type": "appointments", ...}}},
+//	  {"status": 422, "document": {"errors": [{"source": {"pointer": "/data/1/attributes/start"}, ...}]}}
+//	]}}
+//
+// Error pointers locate the resource in the request document. A request
+// that cannot be read as a whole gets a plain error document.
+type Bulk struct {
+	// Type is the model type, a pointer to a tagged struct.
+	Type reflect.Type
+	// Save stores each resource that decoded successfully.
+	Save BulkFunc
+	// Status is the status of stored resources; 201 Created if zero.
+	Status int
+	// UnmarshalOptions apply to each resource, e.g. ValidateAttributes.
+	UnmarshalOptions []UnmarshalOption
+	// MarshalOptions apply to the documents of stored resources.
+	MarshalOptions []MarshalOption
+}
+
+// BulkResult is the outcome of one resource of a bulk request: the stored
+// model, or the error that prevented it from being stored.
+type BulkResult struct {
+	// Status is the status of the stored model; 200 OK if zero. Failed
+	// results get the status of StatusForError instead.
+	Status int
+	Model  interface{}
+	Err    error
+}
+
+func (b *Bulk) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	models, itemErrs, err := UnmarshalManyLenient(r.Body, b.Type, b.UnmarshalOptions...)
+	if err != nil {
+		_ = WriteErrorFor(w, r, err)
+		return
+	}
+
+	status := b.Status
+	if status == 0 {
+		status = http.StatusCreated
+	}
+
+	results := make([]BulkResult, len(models))
+	for _, e := range itemErrs {
+		// Errors of included resources do not concern a single item.
+		if strings.HasPrefix(e.Pointer, "/data/") {
+			results[e.Index].Err = e
+		}
+	}
+	for i, model := range models {
+		if results[i].Err != nil || model == nil {
+			continue
+		}
+		saved, err := b.Save(r, i, model)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i] = BulkResult{Status: status, Model: saved}
+	}
+
+	_ = WriteBulkResults(w, results, b.MarshalOptions...)
+}
+
+// WriteBulkResults writes the 207 Multi-Status response of a bulk request
+// with one result per resource of its data, see Bulk. Failed results get
+// the status and error objects of ErrorObjectsFor, their pointers located
+// in the request document.
+func WriteBulkResults(w http.ResponseWriter, results []BulkResult, opts ...MarshalOption) error {
+	type result struct {
+		Status   int             `json:"status"`
+		Document json.RawMessage `json:"document"`
+	}
+	encoded := make([]result, len(results))
+
+	for i, res := range results {
+		if res.Err == nil {
+			doc, err := MarshalBytes(res.Model, opts...)
+			if err != nil {
+				res.Err = err
+			} else {
+				status := res.Status
+				if status == 0 {
+					status = http.StatusOK
+				}
+				encoded[i] = result{Status: status, Document: doc}
+				continue
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := MarshalErrors(&buf, itemErrorObjects(i, ErrorObjectsFor(res.Err)), opts...); err != nil {
+			return err
+		}
+		encoded[i] = result{Status: StatusForError(res.Err), Document: bytes.TrimSpace(buf.Bytes())}
+	}
+
+	w.Header().Set("Content-Type", MediaType)
+	w.WriteHeader(http.StatusMultiStatus)
+	return encodePayload(w, map[string]interface{}{
+		"meta": map[string]interface{}{KeyBulkResultsMeta: encoded},
+	}, newMarshalOptions(opts))
+}
+
+// itemErrorObjects returns copies of objs, the errors of the resource at
+// index i, whose pointers into a single-resource document, e.g.
+// /data/attributes/start, point into the collection instead.
+func itemErrorObjects(i int, objs []*ErrorObject) []*ErrorObject {
+	prefix := "/data/" + strconv.Itoa(i)
+	located := make([]*ErrorObject, len(objs))
+	for j, obj := range objs {
+		c := *obj
+		switch {
+		case c.Source == nil:
+			c.Source = &ErrorSource{Pointer: prefix}
+		case c.Source.Pointer == "/data" || strings.HasPrefix(c.Source.Pointer, "/data/") &&
+			!isIndexedPointer(c.Source.Pointer):
+			source := *c.Source
+			source.Pointer = prefix + strings.TrimPrefix(source.Pointer, "/data")
+			c.Source = &source
+		}
+		located[j] = &c
+	}
+	return located
+}
+
+// isIndexedPointer reports whether pointer, under /data/, starts with an
+// array index.
+func isIndexedPointer(pointer string) bool {
+	segment := strings.SplitN(strings.TrimPrefix(pointer, "/data/"), "/", 2)[0]
+	_, err := strconv.Atoi(segment)
+	return err == nil
+}
@@ -0,0 +1,157 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// bulkResults decodes the results of a bulk response.
+func bulkResults(t *testing.T, w *httptest.ResponseRecorder) []struct {
+	Status   int                    `json:"status"`
+	Document map[string]interface{} `json:"document"`
+} {
+	t.Helper()
+	var doc struct {
+		Meta struct {
+			Results []struct {
+				Status   int                    `json:"status"`
+				Document map[string]interface{} `json:"document"`
+			} `json:"results"`
+		} `json:"meta"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc.Meta.Results
+}
+
+func TestBulk(t *testing.T) {
+	var saved []string
+	h := &Bulk{
+		Type: reflect.TypeOf(new(reqAuthor)),
+		Save: func(r *http.Request, i int, model interface{}) (interface{}, error) {
+			a := model.(*reqAuthor)
+			if a.Name == "taken" {
+				return nil, &ErrorObject{Status: "409", Title: "Conflict"}
+			}
+			saved = append(saved, a.ID)
+			return a, nil
+		},
+	}
+
+	body := `{"data": [
+		{"type": "people", "id": "1", "attributes": {"name": "Ann"}},
+		{"type": "people", "id": "2", "attributes": {"name": 5}},
+		{"type": "people", "id": "3", "attributes": {"name": "taken"}}
+	]}`
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/people", strings.NewReader(body)))
+
+	if w.Code != http.StatusMultiStatus || w.Header().Get("Content-Type") != MediaType {
+		t.Fatalf("status %d, headers %v", w.Code, w.Header())
+	}
+	if !equalStrings(saved, []string{"1"}) {
+		t.Errorf("saved %v, want only the valid resource", saved)
+	}
+
+	results := bulkResults(t, w)
+	if len(results) != 3 {
+		t.Fatalf("results %v", results)
+	}
+	if results[0].Status != http.StatusCreated || results[0].Document["data"].(map[string]interface{})["id"] != "1" {
+		t.Errorf("results[0] %v", results[0])
+	}
+
+	tests := []struct {
+		i       int
+		status  int
+		pointer string
+	}{
+		{1, http.StatusUnprocessableEntity, "/data/1/attributes/name"},
+		{2, http.StatusConflict, "/data/2"},
+	}
+	for _, tt := range tests {
+		res := results[tt.i]
+		errs := res.Document["errors"].([]interface{})
+		source := errs[0].(map[string]interface{})["source"].(map[string]interface{})
+		if res.Status != tt.status || source["pointer"] != tt.pointer {
+			t.Errorf("results[%d]: status %d, source %v; want %d at %s", tt.i, res.Status, source, tt.status, tt.pointer)
+		}
+	}
+
+	// The status of stored resources can be chosen.
+	h.Status = http.StatusOK
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/people",
+		strings.NewReader(`{"data": [{"type": "people", "id": "4"}]}`)))
+	if results := bulkResults(t, w); len(results) != 1 || results[0].Status != http.StatusOK {
+		t.Errorf("results %v, want one 200", results)
+	}
+}
+
+func TestBulkInvalidRequest(t *testing.T) {
+	h := &Bulk{Type: reflect.TypeOf(new(reqAuthor))}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/people", strings.NewReader(`{"data": `)))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"errors"`) {
+		t.Errorf("status %d, body %s; want a plain errors document", w.Code, w.Body)
+	}
+}
+
+func TestWriteBulkResults(t *testing.T) {
+	results := []BulkResult{
+		{Model: &reqAuthor{ID: "1", Name: "Ann"}},
+		{Status: http.StatusCreated, Model: &reqAuthor{ID: "2", Name: "Bob"}},
+		{Err: &ErrorObject{Status: "422", Title: "Invalid", Source: &ErrorSource{Pointer: "/data/attributes/name"}}},
+		{Status: http.StatusCreated, Err: errors.New("secret")},
+	}
+	w := httptest.NewRecorder()
+	if err := WriteBulkResults(w, results); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Code != http.StatusMultiStatus || w.Header().Get("Content-Type") != MediaType {
+		t.Errorf("status %d, headers %v", w.Code, w.Header())
+	}
+	want := `{"meta":{"results":[` +
+		`{"status":200,"document":{"data":{"type":"people","id":"1","attributes":{"name":"Ann"}}}},` +
+		`{"status":201,"document":{"data":{"type":"people","id":"2","attributes":{"name":"Bob"}}}},` +
+		`{"status":422,"document":{"errors":[{"title":"Invalid","status":"422","source":{"pointer":"/data/2/attributes/name"}}]}},` +
+		`{"status":500,"document":{"errors":[{"title":"Internal Server Error","status":"500","source":{"pointer":"/data/3"}}]}}` +
+		`]}}` + "\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("body\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestItemErrorObjects(t *testing.T) {
+	tests := []struct {
+		source *ErrorSource
+		want   string
+	}{
+		{nil, "/data/3"},
+		{&ErrorSource{Pointer: "/data"}, "/data/3"},
+		{&ErrorSource{Pointer: "/data/attributes/name"}, "/data/3/attributes/name"},
+		{&ErrorSource{Pointer: "/data/3/attributes/name"}, "/data/3/attributes/name"},
+		{&ErrorSource{Pointer: "/included/0"}, "/included/0"},
+	}
+	for _, tt := range tests {
+		var original string
+		if tt.source != nil {
+			original = tt.source.Pointer
+		}
+		obj := &ErrorObject{Status: "422", Source: tt.source}
+		got := itemErrorObjects(3, []*ErrorObject{obj})[0]
+		if got.Source.Pointer != tt.want {
+			t.Errorf("%q: pointer %q, want %q", original, got.Source.Pointer, tt.want)
+		}
+		if obj.Source != tt.source || tt.source != nil && tt.source.Pointer != original {
+			t.Errorf("%q: the original error object was changed", original)
+		}
+	}
+}
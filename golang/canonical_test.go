@@ -0,0 +1,98 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCanonicalNumber(t *testing.T) {
+	// Examples from RFC 8785, appendix B.
+	tests := []struct {
+		in, want string
+	}{
+		{"0", "0"},
+		{"-0", "0"},
+		{"1", "1"},
+		{"-1.5", "-1.5"},
+		{"1e21", "1e+21"},
+		{"1e20", "100000000000000000000"},
+		{"0.000001", "0.000001"},
+		{"0.0000001", "1e-7"},
+		{"1.5e-7", "1.5e-7"},
+		{"4.50", "4.5"},
+		{"2e-3", "0.002"},
+		{"9007199254740993", "9007199254740992"},
+		{"1.7976931348623157e308", "1.7976931348623157e+308"},
+		{"5e-324", "5e-324"},
+	}
+	for _, tt := range tests {
+		got, err := canonicalNumber(json.Number(tt.in))
+		if err != nil || got != tt.want {
+			t.Errorf("canonicalNumber(%s) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	if _, err := canonicalNumber("1e400"); err == nil {
+		t.Error("expected an error for a number out of range")
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{` { "b" : [1, 2.50, true, null], "a" : "x" } `, `{"a":"x","b":[1,2.5,true,null]}`},
+		{`"\u00e9\u0001\t\/<>&"`, `"é\u0001\t/<>&"`},
+		// Members are sorted by UTF-16 code units, which puts U+1F600,
+		// a surrogate pair, before U+FB33.
+		{`{"\ufb33": 1, "\ud83d\ude00": 2, "\u20ac": 3, "1": 4, "\r": 5}`,
+			"{\"\\r\":5,\"1\":4,\"€\":3,\"\U0001F600\":2,\"\uFB33\":1}"},
+		{`{}`, `{}`},
+		{`[]`, `[]`},
+	}
+	for _, tt := range tests {
+		got, err := Canonicalize([]byte(tt.in))
+		if err != nil {
+			t.Errorf("%s: %v", tt.in, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("Canonicalize(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{``, `{"a":`, `{} {}`, `[1e400]`} {
+		if _, err := Canonicalize([]byte(in)); err == nil {
+			t.Errorf("Canonicalize(%q): expected an error", in)
+		}
+	}
+}
+
+func TestCanonicalMarshal(t *testing.T) {
+	item := &ndjsonItem{ID: "1", Zeta: "<z>", Alpha: 1}
+
+	got, err := CanonicalMarshal(item, WithIndent("", "  "), WithAttributeOrder(AttributeDeclarationOrder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"data":{"attributes":{"alpha":1,"zeta":"<z>"},"id":"1",` +
+		`"relationships":{"owner":{"data":null}},"type":"items"}}`
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	// The same document, however it was written, has the same form.
+	again, err := Canonicalize([]byte(`{"data": {"type": "items", "id": "1",
+		"relationships": {"owner": {"data": null}},
+		"attributes": {"zeta": "\u003cz\u003e", "alpha": 1.0}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != want {
+		t.Errorf("got %s, want %s", again, want)
+	}
+
+	if _, err := CanonicalMarshal(1); err != ErrUnexpectedType {
+		t.Errorf("error %v, want ErrUnexpectedType", err)
+	}
+}
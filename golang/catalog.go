@@ -0,0 +1,176 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrorTemplate is the text of an error object for one error code and
+// locale. Title and Detail may contain placeholders such as {name}, which
+// are replaced with the parameters passed to ErrorCatalog.Error.
+type ErrorTemplate struct {
+	Status int
+	Title  string
+	Detail string
+}
+
+// ErrorCatalog maps application error codes to localized error templates,
+// so that handlers report errors by code and the catalog produces the
+// error objects in the language the client asked for:
+//
+//	catalog := jsonapi.NewErrorCatalog("en")
+//	catalog.Add("slot-taken", "en", jsonapi.ErrorTemplate{
+//		Status: http.StatusConflict,
+//		Title:  "Slot taken",
+//		Detail: "The slot at {time} is already booked.",
+//	})
+//	catalog.Add("slot-taken", "fr", jsonapi.ErrorTemplate{
+//		Status: http.StatusConflict,
+//		Title:  "Créneau pris",
+//		Detail: "Le créneau de {time} est déjà réservé.",
+//	})
+//
+//	obj := catalog.ErrorForRequest(r, "slot-taken", map[string]interface{}{"time": "10:00"})
+//
+// An ErrorCatalog is safe for concurrent use.
+type ErrorCatalog struct {
+	defaultLocale string
+
+	mu        sync.RWMutex
+	templates map[string]map[string]ErrorTemplate
+}
+
+// NewErrorCatalog returns an empty catalog falling back to defaultLocale
+// when none of the locales asked for has a template.
+func NewErrorCatalog(defaultLocale string) *ErrorCatalog {
+	return &ErrorCatalog{
+		defaultLocale: defaultLocale,
+		templates:     map[string]map[string]ErrorTemplate{},
+	}
+}
+
+// Add sets the template of code in locale, a language tag such as "en" or
+// "pt-BR".
+func (c *ErrorCatalog) Add(code, locale string, t ErrorTemplate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.templates[code] == nil {
+		c.templates[code] = map[string]ErrorTemplate{}
+	}
+	c.templates[code][strings.ToLower(locale)] = t
+}
+
+// Error returns the error object for code in the first of locales that has
+// a template, or else in the default locale. Locales are matched exactly
+// first and then by language, so "fr-CA" finds a template added for "fr".
+// The object carries code, and params in its meta.
+//
+// A code without any usable template yields a 500 error object, so that a
+// missing translation never hides the error itself.
+func (c *ErrorCatalog) Error(code string, locales []string, params map[string]interface{}) *ErrorObject {
+	obj := &ErrorObject{Code: code}
+	if len(params) > 0 {
+		meta := make(map[string]interface{}, len(params))
+		for k, v := range params {
+			meta[k] = v
+		}
+		obj.Meta = &meta
+	}
+
+	t, ok := c.lookup(code, locales)
+	if !ok {
+		obj.Title = http.StatusText(http.StatusInternalServerError)
+		obj.Status = strconv.Itoa(http.StatusInternalServerError)
+		return obj
+	}
+
+	obj.Title = expandTemplate(t.Title, params)
+	obj.Detail = expandTemplate(t.Detail, params)
+	if t.Status != 0 {
+		obj.Status = strconv.Itoa(t.Status)
+	}
+	return obj
+}
+
+// ErrorForRequest is like Error with the locales of the Accept-Language
+// header of r, in order of preference.
+func (c *ErrorCatalog) ErrorForRequest(r *http.Request, code string, params map[string]interface{}) *ErrorObject {
+	return c.Error(code, AcceptedLanguages(r.Header.Get("Accept-Language")), params)
+}
+
+func (c *ErrorCatalog) lookup(code string, locales []string) (ErrorTemplate, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	byLocale := c.templates[code]
+	for _, locale := range locales {
+		locale = strings.ToLower(locale)
+		if t, ok := byLocale[locale]; ok {
+			return t, true
+		}
+		if i := strings.IndexByte(locale, '-'); i > 0 {
+			if t, ok := byLocale[locale[:i]]; ok {
+				return t, true
+			}
+		}
+	}
+	t, ok := byLocale[strings.ToLower(c.defaultLocale)]
+	return t, ok
+}
+
+// expandTemplate replaces the {name} placeholders of s with the values of
+// params. Placeholders without a parameter are left as they are.
+func expandTemplate(s string, params map[string]interface{}) string {
+	if len(params) == 0 || !strings.Contains(s, "{") {
+		return s
+	}
+	pairs := make([]string, 0, 2*len(params))
+	for k, v := range params {
+		pairs = append(pairs, "{"+k+"}", fmt.Sprint(v))
+	}
+	return strings.NewReplacer(pairs...).Replace(s)
+}
+
+// AcceptedLanguages returns the language tags of an Accept-Language header
+// value, most preferred first. Tags with q=0 and the * wildcard are left
+// out.
+func AcceptedLanguages(header string) []string {
+	type language struct {
+		tag string
+		q   float64
+	}
+	var langs []language
+	for _, entry := range strings.Split(header, ",") {
+		parts := strings.Split(entry, ";")
+		tag := strings.TrimSpace(parts[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, p := range parts[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if f, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					q = f
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		langs = append(langs, language{tag, q})
+	}
+
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+	tags := make([]string, len(langs))
+	for i, l := range langs {
+		tags[i] = l.tag
+	}
+	return tags
+}
@@ -0,0 +1,115 @@
+package jsonapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func testCatalog() *ErrorCatalog {
+	c := NewErrorCatalog("en")
+	c.Add("slot-taken", "en", ErrorTemplate{
+		Status: http.StatusConflict,
+		Title:  "Slot taken",
+		Detail: "The slot at {time} is already booked by {who}.",
+	})
+	c.Add("slot-taken", "fr", ErrorTemplate{
+		Status: http.StatusConflict,
+		Title:  "Créneau pris",
+		Detail: "Le créneau de {time} est déjà réservé.",
+	})
+	c.Add("slot-taken", "pt-BR", ErrorTemplate{Title: "Horário ocupado"})
+	return c
+}
+
+func TestErrorCatalog(t *testing.T) {
+	c := testCatalog()
+	params := map[string]interface{}{"time": "10:00"}
+
+	tests := []struct {
+		locales []string
+		title   string
+		detail  string
+		status  string
+	}{
+		{nil, "Slot taken", "The slot at 10:00 is already booked by {who}.", "409"},
+		{[]string{"fr"}, "Créneau pris", "Le créneau de 10:00 est déjà réservé.", "409"},
+		{[]string{"FR-ca"}, "Créneau pris", "Le créneau de 10:00 est déjà réservé.", "409"},
+		{[]string{"de", "pt-br"}, "Horário ocupado", "", ""},
+		{[]string{"pt"}, "Slot taken", "The slot at 10:00 is already booked by {who}.", "409"},
+	}
+	for _, tt := range tests {
+		obj := c.Error("slot-taken", tt.locales, params)
+		if obj.Title != tt.title || obj.Detail != tt.detail || obj.Status != tt.status || obj.Code != "slot-taken" {
+			t.Errorf("%v: %+v", tt.locales, obj)
+		}
+		if obj.Meta == nil || !reflect.DeepEqual(*obj.Meta, map[string]interface{}{"time": "10:00"}) {
+			t.Errorf("%v: meta %v", tt.locales, obj.Meta)
+		}
+	}
+
+	// The meta is a copy of the parameters.
+	obj := c.Error("slot-taken", nil, params)
+	params["time"] = "11:00"
+	if (*obj.Meta)["time"] != "10:00" {
+		t.Errorf("meta %v shares the parameters", *obj.Meta)
+	}
+}
+
+func TestErrorCatalogMissing(t *testing.T) {
+	c := testCatalog()
+	obj := c.Error("unknown", []string{"en"}, nil)
+	if obj.Status != "500" || obj.Title != "Internal Server Error" || obj.Code != "unknown" || obj.Meta != nil {
+		t.Errorf("unknown code: %+v", obj)
+	}
+
+	// No template in the default locale either.
+	c = NewErrorCatalog("de")
+	c.Add("x", "en", ErrorTemplate{Title: "X"})
+	if obj := c.Error("x", []string{"fr"}, nil); obj.Status != "500" {
+		t.Errorf("missing locale: %+v", obj)
+	}
+}
+
+func TestErrorForRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Language", "de;q=0.9, fr;q=0.8")
+	if obj := testCatalog().ErrorForRequest(r, "slot-taken", nil); obj.Title != "Créneau pris" || obj.Meta != nil {
+		t.Errorf("error object %+v", obj)
+	}
+}
+
+func TestAcceptedLanguages(t *testing.T) {
+	tests := map[string][]string{
+		"":                              {},
+		"fr":                            {"fr"},
+		"da, en-GB;q=0.8, en;q=0.7":     {"da", "en-GB", "en"},
+		"en;q=0.5, fr, *;q=0.1, de;q=0": {"fr", "en"},
+		"en;q=x, fr;q=0.9":              {"en", "fr"},
+		" , pt-BR ; q=0.3,es":           {"es", "pt-BR"},
+	}
+	for header, want := range tests {
+		if got := AcceptedLanguages(header); !equalStrings(got, want) {
+			t.Errorf("AcceptedLanguages(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
+
+func TestErrorCatalogConcurrent(t *testing.T) {
+	c := NewErrorCatalog("en")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			c.Add("x", "en", ErrorTemplate{Title: "X"})
+		}()
+		go func() {
+			defer wg.Done()
+			c.Error("x", []string{"en"}, nil)
+		}()
+	}
+	wg.Wait()
+}
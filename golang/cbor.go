@@ -0,0 +1,331 @@
+package jsonapi
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// CBOR is the Format of RFC 8949, Concise Binary Object Representation.
+// Byte strings and tags, which the JSON data model has no equivalent for,
+// are rejected with ErrBinaryFormat when decoding, and undefined is read
+// as null.
+var CBOR Format = cborFormat{}
+
+type cborFormat struct{}
+
+func (cborFormat) MediaType() string { return "application/cbor" }
+
+func (cborFormat) EncodeValue(w io.Writer, v interface{}) error {
+	e := &cborEncoder{w: w}
+	e.value(v)
+	return e.err
+}
+
+func (cborFormat) DecodeValue(r io.Reader) (interface{}, error) {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		b := bufio.NewReader(r)
+		r, br = b, b
+	}
+	d := &cborDecoder{r: r, br: br}
+	return d.value(0)
+}
+
+// CBOR major types.
+const (
+	cborUint = iota
+	cborNegInt
+	cborBytes
+	cborText
+	cborArray
+	cborMap
+	cborTag
+	cborSimple
+)
+
+// cborBreak ends an indefinite-length item.
+const cborBreak = 0xff
+
+type cborEncoder struct {
+	w   io.Writer
+	err error
+}
+
+func (e *cborEncoder) write(b ...byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+// head writes the initial bytes of an item of the given major type with
+// argument n, in the shortest form.
+func (e *cborEncoder) head(major byte, n uint64) {
+	major <<= 5
+	var buf [9]byte
+	switch {
+	case n < 24:
+		e.write(major | byte(n))
+	case n <= math.MaxUint8:
+		e.write(major|24, byte(n))
+	case n <= math.MaxUint16:
+		buf[0] = major | 25
+		binary.BigEndian.PutUint16(buf[1:], uint16(n))
+		e.write(buf[:3]...)
+	case n <= math.MaxUint32:
+		buf[0] = major | 26
+		binary.BigEndian.PutUint32(buf[1:], uint32(n))
+		e.write(buf[:5]...)
+	default:
+		buf[0] = major | 27
+		binary.BigEndian.PutUint64(buf[1:], n)
+		e.write(buf[:]...)
+	}
+}
+
+func (e *cborEncoder) value(v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		e.write(0xf6)
+	case bool:
+		if v {
+			e.write(0xf5)
+		} else {
+			e.write(0xf4)
+		}
+	case int64:
+		if v >= 0 {
+			e.head(cborUint, uint64(v))
+		} else {
+			e.head(cborNegInt, uint64(-1-v))
+		}
+	case uint64:
+		e.head(cborUint, v)
+	case float64:
+		var buf [9]byte
+		buf[0] = 0xfb
+		binary.BigEndian.PutUint64(buf[1:], math.Float64bits(v))
+		e.write(buf[:]...)
+	case string:
+		e.head(cborText, uint64(len(v)))
+		if e.err == nil {
+			_, e.err = io.WriteString(e.w, v)
+		}
+	case []interface{}:
+		e.head(cborArray, uint64(len(v)))
+		for _, elem := range v {
+			e.value(elem)
+		}
+	case map[string]interface{}:
+		e.head(cborMap, uint64(len(v)))
+		for _, k := range sortedKeys(v) {
+			e.value(k)
+			e.value(v[k])
+		}
+	default:
+		if e.err == nil {
+			e.err = fmt.Errorf("jsonapi: cannot encode %T as CBOR", v)
+		}
+	}
+}
+
+type cborDecoder struct {
+	r  io.Reader
+	br io.ByteReader
+}
+
+// arg reads the argument of an item whose initial byte has the additional
+// information ai. indefinite is set for ai 31.
+func (d *cborDecoder) arg(ai byte) (n uint64, indefinite bool, err error) {
+	switch {
+	case ai < 24:
+		return uint64(ai), false, nil
+	case ai <= 27:
+		size := 1 << (ai - 24)
+		var buf [8]byte
+		if _, err := io.ReadFull(d.r, buf[8-size:]); err != nil {
+			return 0, false, ErrBinaryFormat
+		}
+		return binary.BigEndian.Uint64(buf[:]), false, nil
+	case ai == 31:
+		return 0, true, nil
+	}
+	return 0, false, ErrBinaryFormat
+}
+
+func (d *cborDecoder) value(depth int) (interface{}, error) {
+	b, err := d.br.ReadByte()
+	if err != nil {
+		return nil, ErrBinaryFormat
+	}
+	return d.item(b, depth)
+}
+
+// item decodes the item starting with the initial byte b.
+func (d *cborDecoder) item(b byte, depth int) (interface{}, error) {
+	if depth > maxBinaryNesting {
+		return nil, ErrBinaryFormat
+	}
+	major, ai := b>>5, b&0x1f
+
+	switch major {
+	case cborSimple:
+		return d.simple(ai)
+	case cborBytes, cborTag:
+		return nil, ErrBinaryFormat
+	}
+
+	n, indefinite, err := d.arg(ai)
+	if err != nil {
+		return nil, err
+	}
+	if indefinite && (major == cborUint || major == cborNegInt) {
+		return nil, ErrBinaryFormat
+	}
+
+	switch major {
+	case cborUint:
+		if n <= math.MaxInt64 {
+			return int64(n), nil
+		}
+		return n, nil
+	case cborNegInt:
+		if n <= math.MaxInt64 {
+			return -1 - int64(n), nil
+		}
+		// Below the range of int64.
+		return -1 - float64(n), nil
+	case cborText:
+		if indefinite {
+			return d.chunks()
+		}
+		s, err := readBytes(d.r, n)
+		if err != nil {
+			return nil, err
+		}
+		return string(s), nil
+	case cborArray:
+		a := make([]interface{}, 0, capHint(n))
+		for i := uint64(0); indefinite || i < n; i++ {
+			b, err := d.br.ReadByte()
+			if err != nil {
+				return nil, ErrBinaryFormat
+			}
+			if indefinite && b == cborBreak {
+				break
+			}
+			v, err := d.item(b, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		return a, nil
+	default: // cborMap
+		m := make(map[string]interface{}, capHint(n))
+		for i := uint64(0); indefinite || i < n; i++ {
+			b, err := d.br.ReadByte()
+			if err != nil {
+				return nil, ErrBinaryFormat
+			}
+			if indefinite && b == cborBreak {
+				break
+			}
+			k, err := d.item(b, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, ErrBinaryFormat
+			}
+			if m[key], err = d.value(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+}
+
+// chunks reads the definite-length chunks of an indefinite-length text
+// string.
+func (d *cborDecoder) chunks() (interface{}, error) {
+	var s []byte
+	for {
+		b, err := d.br.ReadByte()
+		if err != nil {
+			return nil, ErrBinaryFormat
+		}
+		if b == cborBreak {
+			return string(s), nil
+		}
+		if b>>5 != cborText {
+			return nil, ErrBinaryFormat
+		}
+		n, indefinite, err := d.arg(b & 0x1f)
+		if err != nil || indefinite {
+			return nil, ErrBinaryFormat
+		}
+		chunk, err := readBytes(d.r, n)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, chunk...)
+	}
+}
+
+func (d *cborDecoder) simple(ai byte) (interface{}, error) {
+	switch ai {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		n, _, err := d.arg(25)
+		if err != nil {
+			return nil, err
+		}
+		return halfFloat(uint16(n)), nil
+	case 26:
+		n, _, err := d.arg(26)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(uint32(n))), nil
+	case 27:
+		n, _, err := d.arg(27)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(n), nil
+	}
+	return nil, ErrBinaryFormat
+}
+
+// halfFloat converts an IEEE 754 half-precision number.
+func halfFloat(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
@@ -0,0 +1,121 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Examples from RFC 8949, appendix A.
+func TestCBORDecode(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want interface{}
+	}{
+		{"00", int64(0)},
+		{"17", int64(23)},
+		{"1818", int64(24)},
+		{"1903e8", int64(1000)},
+		{"1bffffffffffffffff", uint64(math.MaxUint64)},
+		{"20", int64(-1)},
+		{"3903e7", int64(-1000)},
+		{"3bffffffffffffffff", -1 - float64(math.MaxUint64)},
+		{"f90000", 0.0},
+		{"f93c00", 1.0},
+		{"f9c400", -4.0},
+		{"f90001", 5.960464477539063e-8},
+		{"f97bff", 65504.0},
+		{"fa47c35000", 100000.0},
+		{"fb3ff199999999999a", 1.1},
+		{"f4", false},
+		{"f5", true},
+		{"f6", nil},
+		{"f7", nil},
+		{"6449455446", "IETF"},
+		{"7f657374726561646d696e67ff", "streaming"},
+		{"83010203", []interface{}{int64(1), int64(2), int64(3)}},
+		{"9f018202039f0405ffff", []interface{}{int64(1), []interface{}{int64(2), int64(3)}, []interface{}{int64(4), int64(5)}}},
+		{"a26161016162820203", map[string]interface{}{"a": int64(1), "b": []interface{}{int64(2), int64(3)}}},
+		{"bf61610161629f0203ffff", map[string]interface{}{"a": int64(1), "b": []interface{}{int64(2), int64(3)}}},
+	}
+	for _, tt := range tests {
+		b, _ := hex.DecodeString(tt.hex)
+		got, err := CBOR.DecodeValue(bytes.NewReader(b))
+		if err != nil {
+			t.Errorf("%s: %v", tt.hex, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %#v, want %#v", tt.hex, got, tt.want)
+		}
+	}
+
+	for _, h := range []string{"f97c00", "f9fc00"} {
+		b, _ := hex.DecodeString(h)
+		if got, err := CBOR.DecodeValue(bytes.NewReader(b)); err != nil || !math.IsInf(got.(float64), 0) {
+			t.Errorf("%s: got %v, %v, want an infinity", h, got, err)
+		}
+	}
+}
+
+func TestCBOREncode(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{int64(0), "00"},
+		{int64(24), "1818"},
+		{int64(1000000), "1a000f4240"},
+		{int64(1000000000000), "1b000000e8d4a51000"},
+		{int64(-100), "3863"},
+		{1.1, "fb3ff199999999999a"},
+		{"IETF", "6449455446"},
+		{[]interface{}{int64(1), []interface{}{int64(2)}}, "82018102"},
+		{map[string]interface{}{"b": int64(2), "a": int64(1)}, "a2616101616202"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := CBOR.EncodeValue(&buf, tt.v); err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(buf.Bytes()); got != tt.want {
+			t.Errorf("%v: got %s, want %s", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestCBORMalformed(t *testing.T) {
+	tests := []string{
+		"",                   // empty
+		"19",                 // truncated argument
+		"1c",                 // reserved additional information
+		"1f",                 // indefinite integer
+		"62",                 // truncated string
+		"7f6161",             // unterminated indefinite string
+		"7f4161ff",           // byte string chunk in a text string
+		"7f7f6161ffff",       // nested indefinite chunk
+		"a10102",             // integer key
+		"82",                 // truncated array
+		"9f01",               // unterminated indefinite array
+		"f8",                 // unsupported simple value
+		"7bffffffffffffffff", // oversized length
+		"4401020304",         // byte string
+		"5f4101ff",           // indefinite byte string
+		"c074323031332d30332d32315432303a30343a30305a", // tagged date
+	}
+	for _, h := range tests {
+		b, _ := hex.DecodeString(h)
+		if v, err := CBOR.DecodeValue(bytes.NewReader(b)); err != ErrBinaryFormat {
+			t.Errorf("%q: got %v, %v, want ErrBinaryFormat", h, v, err)
+		}
+	}
+
+	deep := strings.Repeat("81", maxBinaryNesting+2) + "00"
+	b, _ := hex.DecodeString(deep)
+	if _, err := CBOR.DecodeValue(bytes.NewReader(b)); err != ErrBinaryFormat {
+		t.Errorf("deep nesting: %v", err)
+	}
+}
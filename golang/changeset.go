@@ -0,0 +1,152 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Changeset records what a document sent to UnmarshalChangeset actually
+// contained, which a model alone cannot tell: an attribute set to its zero
+// value looks the same as one that was left out. It is meant for PATCH
+// handlers, audit logs and validation layered above the decoder.
+type Changeset struct {
+	Type string
+	ID   string
+
+	// Attributes and Relationships are keyed by member name and hold an
+	// entry for every member present in the resource object, including
+	// those with no matching field.
+	Attributes    map[string]*Change
+	Relationships map[string]*Change
+}
+
+// Change describes one member of a resource object.
+type Change struct {
+	// Field is the name of the struct field the member was stored in, or
+	// "" if the model has none.
+	Field string
+	// Raw is the member's value exactly as it appeared in the document.
+	Raw json.RawMessage
+	// Value is Raw decoded the way encoding/json decodes into an
+	// interface{}, before any conversion to the field's type.
+	Value interface{}
+	// Converted is the value of the field after unmarshaling, nil when
+	// Field is "".
+	Converted interface{}
+}
+
+// Has reports whether the document contained the attribute or
+// relationship name.
+func (c *Changeset) Has(name string) bool {
+	if _, ok := c.Attributes[name]; ok {
+		return true
+	}
+	_, ok := c.Relationships[name]
+	return ok
+}
+
+// Fields returns the sorted names of the struct fields that were set from
+// the document, e.g. to restrict an ORM update to them.
+func (c *Changeset) Fields() []string {
+	var fields []string
+	for _, changes := range []map[string]*Change{c.Attributes, c.Relationships} {
+		for _, ch := range changes {
+			if ch.Field != "" {
+				fields = append(fields, ch.Field)
+			}
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// UnmarshalChangeset is like UnmarshalPayload but also returns the
+// Changeset of the primary data. The Changeset is nil when data is null.
+func UnmarshalChangeset(in io.Reader, model interface{}, opts ...UnmarshalOption) (*Changeset, error) {
+	o := newUnmarshalOptions(opts)
+
+	b, err := ioutil.ReadAll(o.body(in))
+	if err != nil {
+		return nil, err
+	}
+	if err := UnmarshalPayload(bytes.NewReader(b), model, opts...); err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Data *struct {
+			Type          string                     `json:"type"`
+			ID            string                     `json:"id"`
+			Attributes    map[string]json.RawMessage `json:"attributes"`
+			Relationships map[string]json.RawMessage `json:"relationships"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Data == nil {
+		return nil, nil
+	}
+
+	fields := memberFields(reflect.TypeOf(model).Elem(), o.tagKey, o.jsonFallback, o.reservedPrefix)
+	modelValue := reflect.ValueOf(model).Elem()
+
+	change := func(kind, name string, raw json.RawMessage) (*Change, error) {
+		ch := &Change{Raw: raw}
+
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		if o.useNumber {
+			dec.UseNumber()
+		}
+		if err := dec.Decode(&ch.Value); err != nil {
+			return nil, err
+		}
+
+		if i, ok := fields[kind+","+name]; ok {
+			ch.Field = modelValue.Type().Field(i).Name
+			ch.Converted = modelValue.Field(i).Interface()
+		}
+		return ch, nil
+	}
+
+	cs := &Changeset{
+		Type:          doc.Data.Type,
+		ID:            doc.Data.ID,
+		Attributes:    make(map[string]*Change, len(doc.Data.Attributes)),
+		Relationships: make(map[string]*Change, len(doc.Data.Relationships)),
+	}
+	for name, raw := range doc.Data.Attributes {
+		if cs.Attributes[name], err = change(annotationAttribute, name, raw); err != nil {
+			return nil, err
+		}
+	}
+	for name, raw := range doc.Data.Relationships {
+		if cs.Relationships[name], err = change(annotationRelation, name, raw); err != nil {
+			return nil, err
+		}
+	}
+
+	return cs, nil
+}
+
+// memberFields maps "attr,NAME" and "relation,NAME" to the index of the
+// field of t holding that member.
+func memberFields(t reflect.Type, tagKey string, jsonFallback bool, reservedPrefix string) map[string]int {
+	fields := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		tag := renameReserved(fieldTag(t.Field(i), tagKey, jsonFallback), reservedPrefix)
+		if tag == "" {
+			continue
+		}
+		args := strings.Split(tag, annotationSeperator)
+		if len(args) > 1 && (args[0] == annotationAttribute || args[0] == annotationRelation) {
+			fields[args[0]+","+args[1]] = i
+		}
+	}
+	return fields
+}
@@ -0,0 +1,67 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalChangeset(t *testing.T) {
+	doc := `{"data": {"type": "articles", "id": "1",
+		"attributes": {"title": "Hello", "views": 0, "unknown": [1]},
+		"relationships": {"author": {"data": {"type": "people", "id": "9"}}}}}`
+	a := new(reqArticle)
+	cs, err := UnmarshalChangeset(strings.NewReader(doc), a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cs.Type != "articles" || cs.ID != "1" || a.Title != "Hello" {
+		t.Errorf("changeset %+v, model %+v", cs, a)
+	}
+	if !cs.Has("views") || !cs.Has("author") || cs.Has("score") || cs.Has("comments") {
+		t.Error("Has does not match the members of the document")
+	}
+	if want := []string{"Author", "Title", "Views"}; !equalStrings(cs.Fields(), want) {
+		t.Errorf("fields %v, want %v", cs.Fields(), want)
+	}
+
+	views := cs.Attributes["views"]
+	if string(views.Raw) != "0" || views.Value != float64(0) || views.Converted != 0 || views.Field != "Views" {
+		t.Errorf("views %+v", views)
+	}
+	if unknown := cs.Attributes["unknown"]; unknown.Field != "" || unknown.Converted != nil || string(unknown.Raw) != "[1]" {
+		t.Errorf("unknown %+v", unknown)
+	}
+	if author := cs.Relationships["author"]; author.Converted.(*reqAuthor).ID != "9" {
+		t.Errorf("author %+v", author)
+	}
+}
+
+func TestUnmarshalChangesetOptions(t *testing.T) {
+	doc := `{"data": {"type": "articles", "id": "1", "attributes": {"views": 9007199254740993}}}`
+	cs, err := UnmarshalChangeset(strings.NewReader(doc), new(reqArticle), UseNumber())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := cs.Attributes["views"].Value; v != json.Number("9007199254740993") {
+		t.Errorf("value %#v, want a json.Number", v)
+	}
+
+	if _, err := UnmarshalChangeset(strings.NewReader(doc), new(reqArticle), MaxBodyBytes(10)); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("error %v, want ErrBodyTooLarge", err)
+	}
+}
+
+func TestUnmarshalChangesetNullData(t *testing.T) {
+	cs, err := UnmarshalChangeset(strings.NewReader(`{"data": null}`), new(reqArticle))
+	if err != nil || cs != nil {
+		t.Errorf("got %v, %v, want a nil changeset", cs, err)
+	}
+
+	if _, err := UnmarshalChangeset(strings.NewReader(`{"data": {"type": "articles", "id": "1", "attributes": {"views": "x"}}}`),
+		new(reqArticle)); err == nil {
+		t.Error("expected the unmarshal error")
+	}
+}
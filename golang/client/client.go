@@ -0,0 +1,250 @@
+// Package client is a small HTTP client for JSON:API services built on the
+// jsonapi marshaler.
+//
+// Models are the same tagged structs used on the server side:
+//
+//	c := client.New("https://api.example.com")
+//	var posts []*Post
+//	q := new(jsonapi.Query).Including("author").SortBy("-created")
+//	err := c.GetAll(ctx, "/posts", q, &posts)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	jsonapi "test3"
+)
+
+// Client performs JSON:API requests against a single service.
+type Client struct {
+	// BaseURL is prepended to request paths.
+	BaseURL string
+	// HTTPClient is used to send requests; http.DefaultClient if nil.
+	HTTPClient *http.Client
+	// Header is added to every request, e.g. for authorization.
+	Header http.Header
+}
+
+// New returns a Client for the service at baseURL.
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Error is returned for responses with a 4xx or 5xx status. Errors holds
+// the error objects of the response's errors document, if it had one.
+type Error struct {
+	StatusCode int
+	Errors     []*jsonapi.ErrorObject
+}
+
+func (e *Error) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("jsonapi client: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+
+	details := make([]string, len(e.Errors))
+	for i, obj := range e.Errors {
+		details[i] = strings.TrimSpace(obj.Title + " " + obj.Detail)
+	}
+	return fmt.Sprintf("jsonapi client: %d: %s", e.StatusCode, strings.Join(details, "; "))
+}
+
+// Get fetches path with the query q (which may be nil) and decodes the
+// response into out: a pointer to a tagged struct for single-resource
+// documents or a pointer to a slice of struct pointers for collections.
+func (c *Client) Get(ctx context.Context, path string, q *jsonapi.Query, out interface{}) error {
+	_, err := c.get(ctx, c.url(path, q), out)
+	return err
+}
+
+// GetAll is like Get for collections, but keeps following the next link of
+// each page and appends every page to out, a pointer to a slice of struct
+// pointers.
+func (c *Client) GetAll(ctx context.Context, path string, q *jsonapi.Query, out interface{}) error {
+	slice := reflect.ValueOf(out)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return jsonapi.ErrExpectedSlice
+	}
+
+	next := c.url(path, q)
+	for next != "" {
+		page := reflect.New(slice.Elem().Type())
+		links, err := c.get(ctx, next, page.Interface())
+		if err != nil {
+			return err
+		}
+		slice.Elem().Set(reflect.AppendSlice(slice.Elem(), page.Elem()))
+
+		next = ""
+		if links != nil {
+			if href := linkHref((*links)[jsonapi.KeyNextPage]); href != "" {
+				next = c.resolve(href)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Create POSTs model to path and decodes the created resource into out,
+// which may be model itself or nil to ignore the response body.
+func (c *Client) Create(ctx context.Context, path string, model, out interface{}) error {
+	return c.send(ctx, http.MethodPost, path, model, out)
+}
+
+// Update PATCHes model to path and decodes the updated resource into out,
+// which may be model itself or nil to ignore the response body.
+func (c *Client) Update(ctx context.Context, path string, model, out interface{}) error {
+	return c.send(ctx, http.MethodPatch, path, model, out)
+}
+
+// Delete sends a DELETE request to path.
+func (c *Client) Delete(ctx context.Context, path string) error {
+	_, err := c.do(ctx, http.MethodDelete, c.url(path, nil), nil, nil)
+	return err
+}
+
+func (c *Client) send(ctx context.Context, method, path string, model, out interface{}) error {
+	body, err := jsonapi.MarshalBytes(model)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(ctx, method, c.url(path, nil), bytes.NewReader(body), out)
+	return err
+}
+
+func (c *Client) get(ctx context.Context, u string, out interface{}) (*jsonapi.Links, error) {
+	return c.do(ctx, http.MethodGet, u, nil, out)
+}
+
+// do sends a request and decodes its response into out. It returns the
+// top-level links of the response document.
+func (c *Client) do(ctx context.Context, method, u string, body io.Reader,
+	out interface{}) (*jsonapi.Links, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", jsonapi.MediaType)
+	if body != nil {
+		req.Header.Set("Content-Type", jsonapi.MediaType)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		e := &Error{StatusCode: resp.StatusCode}
+		var payload jsonapi.ErrorsPayload
+		if json.Unmarshal(data, &payload) == nil {
+			e.Errors = payload.Errors
+		}
+		return nil, e
+	}
+
+	if out == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	return decode(data, out)
+}
+
+func decode(data []byte, out interface{}) (*jsonapi.Links, error) {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr {
+		return nil, jsonapi.ErrUnexpectedType
+	}
+
+	var doc struct {
+		Links *jsonapi.Links `json:"links"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	if v.Elem().Kind() != reflect.Slice {
+		return doc.Links, jsonapi.UnmarshalPayload(bytes.NewReader(data), out)
+	}
+
+	models, err := jsonapi.UnmarshalManyPayload(bytes.NewReader(data), v.Elem().Type().Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	slice := reflect.MakeSlice(v.Elem().Type(), 0, len(models))
+	for _, m := range models {
+		slice = reflect.Append(slice, reflect.ValueOf(m))
+	}
+	v.Elem().Set(slice)
+
+	return doc.Links, nil
+}
+
+// url returns the request URL for path, which is relative to BaseURL.
+func (c *Client) url(path string, q *jsonapi.Query) string {
+	u := c.BaseURL + "/" + strings.TrimPrefix(path, "/")
+	if q == nil {
+		return u
+	}
+	if enc := q.Encode(); enc != "" {
+		if strings.Contains(u, "?") {
+			return u + "&" + enc
+		}
+		return u + "?" + enc
+	}
+	return u
+}
+
+// resolve turns a link found in a response, which may be relative to the
+// service, into an absolute URL.
+func (c *Client) resolve(ref string) string {
+	base, err := url.Parse(c.BaseURL + "/")
+	if err != nil {
+		return ref
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(r).String()
+}
+
+// linkHref returns the URL of a links member, which is either a string or
+// a link object.
+func linkHref(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		href, _ := v["href"].(string)
+		return href
+	case jsonapi.Link:
+		return v.Href
+	}
+	return ""
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jsonapi "test3"
+)
+
+type post struct {
+	ID    string `jsonapi:"primary,posts"`
+	Title string `jsonapi:"attr,title"`
+}
+
+func TestGetAllFollowsNextLinks(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		if r.Header.Get("Accept") != jsonapi.MediaType {
+			t.Errorf("Accept %q", r.Header.Get("Accept"))
+		}
+		w.Header().Set("Content-Type", jsonapi.MediaType)
+		if r.URL.Query().Get("page[number]") == "" {
+			fmt.Fprint(w, `{"data":[{"type":"posts","id":"1","attributes":{"title":"a"}}],`+
+				`"links":{"next":"/posts?page%5Bnumber%5D=2"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":[{"type":"posts","id":"2","attributes":{"title":"b"}}]}`)
+	}))
+	defer srv.Close()
+
+	var posts []*post
+	q := new(jsonapi.Query).SortBy("-title")
+	if err := New(srv.URL).GetAll(context.Background(), "/posts", q, &posts); err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 || posts[0].ID != "1" || posts[1].Title != "b" {
+		t.Errorf("posts %+v", posts)
+	}
+	if len(queries) != 2 || queries[0] != "sort=-title" {
+		t.Errorf("queries %q", queries)
+	}
+}
+
+func TestGetAllExpectsSlice(t *testing.T) {
+	var p post
+	if err := New("http://example.com").GetAll(context.Background(), "/posts", nil, &p); err != jsonapi.ErrExpectedSlice {
+		t.Errorf("error %v, want ErrExpectedSlice", err)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != jsonapi.MediaType ||
+			r.Header.Get("Authorization") != "Bearer t" {
+			t.Errorf("%s with headers %v", r.Method, r.Header)
+		}
+		if len(body) == 0 {
+			t.Error("empty request body")
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"data":{"type":"posts","id":"7","attributes":{"title":"hi"}}}`)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL + "/")
+	c.Header = http.Header{"Authorization": {"Bearer t"}}
+	p := &post{Title: "hi"}
+	if err := c.Create(context.Background(), "posts", p, p); err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != "7" {
+		t.Errorf("id %q, want 7", p.ID)
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		fmt.Fprint(w, `{"errors":[{"title":"Invalid","detail":"title is blank"}]}`)
+	}))
+	defer srv.Close()
+
+	err := New(srv.URL).Delete(context.Background(), "/posts/1")
+	e, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("error %v, want *Error", err)
+	}
+	if e.StatusCode != http.StatusUnprocessableEntity || len(e.Errors) != 1 {
+		t.Errorf("error %+v", e)
+	}
+	if got, want := e.Error(), "jsonapi client: 422: Invalid title is blank"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestURL(t *testing.T) {
+	c := New("http://example.com/api/")
+	tests := []struct {
+		path string
+		q    *jsonapi.Query
+		want string
+	}{
+		{"/posts", nil, "http://example.com/api/posts"},
+		{"posts?x=1", new(jsonapi.Query).Including("author"), "http://example.com/api/posts?x=1&include=author"},
+		{"posts", new(jsonapi.Query), "http://example.com/api/posts"},
+	}
+	for _, tt := range tests {
+		if got := c.url(tt.path, tt.q); got != tt.want {
+			t.Errorf("url(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	if got, want := c.resolve("/posts?page=2"), "http://example.com/posts?page=2"; got != want {
+		t.Errorf("resolve = %q, want %q", got, want)
+	}
+	if got, want := c.resolve("posts?page=2"), "http://example.com/api/posts?page=2"; got != want {
+		t.Errorf("resolve = %q, want %q", got, want)
+	}
+}
+
+func TestLinkHref(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want string
+	}{
+		{"/a", "/a"},
+		{map[string]interface{}{"href": "/b"}, "/b"},
+		{jsonapi.Link{Href: "/c"}, "/c"},
+		{nil, ""},
+		{42, ""},
+	}
+	for _, tt := range tests {
+		if got := linkHref(tt.in); got != tt.want {
+			t.Errorf("linkHref(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
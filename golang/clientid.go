@@ -0,0 +1,120 @@
+package jsonapi
+
+import "errors"
+
+// ErrClientGeneratedID is the error for create requests whose resource has
+// an id although the server does not accept client-generated ids, answered
+// with 403 Forbidden as the specification requires. Handlers return it
+// after checking the ResourceIdentity of the resource.
+var ErrClientGeneratedID = errors.New("jsonapi: client-generated ids are not supported")
+
+// ResourceIdentity is how a resource of a document being unmarshaled
+// identifies itself, which create handlers need in order to honor or
+// reject client-generated ids.
+type ResourceIdentity struct {
+	Type string
+	// ID is the id member, "" if absent.
+	ID string
+	// LID is the local id: the lid member or, from older clients, the
+	// client-id member. It is also stored in the client-id field.
+	LID string
+}
+
+// HasID reports whether the client supplied an id.
+func (i ResourceIdentity) HasID() bool {
+	return i.ID != ""
+}
+
+// CollectIdentities makes UnmarshalPayload and UnmarshalManyPayload store
+// the ResourceIdentity of each resource of the primary data in dst, in
+// order:
+//
+//	var ids []jsonapi.ResourceIdentity
+//	err := jsonapi.UnmarshalPayload(r.Body, appt, jsonapi.CollectIdentities(&ids))
+//	...
+//	if len(ids) == 1 && ids[0].HasID() {
+//		jsonapi.WriteError(w, jsonapi.ErrClientGeneratedID)
+//		return
+//	}
+func CollectIdentities(dst *[]ResourceIdentity) UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.identities = dst
+	}
+}
+
+// collectIdentities implements CollectIdentities.
+func (o *unmarshalOptions) collectIdentities(nodes ...*Node) {
+	if o.identities == nil {
+		return
+	}
+	ids := make([]ResourceIdentity, 0, len(nodes))
+	for _, n := range nodes {
+		if n != nil {
+			ids = append(ids, ResourceIdentity{Type: n.Type, ID: n.ID, LID: n.ClientID})
+		}
+	}
+	*o.identities = ids
+}
+
+// lidNode decodes a resource object along with its lid member, which Node
+// does not hold.
+type lidNode struct {
+	*Node
+	LID string `json:"lid,omitempty"`
+}
+
+// node returns the decoded Node, whose ClientID is the lid unless it had a
+// client-id member.
+func (n *lidNode) node() *Node {
+	if n == nil {
+		return nil
+	}
+	if n.Node == nil {
+		n.Node = new(Node)
+	}
+	if n.ClientID == "" {
+		n.ClientID = n.LID
+	}
+	return n.Node
+}
+
+func lidNodes(nodes []*lidNode) []*Node {
+	if nodes == nil {
+		return nil
+	}
+	decoded := make([]*Node, len(nodes))
+	for i, n := range nodes {
+		decoded[i] = n.node()
+	}
+	return decoded
+}
+
+// withLIDs returns the value to decode into instead of v, a *OnePayload,
+// *ManyPayload or *Node, so that lid members are kept, and the function
+// storing them in v once decoded. Other values are returned as they are.
+func withLIDs(v interface{}) (interface{}, func()) {
+	switch p := v.(type) {
+	case *OnePayload:
+		aux := &struct {
+			*OnePayload
+			Data     *lidNode   `json:"data"`
+			Included []*lidNode `json:"included,omitempty"`
+		}{OnePayload: p}
+		return aux, func() {
+			p.Data, p.Included = aux.Data.node(), lidNodes(aux.Included)
+		}
+	case *ManyPayload:
+		aux := &struct {
+			*ManyPayload
+			Data     []*lidNode `json:"data"`
+			Included []*lidNode `json:"included,omitempty"`
+		}{ManyPayload: p}
+		return aux, func() {
+			p.Data, p.Included = lidNodes(aux.Data), lidNodes(aux.Included)
+		}
+	case *Node:
+		aux := &lidNode{Node: p}
+		return aux, func() { aux.node() }
+	}
+	return v, func() {}
+}
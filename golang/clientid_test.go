@@ -0,0 +1,90 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type lidComment struct {
+	ID       string `jsonapi:"primary,comments"`
+	ClientID string `jsonapi:"client-id"`
+	Body     string `jsonapi:"attr,body"`
+}
+
+func TestUnmarshalLID(t *testing.T) {
+	tests := []struct {
+		name, members, want string
+	}{
+		{"lid", `"lid": "tmp-1"`, "tmp-1"},
+		{"client-id", `"client-id": "old-1"`, "old-1"},
+		{"both", `"lid": "tmp-1", "client-id": "old-1"`, "old-1"},
+		{"none", `"id": "1"`, ""},
+	}
+	for _, tt := range tests {
+		doc := `{"data": {"type": "comments", ` + tt.members + `, "attributes": {"body": "hi"}}}`
+		var c lidComment
+		if err := UnmarshalPayload(strings.NewReader(doc), &c); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if c.ClientID != tt.want || c.Body != "hi" {
+			t.Errorf("%s: %+v, want client id %q", tt.name, c, tt.want)
+		}
+	}
+}
+
+func TestCollectIdentities(t *testing.T) {
+	var ids []ResourceIdentity
+	doc := `{"data": {"type": "comments", "lid": "tmp-1"}}`
+	if err := UnmarshalPayload(strings.NewReader(doc), new(lidComment), CollectIdentities(&ids)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []ResourceIdentity{{Type: "comments", LID: "tmp-1"}}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("identities %+v, want %+v", ids, want)
+	}
+	if ids[0].HasID() {
+		t.Error("HasID without an id")
+	}
+
+	doc = `{"data": [
+		{"type": "comments", "id": "1"},
+		{"type": "comments", "lid": "tmp-2"}
+	], "included": [{"type": "comments", "id": "9", "lid": "tmp-9"}]}`
+	if _, err := UnmarshalManyPayload(strings.NewReader(doc), reflect.TypeOf(new(lidComment)),
+		CollectIdentities(&ids)); err != nil {
+		t.Fatal(err)
+	}
+	want := []ResourceIdentity{{Type: "comments", ID: "1"}, {Type: "comments", LID: "tmp-2"}}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("identities %+v, want %+v, the primary data only", ids, want)
+	}
+	if !ids[0].HasID() {
+		t.Error("HasID false with an id")
+	}
+
+	// A null primary data has no identity.
+	if err := UnmarshalPayload(strings.NewReader(`{"data": null}`), new(lidComment), CollectIdentities(&ids)); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 0 {
+		t.Errorf("identities %+v, want none", ids)
+	}
+}
+
+func TestWithLIDs(t *testing.T) {
+	var n Node
+	target, store := withLIDs(&n)
+	if err := json.Unmarshal([]byte(`{"type": "comments", "lid": "tmp-1"}`), target); err != nil {
+		t.Fatal(err)
+	}
+	store()
+	if n.Type != "comments" || n.ClientID != "tmp-1" {
+		t.Errorf("node %+v", n)
+	}
+
+	var m map[string]interface{}
+	if target, _ := withLIDs(&m); target != &m {
+		t.Errorf("withLIDs(%T) = %T, want the value itself", &m, target)
+	}
+}
@@ -0,0 +1,251 @@
+// Command jsonapi-bench measures the marshal and unmarshal paths of the
+// jsonapi package and guards them against performance regressions.
+//
+// Usage:
+//
+//	jsonapi-bench [-bench regexp] [-count n] [-compare old.txt] [-threshold pct]
+//
+// Results are printed in the format of go test -bench with allocation
+// reporting, so that runs saved to files can be compared with benchstat:
+//
+//	jsonapi-bench -count 10 > old.txt
+//	# change the code
+//	jsonapi-bench -count 10 > new.txt
+//	benchstat old.txt new.txt
+//
+// With -compare, the medians of the current run are also checked against a
+// saved run and the command exits with status 1 if the time or the
+// allocations per operation of any benchmark grew by more than -threshold
+// percent, which makes it usable as a CI gate where benchstat is not
+// available.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	jsonapi "test3"
+	"test3/internal/benchmodels"
+)
+
+type benchmark struct {
+	name string
+	fn   func(b *testing.B)
+}
+
+// benchmarks build their models on first use, so that listing or
+// filtering them costs nothing.
+var benchmarks = []benchmark{
+	{"MarshalSmall", marshalBench(func() interface{} { return benchmodels.NewPost(0) })},
+	{"UnmarshalSmall", unmarshalOneBench(func() interface{} { return benchmodels.NewPost(0) },
+		func() interface{} { return new(benchmodels.Post) })},
+	{"MarshalSlice10k", marshalBench(func() interface{} { return benchmodels.NewPosts(10000) })},
+	{"UnmarshalSlice10k", unmarshalManyBench(func() interface{} { return benchmodels.NewPosts(10000) },
+		reflect.TypeOf(new(benchmodels.Post)))},
+	{"MarshalDeepRelationships", marshalBench(func() interface{} { return benchmodels.NewChain(20) })},
+	{"UnmarshalDeepRelationships", unmarshalOneBench(func() interface{} { return benchmodels.NewChain(20) },
+		func() interface{} { return new(benchmodels.Link) })},
+	{"MarshalWideAttributes", marshalBench(benchmodels.NewWide)},
+	{"UnmarshalWideAttributes", unmarshalOneBench(benchmodels.NewWide,
+		func() interface{} { return reflect.New(benchmodels.WideType()).Interface() })},
+}
+
+func main() {
+	pattern := flag.String("bench", ".", "run only the benchmarks matching `regexp`")
+	count := flag.Int("count", 1, "run each benchmark `n` times")
+	compare := flag.String("compare", "", "check the results against a saved run in `file`")
+	threshold := flag.Float64("threshold", 10, "regression tolerated by -compare, in `percent`")
+	flag.Parse()
+
+	re, err := regexp.Compile(*pattern)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	var old map[string]*samples
+	if *compare != "" {
+		data, err := ioutil.ReadFile(*compare)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		old = parseResults(data)
+	}
+
+	var out bytes.Buffer
+	fmt.Printf("goos: %s\ngoarch: %s\npkg: jsonapi\n", runtime.GOOS, runtime.GOARCH)
+	for _, bm := range benchmarks {
+		if !re.MatchString(bm.name) {
+			continue
+		}
+		for i := 0; i < *count; i++ {
+			r := testing.Benchmark(bm.fn)
+			line := fmt.Sprintf("Benchmark%s-%d\t%s\t%s\n",
+				bm.name, runtime.GOMAXPROCS(0), r.String(), r.MemString())
+			fmt.Print(line)
+			out.WriteString(line)
+		}
+	}
+
+	if old == nil {
+		return
+	}
+	if regressions := compareResults(old, parseResults(out.Bytes()), *threshold); len(regressions) > 0 {
+		for _, r := range regressions {
+			fmt.Fprintln(os.Stderr, r)
+		}
+		os.Exit(1)
+	}
+}
+
+func marshalBench(build func() interface{}) func(b *testing.B) {
+	var once sync.Once
+	var model interface{}
+	return func(b *testing.B) {
+		once.Do(func() { model = build() })
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := jsonapi.MarshalPayload(ioutil.Discard, model); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func unmarshalOneBench(build func() interface{}, newModel func() interface{}) func(b *testing.B) {
+	var once sync.Once
+	var doc []byte
+	return func(b *testing.B) {
+		once.Do(func() { doc = encode(build()) })
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := jsonapi.UnmarshalPayload(bytes.NewReader(doc), newModel()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func unmarshalManyBench(build func() interface{}, t reflect.Type) func(b *testing.B) {
+	var once sync.Once
+	var doc []byte
+	return func(b *testing.B) {
+		once.Do(func() { doc = encode(build()) })
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := jsonapi.UnmarshalManyPayload(bytes.NewReader(doc), t); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func encode(model interface{}) []byte {
+	var buf bytes.Buffer
+	if err := jsonapi.MarshalPayload(&buf, model); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+// samples holds the ns/op and allocs/op measurements of one benchmark.
+type samples struct {
+	ns, allocs []float64
+}
+
+// parseResults reads benchmark lines in the go test -bench format.
+func parseResults(data []byte) map[string]*samples {
+	results := map[string]*samples{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		name := fields[0]
+		if i := strings.LastIndex(name, "-"); i > 0 {
+			name = name[:i]
+		}
+
+		s := results[name]
+		if s == nil {
+			s = &samples{}
+			results[name] = s
+		}
+		for i := 2; i+1 < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				continue
+			}
+			switch fields[i+1] {
+			case "ns/op":
+				s.ns = append(s.ns, v)
+			case "allocs/op":
+				s.allocs = append(s.allocs, v)
+			}
+		}
+	}
+	return results
+}
+
+// compareResults describes every benchmark of cur whose median time or
+// allocations per operation exceed those of old by more than threshold
+// percent.
+func compareResults(old, cur map[string]*samples, threshold float64) []string {
+	names := make([]string, 0, len(cur))
+	for name := range cur {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var regressions []string
+	for _, name := range names {
+		prev, ok := old[name]
+		if !ok {
+			continue
+		}
+		for _, m := range []struct {
+			unit     string
+			old, cur []float64
+		}{
+			{"ns/op", prev.ns, cur[name].ns},
+			{"allocs/op", prev.allocs, cur[name].allocs},
+		} {
+			if len(m.old) == 0 || len(m.cur) == 0 {
+				continue
+			}
+			before, after := median(m.old), median(m.cur)
+			if before > 0 && (after-before)/before*100 > threshold {
+				regressions = append(regressions, fmt.Sprintf("%s: %s regressed from %.0f to %.0f (+%.1f%%)",
+					name, m.unit, before, after, (after-before)/before*100))
+			}
+		}
+	}
+	return regressions
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const oldRun = `goos: linux
+goarch: amd64
+pkg: jsonapi
+BenchmarkMarshalSmall-8   	  100000	      1000 ns/op	     512 B/op	      10 allocs/op
+BenchmarkMarshalSmall-8   	  100000	      1200 ns/op	     512 B/op	      10 allocs/op
+BenchmarkMarshalSmall-8   	  100000	      1100 ns/op	     512 B/op	      10 allocs/op
+BenchmarkUnmarshalSmall-8 	   50000	      2000 ns/op	    1024 B/op	      20 allocs/op
+PASS
+`
+
+func TestParseResults(t *testing.T) {
+	got := parseResults([]byte(oldRun))
+	want := map[string]*samples{
+		"BenchmarkMarshalSmall":   {ns: []float64{1000, 1200, 1100}, allocs: []float64{10, 10, 10}},
+		"BenchmarkUnmarshalSmall": {ns: []float64{2000}, allocs: []float64{20}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseResults = %v, want %v", got, want)
+	}
+}
+
+func TestCompareResults(t *testing.T) {
+	old := parseResults([]byte(oldRun))
+	cur := parseResults([]byte(`
+BenchmarkMarshalSmall-4   	  100000	      1150 ns/op	     512 B/op	      12 allocs/op
+BenchmarkUnmarshalSmall-4 	   50000	      2500 ns/op	    1024 B/op	      20 allocs/op
+BenchmarkNew-4            	   50000	      9999 ns/op	    1024 B/op	      99 allocs/op
+`))
+
+	got := compareResults(old, cur, 10)
+	want := []string{
+		"BenchmarkMarshalSmall: allocs/op regressed from 10 to 12 (+20.0%)",
+		"BenchmarkUnmarshalSmall: ns/op regressed from 2000 to 2500 (+25.0%)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("compareResults = %q, want %q", got, want)
+	}
+
+	if got := compareResults(old, cur, 30); len(got) != 0 {
+		t.Errorf("compareResults within the threshold = %q", got)
+	}
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   float64
+	}{
+		{[]float64{3}, 3},
+		{[]float64{3, 1, 2}, 2},
+		{[]float64{4, 1, 3, 2}, 2.5},
+	}
+	for _, tt := range tests {
+		if got := median(tt.values); got != tt.want {
+			t.Errorf("median(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestBenchmarksRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs every benchmark once")
+	}
+	for _, bm := range benchmarks {
+		if r := testing.Benchmark(bm.fn); r.N == 0 {
+			t.Errorf("%s did not run", bm.name)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Command jsonapi-gen generates Go structs with jsonapi tags from a sample
+// JSON:API document.
+//
+// Usage:
+//
+//	jsonapi-gen [-pkg name] [-o out.go] [sample.json]
+//
+// The sample is read from standard input when no file is given, and the
+// source is written to standard output unless -o is set. See
+// jsonapi.GenerateStructs for how types are inferred.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	jsonapi "test3"
+)
+
+func main() {
+	pkg := flag.String("pkg", "models", "package name of the generated source")
+	out := flag.String("o", "", "write the source to `file` instead of standard output")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: jsonapi-gen [-pkg name] [-o out.go] [sample.json]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var in io.Reader = os.Stdin
+	switch flag.NArg() {
+	case 0:
+	case 1:
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		defer f.Close()
+		in = f
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	src, err := jsonapi.GenerateStructs(in, *pkg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := ioutil.WriteFile(*out, src, 0666); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
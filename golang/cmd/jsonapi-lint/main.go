@@ -0,0 +1,365 @@
+// Command jsonapi-lint checks the jsonapi struct tags in Go source files.
+//
+// Usage:
+//
+//	jsonapi-lint [dir|file.go ...]
+//
+// Each argument is either a Go source file or a directory whose .go files
+// are checked. With no arguments the current directory is used. Problems
+// are printed one per line as file:line: message and the command exits
+// with status 1 if any were found.
+//
+// The checks are syntactic: field types are judged from their source
+// expression, so models spread over several packages are best checked at
+// runtime with jsonapi.CheckModel.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	jsonapi "test3"
+)
+
+var idTypes = map[string]bool{
+	"string": true,
+	"int":    true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+}
+
+// predeclared lists the predeclared type names other than idTypes. None of
+// them is a struct or implements encoding.TextMarshaler.
+var predeclared = map[string]bool{
+	"any": true, "bool": true, "byte": true, "complex64": true, "complex128": true,
+	"error": true, "float32": true, "float64": true, "rune": true, "uintptr": true,
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: jsonapi-lint [dir|file.go ...]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	for _, p := range paths {
+		files, err := sourceFiles(p)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		for _, name := range files {
+			f, err := parser.ParseFile(fset, name, nil, 0)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+			parsed = append(parsed, f)
+		}
+	}
+
+	types, texts := declaredTypes(parsed), textTypes(parsed)
+	var problems []string
+	for _, f := range parsed {
+		problems = append(problems, lintFile(fset, f, types, texts)...)
+	}
+
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
+
+func sourceFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// declaredTypes maps the names of the types declared in files to their
+// definitions; relation fields may use interfaces in place of struct
+// pointers, and structs by value.
+func declaredTypes(files []*ast.File) map[string]ast.Expr {
+	types := map[string]ast.Expr{}
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			if spec, ok := n.(*ast.TypeSpec); ok {
+				types[spec.Name.Name] = spec.Type
+			}
+			return true
+		})
+	}
+	return types
+}
+
+// textTypes returns the names of the types declared in files with both a
+// MarshalText and an UnmarshalText method, which is what jsonapi.CheckModel
+// asks of named primary types.
+func textTypes(files []*ast.File) map[string]bool {
+	marshal, unmarshal := map[string]bool{}, map[string]bool{}
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || len(fn.Recv.List) != 1 {
+				continue
+			}
+			recv := fn.Recv.List[0].Type
+			if star, ok := recv.(*ast.StarExpr); ok {
+				recv = star.X
+			}
+			ident, ok := recv.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			switch fn.Name.Name {
+			case "MarshalText":
+				marshal[ident.Name] = true
+			case "UnmarshalText":
+				unmarshal[ident.Name] = true
+			}
+		}
+	}
+
+	texts := map[string]bool{}
+	for name := range marshal {
+		texts[name] = unmarshal[name]
+	}
+	return texts
+}
+
+func lintFile(fset *token.FileSet, f *ast.File, types map[string]ast.Expr, texts map[string]bool) []string {
+	var problems []string
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		spec, ok := n.(*ast.TypeSpec)
+		if !ok {
+			return true
+		}
+		st, ok := spec.Type.(*ast.StructType)
+		if !ok {
+			return true
+		}
+
+		report := func(pos token.Pos, format string, args ...interface{}) {
+			problems = append(problems, fmt.Sprintf("%s: %s: %s",
+				fset.Position(pos), spec.Name.Name, fmt.Sprintf(format, args...)))
+		}
+
+		var tagged bool
+		var primaries int
+		type member struct {
+			field string
+			args  []string
+		}
+		members := map[string]member{}
+
+		for _, field := range st.Fields.List {
+			tag := jsonapiTag(field)
+			if tag == "" {
+				continue
+			}
+
+			name := fieldName(field)
+			if err := jsonapi.CheckTag(tag); err != nil {
+				tagged = true
+				report(field.Pos(), "%s: %v", name, err)
+				continue
+			}
+			if tag == "-" {
+				// Ignored fields are left out, like untagged ones.
+				continue
+			}
+			tagged = true
+
+			args := strings.Split(tag, ",")
+			switch args[0] {
+			case "primary":
+				primaries++
+				if len(args) > 1 && args[1] != "" {
+					if err := jsonapi.CheckMemberName(args[1]); err != nil {
+						report(field.Pos(), "%s: resource type: %v", name, err)
+					}
+				}
+				if !isIDExpr(field.Type, types, texts) {
+					report(field.Pos(), "%s: primary field must be a string, int, uint or text marshaler type", name)
+				}
+			case "attr", "relation":
+				if err := jsonapi.CheckMemberName(args[1]); err != nil {
+					report(field.Pos(), "%s: %v", name, err)
+				} else if args[1] == "type" || args[1] == "id" {
+					report(field.Pos(), "%s: member name %q is reserved", name, args[1])
+				}
+				// Fields for different API versions may share a name; the
+				// ranges are left to jsonapi.CheckModel.
+				if other, ok := members[args[1]]; ok && !isVersioned(args) && !isVersioned(other.args) {
+					report(field.Pos(), "%s: member name %q is already used by field %s",
+						name, args[1], other.field)
+				} else {
+					members[args[1]] = member{name, args}
+				}
+				if args[0] == "relation" && hasIDsOption(args) {
+					if !isIDsRelationExpr(field.Type, types, texts) {
+						report(field.Pos(),
+							"%s: ids relation field must be an id type, a pointer or a slice of one", name)
+					}
+				} else if args[0] == "relation" && !isRelationExpr(field.Type, types) {
+					report(field.Pos(),
+						"%s: relation field must be a struct, a struct pointer, an interface, or a slice or string-keyed map of pointers or interfaces", name)
+				}
+			}
+		}
+
+		if tagged && primaries == 0 {
+			report(spec.Pos(), "missing primary annotation")
+		} else if primaries > 1 {
+			report(spec.Pos(), "%d primary annotations, expected exactly one", primaries)
+		}
+
+		return true
+	})
+
+	return problems
+}
+
+func jsonapiTag(field *ast.Field) string {
+	if field.Tag == nil {
+		return ""
+	}
+	raw, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	return reflect.StructTag(raw).Get("jsonapi")
+}
+
+func fieldName(field *ast.Field) string {
+	if len(field.Names) == 0 {
+		return "(embedded)"
+	}
+	return field.Names[0].Name
+}
+
+// isIDExpr applies the rules of jsonapi.CheckModel: it accepts the
+// predeclared string and integer types, and named types declared in the
+// checked files only if they have MarshalText and UnmarshalText methods.
+// Named types declared elsewhere, such as primitive.ObjectID, may
+// implement encoding.TextMarshaler; whether they do is left to
+// jsonapi.CheckModel.
+func isIDExpr(expr ast.Expr, types map[string]ast.Expr, texts map[string]bool) bool {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch x := expr.(type) {
+	case *ast.Ident:
+		if _, ok := types[x.Name]; ok {
+			return texts[x.Name]
+		}
+		return idTypes[x.Name] || !predeclared[x.Name]
+	case *ast.SelectorExpr:
+		return true
+	}
+	return false
+}
+
+func hasIDsOption(args []string) bool {
+	for _, arg := range args[2:] {
+		if arg == "ids" || strings.HasPrefix(arg, "ids=") {
+			return true
+		}
+	}
+	return false
+}
+
+func isVersioned(args []string) bool {
+	for _, arg := range args[2:] {
+		if strings.HasPrefix(arg, "since=") || strings.HasPrefix(arg, "until=") {
+			return true
+		}
+	}
+	return false
+}
+
+// isIDsRelationExpr accepts the id types of isIDExpr, a pointer or a slice
+// of one.
+func isIDsRelationExpr(expr ast.Expr, types map[string]ast.Expr, texts map[string]bool) bool {
+	if arr, ok := expr.(*ast.ArrayType); ok {
+		if arr.Len != nil {
+			return false
+		}
+		expr = arr.Elt
+	}
+	return isIDExpr(expr, types, texts)
+}
+
+// isRelationExpr accepts a struct, a struct pointer or an interface, or a
+// slice or string-keyed map of pointers or interfaces. Types from other
+// packages, and types declared outside the checked files, are assumed to
+// be structs.
+func isRelationExpr(expr ast.Expr, types map[string]ast.Expr) bool {
+	switch x := expr.(type) {
+	case *ast.ArrayType:
+		if x.Len != nil {
+			return false
+		}
+		expr = x.Elt
+	case *ast.MapType:
+		if key, ok := x.Key.(*ast.Ident); !ok || key.Name != "string" {
+			return false
+		}
+		expr = x.Value
+	default:
+		// Only to-one relations hold structs by value.
+		if isStructExpr(expr, types) {
+			return true
+		}
+	}
+
+	if ident, ok := expr.(*ast.Ident); ok {
+		_, ok := types[ident.Name].(*ast.InterfaceType)
+		return ok
+	}
+	star, ok := expr.(*ast.StarExpr)
+	return ok && isStructExpr(star.X, types)
+}
+
+func isStructExpr(expr ast.Expr, types map[string]ast.Expr) bool {
+	switch x := expr.(type) {
+	case *ast.Ident:
+		if def, ok := types[x.Name]; ok {
+			_, ok := def.(*ast.StructType)
+			return ok
+		}
+		return !idTypes[x.Name] && !predeclared[x.Name]
+	case *ast.SelectorExpr, *ast.StructType:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+const lintSource = `package models
+
+type Node interface{ Name() string }
+
+type Good struct {
+	ID       string            ` + "`jsonapi:\"primary,goods\"`" + `
+	Name     string            ` + "`jsonapi:\"attr,name\"`" + `
+	Parent   *Good             ` + "`jsonapi:\"relation,parent\"`" + `
+	Origin   Place             ` + "`jsonapi:\"relation,origin\"`" + `
+	Children []Node            ` + "`jsonapi:\"relation,children\"`" + `
+	Sites    map[string]*Place ` + "`jsonapi:\"relation,sites\"`" + `
+	Peers    map[string]Node   ` + "`jsonapi:\"relation,peers\"`" + `
+	TagIDs   []int             ` + "`jsonapi:\"relation,tags,ids\"`" + `
+	Old      string            ` + "`jsonapi:\"attr,title,until=2\"`" + `
+	New      string            ` + "`jsonapi:\"attr,title,since=2\"`" + `
+	Cache    []byte            ` + "`jsonapi:\"-\"`" + `
+	Plain    string
+}
+
+type Place struct {
+	ID string ` + "`jsonapi:\"primary,places\"`" + `
+}
+
+type Status string
+
+type Key [12]byte
+
+func (k Key) MarshalText() ([]byte, error) { return k[:], nil }
+
+func (k *Key) UnmarshalText(b []byte) error { copy(k[:], b); return nil }
+
+type Keyed struct {
+	ID    Key                ` + "`jsonapi:\"primary,keyed\"`" + `
+	Owner primitive.ObjectID ` + "`jsonapi:\"relation,owners,ids\"`" + `
+}
+
+type Document struct {
+	ID *primitive.ObjectID ` + "`jsonapi:\"primary,documents\"`" + `
+}
+
+type Bad struct {
+	ID      float64        ` + "`jsonapi:\"primary,bad.things\"`" + `
+	Dotted  string         ` + "`jsonapi:\"attr,first.name\"`" + `
+	Type    string         ` + "`jsonapi:\"attr,type\"`" + `
+	Again   string         ` + "`jsonapi:\"attr,first-name\"`" + `
+	Twice   string         ` + "`jsonapi:\"attr,first-name\"`" + `
+	Owner   string         ` + "`jsonapi:\"relation,owner\"`" + `
+	OwnerID float64        ` + "`jsonapi:\"relation,owners,ids\"`" + `
+	State   Status         ` + "`jsonapi:\"relation,state\"`" + `
+	Places  []Place        ` + "`jsonapi:\"relation,places\"`" + `
+	ByRank  map[int]*Place ` + "`jsonapi:\"relation,ranked\"`" + `
+	Broken  string         ` + "`jsonapi:\"bogus,x\"`" + `
+}
+
+type Slugged struct {
+	ID Status ` + "`jsonapi:\"primary,slugs\"`" + `
+}
+
+type NoPrimary struct {
+	Name string ` + "`jsonapi:\"attr,name\"`" + `
+}
+`
+
+func lint(t *testing.T, src string) []string {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "models.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []*ast.File{f}
+	return lintFile(fset, f, declaredTypes(files), textTypes(files))
+}
+
+func TestLintFile(t *testing.T) {
+	problems := lint(t, lintSource)
+
+	want := []string{
+		"Bad: ID: resource type:",
+		"Bad: ID: primary field must be",
+		"Bad: Dotted:",
+		`Bad: Type: member name "type" is reserved`,
+		`Bad: Twice: member name "first-name" is already used by field Again`,
+		"Bad: Owner: relation field must be",
+		"Bad: OwnerID: ids relation field must be",
+		"Bad: State: relation field must be",
+		"Bad: Places: relation field must be",
+		"Bad: ByRank: relation field must be",
+		"Bad: Broken:",
+		"Slugged: ID: primary field must be",
+		"NoPrimary: missing primary annotation",
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("problems:\n%s\nwant %d", strings.Join(problems, "\n"), len(want))
+	}
+	for i, p := range problems {
+		if !strings.HasPrefix(p, "models.go:") || !strings.Contains(p, want[i]) {
+			t.Errorf("problem %d: %q, want it to contain %q", i, p, want[i])
+		}
+	}
+}
+
+func TestLintFileClean(t *testing.T) {
+	src := `package models
+
+type Unrelated struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+type Ignored struct {
+	Name  string ` + "`json:\"name\"`" + `
+	Cache []byte ` + "`json:\"-\" jsonapi:\"-\"`" + `
+}
+`
+	if problems := lint(t, src); len(problems) != 0 {
+		t.Errorf("problems %q", problems)
+	}
+}
@@ -0,0 +1,88 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Codec is the JSON implementation used to encode and decode documents.
+// The default is encoding/json; faster libraries can be swapped in per call
+// with WithCodec and UseCodec, or for the whole program by building with
+// one of these tags:
+//
+//	jsoniter   github.com/json-iterator/go
+//	go_json    github.com/goccy/go-json
+//	segmentio  github.com/segmentio/encoding/json
+//
+// A Codec must behave like encoding/json, honoring its struct tags and the
+// json.Marshaler and json.Unmarshaler interfaces. Only the document as a
+// whole goes through the Codec; attribute values that are re-encoded on
+// the way, such as struct and map attributes, still use encoding/json.
+type Codec interface {
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Encoder is the subset of json.Encoder used by the marshal functions.
+type Encoder interface {
+	Encode(v interface{}) error
+	SetIndent(prefix, indent string)
+	SetEscapeHTML(on bool)
+}
+
+// Decoder is the subset of json.Decoder used by the unmarshal functions.
+type Decoder interface {
+	Decode(v interface{}) error
+	UseNumber()
+}
+
+// StdCodec is the Codec backed by encoding/json.
+var StdCodec Codec = stdCodec{}
+
+// defaultCodec is replaced by the adapters selected with build tags.
+var defaultCodec = StdCodec
+
+type stdCodec struct{}
+
+func (stdCodec) NewEncoder(w io.Writer) Encoder { return json.NewEncoder(w) }
+func (stdCodec) NewDecoder(r io.Reader) Decoder { return json.NewDecoder(r) }
+
+// CodecError is returned for documents that the Decoder of a third-party
+// codec rejects, as those do not use the error types of encoding/json.
+// StatusForError reports them as malformed documents.
+type CodecError struct {
+	Err error
+}
+
+func (e *CodecError) Error() string { return e.Err.Error() }
+
+func (e *CodecError) Unwrap() error { return e.Err }
+
+// codecDecoder wraps the errors of a third-party Decoder in *CodecError,
+// except io.EOF, which marks an empty document.
+type codecDecoder struct {
+	Decoder
+}
+
+func (d codecDecoder) Decode(v interface{}) error {
+	err := d.Decoder.Decode(v)
+	if err == nil || err == io.EOF {
+		return err
+	}
+	return &CodecError{Err: err}
+}
+
+// WithCodec makes MarshalPayload and the other writing functions encode the
+// document with c.
+func WithCodec(c Codec) MarshalOption {
+	return func(o *marshalOptions) {
+		o.codec = c
+	}
+}
+
+// UseCodec makes unmarshaling decode the document with c.
+func UseCodec(c Codec) UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.codec = c
+	}
+}
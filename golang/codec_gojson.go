@@ -0,0 +1,21 @@
+//go:build go_json && !jsoniter
+// +build go_json,!jsoniter
+
+package jsonapi
+
+import (
+	"io"
+
+	gojson "github.com/goccy/go-json"
+)
+
+func init() {
+	defaultCodec = goJSONCodec{}
+}
+
+type goJSONCodec struct{}
+
+func (goJSONCodec) NewEncoder(w io.Writer) Encoder { return gojson.NewEncoder(w) }
+func (goJSONCodec) NewDecoder(r io.Reader) Decoder {
+	return codecDecoder{gojson.NewDecoder(r)}
+}
@@ -0,0 +1,29 @@
+//go:build go_json && !jsoniter
+// +build go_json,!jsoniter
+
+package jsonapi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGoJSONCodec(t *testing.T) {
+	if _, ok := defaultCodec.(goJSONCodec); !ok {
+		t.Fatalf("default codec %T", defaultCodec)
+	}
+
+	err := UnmarshalPayload(strings.NewReader(`{"data": `), new(reqAuthor))
+	if got := StatusForError(err); got != http.StatusBadRequest {
+		t.Errorf("status %d for %v, want 400", got, err)
+	}
+	if _, err := Parse(strings.NewReader(`{"data": null, "included": null}`)); err != nil {
+		t.Errorf("null members: %v", err)
+	}
+	err = UnmarshalPayload(strings.NewReader(`{"data": {"type": "people", "id": "1"}}`), new(reqAuthor), MaxBodyBytes(8))
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("error %v, want ErrBodyTooLarge", err)
+	}
+}
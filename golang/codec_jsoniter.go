@@ -0,0 +1,46 @@
+//go:build jsoniter
+// +build jsoniter
+
+package jsonapi
+
+import (
+	"io"
+	"unsafe"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+func init() {
+	defaultCodec = jsoniterCodec{}
+	jsoniter.RegisterTypeEncoderFunc("*jsonapi.Node", encodeJsoniterNode, func(ptr unsafe.Pointer) bool {
+		return *(**Node)(ptr) == nil
+	})
+}
+
+// encodeJsoniterNode writes nodes without an attribute order as plain
+// structs: jsoniter copies the output of MarshalJSON as is, without the
+// indentation and HTML escaping of the encoder.
+func encodeJsoniterNode(ptr unsafe.Pointer, stream *jsoniter.Stream) {
+	n := *(**Node)(ptr)
+	if n == nil || n.attributeOrder == nil {
+		stream.WriteVal((*plainNode)(n))
+		return
+	}
+	b, err := n.MarshalJSON()
+	if err != nil {
+		stream.Error = err
+		return
+	}
+	stream.Write(b)
+}
+
+// jsoniterCodec uses jsoniter's encoding/json compatible configuration.
+type jsoniterCodec struct{}
+
+func (jsoniterCodec) NewEncoder(w io.Writer) Encoder {
+	return jsoniter.ConfigCompatibleWithStandardLibrary.NewEncoder(w)
+}
+
+func (jsoniterCodec) NewDecoder(r io.Reader) Decoder {
+	return codecDecoder{jsoniter.ConfigCompatibleWithStandardLibrary.NewDecoder(r)}
+}
@@ -0,0 +1,29 @@
+//go:build jsoniter
+// +build jsoniter
+
+package jsonapi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestJsoniterCodec(t *testing.T) {
+	if _, ok := defaultCodec.(jsoniterCodec); !ok {
+		t.Fatalf("default codec %T", defaultCodec)
+	}
+
+	err := UnmarshalPayload(strings.NewReader(`{"data": `), new(reqAuthor))
+	if got := StatusForError(err); got != http.StatusBadRequest {
+		t.Errorf("status %d for %v, want 400", got, err)
+	}
+	if _, err := Parse(strings.NewReader(`{"data": null, "included": null}`)); err != nil {
+		t.Errorf("null members: %v", err)
+	}
+	err = UnmarshalPayload(strings.NewReader(`{"data": {"type": "people", "id": "1"}}`), new(reqAuthor), MaxBodyBytes(8))
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("error %v, want ErrBodyTooLarge", err)
+	}
+}
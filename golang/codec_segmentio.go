@@ -0,0 +1,21 @@
+//go:build segmentio && !jsoniter && !go_json
+// +build segmentio,!jsoniter,!go_json
+
+package jsonapi
+
+import (
+	"io"
+
+	segmentio "github.com/segmentio/encoding/json"
+)
+
+func init() {
+	defaultCodec = segmentioCodec{}
+}
+
+type segmentioCodec struct{}
+
+func (segmentioCodec) NewEncoder(w io.Writer) Encoder { return segmentio.NewEncoder(w) }
+func (segmentioCodec) NewDecoder(r io.Reader) Decoder {
+	return codecDecoder{segmentio.NewDecoder(r)}
+}
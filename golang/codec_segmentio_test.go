@@ -0,0 +1,66 @@
+//go:build segmentio && !jsoniter && !go_json
+// +build segmentio,!jsoniter,!go_json
+
+package jsonapi
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSegmentioCodec(t *testing.T) {
+	if _, ok := defaultCodec.(segmentioCodec); !ok {
+		t.Fatalf("default codec %T", defaultCodec)
+	}
+
+	err := UnmarshalPayload(strings.NewReader(`{"data": `), new(reqAuthor))
+	var codecErr *CodecError
+	if !errors.As(err, &codecErr) {
+		t.Errorf("error %v, want a *CodecError", err)
+	}
+	if got := StatusForError(err); got != http.StatusBadRequest {
+		t.Errorf("status %d for %v, want 400", got, err)
+	}
+	if _, err := Parse(strings.NewReader(`{"data": null, "included": null}`)); err != nil {
+		t.Errorf("null members: %v", err)
+	}
+	err = UnmarshalPayload(strings.NewReader(`{"data": {"type": "people", "id": "1"}}`), new(reqAuthor), MaxBodyBytes(8))
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("error %v, want ErrBodyTooLarge", err)
+	}
+}
+
+func TestSegmentioRoundTrip(t *testing.T) {
+	created := time.Unix(1600000000, 0).UTC()
+	in := &reqArticle{
+		ID: "1", Title: "Hello", Views: 3, Score: 4.5, Published: true,
+		Created: created, Updated: created,
+		Author:   &reqAuthor{ID: "2", Name: "Ann"},
+		Comments: []*reqComment{{ID: 3, Body: "First"}, {ID: 4, Body: "Second"}},
+	}
+
+	var buf bytes.Buffer
+	if err := MarshalPayload(&buf, in); err != nil {
+		t.Fatal(err)
+	}
+	out := new(reqArticle)
+	if err := UnmarshalPayload(&buf, out); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.ID != in.ID || out.Title != in.Title || out.Views != in.Views ||
+		out.Score != in.Score || out.Published != in.Published ||
+		!out.Created.Equal(created) || !out.Updated.Equal(created) {
+		t.Errorf("attributes %+v, want %+v", out, in)
+	}
+	if out.Author == nil || *out.Author != *in.Author {
+		t.Errorf("author %+v, want %+v", out.Author, in.Author)
+	}
+	if len(out.Comments) != 2 || *out.Comments[0] != *in.Comments[0] || *out.Comments[1] != *in.Comments[1] {
+		t.Errorf("comments %+v, want %+v", out.Comments, in.Comments)
+	}
+}
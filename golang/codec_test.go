@@ -0,0 +1,164 @@
+package jsonapi
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// recordingCodec wraps StdCodec and records the calls made to it.
+type recordingCodec struct {
+	calls []string
+}
+
+func (c *recordingCodec) NewEncoder(w io.Writer) Encoder {
+	c.calls = append(c.calls, "NewEncoder")
+	return &recordingEncoder{StdCodec.NewEncoder(w), c}
+}
+
+func (c *recordingCodec) NewDecoder(r io.Reader) Decoder {
+	c.calls = append(c.calls, "NewDecoder")
+	return &recordingDecoder{StdCodec.NewDecoder(r), c}
+}
+
+type recordingEncoder struct {
+	Encoder
+	c *recordingCodec
+}
+
+func (e *recordingEncoder) Encode(v interface{}) error {
+	e.c.calls = append(e.c.calls, "Encode")
+	return e.Encoder.Encode(v)
+}
+
+type recordingDecoder struct {
+	Decoder
+	c *recordingCodec
+}
+
+func (d *recordingDecoder) UseNumber() {
+	d.c.calls = append(d.c.calls, "UseNumber")
+	d.Decoder.UseNumber()
+}
+
+func (d *recordingDecoder) Decode(v interface{}) error {
+	d.c.calls = append(d.c.calls, "Decode")
+	return d.Decoder.Decode(v)
+}
+
+func TestWithCodec(t *testing.T) {
+	c := new(recordingCodec)
+	var buf bytes.Buffer
+	if err := MarshalPayload(&buf, &reqAuthor{ID: "1", Name: "<Ann>"}, WithCodec(c),
+		WithIndent("", "  "), WithEscapeHTML(false)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"NewEncoder", "Encode"}; !equalStrings(c.calls, want) {
+		t.Errorf("calls %v, want %v", c.calls, want)
+	}
+	// The encoder options reach the codec's encoder.
+	if out := buf.String(); !strings.Contains(out, "\n  \"data\"") || !strings.Contains(out, "<Ann>") {
+		t.Errorf("document %s", out)
+	}
+}
+
+func TestCodecEncodesEveryDocument(t *testing.T) {
+	c := new(recordingCodec)
+	var buf bytes.Buffer
+	if err := MarshalErrors(&buf, []*ErrorObject{{Title: "<bad>"}}, WithCodec(c), WithEscapeHTML(false)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "<bad>") {
+		t.Errorf("errors document %s", buf.String())
+	}
+
+	payload, err := Marshal(&reqAuthor{ID: "1", Name: "Ann"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := EncodePayload(&buf, payload, WithCodec(c), WithoutTrailingNewline()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasSuffix(buf.String(), "\n") {
+		t.Errorf("document %q has a trailing newline", buf.String())
+	}
+	if want := []string{"NewEncoder", "Encode", "NewEncoder", "Encode"}; !equalStrings(c.calls, want) {
+		t.Errorf("calls %v, want %v", c.calls, want)
+	}
+}
+
+func TestUseCodec(t *testing.T) {
+	c := new(recordingCodec)
+	doc := `{"data": {"type": "people", "id": "1", "attributes": {"name": "Ann"}}}`
+	out := new(reqAuthor)
+	if err := UnmarshalPayload(strings.NewReader(doc), out, UseCodec(c), UseNumber()); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "Ann" {
+		t.Errorf("unmarshaled %+v", out)
+	}
+	if want := []string{"NewDecoder", "UseNumber", "Decode"}; !equalStrings(c.calls, want) {
+		t.Errorf("calls %v, want %v", c.calls, want)
+	}
+}
+
+func TestDefaultCodec(t *testing.T) {
+	if newMarshalOptions(nil).codec != defaultCodec || newUnmarshalOptions(nil).codec != defaultCodec {
+		t.Error("options do not default to defaultCodec")
+	}
+}
+
+// flatCodec mimics third-party codecs: its decoder reports every failure,
+// reader errors included, as an error of its own type.
+type flatCodec struct{}
+
+type flatError string
+
+func (e flatError) Error() string { return string(e) }
+
+func (flatCodec) NewEncoder(w io.Writer) Encoder { return StdCodec.NewEncoder(w) }
+
+func (flatCodec) NewDecoder(r io.Reader) Decoder {
+	return codecDecoder{flatDecoder{StdCodec.NewDecoder(r)}}
+}
+
+type flatDecoder struct {
+	Decoder
+}
+
+func (d flatDecoder) Decode(v interface{}) error {
+	if err := d.Decoder.Decode(v); err != nil {
+		return flatError("flat: " + err.Error())
+	}
+	return nil
+}
+
+func TestCodecErrors(t *testing.T) {
+	err := UnmarshalPayload(strings.NewReader(`{"data": `), new(reqAuthor), UseCodec(flatCodec{}))
+	var codecErr *CodecError
+	if !errors.As(err, &codecErr) || !strings.HasPrefix(codecErr.Error(), "flat: ") {
+		t.Fatalf("error %v, want a *CodecError", err)
+	}
+	var flat flatError
+	if !errors.As(err, &flat) {
+		t.Errorf("error %v does not unwrap to the codec's", err)
+	}
+	if got := StatusForError(err); got != http.StatusBadRequest {
+		t.Errorf("status %d, want 400", got)
+	}
+
+	// The size limit is told apart even though the codec hides it.
+	big := `{"data": {"type": "people", "id": "1", "attributes": {"name": "` + strings.Repeat("a", 100) + `"}}}`
+	err = UnmarshalPayload(strings.NewReader(big), new(reqAuthor), UseCodec(flatCodec{}), MaxBodyBytes(64))
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("error %v, want ErrBodyTooLarge", err)
+	}
+
+	if err := (codecDecoder{StdCodec.NewDecoder(strings.NewReader(""))}).Decode(new(Node)); err != io.EOF {
+		t.Errorf("error %v for an empty document, want io.EOF", err)
+	}
+}
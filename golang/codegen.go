@@ -0,0 +1,333 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// GenerateStructs reads a sample JSON:API document from in and returns Go
+// source declaring, in package pkg, one tagged struct per resource type
+// found in its primary data, its included resources and its resource
+// linkage. It is a starting point for clients of third-party APIs, to be
+// reviewed and edited by hand.
+//
+// Attribute types are inferred from every sample of the member: numbers
+// become int64 or float64, RFC 3339 strings time.Time, objects
+// map[string]interface{}, and members that are null in some sample become
+// pointers. Members missing from some samples are tagged omitempty.
+// Relationships whose linkage names a single resource type point at its
+// struct, others are interface{}; relationships without data in any sample
+// are left out, as their cardinality is unknown.
+func GenerateStructs(in io.Reader, pkg string) ([]byte, error) {
+	dec := json.NewDecoder(in)
+	dec.UseNumber()
+
+	var doc struct {
+		Data     interface{}   `json:"data"`
+		Included []interface{} `json:"included"`
+	}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var objects []interface{}
+	switch data := doc.Data.(type) {
+	case []interface{}:
+		objects = append(objects, data...)
+	case map[string]interface{}:
+		objects = append(objects, data)
+	}
+	objects = append(objects, doc.Included...)
+	if len(objects) == 0 {
+		return nil, errors.New("jsonapi: sample document has no resource objects")
+	}
+
+	g := &generator{types: map[string]*genType{}}
+	for _, obj := range objects {
+		if err := g.addResource(obj); err != nil {
+			return nil, err
+		}
+	}
+	return g.source(pkg)
+}
+
+type generator struct {
+	types map[string]*genType
+}
+
+// genType accumulates the samples of one resource type.
+type genType struct {
+	typ     string
+	samples int
+	attrs   map[string]*genAttr
+	rels    map[string]*genRel
+}
+
+type genAttr struct {
+	goType string // "" while only null has been seen
+	seen   int
+	null   bool
+}
+
+type genRel struct {
+	many    bool
+	targets map[string]bool
+}
+
+func (g *generator) genType(typ string) *genType {
+	t, ok := g.types[typ]
+	if !ok {
+		t = &genType{typ: typ, attrs: map[string]*genAttr{}, rels: map[string]*genRel{}}
+		g.types[typ] = t
+	}
+	return t
+}
+
+func (g *generator) addResource(obj interface{}) error {
+	m, ok := obj.(map[string]interface{})
+	if !ok {
+		return errors.New("jsonapi: resource object is not an object")
+	}
+	typ, _ := m["type"].(string)
+	if typ == "" {
+		return errors.New("jsonapi: resource object has no type")
+	}
+
+	t := g.genType(typ)
+	t.samples++
+
+	attrs, _ := m["attributes"].(map[string]interface{})
+	for name, v := range attrs {
+		a, ok := t.attrs[name]
+		if !ok {
+			a = &genAttr{}
+			t.attrs[name] = a
+		}
+		a.seen++
+		if v == nil {
+			a.null = true
+			continue
+		}
+		a.goType = mergeGoTypes(a.goType, sampleGoType(v))
+	}
+
+	rels, _ := m["relationships"].(map[string]interface{})
+	for name, v := range rels {
+		rel, _ := v.(map[string]interface{})
+		data, ok := rel["data"]
+		if !ok {
+			continue
+		}
+
+		r, ok := t.rels[name]
+		if !ok {
+			r = &genRel{targets: map[string]bool{}}
+			t.rels[name] = r
+		}
+
+		var linkage []interface{}
+		switch data := data.(type) {
+		case []interface{}:
+			r.many = true
+			linkage = data
+		case map[string]interface{}:
+			linkage = []interface{}{data}
+		}
+		for _, l := range linkage {
+			l, _ := l.(map[string]interface{})
+			if target, _ := l["type"].(string); target != "" {
+				r.targets[target] = true
+				g.genType(target)
+			}
+		}
+	}
+	return nil
+}
+
+// sampleGoType returns the Go type of a non-null JSON value.
+func sampleGoType(v interface{}) string {
+	switch v := v.(type) {
+	case bool:
+		return "bool"
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			return "float64"
+		}
+		return "int64"
+	case string:
+		if _, err := time.Parse(time.RFC3339, v); err == nil {
+			return "time.Time"
+		}
+		return "string"
+	case []interface{}:
+		elem := ""
+		for _, e := range v {
+			if e == nil {
+				return "[]interface{}"
+			}
+			elem = mergeGoTypes(elem, sampleGoType(e))
+		}
+		if strings.HasPrefix(elem, "[]") {
+			return "[]interface{}"
+		}
+		// "[]" stands for an empty array until a sample tells the
+		// element type.
+		return "[]" + elem
+	}
+	return "map[string]interface{}"
+}
+
+// mergeGoTypes returns a type that can hold samples of both a and b.
+func mergeGoTypes(a, b string) string {
+	switch {
+	case a == "" || a == b:
+		return b
+	case b == "":
+		return a
+	case a == "int64" && b == "float64", a == "float64" && b == "int64":
+		return "float64"
+	case a == "time.Time" && b == "string", a == "string" && b == "time.Time":
+		return "string"
+	case strings.HasPrefix(a, "[]") && strings.HasPrefix(b, "[]"):
+		return "[]" + strings.TrimPrefix(mergeGoTypes(a[2:], b[2:]), "[]")
+	}
+	return "interface{}"
+}
+
+// source renders the collected types as formatted Go source.
+func (g *generator) source(pkg string) ([]byte, error) {
+	names := map[string]string{}
+	used := map[string]bool{}
+	typs := make([]string, 0, len(g.types))
+	for typ := range g.types {
+		typs = append(typs, typ)
+	}
+	sort.Strings(typs)
+	for _, typ := range typs {
+		names[typ] = uniqueName(goIdentifier(singularize(typ)), used)
+	}
+	sort.Slice(typs, func(i, j int) bool { return names[typs[i]] < names[typs[j]] })
+
+	var body bytes.Buffer
+	var usesTime bool
+	for _, typ := range typs {
+		t := g.types[typ]
+		fields := map[string]bool{"ID": true}
+
+		fmt.Fprintf(&body, "\ntype %s struct {\n", names[typ])
+		fmt.Fprintf(&body, "ID string `jsonapi:\"primary,%s\"`\n", typ)
+
+		for _, name := range sortedMapKeys(t.attrs) {
+			a := t.attrs[name]
+			goType := a.goType
+			tag := annotationAttribute + annotationSeperator + name
+			switch {
+			case goType == "":
+				goType = "interface{}"
+			case goType == "[]":
+				goType = "[]interface{}"
+			case a.null && goType != "interface{}" && !strings.HasPrefix(goType, "[]") &&
+				!strings.HasPrefix(goType, "map["):
+				goType = "*" + goType
+			}
+			if strings.Contains(goType, "time.Time") {
+				usesTime = true
+				tag += annotationSeperator + annotationRFC3339
+			}
+			if a.seen < t.samples {
+				tag += annotationSeperator + annotationOmitEmpty
+			}
+			fmt.Fprintf(&body, "%s %s `jsonapi:%q`\n", uniqueName(goIdentifier(name), fields), goType, tag)
+		}
+
+		for _, name := range sortedMapKeys(t.rels) {
+			r := t.rels[name]
+			goType := "interface{}"
+			if len(r.targets) == 1 {
+				for target := range r.targets {
+					goType = "*" + names[target]
+				}
+			}
+			if r.many {
+				goType = "[]" + goType
+			}
+			tag := annotationRelation + annotationSeperator + name
+			fmt.Fprintf(&body, "%s %s `jsonapi:%q`\n", uniqueName(goIdentifier(name), fields), goType, tag)
+		}
+		body.WriteString("}\n")
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "package %s\n", pkg)
+	if usesTime {
+		src.WriteString("\nimport \"time\"\n")
+	}
+	src.Write(body.Bytes())
+	return format.Source(src.Bytes())
+}
+
+func sortedMapKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]*genAttr:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]*genRel:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// commonInitialisms are written in upper case in generated identifiers.
+var commonInitialisms = map[string]bool{
+	"api": true, "html": true, "http": true, "id": true, "ip": true,
+	"json": true, "sql": true, "uri": true, "url": true, "uuid": true,
+}
+
+// goIdentifier converts a member name such as "first-name" or "createdAt"
+// into an exported Go identifier, "FirstName" or "CreatedAt".
+func goIdentifier(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		for _, w := range splitWords(part) {
+			if commonInitialisms[w] {
+				b.WriteString(strings.ToUpper(w))
+				continue
+			}
+			r := []rune(w)
+			r[0] = unicode.ToUpper(r[0])
+			b.WriteString(string(r))
+		}
+	}
+
+	id := b.String()
+	if id == "" || !unicode.IsLetter([]rune(id)[0]) {
+		id = "X" + id
+	}
+	return id
+}
+
+// uniqueName returns name, or name with a numeric suffix if it is already
+// in used, and records the result.
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	for i := 2; used[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	used[unique] = true
+	return unique
+}
@@ -0,0 +1,110 @@
+package jsonapi
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestGenerateStructs(t *testing.T) {
+	sample := `{
+		"data": [
+			{"type": "articles", "id": "1",
+				"attributes": {"title": "a", "views": 1, "score": 1, "published-at": "2020-01-02T03:04:05Z", "tags": [], "extra": {"k": 1}},
+				"relationships": {
+					"author": {"data": {"type": "people", "id": "9"}},
+					"comments": {"data": [{"type": "comments", "id": "5"}]},
+					"links-only": {"links": {"related": "/x"}}
+				}},
+			{"type": "articles", "id": "2",
+				"attributes": {"title": "b", "views": 2, "score": 1.5, "published-at": null, "tags": ["x"]}}
+		],
+		"included": [{"type": "people", "id": "9", "attributes": {"first-name": "Ann", "homepage-url": "/ann"}}]
+	}`
+	src, err := GenerateStructs(strings.NewReader(sample), "models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "models.go", src, 0); err != nil {
+		t.Fatalf("generated source does not parse: %v\n%s", err, src)
+	}
+
+	got := strings.Join(strings.Fields(string(src)), " ")
+	for _, want := range []string{
+		"package models",
+		`import "time"`,
+		"type Article struct {",
+		"ID string `jsonapi:\"primary,articles\"`",
+		"Title string `jsonapi:\"attr,title\"`",
+		"Views int64 `jsonapi:\"attr,views\"`",
+		"Score float64 `jsonapi:\"attr,score\"`",
+		"PublishedAt *time.Time `jsonapi:\"attr,published-at,rfc3339\"`",
+		"Tags []string `jsonapi:\"attr,tags\"`",
+		"Extra map[string]interface{} `jsonapi:\"attr,extra,omitempty\"`",
+		"Author *Person `jsonapi:\"relation,author\"`",
+		"Comments []*Comment `jsonapi:\"relation,comments\"`",
+		"type Comment struct { ID string `jsonapi:\"primary,comments\"` }",
+		"FirstName string `jsonapi:\"attr,first-name\"`",
+		"HomepageURL string",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("generated source lacks %q:\n%s", want, src)
+		}
+	}
+	if strings.Contains(got, "links-only") {
+		t.Errorf("relationship without data generated:\n%s", src)
+	}
+}
+
+func TestGenerateStructsErrors(t *testing.T) {
+	for _, doc := range []string{
+		`{"data": `,
+		`{"data": null}`,
+		`{"data": [1]}`,
+		`{"data": {"id": "1"}}`,
+	} {
+		if _, err := GenerateStructs(strings.NewReader(doc), "models"); err == nil {
+			t.Errorf("%s: expected an error", doc)
+		}
+	}
+}
+
+func TestMergeGoTypes(t *testing.T) {
+	tests := []struct{ a, b, want string }{
+		{"", "int64", "int64"},
+		{"string", "", "string"},
+		{"int64", "float64", "float64"},
+		{"time.Time", "string", "string"},
+		{"[]", "[]int64", "[]int64"},
+		{"[]int64", "[]float64", "[]float64"},
+		{"bool", "string", "interface{}"},
+		{"[]bool", "[]string", "[]interface{}"},
+	}
+	for _, tt := range tests {
+		if got := mergeGoTypes(tt.a, tt.b); got != tt.want {
+			t.Errorf("mergeGoTypes(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestGoIdentifier(t *testing.T) {
+	tests := map[string]string{
+		"first-name":  "FirstName",
+		"createdAt":   "CreatedAt",
+		"user_id":     "UserID",
+		"api-url":     "APIURL",
+		"2fa-enabled": "X2faEnabled",
+		"--":          "X",
+	}
+	for name, want := range tests {
+		if got := goIdentifier(name); got != want {
+			t.Errorf("goIdentifier(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	used := map[string]bool{}
+	if a, b := uniqueName("ID", used), uniqueName("ID", used); a != "ID" || b != "ID2" {
+		t.Errorf("unique names %q, %q", a, b)
+	}
+}
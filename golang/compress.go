@@ -0,0 +1,85 @@
+package jsonapi
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// MarshalPayloadCompressed marshals models like MarshalPayload and writes
+// the document to w, compressed with gzip or deflate when the
+// Accept-Encoding header of r allows it. The document is streamed through
+// the compressor rather than buffered. Marshaling errors are returned
+// before anything is written, so the caller can still send an error
+// response.
+func MarshalPayloadCompressed(w http.ResponseWriter, r *http.Request, models interface{},
+	opts ...MarshalOption) error {
+	payload, err := Marshal(models, opts...)
+	if err != nil {
+		return err
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", MediaType)
+	h.Add("Vary", "Accept-Encoding")
+
+	var out io.WriteCloser
+	switch acceptedEncoding(r.Header.Get("Accept-Encoding")) {
+	case "gzip":
+		h.Set("Content-Encoding", "gzip")
+		out = gzip.NewWriter(w)
+	case "deflate":
+		// HTTP's deflate coding is the zlib format.
+		h.Set("Content-Encoding", "deflate")
+		out = zlib.NewWriter(w)
+	default:
+		return encodePayload(w, payload, newMarshalOptions(opts))
+	}
+	h.Del("Content-Length")
+
+	if err := encodePayload(out, payload, newMarshalOptions(opts)); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// acceptedEncoding picks gzip or deflate from an Accept-Encoding header,
+// preferring the higher quality value and gzip on ties. It returns "" when
+// neither is acceptable.
+func acceptedEncoding(header string) string {
+	quality := map[string]float64{}
+	for _, entry := range strings.Split(header, ",") {
+		parts := strings.Split(entry, ";")
+		coding := strings.ToLower(strings.TrimSpace(parts[0]))
+		q := 1.0
+		for _, p := range parts[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if f, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					q = f
+				}
+			}
+		}
+		quality[coding] = q
+	}
+
+	for _, coding := range []string{"gzip", "deflate"} {
+		if _, ok := quality[coding]; !ok {
+			if q, ok := quality["*"]; ok {
+				quality[coding] = q
+			}
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, coding := range []string{"gzip", "deflate"} {
+		if q := quality[coding]; q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
@@ -0,0 +1,104 @@
+package jsonapi
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAcceptedEncoding(t *testing.T) {
+	tests := []struct {
+		header, want string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"deflate, gzip", "gzip"},
+		{"gzip;q=0.5, deflate", "deflate"},
+		{"GZIP;q=0", ""},
+		{"*", "gzip"},
+		{"*;q=0.1, deflate;q=0.2", "deflate"},
+		{"br", ""},
+	}
+	for _, tt := range tests {
+		if got := acceptedEncoding(tt.header); got != tt.want {
+			t.Errorf("acceptedEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestMarshalPayloadCompressed(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		reader         func(io.Reader) (io.Reader, error)
+	}{
+		{"", func(r io.Reader) (io.Reader, error) { return r, nil }},
+		{"gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"deflate", func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) }},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/people/1", nil)
+		r.Header.Set("Accept-Encoding", tt.acceptEncoding)
+		if err := MarshalPayloadCompressed(w, r, &reqAuthor{ID: "1", Name: "Ann"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := w.Header().Get("Content-Encoding"); got != tt.acceptEncoding {
+			t.Errorf("%q: Content-Encoding %q", tt.acceptEncoding, got)
+		}
+		if w.Header().Get("Vary") != "Accept-Encoding" || w.Header().Get("Content-Type") != MediaType {
+			t.Errorf("%q: headers %v", tt.acceptEncoding, w.Header())
+		}
+
+		body, err := tt.reader(w.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc map[string]interface{}
+		if err := json.NewDecoder(body).Decode(&doc); err != nil {
+			t.Fatalf("%q: %v", tt.acceptEncoding, err)
+		}
+		if id := doc["data"].(map[string]interface{})["id"]; id != "1" {
+			t.Errorf("%q: id %v", tt.acceptEncoding, id)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if err := MarshalPayloadCompressed(w, r, 1); err != ErrUnexpectedType || w.Body.Len() > 0 {
+		t.Errorf("error %v, body %q; want ErrUnexpectedType and nothing written", err, w.Body)
+	}
+}
+
+func TestMarshalPayloadCompressedAttributeOrder(t *testing.T) {
+	for _, acceptEncoding := range []string{"", "gzip"} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+		err := MarshalPayloadCompressed(w, r, &ndjsonItem{ID: "1", Zeta: "z", Alpha: 1},
+			WithAttributeOrder(AttributeDeclarationOrder))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var body io.Reader = w.Body
+		if acceptEncoding == "gzip" {
+			if body, err = gzip.NewReader(w.Body); err != nil {
+				t.Fatal(err)
+			}
+		}
+		b, err := ioutil.ReadAll(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(b), `"attributes":{"zeta":"z","alpha":1}`) {
+			t.Errorf("%q: document %s, want attributes in field order", acceptEncoding, b)
+		}
+	}
+}
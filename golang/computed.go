@@ -0,0 +1,43 @@
+package jsonapi
+
+import "fmt"
+
+// ComputedAttributer lets a model add attributes derived from its fields,
+// such as a full name or a duration in minutes, without storing them on
+// the struct. The values are encoded with encoding/json. Computed
+// attributes are written only; unmarshaling ignores them unless
+// DisallowUnknownFields is set, which rejects them as unknown.
+type ComputedAttributer interface {
+	JSONAPIComputedAttributes() map[string]interface{}
+}
+
+// addComputedAttributes adds the computed attributes of model to node. A
+// computed attribute may not replace an attribute written from a field.
+func addComputedAttributes(model interface{}, node *Node) error {
+	c, ok := model.(ComputedAttributer)
+	if !ok {
+		return nil
+	}
+
+	attrs := c.JSONAPIComputedAttributes()
+	if len(attrs) == 0 {
+		return nil
+	}
+	if node.Attributes == nil {
+		node.Attributes = make(map[string]interface{}, len(attrs))
+	}
+	for name, v := range attrs {
+		if err := CheckMemberName(name); err != nil {
+			return fmt.Errorf("computed attribute of %T: %w", model, err)
+		}
+		if reservedFieldNames[name] {
+			return fmt.Errorf("%w %q: computed attribute of %T uses a reserved name",
+				ErrInvalidMemberName, name, model)
+		}
+		if _, ok := node.Attributes[name]; ok {
+			return fmt.Errorf("jsonapi: computed attribute %q of %T collides with a field", name, model)
+		}
+		node.Attributes[name] = v
+	}
+	return nil
+}
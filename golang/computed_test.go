@@ -0,0 +1,95 @@
+package jsonapi
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type computedPerson struct {
+	ID       string                 `jsonapi:"primary,people"`
+	First    string                 `jsonapi:"attr,first"`
+	Last     string                 `jsonapi:"attr,last"`
+	Extras   map[string]interface{} `jsonapi:"extras"`
+	computed map[string]interface{}
+}
+
+func (p *computedPerson) JSONAPIComputedAttributes() map[string]interface{} {
+	if p.computed != nil {
+		return p.computed
+	}
+	return map[string]interface{}{"full-name": p.First + " " + p.Last}
+}
+
+func TestComputedAttributes(t *testing.T) {
+	in := &computedPerson{ID: "1", First: "Ann", Last: "Lee", Extras: map[string]interface{}{"nick": "al", "full-name": "extra"}}
+	attrs := marshalDoc(t, in)["data"].(map[string]interface{})["attributes"]
+	want := map[string]interface{}{"first": "Ann", "last": "Lee", "full-name": "Ann Lee", "nick": "al"}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("attributes %v, want %v", attrs, want)
+	}
+
+	// No attributes at all but computed ones.
+	bare := &computedPerson{ID: "2", computed: map[string]interface{}{"n": 1}}
+	if _, err := Marshal(bare); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := `{"data": {"type": "people", "id": "1", "attributes": {"first": "Ann", "full-name": "Ann Lee"}}}`
+	out := new(computedPerson)
+	if err := UnmarshalPayload(strings.NewReader(doc), out); err != nil {
+		t.Fatal(err)
+	}
+	if out.First != "Ann" {
+		t.Errorf("unmarshaled %+v", out)
+	}
+	if out.Extras["full-name"] != "Ann Lee" {
+		t.Errorf("extras %v, want the computed attribute", out.Extras)
+	}
+}
+
+type computedEvent struct {
+	ID      string `jsonapi:"primary,events"`
+	Seconds int    `jsonapi:"attr,seconds"`
+}
+
+func (e computedEvent) JSONAPIComputedAttributes() map[string]interface{} {
+	return map[string]interface{}{"minutes": e.Seconds / 60}
+}
+
+func TestComputedAttributesUnknown(t *testing.T) {
+	attrs := marshalDoc(t, &computedEvent{ID: "1", Seconds: 120})["data"].(map[string]interface{})["attributes"]
+	if want := map[string]interface{}{"seconds": float64(120), "minutes": float64(2)}; !reflect.DeepEqual(attrs, want) {
+		t.Errorf("attributes %v, want %v", attrs, want)
+	}
+
+	doc := `{"data": {"type": "events", "id": "1", "attributes": {"seconds": 120, "minutes": 2}}}`
+	var unknown *UnknownFieldsError
+	err := UnmarshalPayload(strings.NewReader(doc), new(computedEvent), DisallowUnknownFields())
+	if !errors.As(err, &unknown) || !reflect.DeepEqual(unknown.Members(), []string{"minutes"}) {
+		t.Errorf("error %v, want minutes unknown", err)
+	}
+}
+
+func TestComputedAttributesErrors(t *testing.T) {
+	tests := []struct {
+		computed map[string]interface{}
+		want     string
+	}{
+		{map[string]interface{}{"first": "x"}, `computed attribute "first"`},
+		{map[string]interface{}{"id": "x"}, "reserved name"},
+		{map[string]interface{}{"a.b": "x"}, "computed attribute of *jsonapi.computedPerson"},
+	}
+	for _, tt := range tests {
+		_, err := Marshal(&computedPerson{ID: "1", First: "Ann", computed: tt.computed})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: error %v, want %q", tt.computed, err, tt.want)
+		}
+	}
+
+	_, err := Marshal(&computedPerson{ID: "1", computed: map[string]interface{}{"type": "x"}})
+	if !errors.Is(err, ErrInvalidMemberName) {
+		t.Errorf("reserved name: error %v, want ErrInvalidMemberName", err)
+	}
+}
@@ -0,0 +1,489 @@
+// Package conformance checks a live JSON:API service for compliance with
+// the specification, e.g. in the CI of services built on the jsonapi
+// marshaler:
+//
+//	s := &conformance.Suite{BaseURL: "http://localhost:8080", Collection: "/posts"}
+//	for _, v := range s.Run(ctx) {
+//		t.Error(v)
+//	}
+//
+// The suite fetches the collection, the first resource of it, its
+// relationship and related resource links and the next pages, and checks
+// media type handling, the structure of documents and resource objects,
+// error documents and pagination links. It only sends safe requests,
+// except for a POST with an unsupported Content-Type, which a compliant
+// server rejects before handling it.
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	jsonapi "test3"
+)
+
+// Violation is a failed check.
+type Violation struct {
+	// Check names the check, e.g. "media-type".
+	Check  string
+	Method string
+	URL    string
+	// Message describes what the response does wrong.
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s %s: %s", v.Check, v.Method, v.URL, v.Message)
+}
+
+// Suite holds the service to check.
+type Suite struct {
+	// BaseURL is prepended to Collection and MissingResource.
+	BaseURL string
+	// Collection is the path of a non-empty collection, e.g. "/posts".
+	Collection string
+	// MissingResource is the path of a resource that does not exist;
+	// Collection + "/conformance-missing-id" if empty.
+	MissingResource string
+	// MaxPages bounds the next links followed; 3 if zero.
+	MaxPages int
+	// HTTPClient is used to send requests; http.DefaultClient if nil.
+	HTTPClient *http.Client
+	// Header is added to every request, e.g. for authorization.
+	Header http.Header
+}
+
+// run collects the violations of a single Run.
+type run struct {
+	*Suite
+	ctx        context.Context
+	violations []Violation
+}
+
+// Run performs every check and returns the violations found, in the order
+// of the requests. Requests that fail to be sent are violations too.
+func (s *Suite) Run(ctx context.Context) []Violation {
+	r := &run{Suite: s, ctx: ctx}
+	collection := s.url(s.Collection)
+
+	r.checkMediaType(collection)
+	r.checkAcceptParams(collection)
+	r.checkContentTypeParams(collection)
+	r.checkNotFound()
+
+	doc, ok := r.document("collection", collection, http.StatusOK)
+	if !ok {
+		return r.violations
+	}
+	r.checkPagination(collection, doc)
+
+	data, _ := doc["data"].([]interface{})
+	if len(data) == 0 {
+		r.report("collection", http.MethodGet, collection, "primary data is not a non-empty array")
+		return r.violations
+	}
+	r.checkResource(data[0])
+
+	return r.violations
+}
+
+func (s *Suite) url(path string) string {
+	return strings.TrimSuffix(s.BaseURL, "/") + path
+}
+
+func (r *run) report(check, method, u, format string, args ...interface{}) {
+	r.violations = append(r.violations, Violation{
+		Check: check, Method: method, URL: u, Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// do sends a request with the given Accept and Content-Type headers,
+// omitted if empty, and returns the response with its body read.
+func (r *run) do(check, method, u, accept, contentType string, body []byte) (*http.Response, []byte, bool) {
+	req, err := http.NewRequestWithContext(r.ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		r.report(check, method, u, "%v", err)
+		return nil, nil, false
+	}
+	for k, v := range r.Header {
+		req.Header[k] = v
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	client := r.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		r.report(check, method, u, "%v", err)
+		return nil, nil, false
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		r.report(check, method, u, "reading body: %v", err)
+		return nil, nil, false
+	}
+	return resp, b, true
+}
+
+// document GETs u, expecting the given status, and returns the document of
+// the response after checking its media type and top-level structure.
+func (r *run) document(check, u string, status int) (map[string]interface{}, bool) {
+	resp, body, ok := r.do(check, http.MethodGet, u, jsonapi.MediaType, "", nil)
+	if !ok {
+		return nil, false
+	}
+	if resp.StatusCode != status {
+		r.report(check, http.MethodGet, u, "status %d, want %d", resp.StatusCode, status)
+		return nil, false
+	}
+	r.checkContentType(check, http.MethodGet, u, resp)
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		r.report(check, http.MethodGet, u, "body is not a JSON object: %v", err)
+		return nil, false
+	}
+	r.checkTopLevel(check, u, doc)
+	return doc, true
+}
+
+// checkContentType reports responses whose Content-Type is not the
+// JSON:API media type with at most the ext and profile parameters.
+func (r *run) checkContentType(check, method, u string, resp *http.Response) {
+	mt, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mt != jsonapi.MediaType {
+		r.report(check, method, u, "Content-Type %q is not %s", resp.Header.Get("Content-Type"), jsonapi.MediaType)
+		return
+	}
+	for name := range params {
+		if name != jsonapi.MediaTypeParamExt && name != jsonapi.MediaTypeParamProfile {
+			r.report(check, method, u, "Content-Type has parameter %q", name)
+		}
+	}
+}
+
+func (r *run) checkMediaType(u string) {
+	if resp, _, ok := r.do("media-type", http.MethodGet, u, jsonapi.MediaType, "", nil); ok {
+		r.checkContentType("media-type", http.MethodGet, u, resp)
+	}
+}
+
+// checkAcceptParams expects 406 when every JSON:API entry of Accept has
+// parameters other than ext and profile.
+func (r *run) checkAcceptParams(u string) {
+	resp, _, ok := r.do("accept-params", http.MethodGet, u, jsonapi.MediaType+"; charset=utf-8", "", nil)
+	if ok && resp.StatusCode != http.StatusNotAcceptable {
+		r.report("accept-params", http.MethodGet, u,
+			"status %d for an Accept media type with parameters, want 406", resp.StatusCode)
+	}
+}
+
+// checkContentTypeParams expects 415 for request documents whose
+// Content-Type has parameters other than ext and profile.
+func (r *run) checkContentTypeParams(u string) {
+	body := []byte(`{"data":{"type":"conformance"}}`)
+	resp, _, ok := r.do("content-type-params", http.MethodPost, u,
+		jsonapi.MediaType, jsonapi.MediaType+"; charset=utf-8", body)
+	if ok && resp.StatusCode != http.StatusUnsupportedMediaType {
+		r.report("content-type-params", http.MethodPost, u,
+			"status %d for a Content-Type with parameters, want 415", resp.StatusCode)
+	}
+}
+
+// checkNotFound expects an errors document for a missing resource.
+func (r *run) checkNotFound() {
+	path := r.MissingResource
+	if path == "" {
+		path = r.Collection + "/conformance-missing-id"
+	}
+	u := r.url(path)
+	doc, ok := r.document("error-document", u, http.StatusNotFound)
+	if !ok {
+		return
+	}
+
+	errs, ok := doc["errors"].([]interface{})
+	if !ok || len(errs) == 0 {
+		r.report("error-document", http.MethodGet, u, "errors is not a non-empty array")
+		return
+	}
+	for i, e := range errs {
+		obj, ok := e.(map[string]interface{})
+		if !ok {
+			r.report("error-document", http.MethodGet, u, "errors[%d] is not an object", i)
+			continue
+		}
+		if status, ok := obj["status"]; ok {
+			if _, ok := status.(string); !ok {
+				r.report("error-document", http.MethodGet, u, "errors[%d].status is not a string", i)
+			}
+		}
+		for _, member := range []string{"links", "source", "meta"} {
+			if v, ok := obj[member]; ok {
+				if _, ok := v.(map[string]interface{}); !ok {
+					r.report("error-document", http.MethodGet, u, "errors[%d].%s is not an object", i, member)
+				}
+			}
+		}
+	}
+}
+
+// checkTopLevel checks the top-level members of a document and the
+// resource objects it holds.
+func (r *run) checkTopLevel(check, u string, doc map[string]interface{}) {
+	_, hasData := doc["data"]
+	_, hasErrors := doc["errors"]
+	_, hasMeta := doc["meta"]
+	if !hasData && !hasErrors && !hasMeta {
+		r.report(check, http.MethodGet, u, "document has none of data, errors and meta")
+	}
+	if hasData && hasErrors {
+		r.report(check, http.MethodGet, u, "document has both data and errors")
+	}
+	if _, ok := doc["included"]; ok && !hasData {
+		r.report(check, http.MethodGet, u, "document has included without data")
+	}
+
+	var resources []interface{}
+	switch data := doc["data"].(type) {
+	case []interface{}:
+		resources = data
+	case map[string]interface{}:
+		resources = []interface{}{data}
+	}
+	if included, ok := doc["included"].([]interface{}); ok {
+		resources = append(resources, included...)
+	}
+	for _, res := range resources {
+		r.checkResourceObject(check, u, res)
+	}
+}
+
+// checkResourceObject checks the members of a resource object.
+func (r *run) checkResourceObject(check, u string, v interface{}) {
+	res, ok := v.(map[string]interface{})
+	if !ok {
+		r.report(check, http.MethodGet, u, "resource object is not an object")
+		return
+	}
+	typ, okType := res["type"].(string)
+	id, okID := res["id"].(string)
+	if !okType || !okID {
+		r.report(check, http.MethodGet, u, "resource object has no string type and id")
+		return
+	}
+	at := fmt.Sprintf("resource %s/%s", typ, id)
+
+	attributes, _ := res["attributes"].(map[string]interface{})
+	relationships, _ := res["relationships"].(map[string]interface{})
+	for _, member := range []string{"attributes", "relationships", "links", "meta"} {
+		if m, ok := res[member]; ok {
+			if _, ok := m.(map[string]interface{}); !ok {
+				r.report(check, http.MethodGet, u, "%s: %s is not an object", at, member)
+			}
+		}
+	}
+	for _, name := range []string{"id", "type"} {
+		if _, ok := attributes[name]; ok {
+			r.report(check, http.MethodGet, u, "%s: attribute named %q", at, name)
+		}
+		if _, ok := relationships[name]; ok {
+			r.report(check, http.MethodGet, u, "%s: relationship named %q", at, name)
+		}
+	}
+	for name := range attributes {
+		if _, ok := relationships[name]; ok {
+			r.report(check, http.MethodGet, u, "%s: %q is both an attribute and a relationship", at, name)
+		}
+	}
+	for _, name := range sortedKeys(relationships) {
+		rel, ok := relationships[name].(map[string]interface{})
+		if !ok {
+			r.report(check, http.MethodGet, u, "%s: relationship %q is not an object", at, name)
+			continue
+		}
+		_, hasData := rel["data"]
+		_, hasLinks := rel["links"]
+		_, hasMeta := rel["meta"]
+		if !hasData && !hasLinks && !hasMeta {
+			r.report(check, http.MethodGet, u, "%s: relationship %q has none of data, links and meta", at, name)
+		}
+		if hasData && !isLinkage(rel["data"]) {
+			r.report(check, http.MethodGet, u, "%s: relationship %q has invalid resource linkage", at, name)
+		}
+	}
+}
+
+// checkResource fetches the first resource of the collection and its
+// relationship and related resource links.
+func (r *run) checkResource(v interface{}) {
+	res, _ := v.(map[string]interface{})
+	links, _ := res["links"].(map[string]interface{})
+	self := r.href(links["self"])
+	if self == "" {
+		id, _ := res["id"].(string)
+		if id == "" {
+			return
+		}
+		self = r.url(r.Collection + "/" + url.PathEscape(id))
+	}
+	if doc, ok := r.document("resource", self, http.StatusOK); ok {
+		if _, ok := doc["data"].(map[string]interface{}); !ok {
+			r.report("resource", http.MethodGet, self, "primary data is not a resource object")
+		}
+	}
+
+	relationships, _ := res["relationships"].(map[string]interface{})
+	for _, name := range sortedKeys(relationships) {
+		rel, _ := relationships[name].(map[string]interface{})
+		relLinks, _ := rel["links"].(map[string]interface{})
+
+		if u := r.href(relLinks["self"]); u != "" {
+			if doc, ok := r.document("relationship", u, http.StatusOK); ok {
+				data, ok := doc["data"]
+				if !ok || !isLinkage(data) {
+					r.report("relationship", http.MethodGet, u, "primary data is not resource linkage")
+				}
+			}
+		}
+		if u := r.href(relLinks["related"]); u != "" {
+			r.document("related", u, http.StatusOK)
+		}
+	}
+}
+
+// checkPagination checks the pagination links of a collection document
+// and follows its next links.
+func (r *run) checkPagination(u string, doc map[string]interface{}) {
+	max := r.MaxPages
+	if max == 0 {
+		max = 3
+	}
+	seen := map[string]bool{u: true}
+
+	for page := 0; ; page++ {
+		links, _ := doc["links"].(map[string]interface{})
+		for _, key := range []string{jsonapi.KeyFirstPage, jsonapi.KeyPreviousPage, jsonapi.KeyNextPage, jsonapi.KeyLastPage} {
+			if link, ok := links[key]; ok && link != nil && !isLink(link) {
+				r.report("pagination", http.MethodGet, u, "links.%s is neither null nor a link", key)
+			}
+		}
+
+		next := r.href(links[jsonapi.KeyNextPage])
+		if next == "" || page >= max {
+			return
+		}
+		if seen[next] {
+			r.report("pagination", http.MethodGet, u, "links.next %q was already visited", next)
+			return
+		}
+		seen[next] = true
+
+		var ok bool
+		if doc, ok = r.document("pagination", next, http.StatusOK); !ok {
+			return
+		}
+		if _, ok := doc["data"].([]interface{}); !ok {
+			r.report("pagination", http.MethodGet, next, "primary data is not an array")
+		}
+		u = next
+	}
+}
+
+// href returns the absolute URL of a link, or "".
+func (r *run) href(link interface{}) string {
+	var h string
+	switch l := link.(type) {
+	case string:
+		h = l
+	case map[string]interface{}:
+		h, _ = l["href"].(string)
+	}
+	if h == "" {
+		return ""
+	}
+	base, err := url.Parse(r.BaseURL + "/")
+	if err != nil {
+		return h
+	}
+	ref, err := url.Parse(h)
+	if err != nil {
+		return h
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// isLink reports whether v is a link: a string or a link object with a
+// string href.
+func isLink(v interface{}) bool {
+	switch l := v.(type) {
+	case string:
+		return true
+	case map[string]interface{}:
+		_, ok := l["href"].(string)
+		return ok
+	}
+	return false
+}
+
+// isLinkage reports whether v is resource linkage: null, a resource
+// identifier object or an array of them.
+func isLinkage(v interface{}) bool {
+	switch l := v.(type) {
+	case nil:
+		return true
+	case []interface{}:
+		for _, id := range l {
+			if !isIdentifier(id) {
+				return false
+			}
+		}
+		return true
+	}
+	return isIdentifier(v)
+}
+
+func isIdentifier(v interface{}) bool {
+	id, ok := v.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	if _, ok := id["type"].(string); !ok {
+		return false
+	}
+	_, hasID := id["id"].(string)
+	_, hasLID := id["lid"].(string)
+	for name := range id {
+		switch name {
+		case "type", "id", "lid", "meta":
+		default:
+			return false
+		}
+	}
+	return hasID || hasLID
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,165 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	jsonapi "test3"
+)
+
+// service is a small JSON:API service with two pages of posts, whose
+// behavior the tests break one way or another.
+type service struct {
+	contentType   string
+	ignoreParams  bool
+	plainNotFound string
+	pages         map[string]string
+	resource      string
+}
+
+func newService() *service {
+	return &service{
+		contentType: jsonapi.MediaType,
+		pages: map[string]string{
+			"": `{"data": [{"type": "posts", "id": "1",
+				"links": {"self": "/posts/1"},
+				"relationships": {"author": {
+					"links": {"self": "/posts/1/relationships/author", "related": "/posts/1/author"},
+					"data": {"type": "people", "id": "9"}}}}],
+				"links": {"first": "/posts", "prev": null, "next": "/posts?page=2"}}`,
+			"2": `{"data": [{"type": "posts", "id": "2"}], "links": {"prev": {"href": "/posts"}}}`,
+		},
+		resource: `{"data": {"type": "posts", "id": "1", "attributes": {"title": "Hello"}}}`,
+	}
+}
+
+func (s *service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.ignoreParams {
+		if mt, params, _ := mime.ParseMediaType(r.Header.Get("Accept")); mt == jsonapi.MediaType && len(params) > 0 {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "" && ct != jsonapi.MediaType {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+	}
+
+	body, status := "", http.StatusOK
+	switch r.URL.Path {
+	case "/posts":
+		body = s.pages[r.URL.Query().Get("page")]
+	case "/posts/1":
+		body = s.resource
+	case "/posts/1/relationships/author":
+		body = `{"data": {"type": "people", "id": "9"}}`
+	case "/posts/1/author":
+		body = `{"data": {"type": "people", "id": "9", "attributes": {"name": "Ann"}}}`
+	default:
+		status = http.StatusNotFound
+		body = `{"errors": [{"status": "404", "title": "Not Found"}]}`
+		if s.plainNotFound != "" {
+			body = s.plainNotFound
+		}
+	}
+	w.Header().Set("Content-Type", s.contentType)
+	w.WriteHeader(status)
+	fmt.Fprint(w, body)
+}
+
+func runSuite(t *testing.T, s *service) []Violation {
+	t.Helper()
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	suite := &Suite{BaseURL: srv.URL, Collection: "/posts", Header: http.Header{"Authorization": {"Bearer x"}}}
+	return suite.Run(context.Background())
+}
+
+func checks(violations []Violation) string {
+	var names []string
+	for _, v := range violations {
+		names = append(names, v.Check)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestRunCompliant(t *testing.T) {
+	for _, v := range runSuite(t, newService()) {
+		t.Error(v)
+	}
+}
+
+func TestRunViolations(t *testing.T) {
+	tests := []struct {
+		name   string
+		breaks func(s *service)
+		want   string
+	}{
+		{"content type", func(s *service) { s.contentType = "application/json" },
+			"media-type,error-document,collection,pagination,resource,relationship,related"},
+		{"content type params", func(s *service) { s.contentType = jsonapi.MediaType + "; charset=utf-8" },
+			"media-type,error-document,collection,pagination,resource,relationship,related"},
+		{"ignored params", func(s *service) { s.ignoreParams = true },
+			"accept-params,content-type-params"},
+		{"error document", func(s *service) { s.plainNotFound = `{"errors": [{"status": 404, "source": "x"}]}` },
+			"error-document,error-document"},
+		{"empty errors", func(s *service) { s.plainNotFound = `{"errors": []}` },
+			"error-document"},
+		{"data and errors", func(s *service) { s.resource = `{"data": null, "errors": []}` },
+			"resource,resource"},
+		{"reserved names", func(s *service) {
+			s.resource = `{"data": {"type": "posts", "id": "1", "attributes": {"id": 1, "author": 2},
+				"relationships": {"author": {}, "tags": {"data": [{"type": "tags"}]}}}}`
+		}, "resource,resource,resource,resource"},
+		{"pagination", func(s *service) { s.pages["2"] = `{"data": {}, "links": {"next": "/posts?page=2", "last": 1}}` },
+			"pagination,pagination,pagination,pagination"},
+		{"empty collection", func(s *service) { s.pages[""] = `{"data": []}` },
+			"collection"},
+	}
+	for _, tt := range tests {
+		s := newService()
+		tt.breaks(s)
+		violations := runSuite(t, s)
+		if got := checks(violations); got != tt.want {
+			t.Errorf("%s: violations %q, want %q: %v", tt.name, got, tt.want, violations)
+		}
+	}
+}
+
+func TestRunUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	violations := (&Suite{BaseURL: srv.URL, Collection: "/posts"}).Run(context.Background())
+	if len(violations) == 0 {
+		t.Fatal("no violations for an unreachable service")
+	}
+	if s := violations[0].String(); !strings.HasPrefix(s, "media-type: GET "+srv.URL+"/posts: ") {
+		t.Errorf("violation %q", s)
+	}
+}
+
+func TestIsLinkage(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want bool
+	}{
+		{nil, true},
+		{[]interface{}{}, true},
+		{map[string]interface{}{"type": "a", "id": "1"}, true},
+		{map[string]interface{}{"type": "a", "lid": "x", "meta": map[string]interface{}{}}, true},
+		{map[string]interface{}{"type": "a"}, false},
+		{map[string]interface{}{"type": "a", "id": "1", "attributes": map[string]interface{}{}}, false},
+		{[]interface{}{map[string]interface{}{"type": "a", "id": 1}}, false},
+		{"a/1", false},
+	}
+	for _, tt := range tests {
+		if got := isLinkage(tt.v); got != tt.want {
+			t.Errorf("isLinkage(%v) = %v", tt.v, got)
+		}
+	}
+}
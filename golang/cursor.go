@@ -0,0 +1,170 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// Query parameters used for cursor (keyset) pagination, following the
+// JSON:API cursor pagination profile.
+const (
+	QueryParamPageAfter  = "page[after]"
+	QueryParamPageBefore = "page[before]"
+	QueryParamPageSize   = "page[size]"
+)
+
+// ErrInvalidCursor is returned when a cursor string cannot be decoded.
+var ErrInvalidCursor = errors.New("jsonapi: invalid pagination cursor")
+
+// Cursor is a keyset pagination position: the values of the sort key
+// fields of the record at the edge of a page. It is encoded as base64url
+// JSON so clients treat it as opaque.
+type Cursor map[string]interface{}
+
+// Encode returns the opaque string form of c.
+func (c Cursor) Encode() (string, error) {
+	b, err := json.Marshal(map[string]interface{}(c))
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Decode parses a string produced by Encode into c. Numbers are decoded as
+// json.Number so that large integer keys survive the round trip.
+func (c *Cursor) Decode(s string) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	m := map[string]interface{}{}
+	if err := dec.Decode(&m); err != nil {
+		return ErrInvalidCursor
+	}
+	*c = m
+	return nil
+}
+
+// DecodeCursor is shorthand for Cursor.Decode.
+func DecodeCursor(s string) (Cursor, error) {
+	var c Cursor
+	err := c.Decode(s)
+	return c, err
+}
+
+// CursorFor builds the cursor of model, a pointer to a tagged struct, from
+// the given member names. "id" selects the primary field; other names are
+// attribute names.
+func CursorFor(model interface{}, fields ...string) (Cursor, error) {
+	return CursorForOptions(model, nil, fields...)
+}
+
+// CursorForOptions is CursorFor for models marshaled with opts, whose
+// member names it follows, e.g. those of WithTagKey.
+func CursorForOptions(model interface{}, opts []MarshalOption, fields ...string) (Cursor, error) {
+	v := reflect.ValueOf(model)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, ErrUnexpectedType
+	}
+	v = v.Elem()
+
+	tags := &newMarshalOptions(opts).tagOptions
+	c := Cursor{}
+	for _, name := range fields {
+		field, ok := memberField(v.Type(), name, tags)
+		if !ok {
+			return nil, fmt.Errorf("jsonapi: %s has no member %q", v.Type(), name)
+		}
+		c[name] = v.FieldByIndex(field.Index).Interface()
+	}
+	return c, nil
+}
+
+// memberField finds the struct field tagged with the member name, with
+// tags read as set by tags; "id" finds the primary field.
+func memberField(t reflect.Type, name string, tags *tagOptions) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		args := strings.Split(tags.tag(field), annotationSeperator)
+		switch {
+		case args[0] == annotationPrimary && name == "id":
+			return field, true
+		case (args[0] == annotationAttribute || args[0] == annotationRelation) &&
+			len(args) > 1 && args[1] == name:
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// CursorPage describes a page of keyset pagination. Next and Prev are the
+// cursors of the neighbouring pages, nil when there is none.
+type CursorPage struct {
+	Next *Cursor
+	Prev *Cursor
+	Size int
+}
+
+// SetCursorPage adds next and prev links for page to payload, derived from
+// self (usually the request URL) so that filters and other query
+// parameters are preserved, and a page meta object with the cursors and
+// page size.
+func SetCursorPage(payload *ManyPayload, self *url.URL, page CursorPage) error {
+	links := Links{}
+	pageMeta := map[string]interface{}{}
+	if page.Size > 0 {
+		pageMeta["size"] = page.Size
+	}
+
+	for _, edge := range []struct {
+		key, param, metaKey string
+		cursor              *Cursor
+	}{
+		{KeyNextPage, QueryParamPageAfter, "nextCursor", page.Next},
+		{KeyPreviousPage, QueryParamPageBefore, "prevCursor", page.Prev},
+	} {
+		if edge.cursor == nil {
+			continue
+		}
+		enc, err := edge.cursor.Encode()
+		if err != nil {
+			return err
+		}
+
+		u := *self
+		q := u.Query()
+		q.Del(QueryParamPageAfter)
+		q.Del(QueryParamPageBefore)
+		q.Set(edge.param, enc)
+		if page.Size > 0 {
+			q.Set(QueryParamPageSize, fmt.Sprint(page.Size))
+		}
+		u.RawQuery = q.Encode()
+
+		links[edge.key] = u.String()
+		pageMeta[edge.metaKey] = enc
+	}
+
+	if len(links) > 0 && payload.Links == nil {
+		payload.Links = &Links{}
+	}
+	for k, v := range links {
+		(*payload.Links)[k] = v
+	}
+	if payload.Meta == nil {
+		payload.Meta = &Meta{}
+	}
+	(*payload.Meta)["page"] = pageMeta
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+)
+
+type cursorPost struct {
+	ID    int64  `jsonapi:"primary,posts"`
+	Title string `jsonapi:"attr,title"`
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	c, err := CursorFor(&cursorPost{ID: 1<<62 + 1, Title: "a"}, "title", "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := c.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := DecodeCursor(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["title"] != "a" || got["id"] != json.Number("4611686018427387905") {
+		t.Errorf("decoded %v", got)
+	}
+}
+
+func TestCursorErrors(t *testing.T) {
+	for _, s := range []string{"not base64!", "bm90IGpzb24"} {
+		if _, err := DecodeCursor(s); err != ErrInvalidCursor {
+			t.Errorf("DecodeCursor(%q) error %v, want ErrInvalidCursor", s, err)
+		}
+	}
+	if _, err := CursorFor(cursorPost{}, "id"); err != ErrUnexpectedType {
+		t.Errorf("CursorFor(struct) error %v, want ErrUnexpectedType", err)
+	}
+	if _, err := CursorFor(&cursorPost{}, "body"); err == nil {
+		t.Error("CursorFor with an unknown member succeeded")
+	}
+}
+
+func TestSetCursorPage(t *testing.T) {
+	self, _ := url.Parse("/posts?filter%5Btitle%5D=a&page%5Bafter%5D=old")
+	next := Cursor{"id": 3}
+	payload := &ManyPayload{}
+	if err := SetCursorPage(payload, self, CursorPage{Next: &next, Size: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	enc, _ := next.Encode()
+	link, _ := url.Parse((*payload.Links)[KeyNextPage].(string))
+	q := link.Query()
+	if q.Get(QueryParamPageAfter) != enc || q.Get(QueryParamPageSize) != "2" || q.Get("filter[title]") != "a" {
+		t.Errorf("next link %s", link)
+	}
+	if _, ok := (*payload.Links)[KeyPreviousPage]; ok {
+		t.Error("prev link without a prev cursor")
+	}
+
+	page := (*payload.Meta)["page"].(map[string]interface{})
+	if page["nextCursor"] != enc || page["size"] != 2 {
+		t.Errorf("page meta %v", page)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build shopspring
+// +build shopspring
+
+package jsonapi
+
+import "github.com/shopspring/decimal"
+
+func init() {
+	if err := RegisterDecimal(decimal.Decimal{}); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,34 @@
+//go:build shopspring
+// +build shopspring
+
+package jsonapi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestShopspringDecimal(t *testing.T) {
+	type invoice struct {
+		ID    string          `jsonapi:"primary,invoices"`
+		Total decimal.Decimal `jsonapi:"attr,total"`
+	}
+	in := &invoice{ID: "1", Total: decimal.RequireFromString("1234567890.123456789")}
+	b, err := MarshalBytes(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"total":"1234567890.123456789"`) {
+		t.Errorf("document %s", b)
+	}
+
+	out := new(invoice)
+	if err := UnmarshalPayload(strings.NewReader(string(b)), out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.Total.Equal(in.Total) {
+		t.Errorf("total %s, want %s", out.Total, in.Total)
+	}
+}
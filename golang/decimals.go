@@ -0,0 +1,128 @@
+package jsonapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var decimals = struct {
+	sync.RWMutex
+	types map[reflect.Type]bool
+}{types: map[reflect.Type]bool{
+	reflect.TypeOf(big.Int{}):   true,
+	reflect.TypeOf(big.Float{}): true,
+}}
+
+// RegisterDecimal makes attributes of the type of v, and pointers to it,
+// marshal to the text of their MarshalText method and unmarshal with
+// UnmarshalText, so that no precision is lost to float64 on the way. The
+// type must implement encoding.TextMarshaler and its pointer
+// encoding.TextUnmarshaler, as shopspring/decimal's Decimal does:
+//
+//	jsonapi.RegisterDecimal(decimal.Decimal{})
+//
+// math/big's Int and Float are registered already. Values are written as
+// JSON strings, or as JSON numbers for attributes tagged with the number
+// option, e.g. `jsonapi:"attr,amount,number"`. Both forms are accepted on
+// unmarshal; numbers keep their precision only with UseNumber.
+func RegisterDecimal(v interface{}) error {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() == reflect.Ptr ||
+		!(t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)) ||
+		!reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return fmt.Errorf("jsonapi: RegisterDecimal takes a non-pointer text marshaler, got %T", v)
+	}
+
+	decimals.Lock()
+	decimals.types[t] = true
+	decimals.Unlock()
+	return nil
+}
+
+// isDecimalType reports whether t, or the element type of the pointer t,
+// is a registered decimal type.
+func isDecimalType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	decimals.RLock()
+	defer decimals.RUnlock()
+	return decimals.types[t]
+}
+
+// marshalDecimal returns the attribute value of v, whose type satisfies
+// isDecimalType: its text as a string, or as a json.Number with number.
+func marshalDecimal(v reflect.Value, number bool) (interface{}, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+	} else {
+		// MarshalText may have a pointer receiver, as big.Float's has.
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		v = ptr
+	}
+
+	text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	if number {
+		return json.Number(text), nil
+	}
+	return string(text), nil
+}
+
+// unmarshalDecimal parses the attribute value of a decimal field of type t,
+// not a pointer, into a pointer to a value of t.
+func unmarshalDecimal(attribute interface{}, t reflect.Type) (reflect.Value, error) {
+	var text string
+	switch a := attribute.(type) {
+	case string:
+		text = a
+	case json.Number:
+		text = a.String()
+	case float64:
+		if math.IsInf(a, 0) || math.IsNaN(a) {
+			return reflect.Value{}, ErrInvalidType
+		}
+		text = strconv.FormatFloat(a, 'g', -1, 64)
+	default:
+		return reflect.Value{}, ErrInvalidType
+	}
+
+	ptr := reflect.New(t)
+	if f, ok := ptr.Interface().(*big.Float); ok {
+		// A zero big.Float would round to 64 bits.
+		f.SetPrec(decimalPrec(text))
+	}
+	if err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text)); err != nil {
+		return reflect.Value{}, fmt.Errorf("%w: %v", ErrInvalidType, err)
+	}
+	return ptr, nil
+}
+
+// decimalPrec returns the precision, in bits, needed to hold the digits of
+// the decimal number text without rounding, and at least that of a
+// float64.
+func decimalPrec(text string) uint {
+	digits := 0
+	for _, r := range strings.SplitN(strings.ToLower(text), "e", 2)[0] {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	prec := uint(math.Ceil(float64(digits) * math.Log2(10)))
+	if prec < 53 {
+		prec = 53
+	}
+	return prec
+}
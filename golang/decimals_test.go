@@ -0,0 +1,139 @@
+package jsonapi
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+type decimalOrder struct {
+	ID     string     `jsonapi:"primary,orders"`
+	Total  big.Int    `jsonapi:"attr,total"`
+	Rate   *big.Float `jsonapi:"attr,rate,number"`
+	Refund *big.Int   `jsonapi:"attr,refund"`
+}
+
+func TestMarshalDecimals(t *testing.T) {
+	in := &decimalOrder{ID: "1", Rate: big.NewFloat(0.25)}
+	in.Total.SetString("123456789012345678901234567890", 10)
+
+	b, err := MarshalBytes(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"total":"123456789012345678901234567890"`,
+		`"rate":0.25`,
+		`"refund":null`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("document lacks %s:\n%s", want, b)
+		}
+	}
+}
+
+func TestUnmarshalDecimals(t *testing.T) {
+	doc := `{"data": {"type": "orders", "id": "1", "attributes": {
+		"total": "123456789012345678901234567890",
+		"rate": 0.12345678901234567890123,
+		"refund": 5}}}`
+
+	out := new(decimalOrder)
+	if err := UnmarshalPayload(strings.NewReader(doc), out, UseNumber()); err != nil {
+		t.Fatal(err)
+	}
+	if out.Total.String() != "123456789012345678901234567890" {
+		t.Errorf("total %s", out.Total.String())
+	}
+	if got := out.Rate.Text('g', 23); got != "0.12345678901234567890123" {
+		t.Errorf("rate %s, want every digit kept with UseNumber", got)
+	}
+	if out.Refund == nil || out.Refund.Int64() != 5 {
+		t.Errorf("refund %v", out.Refund)
+	}
+
+	// Without UseNumber the number arrives as a float64.
+	out = new(decimalOrder)
+	if err := UnmarshalPayload(strings.NewReader(doc), out); err != nil {
+		t.Fatal(err)
+	}
+	if f, _ := out.Rate.Float64(); f != 0.12345678901234568 {
+		t.Errorf("rate %v", out.Rate)
+	}
+
+	for _, attrs := range []string{`{"total": "12.5"}`, `{"total": true}`, `{"rate": "x"}`} {
+		doc := `{"data": {"type": "orders", "id": "1", "attributes": ` + attrs + `}}`
+		if err := UnmarshalPayload(strings.NewReader(doc), new(decimalOrder)); !errors.Is(err, ErrInvalidType) {
+			t.Errorf("%s: error %v, want ErrInvalidType", attrs, err)
+		}
+	}
+}
+
+type decimalText struct{ s string }
+
+func (d decimalText) MarshalText() ([]byte, error) { return []byte(d.s), nil }
+
+func (d *decimalText) UnmarshalText(b []byte) error {
+	d.s = string(b)
+	return nil
+}
+
+func TestRegisterDecimal(t *testing.T) {
+	for _, v := range []interface{}{nil, 1, new(decimalText), struct{}{}} {
+		if err := RegisterDecimal(v); err == nil {
+			t.Errorf("RegisterDecimal(%T) succeeded", v)
+		}
+	}
+	if err := RegisterDecimal(decimalText{}); err != nil {
+		t.Fatal(err)
+	}
+
+	type price struct {
+		ID     string       `jsonapi:"primary,prices"`
+		Amount decimalText  `jsonapi:"attr,amount,number"`
+		Old    *decimalText `jsonapi:"attr,old"`
+	}
+	b, err := MarshalBytes(&price{ID: "1", Amount: decimalText{"9.99"}, Old: &decimalText{"10.49"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"amount":9.99`) || !strings.Contains(string(b), `"old":"10.49"`) {
+		t.Errorf("document %s", b)
+	}
+
+	out := new(price)
+	if err := UnmarshalPayload(strings.NewReader(string(b)), out, UseNumber()); err != nil {
+		t.Fatal(err)
+	}
+	if out.Amount.s != "9.99" || out.Old == nil || out.Old.s != "10.49" {
+		t.Errorf("unmarshaled %+v", out)
+	}
+	if errs := CheckModel(&price{}); errs != nil {
+		t.Errorf("CheckModel = %v", errs)
+	}
+}
+
+func TestDecimalPrec(t *testing.T) {
+	tests := map[string]uint{
+		"1":                         53,
+		"0.12345678901234567890123": 80,
+		"-1.5e300":                  53,
+	}
+	for text, want := range tests {
+		if got := decimalPrec(text); got != want {
+			t.Errorf("decimalPrec(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
+
+func TestNumberOptionCheckModel(t *testing.T) {
+	type bad struct {
+		ID    string  `jsonapi:"primary,bad"`
+		Count float64 `jsonapi:"attr,count,number"`
+	}
+	errs := CheckModel(&bad{})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "number option used on non-decimal field") {
+		t.Errorf("CheckModel = %v", errs)
+	}
+}
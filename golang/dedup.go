@@ -0,0 +1,83 @@
+package jsonapi
+
+// A collection document lists each related resource once in included, no
+// matter how many resources of the primary data refer to it, and leaves
+// out those that are part of the primary data themselves, as the
+// specification requires of compound documents.
+
+// KeyDeduplicatedMeta is the top-level meta member, added by
+// WithDeduplicatedMeta, holding the number of resource objects that
+// deduplication left out of included.
+const KeyDeduplicatedMeta = "deduplicated"
+
+// WithoutIncludedDedup makes Marshal list in included, for each resource of
+// a collection in turn, every resource it refers to, even those already
+// listed for an earlier resource or part of the primary data, so that
+// clients can process each resource with its related resources alone. Such
+// a document has several resource objects with the same type and id,
+// which the specification does not allow; only use it for clients that
+// ask for it.
+func WithoutIncludedDedup() MarshalOption {
+	return func(o *marshalOptions) {
+		o.noIncludedDedup = true
+	}
+}
+
+// WithDeduplicatedMeta makes Marshal add KeyDeduplicatedMeta to the
+// top-level meta of collection documents: the number of resource objects
+// included would have without deduplication, minus the number it has.
+func WithDeduplicatedMeta() MarshalOption {
+	return func(o *marshalOptions) {
+		o.deduplicatedMeta = true
+	}
+}
+
+// itemIncluded returns, for each node of data, the resources of included
+// and data it refers to, directly or not, in the order chosen by o.
+func itemIncluded(included map[string]*Node, data []*Node, o *marshalOptions) [][]*Node {
+	index := make(map[string]*Node, len(included)+len(data))
+	for key, n := range included {
+		index[key] = n
+	}
+	for _, n := range data {
+		index[nodeKey(n)] = n
+	}
+
+	items := make([][]*Node, len(data))
+	for i, root := range data {
+		reached := map[string]*Node{}
+		seen := map[string]bool{nodeKey(root): true}
+		queue := []*Node{root}
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			for _, rel := range n.Relationships {
+				for _, ref := range relationshipData(rel) {
+					key := nodeKey(ref)
+					full, ok := index[key]
+					if !ok || seen[key] {
+						continue
+					}
+					seen[key] = true
+					reached[key] = full
+					queue = append(queue, full)
+				}
+			}
+		}
+		items[i] = orderIncluded(reached, o, root)
+	}
+	return items
+}
+
+// withDeduplicated returns a copy of meta with n added as
+// KeyDeduplicatedMeta.
+func withDeduplicated(meta *Meta, n int) *Meta {
+	m := Meta{}
+	if meta != nil {
+		for k, v := range *meta {
+			m[k] = v
+		}
+	}
+	m[KeyDeduplicatedMeta] = n
+	return &m
+}
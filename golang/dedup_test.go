@@ -0,0 +1,72 @@
+package jsonapi
+
+import "testing"
+
+// dedupPeople returns two people who are both friends with a third, the
+// second also with the first.
+func dedupPeople() []*limitPerson {
+	three := &limitPerson{ID: "3"}
+	one := &limitPerson{ID: "1", Friend: three}
+	two := &limitPerson{ID: "2", Friend: three, Friends: []*limitPerson{one}}
+	return []*limitPerson{one, two}
+}
+
+func TestIncludedDedup(t *testing.T) {
+	if got, want := includedKeys(t, dedupPeople()), []string{"people/3"}; !equalStrings(got, want) {
+		t.Errorf("included %v, want %v", got, want)
+	}
+	doc := marshalDoc(t, dedupPeople())
+	if _, ok := doc["meta"]; ok {
+		t.Errorf("meta %v, want none without WithDeduplicatedMeta", doc["meta"])
+	}
+}
+
+func TestWithoutIncludedDedup(t *testing.T) {
+	got := includedKeys(t, dedupPeople(), WithoutIncludedDedup())
+	if want := []string{"people/3", "people/3", "people/1"}; !equalStrings(got, want) {
+		t.Errorf("included %v, want %v", got, want)
+	}
+
+	// Resources referring to nothing add nothing.
+	doc := marshalDoc(t, []*limitPerson{{ID: "1"}}, WithoutIncludedDedup())
+	if included, ok := doc["included"].([]interface{}); ok && len(included) != 0 {
+		t.Errorf("included %v", included)
+	}
+}
+
+func TestWithDeduplicatedMeta(t *testing.T) {
+	tests := []struct {
+		opts []MarshalOption
+		want float64
+	}{
+		{[]MarshalOption{WithDeduplicatedMeta()}, 2},
+		{[]MarshalOption{WithDeduplicatedMeta(), WithoutIncludedDedup()}, 0},
+	}
+	for _, tt := range tests {
+		doc := marshalDoc(t, dedupPeople(), tt.opts...)
+		meta, _ := doc["meta"].(map[string]interface{})
+		if meta[KeyDeduplicatedMeta] != tt.want {
+			t.Errorf("meta %v, want %s %v", doc["meta"], KeyDeduplicatedMeta, tt.want)
+		}
+	}
+
+	// Single-resource documents are not deduplicated.
+	doc := marshalDoc(t, dedupPeople()[1], WithDeduplicatedMeta())
+	if _, ok := doc["meta"]; ok {
+		t.Errorf("meta %v, want none for a single resource", doc["meta"])
+	}
+}
+
+func TestWithDeduplicated(t *testing.T) {
+	meta := &Meta{"total": 3}
+	got := withDeduplicated(meta, 2)
+	if (*got)["total"] != 3 || (*got)[KeyDeduplicatedMeta] != 2 {
+		t.Errorf("meta %v", *got)
+	}
+	if _, ok := (*meta)[KeyDeduplicatedMeta]; ok {
+		t.Errorf("original meta %v changed", *meta)
+	}
+	if got := withDeduplicated(nil, 0); (*got)[KeyDeduplicatedMeta] != 0 {
+		t.Errorf("meta %v", *got)
+	}
+}
@@ -0,0 +1,103 @@
+package jsonapi
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An attribute tagged with default=LITERAL is given that value when a
+// document being unmarshaled leaves it out and the field is still zero, so
+// that defaults never overwrite values already loaded into the model:
+//
+//	Status  string        `jsonapi:"attr,status,default=pending"`
+//	Retries int           `jsonapi:"attr,retries,default=3"`
+//	Timeout time.Duration `jsonapi:"attr,timeout,default=30s"`
+//
+// The literal is parsed according to the field type: strings are taken as
+// is, bools, integers and floats with strconv, time.Duration with
+// time.ParseDuration and types implementing encoding.TextUnmarshaler with
+// UnmarshalText. Pointer fields get a pointer to the value. The literal
+// cannot contain a comma, which separates tag options.
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// defaultOption returns the literal of the default option among the tag
+// options opts.
+func defaultOption(opts []string) (string, bool) {
+	for _, opt := range opts {
+		if strings.HasPrefix(opt, annotationDefault+"=") {
+			return opt[len(annotationDefault)+1:], true
+		}
+	}
+	return "", false
+}
+
+// setDefault stores literal in fieldValue if it is zero.
+func setDefault(fieldValue reflect.Value, literal string) error {
+	if !fieldValue.IsZero() {
+		return nil
+	}
+	v, err := parseDefault(fieldValue.Type(), literal)
+	if err != nil {
+		return err
+	}
+	fieldValue.Set(v)
+	return nil
+}
+
+// parseDefault converts literal to a value of type t.
+func parseDefault(t reflect.Type, literal string) (reflect.Value, error) {
+	if t.Kind() == reflect.Ptr {
+		v, err := parseDefault(t.Elem(), literal)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		p := reflect.New(t.Elem())
+		p.Elem().Set(v)
+		return p, nil
+	}
+
+	v := reflect.New(t).Elem()
+	var err error
+
+	switch {
+	case t == durationType:
+		var d time.Duration
+		d, err = time.ParseDuration(literal)
+		v.SetInt(int64(d))
+	case reflect.PtrTo(t).Implements(textUnmarshalerType):
+		err = v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(literal))
+	default:
+		switch t.Kind() {
+		case reflect.String:
+			v.SetString(literal)
+		case reflect.Bool:
+			var b bool
+			b, err = strconv.ParseBool(literal)
+			v.SetBool(b)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			var n int64
+			n, err = strconv.ParseInt(literal, 10, t.Bits())
+			v.SetInt(n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			var n uint64
+			n, err = strconv.ParseUint(literal, 10, t.Bits())
+			v.SetUint(n)
+		case reflect.Float32, reflect.Float64:
+			var f float64
+			f, err = strconv.ParseFloat(literal, t.Bits())
+			v.SetFloat(f)
+		default:
+			return reflect.Value{}, fmt.Errorf("default values are not supported for fields of type %s", t)
+		}
+	}
+
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("invalid default %q for field of type %s: %v", literal, t, err)
+	}
+	return v, nil
+}
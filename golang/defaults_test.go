@@ -0,0 +1,105 @@
+package jsonapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type defaultsTask struct {
+	ID      string        `jsonapi:"primary,tasks"`
+	Status  string        `jsonapi:"attr,status,default=pending"`
+	Retries int           `jsonapi:"attr,retries,default=3"`
+	Timeout time.Duration `jsonapi:"attr,timeout,default=30s"`
+	Urgent  *bool         `jsonapi:"attr,urgent,default=true"`
+	Due     time.Time     `jsonapi:"attr,due,iso8601,default=2020-01-02T00:00:00Z"`
+}
+
+func TestParseDefault(t *testing.T) {
+	tests := []struct {
+		literal string
+		want    interface{}
+	}{
+		{"x", "x"},
+		{"true", true},
+		{"-5", int8(-5)},
+		{"7", uint16(7)},
+		{"1.5", float32(1.5)},
+		{"1m30s", 90 * time.Second},
+		{"2020-01-02T00:00:00Z", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		v, err := parseDefault(reflect.TypeOf(tt.want), tt.literal)
+		if err != nil {
+			t.Errorf("%q: %v", tt.literal, err)
+			continue
+		}
+		if got := v.Interface(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: %#v, want %#v", tt.literal, got, tt.want)
+		}
+	}
+
+	v, err := parseDefault(reflect.TypeOf(new(int)), "4")
+	if err != nil || *v.Interface().(*int) != 4 {
+		t.Errorf("pointer default %v, %v", v, err)
+	}
+
+	for _, bad := range []struct {
+		t       reflect.Type
+		literal string
+	}{
+		{reflect.TypeOf(int8(0)), "300"},
+		{reflect.TypeOf(uint(0)), "-1"},
+		{reflect.TypeOf(false), "maybe"},
+		{reflect.TypeOf(time.Duration(0)), "soon"},
+		{reflect.TypeOf(time.Time{}), "yesterday"},
+		{reflect.TypeOf([]string{}), "a"},
+		{reflect.TypeOf(new(float64)), "x"},
+	} {
+		if _, err := parseDefault(bad.t, bad.literal); err == nil {
+			t.Errorf("parseDefault(%s, %q) succeeded", bad.t, bad.literal)
+		}
+	}
+}
+
+func TestUnmarshalDefaults(t *testing.T) {
+	doc := `{"data": {"type": "tasks", "id": "1", "attributes": {"retries": 0}}}`
+	out := new(defaultsTask)
+	if err := UnmarshalPayload(strings.NewReader(doc), out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Status != "pending" || out.Retries != 0 || out.Timeout != 30*time.Second ||
+		out.Urgent == nil || !*out.Urgent || !out.Due.Equal(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unmarshaled %+v", out)
+	}
+
+	// Values already in the model are kept, also without any attributes.
+	doc = `{"data": {"type": "tasks", "id": "1"}}`
+	out = &defaultsTask{Status: "done"}
+	if err := UnmarshalPayload(strings.NewReader(doc), out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Status != "done" || out.Retries != 3 {
+		t.Errorf("unmarshaled %+v", out)
+	}
+}
+
+func TestUnmarshalInvalidDefault(t *testing.T) {
+	type bad struct {
+		ID    string `jsonapi:"primary,bad"`
+		Count int    `jsonapi:"attr,count,default=many"`
+	}
+	err := UnmarshalPayload(strings.NewReader(`{"data": {"type": "bad", "id": "1"}}`), new(bad))
+	if err == nil || !strings.Contains(err.Error(), `invalid default "many"`) {
+		t.Errorf("error %v", err)
+	}
+
+	errs := CheckModel(&bad{})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), `invalid default "many"`) {
+		t.Errorf("CheckModel = %v", errs)
+	}
+	if errs := CheckModel(&defaultsTask{}); errs != nil {
+		t.Errorf("CheckModel(defaultsTask) = %v", errs)
+	}
+}
@@ -0,0 +1,192 @@
+package jsonapi
+
+import "sort"
+
+// Denormalized documents embed the related resources in the relationships
+// that refer to them instead of listing them in included:
+//
+//	"relationships": {"author": {"data": {"type": "people", "id": "9",
+//		"attributes": {"name": "Ann"}}}}
+//
+// This is not valid JSON:API, but saves internal consumers from resolving
+// linkages themselves. The unmarshal functions accept such documents, as
+// they fall back to the relationship data for resources missing from
+// included.
+
+// WithDenormalize makes Marshal and MarshalPayload write denormalized
+// documents, see OnePayload.Denormalize.
+func WithDenormalize() MarshalOption {
+	return func(o *marshalOptions) {
+		o.denormalize = true
+	}
+}
+
+// Denormalize replaces the resource identifiers in the relationships of
+// the document with copies of the included resources they refer to,
+// recursively, and empties included. A resource is embedded again
+// wherever it is referred to, except within itself: linkages that would
+// make a cycle stay identifiers.
+func (p *OnePayload) Denormalize() {
+	if p.Data != nil {
+		denormalize([]*Node{p.Data}, p.Included)
+	}
+	p.Included = nil
+}
+
+// Denormalize replaces the resource identifiers in the relationships of
+// the document with copies of the included resources they refer to; see
+// OnePayload.Denormalize.
+func (p *ManyPayload) Denormalize() {
+	denormalize(p.Data, p.Included)
+	p.Included = nil
+}
+
+// Normalize is the inverse of Denormalize: it moves resources embedded in
+// relationships to included, once each, leaving resource identifiers in
+// their place.
+func (p *OnePayload) Normalize() {
+	var data []*Node
+	if p.Data != nil {
+		data = []*Node{p.Data}
+	}
+	p.Included = normalize(data, p.Included)
+}
+
+// Normalize is the inverse of Denormalize; see OnePayload.Normalize.
+func (p *ManyPayload) Normalize() {
+	p.Included = normalize(p.Data, p.Included)
+}
+
+// denormalizePayload implements WithDenormalize.
+func denormalizePayload(payload Payloader) {
+	switch p := payload.(type) {
+	case *OnePayload:
+		p.Denormalize()
+	case *ManyPayload:
+		p.Denormalize()
+	}
+}
+
+func denormalize(data, included []*Node) {
+	index := includedMap(included)
+	for _, n := range data {
+		path := map[string]bool{nodeKey(n): true}
+		for name, rel := range n.Relationships {
+			n.Relationships[name] = mapLinkage(typedRelationship(rel), func(id *Node) *Node {
+				return embedNode(id, index, path)
+			})
+		}
+	}
+}
+
+// embedNode returns a copy of the included resource id refers to, with its
+// relationships denormalized, or id itself if it is not included or is
+// already being embedded along path.
+func embedNode(id *Node, index *map[string]*Node, path map[string]bool) *Node {
+	key := nodeKey(id)
+	full := fullNode(id, index)
+	if full == id || path[key] {
+		return id
+	}
+
+	path[key] = true
+	defer delete(path, key)
+
+	embedded := *full
+	if full.Relationships != nil {
+		embedded.Relationships = make(map[string]interface{}, len(full.Relationships))
+		for name, rel := range full.Relationships {
+			embedded.Relationships[name] = mapLinkage(typedRelationship(rel), func(id *Node) *Node {
+				return embedNode(id, index, path)
+			})
+		}
+	}
+	return &embedded
+}
+
+func normalize(data, included []*Node) []*Node {
+	resources := append(append([]*Node{}, data...), included...)
+	seen := make(map[string]bool, len(data)+len(included))
+	for _, n := range data {
+		seen[nodeKey(n)] = true
+	}
+	for _, n := range included {
+		seen[nodeKey(n)] = true
+	}
+
+	var extract func(n *Node)
+	extract = func(n *Node) {
+		// Go through relationships by name so that included is stable.
+		names := make([]string, 0, len(n.Relationships))
+		for name := range n.Relationships {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			n.Relationships[name] = mapLinkage(typedRelationship(n.Relationships[name]), func(d *Node) *Node {
+				if d.Attributes == nil && d.Relationships == nil && d.Links == nil {
+					return d
+				}
+				if !seen[nodeKey(d)] {
+					seen[nodeKey(d)] = true
+					included = append(included, d)
+					extract(d)
+				}
+				return &Node{Type: d.Type, ID: d.ID}
+			})
+		}
+	}
+
+	for _, n := range resources {
+		extract(n)
+	}
+	return included
+}
+
+// typedRelationship converts a relationship object decoded from JSON, a
+// map, into a *RelationshipOneNode or *RelationshipManyNode. Relationships
+// without data, and those already typed, are returned as they are.
+func typedRelationship(rel interface{}) interface{} {
+	m, ok := rel.(map[string]interface{})
+	if !ok {
+		return rel
+	}
+	data, ok := m["data"]
+	if !ok {
+		return rel
+	}
+
+	var typed interface{} = new(RelationshipOneNode)
+	if _, many := data.([]interface{}); many {
+		typed = new(RelationshipManyNode)
+	}
+	if err := remarshal(m, typed); err != nil {
+		return rel
+	}
+	return typed
+}
+
+// mapLinkage returns a copy of the relationship rel with each node of its
+// data replaced by f of it.
+func mapLinkage(rel interface{}, f func(*Node) *Node) interface{} {
+	switch r := rel.(type) {
+	case *RelationshipOneNode:
+		c := *r
+		if c.Data != nil {
+			c.Data = f(c.Data)
+		}
+		return &c
+	case *RelationshipManyNode:
+		c := *r
+		if r.Data == nil {
+			return &c
+		}
+		c.Data = make([]*Node, len(r.Data))
+		for i, n := range r.Data {
+			c.Data[i] = f(n)
+		}
+		return &c
+	}
+	return rel
+}
@@ -0,0 +1,144 @@
+package jsonapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func denormalizeArticle() *reqArticle {
+	ann := &reqAuthor{ID: "9", Name: "Ann"}
+	return &reqArticle{
+		ID:       "1",
+		Title:    "Hello",
+		Author:   ann,
+		Comments: []*reqComment{{ID: 5, Body: "First"}, {ID: 6, Body: "Second"}},
+	}
+}
+
+func TestWithDenormalize(t *testing.T) {
+	doc := marshalDoc(t, denormalizeArticle(), WithDenormalize())
+	if _, ok := doc["included"]; ok {
+		t.Errorf("included %v, want none", doc["included"])
+	}
+
+	rels := doc["data"].(map[string]interface{})["relationships"].(map[string]interface{})
+	author := rels["author"].(map[string]interface{})["data"].(map[string]interface{})
+	if author["id"] != "9" || author["attributes"].(map[string]interface{})["name"] != "Ann" {
+		t.Errorf("author %v, want the embedded person", author)
+	}
+	comments := rels["comments"].(map[string]interface{})["data"].([]interface{})
+	if len(comments) != 2 || comments[1].(map[string]interface{})["attributes"].(map[string]interface{})["body"] != "Second" {
+		t.Errorf("comments %v, want the embedded comments", comments)
+	}
+
+	// The unmarshal functions read denormalized documents.
+	b, err := MarshalBytes(denormalizeArticle(), WithDenormalize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(reqArticle)
+	if err := UnmarshalPayload(strings.NewReader(string(b)), out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Author == nil || out.Author.Name != "Ann" || len(out.Comments) != 2 || out.Comments[0].Body != "First" {
+		t.Errorf("unmarshaled %+v", out)
+	}
+}
+
+func TestWithDenormalizeMany(t *testing.T) {
+	a, b := denormalizeArticle(), denormalizeArticle()
+	b.ID = "2"
+	doc := marshalDoc(t, []*reqArticle{a, b}, WithDenormalize())
+	for _, r := range doc["data"].([]interface{}) {
+		author := r.(map[string]interface{})["relationships"].(map[string]interface{})["author"].(map[string]interface{})["data"].(map[string]interface{})
+		if author["attributes"] == nil {
+			t.Errorf("author %v, want it embedded in every resource", author)
+		}
+	}
+}
+
+func TestDenormalizeCycle(t *testing.T) {
+	one := &limitPerson{ID: "1", Name: "One"}
+	two := &limitPerson{ID: "2", Name: "Two", Friend: one}
+	one.Friend = two
+
+	p, err := Marshal(one)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := p.(*OnePayload)
+	payload.Denormalize()
+
+	friend := payload.Data.Relationships["friend"].(*RelationshipOneNode).Data
+	if friend.ID != "2" || friend.Attributes["name"] != "Two" {
+		t.Fatalf("friend %+v, want the embedded person", friend)
+	}
+	back := friend.Relationships["friend"].(*RelationshipOneNode).Data
+	if back.ID != "1" || back.Attributes != nil || back.Relationships != nil {
+		t.Errorf("friend of friend %+v, want an identifier", back)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	p, err := Marshal(denormalizeArticle())
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := p.(*OnePayload)
+	want := make([]string, len(payload.Included))
+	for i, n := range payload.Included {
+		want[i] = n.Type + "/" + n.ID
+	}
+
+	payload.Denormalize()
+	payload.Normalize()
+	got := make([]string, len(payload.Included))
+	for i, n := range payload.Included {
+		got[i] = n.Type + "/" + n.ID
+	}
+	// Normalize visits relationships by name: comments after author.
+	if !equalStrings(got, []string{"people/9", "comments/5", "comments/6"}) {
+		t.Errorf("included %v, had %v", got, want)
+	}
+	author := payload.Data.Relationships["author"].(*RelationshipOneNode).Data
+	if !reflect.DeepEqual(author, &Node{Type: "people", ID: "9"}) {
+		t.Errorf("author %+v, want an identifier", author)
+	}
+}
+
+func TestNormalizeDecoded(t *testing.T) {
+	// Relationships decoded from JSON are maps until typed.
+	payload := &ManyPayload{Data: []*Node{{
+		Type: "articles", ID: "1",
+		Relationships: map[string]interface{}{
+			"author": map[string]interface{}{"data": map[string]interface{}{
+				"type": "people", "id": "9", "attributes": map[string]interface{}{"name": "Ann"}}},
+			"tags": map[string]interface{}{"data": []interface{}{
+				map[string]interface{}{"type": "tags", "id": "t"}}},
+			"links-only": map[string]interface{}{"links": map[string]interface{}{"related": "/x"}},
+		},
+	}}}
+	payload.Normalize()
+
+	if len(payload.Included) != 1 || payload.Included[0].Attributes["name"] != "Ann" {
+		t.Errorf("included %+v", payload.Included)
+	}
+	rels := payload.Data[0].Relationships
+	if id := rels["author"].(*RelationshipOneNode).Data; id.Attributes != nil {
+		t.Errorf("author %+v, want an identifier", id)
+	}
+	if tags := rels["tags"].(*RelationshipManyNode).Data; len(tags) != 1 || tags[0].ID != "t" {
+		t.Errorf("tags %+v", tags)
+	}
+	if _, ok := rels["links-only"].(map[string]interface{}); !ok {
+		t.Errorf("links-only %T, want it untouched", rels["links-only"])
+	}
+
+	var empty OnePayload
+	empty.Normalize()
+	empty.Denormalize()
+	if empty.Included != nil {
+		t.Errorf("included %v", empty.Included)
+	}
+}
@@ -0,0 +1,88 @@
+package jsonapi
+
+import (
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// KeyDescribedByLink is the top-level link to a description of the
+// document, added by WithDescribedBy.
+const KeyDescribedByLink = "describedby"
+
+// SchemaMediaType is the media type of the JSON Schemas served by
+// SchemaHandler.
+const SchemaMediaType = "application/schema+json"
+
+// WithDescribedBy adds a top-level describedby link to documents, pointing
+// to baseURL/TYPE for the resource type of their primary data, where a
+// SchemaHandler serves its JSON Schema:
+//
+//	schemas, err := jsonapi.SchemaHandler(&Appointment{}, &Customer{})
+//	...
+//	mux.Handle("/schemas/", schemas)
+//	jsonapi.MarshalPayload(w, appt, jsonapi.WithDescribedBy("https://api.example.com/schemas"))
+//
+// A describedby link returned by a Linkable collection takes precedence.
+func WithDescribedBy(baseURL string) MarshalOption {
+	return func(o *marshalOptions) {
+		o.describedBy = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// addDescribedBy adds the describedby link of the resource type typ, or of
+// the type of the primary data if typ is "", to payload.
+func addDescribedBy(payload Payloader, typ, baseURL string) {
+	switch p := payload.(type) {
+	case *OnePayload:
+		if typ == "" && p.Data != nil {
+			typ = p.Data.Type
+		}
+		if typ != "" {
+			p.Links = mergeLinks(p.Links, Links{KeyDescribedByLink: baseURL + "/" + typ})
+		}
+	case *ManyPayload:
+		if typ == "" && len(p.Data) > 0 {
+			typ = p.Data[0].Type
+		}
+		if typ != "" {
+			p.Links = mergeLinks(p.Links, Links{KeyDescribedByLink: baseURL + "/" + typ})
+		}
+	}
+}
+
+// SchemaHandler returns an http.Handler serving the JSON Schema of each of
+// models, see ExportJSONSchema, at a path ending in its resource type, the
+// targets of the links of WithDescribedBy. Other paths get 404 Not Found.
+func SchemaHandler(models ...interface{}) (http.Handler, error) {
+	return SchemaHandlerOptions(nil, models...)
+}
+
+// SchemaHandlerOptions is SchemaHandler for models marshaled with opts,
+// e.g. WithTagKey, whose members the schemas describe.
+func SchemaHandlerOptions(opts []MarshalOption, models ...interface{}) (http.Handler, error) {
+	tags := &newMarshalOptions(opts).tagOptions
+	schemas := make(map[string][]byte, len(models))
+	for _, model := range models {
+		schema, err := ExportJSONSchema(model, opts...)
+		if err != nil {
+			return nil, err
+		}
+		schemas[modelTypeName(reflect.TypeOf(model), tags)] = schema
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		schema, ok := schemas[path.Base(r.URL.Path)]
+		if !ok {
+			_ = WriteErrorFor(w, r, &ErrorObject{
+				Title:  http.StatusText(http.StatusNotFound),
+				Status: strconv.Itoa(http.StatusNotFound),
+			})
+			return
+		}
+		w.Header().Set("Content-Type", SchemaMediaType)
+		_, _ = w.Write(schema)
+	}), nil
+}
@@ -0,0 +1,85 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type describedPeople []*reqAuthor
+
+func (describedPeople) JSONAPILinks() *Links {
+	return &Links{KeyDescribedByLink: "https://example.com/people.json"}
+}
+
+func TestWithDescribedBy(t *testing.T) {
+	opt := WithDescribedBy("https://api.example.com/schemas/")
+	tests := []struct {
+		name   string
+		models interface{}
+		want   interface{}
+	}{
+		{"one", &reqAuthor{ID: "1"}, "https://api.example.com/schemas/people"},
+		{"many", []*reqAuthor{{ID: "1"}}, "https://api.example.com/schemas/people"},
+		{"empty", []*reqAuthor{}, "https://api.example.com/schemas/people"},
+		{"linkable", describedPeople{{ID: "1"}}, "https://example.com/people.json"},
+	}
+	for _, tt := range tests {
+		doc := marshalDoc(t, tt.models, opt)
+		links, _ := doc["links"].(map[string]interface{})
+		if links[KeyDescribedByLink] != tt.want {
+			t.Errorf("%s: links %v, want describedby %v", tt.name, links, tt.want)
+		}
+	}
+
+	if _, ok := marshalDoc(t, &reqAuthor{ID: "1"})["links"]; ok {
+		t.Error("describedby link without the option")
+	}
+}
+
+func TestAddDescribedBy(t *testing.T) {
+	one := &OnePayload{Data: NewNode("people", "1")}
+	addDescribedBy(one, "", "/schemas")
+	if one.Links == nil || (*one.Links)[KeyDescribedByLink] != "/schemas/people" {
+		t.Errorf("links %v, want the type of the data", one.Links)
+	}
+
+	// Without a type there is nothing to describe.
+	empty, none := &OnePayload{}, &ManyPayload{}
+	addDescribedBy(empty, "", "/schemas")
+	addDescribedBy(none, "", "/schemas")
+	if empty.Links != nil || none.Links != nil {
+		t.Errorf("links %v and %v, want none", empty.Links, none.Links)
+	}
+}
+
+func TestSchemaHandler(t *testing.T) {
+	h, err := SchemaHandler(&reqAuthor{}, &reqComment{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/schemas/people", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != SchemaMediaType {
+		t.Fatalf("status %d, headers %v", w.Code, w.Header())
+	}
+	want, err := ExportJSONSchema(&reqAuthor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Body.String() != string(want) || !json.Valid(w.Body.Bytes()) {
+		t.Errorf("schema %s, want %s", w.Body, want)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/schemas/articles", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status %d, want 404", w.Code)
+	}
+
+	if _, err := SchemaHandler(1); err == nil {
+		t.Error("expected an error for a value that is not a model")
+	}
+}
@@ -0,0 +1,212 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// ErrInvalidDocument is returned by Parse for documents whose top level
+// breaks the rules of the specification: none of data, errors and meta, or
+// both data and errors.
+var ErrInvalidDocument = errors.New("jsonapi: invalid document structure")
+
+// Document is a JSON:API document of any shape: a single resource, a
+// collection or errors. It is meant for generic middleware and proxies
+// that inspect or rewrite documents without knowing up front what they
+// hold; handlers working with models use the payload types. Documents are
+// read with Parse, which tells null data from missing data and keeps
+// unknown members, and written with encoding/json.
+type Document struct {
+	// Data is nil when the document has no data member.
+	Data     *PrimaryData   `json:"data,omitempty"`
+	Errors   []*ErrorObject `json:"errors,omitempty"`
+	Meta     *Meta          `json:"meta,omitempty"`
+	Links    *Links         `json:"links,omitempty"`
+	Included []*Node        `json:"included,omitempty"`
+	JSONAPI  *JSONAPIObject `json:"jsonapi,omitempty"`
+
+	// Extras holds the top-level members the specification does not
+	// define, such as those of extensions ("atomic:results") and
+	// profiles, by name. They are written back as they are.
+	Extras map[string]json.RawMessage `json:"-"`
+	// ResourceExtras holds the members the specification does not define
+	// of the resource objects of the document, by resource object.
+	ResourceExtras map[*Node]map[string]json.RawMessage `json:"-"`
+}
+
+// PrimaryData is the data member of a Document: a resource object or null
+// in One, or an array of them in Many when IsMany is set.
+type PrimaryData struct {
+	One    *Node
+	Many   []*Node
+	IsMany bool
+}
+
+// JSONAPIObject is the jsonapi member of a document, describing the server
+// implementation.
+type JSONAPIObject struct {
+	Version string   `json:"version,omitempty"`
+	Ext     []string `json:"ext,omitempty"`
+	Profile []string `json:"profile,omitempty"`
+	Meta    *Meta    `json:"meta,omitempty"`
+}
+
+// Parse reads a document of any shape from in. MaxBodyBytes,
+// MaxIncludedResources, UseNumber and InternStrings apply. Members not
+// defined by the specification, at the top level and in resource objects,
+// are kept in Extras and ResourceExtras.
+func Parse(in io.Reader, opts ...UnmarshalOption) (*Document, error) {
+	o := newUnmarshalOptions(opts)
+
+	// Members are decoded one by one: encoding/json leaves a pointer nil
+	// for both a null and a missing data member, and the unknown ones are
+	// kept as they are.
+	var members map[string]json.RawMessage
+	if err := o.decode(in, &members); err != nil {
+		return nil, err
+	}
+
+	doc := new(Document)
+	for name, raw := range members {
+		// jsoniter decodes null to an empty json.RawMessage.
+		if len(raw) == 0 {
+			raw = json.RawMessage("null")
+		}
+		var err error
+		switch name {
+		case "data":
+			doc.Data, err = doc.parseData(raw, o)
+		case "included":
+			doc.Included, err = doc.parseResources(raw, o)
+		case "errors":
+			err = o.decode(bytes.NewReader(raw), &doc.Errors)
+		case "meta":
+			err = o.decode(bytes.NewReader(raw), &doc.Meta)
+		case "links":
+			err = o.decode(bytes.NewReader(raw), &doc.Links)
+		case "jsonapi":
+			err = o.decode(bytes.NewReader(raw), &doc.JSONAPI)
+		default:
+			if doc.Extras == nil {
+				doc.Extras = make(map[string]json.RawMessage)
+			}
+			doc.Extras[name] = raw
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if doc.Data == nil && doc.Errors == nil && doc.Meta == nil ||
+		doc.Data != nil && doc.Errors != nil {
+		return nil, ErrInvalidDocument
+	}
+	if err := o.checkIncluded(len(doc.Included)); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (d *Document) parseData(raw json.RawMessage, o *unmarshalOptions) (*PrimaryData, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		many, err := d.parseResources(raw, o)
+		if many == nil {
+			many = []*Node{}
+		}
+		return &PrimaryData{Many: many, IsMany: true}, err
+	}
+	one, err := d.parseResource(raw, o)
+	return &PrimaryData{One: one}, err
+}
+
+func (d *Document) parseResources(raw json.RawMessage, o *unmarshalOptions) ([]*Node, error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(raw, &raws); err != nil {
+		return nil, err
+	}
+	if raws == nil {
+		return nil, nil
+	}
+	nodes := make([]*Node, 0, len(raws))
+	for _, r := range raws {
+		n, err := d.parseResource(r, o)
+		if err != nil {
+			return nil, err
+		}
+		if n != nil {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes, nil
+}
+
+// parseResource decodes the resource object raw, nil for null, recording
+// its unknown members in d.
+func (d *Document) parseResource(raw json.RawMessage, o *unmarshalOptions) (*Node, error) {
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return nil, nil
+	}
+	n := new(Node)
+	if err := o.decode(bytes.NewReader(raw), n); err != nil {
+		return nil, err
+	}
+	if extras := unknownMembers(raw, resourceMembers); extras != nil {
+		if d.ResourceExtras == nil {
+			d.ResourceExtras = make(map[*Node]map[string]json.RawMessage)
+		}
+		d.ResourceExtras[n] = extras
+	}
+	return n, nil
+}
+
+// Resources returns the primary data of d as a slice, whatever its shape:
+// empty for null data and for documents without data.
+func (d *Document) Resources() []*Node {
+	switch {
+	case d.Data == nil:
+		return nil
+	case d.Data.IsMany:
+		return d.Data.Many
+	case d.Data.One != nil:
+		return []*Node{d.Data.One}
+	}
+	return nil
+}
+
+// Payload returns d as a *OnePayload or a *ManyPayload, dropping the
+// jsonapi member, or nil for documents without data.
+func (d *Document) Payload() Payloader {
+	switch {
+	case d.Data == nil:
+		return nil
+	case d.Data.IsMany:
+		return &ManyPayload{Data: d.Data.Many, Included: d.Included, Links: d.Links, Meta: d.Meta}
+	}
+	return &OnePayload{Data: d.Data.One, Included: d.Included, Links: d.Links, Meta: d.Meta}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p PrimaryData) MarshalJSON() ([]byte, error) {
+	if p.IsMany {
+		if p.Many == nil {
+			return []byte("[]"), nil
+		}
+		return json.Marshal(p.Many)
+	}
+	return json.Marshal(p.One)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *PrimaryData) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	*p = PrimaryData{}
+	if len(b) > 0 && b[0] == '[' {
+		p.IsMany = true
+		p.Many = []*Node{}
+		return json.Unmarshal(b, &p.Many)
+	}
+	return json.Unmarshal(b, &p.One)
+}
@@ -0,0 +1,165 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		doc       string
+		data      bool
+		isMany    bool
+		resources int
+	}{
+		{reqArticleDoc, true, false, 1},
+		{`{"data": null}`, true, false, 0},
+		{`{"data": []}`, true, true, 0},
+		{`{"data": [{"type": "a", "id": "1"}, null, {"type": "a", "id": "2"}]}`, true, true, 2},
+		{`{"errors": [{"status": "404", "title": "Not Found"}]}`, false, false, 0},
+		{`{"meta": {"count": 3}}`, false, false, 0},
+	}
+	for _, tt := range tests {
+		doc, err := Parse(strings.NewReader(tt.doc))
+		if err != nil {
+			t.Errorf("%.30s: %v", tt.doc, err)
+			continue
+		}
+		if (doc.Data != nil) != tt.data || doc.Data != nil && doc.Data.IsMany != tt.isMany {
+			t.Errorf("%.30s: data %+v", tt.doc, doc.Data)
+		}
+		if got := len(doc.Resources()); got != tt.resources {
+			t.Errorf("%.30s: %d resources, want %d", tt.doc, got, tt.resources)
+		}
+	}
+
+	doc, err := Parse(strings.NewReader(reqArticleDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Data.One.ID != "1" || len(doc.Included) != 2 || doc.Included[0].Attributes["name"] != "Ann" {
+		t.Errorf("document %+v", doc)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		doc  string
+		opts []UnmarshalOption
+		want error
+	}{
+		{`{}`, nil, ErrInvalidDocument},
+		{`{"links": {"self": "/"}}`, nil, ErrInvalidDocument},
+		{`{"data": null, "errors": []}`, nil, ErrInvalidDocument},
+		{`{"data": [], "included": [{"type": "a", "id": "1"}, {"type": "a", "id": "2"}]}`,
+			[]UnmarshalOption{MaxIncludedResources(1)}, ErrTooManyIncluded},
+		{`{"data": null, "meta": {"pad": "` + strings.Repeat("x", 100) + `"}}`,
+			[]UnmarshalOption{MaxBodyBytes(64)}, ErrBodyTooLarge},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(strings.NewReader(tt.doc), tt.opts...); !errors.Is(err, tt.want) {
+			t.Errorf("%.40s: error %v, want %v", tt.doc, err, tt.want)
+		}
+	}
+	if got := StatusForError(ErrInvalidDocument); got != http.StatusBadRequest {
+		t.Errorf("status %d for ErrInvalidDocument, want 400", got)
+	}
+
+	for _, doc := range []string{`[]`, `{"data": 1}`, `{"data": [1]}`, `{"errors": {}}`, `{"data": `} {
+		if _, err := Parse(strings.NewReader(doc)); err == nil {
+			t.Errorf("%s: expected an error", doc)
+		}
+	}
+}
+
+func TestParseUseNumber(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`{"data": {"type": "a", "id": "1", "attributes": {"n": 1}}, "meta": {"m": 2}}`), UseNumber())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Data.One.Attributes["n"] != json.Number("1") || (*doc.Meta)["m"] != json.Number("2") {
+		t.Errorf("attributes %v, meta %v, want json.Number", doc.Data.One.Attributes, *doc.Meta)
+	}
+}
+
+func TestDocumentPayload(t *testing.T) {
+	doc, err := Parse(strings.NewReader(reqArticleDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	one, ok := doc.Payload().(*OnePayload)
+	if !ok || one.Data != doc.Data.One || len(one.Included) != 2 {
+		t.Errorf("payload %+v", doc.Payload())
+	}
+
+	doc, err = Parse(strings.NewReader(`{"data": [{"type": "a", "id": "1"}], "meta": {"n": 1}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	many, ok := doc.Payload().(*ManyPayload)
+	if !ok || len(many.Data) != 1 || many.Meta == nil {
+		t.Errorf("payload %+v", doc.Payload())
+	}
+
+	if p := (&Document{}).Payload(); p != nil {
+		t.Errorf("payload %+v, want nil without data", p)
+	}
+}
+
+func TestDocumentRoundTrip(t *testing.T) {
+	for _, in := range []string{
+		`{"data":null}`,
+		`{"data":[]}`,
+		`{"jsonapi":{"version":"1.1"},"data":{"type":"a","id":"1"}}`,
+		`{"errors":[{"title":"Gone","status":"410"}]}`,
+	} {
+		doc, err := Parse(strings.NewReader(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != in {
+			t.Errorf("round trip of %s gave %s", in, out)
+		}
+	}
+}
+
+func TestPrimaryDataJSON(t *testing.T) {
+	tests := []struct {
+		in     string
+		isMany bool
+		out    string
+	}{
+		{`null`, false, `null`},
+		{`{"type":"a","id":"1"}`, false, `{"type":"a","id":"1"}`},
+		{` []`, true, `[]`},
+		{`[{"type":"a","id":"1"}]`, true, `[{"type":"a","id":"1"}]`},
+	}
+	for _, tt := range tests {
+		var p PrimaryData
+		if err := json.Unmarshal([]byte(tt.in), &p); err != nil {
+			t.Fatal(err)
+		}
+		if p.IsMany != tt.isMany {
+			t.Errorf("%s: %+v", tt.in, p)
+		}
+		out, err := json.Marshal(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != tt.out {
+			t.Errorf("%s: marshaled %s, want %s", tt.in, out, tt.out)
+		}
+	}
+
+	out, err := json.Marshal(PrimaryData{IsMany: true})
+	if err != nil || string(out) != "[]" {
+		t.Errorf("nil many: %s, %v", out, err)
+	}
+}
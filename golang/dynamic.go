@@ -0,0 +1,218 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// ErrInvalidRelationship is returned when a relationship object in a
+// payload is neither null, a resource identifier nor an array of them.
+var ErrInvalidRelationship = errors.New("jsonapi: invalid relationship data")
+
+// ErrNilDynamicResource is returned for a nil *DynamicResource, which has
+// no resource object.
+var ErrNilDynamicResource = errors.New("jsonapi: nil *DynamicResource")
+
+// DynamicResource is a resource object of arbitrary shape. It can be passed
+// to Marshal and MarshalPayload like a tagged struct, and is produced by
+// UnmarshalDynamicPayload and UnmarshalManyDynamicPayload, which makes it
+// suitable for gateways and proxies that pass resources through without
+// knowing their Go types.
+type DynamicResource struct {
+	Type          string
+	ID            string
+	Attributes    map[string]interface{}
+	Relationships map[string]Relationship
+	Links         *Links
+	Meta          *Meta
+}
+
+// ResourceIdentifier identifies a single resource by type and id.
+type ResourceIdentifier struct {
+	Type string
+	ID   string
+}
+
+// Relationship is a relationship of a DynamicResource. Many tells a to-many
+// relationship from a to-one one; a to-one relationship without Data is
+// null. NoData marks a relationship object without a data member, e.g. one
+// with links only, which is written back without one.
+type Relationship struct {
+	Data   []ResourceIdentifier
+	Many   bool
+	NoData bool
+	Links  *Links
+	Meta   *Meta
+}
+
+// Node converts r to the Node representation used in payloads.
+func (r *DynamicResource) Node() (*Node, error) {
+	if r == nil {
+		return nil, ErrNilDynamicResource
+	}
+	node := &Node{
+		Type:       r.Type,
+		ID:         r.ID,
+		Attributes: r.Attributes,
+		Links:      r.Links,
+		Meta:       r.Meta,
+	}
+
+	if len(r.Relationships) > 0 {
+		node.Relationships = make(map[string]interface{}, len(r.Relationships))
+	}
+	for name, rel := range r.Relationships {
+		if rel.NoData {
+			// Without links or meta either, there is no relationship
+			// object to write.
+			if rel.Links != nil || rel.Meta != nil {
+				node.Relationships[name] = &RelationshipLinksNode{Links: rel.Links, Meta: rel.Meta}
+			}
+			continue
+		}
+		if rel.Many {
+			data := make([]*Node, len(rel.Data))
+			for i, id := range rel.Data {
+				data[i] = &Node{Type: id.Type, ID: id.ID}
+			}
+			node.Relationships[name] = &RelationshipManyNode{
+				Data:  data,
+				Links: rel.Links,
+				Meta:  rel.Meta,
+			}
+			continue
+		}
+
+		one := &RelationshipOneNode{Links: rel.Links, Meta: rel.Meta}
+		if len(rel.Data) > 0 {
+			one.Data = &Node{Type: rel.Data[0].Type, ID: rel.Data[0].ID}
+		}
+		node.Relationships[name] = one
+	}
+
+	return node, nil
+}
+
+// NewDynamicResource converts a Node, either built by Marshal or decoded
+// from JSON, into a DynamicResource.
+func NewDynamicResource(n *Node) (*DynamicResource, error) {
+	r := &DynamicResource{
+		Type:       n.Type,
+		ID:         n.ID,
+		Attributes: n.Attributes,
+		Links:      n.Links,
+		Meta:       n.Meta,
+	}
+
+	if len(n.Relationships) > 0 {
+		r.Relationships = make(map[string]Relationship, len(n.Relationships))
+	}
+	for name, v := range n.Relationships {
+		rel, err := toRelationship(v)
+		if err != nil {
+			return nil, err
+		}
+		r.Relationships[name] = rel
+	}
+
+	return r, nil
+}
+
+func toRelationship(v interface{}) (Relationship, error) {
+	switch v := v.(type) {
+	case *RelationshipOneNode:
+		rel := Relationship{Links: v.Links, Meta: v.Meta}
+		if v.Data != nil {
+			rel.Data = []ResourceIdentifier{{Type: v.Data.Type, ID: v.Data.ID}}
+		}
+		return rel, nil
+	case *RelationshipManyNode:
+		rel := Relationship{Many: true, Links: v.Links, Meta: v.Meta}
+		for _, n := range v.Data {
+			rel.Data = append(rel.Data, ResourceIdentifier{Type: n.Type, ID: n.ID})
+		}
+		return rel, nil
+	case *RelationshipLinksNode:
+		return Relationship{NoData: true, Links: v.Links, Meta: v.Meta}, nil
+	}
+
+	// Relationships decoded straight from JSON are generic maps; round-trip
+	// them through the typed nodes to pick the right shape.
+	b, err := json.Marshal(v)
+	if err != nil {
+		return Relationship{}, err
+	}
+	var raw struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return Relationship{}, ErrInvalidRelationship
+	}
+
+	if len(raw.Data) == 0 {
+		links := new(RelationshipLinksNode)
+		if err := json.Unmarshal(b, links); err != nil {
+			return Relationship{}, ErrInvalidRelationship
+		}
+		return toRelationship(links)
+	}
+	if raw.Data[0] == '[' {
+		many := new(RelationshipManyNode)
+		if err := json.Unmarshal(b, many); err != nil {
+			return Relationship{}, ErrInvalidRelationship
+		}
+		return toRelationship(many)
+	}
+
+	one := new(RelationshipOneNode)
+	if err := json.Unmarshal(b, one); err != nil {
+		return Relationship{}, ErrInvalidRelationship
+	}
+	return toRelationship(one)
+}
+
+// UnmarshalDynamicPayload reads a single-resource document into a
+// DynamicResource. Included resources are ignored; decode a OnePayload and
+// convert its nodes with NewDynamicResource to keep them.
+func UnmarshalDynamicPayload(in io.Reader) (*DynamicResource, error) {
+	payload := new(OnePayload)
+	if err := json.NewDecoder(in).Decode(payload); err != nil {
+		return nil, err
+	}
+	if payload.Data == nil {
+		return nil, nil
+	}
+	return NewDynamicResource(payload.Data)
+}
+
+// UnmarshalManyDynamicPayload reads a collection document into a slice of
+// DynamicResource.
+func UnmarshalManyDynamicPayload(in io.Reader) ([]*DynamicResource, error) {
+	payload := new(ManyPayload)
+	if err := json.NewDecoder(in).Decode(payload); err != nil {
+		return nil, err
+	}
+
+	resources := make([]*DynamicResource, 0, len(payload.Data))
+	for _, n := range payload.Data {
+		r, err := NewDynamicResource(n)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, r)
+	}
+	return resources, nil
+}
+
+func marshalDynamic(models []*DynamicResource) (*ManyPayload, error) {
+	payload := &ManyPayload{Data: make([]*Node, 0, len(models))}
+	for _, r := range models {
+		n, err := r.Node()
+		if err != nil {
+			return nil, err
+		}
+		payload.Data = append(payload.Data, n)
+	}
+	return payload, nil
+}
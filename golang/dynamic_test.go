@@ -0,0 +1,96 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// roundTripDynamic decodes doc as a DynamicResource and marshals it again.
+func roundTripDynamic(t *testing.T, doc string) map[string]interface{} {
+	t.Helper()
+	r, err := UnmarshalDynamicPayload(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("UnmarshalDynamicPayload: %v", err)
+	}
+	b, err := MarshalBytes(r)
+	if err != nil {
+		t.Fatalf("MarshalBytes: %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestDynamicResourcePassThrough(t *testing.T) {
+	doc := `{"data":{"type":"articles","id":"1",
+		"attributes":{"title":"Hello","tags":["a","b"]},
+		"relationships":{
+			"author":{"data":{"type":"people","id":"9"}},
+			"editor":{"data":null},
+			"comments":{"data":[{"type":"comments","id":"5"},{"type":"comments","id":"12"}]},
+			"tags":{"data":[]},
+			"reviews":{"links":{"related":"/articles/1/reviews"}},
+			"stats":{"meta":{"views":3}}
+		}}}`
+
+	var want map[string]interface{}
+	if err := json.Unmarshal([]byte(doc), &want); err != nil {
+		t.Fatal(err)
+	}
+	if got := roundTripDynamic(t, doc); !reflect.DeepEqual(got, want) {
+		gb, _ := json.Marshal(got)
+		wb, _ := json.Marshal(want)
+		t.Errorf("round trip changed the document:\ngot  %s\nwant %s", gb, wb)
+	}
+}
+
+func TestDynamicRelationshipShapes(t *testing.T) {
+	r, err := UnmarshalDynamicPayload(strings.NewReader(`{"data":{"type":"a","id":"1","relationships":{
+		"one":{"data":{"type":"b","id":"2"}},
+		"null":{"data":null},
+		"many":{"data":[{"type":"b","id":"3"}]},
+		"links":{"links":{"self":"/a/1/relationships/links"}}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]Relationship{
+		"one":   {Data: []ResourceIdentifier{{Type: "b", ID: "2"}}},
+		"null":  {},
+		"many":  {Data: []ResourceIdentifier{{Type: "b", ID: "3"}}, Many: true},
+		"links": {NoData: true, Links: &Links{"self": "/a/1/relationships/links"}},
+	}
+	for name, want := range tests {
+		if got := r.Relationships[name]; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s = %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestDynamicResourceNil(t *testing.T) {
+	var r *DynamicResource
+	if _, err := r.Node(); err != ErrNilDynamicResource {
+		t.Errorf("Node() error = %v, want ErrNilDynamicResource", err)
+	}
+	if _, err := Marshal(r); err != ErrNilDynamicResource {
+		t.Errorf("Marshal error = %v, want ErrNilDynamicResource", err)
+	}
+	if _, err := Marshal([]*DynamicResource{{Type: "a", ID: "1"}, nil}); err != ErrNilDynamicResource {
+		t.Errorf("Marshal of a slice with nil error = %v, want ErrNilDynamicResource", err)
+	}
+}
+
+func TestUnmarshalManyDynamicPayload(t *testing.T) {
+	rs, err := UnmarshalManyDynamicPayload(strings.NewReader(
+		`{"data":[{"type":"a","id":"1","attributes":{"n":1}},{"type":"a","id":"2"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 2 || rs[0].ID != "1" || rs[1].ID != "2" || rs[0].Attributes["n"] != float64(1) {
+		t.Errorf("got %+v", rs)
+	}
+}
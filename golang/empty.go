@@ -0,0 +1,57 @@
+package jsonapi
+
+import "encoding/json"
+
+// EmptyToMany selects how Marshal writes a to-many relationship whose
+// relation field is empty.
+type EmptyToMany int
+
+const (
+	// EmptyToManyData writes `"data": []`, stating that the relationship is
+	// known to be empty. This is the default.
+	EmptyToManyData EmptyToMany = iota
+	// EmptyToManyOmit leaves the relationship out, as the omitempty tag
+	// option does for a single field.
+	EmptyToManyOmit
+	// EmptyToManyLinks writes the relationship's links and meta without
+	// resource linkage, for fields that were not loaded rather than found
+	// empty. A relationship with neither falls back to `"data": []`.
+	EmptyToManyLinks
+)
+
+// WithEmptyToMany sets how empty to-many relationships are written. The
+// omitempty tag option takes precedence.
+func WithEmptyToMany(mode EmptyToMany) MarshalOption {
+	return func(o *marshalOptions) {
+		o.emptyToMany = mode
+	}
+}
+
+// RelationshipLinksNode is a relationship object without resource
+// linkage, written for empty to-many relationships with EmptyToManyLinks.
+type RelationshipLinksNode struct {
+	Links *Links `json:"links,omitempty"`
+	Meta  *Meta  `json:"meta,omitempty"`
+}
+
+// MarshalJSON writes an empty data member if n has neither links nor meta,
+// since a relationship object must contain at least one of the three.
+func (n *RelationshipLinksNode) MarshalJSON() ([]byte, error) {
+	if n.Links == nil && n.Meta == nil {
+		return []byte(`{"data":[]}`), nil
+	}
+	type node RelationshipLinksNode
+	return json.Marshal((*node)(n))
+}
+
+// emptyToMany applies mode to rel, an empty to-many relationship, and
+// returns the relationship object to write, or nil to leave it out.
+func emptyToMany(rel *RelationshipManyNode, mode EmptyToMany) interface{} {
+	switch mode {
+	case EmptyToManyOmit:
+		return nil
+	case EmptyToManyLinks:
+		return &RelationshipLinksNode{Links: rel.Links, Meta: rel.Meta}
+	}
+	return rel
+}
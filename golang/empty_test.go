@@ -0,0 +1,67 @@
+package jsonapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+type emptyPost struct {
+	ID       string          `jsonapi:"primary,posts"`
+	Comments []*orderComment `jsonapi:"relation,comments"`
+	Tags     []*orderComment `jsonapi:"relation,tags"`
+	Notes    []*orderComment `jsonapi:"relation,notes,omitempty"`
+}
+
+func (p *emptyPost) JSONAPIRelationshipLinks(relation string) *Links {
+	if relation == "comments" {
+		return &Links{KeyRelatedLink: "/posts/" + p.ID + "/comments"}
+	}
+	return nil
+}
+
+func TestWithEmptyToMany(t *testing.T) {
+	tests := []struct {
+		mode EmptyToMany
+		want map[string]interface{}
+	}{
+		{EmptyToManyData, map[string]interface{}{
+			"comments": map[string]interface{}{"data": []interface{}{}, "links": map[string]interface{}{"related": "/posts/1/comments"}},
+			"tags":     map[string]interface{}{"data": []interface{}{}},
+		}},
+		{EmptyToManyOmit, nil},
+		{EmptyToManyLinks, map[string]interface{}{
+			"comments": map[string]interface{}{"links": map[string]interface{}{"related": "/posts/1/comments"}},
+			// Without links or meta, there is nothing but data to write.
+			"tags": map[string]interface{}{"data": []interface{}{}},
+		}},
+	}
+	for _, tt := range tests {
+		data := marshalDoc(t, &emptyPost{ID: "1"}, WithEmptyToMany(tt.mode))["data"].(map[string]interface{})
+		rels, _ := data["relationships"].(map[string]interface{})
+		if !reflect.DeepEqual(rels, tt.want) {
+			t.Errorf("mode %d: relationships %v, want %v", tt.mode, rels, tt.want)
+		}
+	}
+
+	// Non-empty relationships are unaffected.
+	post := &emptyPost{ID: "1", Tags: []*orderComment{{ID: "a"}}}
+	rels := marshalDoc(t, post, WithEmptyToMany(EmptyToManyOmit))["data"].(map[string]interface{})["relationships"].(map[string]interface{})
+	if _, ok := rels["tags"]; !ok || len(rels) != 1 {
+		t.Errorf("relationships %v, want tags only", rels)
+	}
+}
+
+func TestEmptyToManyDynamic(t *testing.T) {
+	payload, err := Marshal(&emptyPost{ID: "1"}, WithEmptyToMany(EmptyToManyLinks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewDynamicResource(payload.(*OnePayload).Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments := r.Relationships["comments"]
+	if !comments.NoData || comments.Links == nil || len(comments.Data) != 0 {
+		t.Errorf("comments %+v, want links without data", comments)
+	}
+}
@@ -0,0 +1,190 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownEnumValue is wrapped by the errors returned when an enum
+// attribute holds a value, or a document holds a name, that was not
+// registered with RegisterEnum.
+var ErrUnknownEnumValue = errors.New("jsonapi: unknown enum value")
+
+// enumNames maps the values of one integer type to their names and back.
+// Values of unsigned types are stored converted to int64.
+type enumNames struct {
+	byValue map[int64]string
+	byName  map[string]int64
+}
+
+var enums = struct {
+	sync.RWMutex
+	types map[reflect.Type]*enumNames
+}{types: map[reflect.Type]*enumNames{}}
+
+// RegisterEnum makes attributes of an integer-backed enum type marshal to
+// the names of their values and unmarshal from them, instead of exposing
+// the numbers. names maps every value of the type to its name:
+//
+//	jsonapi.RegisterEnum(map[Status]string{
+//		StatusDraft:     "draft",
+//		StatusPublished: "published",
+//	})
+//
+// Pointers and slices of the type are converted too. Values and names that
+// were not registered are errors wrapping ErrUnknownEnumValue. Registering
+// a type again replaces its names. It should be called at start-up.
+func RegisterEnum(names interface{}) error {
+	v := reflect.ValueOf(names)
+	if v.Kind() != reflect.Map || v.Type().Elem().Kind() != reflect.String || !isIntegerKind(v.Type().Key().Kind()) {
+		return fmt.Errorf("jsonapi: RegisterEnum takes a map from an integer type to string, got %T", names)
+	}
+
+	e := &enumNames{
+		byValue: make(map[int64]string, v.Len()),
+		byName:  make(map[string]int64, v.Len()),
+	}
+	iter := v.MapRange()
+	for iter.Next() {
+		n := enumInt(iter.Key())
+		name := iter.Value().String()
+		if _, ok := e.byName[name]; ok {
+			return fmt.Errorf("jsonapi: enum name %q of %s is used twice", name, v.Type().Key())
+		}
+		e.byValue[n] = name
+		e.byName[name] = n
+	}
+
+	enums.Lock()
+	enums.types[v.Type().Key()] = e
+	enums.Unlock()
+	return nil
+}
+
+// EnumNames returns the registered names of the enum type of v, sorted,
+// e.g. for documentation or a oneof validation.
+func EnumNames(v interface{}) []string {
+	return enumTypeNames(reflect.TypeOf(v))
+}
+
+func enumTypeNames(t reflect.Type) []string {
+	e := lookupEnum(t)
+	if e == nil {
+		return nil
+	}
+	names := make([]string, 0, len(e.byName))
+	for name := range e.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupEnum(t reflect.Type) *enumNames {
+	if t == nil {
+		return nil
+	}
+	enums.RLock()
+	defer enums.RUnlock()
+	return enums.types[t]
+}
+
+func isIntegerKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func enumInt(v reflect.Value) int64 {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	}
+	return v.Int()
+}
+
+// isEnumType reports whether t, or the element type of the pointer or
+// slice t, is a registered enum.
+func isEnumType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return lookupEnum(t) != nil
+}
+
+// marshalEnum returns the attribute value of v, whose type satisfies
+// isEnumType: a name, nil for a nil pointer or a slice of names.
+func marshalEnum(v reflect.Value) (interface{}, error) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return marshalEnum(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil, nil
+		}
+		names := make([]string, v.Len())
+		for i := range names {
+			name, err := marshalEnum(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			names[i] = name.(string)
+		}
+		return names, nil
+	}
+
+	name, ok := lookupEnum(v.Type()).byValue[enumInt(v)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v of %s", ErrUnknownEnumValue, v.Interface(), v.Type())
+	}
+	return name, nil
+}
+
+// unmarshalEnum converts the attribute value of an enum field of type t,
+// not a pointer, into a pointer to a value of t.
+func unmarshalEnum(attribute interface{}, t reflect.Type) (reflect.Value, error) {
+	ptr := reflect.New(t)
+
+	if t.Kind() == reflect.Slice {
+		values, ok := attribute.([]interface{})
+		if !ok {
+			return reflect.Value{}, ErrInvalidType
+		}
+		slice := reflect.MakeSlice(t, len(values), len(values))
+		for i, v := range values {
+			elem, err := unmarshalEnum(v, t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			slice.Index(i).Set(elem.Elem())
+		}
+		ptr.Elem().Set(slice)
+		return ptr, nil
+	}
+
+	name, ok := attribute.(string)
+	if !ok {
+		return reflect.Value{}, ErrInvalidType
+	}
+	n, ok := lookupEnum(t).byName[name]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("%w: %q is not a name of %s", ErrUnknownEnumValue, name, t)
+	}
+
+	switch t.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		ptr.Elem().SetUint(uint64(n))
+	default:
+		ptr.Elem().SetInt(n)
+	}
+	return ptr, nil
+}
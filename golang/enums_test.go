@@ -0,0 +1,130 @@
+package jsonapi
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type enumStatus int
+
+const (
+	enumDraft enumStatus = iota
+	enumPublished
+	enumArchived
+)
+
+type enumLevel uint8
+
+type enumPost struct {
+	ID      string       `jsonapi:"primary,posts"`
+	Status  enumStatus   `jsonapi:"attr,status"`
+	Prev    *enumStatus  `jsonapi:"attr,prev"`
+	History []enumStatus `jsonapi:"attr,history"`
+	Level   enumLevel    `jsonapi:"attr,level"`
+}
+
+func init() {
+	if err := RegisterEnum(map[enumStatus]string{enumDraft: "draft", enumPublished: "published"}); err != nil {
+		panic(err)
+	}
+	if err := RegisterEnum(map[enumLevel]string{1: "low", 200: "high"}); err != nil {
+		panic(err)
+	}
+}
+
+func TestRegisterEnumErrors(t *testing.T) {
+	for _, names := range []interface{}{
+		nil,
+		map[string]string{"a": "b"},
+		map[enumStatus]int{},
+		[]string{"draft"},
+		map[int]string{1: "same", 2: "same"},
+	} {
+		if err := RegisterEnum(names); err == nil {
+			t.Errorf("RegisterEnum(%#v) succeeded", names)
+		}
+	}
+}
+
+func TestEnumNames(t *testing.T) {
+	if got := EnumNames(enumDraft); !equalStrings(got, []string{"draft", "published"}) {
+		t.Errorf("EnumNames = %v", got)
+	}
+	if got := EnumNames(0); got != nil {
+		t.Errorf("EnumNames(int) = %v, want nil", got)
+	}
+	if got := EnumNames(nil); got != nil {
+		t.Errorf("EnumNames(nil) = %v, want nil", got)
+	}
+}
+
+func TestMarshalEnum(t *testing.T) {
+	prev := enumDraft
+	in := &enumPost{ID: "1", Status: enumPublished, Prev: &prev, History: []enumStatus{enumDraft, enumPublished}, Level: 200}
+	attrs := marshalDoc(t, in)["data"].(map[string]interface{})["attributes"]
+	want := map[string]interface{}{
+		"status": "published", "prev": "draft", "history": []interface{}{"draft", "published"}, "level": "high",
+	}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("attributes %v, want %v", attrs, want)
+	}
+
+	attrs = marshalDoc(t, &enumPost{ID: "1", Level: 1})["data"].(map[string]interface{})["attributes"]
+	want = map[string]interface{}{"status": "draft", "prev": nil, "history": nil, "level": "low"}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("attributes %v, want %v", attrs, want)
+	}
+
+	_, err := Marshal(&enumPost{ID: "1", Status: enumArchived, Level: 1})
+	if !errors.Is(err, ErrUnknownEnumValue) {
+		t.Errorf("error %v, want ErrUnknownEnumValue", err)
+	}
+	_, err = Marshal(&enumPost{ID: "1", History: []enumStatus{enumArchived}, Level: 1})
+	if !errors.Is(err, ErrUnknownEnumValue) {
+		t.Errorf("slice error %v, want ErrUnknownEnumValue", err)
+	}
+}
+
+func TestUnmarshalEnum(t *testing.T) {
+	doc := `{"data": {"type": "posts", "id": "1", "attributes": {
+		"status": "published", "prev": "draft", "history": ["draft", "published"], "level": "high"}}}`
+	out := new(enumPost)
+	if err := UnmarshalPayload(strings.NewReader(doc), out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Status != enumPublished || out.Prev == nil || *out.Prev != enumDraft || out.Level != 200 ||
+		!reflect.DeepEqual(out.History, []enumStatus{enumDraft, enumPublished}) {
+		t.Errorf("unmarshaled %+v", out)
+	}
+
+	for _, attrs := range []string{
+		`{"status": "archived"}`,
+		`{"history": ["draft", "archived"]}`,
+	} {
+		doc := `{"data": {"type": "posts", "id": "1", "attributes": ` + attrs + `}}`
+		if err := UnmarshalPayload(strings.NewReader(doc), new(enumPost)); !errors.Is(err, ErrUnknownEnumValue) {
+			t.Errorf("%s: error %v, want ErrUnknownEnumValue", attrs, err)
+		}
+	}
+	for _, attrs := range []string{
+		`{"status": 1}`,
+		`{"history": "draft"}`,
+	} {
+		doc := `{"data": {"type": "posts", "id": "1", "attributes": ` + attrs + `}}`
+		if err := UnmarshalPayload(strings.NewReader(doc), new(enumPost)); err == nil {
+			t.Errorf("%s: expected an error", attrs)
+		}
+	}
+}
+
+func TestEnumSchema(t *testing.T) {
+	b, err := ExportJSONSchema(new(enumPost))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"status":{"enum":["draft","published"],"type":"string"}`) {
+		t.Errorf("schema lacks the status enum:\n%s", b)
+	}
+}
@@ -0,0 +1,74 @@
+package jsonapi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ETag returns a strong entity tag for document, the bytes of a marshaled
+// payload, hashing them as they are. Marshal orders the included section
+// deterministically and the built-in codecs sort object keys, so equal
+// models marshaled with the same options yield equal tags. Other options,
+// such as WithIndent, a Codec that does not sort keys, or attributes whose
+// MarshalJSON varies, give other bytes and so other tags; hash the
+// Canonicalize form of the document for a tag that ignores the encoding.
+func ETag(document []byte) string {
+	sum := sha256.Sum256(document)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// MarshalPayloadConditional marshals models like MarshalPayload and sets
+// the ETag header of the response. For GET and HEAD requests whose
+// If-None-Match header matches the tag it writes 304 Not Modified without
+// a body; otherwise it writes the document with 200 OK.
+func MarshalPayloadConditional(w http.ResponseWriter, r *http.Request, models interface{},
+	opts ...MarshalOption) error {
+	o := newMarshalOptions(opts)
+
+	payload, err := Marshal(models, opts...)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := encodePayload(&buf, payload, o); err != nil {
+		return err
+	}
+
+	tag := ETag(buf.Bytes())
+	w.Header().Set("ETag", tag)
+
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) &&
+		etagMatches(r.Header.Get("If-None-Match"), tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", MediaType)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return nil
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// etagMatches reports whether an If-None-Match header matches tag, using
+// the weak comparison RFC 7232 prescribes for it.
+func etagMatches(header, tag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
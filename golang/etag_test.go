@@ -0,0 +1,105 @@
+package jsonapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMarshalPayloadConditional(t *testing.T) {
+	author := &reqAuthor{ID: "1", Name: "Ann"}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/people/1", nil)
+	if err := MarshalPayloadConditional(w, r, author); err != nil {
+		t.Fatal(err)
+	}
+	tag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || tag == "" || w.Body.Len() == 0 {
+		t.Fatalf("status %d, ETag %q, body %q", w.Code, tag, w.Body)
+	}
+	if tag != ETag(w.Body.Bytes()) {
+		t.Errorf("ETag %s does not match the body", tag)
+	}
+
+	tests := []struct {
+		method, ifNoneMatch string
+		status              int
+		body                bool
+	}{
+		{http.MethodGet, tag, http.StatusNotModified, false},
+		{http.MethodGet, `"other", W/` + tag, http.StatusNotModified, false},
+		{http.MethodGet, "*", http.StatusNotModified, false},
+		{http.MethodGet, `"other"`, http.StatusOK, true},
+		{http.MethodHead, `"other"`, http.StatusOK, false},
+		{http.MethodPost, tag, http.StatusOK, true},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(tt.method, "/people/1", nil)
+		r.Header.Set("If-None-Match", tt.ifNoneMatch)
+		if err := MarshalPayloadConditional(w, r, author); err != nil {
+			t.Fatal(err)
+		}
+		if w.Code != tt.status || (w.Body.Len() > 0) != tt.body || w.Header().Get("ETag") != tag {
+			t.Errorf("%s If-None-Match %s: status %d, body %q, ETag %q",
+				tt.method, tt.ifNoneMatch, w.Code, w.Body, w.Header().Get("ETag"))
+		}
+	}
+
+	// A different document gets a different tag.
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/people/1", nil)
+	if err := MarshalPayloadConditional(w, r, &reqAuthor{ID: "1", Name: "Bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if w.Header().Get("ETag") == tag {
+		t.Error("changed document kept its ETag")
+	}
+}
+
+func TestMarshalPayloadConditionalAttributeOrder(t *testing.T) {
+	item := &ndjsonItem{ID: "1", Zeta: "z", Alpha: 1}
+	opt := WithAttributeOrder(AttributeDeclarationOrder)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	if err := MarshalPayloadConditional(w, r, item, opt); err != nil {
+		t.Fatal(err)
+	}
+	want, err := MarshalBytes(item, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSuffix(w.Body.String(), "\n"); got != string(want) {
+		t.Errorf("document %s, want %s", got, want)
+	}
+}
+
+func TestETagHashesTheBytes(t *testing.T) {
+	author := &reqAuthor{ID: "1", Name: "Ann"}
+	compact, err := MarshalBytes(author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indented, err := MarshalBytes(author, WithIndent("", "  "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ETag(compact) == ETag(indented) {
+		t.Error("documents of different bytes share a tag")
+	}
+
+	a, err := Canonicalize(compact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Canonicalize(indented)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ETag(a) != ETag(b) {
+		t.Error("canonical forms of the same document have different tags")
+	}
+}
@@ -0,0 +1,199 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// resourceMembers are the members of a resource object that Node holds.
+var resourceMembers = jsonMembers(reflect.TypeOf(Node{}))
+
+// jsonMembers returns the member names of the fields of the struct type t,
+// per their json tags.
+func jsonMembers(t reflect.Type) map[string]bool {
+	members := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		members[name] = true
+	}
+	return members
+}
+
+// unknownMembers returns the members of the JSON object raw that are not
+// in known, or nil if there are none.
+func unknownMembers(raw json.RawMessage, known map[string]bool) map[string]json.RawMessage {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &members); err != nil {
+		return nil
+	}
+	var unknown map[string]json.RawMessage
+	for name, value := range members {
+		if known[name] {
+			continue
+		}
+		if unknown == nil {
+			unknown = make(map[string]json.RawMessage)
+		}
+		unknown[name] = value
+	}
+	return unknown
+}
+
+// withExtras returns the JSON encoding of v, which encodes as an object,
+// with the members of extras it lacks added.
+func withExtras(v interface{}, extras map[string]json.RawMessage) (json.RawMessage, error) {
+	b, err := json.Marshal(v)
+	if err != nil || len(extras) == 0 {
+		return b, err
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(b, &members); err != nil {
+		return nil, err
+	}
+	for name, value := range extras {
+		if _, ok := members[name]; !ok {
+			members[name] = value
+		}
+	}
+	return json.Marshal(members)
+}
+
+// MarshalJSON implements json.Marshaler, writing Extras and ResourceExtras
+// along with the members of d.
+func (d Document) MarshalJSON() ([]byte, error) {
+	type members Document
+	doc := struct {
+		members
+		Data     interface{} `json:"data,omitempty"`
+		Included interface{} `json:"included,omitempty"`
+	}{members: members(d)}
+
+	if d.Data != nil {
+		doc.Data = d.Data
+		if len(d.ResourceExtras) > 0 {
+			var err error
+			if d.Data.IsMany {
+				doc.Data, err = d.resources(d.Data.Many)
+			} else {
+				doc.Data, err = withExtras(d.Data.One, d.ResourceExtras[d.Data.One])
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if d.Included != nil {
+		doc.Included = d.Included
+		if len(d.ResourceExtras) > 0 {
+			var err error
+			if doc.Included, err = d.resources(d.Included); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return withExtras(doc, d.Extras)
+}
+
+// resources encodes nodes with their ResourceExtras.
+func (d Document) resources(nodes []*Node) ([]json.RawMessage, error) {
+	encoded := make([]json.RawMessage, len(nodes))
+	for i, n := range nodes {
+		b, err := withExtras(n, d.ResourceExtras[n])
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = b
+	}
+	return encoded, nil
+}
+
+// annotationExtras tags a map field collecting the attributes that no
+// other field declares, `jsonapi:"extras"`, so that typed resources can
+// carry schemaless extensions:
+//
+//	type Appointment struct {
+//		ID     string                 `jsonapi:"primary,appointments"`
+//		Start  time.Time              `jsonapi:"attr,start,iso8601"`
+//		Extras map[string]interface{} `jsonapi:"extras"`
+//	}
+//
+// Unmarshaling stores the undeclared attributes in the map, which is then
+// nil if there are none, and DisallowUnknownFields no longer rejects them.
+// Marshaling writes its entries as attributes, except those that a field
+// already wrote. The map may hold json.RawMessage values instead.
+const annotationExtras = "extras"
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+// isExtrasType reports whether t can be an extras field: a string-keyed
+// map of interface{} or json.RawMessage.
+func isExtrasType(t reflect.Type) bool {
+	if t.Kind() != reflect.Map || t.Key().Kind() != reflect.String {
+		return false
+	}
+	elem := t.Elem()
+	return elem == rawMessageType || elem.Kind() == reflect.Interface && elem.NumMethod() == 0
+}
+
+func extrasTypeMsg(t reflect.Type) string {
+	return fmt.Sprintf("extras field must be a string-keyed map of interface{} or json.RawMessage, got %s", t)
+}
+
+// addExtraAttributes adds the entries of extras, the value of an extras
+// field, to the attributes of node that no field set.
+func addExtraAttributes(node *Node, extras reflect.Value) {
+	if !extras.IsValid() || extras.Len() == 0 {
+		return
+	}
+	if node.Attributes == nil {
+		node.Attributes = make(map[string]interface{}, extras.Len())
+	}
+	iter := extras.MapRange()
+	for iter.Next() {
+		name := iter.Key().String()
+		if _, ok := node.Attributes[name]; !ok {
+			node.Attributes[name] = iter.Value().Interface()
+		}
+	}
+}
+
+// unmarshalExtras stores in the extras field v the attributes of data that
+// modelType does not declare.
+func unmarshalExtras(data *Node, modelType reflect.Type, v reflect.Value, o *unmarshalOptions) error {
+	attrs, _, _ := declaredMembers(modelType, o)
+	t := v.Type()
+
+	var m reflect.Value
+	for name, value := range data.Attributes {
+		if attrs[name] {
+			continue
+		}
+		elem := reflect.Zero(t.Elem())
+		if t.Elem() == rawMessageType {
+			b, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			elem = reflect.ValueOf(json.RawMessage(b))
+		} else if value != nil {
+			elem = reflect.ValueOf(o.floatNumbers(value))
+		}
+		if !m.IsValid() {
+			m = reflect.MakeMap(t)
+		}
+		m.SetMapIndex(reflect.ValueOf(name).Convert(t.Key()), elem)
+	}
+	if m.IsValid() {
+		v.Set(m)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDocumentExtras(t *testing.T) {
+	in := `{"data":{"type":"a","id":"1","attributes":{"n":1},"ext:x":"x"},` +
+		`"included":[{"type":"b","id":"2","ext:flag":true}],` +
+		`"atomic:results":[{}],"meta":{"m":1}}`
+	doc, err := Parse(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(doc.Extras["atomic:results"]) != `[{}]` || len(doc.Extras) != 1 {
+		t.Errorf("extras %v", doc.Extras)
+	}
+	if string(doc.ResourceExtras[doc.Data.One]["ext:x"]) != `"x"` {
+		t.Errorf("data extras %v", doc.ResourceExtras[doc.Data.One])
+	}
+	if string(doc.ResourceExtras[doc.Included[0]]["ext:flag"]) != `true` {
+		t.Errorf("included extras %v", doc.ResourceExtras[doc.Included[0]])
+	}
+
+	out, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameJSON(t, string(out), in) {
+		t.Errorf("round trip gave %s, want %s", out, in)
+	}
+}
+
+// sameJSON reports whether a and b encode the same JSON value.
+func sameJSON(t *testing.T, a, b string) bool {
+	t.Helper()
+	var va, vb interface{}
+	if err := json.Unmarshal([]byte(a), &va); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(b), &vb); err != nil {
+		t.Fatal(err)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func TestDocumentExtrasMany(t *testing.T) {
+	in := `{"data":[{"type":"a","id":"1","x":1},{"type":"a","id":"2"}]}`
+	doc, err := Parse(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.ResourceExtras) != 1 || doc.ResourceExtras[doc.Data.Many[1]] != nil {
+		t.Errorf("resource extras %v", doc.ResourceExtras)
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameJSON(t, string(out), in) {
+		t.Errorf("round trip gave %s, want %s", out, in)
+	}
+}
+
+func TestDocumentExtrasDoNotOverride(t *testing.T) {
+	doc := Document{
+		Data:   &PrimaryData{One: &Node{Type: "a", ID: "1"}},
+		Extras: map[string]json.RawMessage{"data": json.RawMessage(`null`), "x": json.RawMessage(`1`)},
+	}
+	doc.ResourceExtras = map[*Node]map[string]json.RawMessage{
+		doc.Data.One: {"id": json.RawMessage(`"2"`)},
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"data":{"type":"a","id":"1"},"x":1}`; !sameJSON(t, string(out), want) {
+		t.Errorf("marshaled %s, want %s", out, want)
+	}
+}
+
+func TestUnknownMembers(t *testing.T) {
+	known := map[string]bool{"a": true}
+	if got := unknownMembers(json.RawMessage(`{"a":1}`), known); got != nil {
+		t.Errorf("unknown %v, want nil", got)
+	}
+	if got := unknownMembers(json.RawMessage(`[]`), known); got != nil {
+		t.Errorf("unknown %v for a non-object", got)
+	}
+	got := unknownMembers(json.RawMessage(`{"a":1,"b":2}`), known)
+	if len(got) != 1 || string(got["b"]) != "2" {
+		t.Errorf("unknown %v", got)
+	}
+
+	for _, name := range []string{"type", "id", "attributes", "relationships", "links", "meta"} {
+		if !resourceMembers[name] {
+			t.Errorf("%s is not a resource member", name)
+		}
+	}
+}
+
+type extrasPerson struct {
+	ID     string                 `jsonapi:"primary,people"`
+	Name   string                 `jsonapi:"attr,name"`
+	Extras map[string]interface{} `jsonapi:"extras"`
+}
+
+type rawExtrasPerson struct {
+	ID     string                     `jsonapi:"primary,people"`
+	Name   string                     `jsonapi:"attr,name"`
+	Extras map[string]json.RawMessage `jsonapi:"extras"`
+}
+
+func TestExtrasField(t *testing.T) {
+	in := &extrasPerson{ID: "1", Name: "Ann", Extras: map[string]interface{}{"color": "red", "name": "ignored"}}
+	attrs := marshalDoc(t, in)["data"].(map[string]interface{})["attributes"]
+	if want := map[string]interface{}{"name": "Ann", "color": "red"}; !reflect.DeepEqual(attrs, want) {
+		t.Errorf("attributes %v, want %v", attrs, want)
+	}
+
+	doc := `{"data": {"type": "people", "id": "1", "attributes": {"name": "Ann", "color": "red", "size": [1, 2], "gone": null}}}`
+	out := new(extrasPerson)
+	if err := UnmarshalPayload(strings.NewReader(doc), out, DisallowUnknownFields()); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"color": "red", "size": []interface{}{float64(1), float64(2)}, "gone": nil}
+	if out.Name != "Ann" || !reflect.DeepEqual(out.Extras, want) {
+		t.Errorf("unmarshaled %+v, want extras %v", out, want)
+	}
+
+	raw := new(rawExtrasPerson)
+	if err := UnmarshalPayload(strings.NewReader(doc), raw); err != nil {
+		t.Fatal(err)
+	}
+	if string(raw.Extras["size"]) != "[1,2]" || string(raw.Extras["gone"]) != "null" || len(raw.Extras) != 3 {
+		t.Errorf("raw extras %s", raw.Extras)
+	}
+	b, err := MarshalBytes(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"size":[1,2]`) {
+		t.Errorf("document %s, want the raw extras written as they are", b)
+	}
+
+	// Without undeclared attributes the map stays nil.
+	out = new(extrasPerson)
+	if err := UnmarshalPayload(strings.NewReader(`{"data": {"type": "people", "id": "1", "attributes": {"name": "Bob"}}}`),
+		out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Extras != nil {
+		t.Errorf("extras %v, want nil", out.Extras)
+	}
+}
+
+func TestExtrasFieldErrors(t *testing.T) {
+	type badExtras struct {
+		ID     string         `jsonapi:"primary,people"`
+		Extras map[string]int `jsonapi:"extras"`
+	}
+	type extrasArgs struct {
+		ID     string                 `jsonapi:"primary,people"`
+		Extras map[string]interface{} `jsonapi:"extras,x"`
+	}
+	doc := `{"data": {"type": "people", "id": "1", "attributes": {"a": 1}}}`
+	for _, model := range []interface{}{&badExtras{ID: "1"}, &extrasArgs{ID: "1"}} {
+		if _, err := Marshal(model); !errors.Is(err, ErrBadJSONAPIStructTag) {
+			t.Errorf("marshal %T: error %v, want ErrBadJSONAPIStructTag", model, err)
+		}
+		if err := UnmarshalPayload(strings.NewReader(doc), model); !errors.Is(err, ErrBadJSONAPIStructTag) {
+			t.Errorf("unmarshal %T: error %v, want ErrBadJSONAPIStructTag", model, err)
+		}
+	}
+}
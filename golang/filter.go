@@ -0,0 +1,317 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FilterOp is a comparison operator of a filter condition.
+type FilterOp string
+
+// Supported filter operators.
+const (
+	FilterEq   FilterOp = "eq"
+	FilterNe   FilterOp = "ne"
+	FilterLt   FilterOp = "lt"
+	FilterGt   FilterOp = "gt"
+	FilterIn   FilterOp = "in"
+	FilterLike FilterOp = "like"
+)
+
+// FilterExpr is a node of a parsed filter: a FilterCondition or a
+// FilterAnd of other expressions.
+type FilterExpr interface {
+	filterExpr()
+}
+
+// FilterCondition compares the member Field ("id" or an attribute name)
+// against Values. Only FilterIn uses more than one value. FilterLike
+// values use SQL wildcards: % for any run of characters, _ for one.
+type FilterCondition struct {
+	Field  string
+	Op     FilterOp
+	Values []string
+}
+
+// FilterAnd matches when all of its expressions match.
+type FilterAnd []FilterExpr
+
+func (FilterCondition) filterExpr() {}
+func (FilterAnd) filterExpr()       {}
+
+// ParseFilter parses the filter query parameters, as collected in
+// Query.Filter, using the syntax filter[field][op]=value. filter[field]=
+// value is short for the eq operator and in takes a comma separated list.
+// Conditions are combined with FilterAnd in key order.
+func ParseFilter(filters map[string]string) (FilterExpr, error) {
+	keys := make([]string, 0, len(filters))
+	for k := range filters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	and := FilterAnd{}
+	for _, key := range keys {
+		// Query.Filter keys are what was between the outer brackets, so
+		// filter[age][gt] arrives as "age][gt".
+		parts := strings.Split(key, "][")
+		if len(parts) > 2 || parts[0] == "" {
+			return nil, fmt.Errorf("jsonapi: invalid filter parameter %q", "filter["+key+"]")
+		}
+
+		c := FilterCondition{Field: parts[0], Op: FilterEq}
+		if len(parts) == 2 {
+			c.Op = FilterOp(parts[1])
+		}
+
+		switch c.Op {
+		case FilterIn:
+			c.Values = strings.Split(filters[key], ",")
+		case FilterEq, FilterNe, FilterLt, FilterGt, FilterLike:
+			c.Values = []string{filters[key]}
+		default:
+			return nil, fmt.Errorf("jsonapi: unknown filter operator %q", c.Op)
+		}
+
+		and = append(and, c)
+	}
+
+	return and, nil
+}
+
+// MatchFilter reports whether model, a pointer to a tagged struct,
+// satisfies expr. opts, e.g. WithTagKey, name the members as for Marshal.
+func MatchFilter(expr FilterExpr, model interface{}, opts ...MarshalOption) (bool, error) {
+	v := reflect.Indirect(reflect.ValueOf(model))
+	if v.Kind() != reflect.Struct {
+		return false, ErrUnexpectedType
+	}
+	return matchFilter(expr, v, &newMarshalOptions(opts).tagOptions)
+}
+
+func matchFilter(expr FilterExpr, v reflect.Value, tags *tagOptions) (bool, error) {
+	switch e := expr.(type) {
+	case FilterAnd:
+		for _, sub := range e {
+			ok, err := matchFilter(sub, v, tags)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	case FilterCondition:
+		field, ok := memberField(v.Type(), e.Field, tags)
+		if !ok || !sortable(field.Type) {
+			return false, fmt.Errorf("jsonapi: cannot filter %s by %q", v.Type(), e.Field)
+		}
+		return matchCondition(e, v.FieldByIndex(field.Index))
+	}
+	return false, fmt.Errorf("jsonapi: unsupported filter expression %T", expr)
+}
+
+func matchCondition(c FilterCondition, fv reflect.Value) (bool, error) {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			// A missing value only differs from everything.
+			return c.Op == FilterNe, nil
+		}
+		fv = fv.Elem()
+	}
+
+	if c.Op == FilterLike {
+		re, err := likePattern(c.Values[0])
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(fmt.Sprint(fv.Interface())), nil
+	}
+
+	for _, raw := range c.Values {
+		want, err := parseFilterValue(raw, fv.Type())
+		if err != nil {
+			return false, fmt.Errorf("jsonapi: filter %s: %v", c.Field, err)
+		}
+		cmp := compareValues(fv, want)
+
+		switch c.Op {
+		case FilterEq, FilterIn:
+			if cmp == 0 {
+				return true, nil
+			}
+		case FilterNe:
+			return cmp != 0, nil
+		case FilterLt:
+			return cmp < 0, nil
+		case FilterGt:
+			return cmp > 0, nil
+		}
+	}
+	return false, nil
+}
+
+// parseFilterValue converts the query string value s to type t.
+func parseFilterValue(s string, t reflect.Type) (reflect.Value, error) {
+	v := reflect.New(t).Elem()
+
+	if t == timeType {
+		tm, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return v, err
+		}
+		v.Set(reflect.ValueOf(tm))
+		return v, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return v, err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return v, err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return v, err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return v, err
+		}
+		v.SetFloat(f)
+	}
+	return v, nil
+}
+
+func likePattern(p string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("(?is)^")
+	for _, r := range p {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// ApplyFilter removes the elements of *models, a slice of tagged structs
+// or struct pointers, that do not satisfy expr. Nil elements are removed.
+// opts name the members as for MatchFilter.
+func ApplyFilter(models interface{}, expr FilterExpr, opts ...MarshalOption) error {
+	ptr := reflect.ValueOf(models)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Slice {
+		return ErrExpectedSlice
+	}
+	slice := ptr.Elem()
+
+	tags := &newMarshalOptions(opts).tagOptions
+	kept := reflect.MakeSlice(slice.Type(), 0, slice.Len())
+	for i := 0; i < slice.Len(); i++ {
+		elem := slice.Index(i)
+		v := reflect.Indirect(elem)
+		if !v.IsValid() {
+			continue
+		}
+		if v.Kind() != reflect.Struct {
+			return ErrExpectedSlice
+		}
+
+		ok, err := matchFilter(expr, v, tags)
+		if err != nil {
+			return err
+		}
+		if ok {
+			kept = reflect.Append(kept, elem)
+		}
+	}
+
+	slice.Set(kept)
+	return nil
+}
+
+// FilterConditionFunc translates a single condition into a query fragment
+// and its arguments, e.g. for an SQL WHERE clause or an ORM.
+type FilterConditionFunc func(c FilterCondition) (string, []interface{}, error)
+
+// TranslateFilter walks expr and joins the fragments produced by cond with
+// " AND ", wrapping nested conjunctions in parentheses.
+func TranslateFilter(expr FilterExpr, cond FilterConditionFunc) (string, []interface{}, error) {
+	switch e := expr.(type) {
+	case FilterCondition:
+		return cond(e)
+	case FilterAnd:
+		var parts []string
+		var args []interface{}
+		for _, sub := range e {
+			s, a, err := TranslateFilter(sub, cond)
+			if err != nil {
+				return "", nil, err
+			}
+			if s == "" {
+				continue
+			}
+			if _, nested := sub.(FilterAnd); nested {
+				s = "(" + s + ")"
+			}
+			parts = append(parts, s)
+			args = append(args, a...)
+		}
+		return strings.Join(parts, " AND "), args, nil
+	}
+	return "", nil, fmt.Errorf("jsonapi: unsupported filter expression %T", expr)
+}
+
+// SQLFilterCondition returns a FilterConditionFunc producing SQL with ?
+// placeholders. column maps member names to column names and rejects
+// members that may not be filtered on.
+func SQLFilterCondition(column func(field string) (string, bool)) FilterConditionFunc {
+	return func(c FilterCondition) (string, []interface{}, error) {
+		col, ok := column(c.Field)
+		if !ok {
+			return "", nil, fmt.Errorf("jsonapi: cannot filter by %q", c.Field)
+		}
+
+		args := make([]interface{}, len(c.Values))
+		for i, v := range c.Values {
+			args[i] = v
+		}
+
+		switch c.Op {
+		case FilterEq:
+			return col + " = ?", args, nil
+		case FilterNe:
+			return col + " <> ?", args, nil
+		case FilterLt:
+			return col + " < ?", args, nil
+		case FilterGt:
+			return col + " > ?", args, nil
+		case FilterLike:
+			return col + " LIKE ?", args, nil
+		case FilterIn:
+			marks := strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")
+			return col + " IN (" + marks + ")", args, nil
+		}
+		return "", nil, fmt.Errorf("jsonapi: unknown filter operator %q", c.Op)
+	}
+}
@@ -0,0 +1,138 @@
+package jsonapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFilter(t *testing.T) {
+	expr, err := ParseFilter(map[string]string{
+		"title":        "a",
+		"priority][gt": "1",
+		"id][in":       "1,2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FilterAnd{
+		FilterCondition{Field: "id", Op: FilterIn, Values: []string{"1", "2"}},
+		FilterCondition{Field: "priority", Op: FilterGt, Values: []string{"1"}},
+		FilterCondition{Field: "title", Op: FilterEq, Values: []string{"a"}},
+	}
+	if !reflect.DeepEqual(expr, want) {
+		t.Errorf("ParseFilter = %#v, want %#v", expr, want)
+	}
+
+	for _, key := range []string{"", "title][eq][x", "title][between"} {
+		if _, err := ParseFilter(map[string]string{key: "a"}); err == nil {
+			t.Errorf("ParseFilter(%q) succeeded", key)
+		}
+	}
+}
+
+func TestMatchFilter(t *testing.T) {
+	due := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	task := &sortTask{ID: "1", Title: "Write Tests", Priority: 2, Due: &due}
+
+	tests := []struct {
+		filters map[string]string
+		want    bool
+	}{
+		{map[string]string{"title": "Write Tests"}, true},
+		{map[string]string{"title][ne": "Write Tests"}, false},
+		{map[string]string{"title][like": "write%"}, true},
+		{map[string]string{"title][like": "_rite"}, false},
+		{map[string]string{"priority][gt": "1", "priority][lt": "3"}, true},
+		{map[string]string{"id][in": "3,1"}, true},
+		{map[string]string{"due][lt": "2024-01-03T00:00:00Z"}, true},
+		{map[string]string{"done": "true"}, false},
+	}
+	for _, tt := range tests {
+		expr, err := ParseFilter(tt.filters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := MatchFilter(expr, task)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.filters, err)
+		}
+		if got != tt.want {
+			t.Errorf("%v: match %v, want %v", tt.filters, got, tt.want)
+		}
+	}
+
+	// A nil value only differs from everything.
+	expr, _ := ParseFilter(map[string]string{"due][ne": "2024-01-03T00:00:00Z"})
+	if ok, _ := MatchFilter(expr, &sortTask{}); !ok {
+		t.Error("nil due does not differ")
+	}
+}
+
+func TestMatchFilterErrors(t *testing.T) {
+	tests := []struct {
+		filters map[string]string
+		model   interface{}
+	}{
+		{map[string]string{"tags": "a"}, &sortTask{}},
+		{map[string]string{"missing": "a"}, &sortTask{}},
+		{map[string]string{"priority": "high"}, &sortTask{}},
+		{map[string]string{"id": "1"}, 1},
+	}
+	for _, tt := range tests {
+		expr, err := ParseFilter(tt.filters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := MatchFilter(expr, tt.model); err == nil {
+			t.Errorf("MatchFilter(%v, %T) succeeded", tt.filters, tt.model)
+		}
+	}
+}
+
+func TestApplyFilter(t *testing.T) {
+	tasks := []*sortTask{{ID: "1", Priority: 1}, nil, {ID: "2", Priority: 3}, {ID: "3", Priority: 5}}
+	expr, _ := ParseFilter(map[string]string{"priority][gt": "2"})
+	if err := ApplyFilter(&tasks, expr); err != nil {
+		t.Fatal(err)
+	}
+	if got := sortTaskIDs(tasks); !equalStrings(got, []string{"2", "3"}) {
+		t.Errorf("kept %v", got)
+	}
+
+	if err := ApplyFilter(tasks, expr); err != ErrExpectedSlice {
+		t.Errorf("ApplyFilter(slice) error %v, want ErrExpectedSlice", err)
+	}
+}
+
+func TestTranslateFilter(t *testing.T) {
+	expr := FilterAnd{
+		FilterCondition{Field: "title", Op: FilterLike, Values: []string{"a%"}},
+		FilterAnd{
+			FilterCondition{Field: "id", Op: FilterIn, Values: []string{"1", "2"}},
+			FilterCondition{Field: "priority", Op: FilterNe, Values: []string{"3"}},
+		},
+	}
+	columns := map[string]string{"title": "title", "id": "task_id", "priority": "prio"}
+	cond := SQLFilterCondition(func(field string) (string, bool) {
+		col, ok := columns[field]
+		return col, ok
+	})
+
+	sql, args, err := TranslateFilter(expr, cond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "title LIKE ? AND (task_id IN (?, ?) AND prio <> ?)"; sql != want {
+		t.Errorf("sql %q, want %q", sql, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"a%", "1", "2", "3"}) {
+		t.Errorf("args %v", args)
+	}
+
+	_, _, err = TranslateFilter(FilterCondition{Field: "secret", Op: FilterEq, Values: []string{"x"}}, cond)
+	if err == nil || !strings.Contains(err.Error(), "secret") {
+		t.Errorf("error %v, want one naming the field", err)
+	}
+}
@@ -0,0 +1,71 @@
+package jsonapi
+
+import (
+	"bytes"
+	"reflect"
+	"time"
+)
+
+// Entry points for go-fuzz, e.g.
+//
+//	go-fuzz-build -func FuzzUnmarshalPayload && go-fuzz
+//
+// They also back the native fuzz targets run by go test -fuzz on Go 1.18
+// and later.
+//
+// Each decodes its input into fuzzModel and, when that succeeds, checks
+// with RoundTrip that the result survives being encoded and decoded again.
+
+type fuzzModel struct {
+	ID       string                 `jsonapi:"primary,fuzz"`
+	Name     string                 `jsonapi:"attr,name"`
+	Count    int64                  `jsonapi:"attr,count"`
+	Ratio    float64                `jsonapi:"attr,ratio"`
+	Active   *bool                  `jsonapi:"attr,active"`
+	At       time.Time              `jsonapi:"attr,at,iso8601"`
+	Tags     []string               `jsonapi:"attr,tags"`
+	Extra    map[string]interface{} `jsonapi:"attr,extra"`
+	Parent   *fuzzModel             `jsonapi:"relation,parent"`
+	Children []*fuzzModel           `jsonapi:"relation,children"`
+}
+
+// fuzzDepth bounds relationships followed through included, which may be
+// cyclic in arbitrary input.
+const fuzzDepth = 16
+
+// FuzzUnmarshalPayload fuzzes UnmarshalPayload.
+func FuzzUnmarshalPayload(data []byte) int {
+	m := new(fuzzModel)
+	if err := UnmarshalPayload(bytes.NewReader(data), m, MaxDepth(fuzzDepth)); err != nil {
+		return 0
+	}
+	if err := RoundTrip(m); err != nil {
+		panic(err)
+	}
+	return 1
+}
+
+// FuzzUnmarshalManyPayload fuzzes UnmarshalManyPayload.
+func FuzzUnmarshalManyPayload(data []byte) int {
+	models, err := UnmarshalManyPayload(bytes.NewReader(data), reflect.TypeOf(new(fuzzModel)),
+		MaxDepth(fuzzDepth))
+	if err != nil {
+		return 0
+	}
+	typed := make([]*fuzzModel, len(models))
+	for i, m := range models {
+		typed[i] = m.(*fuzzModel)
+	}
+	if err := RoundTrip(typed); err != nil {
+		panic(err)
+	}
+	return 1
+}
+
+// FuzzUnmarshalDynamicPayload fuzzes UnmarshalDynamicPayload.
+func FuzzUnmarshalDynamicPayload(data []byte) int {
+	if _, err := UnmarshalDynamicPayload(bytes.NewReader(data)); err != nil {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,51 @@
+//go:build go1.18
+// +build go1.18
+
+package jsonapi
+
+import "testing"
+
+// Native fuzz targets for the go-fuzz entry points, e.g.
+//
+//	go test -fuzz FuzzUnmarshal -run '^$'
+
+var fuzzSeeds = []string{
+	`{"data": {"type": "fuzz", "id": "1", "attributes": {"name": "a", "count": 2, "ratio": 0.5,
+		"active": true, "at": "2020-09-13T12:26:40Z", "tags": ["x"], "extra": {"k": [1, "v"]}}}}`,
+	`{"data": {"type": "fuzz", "id": "1", "relationships": {
+		"parent": {"data": {"type": "fuzz", "id": "2"}},
+		"children": {"data": [{"type": "fuzz", "id": "3"}]}}},
+	"included": [{"type": "fuzz", "id": "2", "relationships": {"parent": {"data": {"type": "fuzz", "id": "1"}}}},
+		{"type": "fuzz", "id": "3", "attributes": {"name": "c"}}]}`,
+	`{"data": [{"type": "fuzz", "id": "1"}, {"type": "fuzz", "id": "2", "attributes": {"count": -1}}]}`,
+	`{"data": null}`,
+	`{"data": {"type": "fuzz"}}`,
+	`{"errors": [{"status": "404"}]}`,
+}
+
+func addFuzzSeeds(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add([]byte(s))
+	}
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		FuzzUnmarshalPayload(data)
+	})
+}
+
+func FuzzUnmarshalMany(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		FuzzUnmarshalManyPayload(data)
+	})
+}
+
+func FuzzUnmarshalDynamic(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		FuzzUnmarshalDynamicPayload(data)
+	})
+}
@@ -1,27 +0,0 @@
-package main
-
-import (
-    "fmt"
-    "io/ioutil"
-    "log"
-    "net/http"
-)
-
-func main() {
-
-    resp, err := http.Get("User//")
-
-    if err != nil {
-        log.Fatal(err)
-    }
-
-    defer resp.Body.Close()
-
-    body, err := ioutil.ReadAll(resp.Body)
-
-    if err != nil {
-        log.Fatal(err)
-    }
-
-    fmt.Println(string(body))
-}
@@ -0,0 +1,32 @@
+//go:build gorm
+// +build gorm
+
+package gormadapter
+
+import (
+	"gorm.io/gorm"
+)
+
+// Apply adds the clauses of p to db and returns the result. It is a GORM
+// scope, e.g. db.Scopes(plan.Apply).Find(&posts).
+func (p *Plan) Apply(db *gorm.DB) *gorm.DB {
+	for _, preload := range p.Preloads {
+		db = db.Preload(preload)
+	}
+	if len(p.Select) > 0 {
+		db = db.Select(p.Select)
+	}
+	for _, order := range p.Order {
+		db = db.Order(order)
+	}
+	if p.Where != "" {
+		db = db.Where(p.Where, p.Args...)
+	}
+	if p.Offset > 0 {
+		db = db.Offset(p.Offset)
+	}
+	if p.Limit > 0 {
+		db = db.Limit(p.Limit)
+	}
+	return db
+}
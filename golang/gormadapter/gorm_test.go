@@ -0,0 +1,95 @@
+//go:build gorm
+// +build gorm
+
+package gormadapter
+
+import (
+	"reflect"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	jsonapi "test3"
+)
+
+// openDB returns an in-memory SQLite database holding companies and users.
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection would open a database of its own.
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&company{}, &user{}); err != nil {
+		t.Fatal(err)
+	}
+	acme := &company{ID: 1, Name: "Acme"}
+	users := []*user{
+		{ID: 1, FirstName: "Ann", Email: "ann@example.com", Age: 25, CompanyID: 1},
+		{ID: 2, FirstName: "Bob", Email: "bob@example.com", Age: 35, CompanyID: 1},
+		{ID: 3, FirstName: "Cid", Email: "cid@example.com", Age: 45, CompanyID: 1},
+		{ID: 4, FirstName: "Dee", Email: "dee@example.com", Age: 55, CompanyID: 1},
+	}
+	if err := db.Create(acme).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Omit("Company").Create(users).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestApply(t *testing.T) {
+	db := openDB(t)
+
+	q := (&jsonapi.Query{}).
+		Including("company").
+		SelectFields("users", "first-name").
+		SortBy("-age").
+		Paging("offset", "1").
+		Paging("limit", "2").
+		Filtering("age][gt", "30")
+	plan, err := Build(q, new(user))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var users []*user
+	if err := db.Scopes(plan.Apply).Find(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, u := range users {
+		names = append(names, u.FirstName)
+		if u.Email != "" || u.Age != 0 {
+			t.Errorf("unselected columns read: %+v", u)
+		}
+		if u.Company == nil || u.Company.Name != "Acme" {
+			t.Errorf("company of %s not preloaded: %+v", u.FirstName, u.Company)
+		}
+	}
+	if want := []string{"Cid", "Bob"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("users %v, want %v", names, want)
+	}
+}
+
+func TestApplyEmptyPlan(t *testing.T) {
+	db := openDB(t)
+
+	var users []*user
+	if err := db.Scopes((&Plan{}).Apply).Order("id").Find(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 4 || users[0].Email != "ann@example.com" || users[0].Company != nil {
+		t.Errorf("users %+v", users)
+	}
+}
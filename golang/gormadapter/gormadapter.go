@@ -0,0 +1,277 @@
+// Package gormadapter turns a parsed jsonapi.Query into GORM query
+// clauses: include becomes Preload, fields[TYPE] becomes Select, sort
+// becomes Order, page becomes Offset/Limit and filter becomes Where.
+//
+// Build returns a Plan of plain values and does not need GORM. Plan.Apply,
+// a GORM scope adding the clauses to a *gorm.DB, is only built with the
+// gorm build tag:
+//
+//	plan, err := gormadapter.Build(query, &Post{})
+//	if err != nil { ... }
+//	err = db.Scopes(plan.Apply).Find(&posts).Error
+//
+// Member names are mapped to columns with the column option of the gorm
+// struct tag, falling back to GORM's default snake_case naming.
+package gormadapter
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	jsonapi "test3"
+)
+
+// Plan holds the clauses derived from a query.
+type Plan struct {
+	Preloads []string
+	Select   []string
+	Order    []string
+	Where    string
+	Args     []interface{}
+	Offset   int
+	Limit    int
+}
+
+// Build derives a Plan for querying model, a pointer to a tagged struct,
+// from q. Unknown members in include, fields, sort or filter are errors;
+// relationships named in fields select no column, but included ones that
+// model holds the foreign key of select it, so that they can be preloaded.
+// page accepts either number/size or offset/limit. opts, e.g.
+// jsonapi.WithTagKey, name the members as for jsonapi.Marshal.
+func Build(q *jsonapi.Query, model interface{}, opts ...jsonapi.MarshalOption) (*Plan, error) {
+	t := reflect.TypeOf(model)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil, jsonapi.ErrUnexpectedType
+	}
+	t = t.Elem()
+	plan := &Plan{}
+	if q == nil {
+		return plan, nil
+	}
+
+	for _, path := range q.Include {
+		preload, err := preloadPath(t, path, opts)
+		if err != nil {
+			return nil, err
+		}
+		plan.Preloads = append(plan.Preloads, preload)
+	}
+
+	if fields, ok := q.Fields[jsonapi.ResourceType(model, opts...)]; ok {
+		if pk, ok := primaryField(t, opts); ok {
+			plan.Select = append(plan.Select, column(pk))
+		}
+		for _, name := range fields {
+			field, ok := member(t, "attr", name, opts)
+			if !ok {
+				if _, ok := member(t, "relation", name, opts); ok {
+					continue
+				}
+				return nil, fmt.Errorf("gormadapter: %s has no field %q", t, name)
+			}
+			plan.Select = append(plan.Select, column(field))
+		}
+		for _, path := range q.Include {
+			if fk, ok := foreignKey(t, strings.Split(path, ".")[0], opts); ok {
+				plan.Select = appendUnique(plan.Select, column(fk))
+			}
+		}
+	}
+
+	for _, s := range q.Sort {
+		col, ok := columnFor(t, s.Field, opts)
+		if !ok {
+			return nil, fmt.Errorf("gormadapter: cannot sort by %q", s.Field)
+		}
+		if s.Descending {
+			col += " DESC"
+		} else {
+			col += " ASC"
+		}
+		plan.Order = append(plan.Order, col)
+	}
+
+	if err := plan.page(q.Page); err != nil {
+		return nil, err
+	}
+
+	if len(q.Filter) > 0 {
+		expr, err := jsonapi.ParseFilter(q.Filter)
+		if err != nil {
+			return nil, err
+		}
+		plan.Where, plan.Args, err = jsonapi.TranslateFilter(expr,
+			jsonapi.SQLFilterCondition(func(field string) (string, bool) {
+				return columnFor(t, field, opts)
+			}))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return plan, nil
+}
+
+func (p *Plan) page(page map[string]string) error {
+	get := func(key string) (int, bool, error) {
+		s, ok := page[key]
+		if !ok {
+			return 0, false, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return 0, false, fmt.Errorf("gormadapter: invalid page[%s] %q", key, s)
+		}
+		return n, true, nil
+	}
+
+	size, hasSize, err := get("size")
+	if err != nil {
+		return err
+	}
+	number, hasNumber, err := get("number")
+	if err != nil {
+		return err
+	}
+	if hasSize {
+		p.Limit = size
+		if hasNumber && number > 0 {
+			p.Offset = (number - 1) * size
+		}
+	}
+
+	if limit, ok, err := get("limit"); err != nil {
+		return err
+	} else if ok {
+		p.Limit = limit
+	}
+	if offset, ok, err := get("offset"); err != nil {
+		return err
+	} else if ok {
+		p.Offset = offset
+	}
+
+	return nil
+}
+
+// preloadPath maps an include path of member names, e.g.
+// "author.company", to GORM's field path, e.g. "Author.Company".
+func preloadPath(t reflect.Type, path string, opts []jsonapi.MarshalOption) (string, error) {
+	var fields []string
+	for _, name := range strings.Split(path, ".") {
+		field, ok := member(t, "relation", name, opts)
+		if !ok {
+			return "", fmt.Errorf("gormadapter: %s has no relationship %q", t, name)
+		}
+		fields = append(fields, field.Name)
+
+		t = field.Type
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return "", fmt.Errorf("gormadapter: cannot preload %q", path)
+		}
+	}
+	return strings.Join(fields, "."), nil
+}
+
+// foreignKey returns the field of t holding the foreign key of its
+// relationship name, as GORM finds it for a belongs-to association: the
+// foreignKey option of the gorm tag, or the field name followed by ID.
+func foreignKey(t reflect.Type, name string, opts []jsonapi.MarshalOption) (reflect.StructField, bool) {
+	rel, ok := member(t, "relation", name, opts)
+	if !ok {
+		return reflect.StructField{}, false
+	}
+	fk := rel.Name + "ID"
+	if opt, ok := gormOption(rel, "foreignKey"); ok {
+		fk = opt
+	}
+	return t.FieldByName(fk)
+}
+
+func appendUnique(s []string, v string) []string {
+	for _, e := range s {
+		if e == v {
+			return s
+		}
+	}
+	return append(s, v)
+}
+
+func columnFor(t reflect.Type, name string, opts []jsonapi.MarshalOption) (string, bool) {
+	if name == "id" {
+		if pk, ok := primaryField(t, opts); ok {
+			return column(pk), true
+		}
+		return "", false
+	}
+	field, ok := member(t, "attr", name, opts)
+	if !ok {
+		return "", false
+	}
+	return column(field), true
+}
+
+// primaryField and member find fields by their tags as jsonapi.Marshal
+// reads them with opts; see jsonapi.FieldTag.
+func primaryField(t reflect.Type, opts []jsonapi.MarshalOption) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		args := strings.Split(jsonapi.FieldTag(t.Field(i), opts...), ",")
+		if args[0] == "primary" {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func member(t reflect.Type, annotation, name string, opts []jsonapi.MarshalOption) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		args := strings.Split(jsonapi.FieldTag(t.Field(i), opts...), ",")
+		if args[0] == annotation && len(args) > 1 && args[1] == name {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// column returns the database column of field following GORM's rules.
+func column(field reflect.StructField) string {
+	if col, ok := gormOption(field, "column"); ok {
+		return col
+	}
+	return snakeCase(field.Name)
+}
+
+// gormOption returns the value of the option key of the gorm tag of field.
+func gormOption(field reflect.StructField, key string) (string, bool) {
+	for _, opt := range strings.Split(field.Tag.Get("gorm"), ";") {
+		kv := strings.SplitN(opt, ":", 2)
+		if len(kv) == 2 && strings.EqualFold(strings.TrimSpace(kv[0]), key) {
+			return strings.TrimSpace(kv[1]), true
+		}
+	}
+	return "", false
+}
+
+// snakeCase converts a Go field name to GORM's default column name,
+// keeping initialisms together: "UserID" becomes "user_id".
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,104 @@
+package gormadapter
+
+import (
+	"reflect"
+	"testing"
+
+	jsonapi "test3"
+)
+
+type company struct {
+	ID   int    `jsonapi:"primary,companies"`
+	Name string `jsonapi:"attr,name"`
+}
+
+type user struct {
+	ID        int      `jsonapi:"primary,users"`
+	FirstName string   `jsonapi:"attr,first-name"`
+	Email     string   `jsonapi:"attr,email" gorm:"column:email_address"`
+	Age       int      `jsonapi:"attr,age"`
+	Company   *company `jsonapi:"relation,company"`
+	CompanyID int
+}
+
+func TestBuild(t *testing.T) {
+	q := (&jsonapi.Query{}).
+		Including("company").
+		SelectFields("users", "first-name", "email", "company").
+		SortBy("-age", "id").
+		Paging("number", "3").
+		Paging("size", "10").
+		Filtering("age][gt", "30")
+
+	plan, err := Build(q, new(user))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Plan{
+		Preloads: []string{"Company"},
+		Select:   []string{"id", "first_name", "email_address", "company_id"},
+		Order:    []string{"age DESC", "id ASC"},
+		Where:    plan.Where,
+		Args:     plan.Args,
+		Offset:   20,
+		Limit:    10,
+	}
+	if !reflect.DeepEqual(plan, want) {
+		t.Errorf("plan = %+v\nwant %+v", plan, want)
+	}
+	if plan.Where == "" || len(plan.Args) != 1 {
+		t.Errorf("where %q %v", plan.Where, plan.Args)
+	}
+}
+
+func TestBuildRejectsUnknownMembers(t *testing.T) {
+	tests := map[string]*jsonapi.Query{
+		"include": (&jsonapi.Query{}).Including("manager"),
+		"fields":  (&jsonapi.Query{}).SelectFields("users", "first-name", "nickname"),
+		"sort":    (&jsonapi.Query{}).SortBy("height"),
+		"filter":  (&jsonapi.Query{}).Filtering("height", "2"),
+		"page":    (&jsonapi.Query{}).Paging("size", "-1"),
+	}
+	for name, q := range tests {
+		if _, err := Build(q, new(user)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := Build(nil, user{}); err == nil {
+		t.Error("expected an error for a model that is not a struct pointer")
+	}
+}
+
+type adminUser struct {
+	ID     int    `jsonapi:"primary,users"`
+	Salary int    `jsonapi_admin:"attr,salary"`
+	Email  string `jsonapi:"attr,email" jsonapi_admin:"attr,login"`
+}
+
+func TestBuildTagKey(t *testing.T) {
+	q := (&jsonapi.Query{}).SelectFields("users", "salary", "login").SortBy("salary")
+	if _, err := Build(q, new(adminUser)); err == nil {
+		t.Error("expected an error without the tag key")
+	}
+
+	plan, err := Build(q, new(adminUser), jsonapi.WithTagKey("jsonapi_admin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"id", "salary", "email"}; !reflect.DeepEqual(plan.Select, want) {
+		t.Errorf("select %v, want %v", plan.Select, want)
+	}
+	if want := []string{"salary ASC"}; !reflect.DeepEqual(plan.Order, want) {
+		t.Errorf("order %v, want %v", plan.Order, want)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for in, want := range map[string]string{
+		"UserID": "user_id", "FirstName": "first_name", "HTTPServer": "http_server", "ID": "id",
+	} {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
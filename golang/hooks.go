@@ -0,0 +1,147 @@
+package jsonapi
+
+import (
+	"io"
+	"time"
+)
+
+// Hook observes marshaling and unmarshaling, e.g. to log, trace or measure
+// them. Hooks are attached to a call with WithHook or UseHook and are
+// called synchronously from it, so they should be cheap. Embed NopHook to
+// implement only some of the methods.
+type Hook interface {
+	// OnMarshalStart is called when Marshal, MarshalPayload or
+	// MarshalBytes begins, with the resource type of the models, "" when
+	// it cannot be derived from their type.
+	OnMarshalStart(typ string)
+	// OnMarshalEnd is called when the call returns, with err set if it
+	// failed.
+	OnMarshalEnd(s *HookStats, err error)
+	// OnNodeVisited is called for each resource object built while
+	// marshaling, whether primary data or included.
+	OnNodeVisited(n *Node)
+
+	// OnUnmarshalStart is called when UnmarshalPayload or
+	// UnmarshalManyPayload begins, with the resource type of the model.
+	OnUnmarshalStart(typ string)
+	// OnUnmarshalEnd is called when unmarshaling succeeds.
+	OnUnmarshalEnd(s *HookStats)
+	// OnUnmarshalError is called instead of OnUnmarshalEnd when
+	// unmarshaling fails.
+	OnUnmarshalError(s *HookStats, err error)
+}
+
+// HookStats describes a finished marshal or unmarshal call.
+type HookStats struct {
+	// Type is the resource type passed to the start hook.
+	Type string
+	// Resources and Included count the resource objects in the primary
+	// data and in included. They are zero when the call failed before
+	// the document was built or read.
+	Resources int
+	Included  int
+	// Bytes is the size of the encoded document. It is zero for Marshal,
+	// which does not encode.
+	Bytes    int64
+	Duration time.Duration
+}
+
+// NopHook implements Hook with methods that do nothing.
+type NopHook struct{}
+
+func (NopHook) OnMarshalStart(typ string)                {}
+func (NopHook) OnMarshalEnd(s *HookStats, err error)     {}
+func (NopHook) OnNodeVisited(n *Node)                    {}
+func (NopHook) OnUnmarshalStart(typ string)              {}
+func (NopHook) OnUnmarshalEnd(s *HookStats)              {}
+func (NopHook) OnUnmarshalError(s *HookStats, err error) {}
+
+// WithHook makes the call report to h. It may be given more than once.
+func WithHook(h Hook) MarshalOption {
+	return func(o *marshalOptions) {
+		o.hooks = append(o.hooks, h)
+	}
+}
+
+// UseHook is the unmarshal counterpart of WithHook.
+func UseHook(h Hook) UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.hooks = append(o.hooks, h)
+	}
+}
+
+type hooks []Hook
+
+// marshal runs fn, which fills in the counts of s, between the marshal
+// hooks.
+func (hs hooks) marshal(typ string, fn func(s *HookStats) error) error {
+	s := &HookStats{Type: typ}
+	if len(hs) == 0 {
+		return fn(s)
+	}
+
+	for _, h := range hs {
+		h.OnMarshalStart(typ)
+	}
+	start := time.Now()
+	err := fn(s)
+	s.Duration = time.Since(start)
+	for _, h := range hs {
+		h.OnMarshalEnd(s, err)
+	}
+	return err
+}
+
+// unmarshal runs fn, which reads in and fills in the counts of s, between
+// the unmarshal hooks.
+func (hs hooks) unmarshal(typ string, in io.Reader, fn func(in io.Reader, s *HookStats) error) error {
+	s := &HookStats{Type: typ}
+	if len(hs) == 0 {
+		return fn(in, s)
+	}
+
+	for _, h := range hs {
+		h.OnUnmarshalStart(typ)
+	}
+	start := time.Now()
+	cr := &countingReader{r: in}
+	err := fn(cr, s)
+	s.Duration = time.Since(start)
+	s.Bytes = cr.n
+	for _, h := range hs {
+		if err != nil {
+			h.OnUnmarshalError(s, err)
+		} else {
+			h.OnUnmarshalEnd(s)
+		}
+	}
+	return err
+}
+
+func (hs hooks) nodeVisited(n *Node) {
+	for _, h := range hs {
+		h.OnNodeVisited(n)
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
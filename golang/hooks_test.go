@@ -0,0 +1,125 @@
+package jsonapi
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordingHook records the calls made to it.
+type recordingHook struct {
+	calls []string
+	stats []HookStats
+	nodes []string
+}
+
+func (h *recordingHook) OnMarshalStart(typ string) {
+	h.calls = append(h.calls, "OnMarshalStart "+typ)
+}
+
+func (h *recordingHook) OnMarshalEnd(s *HookStats, err error) {
+	h.calls = append(h.calls, "OnMarshalEnd")
+	h.stats = append(h.stats, *s)
+}
+
+func (h *recordingHook) OnNodeVisited(n *Node) {
+	h.nodes = append(h.nodes, n.Type+"/"+n.ID)
+}
+
+func (h *recordingHook) OnUnmarshalStart(typ string) {
+	h.calls = append(h.calls, "OnUnmarshalStart "+typ)
+}
+
+func (h *recordingHook) OnUnmarshalEnd(s *HookStats) {
+	h.calls = append(h.calls, "OnUnmarshalEnd")
+	h.stats = append(h.stats, *s)
+}
+
+func (h *recordingHook) OnUnmarshalError(s *HookStats, err error) {
+	h.calls = append(h.calls, "OnUnmarshalError")
+	h.stats = append(h.stats, *s)
+}
+
+func TestMarshalHooks(t *testing.T) {
+	h := new(recordingHook)
+	a := &reqArticle{ID: "1", Author: &reqAuthor{ID: "9"}, Comments: []*reqComment{{ID: 5}, {ID: 6}}}
+	var buf bytes.Buffer
+	if err := MarshalPayload(&buf, a, WithHook(h)); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"OnMarshalStart articles", "OnMarshalEnd"}; !equalStrings(h.calls, want) {
+		t.Errorf("calls %v, want %v", h.calls, want)
+	}
+	s := h.stats[0]
+	if s.Type != "articles" || s.Resources != 1 || s.Included != 3 || s.Bytes != int64(buf.Len()) {
+		t.Errorf("stats %+v", s)
+	}
+	if len(h.nodes) != 4 {
+		t.Errorf("visited %v, want every resource object", h.nodes)
+	}
+
+	h = new(recordingHook)
+	if _, err := Marshal([]*reqAuthor{{ID: "1"}, {ID: "2"}}, WithHook(h), WithHook(NopHook{})); err != nil {
+		t.Fatal(err)
+	}
+	if s := h.stats[0]; s.Type != "people" || s.Resources != 2 || s.Bytes != 0 {
+		t.Errorf("stats %+v", s)
+	}
+}
+
+func TestMarshalHooksError(t *testing.T) {
+	h := new(recordingHook)
+	if _, err := Marshal(nil, WithHook(h)); err != ErrUnexpectedType {
+		t.Errorf("error %v, want ErrUnexpectedType", err)
+	}
+	if want := []string{"OnMarshalStart ", "OnMarshalEnd"}; !equalStrings(h.calls, want) {
+		t.Errorf("calls %q, want %q", h.calls, want)
+	}
+	if s := h.stats[0]; s.Resources != 0 || s.Type != "" {
+		t.Errorf("stats %+v", s)
+	}
+}
+
+func TestUnmarshalHooks(t *testing.T) {
+	h := new(recordingHook)
+	if err := UnmarshalPayload(strings.NewReader(reqArticleDoc), new(reqArticle), UseHook(h)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"OnUnmarshalStart articles", "OnUnmarshalEnd"}; !equalStrings(h.calls, want) {
+		t.Errorf("calls %v, want %v", h.calls, want)
+	}
+	if s := h.stats[0]; s.Resources != 1 || s.Included != 2 || s.Bytes != int64(len(reqArticleDoc)) {
+		t.Errorf("stats %+v", s)
+	}
+
+	h = new(recordingHook)
+	doc := `{"data": [{"type": "people", "id": "1"}, {"type": "people", "id": "2"}]}`
+	if _, err := UnmarshalManyPayload(strings.NewReader(doc), reflect.TypeOf(new(reqAuthor)), UseHook(h)); err != nil {
+		t.Fatal(err)
+	}
+	if s := h.stats[0]; s.Type != "people" || s.Resources != 2 {
+		t.Errorf("stats %+v", s)
+	}
+}
+
+func TestUnmarshalHooksError(t *testing.T) {
+	h := new(recordingHook)
+	err := UnmarshalPayload(strings.NewReader(`{"data": `), new(reqArticle), UseHook(h))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if want := []string{"OnUnmarshalStart articles", "OnUnmarshalError"}; !equalStrings(h.calls, want) {
+		t.Errorf("calls %v, want %v", h.calls, want)
+	}
+
+	h = new(recordingHook)
+	if err := UnmarshalPayload(strings.NewReader(reqArticleDoc), nil, UseHook(h)); !errors.Is(err, ErrUnexpectedType) {
+		t.Errorf("error %v, want ErrUnexpectedType", err)
+	}
+	if want := []string{"OnUnmarshalStart ", "OnUnmarshalError"}; !equalStrings(h.calls, want) {
+		t.Errorf("calls %q, want %q", h.calls, want)
+	}
+}
@@ -0,0 +1,231 @@
+package jsonapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// IdempotencyKeyHeader is the request header carrying the idempotency key
+// of a POST or PATCH request, per the IETF Idempotency-Key draft.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Errors of idempotent requests; see StatusForError.
+var (
+	// ErrInvalidIdempotencyKey is returned for an empty key, a key longer
+	// than 255 characters or one with characters other than printable ASCII.
+	ErrInvalidIdempotencyKey = errors.New("jsonapi: invalid idempotency key")
+	// ErrIdempotencyKeyReused is returned when a key is sent again with a
+	// different document.
+	ErrIdempotencyKeyReused = errors.New("jsonapi: idempotency key reused with a different document")
+)
+
+// IdempotencyKey returns the idempotency key of r, or "" if it has none.
+// The key may be sent as a bare token or as a quoted string.
+func IdempotencyKey(r *http.Request) (string, error) {
+	values := r.Header.Values(IdempotencyKeyHeader)
+	if len(values) == 0 {
+		return "", nil
+	}
+	if len(values) > 1 {
+		return "", ErrInvalidIdempotencyKey
+	}
+
+	key := strings.TrimSpace(values[0])
+	if len(key) >= 2 && key[0] == '"' && key[len(key)-1] == '"' {
+		key = key[1 : len(key)-1]
+	}
+	if key == "" || len(key) > 255 {
+		return "", ErrInvalidIdempotencyKey
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e || key[i] == '"' || key[i] == '\\' {
+			return "", ErrInvalidIdempotencyKey
+		}
+	}
+	return key, nil
+}
+
+// DocumentHash returns the hex-encoded SHA-256 of the canonical form of
+// the JSON document doc, see Canonicalize, so that documents differing in
+// member order or whitespace only have the same hash. Other input is
+// hashed as it is.
+func DocumentHash(doc []byte) string {
+	if canonical, err := Canonicalize(doc); err == nil {
+		doc = canonical
+	}
+	sum := sha256.Sum256(doc)
+	return hex.EncodeToString(sum[:])
+}
+
+// UnmarshalIdempotentRequest unmarshals the body of r into model as
+// UnmarshalPayload does, MaxBodyBytes included. It returns the idempotency
+// key of r, "" if it has none, and the DocumentHash of the body, for
+// handlers that detect replays themselves.
+func UnmarshalIdempotentRequest(r *http.Request, model interface{}, opts ...UnmarshalOption) (key, hash string, err error) {
+	if key, err = IdempotencyKey(r); err != nil {
+		return "", "", err
+	}
+	body, err := ioutil.ReadAll(newUnmarshalOptions(opts).body(r.Body))
+	if err != nil {
+		return "", "", err
+	}
+	if err := UnmarshalPayload(bytes.NewReader(body), model, opts...); err != nil {
+		return "", "", err
+	}
+	return key, DocumentHash(body), nil
+}
+
+// IdempotentResponse is a response stored for an idempotency key, along
+// with the hash of the request document.
+type IdempotentResponse struct {
+	Hash   string
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// IdempotencyStore stores the responses of idempotent requests, e.g. in
+// Redis or a database table with an expiry. Idempotency scopes the keys it
+// stores by the method and path of the request, e.g.
+// "POST /appointments 4f2c9a"; they should also be scoped to the client,
+// e.g. by wrapping the store, since clients choose them.
+type IdempotencyStore interface {
+	// Get returns the response stored for key, or nil.
+	Get(ctx context.Context, key string) (*IdempotentResponse, error)
+	// Put stores the response to the request with key.
+	Put(ctx context.Context, key string, resp *IdempotentResponse) error
+}
+
+// MemoryIdempotencyStore is an IdempotencyStore keeping responses in
+// memory, forever, for tests and single-instance services.
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	responses map[string]*IdempotentResponse
+}
+
+// Get implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Get(ctx context.Context, key string) (*IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.responses[key], nil
+}
+
+// Put implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Put(ctx context.Context, key string, resp *IdempotentResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.responses == nil {
+		s.responses = make(map[string]*IdempotentResponse)
+	}
+	s.responses[key] = resp
+	return nil
+}
+
+// defaultIdempotentBodyBytes is the size limit of the bodies Idempotency
+// reads without MaxBodyBytes.
+const defaultIdempotentBodyBytes = 1 << 20
+
+// Idempotency returns middleware making POST and PATCH requests with an
+// idempotency key safe to retry. The response to the first request with a
+// key is stored, unless it has a 5xx status, and replayed for the
+// following ones, marked with an Idempotent-Replayed header; a retry with
+// a different document gets ErrIdempotencyKeyReused. Requests without a
+// key pass through. Concurrent requests with the same key are not
+// serialized; the store may reject the second Put for that.
+//
+// The body is read before the request is handled, to hash it, up to the
+// MaxBodyBytes of opts, 1 MiB by default; a larger body gets
+// ErrBodyTooLarge.
+func Idempotency(store IdempotencyStore, opts ...UnmarshalOption) func(http.Handler) http.Handler {
+	o := newUnmarshalOptions(opts)
+	if o.maxBodyBytes <= 0 {
+		o.maxBodyBytes = defaultIdempotentBodyBytes
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost && r.Method != http.MethodPatch {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key, err := IdempotencyKey(r)
+			if err != nil {
+				_ = WriteErrorFor(w, r, err)
+				return
+			}
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := ioutil.ReadAll(o.body(r.Body))
+			if err != nil {
+				_ = WriteErrorFor(w, r, err)
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			hash := DocumentHash(body)
+			key = r.Method + " " + r.URL.Path + " " + key
+
+			stored, err := store.Get(r.Context(), key)
+			if err != nil {
+				_ = WriteErrorFor(w, r, err)
+				return
+			}
+			if stored != nil {
+				if stored.Hash != hash {
+					_ = WriteErrorFor(w, r, ErrIdempotencyKeyReused)
+					return
+				}
+				for k, v := range stored.Header {
+					w.Header()[k] = v
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(stored.Status)
+				_, _ = w.Write(stored.Body)
+				return
+			}
+
+			rec := &idempotencyRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			if rec.status >= http.StatusInternalServerError {
+				return
+			}
+			_ = store.Put(r.Context(), key, &IdempotentResponse{
+				Hash:   hash,
+				Status: rec.status,
+				Header: w.Header().Clone(),
+				Body:   rec.body.Bytes(),
+			})
+		})
+	}
+}
+
+// idempotencyRecorder passes a response through while keeping a copy.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *idempotencyRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *idempotencyRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the wrapped ResponseWriter, for http.ResponseController.
+func (r *idempotencyRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
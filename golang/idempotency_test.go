@@ -0,0 +1,177 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   string
+		err    error
+	}{
+		{nil, "", nil},
+		{[]string{"abc-123"}, "abc-123", nil},
+		{[]string{` "abc" `}, "abc", nil},
+		{[]string{""}, "", ErrInvalidIdempotencyKey},
+		{[]string{`""`}, "", ErrInvalidIdempotencyKey},
+		{[]string{"a", "b"}, "", ErrInvalidIdempotencyKey},
+		{[]string{strings.Repeat("k", 256)}, "", ErrInvalidIdempotencyKey},
+		{[]string{"café"}, "", ErrInvalidIdempotencyKey},
+		{[]string{`a"b`}, "", ErrInvalidIdempotencyKey},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		for _, v := range tt.values {
+			r.Header.Add(IdempotencyKeyHeader, v)
+		}
+		got, err := IdempotencyKey(r)
+		if got != tt.want || err != tt.err {
+			t.Errorf("%q: key %q, error %v; want %q, %v", tt.values, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestDocumentHash(t *testing.T) {
+	a := DocumentHash([]byte(`{"data": {"type": "people", "id": "1"}}`))
+	b := DocumentHash([]byte(`{"data":{"id":"1","type":"people"}}`))
+	if a != b || len(a) != 64 {
+		t.Errorf("hashes %s and %s of the same document", a, b)
+	}
+	if DocumentHash([]byte(`{"data":{"id":"2","type":"people"}}`)) == a {
+		t.Error("different documents have the same hash")
+	}
+	if DocumentHash([]byte(`not json`)) == DocumentHash([]byte(`not  json`)) {
+		t.Error("other input is not hashed as it is")
+	}
+}
+
+func TestUnmarshalIdempotentRequest(t *testing.T) {
+	body := `{"data": {"type": "people", "id": "1", "attributes": {"name": "Ann"}}}`
+	r := httptest.NewRequest(http.MethodPost, "/people", strings.NewReader(body))
+	r.Header.Set(IdempotencyKeyHeader, "k1")
+	var a reqAuthor
+	key, hash, err := UnmarshalIdempotentRequest(r, &a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "k1" || hash != DocumentHash([]byte(body)) || a.Name != "Ann" {
+		t.Errorf("key %q, hash %s, author %+v", key, hash, a)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/people", strings.NewReader(body))
+	if _, _, err := UnmarshalIdempotentRequest(r, &a, MaxBodyBytes(16)); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("error %v, want ErrBodyTooLarge", err)
+	}
+	r = httptest.NewRequest(http.MethodPost, "/people", strings.NewReader(body))
+	r.Header.Add(IdempotencyKeyHeader, "a")
+	r.Header.Add(IdempotencyKeyHeader, "b")
+	if _, _, err := UnmarshalIdempotentRequest(r, &a); err != ErrInvalidIdempotencyKey {
+		t.Errorf("error %v, want ErrInvalidIdempotencyKey", err)
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	calls := 0
+	h := Idempotency(new(MemoryIdempotencyStore))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var a reqAuthor
+		if err := UnmarshalPayload(r.Body, &a); err != nil {
+			_ = WriteErrorFor(w, r, err)
+			return
+		}
+		if a.Name == "fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Location", "/people/"+a.ID)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "created %d", calls)
+	}))
+	serve := func(method, path, key, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if key != "" {
+			r.Header.Set(IdempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	doc := `{"data": {"type": "people", "id": "1", "attributes": {"name": "Ann"}}}`
+
+	first := serve(http.MethodPost, "/people", "k1", doc)
+	if first.Code != http.StatusCreated || first.Body.String() != "created 1" {
+		t.Fatalf("first: %d %q", first.Code, first.Body)
+	}
+
+	tests := []struct {
+		name         string
+		method, path string
+		key, body    string
+		status       int
+		response     string
+		replayed     bool
+	}{
+		{"replay", http.MethodPost, "/people", "k1", `{"data":{"id":"1","type":"people","attributes":{"name":"Ann"}}}`,
+			http.StatusCreated, "created 1", true},
+		{"reused", http.MethodPost, "/people", "k1", `{"data": {"type": "people", "id": "2"}}`,
+			http.StatusUnprocessableEntity, "", false},
+		{"other path", http.MethodPost, "/authors", "k1", doc, http.StatusCreated, "created 2", false},
+		{"other method", http.MethodPatch, "/people", "k1", doc, http.StatusCreated, "created 3", false},
+		{"no key", http.MethodPost, "/people", "", doc, http.StatusCreated, "created 4", false},
+		{"not stored", http.MethodPost, "/people", "k2", `{"data": {"type": "people", "id": "3", "attributes": {"name": "fail"}}}`,
+			http.StatusServiceUnavailable, "", false},
+		{"retried", http.MethodPost, "/people", "k2", `{"data": {"type": "people", "id": "3", "attributes": {"name": "fail"}}}`,
+			http.StatusServiceUnavailable, "", false},
+		// Other methods pass through, here to a handler rejecting the empty body.
+		{"GET", http.MethodGet, "/people", "k1", "", http.StatusBadRequest, "", false},
+	}
+	for _, tt := range tests {
+		w := serve(tt.method, tt.path, tt.key, tt.body)
+		if w.Code != tt.status || tt.response != "" && w.Body.String() != tt.response {
+			t.Errorf("%s: %d %q, want %d %q", tt.name, w.Code, w.Body, tt.status, tt.response)
+		}
+		if replayed := w.Header().Get("Idempotent-Replayed") == "true"; replayed != tt.replayed {
+			t.Errorf("%s: replayed %v", tt.name, replayed)
+		}
+		if tt.replayed && w.Header().Get("Location") != "/people/1" {
+			t.Errorf("%s: headers %v", tt.name, w.Header())
+		}
+	}
+	if calls != 7 {
+		t.Errorf("handler called %d times, want 7", calls)
+	}
+}
+
+func TestIdempotencyBodyLimit(t *testing.T) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+	doc := `{"data": {"type": "people", "id": "1", "attributes": {"name": "` + strings.Repeat("a", 64) + `"}}}`
+
+	tests := []struct {
+		name   string
+		h      http.Handler
+		body   string
+		status int
+	}{
+		{"option", Idempotency(new(MemoryIdempotencyStore), MaxBodyBytes(32))(next), doc, http.StatusRequestEntityTooLarge},
+		{"default", Idempotency(new(MemoryIdempotencyStore))(next),
+			`"` + strings.Repeat("a", defaultIdempotentBodyBytes) + `"`, http.StatusRequestEntityTooLarge},
+		{"within", Idempotency(new(MemoryIdempotencyStore), MaxBodyBytes(int64(len(doc))))(next), doc, http.StatusOK},
+	}
+	for _, tt := range tests {
+		called = false
+		r := httptest.NewRequest(http.MethodPost, "/people", strings.NewReader(tt.body))
+		r.Header.Set(IdempotencyKeyHeader, "k1")
+		w := httptest.NewRecorder()
+		tt.h.ServeHTTP(w, r)
+		if w.Code != tt.status || called != (tt.status == http.StatusOK) {
+			t.Errorf("%s: status %d, handler called %v", tt.name, w.Code, called)
+		}
+	}
+}
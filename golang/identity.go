@@ -0,0 +1,71 @@
+package jsonapi
+
+// identityMap records the Node built for each model during one traversal
+// of a model graph, keyed by the model pointer. A model found in several
+// places, such as an author shared by the posts of a collection, is then
+// visited once and its Node reused, and self-referential graphs, such as
+// people who are each other's friends, terminate: a model reached again
+// while its own relationships are being visited yields its unfinished
+// Node, whose type and id are set as long as the primary field comes
+// before the relation fields.
+//
+// The Node of a model depends on the model alone unless WithInclude is
+// set, in which case the relationship path it is reached through matters
+// as well and a Node is only reused for the same path. With a NodeVisitor,
+// which may skip a model reached through one path but not another, Nodes
+// are not reused at all; cycles still terminate.
+type identityMap struct {
+	nodes    map[identityKey]*Node
+	visiting map[interface{}]*Node
+}
+
+type identityKey struct {
+	model interface{}
+	path  string
+}
+
+// key returns the key of the Node of model reached through path, or false
+// if it must not be reused.
+func (m *identityMap) key(model interface{}, o *marshalOptions, path string) (identityKey, bool) {
+	if o.visitor != nil {
+		return identityKey{}, false
+	}
+	if o.include == nil {
+		path = ""
+	}
+	return identityKey{model: model, path: path}, true
+}
+
+// lookup returns the Node already built, or being built, for model.
+func (m *identityMap) lookup(model interface{}, o *marshalOptions, path string) (*Node, bool) {
+	if n, ok := m.visiting[model]; ok {
+		return n, true
+	}
+	key, ok := m.key(model, o, path)
+	if !ok {
+		return nil, false
+	}
+	n, ok := m.nodes[key]
+	return n, ok
+}
+
+// enter marks model as being visited, with n as its Node.
+func (m *identityMap) enter(model interface{}, n *Node) {
+	if m.visiting == nil {
+		m.visiting = make(map[interface{}]*Node)
+	}
+	m.visiting[model] = n
+}
+
+// leave records n as the Node of model.
+func (m *identityMap) leave(model interface{}, o *marshalOptions, path string, n *Node) {
+	delete(m.visiting, model)
+	key, ok := m.key(model, o, path)
+	if !ok {
+		return
+	}
+	if m.nodes == nil {
+		m.nodes = make(map[identityKey]*Node)
+	}
+	m.nodes[key] = n
+}
@@ -0,0 +1,121 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestIdentityMap(t *testing.T) {
+	model := &orderPerson{ID: "1"}
+	n := &Node{Type: "people", ID: "1"}
+
+	var m identityMap
+	o := newMarshalOptions(nil)
+	if _, ok := m.lookup(model, o, "author"); ok {
+		t.Fatal("lookup in an empty map succeeded")
+	}
+
+	m.enter(model, n)
+	if got, ok := m.lookup(model, o, "comments.author"); !ok || got != n {
+		t.Errorf("lookup while visiting = %v, %v", got, ok)
+	}
+	m.leave(model, o, "author", n)
+	if _, visiting := m.visiting[model]; visiting {
+		t.Error("model still visiting after leave")
+	}
+	if got, ok := m.lookup(model, o, "comments.author"); !ok || got != n {
+		t.Errorf("lookup through another path without WithInclude = %v, %v", got, ok)
+	}
+
+	// With WithInclude the path matters.
+	var byPath identityMap
+	o = newMarshalOptions([]MarshalOption{WithInclude("author")})
+	byPath.leave(model, o, "author", n)
+	if got, ok := byPath.lookup(model, o, "author"); !ok || got != n {
+		t.Errorf("lookup through the same path = %v, %v", got, ok)
+	}
+	if _, ok := byPath.lookup(model, o, "comments.author"); ok {
+		t.Error("lookup through another path with WithInclude succeeded")
+	}
+
+	// With a NodeVisitor nothing is reused, but cycles are still seen.
+	var visited identityMap
+	o = newMarshalOptions([]MarshalOption{WithNodeVisitor(func(interface{}, *Node, int, string) error { return nil })})
+	visited.leave(model, o, "author", n)
+	if _, ok := visited.lookup(model, o, "author"); ok {
+		t.Error("lookup with a NodeVisitor succeeded")
+	}
+	visited.enter(model, n)
+	if got, ok := visited.lookup(model, o, "author"); !ok || got != n {
+		t.Errorf("lookup while visiting with a NodeVisitor = %v, %v", got, ok)
+	}
+}
+
+func TestMarshalSharedModels(t *testing.T) {
+	ann := &orderPerson{ID: "9"}
+	posts := []*orderPost{
+		{ID: "1", Author: ann, Comments: []*orderComment{{ID: "a", Author: ann}}},
+		{ID: "2", Author: ann},
+	}
+	p, err := Marshal(posts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := p.(*ManyPayload)
+	if got := len(payload.Included); got != 2 {
+		t.Errorf("included %d resources, want the person and the comment once", got)
+	}
+
+	a1 := payload.Data[0].Relationships["author"].(*RelationshipOneNode).Data
+	a2 := payload.Data[1].Relationships["author"].(*RelationshipOneNode).Data
+	if a1.ID != "9" || a2.ID != "9" {
+		t.Errorf("authors %+v, %+v", a1, a2)
+	}
+}
+
+func TestMarshalCycles(t *testing.T) {
+	one := &limitPerson{ID: "1", Name: "One"}
+	two := &limitPerson{ID: "2", Name: "Two", Friend: one, Friends: []*limitPerson{one}}
+	one.Friend = two
+	one.Friends = []*limitPerson{one, two}
+
+	if got, want := includedKeys(t, one), []string{"people/2"}; !equalStrings(got, want) {
+		t.Errorf("included %v, want %v without the primary data", got, want)
+	}
+	if got, want := includedKeys(t, []*limitPerson{two}), []string{"people/1"}; !equalStrings(got, want) {
+		t.Errorf("included %v, want %v", got, want)
+	}
+
+	// Embedded, a resource refers to itself by identifier.
+	var buf bytes.Buffer
+	if err := MarshalOnePayloadEmbedded(&buf, one); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Data struct {
+			Relationships struct {
+				Friend struct {
+					Data struct {
+						Attributes    map[string]interface{} `json:"attributes"`
+						Relationships struct {
+							Friend struct {
+								Data map[string]interface{} `json:"data"`
+							} `json:"friend"`
+						} `json:"relationships"`
+					} `json:"data"`
+				} `json:"friend"`
+			} `json:"relationships"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	friend := doc.Data.Relationships.Friend.Data
+	if friend.Attributes["name"] != "Two" {
+		t.Errorf("friend %+v, want the embedded person", friend)
+	}
+	if back := friend.Relationships.Friend.Data; back["id"] != "1" || back["attributes"] != nil {
+		t.Errorf("friend of friend %v, want an identifier", back)
+	}
+}
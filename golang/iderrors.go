@@ -0,0 +1,42 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// IDError reports the id of a resource of Type that cannot be stored in
+// the primary field of the target struct, such as "abc" for an int field.
+// Err is ErrBadJSONAPIID.
+type IDError struct {
+	Type string
+	ID   string
+	Err  error
+}
+
+func (e *IDError) Error() string {
+	return fmt.Sprintf("jsonapi: id %q of resource of type %q: %v", e.ID, e.Type, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *IDError) Unwrap() error {
+	return e.Err
+}
+
+// Pointer returns a JSON pointer to the id, relative to the resource
+// object.
+func (e *IDError) Pointer() string {
+	return jsonPointer("id")
+}
+
+// ErrorObject converts e into a 400 error object pointing at the primary
+// data of a single-resource document.
+func (e *IDError) ErrorObject() *ErrorObject {
+	return &ErrorObject{
+		Title:  "Invalid id",
+		Detail: e.Error(),
+		Status: strconv.Itoa(http.StatusBadRequest),
+		Source: &ErrorSource{Pointer: "/data" + e.Pointer()},
+	}
+}
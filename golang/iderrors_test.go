@@ -0,0 +1,96 @@
+package jsonapi
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIDError(t *testing.T) {
+	doc := `{"data": {"type": "comments", "id": "abc"}}`
+	err := UnmarshalPayload(strings.NewReader(doc), new(reqComment))
+
+	var idErr *IDError
+	if !errors.As(err, &idErr) || !errors.Is(err, ErrBadJSONAPIID) {
+		t.Fatalf("error %v, want an *IDError for ErrBadJSONAPIID", err)
+	}
+	if idErr.Type != "comments" || idErr.ID != "abc" || idErr.Pointer() != "/id" {
+		t.Errorf("id error %+v", idErr)
+	}
+	obj := idErr.ErrorObject()
+	if obj.Status != "400" || obj.Source.Pointer != "/data/id" || !strings.Contains(obj.Detail, `id "abc"`) {
+		t.Errorf("error object %+v", obj)
+	}
+}
+
+func TestIDErrorLenient(t *testing.T) {
+	doc := `{"data": [{"type": "comments", "id": "1"}, {"type": "comments", "id": "x"}]}`
+	_, errs, err := UnmarshalManyLenient(strings.NewReader(doc), reflect.TypeOf(new(reqComment)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 1 || errs[0].Pointer != "/data/1/id" {
+		t.Errorf("errors %v", errs)
+	}
+}
+
+func TestTypedUnmarshalErrors(t *testing.T) {
+	doc := `{"data": {"type": "articles", "id": "1", "attributes": {"views": "many"}}}`
+	err := UnmarshalPayload(strings.NewReader(doc), new(reqArticle))
+	var attrErr *AttributeError
+	if !errors.As(err, &attrErr) || !errors.Is(err, ErrInvalidType) || attrErr.Attribute != "views" {
+		t.Errorf("error %v, want an *AttributeError for views", err)
+	}
+
+	doc = `{"data": {"type": "people", "id": "1", "relationships": {"friends": {"data": [
+		{"type": "people", "id": "2"}, {"type": "people", "id": "3"}]}}}}`
+	err = UnmarshalPayload(strings.NewReader(doc), new(limitPerson), MaxRelationshipFanout(1))
+	var relErr *RelationshipError
+	if !errors.As(err, &relErr) || !errors.Is(err, ErrRelationshipFanout) || relErr.Relation != "friends" {
+		t.Errorf("error %v, want a *RelationshipError for friends", err)
+	}
+}
+
+func TestModelErrors(t *testing.T) {
+	type unknownAnnotation struct {
+		ID   string `jsonapi:"primary,things"`
+		Name string `jsonapi:"weird,name"`
+	}
+	type shortTag struct {
+		ID   string `jsonapi:"primary,things"`
+		Name string `jsonapi:"attr"`
+	}
+	type floatID struct {
+		ID float64 `jsonapi:"primary,things"`
+	}
+
+	tests := []struct {
+		model   interface{}
+		field   string
+		msg     string
+		wantErr error
+	}{
+		{&unknownAnnotation{ID: "1"}, "Name", `unknown annotation "weird"`, ErrBadJSONAPIStructTag},
+		{&shortTag{ID: "1"}, "Name", "wrong number of arguments for attr", ErrBadJSONAPIStructTag},
+		{&floatID{ID: 1}, "ID", "primary field of unsupported type float64", ErrBadJSONAPIID},
+	}
+	for _, tt := range tests {
+		_, err := Marshal(tt.model)
+		var modelErr *ModelError
+		if !errors.As(err, &modelErr) || !errors.Is(err, tt.wantErr) {
+			t.Errorf("%T: error %v, want a *ModelError for %v", tt.model, err, tt.wantErr)
+			continue
+		}
+		if modelErr.Field != tt.field || modelErr.Msg != tt.msg || modelErr.Model != reflect.TypeOf(tt.model).Elem().String() {
+			t.Errorf("%T: model error %+v", tt.model, modelErr)
+		}
+	}
+
+	doc := `{"data": {"type": "things", "id": "1", "attributes": {"name": "x"}}}`
+	err := UnmarshalPayload(strings.NewReader(doc), new(unknownAnnotation))
+	var modelErr *ModelError
+	if !errors.As(err, &modelErr) || modelErr.Field != "Name" || !errors.Is(err, ErrBadJSONAPIStructTag) {
+		t.Errorf("unmarshal error %v, want a *ModelError for Name", err)
+	}
+}
@@ -0,0 +1,258 @@
+package jsonapi
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// A relation tagged with the ids option is backed by foreign-key fields
+// instead of related structs:
+//
+//	AuthorID string `jsonapi:"relation,author,ids=people"`
+//	TagIDs   []int  `jsonapi:"relation,tags,ids"`
+//
+// The field is a string, an integer, a type implementing both
+// encoding.TextMarshaler and encoding.TextUnmarshaler, a pointer to one of
+// these, or a slice of any of them for a to-many relationship. Marshaling
+// writes the resource linkage only, so there is nothing to include; a zero
+// or nil to-one id is written as null. The related type is given after
+// "ids="; without it, it is the relation name for a to-many relationship
+// and its plural for a to-one relationship, "author" giving "authors".
+
+// idsOption reports whether opt is the ids option and returns the type it
+// names, if any.
+func idsOption(opt string) (string, bool) {
+	if opt == annotationIDs {
+		return "", true
+	}
+	if strings.HasPrefix(opt, annotationIDs+"=") {
+		return opt[len(annotationIDs)+1:], true
+	}
+	return "", false
+}
+
+// isIDsOption reports whether opt is a well-formed ids option.
+func isIDsOption(opt string) bool {
+	typ, ok := idsOption(opt)
+	return opt == annotationIDs || ok && typ != ""
+}
+
+// idsRelationType reports whether the relation tag args of a field of type
+// t carry the ids option, and returns the type of the related resources.
+func idsRelationType(args []string, t reflect.Type) (string, bool) {
+	if len(args) < 2 {
+		return "", false
+	}
+	for _, arg := range args[2:] {
+		typ, ok := idsOption(arg)
+		if !ok {
+			continue
+		}
+		if typ == "" {
+			typ = args[1]
+			if t.Kind() != reflect.Slice {
+				typ = Pluralize(typ)
+			}
+		}
+		return typ, true
+	}
+	return "", false
+}
+
+// isValidRelationIDType reports whether a single id of an ids relation can
+// be stored in a field of type t.
+func isValidRelationIDType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if (t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)) &&
+		reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// formatRelationID returns the string form of the id held by v. ok is false
+// for nil pointers and zero values.
+func formatRelationID(v reflect.Value) (id string, ok bool, err error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", false, nil
+		}
+		v = v.Elem()
+	}
+	if v.IsZero() {
+		return "", false, nil
+	}
+
+	if m, ok := textMarshaler(v); ok {
+		text, err := m.MarshalText()
+		if err != nil {
+			return "", false, err
+		}
+		return string(text), true, nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true, nil
+	}
+	return "", false, ErrBadJSONAPIID
+}
+
+// parseRelationID converts id into a value of type t, the reverse of
+// formatRelationID.
+func parseRelationID(id string, t reflect.Type) (reflect.Value, error) {
+	if t.Kind() == reflect.Ptr {
+		v, err := parseRelationID(id, t.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(v)
+		return ptr, nil
+	}
+
+	v := reflect.New(t).Elem()
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(id)); err != nil {
+			return reflect.Value{}, ErrBadJSONAPIID
+		}
+		return v, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(id)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(id, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, ErrBadJSONAPIID
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(id, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, ErrBadJSONAPIID
+		}
+		v.SetUint(n)
+	default:
+		return reflect.Value{}, ErrBadJSONAPIID
+	}
+	return v, nil
+}
+
+// marshalIDsRelationship builds the relationship object of the ids
+// relation name of model, held by fieldValue. It returns nil when the
+// relationship is empty and omitEmpty is set.
+func marshalIDsRelationship(model interface{}, fieldValue reflect.Value, name, typ string,
+	omitEmpty, count bool) (interface{}, error) {
+	var links *Links
+	if linkableModel, ok := model.(RelationshipLinkable); ok {
+		links = linkableModel.JSONAPIRelationshipLinks(name)
+	}
+
+	var meta *Meta
+	if metableModel, ok := model.(RelationshipMetable); ok {
+		meta = metableModel.JSONAPIRelationshipMeta(name)
+	}
+
+	if fieldValue.Kind() != reflect.Slice {
+		id, ok, err := formatRelationID(fieldValue)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			if omitEmpty {
+				return nil, nil
+			}
+			return &RelationshipOneNode{Data: nil, Links: links, Meta: meta}, nil
+		}
+		return &RelationshipOneNode{Data: &Node{Type: typ, ID: id}, Links: links, Meta: meta}, nil
+	}
+
+	if omitEmpty && fieldValue.Len() < 1 {
+		return nil, nil
+	}
+	if count {
+		meta = withCount(model, name, fieldValue.Len(), meta)
+	}
+
+	data := make([]*Node, 0, fieldValue.Len())
+	for i := 0; i < fieldValue.Len(); i++ {
+		id, ok, err := formatRelationID(fieldValue.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			data = append(data, &Node{Type: typ, ID: id})
+		}
+	}
+	return &RelationshipManyNode{Data: data, Links: links, Meta: meta}, nil
+}
+
+// unmarshalIDsRelationship stores the ids of the relationship object rel in
+// fieldValue, an ids relation field. The related resources must be of type
+// typ.
+func unmarshalIDsRelationship(rel interface{}, fieldValue reflect.Value, typ string,
+	o *unmarshalOptions) error {
+	t := fieldValue.Type()
+
+	if t.Kind() != reflect.Slice {
+		relationship := new(RelationshipOneNode)
+		if err := remarshal(rel, relationship); err != nil {
+			return err
+		}
+		if relationship.Data == nil {
+			fieldValue.Set(reflect.Zero(t))
+			return nil
+		}
+		if relationship.Data.Type != typ {
+			return fmt.Errorf("jsonapi: relationship of type %q, expected %q",
+				relationship.Data.Type, typ)
+		}
+
+		id, err := parseRelationID(relationship.Data.ID, t)
+		if err != nil {
+			return err
+		}
+		fieldValue.Set(id)
+		return nil
+	}
+
+	relationship := new(RelationshipManyNode)
+	if err := remarshal(rel, relationship); err != nil {
+		return err
+	}
+	if o.maxFanout > 0 && len(relationship.Data) > o.maxFanout {
+		return ErrRelationshipFanout
+	}
+
+	ids := reflect.MakeSlice(t, 0, len(relationship.Data))
+	for _, n := range relationship.Data {
+		if n.Type != typ {
+			return fmt.Errorf("jsonapi: relationship of type %q, expected %q", n.Type, typ)
+		}
+		id, err := parseRelationID(n.ID, t.Elem())
+		if err != nil {
+			return err
+		}
+		ids = reflect.Append(ids, id)
+	}
+	fieldValue.Set(ids)
+	return nil
+}
@@ -0,0 +1,131 @@
+package jsonapi
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type idsPost struct {
+	ID       string        `jsonapi:"primary,posts"`
+	AuthorID string        `jsonapi:"relation,author,ids"`
+	EditorID *int          `jsonapi:"relation,editor,ids=people"`
+	TagIDs   []int         `jsonapi:"relation,tags,ids"`
+	Objects  []reqObjectID `jsonapi:"relation,objects,ids=documents"`
+}
+
+func TestIDsRelationType(t *testing.T) {
+	tests := []struct {
+		args []string
+		t    reflect.Type
+		want string
+		ok   bool
+	}{
+		{[]string{"relation", "author", "ids"}, reflect.TypeOf(""), "authors", true},
+		{[]string{"relation", "author", "ids=people"}, reflect.TypeOf(""), "people", true},
+		{[]string{"relation", "tags", "ids"}, reflect.TypeOf([]int{}), "tags", true},
+		{[]string{"relation", "author", "omitempty"}, reflect.TypeOf(""), "", false},
+		{[]string{"relation"}, reflect.TypeOf(""), "", false},
+	}
+	for _, tt := range tests {
+		got, ok := idsRelationType(tt.args, tt.t)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%v: got %q, %v, want %q, %v", tt.args, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	if isIDsOption("ids=") || !isIDsOption("ids") || !isIDsOption("ids=people") {
+		t.Error("isIDsOption misreads the ids option")
+	}
+}
+
+func TestIDsRelationships(t *testing.T) {
+	editor := 7
+	in := &idsPost{
+		ID:       "1",
+		AuthorID: "9",
+		EditorID: &editor,
+		TagIDs:   []int{3, 4},
+		Objects:  []reqObjectID{{0xca, 0xfe, 0xba, 0xbe}},
+	}
+
+	doc := marshalDoc(t, in)
+	if _, ok := doc["included"]; ok {
+		t.Errorf("included %v, want none", doc["included"])
+	}
+	rels := doc["data"].(map[string]interface{})["relationships"].(map[string]interface{})
+	want := map[string]interface{}{
+		"author": map[string]interface{}{"data": map[string]interface{}{"type": "authors", "id": "9"}},
+		"editor": map[string]interface{}{"data": map[string]interface{}{"type": "people", "id": "7"}},
+		"tags": map[string]interface{}{"data": []interface{}{
+			map[string]interface{}{"type": "tags", "id": "3"},
+			map[string]interface{}{"type": "tags", "id": "4"},
+		}},
+		"objects": map[string]interface{}{"data": []interface{}{
+			map[string]interface{}{"type": "documents", "id": "cafebabe"},
+		}},
+	}
+	if !reflect.DeepEqual(rels, want) {
+		t.Errorf("relationships %v, want %v", rels, want)
+	}
+
+	b, err := MarshalBytes(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(idsPost)
+	if err := UnmarshalPayload(strings.NewReader(string(b)), out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("round trip %+v, want %+v", out, in)
+	}
+}
+
+func TestIDsRelationshipsEmpty(t *testing.T) {
+	rels := marshalDoc(t, &idsPost{ID: "1"})["data"].(map[string]interface{})["relationships"].(map[string]interface{})
+	for _, name := range []string{"author", "editor"} {
+		rel := rels[name].(map[string]interface{})
+		if data, ok := rel["data"]; !ok || data != nil {
+			t.Errorf("%s %v, want null linkage", name, rel)
+		}
+	}
+
+	doc := `{"data": {"type": "posts", "id": "1", "relationships": {
+		"author": {"data": null}, "editor": {"data": null}}}}`
+	editor := 7
+	out := &idsPost{AuthorID: "9", EditorID: &editor}
+	if err := UnmarshalPayload(strings.NewReader(doc), out); err != nil {
+		t.Fatal(err)
+	}
+	if out.AuthorID != "" || out.EditorID != nil {
+		t.Errorf("unmarshaled %+v, want the ids cleared", out)
+	}
+}
+
+func TestIDsRelationshipsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		rels string
+		want error
+	}{
+		{"wrong to-one type", `{"author": {"data": {"type": "people", "id": "9"}}}`, nil},
+		{"wrong to-many type", `{"tags": {"data": [{"type": "labels", "id": "3"}]}}`, nil},
+		{"bad integer", `{"tags": {"data": [{"type": "tags", "id": "x"}]}}`, ErrBadJSONAPIID},
+		{"bad text id", `{"objects": {"data": [{"type": "documents", "id": "zz"}]}}`, ErrBadJSONAPIID},
+	}
+	for _, tt := range tests {
+		doc := `{"data": {"type": "posts", "id": "1", "relationships": ` + tt.rels + `}}`
+		err := UnmarshalPayload(strings.NewReader(doc), new(idsPost))
+		if err == nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	doc := `{"data": {"type": "posts", "id": "1", "relationships": {"tags": {"data": [
+		{"type": "tags", "id": "1"}, {"type": "tags", "id": "2"}]}}}}`
+	if err := UnmarshalPayload(strings.NewReader(doc), new(idsPost), MaxRelationshipFanout(1)); !errors.Is(err, ErrRelationshipFanout) {
+		t.Errorf("fanout error %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrNotIncluded is returned by Included.Decode when the document does not
+// include the resource asked for.
+var ErrNotIncluded = errors.New("jsonapi: resource not included")
+
+// Included indexes the included resources of a compound document by type
+// and id, for callers that resolve linkages themselves because the target
+// struct cannot express them, e.g. polymorphic relationships or resource
+// types unknown at compile time:
+//
+//	var inc jsonapi.Included
+//	err := jsonapi.UnmarshalPayload(r, post, jsonapi.CollectIncluded(&inc))
+//	...
+//	if n := inc.Get("videos", id); n != nil {
+//		video := new(Video)
+//		err = inc.Decode("videos", id, video)
+//	}
+//
+// The zero Included is empty.
+type Included struct {
+	nodes []*Node
+	index *map[string]*Node
+	o     *unmarshalOptions
+
+	// exact indexes the nodes as decoded, with json.Number values, for
+	// Decode and As; see decodeNodes.
+	exact *map[string]*Node
+}
+
+// NewIncluded indexes nodes, e.g. those returned by StreamDecoder.Included.
+// The Decode and As methods of the result unmarshal with opts.
+func NewIncluded(nodes []*Node, opts ...UnmarshalOption) *Included {
+	return newIncluded(nodes, newUnmarshalOptions(opts))
+}
+
+func newIncluded(nodes []*Node, o *unmarshalOptions) *Included {
+	return &Included{nodes: nodes, index: includedMap(nodes), o: o}
+}
+
+// CollectIncluded makes UnmarshalPayload and UnmarshalManyPayload store
+// the index of the included resources of the document in dst.
+func CollectIncluded(dst *Included) UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.included = dst
+	}
+}
+
+// collectIncluded implements CollectIncluded.
+func (o *unmarshalOptions) collectIncluded(nodes []*Node) {
+	if o.included != nil {
+		*o.included = *newIncluded(o.floatNodes(nodes), o)
+		o.included.exact = includedMap(nodes)
+	}
+}
+
+// Len returns the number of included resources.
+func (i *Included) Len() int {
+	return len(i.nodes)
+}
+
+// Nodes returns the included resources in document order.
+func (i *Included) Nodes() []*Node {
+	return i.nodes
+}
+
+// Get returns the included resource of type typ with the given id, or nil
+// if the document does not include it.
+func (i *Included) Get(typ, id string) *Node {
+	if i.index == nil {
+		return nil
+	}
+	return (*i.index)[fmt.Sprintf("%s,%s", typ, id)]
+}
+
+// Decode stores the included resource of type typ with the given id in
+// model, a pointer to a tagged struct, resolving its own relationships
+// against the other included resources. It returns ErrNotIncluded if the
+// document does not include the resource.
+func (i *Included) Decode(typ, id string, model interface{}) error {
+	n := i.Get(typ, id)
+	if n == nil {
+		return ErrNotIncluded
+	}
+	return unmarshalNode(i.decoded(n), reflect.ValueOf(model), i.decodeIndex(), i.options(), 0)
+}
+
+// As stores every included resource of the type of the models of target,
+// a pointer to a slice of pointers to tagged structs, in a new model
+// appended to the slice, e.g. all comments with a *[]*Comment.
+func (i *Included) As(target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice ||
+		v.Elem().Type().Elem().Kind() != reflect.Ptr ||
+		v.Elem().Type().Elem().Elem().Kind() != reflect.Struct {
+		return ErrExpectedSlice
+	}
+	slice := v.Elem()
+	modelType := slice.Type().Elem()
+
+	typ := modelTypeName(modelType, &i.options().tagOptions)
+	for _, n := range i.nodes {
+		if n.Type != typ {
+			continue
+		}
+		model := reflect.New(modelType.Elem())
+		if err := unmarshalNode(i.decoded(n), model, i.decodeIndex(), i.options(), 0); err != nil {
+			return err
+		}
+		slice = reflect.Append(slice, model)
+	}
+	v.Elem().Set(slice)
+	return nil
+}
+
+// decodeIndex returns the index Decode and As resolve linkage against.
+func (i *Included) decodeIndex() *map[string]*Node {
+	if i.exact != nil {
+		return i.exact
+	}
+	return i.index
+}
+
+// decoded returns the node n was copied from by floatNodes, if any.
+func (i *Included) decoded(n *Node) *Node {
+	if i.exact != nil {
+		if exact := (*i.exact)[fmt.Sprintf("%s,%s", n.Type, n.ID)]; exact != nil {
+			return exact
+		}
+	}
+	return n
+}
+
+func (i *Included) options() *unmarshalOptions {
+	if i.o == nil {
+		return newUnmarshalOptions(nil)
+	}
+	return i.o
+}
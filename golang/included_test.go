@@ -0,0 +1,125 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type includedVideo struct {
+	ID       string     `jsonapi:"primary,videos"`
+	Length   int64      `jsonapi:"attr,length"`
+	Uploader *reqAuthor `jsonapi:"relation,uploader"`
+}
+
+// includedDoc links a post to a video through a relationship its struct
+// cannot express.
+const includedDoc = `{
+	"data": {"type": "articles", "id": "1", "relationships": {
+		"author": {"data": {"type": "people", "id": "9"}},
+		"attachment": {"data": {"type": "videos", "id": "v1"}}}},
+	"included": [
+		{"type": "people", "id": "9", "attributes": {"name": "Ann"}},
+		{"type": "videos", "id": "v1", "attributes": {"length": 9007199254740993},
+			"relationships": {"uploader": {"data": {"type": "people", "id": "9"}}}},
+		{"type": "videos", "id": "v2", "attributes": {"length": 2}}
+	]
+}`
+
+func TestCollectIncluded(t *testing.T) {
+	var inc Included
+	if err := UnmarshalPayload(strings.NewReader(includedDoc), new(reqArticle), CollectIncluded(&inc)); err != nil {
+		t.Fatal(err)
+	}
+	if inc.Len() != 3 || inc.Nodes()[1].ID != "v1" {
+		t.Fatalf("included %v", inc.Nodes())
+	}
+	if n := inc.Get("people", "9"); n == nil || n.Attributes["name"] != "Ann" {
+		t.Errorf("Get(people, 9) = %+v", n)
+	}
+	if n := inc.Get("videos", "9"); n != nil {
+		t.Errorf("Get(videos, 9) = %+v, want nil", n)
+	}
+	// Nodes carry float64 numbers, as without UseNumber.
+	if _, ok := inc.Get("videos", "v1").Attributes["length"].(float64); !ok {
+		t.Errorf("length %T", inc.Get("videos", "v1").Attributes["length"])
+	}
+
+	video := new(includedVideo)
+	if err := inc.Decode("videos", "v1", video); err != nil {
+		t.Fatal(err)
+	}
+	if video.Length != 9007199254740993 {
+		t.Errorf("length %d, want every digit kept", video.Length)
+	}
+	if video.Uploader == nil || video.Uploader.Name != "Ann" {
+		t.Errorf("uploader %+v, want the included person", video.Uploader)
+	}
+	if err := inc.Decode("videos", "v3", new(includedVideo)); err != ErrNotIncluded {
+		t.Errorf("error %v, want ErrNotIncluded", err)
+	}
+
+	var videos []*includedVideo
+	if err := inc.As(&videos); err != nil {
+		t.Fatal(err)
+	}
+	if len(videos) != 2 || videos[0].ID != "v1" || videos[1].Length != 2 {
+		t.Errorf("videos %+v", videos)
+	}
+	for _, target := range []interface{}{videos, &[]includedVideo{}, new(includedVideo), &[]*int{}} {
+		if err := inc.As(target); err != ErrExpectedSlice {
+			t.Errorf("As(%T) = %v, want ErrExpectedSlice", target, err)
+		}
+	}
+}
+
+func TestCollectIncludedMany(t *testing.T) {
+	doc := `{"data": [{"type": "articles", "id": "1"}], "included": [{"type": "people", "id": "9"}]}`
+	var inc Included
+	if _, err := UnmarshalManyPayload(strings.NewReader(doc), reflect.TypeOf(new(reqArticle)), CollectIncluded(&inc)); err != nil {
+		t.Fatal(err)
+	}
+	if inc.Get("people", "9") == nil {
+		t.Errorf("included %v", inc.Nodes())
+	}
+}
+
+func TestZeroIncluded(t *testing.T) {
+	var inc Included
+	if inc.Len() != 0 || inc.Get("people", "9") != nil {
+		t.Errorf("zero Included %+v", inc)
+	}
+	if err := inc.Decode("people", "9", new(reqAuthor)); err != ErrNotIncluded {
+		t.Errorf("error %v, want ErrNotIncluded", err)
+	}
+	var people []*reqAuthor
+	if err := inc.As(&people); err != nil || len(people) != 0 {
+		t.Errorf("As = %v, %v", people, err)
+	}
+}
+
+func TestNewIncluded(t *testing.T) {
+	doc := `{"data": [], "included": [{"type": "videos", "id": "v1", "attributes": {"length": 7}}]}`
+	dec := NewStreamDecoder(strings.NewReader(doc), UseNumber())
+	if _, err := dec.NextResource(); err != io.EOF {
+		t.Fatalf("error %v, want io.EOF", err)
+	}
+	nodes, err := dec.Included()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inc := NewIncluded(nodes, UseNumber())
+	if inc.Get("videos", "v1").Attributes["length"] != json.Number("7") {
+		t.Errorf("length %v", inc.Get("videos", "v1").Attributes["length"])
+	}
+	video := new(includedVideo)
+	if err := inc.Decode("videos", "v1", video); err != nil {
+		t.Fatal(err)
+	}
+	if video.Length != 7 {
+		t.Errorf("video %+v", video)
+	}
+}
@@ -0,0 +1,65 @@
+package jsonapi
+
+// InternStrings makes unmarshaling share a single copy of every distinct
+// string in the document: resource types, ids, member names and string
+// values, including those nested in map and slice attributes. Documents
+// with thousands of resources repeat the same few types, names and enum
+// values, so the decoded models then hold far less string memory. Strings
+// are shared within one call only.
+func InternStrings() UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.interner = interner{}
+	}
+}
+
+// interner maps each string seen to its first copy.
+type interner map[string]string
+
+func (in interner) string(s string) string {
+	if c, ok := in[s]; ok {
+		return c
+	}
+	in[s] = s
+	return s
+}
+
+// nodes interns the strings of nodes in place.
+func (in interner) nodes(nodes ...*Node) {
+	for _, n := range nodes {
+		if n == nil {
+			continue
+		}
+		n.Type = in.string(n.Type)
+		n.ID = in.string(n.ID)
+		n.Attributes = in.object(n.Attributes)
+
+		for name, rel := range n.Relationships {
+			delete(n.Relationships, name)
+			n.Relationships[in.string(name)] = in.value(rel)
+		}
+	}
+}
+
+// object interns the keys and values of m. Keys are replaced by deleting
+// and re-adding them, which is safe while ranging over m.
+func (in interner) object(m map[string]interface{}) map[string]interface{} {
+	for k, v := range m {
+		delete(m, k)
+		m[in.string(k)] = in.value(v)
+	}
+	return m
+}
+
+func (in interner) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return in.string(v)
+	case map[string]interface{}:
+		return in.object(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = in.value(v[i])
+		}
+	}
+	return v
+}
@@ -0,0 +1,55 @@
+package jsonapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+type internPerson struct {
+	ID     string                 `jsonapi:"primary,people"`
+	Status string                 `jsonapi:"attr,status"`
+	Extra  map[string]interface{} `jsonapi:"attr,extra"`
+}
+
+// sameString reports whether a and b share their bytes.
+func sameString(a, b string) bool {
+	return (*reflect.StringHeader)(unsafe.Pointer(&a)).Data == (*reflect.StringHeader)(unsafe.Pointer(&b)).Data
+}
+
+const internDoc = `{"data": [
+	{"type": "people", "id": "1", "attributes": {"status": "active", "extra": {"tags": ["x"]}}},
+	{"type": "people", "id": "2", "attributes": {"status": "active", "extra": {"tags": ["x"]}}}
+]}`
+
+func TestInternStrings(t *testing.T) {
+	models, err := UnmarshalManyPayload(strings.NewReader(internDoc), reflect.TypeOf(new(internPerson)), InternStrings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := models[0].(*internPerson), models[1].(*internPerson)
+	if a.Status != "active" || !sameString(a.Status, b.Status) {
+		t.Errorf("statuses %q and %q not shared", a.Status, b.Status)
+	}
+	tagA := a.Extra["tags"].([]interface{})[0].(string)
+	tagB := b.Extra["tags"].([]interface{})[0].(string)
+	if tagA != "x" || !sameString(tagA, tagB) {
+		t.Errorf("nested values %q and %q not shared", tagA, tagB)
+	}
+}
+
+func TestInternerNodes(t *testing.T) {
+	in := interner{}
+	n1 := &Node{Type: "people", ID: "1", Attributes: map[string]interface{}{"name": "Ann"},
+		Relationships: map[string]interface{}{"friend": map[string]interface{}{"data": nil}}}
+	n2 := &Node{Type: string([]byte("people")), ID: "2", Attributes: map[string]interface{}{"name": string([]byte("Ann"))}}
+	in.nodes(n1, nil, n2)
+
+	if !sameString(n1.Type, n2.Type) || !sameString(n1.Attributes["name"].(string), n2.Attributes["name"].(string)) {
+		t.Error("strings not shared between nodes")
+	}
+	if _, ok := n1.Relationships["friend"]; !ok || len(n1.Relationships) != 1 {
+		t.Errorf("relationships %v", n1.Relationships)
+	}
+}
@@ -0,0 +1,125 @@
+// Package benchmodels holds the models measured by the benchmarks of the
+// jsonapi package and by cmd/jsonapi-bench, so that both measure the same
+// documents.
+package benchmodels
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Author struct {
+	ID    string `jsonapi:"primary,authors"`
+	Name  string `jsonapi:"attr,name"`
+	Email string `jsonapi:"attr,email"`
+}
+
+type Post struct {
+	ID        int64     `jsonapi:"primary,posts"`
+	Title     string    `jsonapi:"attr,title"`
+	Body      string    `jsonapi:"attr,body"`
+	Views     int       `jsonapi:"attr,views"`
+	Rating    float64   `jsonapi:"attr,rating"`
+	Tags      []string  `jsonapi:"attr,tags"`
+	CreatedAt time.Time `jsonapi:"attr,created-at,iso8601"`
+	Author    *Author   `jsonapi:"relation,author"`
+}
+
+// NewPost returns the i-th post; posts share 50 authors.
+func NewPost(i int) *Post {
+	return &Post{
+		ID:        int64(i + 1),
+		Title:     "Title " + strconv.Itoa(i),
+		Body:      strings.Repeat("lorem ipsum ", 20),
+		Views:     i * 7,
+		Rating:    4.5,
+		Tags:      []string{"go", "json", "api"},
+		CreatedAt: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Author:    &Author{ID: strconv.Itoa(i % 50), Name: "Author", Email: "author@example.com"},
+	}
+}
+
+// NewPosts returns the first n posts.
+func NewPosts(n int) []*Post {
+	posts := make([]*Post, n)
+	for i := range posts {
+		posts[i] = NewPost(i)
+	}
+	return posts
+}
+
+type Link struct {
+	ID       string  `jsonapi:"primary,links"`
+	Name     string  `jsonapi:"attr,name"`
+	Next     *Link   `jsonapi:"relation,next"`
+	Siblings []*Link `jsonapi:"relation,siblings"`
+}
+
+// NewChain returns a chain of depth links, each with two leaf siblings.
+func NewChain(depth int) *Link {
+	var next *Link
+	for i := depth; i > 0; i-- {
+		id := strconv.Itoa(i)
+		next = &Link{
+			ID:   id,
+			Name: "link " + id,
+			Next: next,
+			Siblings: []*Link{
+				{ID: id + "a", Name: "sibling"},
+				{ID: id + "b", Name: "sibling"},
+			},
+		}
+	}
+	return next
+}
+
+var (
+	wideOnce sync.Once
+	wideType reflect.Type
+)
+
+// WideType returns a model with 100 attributes of mixed types, built with
+// reflect.StructOf to keep the source short.
+func WideType() reflect.Type {
+	wideOnce.Do(func() {
+		fields := []reflect.StructField{{
+			Name: "ID",
+			Type: reflect.TypeOf(""),
+			Tag:  `jsonapi:"primary,wide"`,
+		}}
+		types := []reflect.Type{reflect.TypeOf(""), reflect.TypeOf(0), reflect.TypeOf(0.0), reflect.TypeOf(false)}
+		for i := 0; i < 100; i++ {
+			fields = append(fields, reflect.StructField{
+				Name: "F" + strconv.Itoa(i),
+				Type: types[i%len(types)],
+				Tag:  reflect.StructTag(fmt.Sprintf(`jsonapi:"attr,f%d"`, i)),
+			})
+		}
+		wideType = reflect.StructOf(fields)
+	})
+	return wideType
+}
+
+// NewWide returns a pointer to a WideType value with every field set.
+func NewWide() interface{} {
+	v := reflect.New(WideType())
+	v.Elem().Field(0).SetString("1")
+	for i := 1; i < v.Elem().NumField(); i++ {
+		f := v.Elem().Field(i)
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString("value " + strconv.Itoa(i))
+		case reflect.Int:
+			f.SetInt(int64(i))
+		case reflect.Float64:
+			f.SetFloat(float64(i) / 3)
+		case reflect.Bool:
+			f.SetBool(i%3 == 0)
+		}
+	}
+	return v.Interface()
+}
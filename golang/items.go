@@ -0,0 +1,52 @@
+package jsonapi
+
+// ItemDecorator returns links and meta for the resource at index i of a
+// collection being marshaled, e.g. a self link built from a route table,
+// without Linkable or Metable being implemented by every model. Either
+// result may be nil.
+type ItemDecorator func(i int, model interface{}) (*Links, *Meta)
+
+// WithItemDecorator makes Marshal call d for every resource of a collection
+// and add the links and meta it returns to the resource object, replacing
+// members of the same name given by Linkable and Metable. Indexes are those
+// of the models slice, also when a NodeVisitor skips some of them.
+func WithItemDecorator(d ItemDecorator) MarshalOption {
+	return func(o *marshalOptions) {
+		o.itemDecorator = d
+	}
+}
+
+func decorateItem(d ItemDecorator, i int, model interface{}, node *Node) error {
+	links, meta := d(i, model)
+
+	if links != nil {
+		if err := links.validate(); err != nil {
+			return err
+		}
+		merged := Links{}
+		if node.Links != nil {
+			for k, v := range *node.Links {
+				merged[k] = v
+			}
+		}
+		for k, v := range *links {
+			merged[k] = v
+		}
+		node.Links = &merged
+	}
+
+	if meta != nil {
+		merged := Meta{}
+		if node.Meta != nil {
+			for k, v := range *node.Meta {
+				merged[k] = v
+			}
+		}
+		for k, v := range *meta {
+			merged[k] = v
+		}
+		node.Meta = &merged
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package jsonapi
+
+import "testing"
+
+func TestWithItemDecorator(t *testing.T) {
+	var seen []int
+	decorate := func(i int, model interface{}) (*Links, *Meta) {
+		seen = append(seen, i)
+		p := model.(*linkPost)
+		if p.ID == "2" {
+			return nil, nil
+		}
+		return &Links{"related": "/feeds/" + p.ID, KeySelfLink: "/items/" + p.ID}, &Meta{"index": i}
+	}
+	skip := func(model interface{}, node *Node, depth int, path string) error {
+		if node.ID == "3" {
+			return ErrSkipNode
+		}
+		return nil
+	}
+
+	posts := []*linkPost{{ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}}
+	doc := marshalDoc(t, posts, WithItemDecorator(decorate), WithNodeVisitor(skip))
+	data := doc["data"].([]interface{})
+	if len(data) != 3 {
+		t.Fatalf("data %v, want the skipped post left out", data)
+	}
+
+	first := data[0].(map[string]interface{})
+	links := first["links"].(map[string]interface{})
+	if links[KeySelfLink] != "/items/1" || links["related"] != "/feeds/1" {
+		t.Errorf("links %v, want the decorator's replacing the model's", links)
+	}
+	if meta := first["meta"].(map[string]interface{}); meta["index"] != float64(0) {
+		t.Errorf("meta %v", meta)
+	}
+
+	// The model's links stay when the decorator returns none.
+	second := data[1].(map[string]interface{})
+	if links := second["links"].(map[string]interface{}); links[KeySelfLink] != "https://api.example.com/posts/2" {
+		t.Errorf("links %v", links)
+	}
+	if _, ok := second["meta"]; ok {
+		t.Errorf("meta %v, want none", second["meta"])
+	}
+
+	last := data[2].(map[string]interface{})
+	if meta := last["meta"].(map[string]interface{}); meta["index"] != float64(3) {
+		t.Errorf("meta %v, want the index in the models slice", meta)
+	}
+	if len(seen) != 3 {
+		t.Errorf("decorated %v", seen)
+	}
+}
+
+func TestWithItemDecoratorInvalidLinks(t *testing.T) {
+	bad := func(int, interface{}) (*Links, *Meta) {
+		return &Links{KeySelfLink: 5}, nil
+	}
+	if _, err := Marshal([]*linkPost{{ID: "1"}}, WithItemDecorator(bad)); err == nil {
+		t.Error("expected an error for invalid links")
+	}
+}
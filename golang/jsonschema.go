@@ -0,0 +1,44 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// JSONSchemaDraft is the $schema URI of the schemas produced by
+// ExportJSONSchema.
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// ExportJSONSchema returns a JSON Schema (draft 2020-12) describing the
+// resource object marshaled for model, a pointer to a tagged struct.
+// Attribute types follow the Go field types; attributes that are neither
+// pointers nor tagged omitempty are listed as required. opts, e.g.
+// WithTagKey, select the members as for Marshal.
+func ExportJSONSchema(model interface{}, opts ...MarshalOption) ([]byte, error) {
+	t := reflect.TypeOf(model)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil, ErrUnexpectedType
+	}
+	b := newSchemaBuilder("#/$defs/", opts)
+	name, err := b.addModel(t)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := map[string]interface{}{}
+	for k, v := range b.schemas[name].(map[string]interface{}) {
+		schema[k] = v
+	}
+	schema["$schema"] = JSONSchemaDraft
+	schema["title"] = t.Elem().Name()
+
+	// Relationships only reference resource identifiers, so the related
+	// resource schemas are not needed.
+	schema["$defs"] = map[string]interface{}{
+		"ResourceIdentifier": b.schemas["ResourceIdentifier"],
+		"Links":              b.schemas["Links"],
+		"Meta":               b.schemas["Meta"],
+	}
+
+	return json.Marshal(schema)
+}
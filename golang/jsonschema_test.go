@@ -0,0 +1,45 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExportJSONSchema(t *testing.T) {
+	b, err := ExportJSONSchema(new(schemaPost))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema["$schema"] != JSONSchemaDraft || schema["title"] != "schemaPost" {
+		t.Errorf("$schema %v, title %v", schema["$schema"], schema["title"])
+	}
+	defs, _ := schema["$defs"].(map[string]interface{})
+	for _, name := range []string{"ResourceIdentifier", "Links", "Meta"} {
+		if defs[name] == nil {
+			t.Errorf("missing definition %s", name)
+		}
+	}
+	if len(defs) != 3 {
+		t.Errorf("definitions %v, want only the shared ones", defs)
+	}
+}
+
+func TestExportJSONSchemaErrors(t *testing.T) {
+	type clash struct {
+		ID     string        `jsonapi:"primary,clash"`
+		Author *schemaAuthor `jsonapi:"relation,author"`
+		Other  *struct {
+			ID string `jsonapi:"primary,schema-people"`
+		} `jsonapi:"relation,other"`
+	}
+	for _, v := range []interface{}{nil, schemaPost{}, new(struct{}), new(clash)} {
+		if _, err := ExportJSONSchema(v); err == nil {
+			t.Errorf("ExportJSONSchema(%T) succeeded", v)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package jsonapi
+
+import (
+	"reflect"
+	"strings"
+)
+
+// WithJSONTagFallback makes Marshal treat exported fields that have no
+// jsonapi tag but do have a json tag as attributes named after the json
+// tag, honouring its omitempty option. Fields tagged `json:"-"` or
+// `jsonapi:"-"` stay out.
+// It eases moving existing REST models to JSON:API one field at a time.
+func WithJSONTagFallback() MarshalOption {
+	return func(o *marshalOptions) {
+		o.jsonFallback = true
+	}
+}
+
+// JSONTagFallback is the unmarshal counterpart of WithJSONTagFallback.
+func JSONTagFallback() UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.jsonFallback = true
+	}
+}
+
+// tagOptions are the settings, shared by marshalOptions and
+// unmarshalOptions, that decide how the tags of a struct are read. Every
+// reader of jsonapi tags goes through tag so that they all agree with
+// Marshal and UnmarshalPayload on the members of a model.
+type tagOptions struct {
+	// struct tag read before the jsonapi one; see WithTagKey
+	tagKey string
+	// json tags stand in for missing jsonapi ones; see WithJSONTagFallback
+	jsonFallback bool
+	// prefix of fields named after reserved members; see
+	// WithReservedFieldPrefix
+	reservedPrefix string
+	// derives the resource types left out of primary annotations; see
+	// WithTypeNaming
+	typeNaming TypeNamingStrategy
+}
+
+// tag returns the tag of field as read with o, "" if it has none or is
+// ignored. A nil o reads the jsonapi tag alone.
+func (o *tagOptions) tag(field reflect.StructField) string {
+	if o == nil {
+		return fieldTag(field, "", false)
+	}
+	return renameReserved(fieldTag(field, o.tagKey, o.jsonFallback), o.reservedPrefix)
+}
+
+// ignored reports whether field is tagged "-" under the tag key of o, where
+// it has that tag, or else under jsonapi.
+func (o *tagOptions) ignored(field reflect.StructField) bool {
+	if o != nil && o.tagKey != "" {
+		if tag, ok := field.Tag.Lookup(o.tagKey); ok {
+			return tag == annotationIgnore
+		}
+	}
+	return field.Tag.Get(annotationJSONAPI) == annotationIgnore
+}
+
+// FieldTag returns the jsonapi tag of field as Marshal reads it with opts:
+// from the WithTagKey tag where present, from the json tag with
+// WithJSONTagFallback, and with reserved member names prefixed as set by
+// WithReservedFieldPrefix. It returns "" for fields that are not members,
+// including those tagged "-". Packages mapping models to other layers,
+// such as gormadapter, use it to see the members Marshal sees.
+func FieldTag(field reflect.StructField, opts ...MarshalOption) string {
+	return newMarshalOptions(opts).tag(field)
+}
+
+// fieldTag returns the tag of field under tagKey, see WithTagKey, else its
+// jsonapi tag or, when jsonFallback is set and it has none, the attribute
+// tag equivalent to its json tag. It returns "" for fields tagged "-".
+func fieldTag(field reflect.StructField, tagKey string, jsonFallback bool) string {
+	if tagKey != "" {
+		if tag, ok := field.Tag.Lookup(tagKey); ok {
+			if tag == annotationIgnore {
+				return ""
+			}
+			return tag
+		}
+	}
+	tag := field.Tag.Get(annotationJSONAPI)
+	if tag == annotationIgnore {
+		return ""
+	}
+	if tag != "" || !jsonFallback {
+		return tag
+	}
+
+	jsonTag, ok := field.Tag.Lookup("json")
+	if !ok || jsonTag == "-" || field.PkgPath != "" {
+		return ""
+	}
+
+	opts := strings.Split(jsonTag, ",")
+	name := opts[0]
+	if name == "" {
+		name = field.Name
+	}
+
+	tag = annotationAttribute + annotationSeperator + name
+	for _, opt := range opts[1:] {
+		if opt == annotationOmitEmpty {
+			tag += annotationSeperator + annotationOmitEmpty
+		}
+	}
+	return tag
+}
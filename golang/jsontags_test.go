@@ -0,0 +1,103 @@
+package jsonapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type legacyUser struct {
+	ID       string `jsonapi:"primary,users"`
+	Name     string `json:"name"`
+	Nick     string `json:"nick,omitempty"`
+	Password string `json:"-"`
+	Hidden   string `json:"hidden" jsonapi:"-"`
+	Plain    string
+	Email    string `json:"mail" jsonapi:"attr,email"`
+}
+
+func TestFieldTag(t *testing.T) {
+	typ := reflect.TypeOf(legacyUser{})
+	tests := []struct {
+		field        string
+		jsonFallback bool
+		want         string
+	}{
+		{"Name", false, ""},
+		{"Name", true, "attr,name"},
+		{"Nick", true, "attr,nick,omitempty"},
+		{"Password", true, ""},
+		{"Hidden", true, ""},
+		{"Plain", true, ""},
+		{"Email", true, "attr,email"},
+	}
+	for _, tt := range tests {
+		field, _ := typ.FieldByName(tt.field)
+		if got := fieldTag(field, "", tt.jsonFallback); got != tt.want {
+			t.Errorf("%s, fallback %v: tag %q, want %q", tt.field, tt.jsonFallback, got, tt.want)
+		}
+	}
+}
+
+func TestJSONTagFallback(t *testing.T) {
+	in := &legacyUser{ID: "1", Name: "Ann", Password: "p", Hidden: "h", Email: "a@example.com"}
+	attrs := marshalDoc(t, in, WithJSONTagFallback())["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+	want := map[string]interface{}{"name": "Ann", "email": "a@example.com"}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("attributes %v, want %v", attrs, want)
+	}
+
+	doc := `{"data": {"type": "users", "id": "1", "attributes": {"name": "Bob", "nick": "b"}}}`
+	out := new(legacyUser)
+	if err := UnmarshalPayload(strings.NewReader(doc), out, JSONTagFallback()); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "Bob" || out.Nick != "b" {
+		t.Errorf("unmarshaled %+v", out)
+	}
+
+	// Without the option the json tags are ignored.
+	out = new(legacyUser)
+	if err := UnmarshalPayload(strings.NewReader(doc), out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "" {
+		t.Errorf("unmarshaled %+v without the fallback", out)
+	}
+}
+
+type ignoredUser struct {
+	ID       string `jsonapi:"primary,users"`
+	Name     string `jsonapi:"attr,name"`
+	Password string `json:"password" jsonapi:"-"`
+	Token    string `jsonapi:"-" jsonapi_admin:"attr,token"`
+}
+
+func TestIgnoredField(t *testing.T) {
+	in := &ignoredUser{ID: "1", Name: "Ann", Password: "p", Token: "t"}
+	tests := []struct {
+		name string
+		opts []MarshalOption
+		want map[string]interface{}
+	}{
+		{"default", nil, map[string]interface{}{"name": "Ann"}},
+		{"json fallback", []MarshalOption{WithJSONTagFallback()}, map[string]interface{}{"name": "Ann"}},
+		// A tag under the tag key takes precedence.
+		{"tag key", []MarshalOption{WithTagKey("jsonapi_admin")}, map[string]interface{}{"name": "Ann", "token": "t"}},
+	}
+	for _, tt := range tests {
+		attrs := marshalDoc(t, in, tt.opts...)["data"].(map[string]interface{})["attributes"]
+		if !reflect.DeepEqual(attrs, tt.want) {
+			t.Errorf("%s: attributes %v, want %v", tt.name, attrs, tt.want)
+		}
+	}
+
+	doc := `{"data": {"type": "users", "id": "1", "attributes": {"name": "Bob", "password": "p", "token": "t"}}}`
+	out := new(ignoredUser)
+	if err := UnmarshalPayload(strings.NewReader(doc), out, JSONTagFallback()); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "Bob" || out.Password != "" || out.Token != "" {
+		t.Errorf("unmarshaled %+v", out)
+	}
+}
@@ -0,0 +1,117 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ItemError reports a resource of a collection document that could not be
+// unmarshaled. Pointer is a JSON pointer into the document, e.g. /data/3 or
+// /data/3/attributes when the problem could be narrowed down.
+type ItemError struct {
+	Index   int
+	Pointer string
+	Err     error
+}
+
+func (e *ItemError) Error() string {
+	return fmt.Sprintf("jsonapi: %s: %v", e.Pointer, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorObject converts e into a 422 error object pointing at the item.
+func (e *ItemError) ErrorObject() *ErrorObject {
+	return &ErrorObject{
+		Title:  "Invalid resource",
+		Detail: e.Err.Error(),
+		Status: strconv.Itoa(http.StatusUnprocessableEntity),
+		Source: &ErrorSource{Pointer: e.Pointer},
+	}
+}
+
+// UnmarshalManyLenient is like UnmarshalManyPayload but does not give up
+// on the whole document when some resources are malformed. models has one
+// entry per resource in data, nil for those that failed; errs describes
+// each failure, in document order. Malformed included resources are
+// reported with a /included pointer and ignored. err is only set when the
+// document as a whole cannot be read.
+func UnmarshalManyLenient(in io.Reader, t reflect.Type, opts ...UnmarshalOption) (
+	models []interface{}, errs []*ItemError, err error) {
+	o := newUnmarshalOptions(opts)
+
+	var doc struct {
+		Data     []json.RawMessage `json:"data"`
+		Included []json.RawMessage `json:"included"`
+	}
+	if err := o.decode(in, &doc); err != nil {
+		return nil, nil, err
+	}
+	if err := o.checkIncluded(len(doc.Included)); err != nil {
+		return nil, nil, err
+	}
+
+	var includedNodes []*Node
+	for i, raw := range doc.Included {
+		n := new(Node)
+		if err := o.decodeNodes(bytes.NewReader(raw), n); err != nil {
+			errs = append(errs, itemError(i, "/included/"+strconv.Itoa(i), err))
+			continue
+		}
+		includedNodes = append(includedNodes, n)
+	}
+	included := includedMap(includedNodes)
+
+	models = make([]interface{}, len(doc.Data))
+	for i, raw := range doc.Data {
+		pointer := "/data/" + strconv.Itoa(i)
+
+		n := new(Node)
+		if err := o.decodeNodes(bytes.NewReader(raw), n); err != nil {
+			errs = append(errs, itemError(i, pointer, err))
+			continue
+		}
+
+		model := reflect.New(t.Elem())
+		if err := unmarshalNode(n, model, included, o, 0); err != nil {
+			errs = append(errs, itemError(i, pointer, err))
+			continue
+		}
+		models[i] = model.Interface()
+	}
+
+	return models, errs, nil
+}
+
+// itemError narrows pointer down using the details of err where possible.
+func itemError(index int, pointer string, err error) *ItemError {
+	switch e := err.(type) {
+	case *json.UnmarshalTypeError:
+		if e.Field != "" {
+			pointer += jsonPointer(strings.Split(e.Field, ".")...)
+		}
+	case *RelationshipError:
+		pointer += e.Pointer()
+	case *AttributeError:
+		pointer += e.Pointer()
+	case *IDError:
+		pointer += e.Pointer()
+	case *UnknownFieldsError:
+		switch {
+		case len(e.Attributes) > 0:
+			pointer += jsonPointer("attributes", e.Attributes[0])
+		case len(e.Relationships) > 0:
+			pointer += jsonPointer("relationships", e.Relationships[0])
+		}
+	}
+	return &ItemError{Index: index, Pointer: pointer, Err: err}
+}
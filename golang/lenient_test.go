@@ -0,0 +1,73 @@
+package jsonapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalManyLenient(t *testing.T) {
+	doc := `{
+		"data": [
+			{"type": "articles", "id": "1", "attributes": {"title": "ok"}},
+			{"type": "articles", "id": "2", "attributes": {"views": "many"}},
+			"not a resource",
+			{"type": "articles", "id": "4", "relationships": {"author": {"data": {"type": "people", "id": "9"}}}}
+		],
+		"included": [
+			{"type": "people", "id": "9", "attributes": {"name": "Ann"}},
+			[]
+		]
+	}`
+	models, errs, err := UnmarshalManyLenient(strings.NewReader(doc), reflect.TypeOf(new(reqArticle)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(models) != 4 || models[1] != nil || models[2] != nil {
+		t.Fatalf("models %v, want the failed ones nil", models)
+	}
+	if a := models[0].(*reqArticle); a.Title != "ok" {
+		t.Errorf("models[0] = %+v", a)
+	}
+	if a := models[3].(*reqArticle); a.Author == nil || a.Author.Name != "Ann" {
+		t.Errorf("models[3] author = %+v, want the included person", a.Author)
+	}
+
+	var pointers []string
+	for _, e := range errs {
+		pointers = append(pointers, e.Pointer)
+	}
+	if want := []string{"/included/1", "/data/1/attributes/views", "/data/2"}; !equalStrings(pointers, want) {
+		t.Errorf("error pointers %v, want %v", pointers, want)
+	}
+
+	obj := errs[1].ErrorObject()
+	if obj.Status != "422" || obj.Source.Pointer != "/data/1/attributes/views" {
+		t.Errorf("error object %+v", obj)
+	}
+	if errs[2].Index != 2 || errs[2].Unwrap() == nil {
+		t.Errorf("item error %+v", errs[2])
+	}
+}
+
+func TestUnmarshalManyLenientInvalidDocument(t *testing.T) {
+	_, _, err := UnmarshalManyLenient(strings.NewReader(`{"data": `), reflect.TypeOf(new(reqArticle)))
+	if err == nil {
+		t.Error("expected an error for a truncated document")
+	}
+}
+
+func TestUnmarshalManyLenientRelationshipError(t *testing.T) {
+	doc := `{"data": [{"type": "articles", "id": "1", "relationships": {"comments": {"data": null}}}]}`
+	models, errs, err := UnmarshalManyLenient(strings.NewReader(doc), reflect.TypeOf(new(reqArticle)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 1 || models[0] != nil || len(errs) != 1 {
+		t.Fatalf("models %v, errors %v", models, errs)
+	}
+	if errs[0].Pointer != "/data/0/relationships/comments/data" {
+		t.Errorf("pointer %q", errs[0].Pointer)
+	}
+}
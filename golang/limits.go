@@ -0,0 +1,89 @@
+package jsonapi
+
+import (
+	"errors"
+	"io"
+)
+
+// Errors returned when a request document exceeds a limit set with the
+// Max* unmarshal options.
+var (
+	ErrBodyTooLarge       = errors.New("jsonapi: request document exceeds the size limit")
+	ErrTooManyIncluded    = errors.New("jsonapi: too many included resources")
+	ErrRelationshipFanout = errors.New("jsonapi: relationship has too many members")
+	ErrMaxDepth           = errors.New("jsonapi: relationships nested too deeply")
+)
+
+// MaxBodyBytes makes unmarshaling fail with ErrBodyTooLarge once more than
+// n bytes have been read, without reading the rest of the input.
+func MaxBodyBytes(n int64) UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.maxBodyBytes = n
+	}
+}
+
+// MaxIncludedResources makes unmarshaling fail with ErrTooManyIncluded
+// when the included section has more than n resources.
+func MaxIncludedResources(n int) UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.maxIncluded = n
+	}
+}
+
+// MaxRelationshipFanout makes unmarshaling fail with ErrRelationshipFanout
+// when a to-many relationship has more than n members.
+func MaxRelationshipFanout(n int) UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.maxFanout = n
+	}
+}
+
+// MaxDepth makes unmarshaling fail with ErrMaxDepth when following
+// relationships into included resources goes more than n levels below the
+// primary data. It also bounds documents whose included resources refer to
+// each other in a cycle.
+func MaxDepth(n int) UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.maxDepth = n
+	}
+}
+
+// body wraps in according to MaxBodyBytes.
+func (o *unmarshalOptions) body(in io.Reader) io.Reader {
+	if o.maxBodyBytes <= 0 {
+		return in
+	}
+	return &limitedReader{r: in, n: o.maxBodyBytes}
+}
+
+// checkIncluded enforces MaxIncludedResources.
+func (o *unmarshalOptions) checkIncluded(n int) error {
+	if o.maxIncluded > 0 && n > o.maxIncluded {
+		return ErrTooManyIncluded
+	}
+	return nil
+}
+
+// limitedReader is io.LimitedReader returning ErrBodyTooLarge instead of
+// io.EOF once the limit is passed.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrBodyTooLarge
+	}
+	// Read one byte past the limit to tell a body of exactly n bytes from
+	// a longer one.
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, ErrBodyTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,62 @@
+package jsonapi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type limitPerson struct {
+	ID      string         `jsonapi:"primary,people"`
+	Name    string         `jsonapi:"attr,name"`
+	Friend  *limitPerson   `jsonapi:"relation,friend"`
+	Friends []*limitPerson `jsonapi:"relation,friends"`
+}
+
+func TestLimits(t *testing.T) {
+	// 1 refers to 2 and 2 back to 1: following friends never ends.
+	cycle := `{"data": {"type": "people", "id": "1", "relationships": {
+			"friend": {"data": {"type": "people", "id": "2"}}}},
+		"included": [
+			{"type": "people", "id": "2", "relationships": {"friend": {"data": {"type": "people", "id": "1"}}}},
+			{"type": "people", "id": "1", "relationships": {"friend": {"data": {"type": "people", "id": "2"}}}}
+		]}`
+	fanout := `{"data": {"type": "people", "id": "1", "relationships": {"friends": {"data": [
+		{"type": "people", "id": "2"}, {"type": "people", "id": "3"}, {"type": "people", "id": "4"}]}}}}`
+	included := `{"data": {"type": "people", "id": "1"}, "included": [
+		{"type": "people", "id": "2"}, {"type": "people", "id": "3"}]}`
+
+	tests := []struct {
+		name string
+		doc  string
+		opt  UnmarshalOption
+		want error
+	}{
+		{"body", `{"data": {"type": "people", "id": "1", "attributes": {"name": "` +
+			strings.Repeat("a", 100) + `"}}}`, MaxBodyBytes(64), ErrBodyTooLarge},
+		{"fanout", fanout, MaxRelationshipFanout(2), ErrRelationshipFanout},
+		{"included", included, MaxIncludedResources(1), ErrTooManyIncluded},
+		{"depth", cycle, MaxDepth(5), ErrMaxDepth},
+	}
+	for _, tt := range tests {
+		err := UnmarshalPayload(strings.NewReader(tt.doc), new(limitPerson), tt.opt)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// Documents within the limits are accepted.
+	within := []struct {
+		doc string
+		opt UnmarshalOption
+	}{
+		{`{"data": {"type": "people", "id": "1"}}`, MaxBodyBytes(39)},
+		{fanout, MaxRelationshipFanout(3)},
+		{included, MaxIncludedResources(2)},
+	}
+	for _, tt := range within {
+		if err := UnmarshalPayload(strings.NewReader(tt.doc), new(limitPerson), tt.opt); err != nil {
+			t.Errorf("%s: %v", tt.doc, err)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package jsonapi
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// LinkResolver builds the URLs that Marshal adds to a document when
+// WithLinkResolver or WithBaseURL is used. Returning an empty string from
+// any method leaves the corresponding link out.
+type LinkResolver interface {
+	// ResourceLink returns the self link of the resource typ/id.
+	ResourceLink(typ, id string) string
+	// RelationshipLinks returns the self and related links of relation on
+	// the resource typ/id.
+	RelationshipLinks(typ, id, relation string) (self, related string)
+	// CollectionLink returns the self link of a collection of typ.
+	CollectionLink(typ string) string
+}
+
+// Keys of the links generated by a LinkResolver.
+const (
+	KeySelfLink    = "self"
+	KeyRelatedLink = "related"
+)
+
+// Default URL templates used by LinkTemplates.
+const (
+	DefaultResourceTemplate            = "/{type}/{id}"
+	DefaultRelationshipSelfTemplate    = "/{type}/{id}/relationships/{relation}"
+	DefaultRelationshipRelatedTemplate = "/{type}/{id}/{relation}"
+	DefaultCollectionTemplate          = "/{type}"
+)
+
+// LinkTemplates is a LinkResolver driven by URL templates. The
+// placeholders {type}, {id} and {relation} are replaced with path-escaped
+// values and the result is appended to BaseURL. Empty templates fall back
+// to the Default*Template constants.
+type LinkTemplates struct {
+	BaseURL             string
+	Resource            string
+	RelationshipSelf    string
+	RelationshipRelated string
+	Collection          string
+}
+
+// ResourceLink implements LinkResolver.
+func (t *LinkTemplates) ResourceLink(typ, id string) string {
+	return t.expand(t.Resource, DefaultResourceTemplate, typ, id, "")
+}
+
+// RelationshipLinks implements LinkResolver.
+func (t *LinkTemplates) RelationshipLinks(typ, id, relation string) (string, string) {
+	return t.expand(t.RelationshipSelf, DefaultRelationshipSelfTemplate, typ, id, relation),
+		t.expand(t.RelationshipRelated, DefaultRelationshipRelatedTemplate, typ, id, relation)
+}
+
+// CollectionLink implements LinkResolver.
+func (t *LinkTemplates) CollectionLink(typ string) string {
+	return t.expand(t.Collection, DefaultCollectionTemplate, typ, "", "")
+}
+
+func (t *LinkTemplates) expand(tmpl, def, typ, id, relation string) string {
+	if tmpl == "" {
+		tmpl = def
+	}
+	r := strings.NewReplacer(
+		"{type}", url.PathEscape(typ),
+		"{id}", url.PathEscape(id),
+		"{relation}", url.PathEscape(relation),
+	)
+	return strings.TrimSuffix(t.BaseURL, "/") + r.Replace(tmpl)
+}
+
+// applyLinks fills in the links of every resource and relationship of
+// nodes, keeping any link that is already present.
+func applyLinks(r LinkResolver, nodes ...*Node) {
+	for _, n := range nodes {
+		if n == nil || n.ID == "" {
+			continue
+		}
+
+		if self := r.ResourceLink(n.Type, n.ID); self != "" {
+			n.Links = mergeLinks(n.Links, Links{KeySelfLink: self})
+		}
+
+		for name, rel := range n.Relationships {
+			self, related := r.RelationshipLinks(n.Type, n.ID, name)
+			generated := Links{}
+			if self != "" {
+				generated[KeySelfLink] = self
+			}
+			if related != "" {
+				generated[KeyRelatedLink] = related
+			}
+			if len(generated) == 0 {
+				continue
+			}
+
+			switch rel := rel.(type) {
+			case *RelationshipOneNode:
+				rel.Links = mergeLinks(rel.Links, generated)
+			case *RelationshipManyNode:
+				rel.Links = mergeLinks(rel.Links, generated)
+			case *RelationshipLinksNode:
+				rel.Links = mergeLinks(rel.Links, generated)
+			}
+		}
+	}
+}
+
+// deriveRelationshipLinks adds self and related links to the relationships
+// of nodes that have no links, based on the self link of the node.
+func deriveRelationshipLinks(nodes ...*Node) {
+	for _, n := range nodes {
+		if n == nil || n.Links == nil {
+			continue
+		}
+		self := strings.TrimSuffix(linkHref((*n.Links)[KeySelfLink]), "/")
+		if self == "" {
+			continue
+		}
+
+		for name, rel := range n.Relationships {
+			generated := Links{
+				KeySelfLink:    self + "/relationships/" + url.PathEscape(name),
+				KeyRelatedLink: self + "/" + url.PathEscape(name),
+			}
+
+			switch rel := rel.(type) {
+			case *RelationshipOneNode:
+				if rel.Links == nil {
+					rel.Links = &generated
+				}
+			case *RelationshipManyNode:
+				if rel.Links == nil {
+					rel.Links = &generated
+				}
+			case *RelationshipLinksNode:
+				if rel.Links == nil {
+					rel.Links = &generated
+				}
+			}
+		}
+	}
+}
+
+// linkHref returns the URL of a links member, which is either a string or
+// a Link object.
+func linkHref(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case Link:
+		return v.Href
+	case *Link:
+		if v != nil {
+			return v.Href
+		}
+	}
+	return ""
+}
+
+// mergeLinks returns existing with any keys of generated it lacks added.
+func mergeLinks(existing *Links, generated Links) *Links {
+	if existing == nil {
+		return &generated
+	}
+	for k, v := range generated {
+		if _, ok := (*existing)[k]; !ok {
+			(*existing)[k] = v
+		}
+	}
+	return existing
+}
+
+// modelTypeName returns the resource type declared by, or derived from,
+// the primary annotation of t, which may be a struct or a (slice of)
+// pointer to one, with tags read as set by tags. It is "" for a nil t and
+// for types without one.
+func modelTypeName(t reflect.Type, tags *tagOptions) string {
+	if t == nil {
+		return ""
+	}
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return ""
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		args := strings.Split(tags.tag(t.Field(i)), annotationSeperator)
+		if args[0] == annotationPrimary {
+			return tags.primaryType(t, args)
+		}
+	}
+	return ""
+}
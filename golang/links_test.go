@@ -0,0 +1,89 @@
+package jsonapi
+
+import "testing"
+
+type linkPost struct {
+	ID     string       `jsonapi:"primary,posts"`
+	Author *orderPerson `jsonapi:"relation,author"`
+}
+
+func (p *linkPost) JSONAPILinks() *Links {
+	return &Links{KeySelfLink: "https://api.example.com/posts/" + p.ID}
+}
+
+type linkComment struct {
+	ID     string       `jsonapi:"primary,comments"`
+	Author *orderPerson `jsonapi:"relation,author"`
+}
+
+// relationshipLinks returns the links of relationship name of the primary
+// data of doc.
+func relationshipLinks(t *testing.T, doc map[string]interface{}, name string) map[string]interface{} {
+	t.Helper()
+	data := doc["data"].(map[string]interface{})
+	rel := data["relationships"].(map[string]interface{})[name].(map[string]interface{})
+	links, _ := rel["links"].(map[string]interface{})
+	return links
+}
+
+func TestWithBaseURL(t *testing.T) {
+	doc := marshalDoc(t, &linkComment{ID: "a b", Author: &orderPerson{ID: "1"}},
+		WithBaseURL("https://api.example.com/"))
+
+	self := doc["data"].(map[string]interface{})["links"].(map[string]interface{})[KeySelfLink]
+	if self != "https://api.example.com/comments/a%20b" {
+		t.Errorf("self link %v", self)
+	}
+	links := relationshipLinks(t, doc, "author")
+	if links[KeySelfLink] != "https://api.example.com/comments/a%20b/relationships/author" ||
+		links[KeyRelatedLink] != "https://api.example.com/comments/a%20b/author" {
+		t.Errorf("relationship links %v", links)
+	}
+}
+
+func TestLinkTemplates(t *testing.T) {
+	r := &LinkTemplates{BaseURL: "/v1", Resource: "/r/{type}-{id}", Collection: "/c/{type}"}
+	if got := r.ResourceLink("posts", "1"); got != "/v1/r/posts-1" {
+		t.Errorf("ResourceLink = %q", got)
+	}
+	if got := r.CollectionLink("posts"); got != "/v1/c/posts" {
+		t.Errorf("CollectionLink = %q", got)
+	}
+	self, related := r.RelationshipLinks("posts", "1", "author")
+	if self != "/v1/posts/1/relationships/author" || related != "/v1/posts/1/author" {
+		t.Errorf("RelationshipLinks = %q, %q", self, related)
+	}
+}
+
+func TestWithDerivedRelationshipLinks(t *testing.T) {
+	doc := marshalDoc(t, &linkPost{ID: "1", Author: &orderPerson{ID: "9"}}, WithDerivedRelationshipLinks())
+	links := relationshipLinks(t, doc, "author")
+	if links[KeySelfLink] != "https://api.example.com/posts/1/relationships/author" ||
+		links[KeyRelatedLink] != "https://api.example.com/posts/1/author" {
+		t.Errorf("relationship links %v", links)
+	}
+
+	// Without a self link there is nothing to derive from.
+	doc = marshalDoc(t, &linkComment{ID: "1", Author: &orderPerson{ID: "9"}}, WithDerivedRelationshipLinks())
+	if links := relationshipLinks(t, doc, "author"); links != nil {
+		t.Errorf("relationship links %v, want none", links)
+	}
+}
+
+func TestLinkHref(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want string
+	}{
+		{"/a", "/a"},
+		{Link{Href: "/b"}, "/b"},
+		{&Link{Href: "/c"}, "/c"},
+		{(*Link)(nil), ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := linkHref(tt.in); got != tt.want {
+			t.Errorf("linkHref(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// LinkTemplate is a URI template as defined by RFC 6570, up to level 4,
+// e.g. "/appointments/{id}/slots{?date,duration}". Models return them
+// from TemplateLinkable and RelationshipTemplateLinkable to have their
+// links built at marshal time from the resource itself.
+type LinkTemplate string
+
+// TemplateLinkable is implemented by models whose resource links are built
+// from URI templates. The variables of the templates are type, id and the
+// attributes of the resource object by member name, with the values they
+// are marshaled to. Links returned by Linkable take precedence.
+type TemplateLinkable interface {
+	JSONAPILinkTemplates() map[string]LinkTemplate
+}
+
+// RelationshipTemplateLinkable is the TemplateLinkable counterpart of
+// RelationshipLinkable. The variables of the templates also include
+// relation, the name of the relationship.
+type RelationshipTemplateLinkable interface {
+	JSONAPIRelationshipLinkTemplates(relation string) map[string]LinkTemplate
+}
+
+// Expand expands t with vars. Values may be strings, numbers, booleans,
+// slices of them, which are lists, and maps with string keys, which are
+// associative arrays; variables that are missing, nil or empty lists are
+// undefined and left out, as the RFC requires.
+func (t LinkTemplate) Expand(vars map[string]interface{}) (string, error) {
+	var b strings.Builder
+	s := string(t)
+	for {
+		open := strings.IndexByte(s, '{')
+		if open < 0 {
+			if strings.IndexByte(s, '}') >= 0 {
+				return "", fmt.Errorf("jsonapi: malformed link template %q", t)
+			}
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		end := strings.IndexByte(s[open:], '}')
+		if end < 0 || strings.IndexByte(s[:open], '}') >= 0 {
+			return "", fmt.Errorf("jsonapi: malformed link template %q", t)
+		}
+		b.WriteString(s[:open])
+		if err := expandExpression(&b, s[open+1:open+end], vars); err != nil {
+			return "", fmt.Errorf("jsonapi: link template %q: %w", t, err)
+		}
+		s = s[open+end+1:]
+	}
+}
+
+// templateOperator describes the expansion of one RFC 6570 operator.
+type templateOperator struct {
+	first, sep string
+	named      bool
+	ifEmpty    string
+	reserved   bool // whether reserved characters pass unencoded
+}
+
+var templateOperators = map[byte]templateOperator{
+	'+': {first: "", sep: ",", reserved: true},
+	'#': {first: "#", sep: ",", reserved: true},
+	'.': {first: ".", sep: "."},
+	'/': {first: "/", sep: "/"},
+	';': {first: ";", sep: ";", named: true},
+	'?': {first: "?", sep: "&", named: true, ifEmpty: "="},
+	'&': {first: "&", sep: "&", named: true, ifEmpty: "="},
+}
+
+func expandExpression(b *strings.Builder, expr string, vars map[string]interface{}) error {
+	op := templateOperator{sep: ","}
+	if expr != "" {
+		if o, ok := templateOperators[expr[0]]; ok {
+			op = o
+			expr = expr[1:]
+		}
+	}
+	if expr == "" {
+		return fmt.Errorf("empty expression")
+	}
+
+	var parts []string
+	for _, spec := range strings.Split(expr, ",") {
+		name, explode, prefix, err := parseVarSpec(spec)
+		if err != nil {
+			return err
+		}
+		if part, ok := expandVar(op, name, vars[name], explode, prefix); ok {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) > 0 {
+		b.WriteString(op.first)
+		b.WriteString(strings.Join(parts, op.sep))
+	}
+	return nil
+}
+
+// parseVarSpec splits a varspec into its name and modifiers.
+func parseVarSpec(spec string) (name string, explode bool, prefix int, err error) {
+	name = spec
+	if strings.HasSuffix(name, "*") {
+		name, explode = name[:len(name)-1], true
+	} else if i := strings.IndexByte(name, ':'); i >= 0 {
+		prefix, err = strconv.Atoi(name[i+1:])
+		if err != nil || prefix <= 0 || prefix >= 10000 {
+			return "", false, 0, fmt.Errorf("invalid prefix in %q", spec)
+		}
+		name = name[:i]
+	}
+	if name == "" {
+		return "", false, 0, fmt.Errorf("invalid variable %q", spec)
+	}
+	return name, explode, prefix, nil
+}
+
+// expandVar expands one variable, reporting false when it is undefined.
+func expandVar(op templateOperator, name string, value interface{}, explode bool, prefix int) (string, bool) {
+	enc := func(s string) string { return templateEscape(s, op.reserved) }
+	named := func(s string) string {
+		if s == "" {
+			return name + op.ifEmpty
+		}
+		return name + "=" + s
+	}
+
+	list, assoc, scalar, ok := templateValue(value)
+	if !ok {
+		return "", false
+	}
+
+	switch {
+	case list == nil && assoc == nil:
+		if prefix > 0 && utf8.RuneCountInString(scalar) > prefix {
+			scalar = string([]rune(scalar)[:prefix])
+		}
+		if op.named {
+			return named(enc(scalar)), true
+		}
+		return enc(scalar), true
+
+	case list != nil:
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = enc(item)
+			if explode && op.named {
+				items[i] = named(items[i])
+			}
+		}
+		if explode {
+			return strings.Join(items, op.sep), true
+		}
+		if op.named {
+			return named(strings.Join(items, ",")), true
+		}
+		return strings.Join(items, ","), true
+
+	default:
+		keys := make([]string, 0, len(assoc))
+		for k := range assoc {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		items := make([]string, 0, 2*len(keys))
+		for _, k := range keys {
+			if explode {
+				v := enc(assoc[k])
+				if v == "" && op.named {
+					items = append(items, enc(k)+op.ifEmpty)
+				} else {
+					items = append(items, enc(k)+"="+v)
+				}
+			} else {
+				items = append(items, enc(k), enc(assoc[k]))
+			}
+		}
+		if explode {
+			return strings.Join(items, op.sep), true
+		}
+		if op.named {
+			return named(strings.Join(items, ",")), true
+		}
+		return strings.Join(items, ","), true
+	}
+}
+
+// templateValue classifies value as a list, an associative array or a
+// string. ok is false for undefined values and values of other kinds.
+func templateValue(value interface{}) (list []string, assoc map[string]string, scalar string, ok bool) {
+	if value == nil {
+		return nil, nil, "", false
+	}
+	if s, isScalar := templateScalar(value); isScalar {
+		return nil, nil, s, true
+	}
+
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil, "", false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if s, isScalar := templateScalar(v.Index(i).Interface()); isScalar {
+				list = append(list, s)
+			}
+		}
+		return list, nil, "", len(list) > 0
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		assoc = make(map[string]string, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			if s, isScalar := templateScalar(iter.Value().Interface()); isScalar {
+				assoc[iter.Key().String()] = s
+			}
+		}
+		return nil, assoc, "", len(assoc) > 0
+	}
+	return nil, nil, "", false
+}
+
+// templateScalar returns the string form of value if it is a string, a
+// number or a boolean.
+func templateScalar(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case fmt.Stringer:
+		return v.String(), true
+	}
+
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// templateEscape percent-encodes s, leaving unreserved characters, and
+// with reserved set also reserved characters and percent-encoded triplets,
+// as they are.
+func templateEscape(s string, reserved bool) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case reserved && strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0:
+			b.WriteByte(c)
+		case reserved && c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0xf])
+		}
+	}
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// applyLinkTemplates adds the links of the templates of model to node, and
+// to its relationships, keeping the links already there.
+func applyLinkTemplates(model interface{}, node *Node) error {
+	vars := map[string]interface{}{"type": node.Type, "id": node.ID}
+	for name, value := range node.Attributes {
+		vars[name] = value
+	}
+
+	if templated, ok := model.(TemplateLinkable); ok {
+		links, err := expandLinks(templated.JSONAPILinkTemplates(), vars)
+		if err != nil {
+			return err
+		}
+		if len(links) > 0 {
+			node.Links = mergeLinks(node.Links, links)
+		}
+	}
+
+	templated, ok := model.(RelationshipTemplateLinkable)
+	if !ok {
+		return nil
+	}
+	for name, rel := range node.Relationships {
+		vars["relation"] = name
+		links, err := expandLinks(templated.JSONAPIRelationshipLinkTemplates(name), vars)
+		if err != nil {
+			return err
+		}
+		if len(links) == 0 {
+			continue
+		}
+		switch rel := rel.(type) {
+		case *RelationshipOneNode:
+			rel.Links = mergeLinks(rel.Links, links)
+		case *RelationshipManyNode:
+			rel.Links = mergeLinks(rel.Links, links)
+		case *RelationshipLinksNode:
+			rel.Links = mergeLinks(rel.Links, links)
+		}
+	}
+	return nil
+}
+
+func expandLinks(templates map[string]LinkTemplate, vars map[string]interface{}) (Links, error) {
+	links := make(Links, len(templates))
+	for key, t := range templates {
+		href, err := t.Expand(vars)
+		if err != nil {
+			return nil, err
+		}
+		links[key] = href
+	}
+	return links, nil
+}
@@ -0,0 +1,172 @@
+package jsonapi
+
+import (
+	"testing"
+)
+
+// The examples of RFC 6570, section 3.2.
+var rfcVars = map[string]interface{}{
+	"count":      []string{"one", "two", "three"},
+	"dom":        []string{"example", "com"},
+	"dub":        "me/too",
+	"hello":      "Hello World!",
+	"half":       "50%",
+	"var":        "value",
+	"who":        "fred",
+	"base":       "http://example.com/home/",
+	"path":       "/foo/bar",
+	"list":       []string{"red", "green", "blue"},
+	"keys":       map[string]string{"semi": ";", "dot": ".", "comma": ","},
+	"v":          6,
+	"x":          1024,
+	"y":          768,
+	"empty":      "",
+	"empty_keys": map[string]string{},
+	"undef":      nil,
+}
+
+func TestLinkTemplateExpand(t *testing.T) {
+	tests := []struct {
+		template LinkTemplate
+		want     string
+	}{
+		{"{var}", "value"},
+		{"{hello}", "Hello%20World%21"},
+		{"{half}", "50%25"},
+		{"O{empty}X", "OX"},
+		{"O{undef}X", "OX"},
+		{"{x,y}", "1024,768"},
+		{"{x,hello,y}", "1024,Hello%20World%21,768"},
+		{"?{x,empty}", "?1024,"},
+		{"?{x,undef}", "?1024"},
+		{"{var:3}", "val"},
+		{"{var:30}", "value"},
+		{"{list}", "red,green,blue"},
+		{"{list*}", "red,green,blue"},
+		{"{keys}", "comma,%2C,dot,.,semi,%3B"},
+		{"{keys*}", "comma=%2C,dot=.,semi=%3B"},
+		{"{+var}", "value"},
+		{"{+hello}", "Hello%20World!"},
+		{"{+half}", "50%25"},
+		{"{base}index", "http%3A%2F%2Fexample.com%2Fhome%2Findex"},
+		{"{+base}index", "http://example.com/home/index"},
+		{"{+path}/here", "/foo/bar/here"},
+		{"here?ref={+path}", "here?ref=/foo/bar"},
+		{"{+path:6}/here", "/foo/b/here"},
+		{"{#var}", "#value"},
+		{"{#hello}", "#Hello%20World!"},
+		{"{#half}", "#50%25"},
+		{"foo{#empty}", "foo#"},
+		{"foo{#undef}", "foo"},
+		{"{#path,x}/here", "#/foo/bar,1024/here"},
+		{"{.who}", ".fred"},
+		{"{.who,who}", ".fred.fred"},
+		{"{.half,who}", ".50%25.fred"},
+		{"www{.dom*}", "www.example.com"},
+		{"X{.var:3}", "X.val"},
+		{"X{.empty}", "X."},
+		{"X{.undef}", "X"},
+		{"X{.list}", "X.red,green,blue"},
+		{"X{.list*}", "X.red.green.blue"},
+		{"X{.empty_keys}", "X"},
+		{"{/who}", "/fred"},
+		{"{/who,who}", "/fred/fred"},
+		{"{/half,who}", "/50%25/fred"},
+		{"{/who,dub}", "/fred/me%2Ftoo"},
+		{"{/var,x}/here", "/value/1024/here"},
+		{"{/var:1,var}", "/v/value"},
+		{"{/list*,path:4}", "/red/green/blue/%2Ffoo"},
+		{"{;who}", ";who=fred"},
+		{"{;half}", ";half=50%25"},
+		{"{;empty}", ";empty"},
+		{"{;v,empty,who}", ";v=6;empty;who=fred"},
+		{"{;x,y,undef}", ";x=1024;y=768"},
+		{"{;list}", ";list=red,green,blue"},
+		{"{;list*}", ";list=red;list=green;list=blue"},
+		{"{;keys*}", ";comma=%2C;dot=.;semi=%3B"},
+		{"{?who}", "?who=fred"},
+		{"{?half}", "?half=50%25"},
+		{"{?x,y,empty}", "?x=1024&y=768&empty="},
+		{"{?x,y,undef}", "?x=1024&y=768"},
+		{"{?var:3}", "?var=val"},
+		{"{?list}", "?list=red,green,blue"},
+		{"{?list*}", "?list=red&list=green&list=blue"},
+		{"{?keys}", "?keys=comma,%2C,dot,.,semi,%3B"},
+		{"{?keys*}", "?comma=%2C&dot=.&semi=%3B"},
+		{"{&who}", "&who=fred"},
+		{"?fixed=yes{&x}", "?fixed=yes&x=1024"},
+		{"{&var:3}", "&var=val"},
+		{"{?count*}", "?count=one&count=two&count=three"},
+	}
+	for _, tt := range tests {
+		got, err := tt.template.Expand(rfcVars)
+		if err != nil {
+			t.Errorf("%s: %v", tt.template, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s expanded to %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestLinkTemplateExpandErrors(t *testing.T) {
+	for _, tmpl := range []LinkTemplate{"{", "}", "a}{b}", "{}", "{+}", "{a,}", "{a:0}", "{a:x}", "{a:10000}", "{*}"} {
+		if got, err := tmpl.Expand(rfcVars); err == nil {
+			t.Errorf("%s expanded to %q, expected an error", tmpl, got)
+		}
+	}
+}
+
+type templatedSlot struct {
+	ID       string           `jsonapi:"primary,appointments"`
+	Date     string           `jsonapi:"attr,date"`
+	Provider *templatedSlot   `jsonapi:"relation,provider"`
+	Slots    []*templatedSlot `jsonapi:"relation,slots"`
+}
+
+func (templatedSlot) JSONAPILinks() *Links {
+	return &Links{"self": "/own"}
+}
+
+func (templatedSlot) JSONAPILinkTemplates() map[string]LinkTemplate {
+	return map[string]LinkTemplate{
+		"self":  "/{type}/{id}",
+		"slots": "/{type}/{id}/slots{?date}",
+	}
+}
+
+func (templatedSlot) JSONAPIRelationshipLinkTemplates(relation string) map[string]LinkTemplate {
+	return map[string]LinkTemplate{"related": "/appointments/{id}/{relation}"}
+}
+
+func TestLinkTemplatesMarshal(t *testing.T) {
+	doc := marshalDoc(t, &templatedSlot{ID: "7", Date: "2024-05-01"})
+	data := doc["data"].(map[string]interface{})
+
+	links := data["links"].(map[string]interface{})
+	if links["self"] != "/own" || links["slots"] != "/appointments/7/slots?date=2024-05-01" {
+		t.Errorf("links %v", links)
+	}
+	for _, name := range []string{"provider", "slots"} {
+		rel := data["relationships"].(map[string]interface{})[name].(map[string]interface{})
+		related := rel["links"].(map[string]interface{})["related"]
+		if related != "/appointments/7/"+name {
+			t.Errorf("%s related link %v", name, related)
+		}
+	}
+}
+
+type badTemplate struct {
+	ID string `jsonapi:"primary,things"`
+}
+
+func (badTemplate) JSONAPILinkTemplates() map[string]LinkTemplate {
+	return map[string]LinkTemplate{"self": "/{id"}
+}
+
+func TestLinkTemplatesMarshalError(t *testing.T) {
+	if _, err := Marshal(&badTemplate{ID: "1"}); err == nil {
+		t.Error("expected an error for a malformed template")
+	}
+}
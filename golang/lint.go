@@ -0,0 +1,363 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ModelError is a single diagnostic produced by CheckModel, and the error
+// Marshal and the Unmarshal functions return for a field they cannot
+// handle. Model and Field identify where the problem was found; Field is
+// empty for problems that concern the struct as a whole, such as a missing
+// primary annotation. Err is ErrBadJSONAPIStructTag, or ErrBadJSONAPIID for
+// primary fields of an unsupported type.
+type ModelError struct {
+	Model string
+	Field string
+	Tag   string
+	Msg   string
+	Err   error
+}
+
+func (e *ModelError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("jsonapi: %s: %s", e.Model, e.Msg)
+	}
+	return fmt.Sprintf("jsonapi: %s.%s (tag %q): %s", e.Model, e.Field, e.Tag, e.Msg)
+}
+
+func (e *ModelError) Unwrap() error {
+	return e.Err
+}
+
+// tagError returns the error for a field of the struct t whose tag, as
+// read, is unusable.
+func tagError(t reflect.Type, field reflect.StructField, tag, msg string) error {
+	return &ModelError{
+		Model: t.String(),
+		Field: field.Name,
+		Tag:   tag,
+		Msg:   msg,
+		Err:   ErrBadJSONAPIStructTag,
+	}
+}
+
+// idTypeError returns the error for a primary field of the struct t whose
+// type isValidIDType rejects.
+func idTypeError(t reflect.Type, field reflect.StructField, tag string) error {
+	return &ModelError{
+		Model: t.String(),
+		Field: field.Name,
+		Tag:   tag,
+		Msg:   fmt.Sprintf("primary field of unsupported type %s", field.Type),
+		Err:   ErrBadJSONAPIID,
+	}
+}
+
+// CheckTag validates the syntax of a single jsonapi struct tag value, e.g.
+// "attr,name,omitempty". "-", which leaves the field out, is valid. It does
+// not look at the type of the tagged field; use CheckModel for that.
+func CheckTag(tag string) error {
+	if tag == annotationIgnore {
+		return nil
+	}
+	if msg := checkTagArgs(strings.Split(tag, annotationSeperator)); msg != "" {
+		return fmt.Errorf("%w: %s", ErrBadJSONAPIStructTag, msg)
+	}
+	return nil
+}
+
+func checkTagArgs(args []string) string {
+	annotation := args[0]
+
+	switch annotation {
+	case annotationClientID, annotationExtras:
+		if len(args) != 1 {
+			return annotation + " takes no arguments"
+		}
+		return ""
+	case annotationPrimary, annotationAttribute, annotationRelation:
+	default:
+		return fmt.Sprintf("unknown annotation %q", annotation)
+	}
+
+	if len(args) < 2 || args[1] == "" {
+		// A primary annotation without a type derives it from the
+		// struct name.
+		if annotation == annotationPrimary {
+			if len(args) > 2 {
+				return "primary takes no options"
+			}
+			return ""
+		}
+		return fmt.Sprintf("%s requires a member name", annotation)
+	}
+
+	for _, opt := range args[2:] {
+		switch {
+		case annotation == annotationAttribute &&
+			(opt == annotationOmitEmpty || opt == annotationISO8601 || opt == annotationRFC3339 ||
+				opt == annotationDeprecated || opt == annotationReadOnly || opt == annotationWriteOnly ||
+				opt == annotationNumber || opt == annotationBase64URL || opt == annotationAddrSpec ||
+				strings.HasPrefix(opt, annotationDefault+"=")):
+		case annotation == annotationRelation && (opt == annotationOmitEmpty || opt == annotationCount):
+		case annotation == annotationRelation && isIDsOption(opt):
+		case annotation != annotationPrimary && isVersionOption(opt):
+		default:
+			return fmt.Sprintf("invalid option %q for %s", opt, annotation)
+		}
+	}
+
+	return ""
+}
+
+// CheckModel statically validates the jsonapi struct tags of model, which may
+// be a struct, a pointer to a struct or a slice of either. Related models
+// reachable through relation fields are checked as well. It returns one
+// *ModelError per problem found, or nil if the model can be marshaled.
+//
+// CheckModel is meant to be called from tests or at start-up so that tag
+// mistakes surface as readable diagnostics instead of marshal-time failures.
+// opts select the tags read, as they do for Marshal: WithTagKey,
+// WithJSONTagFallback and WithReservedFieldPrefix, and WithTypeNaming the
+// resource types derived.
+func CheckModel(model interface{}, opts ...MarshalOption) []error {
+	t := reflect.TypeOf(model)
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return []error{ErrUnexpectedType}
+	}
+
+	var errs []error
+	checkModelType(t, newMarshalOptions(opts), map[reflect.Type]bool{}, &errs)
+	return errs
+}
+
+func checkModelType(t reflect.Type, o *marshalOptions, seen map[reflect.Type]bool, errs *[]error) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+
+	report := func(field reflect.StructField, tag, msg string) {
+		*errs = append(*errs, &ModelError{
+			Model: t.String(),
+			Field: field.Name,
+			Tag:   tag,
+			Msg:   msg,
+			Err:   ErrBadJSONAPIStructTag,
+		})
+	}
+
+	var primaries, extras int
+	// members maps member names to the fields using them, and fieldArgs
+	// fields to their tag arguments.
+	members := map[string][]reflect.StructField{}
+	fieldArgs := map[string][]string{}
+	var related []reflect.Type
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := renameReserved(fieldTag(field, o.tagKey, o.jsonFallback), o.reservedPrefix)
+		if tag == "" {
+			continue
+		}
+
+		args := strings.Split(tag, annotationSeperator)
+		if msg := checkTagArgs(args); msg != "" {
+			report(field, tag, msg)
+			continue
+		}
+
+		switch args[0] {
+		case annotationPrimary:
+			primaries++
+			if !isValidIDType(field.Type) {
+				report(field, tag, fmt.Sprintf(
+					"primary field must be a string, int, uint or text marshaler type, got %s", field.Type))
+			}
+		case annotationClientID:
+			if field.Type.Kind() != reflect.String {
+				report(field, tag, fmt.Sprintf(
+					"client-id field must be a string, got %s", field.Type))
+			}
+		case annotationExtras:
+			extras++
+			if !isExtrasType(field.Type) {
+				report(field, tag, extrasTypeMsg(field.Type))
+			} else if extras > 1 {
+				report(field, tag, "only one extras field is allowed")
+			}
+		case annotationAttribute, annotationRelation:
+			name := args[1]
+			for _, other := range members[name] {
+				// Fields for different API versions may share a name.
+				if versionsOverlap(args, fieldArgs[other.Name]) {
+					report(field, tag, fmt.Sprintf(
+						"member name %q is already used by field %s", name, other.Name))
+					break
+				}
+			}
+			members[name] = append(members[name], field)
+			fieldArgs[field.Name] = args
+
+			if since, until := versionRange(args); since != "" && until != "" &&
+				compareVersions(since, until) >= 0 {
+				report(field, tag, fmt.Sprintf("since=%s is not older than until=%s", since, until))
+			}
+
+			if args[0] == annotationAttribute {
+				checkAttributeOptions(field, args[2:], func(msg string) {
+					report(field, tag, msg)
+				})
+				continue
+			}
+
+			if _, ok := idsRelationType(args, field.Type); ok {
+				elem := field.Type
+				if elem.Kind() == reflect.Slice {
+					elem = elem.Elem()
+				}
+				if !isValidRelationIDType(elem) {
+					report(field, tag, fmt.Sprintf(
+						"ids relation field must be a string, integer or text marshaler type, a pointer or a slice of one, got %s",
+						field.Type))
+				}
+				continue
+			}
+
+			elem, ok := relationElemType(field.Type)
+			if !ok {
+				report(field, tag, fmt.Sprintf(
+					"relation field must be a struct, a struct pointer, an interface, or a slice or string-keyed map of pointers or interfaces, got %s",
+					field.Type))
+				continue
+			}
+			if elem != nil {
+				related = append(related, elem)
+			}
+		}
+	}
+
+	switch {
+	case primaries == 0:
+		*errs = append(*errs, &ModelError{
+			Model: t.String(),
+			Msg:   "missing primary annotation",
+			Err:   ErrBadJSONAPIStructTag,
+		})
+	case primaries > 1:
+		*errs = append(*errs, &ModelError{
+			Model: t.String(),
+			Msg:   fmt.Sprintf("%d primary annotations, expected exactly one", primaries),
+			Err:   ErrBadJSONAPIStructTag,
+		})
+	}
+
+	*errs = append(*errs, memberNameErrors(t, &o.tagOptions)...)
+
+	for _, r := range related {
+		checkModelType(r, o, seen, errs)
+	}
+}
+
+func checkAttributeOptions(field reflect.StructField, opts []string, report func(string)) {
+	var iso8601, rfc3339, readOnly, writeOnly, number, base64URL, addrSpec bool
+	for _, opt := range opts {
+		switch opt {
+		case annotationAddrSpec:
+			addrSpec = true
+		case annotationBase64URL:
+			base64URL = true
+		case annotationNumber:
+			number = true
+		case annotationISO8601:
+			iso8601 = true
+		case annotationRFC3339:
+			rfc3339 = true
+		case annotationReadOnly:
+			readOnly = true
+		case annotationWriteOnly:
+			writeOnly = true
+		}
+	}
+
+	if readOnly && writeOnly {
+		report("readonly and writeonly are mutually exclusive")
+	}
+
+	if number && !isDecimalType(field.Type) {
+		report(fmt.Sprintf("number option used on non-decimal field of type %s", field.Type))
+	}
+	if base64URL && !isBytesType(field.Type) {
+		report(fmt.Sprintf("base64url option used on non-byte field of type %s", field.Type))
+	}
+	if addrSpec && field.Type != mailAddressType && field.Type != reflect.PtrTo(mailAddressType) {
+		report(fmt.Sprintf("addrspec option used on non-address field of type %s", field.Type))
+	}
+
+	if literal, ok := defaultOption(opts); ok {
+		if _, err := parseDefault(field.Type, literal); err != nil {
+			report(err.Error())
+		}
+	}
+
+	if !iso8601 && !rfc3339 {
+		return
+	}
+	if iso8601 && rfc3339 {
+		report("iso8601 and rfc3339 are mutually exclusive")
+	}
+
+	if field.Type != timeType && field.Type != reflect.PtrTo(timeType) && !isTimeSlice(field.Type) {
+		report(fmt.Sprintf("time format option used on non-time field of type %s", field.Type))
+	}
+}
+
+// isValidIDType reports whether t can be converted by the primary branch of
+// visitModelNode. Apart from types implementing both encoding.TextMarshaler
+// and encoding.TextUnmarshaler, only the predeclared types are accepted
+// there, since values are read back with a type assertion rather than by
+// kind.
+func isValidIDType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if (t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)) &&
+		reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	if t.PkgPath() != "" || t.Name() != t.Kind().String() {
+		return false
+	}
+
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// relationElemType returns the struct type a relation field holds or points at.
+// Interface-typed relations are resolved per element at runtime, so there
+// is nothing further to check and nil is returned for them.
+func relationElemType(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() == reflect.Struct {
+		return t, true
+	}
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Map && t.Key().Kind() == reflect.String {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Interface {
+		return nil, true
+	}
+	if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil, false
+	}
+	return t.Elem(), true
+}
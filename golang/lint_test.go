@@ -0,0 +1,159 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type lintValid struct {
+	ID     string         `jsonapi:"primary,books"`
+	Title  string         `jsonapi:"attr,title,omitempty"`
+	Author *lintValidUser `jsonapi:"relation,author"`
+}
+
+type lintValidUser struct {
+	ID   int    `jsonapi:"primary,users"`
+	Name string `jsonapi:"attr,name"`
+}
+
+type lintBad struct {
+	ID    string  `jsonapi:"primary,books"`
+	Other string  `jsonapi:"primary,other"`
+	Title string  `jsonapi:"attr,title"`
+	Dup   string  `jsonapi:"attr,title"`
+	Bogus string  `jsonapi:"attr,bogus,bogus"`
+	Rel   string  `jsonapi:"relation,rel"`
+	Unk   float64 `jsonapi:"weird,x"`
+}
+
+type lintTagKey struct {
+	ID    string `jsonapi:"primary,books"`
+	Title string `jsonapi:"attr,title" jsonapi_admin:"attr,title,bogus"`
+}
+
+type lintJSONFallback struct {
+	ID    string `jsonapi:"primary,books"`
+	Title string `json:"title"`
+	Kind  string `json:"type"`
+}
+
+func TestCheckModelValid(t *testing.T) {
+	for _, model := range []interface{}{lintValid{}, &lintValid{}, []*lintValid{}} {
+		if errs := CheckModel(model); errs != nil {
+			t.Errorf("CheckModel(%T) = %v, want nil", model, errs)
+		}
+	}
+}
+
+func TestCheckModelReportsProblems(t *testing.T) {
+	errs := CheckModel(&lintBad{})
+	want := []string{
+		"2 primary annotations",
+		`invalid option "bogus"`,
+		`member name "title" is already used by field Title`,
+		"relation field must be",
+		`unknown annotation "weird"`,
+	}
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		if !errors.Is(err, ErrBadJSONAPIStructTag) {
+			t.Errorf("%v does not wrap ErrBadJSONAPIStructTag", err)
+		}
+		msgs[i] = err.Error()
+	}
+	all := strings.Join(msgs, "\n")
+	for _, w := range want {
+		if !strings.Contains(all, w) {
+			t.Errorf("missing %q in:\n%s", w, all)
+		}
+	}
+}
+
+func TestCheckModelNotAStruct(t *testing.T) {
+	errs := CheckModel(42)
+	if len(errs) != 1 || errs[0] != ErrUnexpectedType {
+		t.Errorf("CheckModel(42) = %v, want [ErrUnexpectedType]", errs)
+	}
+}
+
+func TestCheckModelUsesMarshalTagLookup(t *testing.T) {
+	if errs := CheckModel(&lintTagKey{}); errs != nil {
+		t.Errorf("default tags: %v", errs)
+	}
+	if errs := CheckModel(&lintTagKey{}, WithTagKey("jsonapi_admin")); len(errs) != 1 {
+		t.Errorf("WithTagKey: got %v, want one error for the admin tag", errs)
+	}
+
+	if errs := CheckModel(&lintJSONFallback{}); errs != nil {
+		t.Errorf("without fallback: %v", errs)
+	}
+	errs := CheckModel(&lintJSONFallback{}, WithJSONTagFallback())
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), `"type" is reserved`) {
+		t.Errorf("WithJSONTagFallback: got %v, want the reserved type attribute", errs)
+	}
+}
+
+func TestCheckTag(t *testing.T) {
+	for tag, ok := range map[string]bool{
+		"attr,name":               true,
+		"attr,name,omitempty":     true,
+		"primary":                 true,
+		"client-id":               true,
+		"client-id,x":             false,
+		"attr":                    false,
+		"relation,author,ids":     true,
+		"relation,author,iso8601": false,
+		"nonsense,x":              false,
+		"-":                       true,
+		"-,x":                     false,
+	} {
+		if err := CheckTag(tag); (err == nil) != ok {
+			t.Errorf("CheckTag(%q) = %v, want ok=%v", tag, err, ok)
+		}
+	}
+}
+
+func TestCheckModelIgnoredField(t *testing.T) {
+	type ignored struct {
+		ID     string  `jsonapi:"primary,books"`
+		Cache  []byte  `jsonapi:"-"`
+		Parent *string `jsonapi:"-"`
+	}
+	if errs := CheckModel(&ignored{}); errs != nil {
+		t.Errorf("CheckModel = %v, want nil for ignored fields", errs)
+	}
+}
+
+func TestCheckModelExtras(t *testing.T) {
+	type twoExtras struct {
+		ID    string                     `jsonapi:"primary,books"`
+		Tags  map[string]interface{}     `jsonapi:"extras"`
+		Other map[string]json.RawMessage `jsonapi:"extras"`
+	}
+	type badExtras struct {
+		ID   string            `jsonapi:"primary,books"`
+		Tags map[string]string `jsonapi:"extras"`
+	}
+
+	if errs := CheckModel(&extrasPerson{}); errs != nil {
+		t.Errorf("CheckModel(extrasPerson) = %v, want nil", errs)
+	}
+	tests := []struct {
+		model interface{}
+		want  string
+	}{
+		{&twoExtras{}, "only one extras field is allowed"},
+		{&badExtras{}, "extras field must be a string-keyed map"},
+	}
+	for _, tt := range tests {
+		errs := CheckModel(tt.model)
+		if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.want) {
+			t.Errorf("CheckModel(%T) = %v, want %q", tt.model, errs, tt.want)
+		}
+	}
+	if err := CheckTag("extras,x"); err == nil {
+		t.Error(`CheckTag("extras,x") succeeded`)
+	}
+}
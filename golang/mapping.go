@@ -0,0 +1,199 @@
+package jsonapi
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// CopyFields copies the fields of src into dst, both pointers to structs,
+// matching them by name so that database rows (db tags) and API models
+// (jsonapi tags) can be converted without hand-written code.
+//
+// A field is known by its Go name, its db tag and its jsonapi member name
+// ("id" for the primary field), compared case-insensitively and ignoring
+// '_' and '-'. Relation fields are skipped. Values are converted between
+// pointers and values, numeric kinds, named types and the database/sql
+// Valuer and Scanner types such as sql.NullString; a NULL source clears
+// the destination. opts, e.g. WithTagKey, name the members as for
+// Marshal.
+func CopyFields(dst, src interface{}, opts ...MarshalOption) error {
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Ptr || dv.Elem().Kind() != reflect.Struct {
+		return ErrUnexpectedType
+	}
+	sv := reflect.Indirect(reflect.ValueOf(src))
+	if sv.Kind() != reflect.Struct {
+		return ErrUnexpectedType
+	}
+	return copyStruct(dv.Elem(), sv, &newMarshalOptions(opts).tagOptions)
+}
+
+// CopySlice is CopyFields for slices: it sets *dst, a pointer to a slice of
+// structs or struct pointers, to the converted elements of src.
+func CopySlice(dst, src interface{}, opts ...MarshalOption) error {
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Ptr || dv.Elem().Kind() != reflect.Slice {
+		return ErrExpectedSlice
+	}
+	sv := reflect.ValueOf(src)
+	if sv.Kind() != reflect.Slice {
+		return ErrExpectedSlice
+	}
+
+	tags := &newMarshalOptions(opts).tagOptions
+	elemType := dv.Elem().Type().Elem()
+	out := reflect.MakeSlice(dv.Elem().Type(), 0, sv.Len())
+	for i := 0; i < sv.Len(); i++ {
+		s := reflect.Indirect(sv.Index(i))
+		if !s.IsValid() {
+			out = reflect.Append(out, reflect.Zero(elemType))
+			continue
+		}
+		if s.Kind() != reflect.Struct {
+			return ErrExpectedSlice
+		}
+
+		d := reflect.New(elemType).Elem()
+		target := d
+		if elemType.Kind() == reflect.Ptr {
+			d.Set(reflect.New(elemType.Elem()))
+			target = d.Elem()
+		}
+		if target.Kind() != reflect.Struct {
+			return ErrExpectedSlice
+		}
+		if err := copyStruct(target, s, tags); err != nil {
+			return err
+		}
+		out = reflect.Append(out, d)
+	}
+
+	dv.Elem().Set(out)
+	return nil
+}
+
+func copyStruct(dst, src reflect.Value, tags *tagOptions) error {
+	index := map[string]int{}
+	for i := 0; i < src.NumField(); i++ {
+		for _, key := range fieldKeys(src.Type().Field(i), tags) {
+			if _, taken := index[key]; !taken {
+				index[key] = i
+			}
+		}
+	}
+
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Type().Field(i)
+		for _, key := range fieldKeys(field, tags) {
+			j, ok := index[key]
+			if !ok {
+				continue
+			}
+			if err := copyValue(dst.Field(i), src.Field(j)); err != nil {
+				return fmt.Errorf("jsonapi: copy %s.%s: %v", dst.Type(), field.Name, err)
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
+// fieldKeys returns the normalized names a field, whose tags are read as
+// set by tags, is matched by, tag names first. Unexported, ignored and
+// relation fields have none, except for the foreign keys of ids relations.
+func fieldKeys(field reflect.StructField, tags *tagOptions) []string {
+	if field.PkgPath != "" || tags.ignored(field) {
+		return nil
+	}
+
+	var keys []string
+	if tag := tags.tag(field); tag != "" {
+		args := strings.Split(tag, annotationSeperator)
+		switch {
+		case args[0] == annotationRelation:
+			// Foreign keys of ids relations are copied like attributes.
+			if _, ok := idsRelationType(args, field.Type); !ok {
+				return nil
+			}
+		case args[0] == annotationPrimary:
+			keys = append(keys, "id")
+		case args[0] == annotationAttribute && len(args) > 1:
+			keys = append(keys, normalizeName(args[1]))
+		}
+	}
+	if tag := strings.Split(field.Tag.Get("db"), ",")[0]; tag != "" && tag != "-" {
+		keys = append(keys, normalizeName(tag))
+	}
+	return append(keys, normalizeName(field.Name))
+}
+
+func normalizeName(s string) string {
+	s = strings.ToLower(s)
+	s = strings.Replace(s, "_", "", -1)
+	return strings.Replace(s, "-", "", -1)
+}
+
+var (
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// copyValue assigns src to dst, converting between the representations
+// described on CopyFields.
+func copyValue(dst, src reflect.Value) error {
+	if src.Kind() == reflect.Ptr {
+		if src.IsNil() {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		if !src.Type().Implements(valuerType) {
+			return copyValue(dst, src.Elem())
+		}
+	}
+
+	if src.Type().Implements(valuerType) && !src.Type().AssignableTo(dst.Type()) {
+		v, err := src.Interface().(driver.Valuer).Value()
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		src = reflect.ValueOf(v)
+	}
+
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+
+	if reflect.PtrTo(dst.Type()).Implements(scannerType) {
+		return dst.Addr().Interface().(sql.Scanner).Scan(src.Interface())
+	}
+
+	if dst.Kind() == reflect.Ptr {
+		elem := reflect.New(dst.Type().Elem())
+		if err := copyValue(elem.Elem(), src); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	}
+
+	// Go converts integers to strings as runes, which is never wanted here.
+	if dst.Kind() == reflect.String && src.Kind() != reflect.String &&
+		!(src.Kind() == reflect.Slice && src.Type().Elem().Kind() == reflect.Uint8) {
+		return fmt.Errorf("cannot convert %s to %s", src.Type(), dst.Type())
+	}
+	if src.Type().ConvertibleTo(dst.Type()) {
+		dst.Set(src.Convert(dst.Type()))
+		return nil
+	}
+
+	return fmt.Errorf("cannot convert %s to %s", src.Type(), dst.Type())
+}
@@ -0,0 +1,118 @@
+package jsonapi
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+type mappingRow struct {
+	PostID    int64          `db:"post_id"`
+	Title     sql.NullString `db:"title"`
+	Views     int32          `db:"view_count"`
+	Published *time.Time     `db:"published_at"`
+	Secret    string         `db:"-"`
+}
+
+type mappingPost struct {
+	ID        int64        `jsonapi:"primary,posts" db:"post_id"`
+	Title     string       `jsonapi:"attr,title"`
+	Views     int64        `jsonapi:"attr,view-count"`
+	Published time.Time    `jsonapi:"attr,published-at,iso8601"`
+	Secret    string       `jsonapi:"-"`
+	Author    *orderPerson `jsonapi:"relation,author"`
+}
+
+func TestCopyFields(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	row := mappingRow{
+		PostID:    7,
+		Title:     sql.NullString{String: "hi", Valid: true},
+		Views:     3,
+		Published: &now,
+		Secret:    "s",
+	}
+
+	var post mappingPost
+	if err := CopyFields(&post, row); err != nil {
+		t.Fatal(err)
+	}
+	if post.ID != 7 || post.Title != "hi" || post.Views != 3 || !post.Published.Equal(now) || post.Secret != "" {
+		t.Errorf("post %+v", post)
+	}
+
+	// And back, where an empty title is still a valid NullString.
+	var back mappingRow
+	if err := CopyFields(&back, &mappingPost{ID: 8, Views: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if back.PostID != 8 || back.Views != 1 || !back.Title.Valid || back.Published == nil {
+		t.Errorf("row %+v", back)
+	}
+}
+
+func TestCopyFieldsNull(t *testing.T) {
+	post := mappingPost{Title: "old"}
+	if err := CopyFields(&post, mappingRow{}); err != nil {
+		t.Fatal(err)
+	}
+	if post.Title != "" || !post.Published.IsZero() {
+		t.Errorf("NULL values not cleared: %+v", post)
+	}
+}
+
+func TestCopyFieldsErrors(t *testing.T) {
+	type numberTitle struct {
+		Title int `db:"title"`
+	}
+	tests := []struct {
+		dst, src interface{}
+	}{
+		{mappingPost{}, mappingRow{}},
+		{&mappingPost{}, 1},
+		{&mappingPost{}, numberTitle{Title: 65}},
+	}
+	for _, tt := range tests {
+		if err := CopyFields(tt.dst, tt.src); err == nil {
+			t.Errorf("CopyFields(%T, %T) succeeded", tt.dst, tt.src)
+		}
+	}
+}
+
+func TestCopySlice(t *testing.T) {
+	rows := []*mappingRow{{PostID: 1}, nil, {PostID: 2}}
+
+	var posts []*mappingPost
+	if err := CopySlice(&posts, rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 3 || posts[0].ID != 1 || posts[1] != nil || posts[2].ID != 2 {
+		t.Errorf("posts %+v", posts)
+	}
+
+	var values []mappingPost
+	if err := CopySlice(&values, rows[:1]); err != nil || len(values) != 1 || values[0].ID != 1 {
+		t.Errorf("values %+v, error %v", values, err)
+	}
+
+	if err := CopySlice(posts, rows); err != ErrExpectedSlice {
+		t.Errorf("CopySlice(slice) error %v, want ErrExpectedSlice", err)
+	}
+	if err := CopySlice(&[]int{}, rows); err != ErrExpectedSlice {
+		t.Errorf("CopySlice(&[]int) error %v, want ErrExpectedSlice", err)
+	}
+}
+
+func TestCopyFieldsIgnored(t *testing.T) {
+	type secretRow struct {
+		PostID int64
+		Secret string
+	}
+	var post mappingPost
+	if err := CopyFields(&post, secretRow{PostID: 3, Secret: "s"}); err != nil {
+		t.Fatal(err)
+	}
+	if post.ID != 3 || post.Secret != "" {
+		t.Errorf("post %+v, want the ignored field left alone", post)
+	}
+}
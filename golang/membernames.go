@@ -0,0 +1,180 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrInvalidMemberName is wrapped by the errors returned by CheckMemberName
+// and, through *ModelError, by Marshal and CheckModel when a struct tag
+// declares a resource type, attribute or relationship name that breaks the
+// member name rules of the specification.
+var ErrInvalidMemberName = errors.New("jsonapi: invalid member name")
+
+// reservedFieldNames are the names a resource object reserves for itself;
+// attributes and relationships share a namespace with them.
+var reservedFieldNames = map[string]bool{
+	"type": true,
+	"id":   true,
+}
+
+// CheckMemberName reports whether name is a valid member name: it must be
+// non-empty, consist of ASCII letters and digits, non-ASCII characters,
+// hyphens, underscores and spaces, and must not start or end with a hyphen,
+// an underscore or a space.
+func CheckMemberName(name string) error {
+	if msg := memberNameProblem(name); msg != "" {
+		return fmt.Errorf("%w %q: %s", ErrInvalidMemberName, name, msg)
+	}
+	return nil
+}
+
+func memberNameProblem(name string) string {
+	if name == "" {
+		return "member names must not be empty"
+	}
+
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r >= 0x80:
+		case r == '-' || r == '_' || r == ' ':
+			if i == 0 || i == len(name)-1 {
+				return fmt.Sprintf("%q is not allowed at the start or end of a member name", r)
+			}
+		default:
+			return fmt.Sprintf("character %q is not allowed in member names", r)
+		}
+	}
+	return ""
+}
+
+// WithReservedFieldPrefix makes Marshal write attributes and relationships
+// named after a reserved member of the resource object, id or type, under
+// prefix+name instead of failing, e.g. "resource-id" for `attr,id` with the
+// prefix "resource-". Use ReservedFieldPrefix to read them back.
+func WithReservedFieldPrefix(prefix string) MarshalOption {
+	return func(o *marshalOptions) {
+		o.reservedPrefix = prefix
+	}
+}
+
+// ReservedFieldPrefix is the unmarshal counterpart of
+// WithReservedFieldPrefix.
+func ReservedFieldPrefix(prefix string) UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.reservedPrefix = prefix
+	}
+}
+
+// renameReserved rewrites the attr or relation tag of a reserved member
+// name to use prefix+name. Other tags, and all tags when prefix is "", are
+// returned unchanged.
+func renameReserved(tag, prefix string) string {
+	if prefix == "" {
+		return tag
+	}
+	args := strings.Split(tag, annotationSeperator)
+	if len(args) < 2 || (args[0] != annotationAttribute && args[0] != annotationRelation) ||
+		!reservedFieldNames[args[1]] {
+		return tag
+	}
+	args[1] = prefix + args[1]
+	return strings.Join(args, annotationSeperator)
+}
+
+// WithMemberNameWarnings downgrades the invalid member name errors Marshal
+// returns to warnings: each *ModelError is passed to warn, once per struct
+// type and call, and marshaling proceeds. warn may be nil to drop them.
+func WithMemberNameWarnings(warn func(error)) MarshalOption {
+	return func(o *marshalOptions) {
+		o.memberNamesLax = true
+		o.memberNameWarn = warn
+	}
+}
+
+// checkMemberNames validates the names declared by the tags of t, the
+// first time t is seen during the traversal.
+func (o *marshalState) checkMemberNames(t reflect.Type) error {
+	if o.memberNamesChecked[t] {
+		return nil
+	}
+	if o.memberNamesChecked == nil {
+		o.memberNamesChecked = map[reflect.Type]bool{}
+	}
+	o.memberNamesChecked[t] = true
+
+	errs := memberNameErrors(t, &o.tagOptions)
+	if len(errs) == 0 {
+		return nil
+	}
+	if !o.memberNamesLax {
+		return errs[0]
+	}
+	if o.memberNameWarn != nil {
+		for _, err := range errs {
+			o.memberNameWarn(err)
+		}
+	}
+	return nil
+}
+
+// memberNameErrors returns a *ModelError for each resource type, attribute
+// or relationship name declared by the tags of the struct type t that is
+// not a valid member name or is reserved, with tags read as set by tags.
+// Malformed tags are left to CheckModel and the marshaler.
+func memberNameErrors(t reflect.Type, tags *tagOptions) []error {
+	var errs []error
+	report := func(field reflect.StructField, tag, msg string) {
+		errs = append(errs, &ModelError{
+			Model: t.String(),
+			Field: field.Name,
+			Tag:   tag,
+			Msg:   msg,
+			Err:   ErrInvalidMemberName,
+		})
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := tags.tag(field)
+		if tag == "" {
+			continue
+		}
+		args := strings.Split(tag, annotationSeperator)
+
+		switch args[0] {
+		case annotationPrimary:
+			typ := tags.primaryType(t, args)
+			if msg := memberNameProblem(typ); msg != "" {
+				report(field, tag, fmt.Sprintf("invalid resource type %q: %s", typ, msg))
+			}
+		case annotationAttribute, annotationRelation:
+			if len(args) < 2 {
+				continue
+			}
+			name := args[1]
+			kind := "attribute"
+			if args[0] == annotationRelation {
+				kind = "relationship"
+			}
+
+			if msg := memberNameProblem(name); msg != "" {
+				report(field, tag, fmt.Sprintf("invalid %s name %q: %s", kind, name, msg))
+			} else if reservedFieldNames[name] {
+				report(field, tag, fmt.Sprintf(
+					"%s name %q is reserved for the resource object; rename it or use WithReservedFieldPrefix", kind, name))
+			}
+
+			if args[0] == annotationRelation {
+				if typ, ok := idsRelationType(args, field.Type); ok {
+					if msg := memberNameProblem(typ); msg != "" {
+						report(field, tag, fmt.Sprintf("invalid resource type %q: %s", typ, msg))
+					}
+				}
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,138 @@
+package jsonapi
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckMemberName(t *testing.T) {
+	valid := []string{"name", "first-name", "first_name", "first name", "a", "café", "x1"}
+	for _, name := range valid {
+		if err := CheckMemberName(name); err != nil {
+			t.Errorf("%q: %v", name, err)
+		}
+	}
+
+	invalid := []string{"", "-name", "name_", " name", "first.name", "a/b", "a+b", "@type"}
+	for _, name := range invalid {
+		if err := CheckMemberName(name); !errors.Is(err, ErrInvalidMemberName) {
+			t.Errorf("%q: error %v, want ErrInvalidMemberName", name, err)
+		}
+	}
+}
+
+type badNames struct {
+	ID    string     `jsonapi:"primary,bad.things"`
+	Name  string     `jsonapi:"attr,first.name"`
+	Owner *reqAuthor `jsonapi:"relation,_owner"`
+	Type  string     `jsonapi:"attr,type"`
+}
+
+func TestMemberNameErrors(t *testing.T) {
+	errs := memberNameErrors(reflect.TypeOf(badNames{}), nil)
+	var fields []string
+	for _, err := range errs {
+		var modelErr *ModelError
+		if !errors.As(err, &modelErr) || !errors.Is(err, ErrInvalidMemberName) {
+			t.Fatalf("error %v, want a *ModelError for ErrInvalidMemberName", err)
+		}
+		fields = append(fields, modelErr.Field)
+	}
+	if want := []string{"ID", "Name", "Owner", "Type"}; !equalStrings(fields, want) {
+		t.Errorf("fields %v, want %v", fields, want)
+	}
+}
+
+func TestMarshalInvalidMemberNames(t *testing.T) {
+	if _, err := Marshal(&badNames{ID: "1"}); !errors.Is(err, ErrInvalidMemberName) {
+		t.Errorf("error %v, want ErrInvalidMemberName", err)
+	}
+
+	var warnings []error
+	doc := marshalDoc(t, []*badNames{{ID: "1", Name: "a"}, {ID: "2"}},
+		WithMemberNameWarnings(func(err error) { warnings = append(warnings, err) }))
+	if len(warnings) != 4 {
+		t.Errorf("warnings %v, want each problem once per type", warnings)
+	}
+	first := doc["data"].([]interface{})[0].(map[string]interface{})
+	if first["attributes"].(map[string]interface{})["first.name"] != "a" {
+		t.Errorf("resource %v, want the names written as declared", first)
+	}
+
+	if _, err := Marshal(&badNames{ID: "1"}, WithMemberNameWarnings(nil)); err != nil {
+		t.Errorf("error %v with warnings dropped", err)
+	}
+}
+
+func TestCheckModelMemberNames(t *testing.T) {
+	var found bool
+	for _, err := range CheckModel(&badNames{}) {
+		found = found || strings.Contains(err.Error(), "first.name")
+	}
+	if !found {
+		t.Error("CheckModel did not report the invalid attribute name")
+	}
+}
+
+type reservedThing struct {
+	ID    string     `jsonapi:"primary,things"`
+	Type  string     `jsonapi:"attr,type"`
+	Other string     `jsonapi:"attr,id,omitempty"`
+	Owner *reqAuthor `jsonapi:"relation,type"`
+}
+
+func TestRenameReserved(t *testing.T) {
+	tests := []struct {
+		tag, prefix, want string
+	}{
+		{"attr,type", "x-", "attr,x-type"},
+		{"relation,id,omitempty", "x-", "relation,x-id,omitempty"},
+		{"attr,name", "x-", "attr,name"},
+		{"primary,type", "x-", "primary,type"},
+		{"attr,type", "", "attr,type"},
+	}
+	for _, tt := range tests {
+		if got := renameReserved(tt.tag, tt.prefix); got != tt.want {
+			t.Errorf("renameReserved(%q, %q) = %q, want %q", tt.tag, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestReservedFieldPrefix(t *testing.T) {
+	if _, err := Marshal(&reservedThing{ID: "1"}); !errors.Is(err, ErrInvalidMemberName) {
+		t.Errorf("error %v, want ErrInvalidMemberName without a prefix", err)
+	}
+
+	in := &reservedThing{ID: "1", Type: "widget", Other: "legacy-7", Owner: &reqAuthor{ID: "9"}}
+	data := marshalDoc(t, in, WithReservedFieldPrefix("resource-"))["data"].(map[string]interface{})
+	attrs := data["attributes"].(map[string]interface{})
+	if data["type"] != "things" || attrs["resource-type"] != "widget" || attrs["resource-id"] != "legacy-7" {
+		t.Errorf("resource %v", data)
+	}
+	if _, ok := data["relationships"].(map[string]interface{})["resource-type"]; !ok {
+		t.Errorf("relationships %v", data["relationships"])
+	}
+
+	b, err := MarshalBytes(in, WithReservedFieldPrefix("resource-"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(reservedThing)
+	if err := UnmarshalPayload(strings.NewReader(string(b)), out, ReservedFieldPrefix("resource-"),
+		DisallowUnknownFields()); err != nil {
+		t.Fatal(err)
+	}
+	if out.Type != "widget" || out.Other != "legacy-7" || out.Owner == nil || out.Owner.ID != "9" {
+		t.Errorf("unmarshaled %+v", out)
+	}
+
+	cs, err := UnmarshalChangeset(strings.NewReader(string(b)), new(reservedThing), ReservedFieldPrefix("resource-"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ch := cs.Attributes["resource-type"]; ch == nil || ch.Field != "Type" {
+		t.Errorf("changeset %+v", cs.Attributes)
+	}
+}
@@ -0,0 +1,126 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// UnmarshalInto is like UnmarshalPayload but merges the document into
+// model, typically loaded from a database, rather than filling it in, for
+// PATCH handlers:
+//
+//	post, err := store.Get(id)
+//	...
+//	err = jsonapi.UnmarshalInto(r.Body, post)
+//	...
+//	err = store.Save(post)
+//
+// Fields of members absent from the document keep their values; defaults
+// are not applied. Struct and map attributes are merged member by member,
+// as json.Unmarshal merges into a non-zero value, and related resources
+// already held by a relation field, matched by type and id, are updated in
+// place rather than replaced with new models. An explicit null still
+// clears a nullable field.
+func UnmarshalInto(in io.Reader, model interface{}, opts ...UnmarshalOption) error {
+	// Cap opts so that appending never writes to the caller's array.
+	return UnmarshalPayload(in, model, append(opts[:len(opts):len(opts)], func(o *unmarshalOptions) {
+		o.merge = true
+	})...)
+}
+
+// relatedModel returns the model to store the related resource n in: with
+// UnmarshalInto, existing when it holds that resource, otherwise a new
+// model of type t.
+func (o *unmarshalOptions) relatedModel(existing reflect.Value, t reflect.Type, n *Node) (reflect.Value, error) {
+	if o.merge && existing.IsValid() {
+		if existing.Kind() == reflect.Interface {
+			existing = existing.Elem()
+		}
+		if typ, id, ok := resourceIdentity(existing, &o.tagOptions); ok && typ == n.Type && id == n.ID {
+			return existing, nil
+		}
+	}
+	return newRelatedModel(t, n.Type)
+}
+
+// relatedModels indexes the models of a to-many relation field by type and
+// id, for relatedModel. It returns nil outside of UnmarshalInto.
+func (o *unmarshalOptions) relatedModels(field reflect.Value) map[string]reflect.Value {
+	if !o.merge || field.Len() == 0 {
+		return nil
+	}
+	if field.Kind() == reflect.Map {
+		field = relatedValues(field)
+	}
+	models := make(map[string]reflect.Value, field.Len())
+	for i := 0; i < field.Len(); i++ {
+		m := field.Index(i)
+		if m.Kind() == reflect.Interface {
+			m = m.Elem()
+		}
+		if typ, id, ok := resourceIdentity(m, &o.tagOptions); ok {
+			models[typ+","+id] = m
+		}
+	}
+	return models
+}
+
+// resourceIdentity returns the type and id of model, a pointer to a tagged
+// struct whose tags are read as set by tags. ok is false for nil models and
+// models without an id.
+func resourceIdentity(model reflect.Value, tags *tagOptions) (typ, id string, ok bool) {
+	if model.Kind() != reflect.Ptr || model.IsNil() || model.Elem().Kind() != reflect.Struct {
+		return "", "", false
+	}
+	t := model.Type().Elem()
+	for i := 0; i < t.NumField(); i++ {
+		args := strings.Split(tags.tag(t.Field(i)), annotationSeperator)
+		if args[0] != annotationPrimary {
+			continue
+		}
+		id, ok, err := formatRelationID(model.Elem().Field(i))
+		if err != nil || !ok {
+			return "", "", false
+		}
+		return tags.primaryType(t, args), id, true
+	}
+	return "", "", false
+}
+
+// mergeJSON decodes attribute over a copy of the current value of field,
+// a struct or map of type t or a pointer to one, the way handleJSON
+// decodes it into a new value.
+func mergeJSON(attribute interface{}, field reflect.Value, t reflect.Type, useNumber bool) (reflect.Value, error) {
+	current := reflect.Indirect(field)
+	if !current.IsValid() || current.IsZero() {
+		return handleJSON(attribute, t, useNumber)
+	}
+
+	merged := reflect.New(t)
+	if t.Kind() == reflect.Map {
+		m := reflect.MakeMapWithSize(t, current.Len())
+		iter := current.MapRange()
+		for iter.Next() {
+			m.SetMapIndex(iter.Key(), iter.Value())
+		}
+		merged.Elem().Set(m)
+	} else {
+		merged.Elem().Set(current)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := json.NewEncoder(buf).Encode(attribute); err != nil {
+		return reflect.Value{}, ErrInvalidType
+	}
+	dec := json.NewDecoder(buf)
+	if useNumber {
+		dec.UseNumber()
+	}
+	if err := dec.Decode(merged.Interface()); err != nil {
+		return reflect.Value{}, ErrInvalidType
+	}
+	return merged, nil
+}
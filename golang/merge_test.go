@@ -0,0 +1,131 @@
+package jsonapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type mergeAddress struct {
+	Street string `json:"street"`
+	City   string `json:"city"`
+}
+
+type mergeAuthor struct {
+	ID      string            `jsonapi:"primary,people"`
+	Name    string            `jsonapi:"attr,name"`
+	Email   string            `jsonapi:"attr,email"`
+	Address mergeAddress      `jsonapi:"attr,address"`
+	Labels  map[string]string `jsonapi:"attr,labels"`
+	Status  string            `jsonapi:"attr,status,default=active"`
+}
+
+type mergePost struct {
+	ID       string         `jsonapi:"primary,posts"`
+	Title    string         `jsonapi:"attr,title"`
+	Author   *mergeAuthor   `jsonapi:"relation,author"`
+	Editor   *mergeAuthor   `jsonapi:"relation,editor"`
+	Reviewer mergeAuthor    `jsonapi:"relation,reviewer"`
+	Authors  []*mergeAuthor `jsonapi:"relation,authors"`
+}
+
+func TestUnmarshalIntoAttributes(t *testing.T) {
+	author := &mergeAuthor{
+		ID:      "9",
+		Name:    "Ann",
+		Email:   "ann@example.com",
+		Address: mergeAddress{Street: "Main St", City: "Springfield"},
+		Labels:  map[string]string{"a": "1", "b": "2"},
+	}
+	doc := `{"data": {"type": "people", "id": "9", "attributes": {
+		"name": "Ann Lee", "address": {"city": "Shelbyville"}, "labels": {"b": "3"}}}}`
+	if err := UnmarshalInto(strings.NewReader(doc), author); err != nil {
+		t.Fatal(err)
+	}
+
+	if author.Name != "Ann Lee" || author.Email != "ann@example.com" {
+		t.Errorf("author %+v, want email kept", author)
+	}
+	if author.Address != (mergeAddress{Street: "Main St", City: "Shelbyville"}) {
+		t.Errorf("address %+v, want it merged", author.Address)
+	}
+	if author.Labels["a"] != "1" || author.Labels["b"] != "3" {
+		t.Errorf("labels %v, want them merged", author.Labels)
+	}
+	if author.Status != "" {
+		t.Errorf("status %q, want no default applied", author.Status)
+	}
+}
+
+func TestUnmarshalIntoRelationships(t *testing.T) {
+	ann := &mergeAuthor{ID: "9", Name: "Ann", Email: "ann@example.com"}
+	bob := &mergeAuthor{ID: "10", Name: "Bob"}
+	post := &mergePost{ID: "1", Title: "Hello", Author: ann, Authors: []*mergeAuthor{ann, bob}}
+
+	doc := `{"data": {"type": "posts", "id": "1", "relationships": {
+			"author": {"data": {"type": "people", "id": "9"}},
+			"authors": {"data": [{"type": "people", "id": "10"}, {"type": "people", "id": "11"}]}}},
+		"included": [
+			{"type": "people", "id": "9", "attributes": {"name": "Ann Lee"}},
+			{"type": "people", "id": "11", "attributes": {"name": "Cy"}}]}`
+	if err := UnmarshalInto(strings.NewReader(doc), post); err != nil {
+		t.Fatal(err)
+	}
+
+	if post.Title != "Hello" {
+		t.Errorf("title %q, want it kept", post.Title)
+	}
+	if post.Author != ann || ann.Name != "Ann Lee" || ann.Email != "ann@example.com" {
+		t.Errorf("author %+v, want the existing model updated", post.Author)
+	}
+	if len(post.Authors) != 2 || post.Authors[0] != bob || post.Authors[1].Name != "Cy" {
+		t.Errorf("authors %+v", post.Authors)
+	}
+	if bob.Name != "Bob" {
+		t.Errorf("bob %+v, want him unchanged without included attributes", bob)
+	}
+}
+
+func TestUnmarshalIntoNullToOne(t *testing.T) {
+	post := &mergePost{
+		ID:       "1",
+		Author:   &mergeAuthor{ID: "9"},
+		Editor:   &mergeAuthor{ID: "10"},
+		Reviewer: mergeAuthor{ID: "11", Name: "Cy"},
+	}
+	doc := `{"data": {"type": "posts", "id": "1", "relationships": {
+		"author": {"data": null}, "reviewer": {"data": null}}}}`
+	if err := UnmarshalInto(strings.NewReader(doc), post); err != nil {
+		t.Fatal(err)
+	}
+	if post.Author != nil {
+		t.Errorf("author %+v, want it cleared", post.Author)
+	}
+	if !reflect.DeepEqual(post.Reviewer, mergeAuthor{}) {
+		t.Errorf("reviewer %+v, want it cleared", post.Reviewer)
+	}
+	if post.Editor == nil || post.Editor.ID != "10" {
+		t.Errorf("editor %+v, want it kept", post.Editor)
+	}
+}
+
+func TestUnmarshalIntoOptions(t *testing.T) {
+	// UnmarshalInto must not write to spare capacity of the caller's
+	// options.
+	var used bool
+	spare := func(o *unmarshalOptions) { used = true }
+	opts := make([]UnmarshalOption, 1, 2)
+	opts[0] = UseNumber()
+	opts = append(opts, spare)[:1]
+
+	doc := `{"data": {"type": "people", "id": "9"}}`
+	if err := UnmarshalInto(strings.NewReader(doc), &mergeAuthor{ID: "9"}, opts...); err != nil {
+		t.Fatal(err)
+	}
+	if err := UnmarshalPayload(strings.NewReader(doc), new(mergeAuthor), opts[:2]...); err != nil {
+		t.Fatal(err)
+	}
+	if !used {
+		t.Error("UnmarshalInto overwrote the caller's spare option")
+	}
+}
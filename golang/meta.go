@@ -0,0 +1,81 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrNoMeta is returned by the MetaInto helpers when there is no meta
+// object to decode.
+var ErrNoMeta = errors.New("jsonapi: document has no meta object")
+
+// StructMetable is the typed counterpart of Metable. Models (or slices of
+// models) implementing it return a struct whose exported fields are encoded
+// into the meta object following their json tags. If a model implements
+// both interfaces, Metable wins.
+type StructMetable interface {
+	JSONAPIStructMeta() interface{}
+}
+
+// NewMeta converts v, usually a struct with json tags, into a Meta. A nil v
+// yields a nil Meta.
+func NewMeta(v interface{}) (*Meta, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if m, ok := v.(*Meta); ok {
+		return m, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Meta
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// MetaInto decodes the document's top-level meta into dst, which must be a
+// pointer as for json.Unmarshal.
+func (p *OnePayload) MetaInto(dst interface{}) error {
+	return metaInto(p.Meta, dst)
+}
+
+// MetaInto decodes the document's top-level meta into dst, which must be a
+// pointer as for json.Unmarshal.
+func (p *ManyPayload) MetaInto(dst interface{}) error {
+	return metaInto(p.Meta, dst)
+}
+
+// MetaInto decodes the resource object's meta into dst, which must be a
+// pointer as for json.Unmarshal.
+func (n *Node) MetaInto(dst interface{}) error {
+	return metaInto(n.Meta, dst)
+}
+
+func metaInto(m *Meta, dst interface{}) error {
+	if m == nil {
+		return ErrNoMeta
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, dst)
+}
+
+// modelMeta returns the meta of model from Metable or StructMetable.
+func modelMeta(model interface{}) (*Meta, error) {
+	if metableModel, ok := model.(Metable); ok {
+		return metableModel.JSONAPIMeta(), nil
+	}
+	if structMetable, ok := model.(StructMetable); ok {
+		return NewMeta(structMetable.JSONAPIStructMeta())
+	}
+	return nil, nil
+}
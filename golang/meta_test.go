@@ -0,0 +1,111 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type metaStats struct {
+	Views int      `json:"views"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+type metaPost struct {
+	ID    string `jsonapi:"primary,posts"`
+	Stats metaStats
+}
+
+func (p *metaPost) JSONAPIStructMeta() interface{} {
+	return p.Stats
+}
+
+type metaPosts []*metaPost
+
+func (metaPosts) JSONAPIStructMeta() interface{} {
+	return metaStats{Views: 99}
+}
+
+type metaBoth struct {
+	ID string `jsonapi:"primary,posts"`
+}
+
+func (*metaBoth) JSONAPIMeta() *Meta {
+	return &Meta{"from": "metable"}
+}
+
+func (*metaBoth) JSONAPIStructMeta() interface{} {
+	return struct {
+		From string `json:"from"`
+	}{"struct"}
+}
+
+func TestStructMetaRoundTrip(t *testing.T) {
+	want := metaStats{Views: 3, Tags: []string{"go"}}
+	payload, err := Marshal(&metaPost{ID: "1", Stats: want})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc OnePayload
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	var got metaStats
+	if err := doc.Data.MetaInto(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("meta %+v, want %+v", got, want)
+	}
+}
+
+func TestMetaInto(t *testing.T) {
+	var got metaStats
+	one := &OnePayload{Meta: &Meta{"views": 1}}
+	if err := one.MetaInto(&got); err != nil || got.Views != 1 {
+		t.Errorf("OnePayload: %+v, %v", got, err)
+	}
+
+	payload, err := Marshal(metaPosts{{ID: "1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	many := payload.(*ManyPayload)
+	if err := many.MetaInto(&got); err != nil || got.Views != 99 {
+		t.Errorf("ManyPayload: %+v, %v", got, err)
+	}
+
+	node := &Node{Meta: &Meta{"views": 7, "tags": []interface{}{"a"}}}
+	if err := node.MetaInto(&got); err != nil || !reflect.DeepEqual(got, metaStats{Views: 7, Tags: []string{"a"}}) {
+		t.Errorf("Node: %+v, %v", got, err)
+	}
+}
+
+func TestMetaIntoNoMeta(t *testing.T) {
+	var got metaStats
+	if err := (&OnePayload{}).MetaInto(&got); err != ErrNoMeta {
+		t.Errorf("OnePayload: %v, want ErrNoMeta", err)
+	}
+	if err := (&ManyPayload{}).MetaInto(&got); err != ErrNoMeta {
+		t.Errorf("ManyPayload: %v, want ErrNoMeta", err)
+	}
+	if err := (&Node{}).MetaInto(&got); err != ErrNoMeta {
+		t.Errorf("Node: %v, want ErrNoMeta", err)
+	}
+}
+
+func TestMetableWinsOverStructMetable(t *testing.T) {
+	payload, err := Marshal(&metaBoth{ID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := payload.(*OnePayload).Data.Meta
+	if meta == nil || (*meta)["from"] != "metable" {
+		t.Errorf("meta %v, want the Metable one", meta)
+	}
+}
@@ -0,0 +1,165 @@
+//go:build prometheus
+// +build prometheus
+
+// Package metrics exposes Prometheus metrics for jsonapi marshaling and
+// unmarshaling. A *Metrics is both a prometheus.Collector and a
+// jsonapi.Hook:
+//
+//	m := metrics.New("api")
+//	prometheus.MustRegister(m)
+//	...
+//	err := jsonapi.MarshalPayload(w, posts, jsonapi.WithHook(m))
+//	err = jsonapi.UnmarshalPayload(r.Body, post, jsonapi.UseHook(m))
+//
+// Every metric is labeled with op, marshal or unmarshal, and the resource
+// type of the call. The package is built with the prometheus build tag so
+// that the jsonapi module does not depend on the Prometheus client.
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	jsonapi "test3"
+)
+
+// Values of the op label.
+const (
+	OpMarshal   = "marshal"
+	OpUnmarshal = "unmarshal"
+)
+
+// Metrics collects the durations, document sizes, resource counts and
+// errors of the calls it is attached to. It is safe for concurrent use.
+type Metrics struct {
+	jsonapi.NopHook
+
+	duration  *prometheus.HistogramVec
+	size      *prometheus.HistogramVec
+	resources *prometheus.CounterVec
+	included  *prometheus.CounterVec
+	errors    *prometheus.CounterVec
+}
+
+// New returns Metrics whose names are prefixed with namespace, e.g.
+// api_jsonapi_duration_seconds.
+func New(namespace string) *Metrics {
+	labels := []string{"op", "type"}
+	return &Metrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "jsonapi",
+			Name:      "duration_seconds",
+			Help:      "Time spent marshaling or unmarshaling a document.",
+			Buckets:   prometheus.ExponentialBuckets(0.00005, 4, 10),
+		}, labels),
+		size: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "jsonapi",
+			Name:      "document_bytes",
+			Help:      "Size of encoded or decoded documents.",
+			Buckets:   prometheus.ExponentialBuckets(256, 4, 10),
+		}, labels),
+		resources: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "jsonapi",
+			Name:      "resources_total",
+			Help:      "Resource objects in the primary data of documents.",
+		}, labels),
+		included: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "jsonapi",
+			Name:      "included_total",
+			Help:      "Resource objects in the included section of documents.",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "jsonapi",
+			Name:      "errors_total",
+			Help:      "Failed calls, by kind of error.",
+		}, []string{"op", "type", "kind"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.duration.Describe(ch)
+	m.size.Describe(ch)
+	m.resources.Describe(ch)
+	m.included.Describe(ch)
+	m.errors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.duration.Collect(ch)
+	m.size.Collect(ch)
+	m.resources.Collect(ch)
+	m.included.Collect(ch)
+	m.errors.Collect(ch)
+}
+
+func (m *Metrics) observe(op string, s *jsonapi.HookStats, err error) {
+	m.duration.WithLabelValues(op, s.Type).Observe(s.Duration.Seconds())
+	if err != nil {
+		m.errors.WithLabelValues(op, s.Type, ErrorKind(err)).Inc()
+		return
+	}
+	if s.Bytes > 0 {
+		m.size.WithLabelValues(op, s.Type).Observe(float64(s.Bytes))
+	}
+	m.resources.WithLabelValues(op, s.Type).Add(float64(s.Resources))
+	m.included.WithLabelValues(op, s.Type).Add(float64(s.Included))
+}
+
+// OnMarshalEnd implements jsonapi.Hook.
+func (m *Metrics) OnMarshalEnd(s *jsonapi.HookStats, err error) {
+	m.observe(OpMarshal, s, err)
+}
+
+// OnUnmarshalEnd implements jsonapi.Hook.
+func (m *Metrics) OnUnmarshalEnd(s *jsonapi.HookStats) {
+	m.observe(OpUnmarshal, s, nil)
+}
+
+// OnUnmarshalError implements jsonapi.Hook.
+func (m *Metrics) OnUnmarshalError(s *jsonapi.HookStats, err error) {
+	m.observe(OpUnmarshal, s, err)
+}
+
+// ErrorKind returns the value of the kind label for err: syntax for
+// malformed JSON, limit for documents rejected by the limit options,
+// unknown_fields, relationship, model for struct tag and model type
+// mistakes, value for members that do not fit their field, and other.
+func ErrorKind(err error) string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var unknownErr *jsonapi.UnknownFieldsError
+	var relErr *jsonapi.RelationshipError
+	var modelErr *jsonapi.ModelError
+
+	switch {
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, jsonapi.ErrBinaryFormat):
+		return "syntax"
+	case errors.Is(err, jsonapi.ErrBodyTooLarge), errors.Is(err, jsonapi.ErrTooManyIncluded),
+		errors.Is(err, jsonapi.ErrRelationshipFanout), errors.Is(err, jsonapi.ErrMaxDepth):
+		return "limit"
+	case errors.As(err, &unknownErr):
+		return "unknown_fields"
+	case errors.As(err, &relErr), errors.Is(err, jsonapi.ErrInvalidRelationship):
+		return "relationship"
+	case errors.As(err, &modelErr), errors.Is(err, jsonapi.ErrBadJSONAPIStructTag),
+		errors.Is(err, jsonapi.ErrInvalidMemberName), errors.Is(err, jsonapi.ErrUnexpectedType),
+		errors.Is(err, jsonapi.ErrExpectedSlice), errors.Is(err, jsonapi.ErrBadJSONAPIID):
+		return "model"
+	case errors.As(err, &typeErr), errors.Is(err, jsonapi.ErrInvalidType),
+		errors.Is(err, jsonapi.ErrInvalidTime), errors.Is(err, jsonapi.ErrInvalidISO8601),
+		errors.Is(err, jsonapi.ErrInvalidRFC3339), errors.Is(err, jsonapi.ErrUnknownFieldNumberType):
+		return "value"
+	}
+	return "other"
+}
@@ -0,0 +1,106 @@
+//go:build prometheus
+// +build prometheus
+
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	jsonapi "test3"
+)
+
+type author struct {
+	ID   string `jsonapi:"primary,people"`
+	Name string `jsonapi:"attr,name"`
+}
+
+type post struct {
+	ID     string  `jsonapi:"primary,posts"`
+	Title  string  `jsonapi:"attr,title"`
+	Author *author `jsonapi:"relation,author"`
+}
+
+func TestMetrics(t *testing.T) {
+	m := New("test")
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(m); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	p := &post{ID: "1", Title: "Hello", Author: &author{ID: "9"}}
+	if err := jsonapi.MarshalPayload(&buf, p, jsonapi.WithHook(m)); err != nil {
+		t.Fatal(err)
+	}
+	if err := jsonapi.UnmarshalPayload(bytes.NewReader(buf.Bytes()), new(post), jsonapi.UseHook(m)); err != nil {
+		t.Fatal(err)
+	}
+	if err := jsonapi.UnmarshalPayload(strings.NewReader(`{"data": `), new(post), jsonapi.UseHook(m)); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	tests := []struct {
+		c    prometheus.Collector
+		want float64
+	}{
+		{m.resources.WithLabelValues(OpMarshal, "posts"), 1},
+		{m.included.WithLabelValues(OpMarshal, "posts"), 1},
+		{m.resources.WithLabelValues(OpUnmarshal, "posts"), 1},
+		{m.errors.WithLabelValues(OpUnmarshal, "posts", "syntax"), 1},
+	}
+	for i, tt := range tests {
+		if got := testutil.ToFloat64(tt.c); got != tt.want {
+			t.Errorf("metric %d = %v, want %v", i, got, tt.want)
+		}
+	}
+
+	// Durations are observed for every call, sizes only for the
+	// successful ones that encode or decode.
+	if n := testutil.CollectAndCount(m.duration); n != 2 {
+		t.Errorf("%d duration series, want 2", n)
+	}
+	if n := testutil.CollectAndCount(m.size); n != 2 {
+		t.Errorf("%d size series, want 2", n)
+	}
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP test_jsonapi_errors_total Failed calls, by kind of error.
+# TYPE test_jsonapi_errors_total counter
+test_jsonapi_errors_total{kind="syntax",op="unmarshal",type="posts"} 1
+`), "test_jsonapi_errors_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestErrorKind(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{jsonapi.ErrBodyTooLarge, "limit"},
+		{jsonapi.ErrMaxDepth, "limit"},
+		{jsonapi.ErrBinaryFormat, "syntax"},
+		{&jsonapi.UnknownFieldsError{}, "unknown_fields"},
+		{&jsonapi.RelationshipError{Err: jsonapi.ErrNullToMany}, "relationship"},
+		{jsonapi.ErrUnexpectedType, "model"},
+		{&jsonapi.ModelError{Err: jsonapi.ErrInvalidMemberName}, "model"},
+		{jsonapi.ErrInvalidTime, "value"},
+		{errors.New("boom"), "other"},
+	}
+	for _, tt := range tests {
+		if got := ErrorKind(tt.err); got != tt.want {
+			t.Errorf("ErrorKind(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+
+	doc := `{"data": {"type": "posts", "id": "1", "attributes": {"title": 5}}}`
+	err := jsonapi.UnmarshalPayload(strings.NewReader(doc), new(post))
+	if got := ErrorKind(err); got != "value" {
+		t.Errorf("ErrorKind(%v) = %q, want value", err, got)
+	}
+}
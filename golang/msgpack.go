@@ -0,0 +1,260 @@
+package jsonapi
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// MsgPack is the MessagePack Format.
+var MsgPack Format = msgpackFormat{}
+
+type msgpackFormat struct{}
+
+func (msgpackFormat) MediaType() string { return "application/msgpack" }
+
+func (msgpackFormat) EncodeValue(w io.Writer, v interface{}) error {
+	e := &msgpackEncoder{w: w}
+	e.value(v)
+	return e.err
+}
+
+func (msgpackFormat) DecodeValue(r io.Reader) (interface{}, error) {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		b := bufio.NewReader(r)
+		r, br = b, b
+	}
+	d := &msgpackDecoder{r: r, br: br}
+	return d.value(0)
+}
+
+type msgpackEncoder struct {
+	w   io.Writer
+	err error
+}
+
+func (e *msgpackEncoder) write(b ...byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+// head writes a type byte followed by n as a big-endian integer of size
+// bytes.
+func (e *msgpackEncoder) head(typ byte, n uint64, size int) {
+	buf := make([]byte, 9)
+	buf[0] = typ
+	binary.BigEndian.PutUint64(buf[1:], n)
+	e.write(append(buf[:1], buf[9-size:]...)...)
+}
+
+// length writes the header of a string, array or map of length n, using the
+// fix form below fixMax.
+func (e *msgpackEncoder) length(n int, fix byte, fixMax int, typ8, typ16, typ32 byte) {
+	switch {
+	case n < fixMax:
+		e.write(fix | byte(n))
+	case typ8 != 0 && n <= math.MaxUint8:
+		e.head(typ8, uint64(n), 1)
+	case n <= math.MaxUint16:
+		e.head(typ16, uint64(n), 2)
+	default:
+		e.head(typ32, uint64(n), 4)
+	}
+}
+
+func (e *msgpackEncoder) value(v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		e.write(0xc0)
+	case bool:
+		if v {
+			e.write(0xc3)
+		} else {
+			e.write(0xc2)
+		}
+	case int64:
+		switch {
+		case v >= 0 && v <= math.MaxInt8:
+			e.write(byte(v))
+		case v < 0 && v >= -32:
+			e.write(byte(v))
+		case v >= math.MinInt8 && v <= math.MaxInt8:
+			e.head(0xd0, uint64(v), 1)
+		case v >= math.MinInt16 && v <= math.MaxInt16:
+			e.head(0xd1, uint64(v), 2)
+		case v >= math.MinInt32 && v <= math.MaxInt32:
+			e.head(0xd2, uint64(v), 4)
+		default:
+			e.head(0xd3, uint64(v), 8)
+		}
+	case uint64:
+		e.head(0xcf, v, 8)
+	case float64:
+		e.head(0xcb, math.Float64bits(v), 8)
+	case string:
+		e.length(len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		if e.err == nil {
+			_, e.err = io.WriteString(e.w, v)
+		}
+	case []interface{}:
+		e.length(len(v), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, elem := range v {
+			e.value(elem)
+		}
+	case map[string]interface{}:
+		e.length(len(v), 0x80, 16, 0, 0xde, 0xdf)
+		for _, k := range sortedKeys(v) {
+			e.value(k)
+			e.value(v[k])
+		}
+	default:
+		if e.err == nil {
+			e.err = fmt.Errorf("jsonapi: cannot encode %T as MessagePack", v)
+		}
+	}
+}
+
+type msgpackDecoder struct {
+	r  io.Reader
+	br io.ByteReader
+}
+
+// uint reads a big-endian unsigned integer of size bytes.
+func (d *msgpackDecoder) uint(size int) (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(d.r, buf[8-size:]); err != nil {
+		return 0, ErrBinaryFormat
+	}
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+func (d *msgpackDecoder) value(depth int) (interface{}, error) {
+	if depth > maxBinaryNesting {
+		return nil, ErrBinaryFormat
+	}
+	b, err := d.br.ReadByte()
+	if err != nil {
+		return nil, ErrBinaryFormat
+	}
+
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b&0xe0 == 0xa0:
+		return d.str(uint64(b & 0x1f))
+	case b&0xf0 == 0x90:
+		return d.array(uint64(b&0x0f), depth)
+	case b&0xf0 == 0x80:
+		return d.object(uint64(b&0x0f), depth)
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := d.uint(1 << (b - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if n <= math.MaxInt64 {
+			return int64(n), nil
+		}
+		return n, nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (b - 0xd0)
+		n, err := d.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		// Sign-extend from size bytes.
+		shift := uint(64 - 8*size)
+		return int64(n<<shift) >> shift, nil
+	case 0xca:
+		n, err := d.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(uint32(n))), nil
+	case 0xcb:
+		n, err := d.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(n), nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (b - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(n)
+	case 0xc4, 0xc5, 0xc6:
+		// Binary data is read like a string, as JSON has no bytes type.
+		n, err := d.uint(1 << (b - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(n)
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (b - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(n, depth)
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (b - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.object(n, depth)
+	}
+
+	return nil, ErrBinaryFormat
+}
+
+func (d *msgpackDecoder) str(n uint64) (interface{}, error) {
+	b, err := readBytes(d.r, n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (d *msgpackDecoder) array(n uint64, depth int) (interface{}, error) {
+	a := make([]interface{}, 0, capHint(n))
+	for i := uint64(0); i < n; i++ {
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+	}
+	return a, nil
+}
+
+func (d *msgpackDecoder) object(n uint64, depth int) (interface{}, error) {
+	m := make(map[string]interface{}, capHint(n))
+	for i := uint64(0); i < n; i++ {
+		k, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, ErrBinaryFormat
+		}
+		if m[key], err = d.value(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
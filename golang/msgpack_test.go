@@ -0,0 +1,99 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMsgPackEncode(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{nil, "c0"},
+		{true, "c3"},
+		{int64(127), "7f"},
+		{int64(-32), "e0"},
+		{int64(-33), "d0df"},
+		{int64(128), "d10080"},
+		{int64(-40000), "d2ffff63c0"},
+		{int64(1 << 40), "d30000010000000000"},
+		{uint64(1), "cf0000000000000001"},
+		{1.5, "cb3ff8000000000000"},
+		{"abc", "a3616263"},
+		{strings.Repeat("a", 32), "d920" + strings.Repeat("61", 32)},
+		{[]interface{}{int64(1), "a"}, "9201a161"},
+		{map[string]interface{}{"b": int64(2), "a": int64(1)}, "82a16101a16202"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := MsgPack.EncodeValue(&buf, tt.v); err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(buf.Bytes()); got != tt.want {
+			t.Errorf("%.20v: got %s, want %s", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestMsgPackDecode(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want interface{}
+	}{
+		{"c2", false},
+		{"ff", int64(-1)},
+		{"cc80", int64(128)},
+		{"cdffff", int64(65535)},
+		{"ceffffffff", int64(math.MaxUint32)},
+		{"cfffffffffffffffff", uint64(math.MaxUint64)},
+		{"d0ff", int64(-1)},
+		{"d1ff00", int64(-256)},
+		{"d3ffffffffffffffff", int64(-1)},
+		{"ca3fc00000", 1.5},
+		{"c403010203", "\x01\x02\x03"},
+		{"da0003616263", "abc"},
+		{"dc000201c0", []interface{}{int64(1), nil}},
+		{"de0001a16101", map[string]interface{}{"a": int64(1)}},
+	}
+	for _, tt := range tests {
+		b, _ := hex.DecodeString(tt.hex)
+		got, err := MsgPack.DecodeValue(bytes.NewReader(b))
+		if err != nil {
+			t.Errorf("%s: %v", tt.hex, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %#v, want %#v", tt.hex, got, tt.want)
+		}
+	}
+}
+
+func TestMsgPackMalformed(t *testing.T) {
+	tests := []string{
+		"",           // empty
+		"c1",         // never used
+		"d4",         // extension types are not supported
+		"cd00",       // truncated integer
+		"a3616",      // truncated string
+		"92c0",       // truncated array
+		"8101c0",     // integer key
+		"dbffffffff", // oversized length
+	}
+	for _, h := range tests {
+		b, _ := hex.DecodeString(h)
+		if v, err := MsgPack.DecodeValue(bytes.NewReader(b)); err != ErrBinaryFormat {
+			t.Errorf("%q: got %v, %v, want ErrBinaryFormat", h, v, err)
+		}
+	}
+
+	deep := strings.Repeat("91", maxBinaryNesting+2) + "00"
+	b, _ := hex.DecodeString(deep)
+	if _, err := MsgPack.DecodeValue(bytes.NewReader(b)); err != ErrBinaryFormat {
+		t.Errorf("deep nesting: %v", err)
+	}
+}
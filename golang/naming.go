@@ -0,0 +1,159 @@
+package jsonapi
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// TypeNamingStrategy derives a resource type from a struct name. It is used
+// for primary annotations that leave the type out, e.g. `jsonapi:"primary"`;
+// an explicit type always takes precedence.
+type TypeNamingStrategy func(structName string) string
+
+// WithTypeNaming makes Marshal derive resource types with s instead of
+// PluralKebabCase.
+func WithTypeNaming(s TypeNamingStrategy) MarshalOption {
+	return func(o *marshalOptions) {
+		o.typeNaming = s
+	}
+}
+
+// TypeNaming is the unmarshal counterpart of WithTypeNaming.
+func TypeNaming(s TypeNamingStrategy) UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.typeNaming = s
+	}
+}
+
+// PluralKebabCase names BlogPost "blog-posts".
+func PluralKebabCase(name string) string {
+	return Pluralize(strings.Join(splitWords(name), "-"))
+}
+
+// PluralSnakeCase names BlogPost "blog_posts".
+func PluralSnakeCase(name string) string {
+	return Pluralize(strings.Join(splitWords(name), "_"))
+}
+
+// PluralCamelCase names BlogPost "blogPosts".
+func PluralCamelCase(name string) string {
+	words := splitWords(name)
+	for i := 1; i < len(words); i++ {
+		words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+	}
+	return Pluralize(strings.Join(words, ""))
+}
+
+// irregularPlurals covers common English nouns that Pluralize's suffix
+// rules get wrong.
+var irregularPlurals = map[string]string{
+	"person": "people",
+	"child":  "children",
+	"man":    "men",
+	"woman":  "women",
+	"mouse":  "mice",
+}
+
+// Pluralize returns the English plural of the last word of s using simple
+// suffix rules: Blog → Blogs, Address → Addresses, Category → Categories.
+func Pluralize(s string) string {
+	lower := strings.ToLower(s)
+	for singular, plural := range irregularPlurals {
+		if strings.HasSuffix(lower, singular) {
+			start := len(s) - len(singular)
+			if start == 0 || !unicode.IsLetter(rune(lower[start-1])) ||
+				unicode.IsUpper(rune(s[start])) {
+				return s[:start] + matchCase(s[start:], plural)
+			}
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"),
+		strings.HasSuffix(lower, "z"), strings.HasSuffix(lower, "ch"),
+		strings.HasSuffix(lower, "sh"):
+		return s + "es"
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 &&
+		!strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return s[:len(s)-1] + "ies"
+	}
+	return s + "s"
+}
+
+// singularize reverses Pluralize for the last word of s.
+func singularize(s string) string {
+	lower := strings.ToLower(s)
+	for singular, plural := range irregularPlurals {
+		if strings.HasSuffix(lower, plural) {
+			start := len(s) - len(plural)
+			if start == 0 || !unicode.IsLetter(rune(lower[start-1])) ||
+				unicode.IsUpper(rune(s[start])) {
+				return s[:start] + matchCase(s[start:], singular)
+			}
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(lower) > 3:
+		return s[:len(s)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "xes"),
+		strings.HasSuffix(lower, "zes"), strings.HasSuffix(lower, "ches"),
+		strings.HasSuffix(lower, "shes"):
+		return s[:len(s)-2]
+	case strings.HasSuffix(lower, "s") && !strings.HasSuffix(lower, "ss"):
+		return s[:len(s)-1]
+	}
+	return s
+}
+
+// matchCase capitalizes plural like word.
+func matchCase(word, plural string) string {
+	if word != "" && unicode.IsUpper(rune(word[0])) {
+		return strings.ToUpper(plural[:1]) + plural[1:]
+	}
+	return plural
+}
+
+// splitWords splits a Go identifier into lower case words, keeping
+// initialisms together: HTTPRequest becomes "http", "request".
+func splitWords(name string) []string {
+	runes := []rune(name)
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		if unicode.IsUpper(runes[i]) &&
+			(unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			words = append(words, strings.ToLower(string(runes[start:i])))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, strings.ToLower(string(runes[start:])))
+	}
+	return words
+}
+
+// primaryType returns the resource type of the struct t given the
+// arguments of its primary annotation. A nil o names types with
+// PluralKebabCase.
+func (o *tagOptions) primaryType(t reflect.Type, args []string) string {
+	if len(args) > 1 && args[1] != "" {
+		return args[1]
+	}
+	if o == nil || o.typeNaming == nil {
+		return PluralKebabCase(t.Name())
+	}
+	return o.typeNaming(t.Name())
+}
+
+// ResourceType returns the resource type of model, a tagged struct, a
+// pointer to one or a slice of either, as Marshal would write it with
+// opts. It returns "" if model has no primary annotation.
+func ResourceType(model interface{}, opts ...MarshalOption) string {
+	t := reflect.TypeOf(model)
+	if t == nil {
+		return ""
+	}
+	return modelTypeName(t, &newMarshalOptions(opts).tagOptions)
+}
@@ -0,0 +1,88 @@
+package jsonapi
+
+import (
+	"strings"
+	"testing"
+)
+
+type BlogPost struct {
+	ID string `jsonapi:"primary"`
+}
+
+type HTTPCategory struct {
+	ID string `jsonapi:"primary,"`
+}
+
+func TestNamingStrategies(t *testing.T) {
+	tests := []struct {
+		strategy TypeNamingStrategy
+		name     string
+		want     string
+	}{
+		{PluralKebabCase, "BlogPost", "blog-posts"},
+		{PluralKebabCase, "HTTPRequest", "http-requests"},
+		{PluralSnakeCase, "SalesPerson", "sales_people"},
+		{PluralCamelCase, "BlogCategory", "blogCategories"},
+		{PluralCamelCase, "Address", "addresses"},
+	}
+	for _, tt := range tests {
+		if got := tt.strategy(tt.name); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPluralize(t *testing.T) {
+	tests := []struct {
+		singular, plural string
+	}{
+		{"blog", "blogs"},
+		{"box", "boxes"},
+		{"church", "churches"},
+		{"category", "categories"},
+		{"day", "days"},
+		{"person", "people"},
+		{"salesPerson", "salesPeople"},
+		{"Child", "Children"},
+		{"human", "humans"},
+	}
+	for _, tt := range tests {
+		if got := Pluralize(tt.singular); got != tt.plural {
+			t.Errorf("Pluralize(%q) = %q, want %q", tt.singular, got, tt.plural)
+		}
+		if got := singularize(tt.plural); got != tt.singular {
+			t.Errorf("singularize(%q) = %q, want %q", tt.plural, got, tt.singular)
+		}
+	}
+}
+
+func TestResourceTypeNaming(t *testing.T) {
+	if got := ResourceType([]*BlogPost{}); got != "blog-posts" {
+		t.Errorf("ResourceType = %q, want blog-posts", got)
+	}
+	if got := ResourceType(&reqAuthor{}); got != "people" {
+		t.Errorf("explicit type: ResourceType = %q, want people", got)
+	}
+	if got := ResourceType(nil); got != "" {
+		t.Errorf("ResourceType(nil) = %q", got)
+	}
+
+	snake := WithTypeNaming(PluralSnakeCase)
+	if got := ResourceType(HTTPCategory{}, snake); got != "http_categories" {
+		t.Errorf("ResourceType = %q, want http_categories", got)
+	}
+	if got := ResourceType(HTTPCategory{}); got != "http-categories" {
+		t.Errorf("default: ResourceType = %q, want http-categories", got)
+	}
+	if got := marshalDoc(t, &BlogPost{ID: "1"}, snake)["data"].(map[string]interface{})["type"]; got != "blog_posts" {
+		t.Errorf("marshaled type %v, want blog_posts", got)
+	}
+
+	doc := `{"data": {"type": "blog_posts", "id": "1"}}`
+	if err := UnmarshalPayload(strings.NewReader(doc), new(BlogPost), TypeNaming(PluralSnakeCase)); err != nil {
+		t.Errorf("unmarshal with TypeNaming: %v", err)
+	}
+	if err := UnmarshalPayload(strings.NewReader(doc), new(BlogPost)); err == nil {
+		t.Error("unmarshaled blog_posts without TypeNaming")
+	}
+}
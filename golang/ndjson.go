@@ -0,0 +1,106 @@
+package jsonapi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// NDJSONWriter writes resource objects as newline-delimited JSON (JSON
+// Lines): one resource object per line, without the document wrapper, for
+// data pipelines and bulk exports. Related resources appear as resource
+// linkage only; they are never included.
+type NDJSONWriter struct {
+	o   *marshalOptions
+	enc Encoder
+}
+
+// NewNDJSONWriter returns an NDJSONWriter writing to w. opts apply as they
+// would to Marshal, except that indentation is ignored.
+func NewNDJSONWriter(w io.Writer, opts ...MarshalOption) *NDJSONWriter {
+	o := newMarshalOptions(opts)
+	enc := o.codec.NewEncoder(w)
+	enc.SetEscapeHTML(o.escapeHTML)
+	return &NDJSONWriter{o: o, enc: enc}
+}
+
+// Write writes model, a pointer to a tagged struct or a *DynamicResource,
+// as one line. Models skipped by a NodeVisitor are not written.
+func (nw *NDJSONWriter) Write(model interface{}) error {
+	var node *Node
+	o := nw.o.newState()
+	if r, ok := model.(*DynamicResource); ok {
+		n, err := r.Node()
+		if err != nil {
+			return err
+		}
+		nw.o.orderAttributes(n, nil)
+		node = n
+	} else {
+		included := map[string]*Node{}
+		n, err := visitModelNode(model, &included, true, o, "")
+		if err != nil {
+			return err
+		}
+		node = n
+	}
+	if node == nil {
+		return nil
+	}
+
+	if nw.o.links != nil {
+		applyLinks(nw.o.links, node)
+	}
+	if nw.o.deriveLinks {
+		deriveRelationshipLinks(node)
+	}
+	return nw.enc.Encode(node)
+}
+
+// MarshalNDJSON writes models, a slice of struct pointers, as NDJSON with
+// one resource object per line.
+func MarshalNDJSON(w io.Writer, models interface{}, opts ...MarshalOption) error {
+	v := reflect.ValueOf(models)
+	if v.Kind() != reflect.Slice {
+		return ErrExpectedSlice
+	}
+
+	bw := bufio.NewWriter(w)
+	nw := NewNDJSONWriter(bw, opts...)
+	for i := 0; i < v.Len(); i++ {
+		if err := nw.Write(v.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadNDJSON reads resource objects written one per line, as by
+// MarshalNDJSON, and returns them as the primary data of a collection
+// document. Blank lines are skipped. Encode the result, or use
+// NewDynamicResource on its nodes, to turn it back into models.
+func ReadNDJSON(r io.Reader) (*ManyPayload, error) {
+	payload := &ManyPayload{Data: []*Node{}}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		b := bytes.TrimSpace(scanner.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+
+		n := new(Node)
+		if err := defaultCodec.NewDecoder(bytes.NewReader(b)).Decode(n); err != nil {
+			return nil, fmt.Errorf("jsonapi: NDJSON line %d: %w", line, err)
+		}
+		payload.Data = append(payload.Data, n)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
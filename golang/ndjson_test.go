@@ -0,0 +1,100 @@
+package jsonapi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type ndjsonItem struct {
+	ID    string     `jsonapi:"primary,items"`
+	Zeta  string     `jsonapi:"attr,zeta"`
+	Alpha int        `jsonapi:"attr,alpha"`
+	Owner *reqAuthor `jsonapi:"relation,owner"`
+}
+
+func TestMarshalNDJSON(t *testing.T) {
+	items := []*ndjsonItem{
+		{ID: "1", Zeta: "z", Alpha: 1, Owner: &reqAuthor{ID: "9", Name: "Ann"}},
+		{ID: "2", Zeta: "<y>"},
+	}
+	var buf bytes.Buffer
+	if err := MarshalNDJSON(&buf, items, WithAttributeOrder(AttributeDeclarationOrder),
+		WithIndent("", "  "), WithEscapeHTML(false)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"type":"items","id":"1","attributes":{"zeta":"z","alpha":1},"relationships":{"owner":{"data":{"type":"people","id":"9"}}}}
+{"type":"items","id":"2","attributes":{"zeta":"<y>","alpha":0},"relationships":{"owner":{"data":null}}}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%swant\n%s", got, want)
+	}
+
+	if err := MarshalNDJSON(&buf, items[0]); err != ErrExpectedSlice {
+		t.Errorf("error %v, want ErrExpectedSlice", err)
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	skip := func(model interface{}, node *Node, depth int, path string) error {
+		if node.ID == "2" {
+			return ErrSkipNode
+		}
+		return nil
+	}
+	nw := NewNDJSONWriter(&buf, WithNodeVisitor(skip))
+	for _, model := range []interface{}{
+		&ndjsonItem{ID: "1"},
+		&ndjsonItem{ID: "2"},
+		&DynamicResource{Type: "things", ID: "3", Attributes: map[string]interface{}{"a": 1}},
+	} {
+		if err := nw.Write(model); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := nw.Write((*DynamicResource)(nil)); err != ErrNilDynamicResource {
+		t.Errorf("nil resource: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"id":"1"`) || lines[1] != `{"type":"things","id":"3","attributes":{"a":1}}` {
+		t.Errorf("lines %q", lines)
+	}
+}
+
+func TestReadNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	items := []*ndjsonItem{{ID: "1", Zeta: "z", Owner: &reqAuthor{ID: "9"}}, {ID: "2", Alpha: 2}}
+	if err := MarshalNDJSON(&buf, items); err != nil {
+		t.Fatal(err)
+	}
+	buf.WriteString("\n  \n")
+
+	payload, err := ReadNDJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Data) != 2 || payload.Data[0].ID != "1" || payload.Data[1].Attributes["alpha"] != float64(2) {
+		t.Fatalf("data %v", payload.Data)
+	}
+
+	r, err := NewDynamicResource(payload.Data[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner := r.Relationships["owner"]; len(owner.Data) != 1 || owner.Data[0].ID != "9" {
+		t.Errorf("owner %+v", owner)
+	}
+
+	empty, err := ReadNDJSON(strings.NewReader(""))
+	if err != nil || empty.Data == nil || len(empty.Data) != 0 {
+		t.Errorf("empty input: %v, %v", empty, err)
+	}
+
+	_, err = ReadNDJSON(strings.NewReader("{\"type\":\"items\",\"id\":\"1\"}\n\n{oops}\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("error %v, want one for line 3", err)
+	}
+}
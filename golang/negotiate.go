@@ -0,0 +1,159 @@
+package jsonapi
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Media type parameters defined by JSON:API 1.1.
+const (
+	MediaTypeParamExt     = "ext"
+	MediaTypeParamProfile = "profile"
+)
+
+// Errors for the content negotiation failures ContentNegotiation answers,
+// for handlers that negotiate on their own; see StatusForError.
+var (
+	ErrUnsupportedMediaType = errors.New("jsonapi: unsupported media type")
+	ErrNotAcceptable        = errors.New("jsonapi: no acceptable media type")
+)
+
+// NegotiatedMediaType holds the extensions and profiles agreed on for a
+// request by ContentNegotiation.
+type NegotiatedMediaType struct {
+	Ext     []string
+	Profile []string
+}
+
+// String formats n as a Content-Type value for the response.
+func (n NegotiatedMediaType) String() string {
+	params := map[string]string{}
+	if len(n.Ext) > 0 {
+		params[MediaTypeParamExt] = strings.Join(n.Ext, " ")
+	}
+	if len(n.Profile) > 0 {
+		params[MediaTypeParamProfile] = strings.Join(n.Profile, " ")
+	}
+	return mime.FormatMediaType(MediaType, params)
+}
+
+type negotiatedKey struct{}
+
+// MediaTypeFromContext returns the media type negotiated by
+// ContentNegotiation for the request with context ctx.
+func MediaTypeFromContext(ctx context.Context) (NegotiatedMediaType, bool) {
+	n, ok := ctx.Value(negotiatedKey{}).(NegotiatedMediaType)
+	return n, ok
+}
+
+// ContentNegotiation returns middleware enforcing the content negotiation
+// rules of the specification. Requests whose Content-Type is the JSON:API
+// media type with parameters other than ext and profile, or with an
+// extension not in supportedExt, get 415 Unsupported Media Type. Requests
+// whose Accept header lists the JSON:API media type only with such
+// parameters, or that accept neither it nor a wildcard, get 406 Not
+// Acceptable, as a problem document if they accept one, see WriteErrorFor.
+// Otherwise the negotiated media type is stored in the request context,
+// see MediaTypeFromContext.
+func ContentNegotiation(supportedExt ...string) func(http.Handler) http.Handler {
+	supported := make(map[string]bool, len(supportedExt))
+	for _, ext := range supportedExt {
+		supported[ext] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var negotiated NegotiatedMediaType
+
+			if ct := r.Header.Get("Content-Type"); ct != "" {
+				mt, params, err := mime.ParseMediaType(ct)
+				if err == nil && mt == MediaType {
+					ext, profile, ok := mediaTypeParams(params, supported)
+					if !ok {
+						writeNegotiationError(w, r, http.StatusUnsupportedMediaType)
+						return
+					}
+					negotiated = NegotiatedMediaType{Ext: ext, Profile: profile}
+				}
+			}
+
+			if accept := r.Header.Get("Accept"); accept != "" {
+				n, ok := negotiateAccept(accept, supported)
+				if !ok {
+					writeNegotiationError(w, r, http.StatusNotAcceptable)
+					return
+				}
+				if n != nil {
+					negotiated = *n
+				}
+			}
+
+			ctx := context.WithValue(r.Context(), negotiatedKey{}, negotiated)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// negotiateAccept picks the first JSON:API entry of an Accept header that
+// the server can satisfy. It returns nil and true when the header only
+// matches through a wildcard.
+func negotiateAccept(accept string, supported map[string]bool) (*NegotiatedMediaType, bool) {
+	wildcard := false
+	for _, entry := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(entry))
+		if err != nil {
+			continue
+		}
+		if q, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(q, 64); err == nil && f == 0 {
+				continue
+			}
+			delete(params, "q")
+		}
+
+		switch mt {
+		case MediaType:
+			if ext, profile, ok := mediaTypeParams(params, supported); ok {
+				return &NegotiatedMediaType{Ext: ext, Profile: profile}, true
+			}
+		case "*/*", "application/*":
+			wildcard = true
+		}
+	}
+	return nil, wildcard
+}
+
+// mediaTypeParams validates the parameters of a JSON:API media type and
+// returns its extensions and profiles.
+func mediaTypeParams(params map[string]string, supported map[string]bool) ([]string, []string, bool) {
+	var ext, profile []string
+	for name, value := range params {
+		switch name {
+		case MediaTypeParamExt:
+			ext = strings.Fields(value)
+			for _, e := range ext {
+				if !supported[e] {
+					return nil, nil, false
+				}
+			}
+		case MediaTypeParamProfile:
+			profile = strings.Fields(value)
+		default:
+			return nil, nil, false
+		}
+	}
+	return ext, profile, true
+}
+
+// writeNegotiationError answers r with status, as a problem document if
+// the client accepts one rather than a JSON:API document.
+func writeNegotiationError(w http.ResponseWriter, r *http.Request, status int) {
+	_ = WriteErrorFor(w, r, &ErrorObject{
+		Title:  http.StatusText(status),
+		Status: strconv.Itoa(status),
+	})
+}
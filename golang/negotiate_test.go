@@ -0,0 +1,69 @@
+package jsonapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContentNegotiation(t *testing.T) {
+	const atomic = "https://jsonapi.org/ext/atomic"
+
+	tests := []struct {
+		name, contentType, accept string
+		status                    int
+		ext                       []string
+	}{
+		{"no headers", "", "", http.StatusOK, nil},
+		{"plain", MediaType, MediaType, http.StatusOK, nil},
+		{"wildcard", "", "text/html, */*", http.StatusOK, nil},
+		{"supported ext", MediaType + `; ext="` + atomic + `"`, "", http.StatusOK, []string{atomic}},
+		{"accept ext", "", MediaType + `; ext="` + atomic + `"`, http.StatusOK, []string{atomic}},
+		{"unknown param", MediaType + "; charset=utf-8", "", http.StatusUnsupportedMediaType, nil},
+		{"unsupported ext", MediaType + `; ext="https://example.com/x"`, "", http.StatusUnsupportedMediaType, nil},
+		{"other content type", "application/json", "", http.StatusOK, nil},
+		{"only modified", "", MediaType + "; charset=utf-8", http.StatusNotAcceptable, nil},
+		{"refused", "", MediaType + ";q=0", http.StatusNotAcceptable, nil},
+		{"fallback entry", "", MediaType + "; charset=utf-8, " + MediaType, http.StatusOK, nil},
+		{"not acceptable", "", "text/html", http.StatusNotAcceptable, nil},
+	}
+	for _, tt := range tests {
+		var got NegotiatedMediaType
+		h := ContentNegotiation(atomic)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, _ = MediaTypeFromContext(r.Context())
+		}))
+
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		if tt.contentType != "" {
+			r.Header.Set("Content-Type", tt.contentType)
+		}
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.status)
+			continue
+		}
+		if !equalStrings(got.Ext, tt.ext) {
+			t.Errorf("%s: ext %v, want %v", tt.name, got.Ext, tt.ext)
+		}
+	}
+}
+
+func TestNegotiatedMediaTypeString(t *testing.T) {
+	tests := []struct {
+		n    NegotiatedMediaType
+		want string
+	}{
+		{NegotiatedMediaType{}, MediaType},
+		{NegotiatedMediaType{Ext: []string{"a", "b"}, Profile: []string{"p"}}, MediaType + `; ext="a b"; profile=p`},
+	}
+	for _, tt := range tests {
+		if got := tt.n.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
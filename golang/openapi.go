@@ -0,0 +1,95 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// OpenAPIComponents is the components object of an OpenAPI 3.1 document.
+// It marshals to JSON as {"schemas": {...}} and can be merged into a
+// hand-written specification.
+type OpenAPIComponents struct {
+	Schemas map[string]interface{} `json:"schemas"`
+}
+
+// GenerateOpenAPISchema returns OpenAPI 3.1 component schemas for the
+// given models, which must be pointers to tagged structs. Schemas are
+// named after resource types; for a model of type posts it emits:
+//
+//	posts                    the resource object
+//	postsDocument            a document with a single posts resource as primary data
+//	postsCollectionDocument  a document with a list of posts resources as primary data
+//
+// Models reachable through relations get resource object schemas too, and
+// the included member of each document lists them. Shared definitions are
+// emitted as ResourceIdentifier, Links and Meta. Models of the same
+// resource type, and schema names used twice, are reported as errors.
+func GenerateOpenAPISchema(models ...interface{}) (*OpenAPIComponents, error) {
+	return GenerateOpenAPISchemaOptions(nil, models...)
+}
+
+// GenerateOpenAPISchemaOptions is GenerateOpenAPISchema for models
+// marshaled with opts, e.g. WithTagKey, whose members it describes.
+func GenerateOpenAPISchemaOptions(opts []MarshalOption, models ...interface{}) (*OpenAPIComponents, error) {
+	b := newSchemaBuilder("#/components/schemas/", opts)
+	documented := map[string]bool{}
+
+	for _, model := range models {
+		t := reflect.TypeOf(model)
+		if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+			return nil, ErrUnexpectedType
+		}
+		name, err := b.addModel(t)
+		if err != nil {
+			return nil, err
+		}
+		if documented[name] {
+			continue
+		}
+		documented[name] = true
+		for _, doc := range []string{name + "Document", name + "CollectionDocument"} {
+			if _, ok := b.schemas[doc]; ok {
+				return nil, fmt.Errorf("jsonapi: schema name %q of %s is already used", doc, t.Elem())
+			}
+		}
+
+		var included interface{}
+		if related := b.reachable(name); len(related) > 0 {
+			refs := make([]interface{}, len(related))
+			for i, r := range related {
+				refs[i] = b.ref(r)
+			}
+			included = map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"oneOf": refs},
+			}
+		}
+
+		b.schemas[name+"Document"] = documentSchema(b, map[string]interface{}{
+			"oneOf": []interface{}{b.ref(name), map[string]interface{}{"type": "null"}},
+		}, included)
+		b.schemas[name+"CollectionDocument"] = documentSchema(b, map[string]interface{}{
+			"type":  "array",
+			"items": b.ref(name),
+		}, included)
+	}
+
+	return &OpenAPIComponents{Schemas: b.schemas}, nil
+}
+
+func documentSchema(b *schemaBuilder, data, included interface{}) map[string]interface{} {
+	properties := map[string]interface{}{
+		"data":  data,
+		"links": b.ref("Links"),
+		"meta":  b.ref("Meta"),
+	}
+	if included != nil {
+		properties["included"] = included
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"required":   []string{"data"},
+		"properties": properties,
+	}
+}
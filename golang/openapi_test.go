@@ -0,0 +1,112 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type schemaAuthor struct {
+	ID   string `jsonapi:"primary,schema-people"`
+	Name string `jsonapi:"attr,name"`
+}
+
+type schemaPost struct {
+	ID      string          `jsonapi:"primary,schema-posts"`
+	Title   string          `jsonapi:"attr,title"`
+	Body    *string         `jsonapi:"attr,body"`
+	Created time.Time       `jsonapi:"attr,created,iso8601"`
+	Tags    []string        `jsonapi:"attr,tags,omitempty"`
+	Author  *schemaAuthor   `jsonapi:"relation,author"`
+	Editors []*schemaAuthor `jsonapi:"relation,editors"`
+}
+
+func TestGenerateOpenAPISchema(t *testing.T) {
+	c, err := GenerateOpenAPISchema(new(schemaPost), new(schemaPost))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{
+		"schema-posts", "schema-postsDocument", "schema-postsCollectionDocument",
+		"schema-people", "ResourceIdentifier", "Links", "Meta",
+	} {
+		if c.Schemas[name] == nil {
+			t.Errorf("missing schema %s", name)
+		}
+	}
+
+	b, err := json.Marshal(c.Schemas["schema-posts"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"const":"schema-posts"`,
+		`"required":["title"]`,
+		`"body":{"type":["string","null"]}`,
+		`"created":{"format":"date-time","type":"string"}`,
+		`"editors":{"properties":{"data":{"items":{"$ref":"#/components/schemas/ResourceIdentifier"},"type":"array"}`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("resource schema lacks %s:\n%s", want, b)
+		}
+	}
+
+	b, err = json.Marshal(c.Schemas["schema-postsDocument"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"included":{"items":{"oneOf":[{"$ref":"#/components/schemas/schema-people"}]}`) {
+		t.Errorf("document schema does not list the included people:\n%s", b)
+	}
+}
+
+func TestGenerateOpenAPISchemaSameGoNames(t *testing.T) {
+	// Types of the same name, as from different packages, keep separate
+	// schemas as long as their resource types differ.
+	a := func() interface{} {
+		type Post struct {
+			ID string `jsonapi:"primary,blog-posts"`
+		}
+		return new(Post)
+	}()
+	b := func() interface{} {
+		type Post struct {
+			ID string `jsonapi:"primary,forum-posts"`
+		}
+		return new(Post)
+	}()
+
+	c, err := GenerateOpenAPISchema(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Schemas["blog-posts"] == nil || c.Schemas["forum-posts"] == nil {
+		t.Errorf("schemas overwritten: %v", c.Schemas)
+	}
+}
+
+func TestGenerateOpenAPISchemaCollisions(t *testing.T) {
+	type otherAuthor struct {
+		ID string `jsonapi:"primary,schema-people"`
+	}
+	type links struct {
+		ID string `jsonapi:"primary,Links"`
+	}
+	type document struct {
+		ID string `jsonapi:"primary,schema-postsDocument"`
+	}
+
+	tests := map[string][]interface{}{
+		"same resource type": {new(schemaPost), new(otherAuthor)},
+		"shared schema":      {new(links)},
+		"document name":      {new(schemaPost), new(document)},
+		"not a model":        {schemaPost{}},
+		"no primary":         {new(struct{ Name string })},
+	}
+	for name, models := range tests {
+		if _, err := GenerateOpenAPISchema(models...); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
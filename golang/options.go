@@ -0,0 +1,258 @@
+package jsonapi
+
+import (
+	"io"
+	"reflect"
+	"strings"
+)
+
+// MarshalOption configures a single Marshal or MarshalPayload call.
+type MarshalOption func(*marshalOptions)
+
+type marshalOptions struct {
+	links         LinkResolver
+	deriveLinks   bool
+	include       map[string]bool
+	includedOrder IncludedOrder
+	paginator     Paginator
+	visitor       NodeVisitor
+	transformer   Transformer
+	itemDecorator ItemDecorator
+	emptyToMany   EmptyToMany
+
+	// attribute order; see WithAttributeOrder
+	attributeOrder AttributeOrder
+
+	// member name checks; see WithMemberNameWarnings
+	memberNamesLax bool
+	memberNameWarn func(error)
+	version        string
+
+	hooks       hooks
+	denormalize bool
+
+	// base URL of the describedby link; see WithDescribedBy
+	describedBy string
+
+	// how struct tags are read; see tagOptions
+	tagOptions
+
+	// included deduplication; see WithoutIncludedDedup
+	noIncludedDedup  bool
+	deduplicatedMeta bool
+
+	// encoder settings used by MarshalPayload and MarshalBytes
+	codec             Codec
+	prefix, indent    string
+	escapeHTML        bool
+	noTrailingNewline bool
+}
+
+func newMarshalOptions(opts []MarshalOption) *marshalOptions {
+	o := &marshalOptions{codec: defaultCodec, escapeHTML: true}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// marshalState is the state of one traversal of a model graph, and what
+// encoding the resulting document needs to know about it. marshalOptions
+// are only read once built, so that a Builder or an NDJSONWriter can share
+// them between goroutines; every traversal gets a fresh marshalState.
+type marshalState struct {
+	*marshalOptions
+
+	// Nodes built so far; see identityMap
+	identity identityMap
+	// struct types whose member names were checked; see checkMemberNames
+	memberNamesChecked map[reflect.Type]bool
+}
+
+// newState starts a traversal with the options o.
+func (o *marshalOptions) newState() *marshalState {
+	return &marshalState{marshalOptions: o}
+}
+
+// WithLinkResolver makes Marshal generate resource, relationship and
+// collection links using r. Links returned by Linkable and
+// RelationshipLinkable models take precedence over generated ones.
+func WithLinkResolver(r LinkResolver) MarshalOption {
+	return func(o *marshalOptions) {
+		o.links = r
+	}
+}
+
+// WithBaseURL is shorthand for WithLinkResolver with the default
+// LinkTemplates rooted at baseURL, e.g. "https://api.example.com/v1".
+func WithBaseURL(baseURL string) MarshalOption {
+	return WithLinkResolver(&LinkTemplates{BaseURL: baseURL})
+}
+
+// WithDerivedRelationshipLinks makes Marshal synthesize the links of
+// relationships that have none from the self link of their resource,
+// following the URL conventions of the specification: {self}/{relation}
+// for related and {self}/relationships/{relation} for self. Resources
+// without a self link, from Linkable or WithLinkResolver, are left alone.
+func WithDerivedRelationshipLinks() MarshalOption {
+	return func(o *marshalOptions) {
+		o.deriveLinks = true
+	}
+}
+
+// WithInclude restricts the included section of the document to the given
+// relationship paths, as found in the include query parameter, e.g.
+// "author" or "comments.author". Intermediate resources of a nested path
+// are included too. Without this option every related resource is
+// included.
+//
+// Relation fields on a requested path that are nil are loaded through
+// RelationshipResolver when the model implements it.
+func WithInclude(paths ...string) MarshalOption {
+	return func(o *marshalOptions) {
+		o.include = make(map[string]bool, len(paths))
+		for _, p := range paths {
+			o.include[p] = true
+		}
+	}
+}
+
+// WithIndent makes MarshalPayload and MarshalBytes pretty-print the
+// document, as json.Encoder.SetIndent does. Useful when debugging.
+func WithIndent(prefix, indent string) MarshalOption {
+	return func(o *marshalOptions) {
+		o.prefix = prefix
+		o.indent = indent
+	}
+}
+
+// WithEscapeHTML sets whether <, > and & are escaped inside JSON strings.
+// The default, as for encoding/json, is true.
+func WithEscapeHTML(on bool) MarshalOption {
+	return func(o *marshalOptions) {
+		o.escapeHTML = on
+	}
+}
+
+// WithoutTrailingNewline stops MarshalPayload from terminating the
+// document with the newline json.Encoder writes.
+func WithoutTrailingNewline() MarshalOption {
+	return func(o *marshalOptions) {
+		o.noTrailingNewline = true
+	}
+}
+
+// includes reports whether the resources found at the relationship path
+// should be sideloaded.
+func (o *marshalOptions) includes(path string) bool {
+	if o.include == nil || o.include[path] {
+		return true
+	}
+	for p := range o.include {
+		if strings.HasPrefix(p, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmarshalOption configures a single UnmarshalPayload or
+// UnmarshalManyPayload call.
+type UnmarshalOption func(*unmarshalOptions)
+
+type unmarshalOptions struct {
+	// how struct tags are read; see tagOptions
+	tagOptions
+
+	disallowUnknown bool
+	transformer     Transformer
+	useNumber       bool
+	interner        interner
+	codec           Codec
+	version         string
+	hooks           hooks
+	ignoreReadOnly  bool
+	validate        bool
+	included        *Included
+	identities      *[]ResourceIdentity
+	merge           bool
+
+	// document limits
+	maxBodyBytes                     int64
+	maxIncluded, maxFanout, maxDepth int
+}
+
+func newUnmarshalOptions(opts []UnmarshalOption) *unmarshalOptions {
+	o := &unmarshalOptions{codec: defaultCodec}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// DisallowUnknownFields makes unmarshaling fail with an *UnknownFieldsError
+// when a resource carries attributes or relationships that have no tagged
+// field on the target struct, the equivalent of
+// json.Decoder.DisallowUnknownFields.
+func DisallowUnknownFields() UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.disallowUnknown = true
+	}
+}
+
+// UseNumber makes unmarshaling decode JSON numbers as json.Number instead
+// of float64, as json.Decoder.UseNumber does. interface{} attributes, and
+// interface{} values inside map and struct attributes, then receive
+// json.Number, so that large integer IDs and monetary amounts keep their
+// precision. Numeric fields are parsed exactly either way.
+func UseNumber() UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.useNumber = true
+	}
+}
+
+// decode reads a JSON value from in into v, applying MaxBodyBytes,
+// UseNumber and InternStrings.
+func (o *unmarshalOptions) decode(in io.Reader, v interface{}) error {
+	return o.decodeValue(in, v, o.useNumber)
+}
+
+// decodeNodes is decode for documents whose resources are stored in
+// models. Numbers are always kept as json.Number so that numeric fields
+// are parsed from their text; floatNumbers turns them into float64 where
+// decoded values are handed out without UseNumber.
+func (o *unmarshalOptions) decodeNodes(in io.Reader, v interface{}) error {
+	return o.decodeValue(in, v, true)
+}
+
+func (o *unmarshalOptions) decodeValue(in io.Reader, v interface{}, useNumber bool) error {
+	body := o.body(in)
+	dec := o.codec.NewDecoder(body)
+	if useNumber {
+		dec.UseNumber()
+	}
+	target, storeLIDs := withLIDs(v)
+	if err := dec.Decode(target); err != nil {
+		// Codecs other than encoding/json may not pass the error of the
+		// reader on.
+		if l, ok := body.(*limitedReader); ok && l.n < 0 {
+			return ErrBodyTooLarge
+		}
+		return err
+	}
+	storeLIDs()
+
+	if o.interner != nil {
+		switch v := v.(type) {
+		case *OnePayload:
+			o.interner.nodes(v.Data)
+			o.interner.nodes(v.Included...)
+		case *ManyPayload:
+			o.interner.nodes(v.Data...)
+			o.interner.nodes(v.Included...)
+		case *Node:
+			o.interner.nodes(v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package jsonapi
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// IncludedOrder selects how the included section of a marshaled document
+// is ordered. Relationship data always keeps the order of the relation
+// field.
+type IncludedOrder int
+
+const (
+	// IncludedDocumentOrder lists included resources in the order they are
+	// first referenced, walking relationships breadth-first from the primary
+	// data and taking relationships of a resource by name. This is the
+	// default.
+	IncludedDocumentOrder IncludedOrder = iota
+	// IncludedSorted lists included resources sorted by type, then id.
+	IncludedSorted
+)
+
+// WithIncludedOrder sets the ordering strategy of the included section.
+func WithIncludedOrder(order IncludedOrder) MarshalOption {
+	return func(o *marshalOptions) {
+		o.includedOrder = order
+	}
+}
+
+// orderIncluded returns the values of included in the order chosen by o.
+// roots is the primary data of the document.
+func orderIncluded(included map[string]*Node, o *marshalOptions, roots ...*Node) []*Node {
+	if o.includedOrder == IncludedSorted {
+		nodes := nodeMapValues(&included)
+		sortNodes(nodes)
+		return nodes
+	}
+
+	nodes := make([]*Node, 0, len(included))
+	seen := make(map[string]bool, len(included))
+	queue := append([]*Node(nil), roots...)
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if n == nil {
+			continue
+		}
+
+		names := make([]string, 0, len(n.Relationships))
+		for name := range n.Relationships {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			for _, ref := range relationshipData(n.Relationships[name]) {
+				key := nodeKey(ref)
+				full, ok := included[key]
+				if !ok || seen[key] {
+					continue
+				}
+				seen[key] = true
+				nodes = append(nodes, full)
+				queue = append(queue, full)
+			}
+		}
+	}
+
+	// Anything left was not reachable from the primary data; keep it, in
+	// a stable order.
+	if len(nodes) < len(included) {
+		var rest []*Node
+		for key, n := range included {
+			if !seen[key] {
+				rest = append(rest, n)
+			}
+		}
+		sortNodes(rest)
+		nodes = append(nodes, rest...)
+	}
+
+	return nodes
+}
+
+// relationshipData returns the resource linkage of a relationship built by
+// visitModelNode.
+func relationshipData(rel interface{}) []*Node {
+	switch rel := rel.(type) {
+	case *RelationshipOneNode:
+		if rel.Data != nil {
+			return []*Node{rel.Data}
+		}
+	case *RelationshipManyNode:
+		return rel.Data
+	}
+	return nil
+}
+
+// AttributeOrder selects how the members of attributes objects are ordered
+// in the resource objects built by Marshal and the functions using it. The
+// order is kept on the nodes, so it holds whatever encodes them, e.g.
+// json.Marshal or the render package.
+type AttributeOrder int
+
+const (
+	// AttributeCodecOrder leaves attributes, held in a map, to the codec:
+	// encoding/json sorts them by name, other codecs may not. This is the
+	// default.
+	AttributeCodecOrder AttributeOrder = iota
+	// AttributeDeclarationOrder lists attributes in the order of the
+	// struct fields, followed by computed attributes sorted by name.
+	AttributeDeclarationOrder
+	// AttributeSorted lists attributes sorted by name, whatever the codec.
+	AttributeSorted
+)
+
+// WithAttributeOrder sets the ordering of attributes objects, making the
+// bytes of a document stable with any codec.
+func WithAttributeOrder(order AttributeOrder) MarshalOption {
+	return func(o *marshalOptions) {
+		o.attributeOrder = order
+	}
+}
+
+// attributeOrder is the order Marshal chose for the attributes of a node:
+// the members named in names first, then the others sorted by name. Values
+// are encoded with the codec and HTML escaping of o.
+type attributeOrder struct {
+	names []string
+	o     *marshalOptions
+}
+
+// orderAttributes makes node, the resource object of a model of type t or
+// of a DynamicResource when t is nil, write its attributes in the order
+// chosen by o whatever encodes it.
+func (o *marshalOptions) orderAttributes(node *Node, t reflect.Type) {
+	if o.attributeOrder == AttributeCodecOrder || len(node.Attributes) == 0 {
+		return
+	}
+	order := &attributeOrder{o: o}
+	if o.attributeOrder == AttributeDeclarationOrder && t != nil {
+		order.names = make([]string, 0, len(node.Attributes))
+		for i := 0; i < t.NumField(); i++ {
+			args := strings.Split(renameReserved(fieldTag(t.Field(i), o.tagKey, o.jsonFallback), o.reservedPrefix),
+				annotationSeperator)
+			if len(args) > 1 && args[0] == annotationAttribute {
+				order.names = append(order.names, args[1])
+			}
+		}
+	}
+	node.attributeOrder = order
+}
+
+// plainNode has the fields of Node without its MarshalJSON method.
+type plainNode Node
+
+// MarshalJSON writes n with its attributes in the order Marshal chose for
+// it under WithAttributeOrder. Other nodes are written as a plain struct
+// with the codec selected by build tags, leaving HTML escaping to the
+// outer encoder. Every member is spelled out rather than shadowed on an
+// embedded struct, which not all codecs resolve.
+func (n *Node) MarshalJSON() ([]byte, error) {
+	if n == nil {
+		return []byte("null"), nil
+	}
+	if n.attributeOrder == nil {
+		var buf bytes.Buffer
+		enc := defaultCodec.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode((*plainNode)(n)); err != nil {
+			return nil, err
+		}
+		return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+	}
+
+	aux := struct {
+		Type          string                 `json:"type"`
+		ID            string                 `json:"id,omitempty"`
+		ClientID      string                 `json:"client-id,omitempty"`
+		Attributes    *orderedAttributes     `json:"attributes,omitempty"`
+		Relationships map[string]interface{} `json:"relationships,omitempty"`
+		Links         *Links                 `json:"links,omitempty"`
+		Meta          *Meta                  `json:"meta,omitempty"`
+	}{
+		Type:          n.Type,
+		ID:            n.ID,
+		ClientID:      n.ClientID,
+		Relationships: n.Relationships,
+		Links:         n.Links,
+		Meta:          n.Meta,
+	}
+	if len(n.Attributes) > 0 {
+		aux.Attributes = &orderedAttributes{n.attributeOrder.names, n.Attributes, n.attributeOrder.o}
+	}
+	return n.attributeOrder.o.encodeJSON(aux)
+}
+
+// orderedAttributes encodes values with the members listed in names
+// first, in that order, and the others sorted by name.
+type orderedAttributes struct {
+	names  []string
+	values map[string]interface{}
+	o      *marshalOptions
+}
+
+func (a *orderedAttributes) MarshalJSON() ([]byte, error) {
+	names := make([]string, 0, len(a.values))
+	written := make(map[string]bool, len(a.values))
+	for _, name := range a.names {
+		if _, ok := a.values[name]; ok && !written[name] {
+			names = append(names, name)
+			written[name] = true
+		}
+	}
+	rest := make([]string, 0, len(a.values)-len(names))
+	for name := range a.values {
+		if !written[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	names = append(names, rest...)
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := a.o.encodeJSON(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := a.o.encodeJSON(a.values[name])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// encodeJSON encodes v with the codec and HTML escaping of o, without
+// indentation, which the outer encoder applies.
+func (o *marshalOptions) encodeJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := o.codec.NewEncoder(&buf)
+	enc.SetEscapeHTML(o.escapeHTML)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,151 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type orderPerson struct {
+	ID string `jsonapi:"primary,people"`
+}
+
+type orderComment struct {
+	ID     string       `jsonapi:"primary,comments"`
+	Author *orderPerson `jsonapi:"relation,author"`
+}
+
+type orderPost struct {
+	ID       string          `jsonapi:"primary,posts"`
+	Comments []*orderComment `jsonapi:"relation,comments"`
+	Author   *orderPerson    `jsonapi:"relation,author"`
+}
+
+// includedKeys returns the type and id of each included resource of the
+// document marshaled from v.
+func includedKeys(t *testing.T, v interface{}, opts ...MarshalOption) []string {
+	t.Helper()
+	doc := marshalDoc(t, v, opts...)
+	var keys []string
+	for _, r := range doc["included"].([]interface{}) {
+		r := r.(map[string]interface{})
+		keys = append(keys, r["type"].(string)+"/"+r["id"].(string))
+	}
+	return keys
+}
+
+func TestIncludedOrder(t *testing.T) {
+	post := &orderPost{
+		ID:     "1",
+		Author: &orderPerson{ID: "9"},
+		Comments: []*orderComment{
+			{ID: "b", Author: &orderPerson{ID: "3"}},
+			{ID: "a", Author: &orderPerson{ID: "9"}},
+		},
+	}
+
+	tests := []struct {
+		order IncludedOrder
+		want  []string
+	}{
+		{IncludedDocumentOrder, []string{"people/9", "comments/b", "comments/a", "people/3"}},
+		{IncludedSorted, []string{"comments/a", "comments/b", "people/3", "people/9"}},
+	}
+	for _, tt := range tests {
+		// Ordering must not depend on map iteration.
+		for i := 0; i < 10; i++ {
+			got := includedKeys(t, post, WithIncludedOrder(tt.order))
+			if !equalStrings(got, tt.want) {
+				t.Fatalf("order %d: included %v, want %v", tt.order, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestIncludedOrderCollection(t *testing.T) {
+	posts := []*orderPost{
+		{ID: "1", Author: &orderPerson{ID: "7"}},
+		{ID: "2", Author: &orderPerson{ID: "5"}},
+	}
+	if got, want := includedKeys(t, posts), []string{"people/7", "people/5"}; !equalStrings(got, want) {
+		t.Errorf("included %v, want %v", got, want)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAttributeOrderKeptByAnyEncoder(t *testing.T) {
+	payload, err := Marshal(&ndjsonItem{ID: "1", Zeta: "z", Alpha: 1}, WithAttributeOrder(AttributeDeclarationOrder))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The payload is encoded without the option, as render and ws do.
+	var buf bytes.Buffer
+	if err := encodePayload(&buf, payload, newMarshalOptions(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"attributes":{"zeta":"z","alpha":1}`) {
+		t.Errorf("document %s, want attributes in field order", buf.String())
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"attributes":{"zeta":"z","alpha":1}`) {
+		t.Errorf("json.Marshal: document %s, want attributes in field order", b)
+	}
+}
+
+// ndjsonItemReversed is another model of the items type, with its
+// attributes declared the other way round.
+type ndjsonItemReversed struct {
+	ID    string `jsonapi:"primary,items"`
+	Alpha int    `jsonapi:"attr,alpha"`
+	Zeta  string `jsonapi:"attr,zeta"`
+}
+
+func TestAttributeOrderPerPayload(t *testing.T) {
+	opt := WithAttributeOrder(AttributeDeclarationOrder)
+	payload, err := Marshal(&ndjsonItem{ID: "1", Zeta: "z", Alpha: 1}, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A model of the same type marshaled meanwhile does not change the
+	// order of the first payload.
+	if _, err := Marshal(&ndjsonItemReversed{ID: "2", Zeta: "z", Alpha: 1}, opt); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := encodePayload(&buf, payload, newMarshalOptions(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"attributes":{"zeta":"z","alpha":1}`) {
+		t.Errorf("document %s, want attributes in the field order of ndjsonItem", buf.String())
+	}
+
+	b := NewBuilder(opt)
+	if err := b.Add(&ndjsonItemReversed{ID: "3", Zeta: "z", Alpha: 1}); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := encodePayload(&buf, b.Payload(), newMarshalOptions(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"attributes":{"alpha":1,"zeta":"z"}`) {
+		t.Errorf("document %s, want attributes in the field order of ndjsonItemReversed", buf.String())
+	}
+}
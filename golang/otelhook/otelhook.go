@@ -0,0 +1,117 @@
+//go:build otel
+// +build otel
+
+// Package otelhook traces jsonapi marshaling and unmarshaling with
+// OpenTelemetry. Each call becomes a span named jsonapi.Marshal or
+// jsonapi.Unmarshal carrying the resource type, the number of primary and
+// included resources, the document size and the number of resource
+// objects built per type:
+//
+//	h := otelhook.New(r.Context(), otel.Tracer("api"))
+//	err := jsonapi.MarshalPayload(w, posts, jsonapi.WithHook(h))
+//
+// The package is built with the otel build tag so that the jsonapi module
+// does not depend on OpenTelemetry.
+package otelhook
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	jsonapi "test3"
+)
+
+// Attribute keys set on spans.
+const (
+	KeyType      = attribute.Key("jsonapi.type")
+	KeyResources = attribute.Key("jsonapi.resources")
+	KeyIncluded  = attribute.Key("jsonapi.included")
+	KeyBytes     = attribute.Key("jsonapi.bytes")
+)
+
+// Hook is a jsonapi.Hook recording spans as children of the span of the
+// context it was created with. Calls using the same Hook must not overlap,
+// so create one per request.
+type Hook struct {
+	ctx    context.Context
+	tracer trace.Tracer
+
+	mu    sync.Mutex
+	span  trace.Span
+	nodes map[string]int
+}
+
+// New returns a Hook starting spans from ctx with tracer.
+func New(ctx context.Context, tracer trace.Tracer) *Hook {
+	return &Hook{ctx: ctx, tracer: tracer}
+}
+
+func (h *Hook) start(name, typ string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, h.span = h.tracer.Start(h.ctx, name, trace.WithAttributes(KeyType.String(typ)))
+	h.nodes = map[string]int{}
+}
+
+func (h *Hook) end(s *jsonapi.HookStats, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.span == nil {
+		return
+	}
+	h.span.SetAttributes(
+		KeyResources.Int(s.Resources),
+		KeyIncluded.Int(s.Included),
+		KeyBytes.Int64(s.Bytes),
+	)
+	for typ, n := range h.nodes {
+		h.span.SetAttributes(attribute.Int("jsonapi.nodes."+typ, n))
+	}
+	if err != nil {
+		h.span.RecordError(err)
+		h.span.SetStatus(codes.Error, err.Error())
+	}
+	h.span.End()
+	h.span = nil
+}
+
+// OnMarshalStart implements jsonapi.Hook.
+func (h *Hook) OnMarshalStart(typ string) {
+	h.start("jsonapi.Marshal", typ)
+}
+
+// OnMarshalEnd implements jsonapi.Hook.
+func (h *Hook) OnMarshalEnd(s *jsonapi.HookStats, err error) {
+	h.end(s, err)
+}
+
+// OnNodeVisited implements jsonapi.Hook.
+func (h *Hook) OnNodeVisited(n *jsonapi.Node) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.nodes != nil {
+		h.nodes[n.Type]++
+	}
+}
+
+// OnUnmarshalStart implements jsonapi.Hook.
+func (h *Hook) OnUnmarshalStart(typ string) {
+	h.start("jsonapi.Unmarshal", typ)
+}
+
+// OnUnmarshalEnd implements jsonapi.Hook.
+func (h *Hook) OnUnmarshalEnd(s *jsonapi.HookStats) {
+	h.end(s, nil)
+}
+
+// OnUnmarshalError implements jsonapi.Hook.
+func (h *Hook) OnUnmarshalError(s *jsonapi.HookStats, err error) {
+	h.end(s, err)
+}
@@ -0,0 +1,79 @@
+//go:build otel
+// +build otel
+
+package otelhook
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	jsonapi "test3"
+)
+
+type author struct {
+	ID string `jsonapi:"primary,people"`
+}
+
+type post struct {
+	ID     string  `jsonapi:"primary,posts"`
+	Title  string  `jsonapi:"attr,title"`
+	Author *author `jsonapi:"relation,author"`
+}
+
+func attributes(s sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	m := map[attribute.Key]attribute.Value{}
+	for _, kv := range s.Attributes() {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestHook(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)).Tracer("test")
+	h := New(context.Background(), tracer)
+
+	var buf bytes.Buffer
+	if err := jsonapi.MarshalPayload(&buf, &post{ID: "1", Author: &author{ID: "9"}}, jsonapi.WithHook(h)); err != nil {
+		t.Fatal(err)
+	}
+	if err := jsonapi.UnmarshalPayload(strings.NewReader(`{"data": `), new(post), jsonapi.UseHook(h)); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("%d spans, want 2", len(spans))
+	}
+
+	marshal := spans[0]
+	attrs := attributes(marshal)
+	if marshal.Name() != "jsonapi.Marshal" || attrs[KeyType].AsString() != "posts" ||
+		attrs[KeyResources].AsInt64() != 1 || attrs[KeyIncluded].AsInt64() != 1 ||
+		attrs[KeyBytes].AsInt64() != int64(buf.Len()) ||
+		attrs["jsonapi.nodes.posts"].AsInt64() != 1 || attrs["jsonapi.nodes.people"].AsInt64() != 1 {
+		t.Errorf("marshal span %s: %v", marshal.Name(), attrs)
+	}
+	if marshal.Status().Code == codes.Error {
+		t.Errorf("marshal status %v", marshal.Status())
+	}
+
+	unmarshal := spans[1]
+	if unmarshal.Name() != "jsonapi.Unmarshal" || unmarshal.Status().Code != codes.Error || len(unmarshal.Events()) != 1 {
+		t.Errorf("unmarshal span %s: status %v, events %v", unmarshal.Name(), unmarshal.Status(), unmarshal.Events())
+	}
+}
+
+func TestHookEndWithoutStart(t *testing.T) {
+	h := New(context.Background(), sdktrace.NewTracerProvider().Tracer("test"))
+	// Neither call may panic.
+	h.OnNodeVisited(&jsonapi.Node{Type: "posts"})
+	h.OnMarshalEnd(&jsonapi.HookStats{}, nil)
+}
@@ -0,0 +1,277 @@
+package jsonapi
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Paginator describes the position of a collection page so that
+// MarshalCollection can link to the pages around it. PageParams returns
+// the page[KEY] parameters, keyed by KEY, of the first, previous, next and
+// last pages; nil for pages that do not exist.
+type Paginator interface {
+	PageParams() (first, prev, next, last map[string]string)
+}
+
+// NumberPaginator paginates with page[number] (starting at 1) and
+// page[size]. Total is the number of resources in the collection, nil when
+// it is unknown, in which case there is no last link and a next link is
+// given as long as the page is full, as reported by Full.
+type NumberPaginator struct {
+	Number, Size int
+	Total        *int
+	Full         bool
+}
+
+// PageParams implements Paginator.
+func (p NumberPaginator) PageParams() (first, prev, next, last map[string]string) {
+	page := func(n int) map[string]string {
+		return map[string]string{"number": strconv.Itoa(n), "size": strconv.Itoa(p.Size)}
+	}
+	if p.Size <= 0 {
+		return nil, nil, nil, nil
+	}
+	number := p.Number
+	if number < 1 {
+		number = 1
+	}
+
+	first = page(1)
+	if number > 1 {
+		prev = page(number - 1)
+	}
+	if p.Total != nil {
+		pages := (*p.Total + p.Size - 1) / p.Size
+		if pages < 1 {
+			pages = 1
+		}
+		last = page(pages)
+		if number < pages {
+			next = page(number + 1)
+		}
+	} else if p.Full {
+		next = page(number + 1)
+	}
+	return first, prev, next, last
+}
+
+// OffsetPaginator paginates with page[offset] and page[limit]. Total and
+// Full behave as for NumberPaginator.
+type OffsetPaginator struct {
+	Offset, Limit int
+	Total         *int
+	Full          bool
+}
+
+// PageParams implements Paginator.
+func (p OffsetPaginator) PageParams() (first, prev, next, last map[string]string) {
+	page := func(offset int) map[string]string {
+		return map[string]string{"offset": strconv.Itoa(offset), "limit": strconv.Itoa(p.Limit)}
+	}
+	if p.Limit <= 0 {
+		return nil, nil, nil, nil
+	}
+
+	first = page(0)
+	if p.Offset > 0 {
+		prevOffset := p.Offset - p.Limit
+		if prevOffset < 0 {
+			prevOffset = 0
+		}
+		prev = page(prevOffset)
+	}
+	if p.Total != nil {
+		lastOffset := 0
+		if *p.Total > 0 {
+			lastOffset = (*p.Total - 1) / p.Limit * p.Limit
+		}
+		last = page(lastOffset)
+		if p.Offset+p.Limit < *p.Total {
+			next = page(p.Offset + p.Limit)
+		}
+	} else if p.Full {
+		next = page(p.Offset + p.Limit)
+	}
+	return first, prev, next, last
+}
+
+// WithPaginator makes MarshalCollection add first, prev, next and last
+// links for the page described by p.
+func WithPaginator(p Paginator) MarshalOption {
+	return func(o *marshalOptions) {
+		o.paginator = p
+	}
+}
+
+// MarshalCollection writes models, a slice of struct pointers, as the
+// response to r. The top-level self link is the absolute request URL, see
+// requestURL, and, with WithPaginator, pagination links are derived from
+// it, keeping every query parameter other than page[KEY]. Links returned by
+// a Linkable collection take precedence.
+func MarshalCollection(w http.ResponseWriter, r *http.Request, models interface{},
+	opts ...MarshalOption) error {
+	if reflect.ValueOf(models).Kind() != reflect.Slice {
+		return ErrExpectedSlice
+	}
+	o := newMarshalOptions(opts)
+
+	p, err := Marshal(models, opts...)
+	if err != nil {
+		return err
+	}
+	payload, ok := p.(*ManyPayload)
+	if !ok {
+		return ErrExpectedSlice
+	}
+
+	self := requestURL(r)
+	links := Links{KeySelfLink: self.String()}
+	if o.paginator != nil {
+		for key, href := range pageLinks(self, o.paginator) {
+			links[key] = href
+		}
+	}
+	payload.Links = mergeLinks(payload.Links, links)
+
+	w.Header().Set("Content-Type", MediaType)
+	return encodePayload(w, payload, o)
+}
+
+// requestURL returns the URL of r made absolute with its Host header and
+// the scheme it was received with. Headers set by proxies, such as
+// X-Forwarded-Proto, are not trusted; a request without a host keeps its
+// relative URL.
+func requestURL(r *http.Request) *url.URL {
+	u := *r.URL
+	if u.Host == "" && r.Host != "" {
+		u.Host = r.Host
+		u.Scheme = "http"
+		if r.TLS != nil {
+			u.Scheme = "https"
+		}
+	}
+	return &u
+}
+
+// pageLinks returns the first, prev, next and last links of the page
+// described by p, derived from u.
+func pageLinks(u *url.URL, p Paginator) Links {
+	links := Links{}
+	first, prev, next, last := p.PageParams()
+	for key, params := range map[string]map[string]string{
+		KeyFirstPage:    first,
+		KeyPreviousPage: prev,
+		KeyNextPage:     next,
+		KeyLastPage:     last,
+	} {
+		if params != nil {
+			links[key] = pageURL(u, params)
+		}
+	}
+	return links
+}
+
+// pageURL returns u with its page[KEY] parameters replaced by params.
+func pageURL(u *url.URL, params map[string]string) string {
+	q := u.Query()
+	for key := range q {
+		if strings.HasPrefix(key, "page[") {
+			q.Del(key)
+		}
+	}
+	for key, value := range params {
+		q.Set("page["+key+"]", value)
+	}
+
+	page := *u
+	page.RawQuery = q.Encode()
+	return page.String()
+}
+
+// KeyTotalMeta is the relationship meta member holding the number of
+// related resources of a paginated to-many relationship; see
+// RelationshipPaginator.
+const KeyTotalMeta = "total"
+
+// RelationshipPaginator lets a model paginate a to-many relationship whose
+// relation field holds one page of the related resources, the others being
+// served by the related endpoint. JSONAPIRelationshipPaginator returns the
+// Paginator of that page, or nil if relation is not paginated.
+//
+// The first, prev, next and last links of the relationship are derived
+// from its related link, given by RelationshipLinkable or WithLinkResolver,
+// as MarshalCollection derives them from the request URL. When the
+// Paginator is a NumberPaginator or an OffsetPaginator with a known Total,
+// the total is added to the relationship meta under KeyTotalMeta.
+type RelationshipPaginator interface {
+	JSONAPIRelationshipPaginator(relation string) Paginator
+}
+
+// paginateRelationship adds the page links and total of the to-many
+// relation of model, whose resource object is node, to links and meta.
+func paginateRelationship(model interface{}, node *Node, relation string,
+	links *Links, meta *Meta, o *marshalOptions) (*Links, *Meta, error) {
+	paginated, ok := model.(RelationshipPaginator)
+	if !ok {
+		return links, meta, nil
+	}
+	p := paginated.JSONAPIRelationshipPaginator(relation)
+	if p == nil {
+		return links, meta, nil
+	}
+
+	var related string
+	if links != nil {
+		related = linkHref((*links)[KeyRelatedLink])
+	}
+	if related == "" && o.links != nil && node.ID != "" {
+		_, related = o.links.RelationshipLinks(node.Type, node.ID, relation)
+	}
+	if related != "" {
+		u, err := url.Parse(related)
+		if err != nil {
+			return nil, nil, err
+		}
+		page := pageLinks(u, p)
+		if links != nil {
+			for key, href := range *links {
+				page[key] = href
+			}
+		}
+		links = &page
+	}
+
+	if total, ok := paginatorTotal(p); ok {
+		m := Meta{}
+		if meta != nil {
+			for k, v := range *meta {
+				m[k] = v
+			}
+		}
+		m[KeyTotalMeta] = total
+		meta = &m
+	}
+	return links, meta, nil
+}
+
+// paginatorTotal returns the Total of the built-in paginators, when known.
+func paginatorTotal(p Paginator) (int, bool) {
+	var total *int
+	switch p := p.(type) {
+	case NumberPaginator:
+		total = p.Total
+	case *NumberPaginator:
+		total = p.Total
+	case OffsetPaginator:
+		total = p.Total
+	case *OffsetPaginator:
+		total = p.Total
+	}
+	if total == nil {
+		return 0, false
+	}
+	return *total, true
+}
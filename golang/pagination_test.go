@@ -0,0 +1,274 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func intPtr(n int) *int {
+	return &n
+}
+
+// pageNumbers returns the value of key in each of the page parameters
+// first, prev, next and last, "-" for missing pages.
+func pageNumbers(p Paginator, key string) []string {
+	first, prev, next, last := p.PageParams()
+	var got []string
+	for _, params := range []map[string]string{first, prev, next, last} {
+		if params == nil {
+			got = append(got, "-")
+			continue
+		}
+		got = append(got, params[key])
+	}
+	return got
+}
+
+func TestNumberPaginator(t *testing.T) {
+	tests := []struct {
+		p    NumberPaginator
+		want []string
+	}{
+		{NumberPaginator{Number: 2, Size: 10, Total: intPtr(35)}, []string{"1", "1", "3", "4"}},
+		{NumberPaginator{Number: 4, Size: 10, Total: intPtr(35)}, []string{"1", "3", "-", "4"}},
+		{NumberPaginator{Number: 1, Size: 10, Total: intPtr(0)}, []string{"1", "-", "-", "1"}},
+		{NumberPaginator{Number: 0, Size: 10}, []string{"1", "-", "-", "-"}},
+		{NumberPaginator{Number: 3, Size: 10, Full: true}, []string{"1", "2", "4", "-"}},
+		{NumberPaginator{Number: 3}, []string{"-", "-", "-", "-"}},
+	}
+	for _, tt := range tests {
+		if got := pageNumbers(tt.p, "number"); !equalStrings(got, tt.want) {
+			t.Errorf("%+v: pages %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestOffsetPaginator(t *testing.T) {
+	tests := []struct {
+		p    OffsetPaginator
+		want []string
+	}{
+		{OffsetPaginator{Offset: 10, Limit: 10, Total: intPtr(35)}, []string{"0", "0", "20", "30"}},
+		{OffsetPaginator{Offset: 5, Limit: 10, Total: intPtr(35)}, []string{"0", "0", "15", "30"}},
+		{OffsetPaginator{Offset: 30, Limit: 10, Total: intPtr(35)}, []string{"0", "20", "-", "30"}},
+		{OffsetPaginator{Offset: 0, Limit: 10, Total: intPtr(0)}, []string{"0", "-", "-", "0"}},
+		{OffsetPaginator{Offset: 20, Limit: 10, Full: true}, []string{"0", "10", "30", "-"}},
+		{OffsetPaginator{Offset: 20, Limit: 10}, []string{"0", "10", "-", "-"}},
+	}
+	for _, tt := range tests {
+		if got := pageNumbers(tt.p, "offset"); !equalStrings(got, tt.want) {
+			t.Errorf("%+v: pages %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestMarshalCollection(t *testing.T) {
+	authors := []*reqAuthor{{ID: "1"}, {ID: "2"}}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/people?sort=name&page%5Bnumber%5D=2&page%5Bsize%5D=2", nil)
+	err := MarshalCollection(w, r, authors,
+		WithPaginator(NumberPaginator{Number: 2, Size: 2, Total: intPtr(4)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Links map[string]string `json:"links"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if want := "http://example.com" + r.URL.String(); doc.Links[KeySelfLink] != want {
+		t.Errorf("self link %q, want %q", doc.Links[KeySelfLink], want)
+	}
+	if _, ok := doc.Links[KeyNextPage]; ok {
+		t.Errorf("next link on the last page: %v", doc.Links)
+	}
+	prev, err := url.Parse(doc.Links[KeyPreviousPage])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := prev.Query(); q.Get("page[number]") != "1" || q.Get("page[size]") != "2" || q.Get("sort") != "name" {
+		t.Errorf("prev link %s", prev)
+	}
+	if prev.Scheme != "http" || prev.Host != "example.com" || prev.Path != "/people" {
+		t.Errorf("prev link %s, want the base of the self link", prev)
+	}
+
+	if err := MarshalCollection(w, r, authors[0]); err != ErrExpectedSlice {
+		t.Errorf("error %v, want ErrExpectedSlice", err)
+	}
+}
+
+func TestRequestURL(t *testing.T) {
+	secure := httptest.NewRequest(http.MethodGet, "https://api.example.com/people?sort=name", nil)
+	secure.URL.Scheme, secure.URL.Host = "", ""
+	noHost := httptest.NewRequest(http.MethodGet, "/people", nil)
+	noHost.Host = ""
+
+	tests := []struct {
+		r    *http.Request
+		want string
+	}{
+		{httptest.NewRequest(http.MethodGet, "/people", nil), "http://example.com/people"},
+		{secure, "https://api.example.com/people?sort=name"},
+		{httptest.NewRequest(http.MethodGet, "http://proxy.example.com/people", nil), "http://proxy.example.com/people"},
+		{noHost, "/people"},
+	}
+	for _, tt := range tests {
+		if got := requestURL(tt.r).String(); got != tt.want {
+			t.Errorf("requestURL(%s) = %s, want %s", tt.r.URL, got, tt.want)
+		}
+	}
+}
+
+type pagedAuthor struct {
+	ID    string         `jsonapi:"primary,people"`
+	Posts []*orderPost   `jsonapi:"relation,posts"`
+	Tags  []*orderPerson `jsonapi:"relation,tags"`
+}
+
+func (a *pagedAuthor) JSONAPIRelationshipPaginator(relation string) Paginator {
+	switch relation {
+	case "posts":
+		return OffsetPaginator{Limit: 1, Total: intPtr(0)}
+	case "tags":
+		return &NumberPaginator{Number: 1, Size: 1, Full: true}
+	}
+	return nil
+}
+
+func TestRelationshipPaginator(t *testing.T) {
+	doc := marshalDoc(t, &pagedAuthor{ID: "1"}, WithBaseURL("/api"))
+	rels := doc["data"].(map[string]interface{})["relationships"].(map[string]interface{})
+
+	posts := rels["posts"].(map[string]interface{})
+	if meta, _ := posts["meta"].(map[string]interface{}); meta == nil || meta[KeyTotalMeta] != float64(0) {
+		t.Errorf("posts meta %v, want a known total of 0", posts["meta"])
+	}
+	links := posts["links"].(map[string]interface{})
+	if links[KeyFirstPage] != "/api/people/1/posts?page%5Blimit%5D=1&page%5Boffset%5D=0" {
+		t.Errorf("posts links %v", links)
+	}
+
+	tags := rels["tags"].(map[string]interface{})
+	if _, ok := tags["meta"]; ok {
+		t.Errorf("tags meta %v, want none for an unknown total", tags["meta"])
+	}
+	if links := tags["links"].(map[string]interface{}); links[KeyNextPage] == nil {
+		t.Errorf("tags links %v, want a next link", links)
+	}
+}
+
+type pagedTopic struct {
+	ID    string         `jsonapi:"primary,topics"`
+	Posts []*orderPost   `jsonapi:"relation,posts"`
+	Tags  []*orderPerson `jsonapi:"relation,tags"`
+	Owner *orderPerson   `jsonapi:"relation,owner"`
+}
+
+func (p *pagedTopic) JSONAPIRelationshipPaginator(relation string) Paginator {
+	if relation == "posts" || relation == "owner" {
+		return NumberPaginator{Number: 2, Size: 10, Total: intPtr(35)}
+	}
+	return nil
+}
+
+func (p *pagedTopic) JSONAPIRelationshipLinks(relation string) *Links {
+	if relation == "posts" {
+		return &Links{KeyRelatedLink: "https://example.com/topics/1/posts?sort=-created", KeySelfLink: "/self"}
+	}
+	return nil
+}
+
+func (p *pagedTopic) JSONAPIRelationshipMeta(relation string) *Meta {
+	if relation == "posts" {
+		return &Meta{"note": "x"}
+	}
+	return nil
+}
+
+func TestRelationshipPaginatorOwnLinks(t *testing.T) {
+	doc := marshalDoc(t, &pagedTopic{ID: "1", Owner: &orderPerson{ID: "9"}})
+	rels := doc["data"].(map[string]interface{})["relationships"].(map[string]interface{})
+
+	posts := rels["posts"].(map[string]interface{})
+	links := posts["links"].(map[string]interface{})
+	if links[KeySelfLink] != "/self" || links[KeyRelatedLink] == nil {
+		t.Errorf("posts links %v, want the model's links kept", links)
+	}
+	if links[KeyPreviousPage] != "https://example.com/topics/1/posts?page%5Bnumber%5D=1&page%5Bsize%5D=10&sort=-created" ||
+		links[KeyLastPage] != "https://example.com/topics/1/posts?page%5Bnumber%5D=4&page%5Bsize%5D=10&sort=-created" {
+		t.Errorf("posts page links %v", links)
+	}
+	if meta := posts["meta"].(map[string]interface{}); meta["note"] != "x" || meta[KeyTotalMeta] != float64(35) {
+		t.Errorf("posts meta %v, want the model's meta and the total", meta)
+	}
+
+	// tags is not paginated.
+	p, err := Marshal(&pagedTopic{ID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := p.(*OnePayload).Data.Relationships["tags"].(*RelationshipManyNode)
+	if tags.Links != nil || tags.Meta != nil {
+		t.Errorf("tags %+v, want no pagination", tags)
+	}
+
+	// To-one relationships are not paginated.
+	owner := rels["owner"].(map[string]interface{})
+	if _, ok := owner["meta"]; ok {
+		t.Errorf("owner %v, want no total", owner)
+	}
+
+	// Without a related link there are no page links, but the total is
+	// known.
+	doc = marshalDoc(t, &pagedAuthor{ID: "1"})
+	posts = doc["data"].(map[string]interface{})["relationships"].(map[string]interface{})["posts"].(map[string]interface{})
+	if _, ok := posts["links"]; ok || posts["meta"].(map[string]interface{})[KeyTotalMeta] != float64(0) {
+		t.Errorf("posts %v, want only the total", posts)
+	}
+}
+
+// fixedPaginator is a Paginator other than the built-in ones.
+type fixedPaginator struct{}
+
+func (fixedPaginator) PageParams() (first, prev, next, last map[string]string) {
+	return nil, nil, nil, nil
+}
+
+func TestPaginatorTotal(t *testing.T) {
+	tests := []struct {
+		p     Paginator
+		total int
+		ok    bool
+	}{
+		{NumberPaginator{Total: intPtr(3)}, 3, true},
+		{&NumberPaginator{Total: intPtr(0)}, 0, true},
+		{OffsetPaginator{}, 0, false},
+		{&OffsetPaginator{Total: intPtr(7)}, 7, true},
+		{fixedPaginator{}, 0, false},
+	}
+	for _, tt := range tests {
+		if total, ok := paginatorTotal(tt.p); total != tt.total || ok != tt.ok {
+			t.Errorf("paginatorTotal(%#v) = %d, %v, want %d, %v", tt.p, total, ok, tt.total, tt.ok)
+		}
+	}
+}
+
+func TestMarshalCollectionAttributeOrder(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/items", nil)
+	err := MarshalCollection(w, r, []*ndjsonItem{{ID: "1", Zeta: "z", Alpha: 1}},
+		WithAttributeOrder(AttributeDeclarationOrder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(w.Body.String(), `"attributes":{"zeta":"z","alpha":1}`) {
+		t.Errorf("document %s, want attributes in field order", w.Body)
+	}
+}
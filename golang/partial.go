@@ -0,0 +1,71 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// UnmarshalIdentifiers reads only the type and id of the primary data of a
+// document, one identifier for a single-resource document and one per
+// resource for a collection; attributes and relationships are skipped
+// without being decoded. It returns nil for null data. It is meant for
+// routing and authorization checks that precede full unmarshaling.
+func UnmarshalIdentifiers(in io.Reader, opts ...UnmarshalOption) ([]ResourceIdentifier, error) {
+	o := newUnmarshalOptions(opts)
+
+	var doc struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := o.decode(in, &doc); err != nil {
+		return nil, err
+	}
+
+	type identifier struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+	}
+	var ids []identifier
+	switch data := bytes.TrimSpace(doc.Data); {
+	case len(data) == 0, bytes.Equal(data, []byte("null")):
+		return nil, nil
+	case data[0] == '[':
+		if err := o.decode(bytes.NewReader(data), &ids); err != nil {
+			return nil, err
+		}
+	default:
+		ids = make([]identifier, 1)
+		if err := o.decode(bytes.NewReader(data), &ids[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	identifiers := make([]ResourceIdentifier, len(ids))
+	for i, id := range ids {
+		identifiers[i] = ResourceIdentifier{Type: id.Type, ID: id.ID}
+	}
+	return identifiers, nil
+}
+
+// UnmarshalAttributesOnly decodes the attributes object of the primary
+// data of a single-resource document into dst, a pointer to a map or to a
+// struct with json tags as for json.Unmarshal, without building a model.
+// The rest of the resource is skipped. dst is left untouched when the data
+// is null or has no attributes. UseNumber and MaxBodyBytes apply.
+func UnmarshalAttributesOnly(in io.Reader, dst interface{}, opts ...UnmarshalOption) error {
+	o := newUnmarshalOptions(opts)
+
+	var doc struct {
+		Data *struct {
+			Attributes json.RawMessage `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := o.decode(in, &doc); err != nil {
+		return err
+	}
+	if doc.Data == nil || len(doc.Data.Attributes) == 0 ||
+		bytes.Equal(bytes.TrimSpace(doc.Data.Attributes), []byte("null")) {
+		return nil
+	}
+	return o.decode(bytes.NewReader(doc.Data.Attributes), dst)
+}
@@ -0,0 +1,88 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalIdentifiers(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want []ResourceIdentifier
+	}{
+		{reqArticleDoc, []ResourceIdentifier{{Type: "articles", ID: "1"}}},
+		{`{"data": [{"type": "a", "id": "1", "attributes": {"x": [1, {"y": 2}]}}, {"type": "b", "id": "2"}]}`,
+			[]ResourceIdentifier{{Type: "a", ID: "1"}, {Type: "b", ID: "2"}}},
+		{`{"data": []}`, []ResourceIdentifier{}},
+		{`{"data": null}`, nil},
+		{`{"meta": {}}`, nil},
+	}
+	for _, tt := range tests {
+		got, err := UnmarshalIdentifiers(strings.NewReader(tt.doc))
+		if err != nil {
+			t.Errorf("%s: %v", tt.doc, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: identifiers %+v, want %+v", tt.doc, got, tt.want)
+		}
+	}
+
+	for _, doc := range []string{`{"data": `, `{"data": [1]}`, `{"data": "x"}`} {
+		if _, err := UnmarshalIdentifiers(strings.NewReader(doc)); err == nil {
+			t.Errorf("%s: expected an error", doc)
+		}
+	}
+
+	big := `{"data": {"type": "a", "id": "1", "attributes": {"x": "` + strings.Repeat("x", 100) + `"}}}`
+	if _, err := UnmarshalIdentifiers(strings.NewReader(big), MaxBodyBytes(64)); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("error %v, want ErrBodyTooLarge", err)
+	}
+}
+
+func TestUnmarshalAttributesOnly(t *testing.T) {
+	var attrs map[string]interface{}
+	if err := UnmarshalAttributesOnly(strings.NewReader(reqArticleDoc), &attrs, UseNumber()); err != nil {
+		t.Fatal(err)
+	}
+	if attrs["title"] != "Hello" {
+		t.Errorf("attributes %v", attrs)
+	}
+	if _, ok := attrs["views"].(json.Number); !ok {
+		t.Errorf("views %T, want json.Number with UseNumber", attrs["views"])
+	}
+
+	var patch struct {
+		Title *string `json:"title"`
+		Views *int    `json:"views"`
+	}
+	doc := `{"data": {"type": "articles", "id": "1", "attributes": {"title": "New"}, "relationships": {"author": {"data": null}}}}`
+	if err := UnmarshalAttributesOnly(strings.NewReader(doc), &patch); err != nil {
+		t.Fatal(err)
+	}
+	if patch.Title == nil || *patch.Title != "New" || patch.Views != nil {
+		t.Errorf("patch %+v", patch)
+	}
+
+	// dst is left untouched without attributes.
+	for _, doc := range []string{
+		`{"data": null}`,
+		`{"data": {"type": "articles", "id": "1"}}`,
+		`{"data": {"type": "articles", "id": "1", "attributes": null}}`,
+	} {
+		kept := map[string]interface{}{"k": "v"}
+		if err := UnmarshalAttributesOnly(strings.NewReader(doc), &kept); err != nil {
+			t.Errorf("%s: %v", doc, err)
+		}
+		if kept["k"] != "v" {
+			t.Errorf("%s: dst %v changed", doc, kept)
+		}
+	}
+
+	if err := UnmarshalAttributesOnly(strings.NewReader(`{"data": {"attributes": []}}`), &attrs); err == nil {
+		t.Error("expected an error for array attributes")
+	}
+}
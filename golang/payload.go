@@ -0,0 +1,211 @@
+package jsonapi
+
+import "sort"
+
+// NewNode returns a resource object of the given type and id with no
+// members. Together with AddAttribute, AddToOne, AddToMany and the payload
+// constructors it assembles documents without tagged structs:
+//
+//	author := NewNode("people", "9").AddAttribute("name", "Dan")
+//	post := NewNode("posts", "1").
+//		AddAttribute("title", "Hello").
+//		AddToOne("author", author)
+//	payload := NewOnePayload(post, author)
+func NewNode(resourceType, id string) *Node {
+	return &Node{Type: resourceType, ID: id}
+}
+
+// AddAttribute sets the attribute name to value, which is encoded with
+// encoding/json, and returns n.
+func (n *Node) AddAttribute(name string, value interface{}) *Node {
+	if n.Attributes == nil {
+		n.Attributes = make(map[string]interface{})
+	}
+	n.Attributes[name] = value
+	return n
+}
+
+// AddToOne sets the to-one relationship name to related, or to null if
+// related is nil, and returns n. Only the type and id of related are used;
+// add it to the payload's included section to sideload it.
+func (n *Node) AddToOne(name string, related *Node) *Node {
+	relationship := &RelationshipOneNode{}
+	if related != nil {
+		relationship.Data = toShallowNode(related)
+	}
+	n.setRelationship(name, relationship)
+	return n
+}
+
+// AddToMany sets the to-many relationship name to related and returns n.
+// Like AddToOne, it only keeps the type and id of each related resource.
+func (n *Node) AddToMany(name string, related ...*Node) *Node {
+	relationship := &RelationshipManyNode{Data: make([]*Node, 0, len(related))}
+	for _, r := range related {
+		if r != nil {
+			relationship.Data = append(relationship.Data, toShallowNode(r))
+		}
+	}
+	n.setRelationship(name, relationship)
+	return n
+}
+
+func (n *Node) setRelationship(name string, relationship interface{}) {
+	if n.Relationships == nil {
+		n.Relationships = make(map[string]interface{})
+	}
+	n.Relationships[name] = relationship
+}
+
+// NewOnePayload returns a single-resource document with data as primary
+// data, nil for null, and included as the included section, as added by
+// AddIncluded.
+func NewOnePayload(data *Node, included ...*Node) *OnePayload {
+	p := &OnePayload{Data: data}
+	p.AddIncluded(included...)
+	return p
+}
+
+// NewManyPayload returns a collection document with data as primary data
+// and included as the included section, as added by AddIncluded.
+func NewManyPayload(data []*Node, included ...*Node) *ManyPayload {
+	if data == nil {
+		data = []*Node{}
+	}
+	p := &ManyPayload{Data: data}
+	p.AddIncluded(included...)
+	return p
+}
+
+// AddIncluded appends nodes to the included section, skipping resources
+// that are already part of the document.
+func (p *OnePayload) AddIncluded(nodes ...*Node) {
+	p.Included = addIncluded(p.Included, []*Node{p.Data}, nodes)
+}
+
+// SetLinks validates and sets the top-level links of the document.
+func (p *OnePayload) SetLinks(links *Links) error {
+	if links != nil {
+		if err := links.validate(); err != nil {
+			return err
+		}
+	}
+	p.Links = links
+	return nil
+}
+
+// SetMeta sets the top-level meta of the document.
+func (p *OnePayload) SetMeta(meta *Meta) {
+	p.Meta = meta
+}
+
+// FilterFields applies sparse fieldsets to every resource in the document.
+// fields maps a resource type to the attribute and relationship names to
+// keep, as in the fields[TYPE] query parameter; types not in fields are
+// left alone.
+func (p *OnePayload) FilterFields(fields map[string][]string) {
+	filterFields(fields, p.Data)
+	filterFields(fields, p.Included...)
+}
+
+// SortIncluded orders the included section by type, then id.
+func (p *OnePayload) SortIncluded() {
+	sortNodes(p.Included)
+}
+
+// AddIncluded appends nodes to the included section, skipping resources
+// that are already part of the document.
+func (p *ManyPayload) AddIncluded(nodes ...*Node) {
+	p.Included = addIncluded(p.Included, p.Data, nodes)
+}
+
+// SetLinks validates and sets the top-level links of the document.
+func (p *ManyPayload) SetLinks(links *Links) error {
+	if links != nil {
+		if err := links.validate(); err != nil {
+			return err
+		}
+	}
+	p.Links = links
+	return nil
+}
+
+// SetMeta sets the top-level meta of the document.
+func (p *ManyPayload) SetMeta(meta *Meta) {
+	p.Meta = meta
+}
+
+// FilterFields applies sparse fieldsets to every resource in the document.
+// fields maps a resource type to the attribute and relationship names to
+// keep, as in the fields[TYPE] query parameter; types not in fields are
+// left alone.
+func (p *ManyPayload) FilterFields(fields map[string][]string) {
+	filterFields(fields, p.Data...)
+	filterFields(fields, p.Included...)
+}
+
+// SortIncluded orders the included section by type, then id.
+func (p *ManyPayload) SortIncluded() {
+	sortNodes(p.Included)
+}
+
+func nodeKey(n *Node) string {
+	return n.Type + "," + n.ID
+}
+
+func addIncluded(included, data, nodes []*Node) []*Node {
+	seen := make(map[string]bool, len(included)+len(data))
+	for _, n := range data {
+		if n != nil {
+			seen[nodeKey(n)] = true
+		}
+	}
+	for _, n := range included {
+		seen[nodeKey(n)] = true
+	}
+
+	for _, n := range nodes {
+		if n == nil || seen[nodeKey(n)] {
+			continue
+		}
+		seen[nodeKey(n)] = true
+		included = append(included, n)
+	}
+	return included
+}
+
+func filterFields(fields map[string][]string, nodes ...*Node) {
+	for _, n := range nodes {
+		if n == nil {
+			continue
+		}
+		keep, ok := fields[n.Type]
+		if !ok {
+			continue
+		}
+
+		allowed := make(map[string]bool, len(keep))
+		for _, f := range keep {
+			allowed[f] = true
+		}
+		for name := range n.Attributes {
+			if !allowed[name] {
+				delete(n.Attributes, name)
+			}
+		}
+		for name := range n.Relationships {
+			if !allowed[name] {
+				delete(n.Relationships, name)
+			}
+		}
+	}
+}
+
+func sortNodes(nodes []*Node) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].Type != nodes[j].Type {
+			return nodes[i].Type < nodes[j].Type
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+}
@@ -0,0 +1,94 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestManyPayloadMutations(t *testing.T) {
+	p := &ManyPayload{Data: []*Node{{Type: "posts", ID: "1"}}}
+	p.AddIncluded(&Node{Type: "people", ID: "2"}, &Node{Type: "posts", ID: "1"},
+		&Node{Type: "comments", ID: "3"}, &Node{Type: "people", ID: "2"}, nil)
+	if len(p.Included) != 2 {
+		t.Fatalf("included = %d nodes, want primary data and duplicates skipped", len(p.Included))
+	}
+
+	p.SortIncluded()
+	if p.Included[0].Type != "comments" || p.Included[1].Type != "people" {
+		t.Errorf("SortIncluded order: %s, %s", p.Included[0].Type, p.Included[1].Type)
+	}
+
+	if err := p.SetLinks(&Links{"self": 5}); err == nil {
+		t.Error("SetLinks accepted an invalid links object")
+	}
+	if err := p.SetLinks(&Links{"self": "/posts"}); err != nil || p.Links == nil {
+		t.Errorf("SetLinks = %v", err)
+	}
+	p.SetMeta(&Meta{"total": 1})
+	if (*p.Meta)["total"] != 1 {
+		t.Error("SetMeta did not set the meta")
+	}
+}
+
+func TestFilterFields(t *testing.T) {
+	post := &Node{Type: "posts", ID: "1",
+		Attributes:    map[string]interface{}{"title": "Hello", "body": "..."},
+		Relationships: map[string]interface{}{"author": &RelationshipOneNode{Data: &Node{Type: "people", ID: "9"}}},
+	}
+	person := &Node{Type: "people", ID: "9", Attributes: map[string]interface{}{"name": "Dan"}}
+
+	p := &OnePayload{Data: post}
+	p.AddIncluded(person, post)
+	if len(p.Included) != 1 {
+		t.Fatalf("included = %d nodes, want the primary data skipped", len(p.Included))
+	}
+	p.FilterFields(map[string][]string{"posts": {"title"}})
+
+	if _, ok := post.Attributes["title"]; !ok {
+		t.Error("title was removed")
+	}
+	if _, ok := post.Attributes["body"]; ok {
+		t.Error("body was kept")
+	}
+	if _, ok := post.Relationships["author"]; ok {
+		t.Error("author was kept")
+	}
+	if _, ok := person.Attributes["name"]; !ok {
+		t.Error("resources of types without a fieldset should be left alone")
+	}
+}
+
+func TestNodeBuilders(t *testing.T) {
+	author := NewNode("people", "9").AddAttribute("name", "Dan")
+	post := NewNode("posts", "1").
+		AddAttribute("title", "Hello").
+		AddToOne("author", author).
+		AddToOne("editor", nil).
+		AddToMany("comments", NewNode("comments", "5"), nil)
+
+	b, err := json.Marshal(NewOnePayload(post, author, post, author))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"data":{"type":"posts","id":"1","attributes":{"title":"Hello"},"relationships":{` +
+		`"author":{"data":{"type":"people","id":"9"}},"comments":{"data":[{"type":"comments","id":"5"}]},` +
+		`"editor":{"data":null}}},"included":[{"type":"people","id":"9","attributes":{"name":"Dan"}}]}`
+	if string(b) != want {
+		t.Errorf("got  %s\nwant %s", b, want)
+	}
+}
+
+func TestNewManyPayload(t *testing.T) {
+	b, err := json.Marshal(NewManyPayload(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"data":[]}` {
+		t.Errorf("empty collection %s, want an empty data array", b)
+	}
+
+	p := NewManyPayload([]*Node{NewNode("posts", "1")}, NewNode("posts", "1"), NewNode("people", "9"))
+	if len(p.Included) != 1 || p.Included[0].ID != "9" {
+		t.Errorf("included %v, want primary data skipped", p.Included)
+	}
+}
@@ -0,0 +1,146 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProblemMediaType is the media type of RFC 7807 problem documents.
+const ProblemMediaType = "application/problem+json"
+
+// Problem is an RFC 7807 problem document, the error format of plain JSON
+// APIs, for clients that do not accept JSON:API documents.
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Extension members carrying the rest of the error objects.
+	Code   string         `json:"code,omitempty"`
+	Source *ErrorSource   `json:"source,omitempty"`
+	Errors []*ErrorObject `json:"errors,omitempty"`
+}
+
+// NewProblem converts error objects into a problem document. The first
+// object gives its title, detail, status, code and source; when there are
+// several, they are all listed in the errors extension member, and the
+// status is that of StatusForErrorObjects.
+func NewProblem(objs []*ErrorObject) *Problem {
+	p := &Problem{Type: "about:blank"}
+	if len(objs) == 0 {
+		p.Status = http.StatusInternalServerError
+		p.Title = http.StatusText(p.Status)
+		return p
+	}
+
+	first := objs[0]
+	p.Title, p.Detail, p.Code, p.Source = first.Title, first.Detail, first.Code, first.Source
+	p.Status = objectStatus(first)
+	if len(objs) > 1 {
+		p.Status = StatusForErrorObjects(objs)
+		p.Errors = objs
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	return p
+}
+
+// StatusForErrorObjects returns the status of a response carrying objs:
+// their common status, 400 Bad Request for different 4xx ones and 500
+// Internal Server Error as soon as one is a 5xx or has none.
+func StatusForErrorObjects(objs []*ErrorObject) int {
+	status := 0
+	for _, obj := range objs {
+		s := objectStatus(obj)
+		switch {
+		case s >= http.StatusInternalServerError:
+			return http.StatusInternalServerError
+		case status == 0:
+			status = s
+		case s != status:
+			status = http.StatusBadRequest
+		}
+	}
+	if status == 0 {
+		return http.StatusInternalServerError
+	}
+	return status
+}
+
+// AcceptsProblem reports whether error responses to r should be problem
+// documents: r has an Accept header that admits neither the JSON:API
+// media type nor a wildcard, but admits application/problem+json or
+// application/json.
+func AcceptsProblem(r *http.Request) bool {
+	problem := false
+	for _, entry := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(entry))
+		if err != nil {
+			continue
+		}
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q == 0 {
+			continue
+		}
+		switch mt {
+		case MediaType, "*/*", "application/*":
+			return false
+		case ProblemMediaType, "application/json":
+			problem = true
+		}
+	}
+	return problem
+}
+
+// WriteErrorFor is like WriteError but writes the problem document of err,
+// see NewProblem, to clients for which AcceptsProblem holds.
+func WriteErrorFor(w http.ResponseWriter, r *http.Request, err error) error {
+	if !AcceptsProblem(r) {
+		return WriteError(w, err)
+	}
+	setRetryAfter(w, err)
+	p := NewProblem(ErrorObjectsFor(err))
+	w.Header().Set("Content-Type", ProblemMediaType)
+	w.WriteHeader(StatusForError(err))
+	return encodePayload(w, p, newMarshalOptions(nil))
+}
+
+// RateLimitError rejects a request because the client sent too many. It
+// is answered with 429 Too Many Requests, and a Retry-After header by
+// WriteError and WriteErrorFor when RetryAfter is set.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("jsonapi: rate limit exceeded, retry after %s", e.RetryAfter)
+	}
+	return "jsonapi: rate limit exceeded"
+}
+
+// ErrorObject converts e into a 429 error object.
+func (e *RateLimitError) ErrorObject() *ErrorObject {
+	return &ErrorObject{
+		Title:  http.StatusText(http.StatusTooManyRequests),
+		Detail: e.Error(),
+		Status: strconv.Itoa(http.StatusTooManyRequests),
+	}
+}
+
+// setRetryAfter sets the Retry-After header of the response to a request
+// that failed with a *RateLimitError, in whole seconds.
+func setRetryAfter(w http.ResponseWriter, err error) {
+	var rl *RateLimitError
+	if errors.As(err, &rl) && rl.RetryAfter > 0 {
+		secs := (rl.RetryAfter + time.Second - 1) / time.Second
+		w.Header().Set("Retry-After", strconv.FormatInt(int64(secs), 10))
+	}
+}
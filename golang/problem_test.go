@@ -0,0 +1,123 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcceptsProblem(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{ProblemMediaType, true},
+		{"application/json", true},
+		{"text/html, application/json;q=0.5", true},
+		{MediaType + ", " + ProblemMediaType, false},
+		{"application/json, */*", false},
+		{"application/json, application/*", false},
+		{ProblemMediaType + ";q=0", false},
+		{MediaType + ";q=0, application/json", true},
+		{"text/html", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", tt.accept)
+		if got := AcceptsProblem(r); got != tt.want {
+			t.Errorf("AcceptsProblem(%q) = %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestStatusForErrorObjects(t *testing.T) {
+	tests := []struct {
+		statuses []string
+		want     int
+	}{
+		{nil, http.StatusInternalServerError},
+		{[]string{"404"}, http.StatusNotFound},
+		{[]string{"422", "422"}, http.StatusUnprocessableEntity},
+		{[]string{"422", "409"}, http.StatusBadRequest},
+		{[]string{"422", "503"}, http.StatusInternalServerError},
+		{[]string{"422", ""}, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		var objs []*ErrorObject
+		for _, s := range tt.statuses {
+			objs = append(objs, &ErrorObject{Status: s})
+		}
+		if got := StatusForErrorObjects(objs); got != tt.want {
+			t.Errorf("%v: status %d, want %d", tt.statuses, got, tt.want)
+		}
+	}
+}
+
+func TestNewProblem(t *testing.T) {
+	if p := NewProblem(nil); p.Status != http.StatusInternalServerError || p.Title != "Internal Server Error" {
+		t.Errorf("problem %+v", p)
+	}
+
+	source := &ErrorSource{Pointer: "/data/attributes/name"}
+	p := NewProblem([]*ErrorObject{{Status: "422", Code: "blank", Detail: "name is blank", Source: source}})
+	want := Problem{Type: "about:blank", Title: "Unprocessable Entity", Status: 422,
+		Detail: "name is blank", Code: "blank", Source: source}
+	if !reflect.DeepEqual(*p, want) {
+		t.Errorf("problem %+v, want %+v", p, want)
+	}
+
+	objs := []*ErrorObject{{Status: "422", Title: "Invalid"}, {Status: "409"}}
+	p = NewProblem(objs)
+	if p.Status != http.StatusBadRequest || p.Title != "Invalid" || len(p.Errors) != 2 {
+		t.Errorf("problem %+v", p)
+	}
+}
+
+func TestWriteErrorFor(t *testing.T) {
+	err := &RateLimitError{RetryAfter: 1500 * time.Millisecond}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", ProblemMediaType)
+	w := httptest.NewRecorder()
+	if err := WriteErrorFor(w, r, err); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Content-Type") != ProblemMediaType ||
+		w.Header().Get("Retry-After") != "2" {
+		t.Errorf("status %d, headers %v", w.Code, w.Header())
+	}
+	var p Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != http.StatusTooManyRequests || p.Detail != err.Error() {
+		t.Errorf("problem %+v", p)
+	}
+
+	// Other clients get a JSON:API errors document.
+	r.Header.Set("Accept", MediaType)
+	w = httptest.NewRecorder()
+	if err := WriteErrorFor(w, r, &RateLimitError{}); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Content-Type") != MediaType ||
+		w.Header().Get("Retry-After") != "" || !strings.Contains(w.Body.String(), `"errors"`) {
+		t.Errorf("status %d, headers %v, body %s", w.Code, w.Header(), w.Body)
+	}
+}
+
+func TestContentNegotiationProblem(t *testing.T) {
+	h := ContentNegotiation()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNotAcceptable || w.Header().Get("Content-Type") != ProblemMediaType {
+		t.Errorf("status %d, headers %v", w.Code, w.Header())
+	}
+}
@@ -0,0 +1,317 @@
+//go:build protobuf
+// +build protobuf
+
+// Package protoadapter exposes protobuf messages as JSON:API resources, so
+// that gRPC-backed services can serve JSON:API without duplicate tagged
+// models. A message type is registered with its resource type and the
+// field holding its id:
+//
+//	protoadapter.Register(&pb.Appointment{}, "appointments", "id")
+//	protoadapter.Register(&pb.Customer{}, "customers", "id")
+//
+//	payload, err := protoadapter.MarshalOne(appt)
+//	...
+//	err = json.NewEncoder(w).Encode(payload)
+//
+// Fields are named after their json_name, lowerCamelCase by default, and
+// rendered as protojson renders them, unpopulated ones included. Message
+// fields, repeated or not, of registered types become relationships, and
+// the messages they hold are included; every other field is an attribute.
+package protoadapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	jsonapi "test3"
+)
+
+type registration struct {
+	typ string
+	id  protoreflect.FieldDescriptor
+}
+
+var registry = struct {
+	sync.RWMutex
+	types map[protoreflect.FullName]registration
+}{types: map[protoreflect.FullName]registration{}}
+
+// Register makes messages of the type of m resources of type typ, whose
+// id is the field named idField in the .proto file. The id field must be
+// a string or an integer.
+func Register(m proto.Message, typ, idField string) error {
+	desc := m.ProtoReflect().Descriptor()
+	fd := desc.Fields().ByName(protoreflect.Name(idField))
+	if fd == nil {
+		return fmt.Errorf("protoadapter: %s has no field %q", desc.FullName(), idField)
+	}
+	if fd.IsList() || fd.IsMap() || !isIDKind(fd.Kind()) {
+		return fmt.Errorf("protoadapter: id field %q of %s is not a string or an integer",
+			idField, desc.FullName())
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	registry.types[desc.FullName()] = registration{typ: typ, id: fd}
+	return nil
+}
+
+func lookup(desc protoreflect.MessageDescriptor) (registration, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	r, ok := registry.types[desc.FullName()]
+	return r, ok
+}
+
+// MarshalOne returns the single-resource document of m, with the messages
+// it relates to included.
+func MarshalOne(m proto.Message) (*jsonapi.OnePayload, error) {
+	included := newIncluded()
+	node, err := marshalNode(m.ProtoReflect(), included)
+	if err != nil {
+		return nil, err
+	}
+	return jsonapi.NewOnePayload(node, included.nodes...), nil
+}
+
+// MarshalMany returns the collection document of ms, with the messages
+// they relate to included.
+func MarshalMany(ms []proto.Message) (*jsonapi.ManyPayload, error) {
+	included := newIncluded()
+	nodes := make([]*jsonapi.Node, len(ms))
+	for i, m := range ms {
+		node, err := marshalNode(m.ProtoReflect(), included)
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = node
+	}
+	return jsonapi.NewManyPayload(nodes, included.nodes...), nil
+}
+
+// Node returns the resource object of m and those of the messages it
+// relates to, to include.
+func Node(m proto.Message) (*jsonapi.Node, []*jsonapi.Node, error) {
+	included := newIncluded()
+	node, err := marshalNode(m.ProtoReflect(), included)
+	if err != nil {
+		return nil, nil, err
+	}
+	return node, included.nodes, nil
+}
+
+// included collects related resource objects, once each.
+type included struct {
+	nodes []*jsonapi.Node
+	seen  map[string]bool
+}
+
+func newIncluded() *included {
+	return &included{seen: map[string]bool{}}
+}
+
+func (in *included) add(n *jsonapi.Node) bool {
+	key := n.Type + "," + n.ID
+	if in.seen[key] {
+		return false
+	}
+	in.seen[key] = true
+	in.nodes = append(in.nodes, n)
+	return true
+}
+
+func marshalNode(m protoreflect.Message, in *included) (*jsonapi.Node, error) {
+	desc := m.Descriptor()
+	reg, ok := lookup(desc)
+	if !ok {
+		return nil, fmt.Errorf("protoadapter: %s is not registered", desc.FullName())
+	}
+
+	b, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(m.Interface())
+	if err != nil {
+		return nil, err
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(b, &members); err != nil {
+		return nil, err
+	}
+
+	node := jsonapi.NewNode(reg.typ, formatID(m.Get(reg.id), reg.id.Kind()))
+	fields := desc.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd == reg.id {
+			continue
+		}
+		name := fd.JSONName()
+
+		if !isRelation(fd) {
+			if raw, ok := members[name]; ok {
+				node.AddAttribute(name, raw)
+			}
+			continue
+		}
+
+		if fd.IsList() {
+			list := m.Get(fd).List()
+			related := make([]*jsonapi.Node, 0, list.Len())
+			for j := 0; j < list.Len(); j++ {
+				n, err := relatedNode(list.Get(j).Message(), in)
+				if err != nil {
+					return nil, err
+				}
+				related = append(related, n)
+			}
+			node.AddToMany(name, related...)
+			continue
+		}
+
+		var related *jsonapi.Node
+		if m.Has(fd) {
+			if related, err = relatedNode(m.Get(fd).Message(), in); err != nil {
+				return nil, err
+			}
+		}
+		node.AddToOne(name, related)
+	}
+	return node, nil
+}
+
+// relatedNode returns the resource object of the related message m after
+// adding it, and the messages it relates to, to in.
+func relatedNode(m protoreflect.Message, in *included) (*jsonapi.Node, error) {
+	n, err := marshalNode(m, in)
+	if err != nil {
+		return nil, err
+	}
+	in.add(n)
+	return n, nil
+}
+
+// Unmarshal fills m from the resource object n, as decoded from a
+// document. Related resources are set to messages holding their id only.
+func Unmarshal(n *jsonapi.Node, m proto.Message) error {
+	desc := m.ProtoReflect().Descriptor()
+	reg, ok := lookup(desc)
+	if !ok {
+		return fmt.Errorf("protoadapter: %s is not registered", desc.FullName())
+	}
+	if n.Type != reg.typ {
+		return fmt.Errorf("protoadapter: resource of type %q cannot be stored in %s",
+			n.Type, desc.FullName())
+	}
+
+	// Assemble the protojson form of the message and let protojson, which
+	// accepts ids as strings for every integer kind, do the conversions.
+	members := make(map[string]interface{}, len(n.Attributes)+len(n.Relationships)+1)
+	for name, value := range n.Attributes {
+		members[name] = value
+	}
+	if n.ID != "" {
+		members[reg.id.JSONName()] = n.ID
+	}
+
+	fields := desc.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		rel, ok := n.Relationships[fd.JSONName()]
+		if !ok || !isRelation(fd) {
+			continue
+		}
+		related, _ := lookup(fd.Message())
+		ids, err := linkage(rel)
+		if err != nil {
+			return err
+		}
+		idName := related.id.JSONName()
+
+		if fd.IsList() {
+			list := make([]map[string]string, len(ids))
+			for j, id := range ids {
+				list[j] = map[string]string{idName: id}
+			}
+			members[fd.JSONName()] = list
+		} else if len(ids) > 0 {
+			members[fd.JSONName()] = map[string]string{idName: ids[0]}
+		}
+	}
+
+	b, err := json.Marshal(members)
+	if err != nil {
+		return err
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, m)
+}
+
+// linkage returns the ids of the resource linkage of a relationship, typed
+// or decoded from JSON.
+func linkage(rel interface{}) ([]string, error) {
+	var typed struct {
+		Data json.RawMessage `json:"data"`
+	}
+	b, err := json.Marshal(rel)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &typed); err != nil {
+		return nil, err
+	}
+
+	type identifier struct {
+		ID string `json:"id"`
+	}
+	var many []identifier
+	if err := json.Unmarshal(typed.Data, &many); err == nil {
+		ids := make([]string, len(many))
+		for i, id := range many {
+			ids[i] = id.ID
+		}
+		return ids, nil
+	}
+	var one *identifier
+	if err := json.Unmarshal(typed.Data, &one); err != nil {
+		return nil, jsonapi.ErrInvalidRelationship
+	}
+	if one == nil {
+		return nil, nil
+	}
+	return []string{one.ID}, nil
+}
+
+// isRelation reports whether fd holds messages of a registered type.
+func isRelation(fd protoreflect.FieldDescriptor) bool {
+	if fd.IsMap() || fd.Message() == nil {
+		return false
+	}
+	_, ok := lookup(fd.Message())
+	return ok
+}
+
+func isIDKind(k protoreflect.Kind) bool {
+	switch k {
+	case protoreflect.StringKind,
+		protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return true
+	}
+	return false
+}
+
+func formatID(v protoreflect.Value, k protoreflect.Kind) string {
+	switch k {
+	case protoreflect.StringKind:
+		return v.String()
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return strconv.FormatUint(v.Uint(), 10)
+	}
+	return strconv.FormatInt(v.Int(), 10)
+}
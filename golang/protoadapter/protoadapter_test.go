@@ -0,0 +1,236 @@
+//go:build protobuf
+// +build protobuf
+
+package protoadapter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	jsonapi "test3"
+)
+
+// testMessages builds, without generated code, the messages of
+//
+//	message Customer { string id = 1; string name = 2; }
+//	message Appointment {
+//		int64 id = 1;
+//		string note = 2;
+//		Customer customer = 3;
+//		repeated Customer attendees = 4;
+//		int32 slot_count = 5;
+//	}
+func testMessages(t *testing.T) (customer, appointment protoreflect.MessageDescriptor) {
+	t.Helper()
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(jsonName(name)),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    label.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("protoadapter_test.proto"),
+		Package: proto.String("protoadaptertest"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Customer"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+				field("name", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+			},
+		}, {
+			Name: proto.String("Appointment"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64, optional, ""),
+				field("note", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+				field("customer", 3, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, optional, ".protoadaptertest.Customer"),
+				field("attendees", 4, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE,
+					descriptorpb.FieldDescriptorProto_LABEL_REPEATED, ".protoadaptertest.Customer"),
+				field("slot_count", 5, descriptorpb.FieldDescriptorProto_TYPE_INT32, optional, ""),
+			},
+		}},
+	}
+	fd, err := protodesc.NewFile(file, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	customer, appointment = fd.Messages().Get(0), fd.Messages().Get(1)
+	if err := Register(dynamicpb.NewMessage(customer), "customers", "id"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Register(dynamicpb.NewMessage(appointment), "appointments", "id"); err != nil {
+		t.Fatal(err)
+	}
+	return customer, appointment
+}
+
+// jsonName is the lowerCamelCase name protoc gives fields.
+func jsonName(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func newCustomer(desc protoreflect.MessageDescriptor, id, name string) *dynamicpb.Message {
+	m := dynamicpb.NewMessage(desc)
+	m.Set(desc.Fields().ByName("id"), protoreflect.ValueOfString(id))
+	m.Set(desc.Fields().ByName("name"), protoreflect.ValueOfString(name))
+	return m
+}
+
+func newAppointment(customer, appointment protoreflect.MessageDescriptor) *dynamicpb.Message {
+	fields := appointment.Fields()
+	m := dynamicpb.NewMessage(appointment)
+	m.Set(fields.ByName("id"), protoreflect.ValueOfInt64(42))
+	m.Set(fields.ByName("note"), protoreflect.ValueOfString("checkup"))
+	m.Set(fields.ByName("customer"), protoreflect.ValueOfMessage(newCustomer(customer, "c1", "Ann")))
+	list := m.Mutable(fields.ByName("attendees")).List()
+	list.Append(protoreflect.ValueOfMessage(newCustomer(customer, "c1", "Ann")))
+	list.Append(protoreflect.ValueOfMessage(newCustomer(customer, "c2", "Bob")))
+	return m
+}
+
+func TestMarshalOne(t *testing.T) {
+	customer, appointment := testMessages(t)
+	payload, err := MarshalOne(newAppointment(customer, appointment))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Data struct {
+			Type          string
+			ID            string
+			Attributes    map[string]interface{}
+			Relationships map[string]struct {
+				Data json.RawMessage
+			}
+		}
+		Included []struct {
+			Type, ID   string
+			Attributes map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Data.Type != "appointments" || doc.Data.ID != "42" {
+		t.Errorf("resource %s/%s", doc.Data.Type, doc.Data.ID)
+	}
+	// Unpopulated fields are attributes too, named by their json_name.
+	if len(doc.Data.Attributes) != 2 || doc.Data.Attributes["note"] != "checkup" ||
+		doc.Data.Attributes["slotCount"] != float64(0) {
+		t.Errorf("attributes %v", doc.Data.Attributes)
+	}
+	if got := string(doc.Data.Relationships["customer"].Data); got != `{"type":"customers","id":"c1"}` {
+		t.Errorf("customer linkage %s", got)
+	}
+	if got := string(doc.Data.Relationships["attendees"].Data); got !=
+		`[{"type":"customers","id":"c1"},{"type":"customers","id":"c2"}]` {
+		t.Errorf("attendees linkage %s", got)
+	}
+	if len(doc.Included) != 2 || doc.Included[0].ID != "c1" || doc.Included[1].Attributes["name"] != "Bob" {
+		t.Errorf("included %+v", doc.Included)
+	}
+}
+
+func TestMarshalMany(t *testing.T) {
+	customer, appointment := testMessages(t)
+	empty := dynamicpb.NewMessage(appointment)
+	payload, err := MarshalMany([]proto.Message{newAppointment(customer, appointment), empty})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Data) != 2 || payload.Data[1].ID != "0" || len(payload.Included) != 2 {
+		t.Fatalf("payload %+v", payload)
+	}
+	rel := payload.Data[1].Relationships["customer"].(*jsonapi.RelationshipOneNode)
+	if rel.Data != nil {
+		t.Errorf("unset customer linkage %+v, want null", rel.Data)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	customer, appointment := testMessages(t)
+	node, _, err := Node(newAppointment(customer, appointment))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Decode it as it would arrive in a request.
+	b, err := json.Marshal(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(jsonapi.Node)
+	if err := json.Unmarshal(b, decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	m := dynamicpb.NewMessage(appointment)
+	if err := Unmarshal(decoded, m); err != nil {
+		t.Fatal(err)
+	}
+	fields := appointment.Fields()
+	if m.Get(fields.ByName("id")).Int() != 42 || m.Get(fields.ByName("note")).String() != "checkup" {
+		t.Errorf("message %v", m)
+	}
+	c := m.Get(fields.ByName("customer")).Message()
+	if c.Get(customer.Fields().ByName("id")).String() != "c1" || c.Get(customer.Fields().ByName("name")).String() != "" {
+		t.Errorf("customer %v, want its id only", c)
+	}
+	if list := m.Get(fields.ByName("attendees")).List(); list.Len() != 2 ||
+		list.Get(1).Message().Get(customer.Fields().ByName("id")).String() != "c2" {
+		t.Errorf("attendees %v", list)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	_, appointment := testMessages(t)
+
+	if err := Register(dynamicpb.NewMessage(appointment), "appointments", "missing"); err == nil {
+		t.Error("expected an error for a missing id field")
+	}
+	if err := Register(dynamicpb.NewMessage(appointment), "appointments", "customer"); err == nil {
+		t.Error("expected an error for a message id field")
+	}
+
+	if _, err := MarshalOne(&descriptorpb.FileDescriptorProto{}); err == nil {
+		t.Error("expected an error for an unregistered message")
+	}
+	if err := Unmarshal(jsonapi.NewNode("appointments", "1"), &descriptorpb.FileDescriptorProto{}); err == nil {
+		t.Error("expected an error for an unregistered message")
+	}
+	if err := Unmarshal(jsonapi.NewNode("customers", "1"), dynamicpb.NewMessage(appointment)); err == nil {
+		t.Error("expected an error for a resource of another type")
+	}
+
+	bad := jsonapi.NewNode("appointments", "1")
+	bad.Relationships = map[string]interface{}{"customer": map[string]interface{}{"data": 1}}
+	if err := Unmarshal(bad, dynamicpb.NewMessage(appointment)); err != jsonapi.ErrInvalidRelationship {
+		t.Errorf("error %v, want ErrInvalidRelationship", err)
+	}
+}
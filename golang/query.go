@@ -0,0 +1,189 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// SortField is a single entry of the sort query parameter.
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+func (s SortField) String() string {
+	if s.Descending {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// Query holds the JSON:API query parameters of a request: include,
+// fields[TYPE], sort, page[KEY] and filter[KEY].
+type Query struct {
+	Include []string
+	Fields  map[string][]string
+	Sort    []SortField
+	Page    map[string]string
+	Filter  map[string]string
+}
+
+// ParseSort parses a sort parameter such as "-created,title".
+func ParseSort(s string) []SortField {
+	var fields []SortField
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if strings.HasPrefix(f, "-") {
+			fields = append(fields, SortField{Field: f[1:], Descending: true})
+		} else {
+			fields = append(fields, SortField{Field: f})
+		}
+	}
+	return fields
+}
+
+// ParseQuery extracts the JSON:API query parameters from values. Unknown
+// parameters are ignored; a family parameter without its bracketed key,
+// e.g. "fields=title", is an error.
+func ParseQuery(values url.Values) (*Query, error) {
+	q := &Query{}
+
+	for key, vals := range values {
+		if len(vals) == 0 {
+			continue
+		}
+		value := vals[len(vals)-1]
+
+		switch {
+		case key == "include":
+			for _, p := range strings.Split(value, ",") {
+				if p = strings.TrimSpace(p); p != "" {
+					q.Include = append(q.Include, p)
+				}
+			}
+		case key == "sort":
+			q.Sort = ParseSort(value)
+		default:
+			family, name, ok := splitFamily(key)
+			if !ok {
+				if key == "fields" || key == "page" || key == "filter" {
+					return nil, fmt.Errorf("jsonapi: query parameter %q needs a [key]", key)
+				}
+				continue
+			}
+
+			switch family {
+			case "fields":
+				var fields []string
+				for _, f := range strings.Split(value, ",") {
+					if f = strings.TrimSpace(f); f != "" {
+						fields = append(fields, f)
+					}
+				}
+				q.SelectFields(name, fields...)
+			case "page":
+				q.Paging(name, value)
+			case "filter":
+				q.Filtering(name, value)
+			}
+		}
+	}
+
+	return q, nil
+}
+
+// splitFamily splits "fields[articles]" into "fields" and "articles".
+func splitFamily(key string) (string, string, bool) {
+	open := strings.IndexByte(key, '[')
+	if open <= 0 || !strings.HasSuffix(key, "]") || open+1 >= len(key)-1 {
+		return "", "", false
+	}
+	return key[:open], key[open+1 : len(key)-1], true
+}
+
+// Including adds relationship paths to the include parameter.
+func (q *Query) Including(paths ...string) *Query {
+	q.Include = append(q.Include, paths...)
+	return q
+}
+
+// SelectFields sets the sparse fieldset of the resource type typ.
+func (q *Query) SelectFields(typ string, fields ...string) *Query {
+	if q.Fields == nil {
+		q.Fields = map[string][]string{}
+	}
+	q.Fields[typ] = fields
+	return q
+}
+
+// SortBy appends sort fields given in query syntax, e.g. "-created".
+func (q *Query) SortBy(fields ...string) *Query {
+	q.Sort = append(q.Sort, ParseSort(strings.Join(fields, ","))...)
+	return q
+}
+
+// Paging sets page[key].
+func (q *Query) Paging(key, value string) *Query {
+	if q.Page == nil {
+		q.Page = map[string]string{}
+	}
+	q.Page[key] = value
+	return q
+}
+
+// Filtering sets filter[key].
+func (q *Query) Filtering(key, value string) *Query {
+	if q.Filter == nil {
+		q.Filter = map[string]string{}
+	}
+	q.Filter[key] = value
+	return q
+}
+
+// Values encodes q as URL query values.
+func (q *Query) Values() url.Values {
+	v := url.Values{}
+	if q == nil {
+		return v
+	}
+
+	if len(q.Include) > 0 {
+		v.Set("include", strings.Join(q.Include, ","))
+	}
+	for typ, fields := range q.Fields {
+		v.Set("fields["+typ+"]", strings.Join(fields, ","))
+	}
+	if len(q.Sort) > 0 {
+		sorts := make([]string, len(q.Sort))
+		for i, s := range q.Sort {
+			sorts[i] = s.String()
+		}
+		v.Set("sort", strings.Join(sorts, ","))
+	}
+	for key, value := range q.Page {
+		v.Set("page["+key+"]", value)
+	}
+	for key, value := range q.Filter {
+		v.Set("filter["+key+"]", value)
+	}
+
+	return v
+}
+
+// Encode encodes q in URL query form, sorted by key.
+func (q *Query) Encode() string {
+	return q.Values().Encode()
+}
+
+// MarshalOptions returns the marshal options implied by q: WithInclude
+// when an include parameter was given.
+func (q *Query) MarshalOptions() []MarshalOption {
+	if q == nil || q.Include == nil {
+		return nil
+	}
+	return []MarshalOption{WithInclude(q.Include...)}
+}
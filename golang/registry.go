@@ -0,0 +1,66 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var registry = struct {
+	sync.RWMutex
+	types map[string]reflect.Type
+}{types: map[string]reflect.Type{}}
+
+// RegisterType records the resource type declared by model's primary
+// annotation, so that relation fields of interface type, e.g. []Owner,
+// can be unmarshaled into the right concrete struct for each element.
+// model must be a pointer to a tagged struct. Registering a second model
+// for the same resource type replaces the first.
+func RegisterType(model interface{}) error {
+	t := reflect.TypeOf(model)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return ErrUnexpectedType
+	}
+
+	name := modelTypeName(t, nil)
+	if name == "" {
+		return fmt.Errorf("jsonapi: %s has no primary annotation", t.Elem())
+	}
+
+	registry.Lock()
+	registry.types[name] = t
+	registry.Unlock()
+
+	return nil
+}
+
+// RegisteredType returns the struct pointer type registered for the
+// resource type name.
+func RegisteredType(name string) (reflect.Type, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	t, ok := registry.types[name]
+	return t, ok
+}
+
+// newRelatedModel allocates the model a related resource of type typ is
+// unmarshaled into. t is the element type of the relation field: either a
+// struct pointer or an interface, in which case the registry decides.
+func newRelatedModel(t reflect.Type, typ string) (reflect.Value, error) {
+	if t.Kind() != reflect.Interface {
+		return reflect.New(t.Elem()), nil
+	}
+
+	registered, ok := RegisteredType(typ)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf(
+			"jsonapi: no type registered for resource type %q", typ)
+	}
+	if !registered.Implements(t) {
+		return reflect.Value{}, fmt.Errorf(
+			"jsonapi: %s registered for %q does not implement %s", registered, typ, t)
+	}
+
+	return reflect.New(registered.Elem()), nil
+}
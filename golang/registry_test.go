@@ -0,0 +1,103 @@
+package jsonapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type regOwner interface {
+	OwnerName() string
+}
+
+type regPerson struct {
+	ID   string `jsonapi:"primary,reg-people"`
+	Name string `jsonapi:"attr,name"`
+}
+
+func (p *regPerson) OwnerName() string { return p.Name }
+
+type regCompany struct {
+	ID    string `jsonapi:"primary,reg-companies"`
+	Title string `jsonapi:"attr,title"`
+}
+
+func (c *regCompany) OwnerName() string { return c.Title }
+
+type regPet struct {
+	ID     string     `jsonapi:"primary,reg-pets"`
+	Owner  regOwner   `jsonapi:"relation,owner"`
+	Owners []regOwner `jsonapi:"relation,owners"`
+}
+
+func TestRegisterType(t *testing.T) {
+	if err := RegisterType(new(regPerson)); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := RegisteredType("reg-people"); !ok || got != reflect.TypeOf(new(regPerson)) {
+		t.Errorf("RegisteredType = %v, %v", got, ok)
+	}
+	if _, ok := RegisteredType("reg-nobody"); ok {
+		t.Error("unregistered type found")
+	}
+
+	for _, v := range []interface{}{nil, regPerson{}, new(int)} {
+		if err := RegisterType(v); err == nil {
+			t.Errorf("RegisterType(%#v) succeeded", v)
+		}
+	}
+}
+
+func TestInterfaceRelationsRoundTrip(t *testing.T) {
+	for _, m := range []interface{}{new(regPerson), new(regCompany)} {
+		if err := RegisterType(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pet := &regPet{
+		ID:     "1",
+		Owner:  &regPerson{ID: "2", Name: "Ann"},
+		Owners: []regOwner{&regPerson{ID: "2", Name: "Ann"}, &regCompany{ID: "3", Title: "Acme"}},
+	}
+	b, err := MarshalBytes(pet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := new(regPet)
+	if err := UnmarshalPayload(strings.NewReader(string(b)), got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, pet) {
+		t.Errorf("round trip of %s:\ngot  %+v\nwant %+v", b, got, pet)
+	}
+}
+
+func TestInterfaceRelationsUnknownType(t *testing.T) {
+	doc := `{"data": {"type": "reg-pets", "id": "1",
+		"relationships": {"owner": {"data": {"type": "reg-robots", "id": "7"}}}}}`
+	if err := UnmarshalPayload(strings.NewReader(doc), new(regPet)); err == nil {
+		t.Error("expected an error for an unregistered resource type")
+	}
+}
+
+func TestNewRelatedModelChecksInterface(t *testing.T) {
+	if err := RegisterType(new(reqAuthor)); err != nil {
+		t.Fatal(err)
+	}
+	iface := reflect.TypeOf((*regOwner)(nil)).Elem()
+	if _, err := newRelatedModel(iface, "people"); err == nil {
+		t.Error("expected an error for a registered type not implementing the field's interface")
+	}
+	v, err := newRelatedModel(reflect.TypeOf(new(reqAuthor)), "anything")
+	if err != nil || v.Type() != reflect.TypeOf(new(reqAuthor)) {
+		t.Errorf("newRelatedModel for a struct pointer = %v, %v", v, err)
+	}
+}
+
+func TestCheckModelAcceptsInterfaceRelations(t *testing.T) {
+	if errs := CheckModel(new(regPet)); len(errs) != 0 {
+		t.Errorf("CheckModel = %v", errs)
+	}
+}
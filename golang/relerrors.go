@@ -0,0 +1,64 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// ErrNullToMany is the error of a RelationshipError for a to-many
+// relationship whose data is null. Only an empty array clears a to-many
+// relationship.
+var ErrNullToMany = errors.New("to-many relationship data must be an array, not null")
+
+// RelationshipError reports an invalid relationship object in a resource
+// of Type.
+type RelationshipError struct {
+	Type     string
+	Relation string
+	Err      error
+}
+
+func (e *RelationshipError) Error() string {
+	return fmt.Sprintf("jsonapi: relationship %q of resource of type %q: %v", e.Relation, e.Type, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *RelationshipError) Unwrap() error {
+	return e.Err
+}
+
+// Pointer returns a JSON pointer to the offending data member, relative to
+// the resource object, e.g. /relationships/tags/data.
+func (e *RelationshipError) Pointer() string {
+	return jsonPointer("relationships", e.Relation, "data")
+}
+
+// ErrorObject converts e into a 400 error object pointing at the primary
+// data of a single-resource document.
+func (e *RelationshipError) ErrorObject() *ErrorObject {
+	return &ErrorObject{
+		Title:  "Invalid relationship",
+		Detail: e.Err.Error(),
+		Status: strconv.Itoa(http.StatusBadRequest),
+		Source: &ErrorSource{Pointer: "/data" + e.Pointer()},
+	}
+}
+
+// linkageState reports whether the relationship object rel has a data
+// member, and whether that member is null.
+func linkageState(rel interface{}) (present, null bool) {
+	switch rel := rel.(type) {
+	case map[string]interface{}:
+		data, ok := rel["data"]
+		return ok, ok && data == nil
+	case *RelationshipOneNode:
+		return true, rel.Data == nil
+	case *RelationshipManyNode:
+		return true, rel.Data == nil
+	case *RelationshipLinksNode:
+		return false, false
+	}
+	return true, false
+}
@@ -0,0 +1,67 @@
+package jsonapi
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestToManyLinkage(t *testing.T) {
+	tests := []struct {
+		name     string
+		comments string
+		want     []*reqComment
+	}{
+		{"empty array", `{"data": []}`, []*reqComment{}},
+		{"links only", `{"links": {"related": "/articles/1/comments"}}`, []*reqComment{{ID: 1}}},
+		{"linkage", `{"data": [{"type": "comments", "id": "2"}]}`, []*reqComment{{ID: 2}}},
+	}
+	for _, tt := range tests {
+		doc := `{"data": {"type": "articles", "id": "1", "relationships": {"comments": ` + tt.comments + `}}}`
+		a := &reqArticle{Comments: []*reqComment{{ID: 1}}}
+		if err := UnmarshalPayload(strings.NewReader(doc), a); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(a.Comments, tt.want) {
+			t.Errorf("%s: comments %v, want %v", tt.name, a.Comments, tt.want)
+		}
+	}
+}
+
+func TestNullToMany(t *testing.T) {
+	doc := `{"data": {"type": "articles", "id": "1", "relationships": {"comments": {"data": null}}}}`
+	err := UnmarshalPayload(strings.NewReader(doc), new(reqArticle))
+
+	var relErr *RelationshipError
+	if !errors.As(err, &relErr) || !errors.Is(err, ErrNullToMany) {
+		t.Fatalf("error %v, want a RelationshipError for ErrNullToMany", err)
+	}
+	if relErr.Type != "articles" || relErr.Relation != "comments" || relErr.Pointer() != "/relationships/comments/data" {
+		t.Errorf("error %+v", relErr)
+	}
+	obj := relErr.ErrorObject()
+	if obj.Status != "400" || obj.Source.Pointer != "/data/relationships/comments/data" {
+		t.Errorf("error object %+v", obj)
+	}
+}
+
+func TestLinkageState(t *testing.T) {
+	tests := []struct {
+		rel           interface{}
+		present, null bool
+	}{
+		{map[string]interface{}{"data": nil}, true, true},
+		{map[string]interface{}{"data": []interface{}{}}, true, false},
+		{map[string]interface{}{"links": map[string]interface{}{}}, false, false},
+		{&RelationshipOneNode{}, true, true},
+		{&RelationshipManyNode{Data: []*Node{}}, true, false},
+		{&RelationshipLinksNode{}, false, false},
+	}
+	for _, tt := range tests {
+		if present, null := linkageState(tt.rel); present != tt.present || null != tt.null {
+			t.Errorf("%#v: got %v, %v, want %v, %v", tt.rel, present, null, tt.present, tt.null)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+//go:build chi
+// +build chi
+
+package render
+
+import (
+	"net/http"
+
+	chirender "github.com/go-chi/render"
+
+	jsonapi "test3"
+)
+
+// The hooks of go-chi/render, which ChiResponder and ChiDecoder return
+// replacements for.
+var (
+	_ func(http.ResponseWriter, *http.Request, interface{}) = chirender.Respond
+	_ func(*http.Request, interface{}) error                = chirender.Decode
+)
+
+// ChiResponder returns a hook for go-chi/render's Respond that writes v as
+// Respond does with opts, but with the status set by chirender.Status, if
+// any, e.g. chirender.Respond = render.ChiResponder().
+func ChiResponder(opts ...jsonapi.MarshalOption) func(w http.ResponseWriter, r *http.Request, v interface{}) {
+	return func(w http.ResponseWriter, r *http.Request, v interface{}) {
+		if status, ok := r.Context().Value(chirender.StatusCtxKey).(int); ok {
+			_ = Write(w, status, v, opts...)
+			return
+		}
+		respond(w, v, opts)
+	}
+}
+
+// ChiDecoder returns a hook for go-chi/render's Decode that decodes
+// JSON:API request bodies with opts, e.g. chirender.Decode =
+// render.ChiDecoder().
+func ChiDecoder(opts ...jsonapi.UnmarshalOption) func(r *http.Request, v interface{}) error {
+	return Binder{Options: opts}.Bind
+}
@@ -0,0 +1,75 @@
+//go:build chi
+// +build chi
+
+package render
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	chirender "github.com/go-chi/render"
+
+	jsonapi "test3"
+)
+
+// chiPost is a post that implements chirender.Binder.
+type chiPost struct {
+	ID    string `jsonapi:"primary,posts"`
+	Title string `jsonapi:"attr,title"`
+}
+
+func (p *chiPost) Bind(r *http.Request) error {
+	if p.Title == "" {
+		return errors.New("title is required")
+	}
+	return nil
+}
+
+func TestChi(t *testing.T) {
+	respond, decode := chirender.Respond, chirender.Decode
+	defer func() { chirender.Respond, chirender.Decode = respond, decode }()
+	chirender.Respond = ChiResponder(jsonapi.WithIndent("", "  "))
+	chirender.Decode = ChiDecoder()
+
+	r := chi.NewRouter()
+	r.Post("/posts", func(w http.ResponseWriter, r *http.Request) {
+		p := new(chiPost)
+		if err := chirender.Bind(r, p); err != nil {
+			chirender.Status(r, http.StatusUnprocessableEntity)
+			chirender.Respond(w, r, err)
+			return
+		}
+		chirender.Status(r, http.StatusCreated)
+		chirender.Respond(w, r, p)
+	})
+	r.Get("/posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		chirender.Respond(w, r, &post{ID: chi.URLParam(r, "id"), Title: "Hi"})
+	})
+
+	tests := []struct {
+		method, path, body string
+		status             int
+		want               string
+	}{
+		{http.MethodPost, "/posts", `{"data":{"type":"posts","id":"1","attributes":{"title":"Hi"}}}`,
+			http.StatusCreated, `"title": "Hi"`},
+		{http.MethodPost, "/posts", `{"data":{"type":"posts","id":"1","attributes":{}}}`,
+			http.StatusUnprocessableEntity, `"status": "422"`},
+		{http.MethodGet, "/posts/7", "", http.StatusOK, `"id": "7"`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != tt.status || !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("%s %s: status %d, body %s", tt.method, tt.path, rec.Code, rec.Body)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != jsonapi.MediaType {
+			t.Errorf("%s %s: content type %q", tt.method, tt.path, ct)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+//go:build echo
+// +build echo
+
+package render
+
+import (
+	"github.com/labstack/echo/v4"
+
+	jsonapi "test3"
+)
+
+// EchoBinder implements echo.Binder, decoding JSON:API request bodies,
+// e.g. e.Binder = render.EchoBinder{}.
+type EchoBinder struct {
+	Options []jsonapi.UnmarshalOption
+}
+
+// Bind decodes the body of the request of c into i, a pointer to a tagged
+// struct.
+func (b EchoBinder) Bind(i interface{}, c echo.Context) error {
+	return Binder{Options: b.Options}.Bind(c.Request(), i)
+}
+
+// EchoWrite renders v with the given status code on the response of c, as
+// Write does with opts, e.g. return render.EchoWrite(c, http.StatusOK, post).
+func EchoWrite(c echo.Context, status int, v interface{}, opts ...jsonapi.MarshalOption) error {
+	return Write(c.Response(), status, v, opts...)
+}
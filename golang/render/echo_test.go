@@ -0,0 +1,34 @@
+//go:build echo
+// +build echo
+
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestEcho(t *testing.T) {
+	e := echo.New()
+	e.Binder = EchoBinder{}
+	e.POST("/posts", func(c echo.Context) error {
+		p := new(post)
+		if err := c.Bind(p); err != nil {
+			return err
+		}
+		return EchoWrite(c, http.StatusCreated, p)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/posts",
+		strings.NewReader(`{"data":{"type":"posts","id":"1","attributes":{"title":"Hi"}}}`))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"title":"Hi"`) {
+		t.Errorf("status %d, body %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,49 @@
+//go:build gin
+// +build gin
+
+package render
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	ginrender "github.com/gin-gonic/gin/render"
+
+	jsonapi "test3"
+)
+
+var (
+	_ ginrender.Render = JSONAPI{}
+	_ ginrender.Render = Errors{}
+	_ binding.Binding  = GinBinding{}
+)
+
+// GinBinding implements gin's binding.Binding, decoding JSON:API request
+// bodies, e.g. c.ShouldBindWith(post, render.GinBinding{}). Like gin's own
+// bindings, it validates the result with binding.Validator.
+type GinBinding struct {
+	Options []jsonapi.UnmarshalOption
+}
+
+// Name returns the name of the binding.
+func (GinBinding) Name() string {
+	return "jsonapi"
+}
+
+// Bind decodes the body of req into obj, a pointer to a tagged struct.
+func (b GinBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := (Binder{Options: b.Options}).Bind(req, obj); err != nil {
+		return err
+	}
+	if binding.Validator == nil {
+		return nil
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// GinWrite renders v with the given status code on the response of c, as
+// Write does with opts, e.g. render.GinWrite(c, http.StatusOK, post).
+func GinWrite(c *gin.Context, status int, v interface{}, opts ...jsonapi.MarshalOption) error {
+	return Write(c.Writer, status, v, opts...)
+}
@@ -0,0 +1,57 @@
+//go:build gin
+// +build gin
+
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	jsonapi "test3"
+)
+
+func TestGin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/posts", func(c *gin.Context) {
+		p := new(post)
+		if err := c.ShouldBindWith(p, GinBinding{}); err != nil {
+			_ = GinWrite(c, http.StatusBadRequest, jsonapi.ErrorObjectsFor(err))
+			return
+		}
+		_ = GinWrite(c, http.StatusCreated, p, jsonapi.WithIndent("", "  "))
+	})
+	r.GET("/posts", func(c *gin.Context) {
+		c.Render(http.StatusOK, JSONAPI{Models: []*post{{ID: "1", Title: "Hi"}}})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/posts",
+		strings.NewReader(`{"data":{"type":"posts","id":"1","attributes":{"title":"Hi"}}}`))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"title": "Hi"`) {
+		t.Errorf("POST status %d, body %s", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != jsonapi.MediaType {
+		t.Errorf("POST content type %q", ct)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(`{"data":`))
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"errors"`) {
+		t.Errorf("POST of a malformed document: status %d, body %s", rec.Code, rec.Body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/posts", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != jsonapi.MediaType ||
+		!strings.Contains(rec.Body.String(), `"title":"Hi"`) {
+		t.Errorf("GET status %d, content type %q, body %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+}
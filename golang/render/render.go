@@ -0,0 +1,145 @@
+// Package render adapts the jsonapi marshaler to common Go web frameworks.
+//
+// The types and functions here only depend on net/http and match the
+// interfaces the frameworks expect structurally, so importing this package
+// does not pull any framework into a build:
+//
+//   - gin: JSONAPI and Errors implement gin's render.Render, e.g.
+//     c.Render(http.StatusOK, render.JSONAPI{Models: posts}).
+//   - chi: Respond has the signature of chi's render.Respond hook, e.g.
+//     chirender.Respond = render.Respond.
+//
+// The adapters whose signatures name framework types are only built with
+// the build tag of their framework:
+//
+//   - echo: EchoBinder implements echo.Binder and EchoWrite writes echo
+//     responses.
+//   - gin: GinBinding implements gin's binding.Binding and GinWrite writes
+//     gin responses.
+//   - chi: ChiResponder and ChiDecoder return hooks for go-chi/render's
+//     Respond and Decode that take options.
+package render
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+
+	jsonapi "test3"
+)
+
+// JSONAPI renders Models as a JSON:API document.
+type JSONAPI struct {
+	Models  interface{}
+	Options []jsonapi.MarshalOption
+}
+
+// Render writes the document to w.
+func (r JSONAPI) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return jsonapi.MarshalPayload(w, r.Models, r.Options...)
+}
+
+// WriteContentType sets the JSON:API media type on w.
+func (r JSONAPI) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w)
+}
+
+// Errors renders an errors document.
+type Errors struct {
+	Errors  []*jsonapi.ErrorObject
+	Options []jsonapi.MarshalOption
+}
+
+// Render writes the errors document to w.
+func (r Errors) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return jsonapi.MarshalErrors(w, r.Errors, r.Options...)
+}
+
+// WriteContentType sets the JSON:API media type on w.
+func (r Errors) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w)
+}
+
+// Write renders v with the given status code, as jsonapi.MarshalPayload
+// does with opts. v may be anything accepted by jsonapi.Marshal, a
+// *jsonapi.ErrorObject, a []*jsonapi.ErrorObject or an error; plain errors
+// are reported as an errors document with the status, 500 if it is not an
+// error status, without leaking their message.
+func Write(w http.ResponseWriter, status int, v interface{}, opts ...jsonapi.MarshalOption) error {
+	switch v := v.(type) {
+	case *jsonapi.ErrorObject:
+		return Write(w, status, []*jsonapi.ErrorObject{v}, opts...)
+	case []*jsonapi.ErrorObject:
+		writeContentType(w)
+		w.WriteHeader(status)
+		return jsonapi.MarshalErrors(w, v, opts...)
+	case error:
+		if status < http.StatusBadRequest {
+			status = http.StatusInternalServerError
+		}
+		return Write(w, status, &jsonapi.ErrorObject{
+			Title:  http.StatusText(status),
+			Status: strconv.Itoa(status),
+		}, opts...)
+	}
+
+	// Marshal before writing the header so that a marshaling failure can
+	// still be reported with the right status.
+	var buf bytes.Buffer
+	if err := jsonapi.MarshalPayload(&buf, v, opts...); err != nil {
+		return Write(w, http.StatusInternalServerError, err, opts...)
+	}
+
+	writeContentType(w)
+	w.WriteHeader(status)
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// Respond writes v as a JSON:API document. Errors objects use the status
+// of their first entry, errors that of jsonapi.StatusForError, everything
+// else is sent with 200 OK. It matches
+// the signature of chi's render.Respond.
+func Respond(w http.ResponseWriter, r *http.Request, v interface{}) {
+	respond(w, v, nil)
+}
+
+func respond(w http.ResponseWriter, v interface{}, opts []jsonapi.MarshalOption) {
+	status := http.StatusOK
+
+	switch e := v.(type) {
+	case *jsonapi.ErrorObject:
+		status = errorStatus(e)
+	case []*jsonapi.ErrorObject:
+		if len(e) > 0 {
+			status = errorStatus(e[0])
+		}
+	case error:
+		status = jsonapi.StatusForError(e)
+	}
+
+	_ = Write(w, status, v, opts...)
+}
+
+func errorStatus(e *jsonapi.ErrorObject) int {
+	if code, err := strconv.Atoi(e.Status); err == nil && code >= 400 {
+		return code
+	}
+	return http.StatusInternalServerError
+}
+
+// Binder decodes JSON:API request bodies.
+type Binder struct {
+	Options []jsonapi.UnmarshalOption
+}
+
+// Bind decodes the body of req into v, a pointer to a tagged struct.
+func (b Binder) Bind(req *http.Request, v interface{}) error {
+	return jsonapi.UnmarshalPayload(req.Body, v, b.Options...)
+}
+
+func writeContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", jsonapi.MediaType)
+}
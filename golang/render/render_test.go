@@ -0,0 +1,173 @@
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	jsonapi "test3"
+)
+
+type post struct {
+	ID    string `jsonapi:"primary,posts"`
+	Title string `jsonapi:"attr,title"`
+}
+
+// decodeErrors returns the statuses of the error objects in the body of rec.
+func decodeErrors(t *testing.T, rec *httptest.ResponseRecorder) []string {
+	t.Helper()
+	var doc struct {
+		Errors []struct {
+			Status string `json:"status"`
+			Detail string `json:"detail"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid errors document %s: %v", rec.Body, err)
+	}
+	var statuses []string
+	for _, e := range doc.Errors {
+		if e.Detail != "" {
+			t.Errorf("error detail %q leaked", e.Detail)
+		}
+		statuses = append(statuses, e.Status)
+	}
+	return statuses
+}
+
+func TestWrite(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := Write(rec, http.StatusCreated, &post{ID: "1", Title: "Hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusCreated || rec.Header().Get("Content-Type") != jsonapi.MediaType {
+		t.Errorf("status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), `"title":"Hi"`) {
+		t.Errorf("body %s", rec.Body)
+	}
+}
+
+func TestWriteOptions(t *testing.T) {
+	opts := []jsonapi.MarshalOption{jsonapi.WithIndent("", "  "), jsonapi.WithEscapeHTML(false)}
+
+	rec := httptest.NewRecorder()
+	if err := Write(rec, http.StatusOK, &post{ID: "1", Title: "<Hi>"}, opts...); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(rec.Body.String(), `"title": "<Hi>"`) {
+		t.Errorf("body %s", rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	if err := Write(rec, http.StatusNotFound, errors.New("secret"), opts...); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(rec.Body.String(), `"status": "404"`) {
+		t.Errorf("errors body %s", rec.Body)
+	}
+}
+
+func TestWriteErrorHonorsStatus(t *testing.T) {
+	tests := []struct {
+		status, want int
+	}{
+		{http.StatusNotFound, http.StatusNotFound},
+		{http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+		{http.StatusOK, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		if err := Write(rec, tt.status, errors.New("secret")); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != tt.want {
+			t.Errorf("Write(%d, err) status = %d, want %d", tt.status, rec.Code, tt.want)
+		}
+		if got := decodeErrors(t, rec); len(got) != 1 || got[0] != strconv.Itoa(tt.want) {
+			t.Errorf("Write(%d, err) error statuses = %v", tt.status, got)
+		}
+	}
+}
+
+func TestRespond(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	rec := httptest.NewRecorder()
+	Respond(rec, req, []*jsonapi.ErrorObject{{Status: "409"}, {Status: "400"}})
+	if rec.Code != http.StatusConflict {
+		t.Errorf("errors status = %d, want the first object's", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	Respond(rec, req, jsonapi.ErrBodyTooLarge)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("error status = %d, want that of StatusForError", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	Respond(rec, req, &post{ID: "1"})
+	if rec.Code != http.StatusOK {
+		t.Errorf("model status = %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	Respond(rec, req, nil)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("nil status = %d", rec.Code)
+	}
+}
+
+func TestWriteNil(t *testing.T) {
+	var nilPost *post
+	for _, v := range []interface{}{nil, nilPost} {
+		rec := httptest.NewRecorder()
+		if err := Write(rec, http.StatusOK, v); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("%#v: status %d", v, rec.Code)
+		}
+		if got := decodeErrors(t, rec); len(got) != 1 || got[0] != "500" {
+			t.Errorf("%#v: error statuses %v", v, got)
+		}
+	}
+}
+
+func TestRenderers(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := (JSONAPI{Models: []*post{{ID: "1"}}}).Render(rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Header().Get("Content-Type") != jsonapi.MediaType || !strings.Contains(rec.Body.String(), `"data":[`) {
+		t.Errorf("JSONAPI rendered %q", rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	if err := (Errors{Errors: []*jsonapi.ErrorObject{{Status: "400"}}}).Render(rec); err != nil {
+		t.Fatal(err)
+	}
+	if got := decodeErrors(t, rec); len(got) != 1 || got[0] != "400" {
+		t.Errorf("Errors rendered %s", rec.Body)
+	}
+}
+
+func TestBinder(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/posts",
+		strings.NewReader(`{"data":{"type":"posts","attributes":{"title":"Hi","x":1}}}`))
+
+	p := new(post)
+	if err := (Binder{}).Bind(req, p); err != nil || p.Title != "Hi" {
+		t.Errorf("Bind = %+v, %v", p, err)
+	}
+
+	req.Body = httptest.NewRequest(http.MethodPost, "/posts",
+		strings.NewReader(`{"data":{"type":"posts","attributes":{"x":1}}}`)).Body
+	if err := (Binder{Options: []jsonapi.UnmarshalOption{jsonapi.DisallowUnknownFields()}}).Bind(req, p); err == nil {
+		t.Error("Binder ignored its options")
+	}
+}
@@ -0,0 +1,927 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidTime = errors.New("Only numbers can be parsed as dates, unix timestamps")
+
+	ErrInvalidISO8601 = errors.New("Only strings can be parsed as dates, ISO8601 timestamps")
+
+	ErrInvalidRFC3339 = errors.New("Only strings can be parsed as dates, RFC3339 timestamps")
+
+	ErrUnknownFieldNumberType = errors.New("The struct field was not of a known number type")
+
+	ErrInvalidType = errors.New("Invalid type provided")
+
+	// ErrNumberOutOfRange is returned for a number that does not fit the
+	// numeric field it is stored in, such as 300 for an int8.
+	ErrNumberOutOfRange = errors.New("jsonapi: number out of range of the field type")
+
+	// ErrFractionalNumber is returned for a number with a fractional part
+	// stored in an integer field.
+	ErrFractionalNumber = errors.New("jsonapi: fractional number for an integer field")
+)
+
+// ErrUnsupportedPtrType is returned when an attribute cannot be stored in
+// a pointer field of the target struct.
+type ErrUnsupportedPtrType struct {
+	rf          reflect.Value
+	t           reflect.Type
+	structField reflect.StructField
+}
+
+func (e ErrUnsupportedPtrType) Error() string {
+	typeName := e.t.Elem().Name()
+	kind := e.t.Elem().Kind()
+	if kind.String() != "" && kind.String() != typeName {
+		typeName = fmt.Sprintf("%s (%s)", typeName, kind.String())
+	}
+	return fmt.Sprintf(
+		"jsonapi: Can't unmarshal %+v (%s) to struct field `%s`, which is a pointer to `%s`",
+		e.rf, e.rf.Type().Kind(), e.structField.Name, typeName,
+	)
+}
+
+func newErrUnsupportedPtrType(rf reflect.Value, t reflect.Type, structField reflect.StructField) error {
+	return ErrUnsupportedPtrType{rf, t, structField}
+}
+
+// UnmarshalPayload reads a single-resource document from in and stores its
+// primary data, along with any related resources found in included, in
+// model, which must be a pointer to a tagged struct.
+func UnmarshalPayload(in io.Reader, model interface{}, opts ...UnmarshalOption) error {
+	o := newUnmarshalOptions(opts)
+
+	return o.hooks.unmarshal(modelTypeName(reflect.TypeOf(model), &o.tagOptions), in, func(in io.Reader, s *HookStats) error {
+		if v := reflect.ValueOf(model); v.Kind() != reflect.Ptr || v.IsNil() {
+			return ErrUnexpectedType
+		}
+
+		payload := new(OnePayload)
+		if err := o.decodeNodes(in, payload); err != nil {
+			return err
+		}
+		o.collectIncluded(payload.Included)
+		o.collectIdentities(payload.Data)
+		if payload.Data == nil {
+			return nil
+		}
+		s.Resources, s.Included = 1, len(payload.Included)
+		if err := o.checkIncluded(len(payload.Included)); err != nil {
+			return err
+		}
+
+		included := includedMap(payload.Included)
+
+		if err := unmarshalNode(payload.Data, reflect.ValueOf(model), included, o, 0); err != nil {
+			return err
+		}
+		if o.validate {
+			var objs []*ErrorObject
+			validateAttributes(reflect.ValueOf(model), "/data", o, &objs)
+			if len(objs) > 0 {
+				return &AttributeValidationError{Errors: objs}
+			}
+		}
+		return nil
+	})
+}
+
+// UnmarshalManyPayload reads a collection document from in and returns one
+// new model of type t, a struct pointer type, per resource in data.
+func UnmarshalManyPayload(in io.Reader, t reflect.Type, opts ...UnmarshalOption) ([]interface{}, error) {
+	o := newUnmarshalOptions(opts)
+
+	var models []interface{}
+	err := o.hooks.unmarshal(modelTypeName(t, &o.tagOptions), in, func(in io.Reader, s *HookStats) error {
+		if t == nil || t.Kind() != reflect.Ptr {
+			return ErrUnexpectedType
+		}
+
+		payload := new(ManyPayload)
+		if err := o.decodeNodes(in, payload); err != nil {
+			return err
+		}
+		o.collectIncluded(payload.Included)
+		o.collectIdentities(payload.Data...)
+		s.Resources, s.Included = len(payload.Data), len(payload.Included)
+		if err := o.checkIncluded(len(payload.Included)); err != nil {
+			return err
+		}
+
+		models = []interface{}{}
+		included := includedMap(payload.Included)
+
+		var objs []*ErrorObject
+		for i, data := range payload.Data {
+			model := reflect.New(t.Elem())
+			if err := unmarshalNode(data, model, included, o, 0); err != nil {
+				return err
+			}
+			if o.validate {
+				validateAttributes(model, jsonPointer("data", strconv.Itoa(i)), o, &objs)
+			}
+			models = append(models, model.Interface())
+		}
+		if len(objs) > 0 {
+			return &AttributeValidationError{Errors: objs}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return models, nil
+}
+
+func includedMap(nodes []*Node) *map[string]*Node {
+	if nodes == nil {
+		return nil
+	}
+
+	included := make(map[string]*Node, len(nodes))
+	for _, n := range nodes {
+		included[fmt.Sprintf("%s,%s", n.Type, n.ID)] = n
+	}
+	return &included
+}
+
+// unmarshalNode stores data in model. depth counts the relationships
+// followed from the primary data.
+func unmarshalNode(data *Node, model reflect.Value, included *map[string]*Node,
+	o *unmarshalOptions, depth int) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("data is not a jsonapi representation of '%v'", model.Type())
+		}
+	}()
+
+	if o.maxDepth > 0 && depth > o.maxDepth {
+		return ErrMaxDepth
+	}
+
+	modelValue := model.Elem()
+	modelType := modelValue.Type()
+
+	if o.disallowUnknown {
+		if err := checkUnknownMembers(data, modelType, o); err != nil {
+			return err
+		}
+	}
+
+	var er error
+
+	for i := 0; i < modelValue.NumField(); i++ {
+		fieldType := modelType.Field(i)
+		tag := renameReserved(fieldTag(fieldType, o.tagKey, o.jsonFallback), o.reservedPrefix)
+		if tag == "" {
+			continue
+		}
+
+		fieldValue := modelValue.Field(i)
+
+		args := strings.Split(tag, annotationSeperator)
+
+		if len(args) < 1 {
+			er = tagError(modelType, fieldType, tag, "empty tag")
+			break
+		}
+
+		if !inVersion(args, o.version) {
+			continue
+		}
+
+		annotation := args[0]
+
+		noArgs := annotation == annotationClientID || annotation == annotationExtras
+		if (noArgs && len(args) != 1) || (!noArgs && annotation != annotationPrimary && len(args) < 2) {
+			er = tagError(modelType, fieldType, tag, "wrong number of arguments for "+annotation)
+			break
+		}
+
+		if annotation == annotationPrimary {
+			if data.ID == "" {
+				continue
+			}
+
+			if typ := o.primaryType(modelType, args); data.Type != typ {
+				er = fmt.Errorf(
+					"Trying to Unmarshal an object of type %#v, but %#v does not match",
+					data.Type,
+					typ,
+				)
+				break
+			}
+
+			// Types such as MongoDB's ObjectID parse their own string form.
+			idType := fieldType.Type
+			if idType.Kind() == reflect.Ptr {
+				idType = idType.Elem()
+			}
+			if reflect.PtrTo(idType).Implements(textUnmarshalerType) {
+				id := reflect.New(idType)
+				if err := id.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(data.ID)); err != nil {
+					er = &IDError{Type: data.Type, ID: data.ID, Err: ErrBadJSONAPIID}
+					break
+				}
+				assign(fieldValue, id)
+				continue
+			}
+			if !isValidIDType(fieldType.Type) {
+				er = idTypeError(modelType, fieldType, tag)
+				break
+			}
+
+			// ID will have to be transmitted as a string per the JSON API spec
+			v := reflect.ValueOf(data.ID)
+
+			var kind reflect.Kind
+			if fieldValue.Kind() == reflect.Ptr {
+				kind = fieldType.Type.Elem().Kind()
+			} else {
+				kind = fieldType.Type.Kind()
+			}
+
+			if kind == reflect.String {
+				assign(fieldValue, v)
+				continue
+			}
+
+			// Value was not a string; the only other supported type is a
+			// number, which has to be parsed out of the id.
+			idValue, err := handleJSONNumber(json.Number(data.ID), fieldType.Type)
+			if err != nil {
+				er = &IDError{Type: data.Type, ID: data.ID, Err: ErrBadJSONAPIID}
+				break
+			}
+
+			assign(fieldValue, idValue)
+		} else if annotation == annotationClientID {
+			if data.ClientID == "" {
+				continue
+			}
+
+			if fieldValue.Kind() != reflect.String {
+				er = tagError(modelType, fieldType, tag, "client-id field must be a string")
+				break
+			}
+			fieldValue.SetString(data.ClientID)
+		} else if annotation == annotationAttribute {
+			attribute, ok := data.Attributes[args[1]]
+
+			// continue if the attribute was not included in the request,
+			// after applying its default
+			if !ok {
+				if literal, ok := defaultOption(args[2:]); ok && !o.merge {
+					if err := setDefault(fieldValue, literal); err != nil {
+						er = tagError(modelType, fieldType, tag, err.Error())
+						break
+					}
+				}
+				continue
+			}
+
+			if hasOption(args, annotationReadOnly) {
+				if o.ignoreReadOnly {
+					continue
+				}
+				er = &AttributeError{Type: data.Type, Attribute: args[1], Err: ErrReadOnly}
+				break
+			}
+
+			if o.transformer != nil {
+				attribute, er = o.transformer.TransformAttribute(data.Type, args[1], o.floatNumbers(attribute))
+				if er != nil {
+					break
+				}
+			}
+
+			// An explicit null clears nullable fields and leaves the others
+			// untouched.
+			if attribute == nil {
+				switch fieldValue.Kind() {
+				case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+					fieldValue.Set(reflect.Zero(fieldValue.Type()))
+				}
+				continue
+			}
+
+			value, err := unmarshalAttribute(attribute, args, fieldType, fieldValue, o)
+			if err != nil {
+				er = &AttributeError{Type: data.Type, Attribute: args[1], Err: err}
+				break
+			}
+
+			assign(fieldValue, value)
+		} else if annotation == annotationRelation {
+			toMany := fieldValue.Kind() == reflect.Slice || fieldValue.Kind() == reflect.Map
+
+			if data.Relationships == nil || data.Relationships[args[1]] == nil {
+				continue
+			}
+
+			// A relationship object with links or meta only does not
+			// change the field. A to-many relationship is cleared with
+			// an empty array; null is an error.
+			present, null := linkageState(data.Relationships[args[1]])
+			if !present {
+				continue
+			}
+			if toMany && null {
+				er = &RelationshipError{Type: data.Type, Relation: args[1], Err: ErrNullToMany}
+				break
+			}
+
+			if typ, ok := idsRelationType(args, fieldValue.Type()); ok {
+				if err := unmarshalIDsRelationship(data.Relationships[args[1]], fieldValue, typ, o); err != nil {
+					er = &RelationshipError{Type: data.Type, Relation: args[1], Err: err}
+					break
+				}
+				continue
+			}
+
+			if toMany {
+				// to-many relationship
+				relationship := new(RelationshipManyNode)
+
+				if err := remarshal(data.Relationships[args[1]], relationship); err != nil {
+					er = err
+					break
+				}
+				if o.maxFanout > 0 && len(relationship.Data) > o.maxFanout {
+					er = &RelationshipError{Type: data.Type, Relation: args[1], Err: ErrRelationshipFanout}
+					break
+				}
+
+				models := reflect.MakeSlice(reflect.SliceOf(fieldValue.Type().Elem()), 0, len(relationship.Data))
+				existing := o.relatedModels(fieldValue)
+
+				for _, n := range relationship.Data {
+					m, err := o.relatedModel(existing[nodeKey(n)], fieldValue.Type().Elem(), n)
+					if err != nil {
+						er = err
+						break
+					}
+
+					if err := unmarshalNode(
+						fullNode(n, included),
+						m,
+						included,
+						o,
+						depth+1,
+					); err != nil {
+						er = err
+						break
+					}
+
+					models = reflect.Append(models, m)
+				}
+				if er != nil {
+					break
+				}
+
+				if fieldValue.Kind() == reflect.Map {
+					models = relatedMap(fieldValue.Type(), models, relationship.Data)
+				}
+				fieldValue.Set(models)
+			} else {
+				// to-one relationships
+				relationship := new(RelationshipOneNode)
+
+				if err := remarshal(data.Relationships[args[1]], relationship); err != nil {
+					er = err
+					break
+				}
+
+				// A null relationship clears the field, which matters
+				// with UnmarshalInto.
+				if relationship.Data == nil {
+					fieldValue.Set(reflect.Zero(fieldValue.Type()))
+					continue
+				}
+
+				// Models held by value are decoded through a pointer.
+				target := fieldValue
+				byValue := target.Kind() == reflect.Struct
+				if byValue {
+					target = target.Addr()
+				}
+
+				m, err := o.relatedModel(target, target.Type(), relationship.Data)
+				if err != nil {
+					er = err
+					break
+				}
+
+				if err := unmarshalNode(
+					fullNode(relationship.Data, included),
+					m,
+					included,
+					o,
+					depth+1,
+				); err != nil {
+					er = err
+					break
+				}
+
+				if byValue {
+					m = m.Elem()
+				}
+				fieldValue.Set(m)
+			}
+		} else if annotation == annotationExtras {
+			if !isExtrasType(fieldType.Type) {
+				er = tagError(modelType, fieldType, tag, extrasTypeMsg(fieldType.Type))
+				break
+			}
+			if err := unmarshalExtras(data, modelType, fieldValue, o); err != nil {
+				er = err
+				break
+			}
+		} else {
+			er = tagError(modelType, fieldType, tag, fmt.Sprintf("unknown annotation %q", annotation))
+			break
+		}
+	}
+
+	return er
+}
+
+// relatedMap indexes models, decoded from the resource linkage nodes, by
+// id in a map of type t.
+func relatedMap(t reflect.Type, models reflect.Value, nodes []*Node) reflect.Value {
+	m := reflect.MakeMapWithSize(t, len(nodes))
+	for i, n := range nodes {
+		m.SetMapIndex(reflect.ValueOf(n.ID).Convert(t.Key()), models.Index(i))
+	}
+	return m
+}
+
+// remarshal converts a generically decoded JSON value into dst.
+func remarshal(v interface{}, dst interface{}) error {
+	buf := bytes.NewBuffer(nil)
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	return json.NewDecoder(buf).Decode(dst)
+}
+
+// fullNode returns the included resource for the identifier n, or n itself
+// when it was not included.
+func fullNode(n *Node, included *map[string]*Node) *Node {
+	includedKey := fmt.Sprintf("%s,%s", n.Type, n.ID)
+
+	if included != nil && (*included)[includedKey] != nil {
+		return (*included)[includedKey]
+	}
+
+	return n
+}
+
+// assign will take the value specified and assign it to the field; if
+// field is expecting a ptr assign will assign a ptr.
+func assign(field, value reflect.Value) {
+	value = reflect.Indirect(value)
+
+	if field.Kind() == reflect.Ptr {
+		// initialize pointer so its value can be set by assignValue
+		field.Set(reflect.New(field.Type().Elem()))
+		field = field.Elem()
+	}
+
+	assignValue(field, value)
+}
+
+// assignValue assigns the specified value to the field, expecting both
+// values not to be pointer types.
+func assignValue(field, value reflect.Value) {
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16,
+		reflect.Int32, reflect.Int64:
+		field.SetInt(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		field.SetUint(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		field.SetFloat(value.Float())
+	case reflect.String:
+		field.SetString(value.String())
+	case reflect.Bool:
+		field.SetBool(value.Bool())
+	default:
+		field.Set(value)
+	}
+}
+
+func unmarshalAttribute(
+	attribute interface{},
+	args []string,
+	structField reflect.StructField,
+	fieldValue reflect.Value,
+	o *unmarshalOptions) (value reflect.Value, err error) {
+	value = reflect.ValueOf(attribute)
+	fieldType := structField.Type
+
+	// Handle field of type time.Time
+	if fieldValue.Type() == reflect.TypeOf(time.Time{}) ||
+		fieldValue.Type() == reflect.TypeOf(new(time.Time)) {
+		value, err = handleTime(attribute, args, fieldValue)
+		return
+	}
+
+	elemType := fieldType
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	// Handle slices of times, formatted per element
+	if isTimeSlice(elemType) {
+		value, err = handleTimeSlice(attribute, args, elemType)
+		return
+	}
+
+	// Handle URLs, IP and mail addresses, sent in their string forms
+	if _, ok := lookupStringType(elemType); ok {
+		value, err = unmarshalStringType(attribute, elemType, args)
+		return
+	}
+
+	// Handle registered enums and slices of them, sent as names
+	if isEnumType(elemType) {
+		value, err = unmarshalEnum(attribute, elemType)
+		return
+	}
+
+	// Handle big numbers and decimals, sent as strings or numbers
+	if isDecimalType(elemType) {
+		value, err = unmarshalDecimal(attribute, elemType)
+		return
+	}
+
+	// Handle byte slices and arrays, sent as base64
+	if isBytesType(elemType) {
+		value, err = unmarshalBytes(attribute, elemType, args)
+		return
+	}
+
+	// Handle types parsing their own text form, such as UUIDs
+	if text, ok := attribute.(string); ok && isTextUnmarshalerType(elemType) {
+		value, err = unmarshalText(text, elemType)
+		return
+	}
+
+	// Handle structs, slices and maps the way encoding/json would, which
+	// is also how the marshal side encodes them.
+	switch elemType.Kind() {
+	case reflect.Struct, reflect.Map:
+		if o.merge {
+			value, err = mergeJSON(attribute, fieldValue, elemType, o.useNumber)
+			return
+		}
+		fallthrough
+	case reflect.Slice:
+		value, err = handleJSON(attribute, elemType, o.useNumber)
+		return
+	}
+
+	// interface{} fields take the decoded JSON value as is: a float64, or
+	// a json.Number with UseNumber.
+	if fieldValue.Kind() == reflect.Interface {
+		value = reflect.ValueOf(o.floatNumbers(attribute))
+		return
+	}
+
+	// JSON value was a number decoded with UseNumber
+	if num, ok := attribute.(json.Number); ok {
+		value, err = handleJSONNumber(num, fieldType)
+		return
+	}
+
+	// JSON value was a float (numeric)
+	if value.Kind() == reflect.Float64 {
+		value, err = handleNumeric(attribute, fieldType)
+		return
+	}
+
+	// Field was a Pointer type
+	if fieldValue.Kind() == reflect.Ptr {
+		value, err = handlePointer(attribute, fieldType, fieldValue, structField)
+		return
+	}
+
+	// As a final catch-all, ensure types line up to avoid a runtime panic.
+	if fieldValue.Kind() != value.Kind() {
+		err = ErrInvalidType
+		return
+	}
+
+	return
+}
+
+func handleJSON(attribute interface{}, t reflect.Type, useNumber bool) (reflect.Value, error) {
+	model := reflect.New(t)
+
+	buf := bytes.NewBuffer(nil)
+	if err := json.NewEncoder(buf).Encode(attribute); err != nil {
+		return reflect.Value{}, ErrInvalidType
+	}
+	dec := json.NewDecoder(buf)
+	if useNumber {
+		dec.UseNumber()
+	}
+	if err := dec.Decode(model.Interface()); err != nil {
+		return reflect.Value{}, ErrInvalidType
+	}
+	return model, nil
+}
+
+// handleJSONNumber parses num into the numeric kind of fieldType without a
+// detour through float64, so large integers keep their precision. Numbers
+// that do not fit the field, or have a fractional part for an integer
+// field, are rejected rather than truncated.
+func handleJSONNumber(num json.Number, fieldType reflect.Type) (reflect.Value, error) {
+	t := fieldType
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	v := reflect.New(t)
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		text, err := integerText(num)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		n, err := strconv.ParseInt(text, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, numberError(err)
+		}
+		v.Elem().SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		text, err := integerText(num)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if strings.HasPrefix(text, "-") {
+			return reflect.Value{}, ErrNumberOutOfRange
+		}
+		n, err := strconv.ParseUint(text, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, numberError(err)
+		}
+		v.Elem().SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(num.String(), t.Bits())
+		if err != nil {
+			return reflect.Value{}, numberError(err)
+		}
+		v.Elem().SetFloat(n)
+	case reflect.String:
+		// json.Number is itself a string type.
+		if t != reflect.TypeOf(num) {
+			return reflect.Value{}, ErrInvalidType
+		}
+		v.Elem().SetString(num.String())
+	default:
+		return reflect.Value{}, ErrUnknownFieldNumberType
+	}
+
+	return v, nil
+}
+
+// integerText rewrites num, a JSON number, as a plain decimal integer, e.g.
+// "1200" for 1.2e3 and "3" for 3.0, so that strconv can parse it exactly.
+// It returns ErrFractionalNumber if num is not a whole number.
+func integerText(num json.Number) (string, error) {
+	s, sign := num.String(), ""
+	if strings.HasPrefix(s, "-") {
+		s, sign = s[1:], "-"
+	}
+
+	exp := 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return "", numberError(err)
+		}
+		s, exp = s[:i], e
+	}
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		exp -= len(s) - i - 1
+		s = s[:i] + s[i+1:]
+	}
+	if s == "" || strings.Trim(s, "0123456789") != "" {
+		return "", ErrInvalidType
+	}
+
+	s = strings.TrimLeft(s, "0")
+	for exp < 0 && strings.HasSuffix(s, "0") {
+		s, exp = s[:len(s)-1], exp+1
+	}
+	switch {
+	case s == "":
+		return "0", nil
+	case exp < 0:
+		return "", ErrFractionalNumber
+	case exp > 20:
+		// More digits than any 64-bit integer has.
+		return "", ErrNumberOutOfRange
+	}
+	return sign + s + strings.Repeat("0", exp), nil
+}
+
+// numberError maps a strconv error to ErrNumberOutOfRange or
+// ErrInvalidType.
+func numberError(err error) error {
+	if errors.Is(err, strconv.ErrRange) {
+		return ErrNumberOutOfRange
+	}
+	return ErrInvalidType
+}
+
+// floatNumbers returns v, a decoded JSON value, with the json.Number values
+// kept by decodeNodes turned into float64, unless UseNumber asked for
+// json.Number. Maps and slices holding numbers are copied.
+func (o *unmarshalOptions) floatNumbers(v interface{}) interface{} {
+	if o.useNumber {
+		return v
+	}
+
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			// Out of the range of float64, as encoding/json would
+			// have reported; keep the text.
+			return v
+		}
+		return f
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = o.floatNumbers(e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = o.floatNumbers(e)
+		}
+		return s
+	}
+	return v
+}
+
+// floatNodes returns copies of nodes, decoded by decodeNodes, with the
+// numbers of their members turned into float64 by floatNumbers, for
+// handing nodes out to callers.
+func (o *unmarshalOptions) floatNodes(nodes []*Node) []*Node {
+	if o.useNumber || nodes == nil {
+		return nodes
+	}
+
+	floats := make([]*Node, len(nodes))
+	for i, n := range nodes {
+		floats[i] = o.floatNode(n)
+	}
+	return floats
+}
+
+func (o *unmarshalOptions) floatNode(n *Node) *Node {
+	if o.useNumber || n == nil {
+		return n
+	}
+
+	c := *n
+	if n.Attributes != nil {
+		c.Attributes = o.floatNumbers(n.Attributes).(map[string]interface{})
+	}
+	if n.Relationships != nil {
+		c.Relationships = o.floatNumbers(n.Relationships).(map[string]interface{})
+	}
+	c.Meta = o.floatMeta(n.Meta)
+	c.Links = o.floatLinks(n.Links)
+	return &c
+}
+
+func (o *unmarshalOptions) floatMeta(meta *Meta) *Meta {
+	if o.useNumber || meta == nil {
+		return meta
+	}
+	m := Meta(o.floatNumbers(map[string]interface{}(*meta)).(map[string]interface{}))
+	return &m
+}
+
+func (o *unmarshalOptions) floatLinks(links *Links) *Links {
+	if o.useNumber || links == nil {
+		return links
+	}
+	l := Links(o.floatNumbers(map[string]interface{}(*links)).(map[string]interface{}))
+	return &l
+}
+
+// handleNumeric stores attribute, a float64 decoded without json.Number,
+// in the numeric kind of fieldType, with the checks of handleJSONNumber.
+func handleNumeric(attribute interface{}, fieldType reflect.Type) (reflect.Value, error) {
+	f := attribute.(float64)
+	return handleJSONNumber(json.Number(strconv.FormatFloat(f, 'g', -1, 64)), fieldType)
+}
+
+func handleTime(attribute interface{}, args []string, fieldValue reflect.Value) (reflect.Value, error) {
+	var isISO8601, isRFC3339 bool
+	v := reflect.ValueOf(attribute)
+
+	if len(args) > 2 {
+		for _, arg := range args[2:] {
+			if arg == annotationISO8601 {
+				isISO8601 = true
+			} else if arg == annotationRFC3339 {
+				isRFC3339 = true
+			}
+		}
+	}
+
+	if isISO8601 {
+		if v.Kind() != reflect.String {
+			return reflect.ValueOf(time.Now()), ErrInvalidISO8601
+		}
+
+		t, err := time.Parse(iso8601TimeFormat, v.Interface().(string))
+		if err != nil {
+			return reflect.ValueOf(time.Now()), ErrInvalidISO8601
+		}
+
+		return reflect.ValueOf(t), nil
+	}
+
+	if isRFC3339 {
+		if v.Kind() != reflect.String {
+			return reflect.ValueOf(time.Now()), ErrInvalidRFC3339
+		}
+
+		t, err := time.Parse(time.RFC3339, v.Interface().(string))
+		if err != nil {
+			return reflect.ValueOf(time.Now()), ErrInvalidRFC3339
+		}
+
+		return reflect.ValueOf(t), nil
+	}
+
+	var at int64
+
+	if num, ok := attribute.(json.Number); ok {
+		n, err := num.Int64()
+		if err != nil {
+			return reflect.ValueOf(time.Now()), ErrInvalidTime
+		}
+		at = n
+	} else if v.Kind() == reflect.Float64 {
+		at = int64(v.Interface().(float64))
+	} else if v.Kind() == reflect.Int {
+		at = v.Int()
+	} else {
+		return reflect.ValueOf(time.Now()), ErrInvalidTime
+	}
+
+	t := time.Unix(at, 0)
+
+	return reflect.ValueOf(t), nil
+}
+
+func handlePointer(
+	attribute interface{},
+	fieldType reflect.Type,
+	fieldValue reflect.Value,
+	structField reflect.StructField) (reflect.Value, error) {
+	v := reflect.ValueOf(attribute)
+	elem := fieldValue.Type().Elem()
+
+	switch v.Kind() {
+	case reflect.String, reflect.Bool:
+	default:
+		return reflect.Value{}, newErrUnsupportedPtrType(v, fieldType, structField)
+	}
+
+	// Pointers to named types such as `type Status string` are accepted
+	// as long as the underlying kind matches.
+	if elem.Kind() != v.Kind() {
+		return reflect.Value{}, newErrUnsupportedPtrType(v, fieldType, structField)
+	}
+
+	concreteVal := reflect.New(elem)
+	concreteVal.Elem().Set(v.Convert(elem))
+
+	return concreteVal, nil
+}
@@ -0,0 +1,425 @@
+package jsonapi
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type reqAuthor struct {
+	ID   string `jsonapi:"primary,people"`
+	Name string `jsonapi:"attr,name"`
+}
+
+type reqComment struct {
+	ID   int    `jsonapi:"primary,comments"`
+	Body string `jsonapi:"attr,body"`
+}
+
+type reqArticle struct {
+	ID        string        `jsonapi:"primary,articles"`
+	Title     string        `jsonapi:"attr,title"`
+	Views     int           `jsonapi:"attr,views"`
+	Score     float64       `jsonapi:"attr,score"`
+	Published bool          `jsonapi:"attr,published"`
+	Created   time.Time     `jsonapi:"attr,created"`
+	Updated   time.Time     `jsonapi:"attr,updated,iso8601"`
+	Author    *reqAuthor    `jsonapi:"relation,author"`
+	Comments  []*reqComment `jsonapi:"relation,comments"`
+}
+
+const reqArticleDoc = `{
+	"data": {
+		"type": "articles", "id": "1",
+		"attributes": {
+			"title": "Hello", "views": 3, "score": 4.5, "published": true,
+			"created": 1600000000, "updated": "2020-09-13T12:26:40Z"
+		},
+		"relationships": {
+			"author": {"data": {"type": "people", "id": "9"}},
+			"comments": {"data": [{"type": "comments", "id": "5"}, {"type": "comments", "id": "6"}]}
+		}
+	},
+	"included": [
+		{"type": "people", "id": "9", "attributes": {"name": "Ann"}},
+		{"type": "comments", "id": "5", "attributes": {"body": "First"}}
+	]
+}`
+
+func TestUnmarshalPayload(t *testing.T) {
+	a := new(reqArticle)
+	if err := UnmarshalPayload(strings.NewReader(reqArticleDoc), a); err != nil {
+		t.Fatal(err)
+	}
+
+	if a.ID != "1" || a.Title != "Hello" || a.Views != 3 || a.Score != 4.5 || !a.Published {
+		t.Errorf("attributes not set: %+v", a)
+	}
+	if !a.Created.Equal(time.Unix(1600000000, 0)) || !a.Updated.Equal(time.Unix(1600000000, 0)) {
+		t.Errorf("times = %v, %v", a.Created, a.Updated)
+	}
+	if a.Author == nil || a.Author.ID != "9" || a.Author.Name != "Ann" {
+		t.Errorf("author = %+v, want the included person", a.Author)
+	}
+	if len(a.Comments) != 2 || a.Comments[0].Body != "First" || a.Comments[1].ID != 6 {
+		t.Errorf("comments = %+v", a.Comments)
+	}
+}
+
+func TestUnmarshalManyPayload(t *testing.T) {
+	doc := `{"data": [
+		{"type": "people", "id": "1", "attributes": {"name": "Ann"}},
+		{"type": "people", "id": "2", "attributes": {"name": "Bob"}}]}`
+	models, err := UnmarshalManyPayload(strings.NewReader(doc), reflect.TypeOf(new(reqAuthor)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 2 {
+		t.Fatalf("got %d models, want 2", len(models))
+	}
+	if a := models[1].(*reqAuthor); a.ID != "2" || a.Name != "Bob" {
+		t.Errorf("models[1] = %+v", a)
+	}
+}
+
+func TestUnmarshalPayloadErrors(t *testing.T) {
+	tests := map[string]string{
+		"wrong type":     `{"data": {"type": "people", "id": "1"}}`,
+		"bad attribute":  `{"data": {"type": "articles", "id": "1", "attributes": {"views": "many"}}}`,
+		"bad time":       `{"data": {"type": "articles", "id": "1", "attributes": {"created": "yesterday"}}}`,
+		"bad iso8601":    `{"data": {"type": "articles", "id": "1", "attributes": {"updated": 5}}}`,
+		"invalid json":   `{"data": `,
+		"bad to-one":     `{"data": {"type": "articles", "id": "1", "relationships": {"author": {"data": 1}}}}`,
+		"bad comment id": `{"data": {"type": "articles", "id": "1", "relationships": {"comments": {"data": [{"type": "comments", "id": "x"}]}}}}`,
+	}
+	for name, doc := range tests {
+		if err := UnmarshalPayload(strings.NewReader(doc), new(reqArticle)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestUnmarshalPayloadNullData(t *testing.T) {
+	a := &reqArticle{Title: "kept"}
+	if err := UnmarshalPayload(strings.NewReader(`{"data": null}`), a); err != nil {
+		t.Fatal(err)
+	}
+	if a.Title != "kept" {
+		t.Errorf("model changed by a document without data: %+v", a)
+	}
+}
+
+type reqNumbers struct {
+	ID     int64       `jsonapi:"primary,numbers"`
+	I64    int64       `jsonapi:"attr,i64"`
+	PI64   *int64      `jsonapi:"attr,pi64"`
+	I8     int8        `jsonapi:"attr,i8"`
+	U64    uint64      `jsonapi:"attr,u64"`
+	PU8    *uint8      `jsonapi:"attr,pu8"`
+	F32    float32     `jsonapi:"attr,f32"`
+	Any    interface{} `jsonapi:"attr,any"`
+	Counts []int       `jsonapi:"attr,counts"`
+}
+
+func TestUnmarshalNumbersExactly(t *testing.T) {
+	doc := `{"data": {"type": "numbers", "id": "9007199254740993", "attributes": {
+		"i64": 9007199254740993, "pi64": -9007199254740993, "i8": -128,
+		"u64": 18446744073709551615, "pu8": 2.55e2, "f32": 1.5,
+		"any": {"n": 1}, "counts": [1, 2]}}}`
+
+	n := new(reqNumbers)
+	if err := UnmarshalPayload(strings.NewReader(doc), n); err != nil {
+		t.Fatal(err)
+	}
+	if n.ID != 9007199254740993 || n.I64 != 9007199254740993 || n.PI64 == nil || *n.PI64 != -9007199254740993 {
+		t.Errorf("int64 values lost precision: id %d, i64 %d, pi64 %v", n.ID, n.I64, n.PI64)
+	}
+	if n.I8 != -128 || n.U64 != 18446744073709551615 || n.PU8 == nil || *n.PU8 != 255 || n.F32 != 1.5 {
+		t.Errorf("got %+v", n)
+	}
+	if !reflect.DeepEqual(n.Any, map[string]interface{}{"n": float64(1)}) {
+		t.Errorf("interface{} attribute = %#v, want float64 numbers", n.Any)
+	}
+	if !reflect.DeepEqual(n.Counts, []int{1, 2}) {
+		t.Errorf("counts = %v", n.Counts)
+	}
+}
+
+func TestUnmarshalNumbersRejectsLossyValues(t *testing.T) {
+	tests := []struct {
+		attrs string
+		want  error
+	}{
+		{`{"i8": 300}`, ErrNumberOutOfRange},
+		{`{"i8": -129}`, ErrNumberOutOfRange},
+		{`{"i64": 9223372036854775808}`, ErrNumberOutOfRange},
+		{`{"i64": 1e30}`, ErrNumberOutOfRange},
+		{`{"u64": -1}`, ErrNumberOutOfRange},
+		{`{"pu8": 256}`, ErrNumberOutOfRange},
+		{`{"f32": 1e39}`, ErrNumberOutOfRange},
+		{`{"i64": 1.5}`, ErrFractionalNumber},
+		{`{"pi64": 2.000001}`, ErrFractionalNumber},
+		{`{"u64": 1e-3}`, ErrFractionalNumber},
+	}
+	for _, tt := range tests {
+		doc := `{"data": {"type": "numbers", "id": "1", "attributes": ` + tt.attrs + `}}`
+		err := UnmarshalPayload(strings.NewReader(doc), new(reqNumbers))
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.attrs, err, tt.want)
+		}
+	}
+
+	err := UnmarshalPayload(strings.NewReader(`{"data": {"type": "numbers", "id": "1.5"}}`), new(reqNumbers))
+	if !errors.Is(err, ErrBadJSONAPIID) {
+		t.Errorf("fractional id: error = %v, want ErrBadJSONAPIID", err)
+	}
+}
+
+func TestUnmarshalNumbersUseNumber(t *testing.T) {
+	doc := `{"data": {"type": "numbers", "id": "1", "attributes": {"i64": 12, "any": 9007199254740993}}}`
+	n := new(reqNumbers)
+	if err := UnmarshalPayload(strings.NewReader(doc), n, UseNumber()); err != nil {
+		t.Fatal(err)
+	}
+	if n.I64 != 12 || n.Any != json.Number("9007199254740993") {
+		t.Errorf("got i64 %d, any %#v", n.I64, n.Any)
+	}
+}
+
+func TestIntegerText(t *testing.T) {
+	tests := map[string]string{
+		"0": "0", "-0": "0", "0.000": "0", "12": "12", "-12": "-12",
+		"1.2e3": "1200", "3.0": "3", "1500e-2": "15", "12E+2": "1200", "007": "7",
+	}
+	for in, want := range tests {
+		if got, err := integerText(json.Number(in)); err != nil || got != want {
+			t.Errorf("integerText(%s) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"1.5", "1e-1", "-0.01"} {
+		if _, err := integerText(json.Number(in)); err != ErrFractionalNumber {
+			t.Errorf("integerText(%s) error = %v, want ErrFractionalNumber", in, err)
+		}
+	}
+}
+
+func TestHandleNumericChecksFloats(t *testing.T) {
+	if _, err := handleNumeric(float64(300), reflect.TypeOf(int8(0))); err != ErrNumberOutOfRange {
+		t.Errorf("300 into int8: error = %v", err)
+	}
+	if _, err := handleNumeric(2.5, reflect.TypeOf(new(int))); err != ErrFractionalNumber {
+		t.Errorf("2.5 into *int: error = %v", err)
+	}
+	v, err := handleNumeric(float64(1<<53), reflect.TypeOf(int64(0)))
+	if err != nil || v.Elem().Int() != 1<<53 {
+		t.Errorf("2^53 into int64 = %v, %v", v, err)
+	}
+}
+
+func TestCollectIncludedNumbers(t *testing.T) {
+	doc := `{"data": {"type": "numbers", "id": "1"},
+		"included": [{"type": "numbers", "id": "2", "attributes": {"i64": 9007199254740993}}]}`
+
+	var inc Included
+	if err := UnmarshalPayload(strings.NewReader(doc), new(reqNumbers), CollectIncluded(&inc)); err != nil {
+		t.Fatal(err)
+	}
+	if v := inc.Get("numbers", "2").Attributes["i64"]; v != float64(9007199254740993) {
+		t.Errorf("Get attribute = %#v, want a float64", v)
+	}
+	n := new(reqNumbers)
+	if err := inc.Decode("numbers", "2", n); err != nil || n.I64 != 9007199254740993 {
+		t.Errorf("Decode = %d, %v, want the exact number", n.I64, err)
+	}
+}
+
+// reqObjectID mimics MongoDB's ObjectID: a byte array with a hex text form.
+type reqObjectID [4]byte
+
+func (id reqObjectID) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(id[:])), nil
+}
+
+func (id *reqObjectID) UnmarshalText(b []byte) error {
+	if hex.DecodedLen(len(b)) != len(id) {
+		return errors.New("bad object id")
+	}
+	_, err := hex.Decode(id[:], b)
+	return err
+}
+
+type reqDocument struct {
+	ID    reqObjectID `jsonapi:"primary,documents"`
+	Title string      `jsonapi:"attr,title"`
+}
+
+func TestTextMarshalerID(t *testing.T) {
+	in := &reqDocument{ID: reqObjectID{0xde, 0xad, 0xbe, 0xef}, Title: "t"}
+	b, err := MarshalBytes(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"id":"deadbeef"`) {
+		t.Errorf("document %s lacks the text id", b)
+	}
+
+	out := new(reqDocument)
+	if err := UnmarshalPayload(strings.NewReader(string(b)), out); err != nil {
+		t.Fatal(err)
+	}
+	if out.ID != in.ID || out.Title != "t" {
+		t.Errorf("round trip %+v, want %+v", out, in)
+	}
+
+	doc := `{"data": {"type": "documents", "id": "nothex"}}`
+	if err := UnmarshalPayload(strings.NewReader(doc), new(reqDocument)); !errors.Is(err, ErrBadJSONAPIID) {
+		t.Errorf("error %v, want ErrBadJSONAPIID", err)
+	}
+	if errs := CheckModel(new(reqDocument)); errs != nil {
+		t.Errorf("CheckModel = %v, want nil", errs)
+	}
+}
+
+// reqSlug is a named string type without a text form of its own.
+type reqSlug string
+
+type reqSlugged struct {
+	ID    reqSlug `jsonapi:"primary,slugs"`
+	Title string  `jsonapi:"attr,title"`
+}
+
+type reqSlugRef struct {
+	ID       string  `jsonapi:"primary,refs"`
+	ClientID reqSlug `jsonapi:"client-id"`
+}
+
+func TestNamedStringID(t *testing.T) {
+	var me *ModelError
+	if _, err := MarshalBytes(&reqSlugged{ID: "a"}); !errors.As(err, &me) || !errors.Is(err, ErrBadJSONAPIID) {
+		t.Errorf("Marshal error %v, want a *ModelError for ErrBadJSONAPIID", err)
+	}
+	doc := `{"data": {"type": "slugs", "id": "a", "attributes": {"title": "t"}}}`
+	if err := UnmarshalPayload(strings.NewReader(doc), new(reqSlugged)); !errors.As(err, &me) || !errors.Is(err, ErrBadJSONAPIID) {
+		t.Errorf("Unmarshal error %v, want a *ModelError for ErrBadJSONAPIID", err)
+	}
+	if errs := CheckModel(new(reqSlugged)); len(errs) != 1 {
+		t.Errorf("CheckModel = %v, want the primary field", errs)
+	}
+
+	b, err := MarshalBytes(&reqSlugRef{ClientID: "tmp-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(reqSlugRef)
+	if err := UnmarshalPayload(strings.NewReader(string(b)), out); err != nil {
+		t.Fatal(err)
+	}
+	if out.ClientID != "tmp-1" {
+		t.Errorf("client id %q, want tmp-1 from %s", out.ClientID, b)
+	}
+}
+
+type reqSettings struct {
+	ID      string                    `jsonapi:"primary,settings"`
+	Values  map[string]interface{}    `jsonapi:"attr,values"`
+	Limits  struct{ Max interface{} } `jsonapi:"attr,limits"`
+	Numbers []interface{}             `jsonapi:"attr,numbers"`
+}
+
+func TestUnmarshalNestedNumbers(t *testing.T) {
+	doc := `{"data": {"type": "settings", "id": "1", "attributes": {
+		"values": {"big": 9007199254740993, "nested": {"n": 1.5}},
+		"limits": {"Max": 12345678901234567890},
+		"numbers": [1, 2.5]}}}`
+
+	s := new(reqSettings)
+	if err := UnmarshalPayload(strings.NewReader(doc), s, UseNumber()); err != nil {
+		t.Fatal(err)
+	}
+	nested, _ := s.Values["nested"].(map[string]interface{})
+	if s.Values["big"] != json.Number("9007199254740993") || nested["n"] != json.Number("1.5") {
+		t.Errorf("values %#v", s.Values)
+	}
+	if s.Limits.Max != json.Number("12345678901234567890") {
+		t.Errorf("limits %#v", s.Limits)
+	}
+	if len(s.Numbers) != 2 || s.Numbers[1] != json.Number("2.5") {
+		t.Errorf("numbers %#v", s.Numbers)
+	}
+
+	// Without UseNumber nested values are float64, as with encoding/json.
+	s = new(reqSettings)
+	if err := UnmarshalPayload(strings.NewReader(doc), s); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Values["big"].(float64); !ok {
+		t.Errorf("values %#v, want float64 numbers", s.Values)
+	}
+}
+
+func TestUnmarshalNil(t *testing.T) {
+	var nilArticle *reqArticle
+	for _, model := range []interface{}{nil, nilArticle, reqArticle{}} {
+		if err := UnmarshalPayload(strings.NewReader(reqArticleDoc), model); err != ErrUnexpectedType {
+			t.Errorf("%#v: error %v, want ErrUnexpectedType", model, err)
+		}
+	}
+
+	for _, typ := range []reflect.Type{nil, reflect.TypeOf(reqArticle{})} {
+		if _, err := UnmarshalManyPayload(strings.NewReader(`{"data": []}`), typ); err != ErrUnexpectedType {
+			t.Errorf("%v: error %v, want ErrUnexpectedType", typ, err)
+		}
+	}
+}
+
+func TestUnmarshalValueRelation(t *testing.T) {
+	doc := `{"data": {"type": "comments", "id": "1", "relationships": {
+			"author": {"data": {"type": "people", "id": "9"}},
+			"editor": {"data": null}}},
+		"included": [{"type": "people", "id": "9", "attributes": {"name": "Ann"}}]}`
+	out := &valueComment{Editor: reqAuthor{ID: "3"}}
+	if err := UnmarshalPayload(strings.NewReader(doc), out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Author != (reqAuthor{ID: "9", Name: "Ann"}) {
+		t.Errorf("author %+v", out.Author)
+	}
+	if out.Editor != (reqAuthor{}) {
+		t.Errorf("editor %+v, want it cleared by null", out.Editor)
+	}
+}
+
+func TestUnmarshalMapRelation(t *testing.T) {
+	doc := `{"data": {"type": "posts", "id": "1", "relationships": {
+			"comments": {"data": [{"type": "people", "id": "a"}, {"type": "people", "id": "b"}]},
+			"editors": {"data": []}}},
+		"included": [{"type": "people", "id": "a", "attributes": {"name": "Ann"}}]}`
+	out := new(mapPost)
+	if err := UnmarshalPayload(strings.NewReader(doc), out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Comments) != 2 || out.Comments["a"].Name != "Ann" || out.Comments["b"].ID != "b" {
+		t.Errorf("comments %v", out.Comments)
+	}
+	if out.Editors == nil || len(out.Editors) != 0 {
+		t.Errorf("editors %v, want an empty map", out.Editors)
+	}
+
+	// Merging keeps the attributes of the models already in the map.
+	into := &mapPost{Comments: map[commentKey]*reqAuthor{"b": {ID: "b", Name: "Bob"}}}
+	if err := UnmarshalInto(strings.NewReader(doc), into); err != nil {
+		t.Fatal(err)
+	}
+	if into.Comments["b"].Name != "Bob" || into.Comments["a"].Name != "Ann" {
+		t.Errorf("merged comments %v", into.Comments)
+	}
+
+	null := `{"data": {"type": "posts", "id": "1", "relationships": {"comments": {"data": null}}}}`
+	if err := UnmarshalPayload(strings.NewReader(null), new(mapPost)); !errors.Is(err, ErrNullToMany) {
+		t.Errorf("error %v, want ErrNullToMany", err)
+	}
+}
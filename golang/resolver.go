@@ -0,0 +1,36 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// RelationshipResolver is implemented by models that load related records
+// lazily. When a document is marshaled WithInclude and a requested relation
+// field is nil, ResolveRelationship is called with the relationship name and
+// its result is marshaled in place of the field. The result must be
+// assignable to the field: a struct pointer for to-one relationships or a
+// slice of struct pointers for to-many ones. Returning nil leaves the
+// relationship empty.
+type RelationshipResolver interface {
+	ResolveRelationship(name string) (interface{}, error)
+}
+
+func resolveRelationship(r RelationshipResolver, name string,
+	fieldType reflect.Type) (reflect.Value, error) {
+	resolved, err := r.ResolveRelationship(name)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if resolved == nil {
+		return reflect.Value{}, nil
+	}
+
+	v := reflect.ValueOf(resolved)
+	if !v.Type().AssignableTo(fieldType) {
+		return reflect.Value{}, fmt.Errorf(
+			"jsonapi: relationship %q resolved to %s, expected %s",
+			name, v.Type(), fieldType)
+	}
+	return v, nil
+}
@@ -0,0 +1,114 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type resolverAuthor struct {
+	ID   string `jsonapi:"primary,people"`
+	Name string `jsonapi:"attr,name"`
+}
+
+type resolverPost struct {
+	ID       string            `jsonapi:"primary,posts"`
+	Author   *resolverAuthor   `jsonapi:"relation,author"`
+	Comments []*resolverAuthor `jsonapi:"relation,commenters"`
+
+	calls []string
+	err   error
+}
+
+func (p *resolverPost) ResolveRelationship(name string) (interface{}, error) {
+	p.calls = append(p.calls, name)
+	if p.err != nil {
+		return nil, p.err
+	}
+	switch name {
+	case "author":
+		return &resolverAuthor{ID: "9", Name: "Ann"}, nil
+	case "commenters":
+		return "not a slice of people", nil
+	}
+	return nil, nil
+}
+
+// marshalDoc marshals v and decodes the document into a generic map.
+func marshalDoc(t *testing.T, v interface{}, opts ...MarshalOption) map[string]interface{} {
+	t.Helper()
+	b, err := MarshalBytes(v, opts...)
+	if err != nil {
+		t.Fatalf("MarshalBytes: %v", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("invalid document %s: %v", b, err)
+	}
+	return doc
+}
+
+func TestWithIncludeResolvesRequestedRelationships(t *testing.T) {
+	p := &resolverPost{ID: "1"}
+	doc := marshalDoc(t, p, WithInclude("author"))
+
+	if len(p.calls) != 1 || p.calls[0] != "author" {
+		t.Errorf("resolved %v, want only author", p.calls)
+	}
+	included, _ := doc["included"].([]interface{})
+	if len(included) != 1 {
+		t.Fatalf("included = %v, want the resolved author", doc["included"])
+	}
+	if id := included[0].(map[string]interface{})["id"]; id != "9" {
+		t.Errorf("included id = %v, want 9", id)
+	}
+}
+
+func TestWithoutIncludeDoesNotResolve(t *testing.T) {
+	p := &resolverPost{ID: "1"}
+	marshalDoc(t, p)
+	if len(p.calls) != 0 {
+		t.Errorf("resolved %v without WithInclude", p.calls)
+	}
+}
+
+func TestWithIncludeRestrictsIncluded(t *testing.T) {
+	p := &resolverPost{
+		ID:       "1",
+		Author:   &resolverAuthor{ID: "9"},
+		Comments: []*resolverAuthor{{ID: "10"}},
+	}
+	doc := marshalDoc(t, p, WithInclude("commenters"))
+
+	included, _ := doc["included"].([]interface{})
+	if len(included) != 1 || included[0].(map[string]interface{})["id"] != "10" {
+		t.Errorf("included = %v, want only the commenter", doc["included"])
+	}
+}
+
+func TestResolveRelationshipErrors(t *testing.T) {
+	boom := errors.New("boom")
+	if _, err := MarshalBytes(&resolverPost{ID: "1", err: boom}, WithInclude("author")); !errors.Is(err, boom) {
+		t.Errorf("error = %v, want the resolver's error", err)
+	}
+	if _, err := MarshalBytes(&resolverPost{ID: "1"}, WithInclude("commenters")); err == nil {
+		t.Error("expected an error for a resolved value of the wrong type")
+	}
+}
+
+func TestIncludesNestedPaths(t *testing.T) {
+	o := newMarshalOptions([]MarshalOption{WithInclude("comments.author")})
+	for path, want := range map[string]bool{
+		"comments":        true,
+		"comments.author": true,
+		"author":          false,
+		"comments.post":   false,
+	} {
+		if got := o.includes(path); got != want {
+			t.Errorf("includes(%q) = %v, want %v", path, got, want)
+		}
+	}
+	if !newMarshalOptions(nil).includes("anything") {
+		t.Error("without WithInclude every path should be included")
+	}
+}
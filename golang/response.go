@@ -1,10 +1,14 @@
 package jsonapi
 
 import (
+	"bytes"
+	"encoding"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -21,16 +25,130 @@ var (
 	ErrUnexpectedType = errors.New("models should be a struct pointer or slice of struct pointers")
 )
 
-func MarshalPayload(w io.Writer, models interface{}) error {
-	payload, err := Marshal(models)
-	if err != nil {
+func MarshalPayload(w io.Writer, models interface{}, opts ...MarshalOption) error {
+	o := newMarshalOptions(opts).newState()
+
+	return o.hooks.marshal(modelTypeName(reflect.TypeOf(models), &o.tagOptions), func(s *HookStats) error {
+		payload, err := marshal(models, o, s)
+		if err != nil {
+			return err
+		}
+		if o.denormalize {
+			denormalizePayload(payload)
+		}
+		if o.describedBy != "" {
+			addDescribedBy(payload, modelTypeName(reflect.TypeOf(models), &o.tagOptions), o.describedBy)
+		}
+
+		if len(o.hooks) == 0 {
+			return encodePayload(w, payload, o.marshalOptions)
+		}
+		cw := &countingWriter{w: w}
+		err = encodePayload(cw, payload, o.marshalOptions)
+		s.Bytes = cw.n
 		return err
+	})
+}
+
+// MarshalBytes is like MarshalPayload but returns the encoded document.
+// As with json.Marshal, the result has no trailing newline.
+func MarshalBytes(models interface{}, opts ...MarshalOption) ([]byte, error) {
+	opts = append(opts[:len(opts):len(opts)], WithoutTrailingNewline())
+
+	var buf bytes.Buffer
+	if err := MarshalPayload(&buf, models, opts...); err != nil {
+		return nil, err
 	}
 
-	return json.NewEncoder(w).Encode(payload)
+	return buf.Bytes(), nil
+}
+
+// EncodePayload writes payload, typically returned by Marshal and then
+// amended, with the codec, indentation and escaping of opts, as
+// MarshalPayload would write it. Other options are ignored.
+func EncodePayload(w io.Writer, payload interface{}, opts ...MarshalOption) error {
+	return encodePayload(w, payload, newMarshalOptions(opts))
 }
 
-func Marshal(models interface{}) (Payloader, error) {
+func encodePayload(w io.Writer, payload interface{}, o *marshalOptions) error {
+	if !o.noTrailingNewline {
+		return newEncoder(w, o).Encode(payload)
+	}
+
+	var buf bytes.Buffer
+	if err := newEncoder(&buf, o).Encode(payload); err != nil {
+		return err
+	}
+
+	_, err := w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return err
+}
+
+func newEncoder(w io.Writer, o *marshalOptions) Encoder {
+	enc := o.codec.NewEncoder(w)
+	enc.SetIndent(o.prefix, o.indent)
+	enc.SetEscapeHTML(o.escapeHTML)
+	return enc
+}
+
+func Marshal(models interface{}, opts ...MarshalOption) (Payloader, error) {
+	o := newMarshalOptions(opts).newState()
+
+	var payload Payloader
+	err := o.hooks.marshal(modelTypeName(reflect.TypeOf(models), &o.tagOptions), func(s *HookStats) error {
+		var err error
+		payload, err = marshal(models, o, s)
+		if err == nil && o.denormalize {
+			denormalizePayload(payload)
+		}
+		if err == nil && o.describedBy != "" {
+			addDescribedBy(payload, modelTypeName(reflect.TypeOf(models), &o.tagOptions), o.describedBy)
+		}
+		return err
+	})
+	return payload, err
+}
+
+// marshal implements Marshal and records the number of resource objects
+// in s.
+func marshal(models interface{}, o *marshalState, s *HookStats) (Payloader, error) {
+	switch m := models.(type) {
+	case *DynamicResource:
+		n, err := m.Node()
+		if err != nil {
+			return nil, err
+		}
+		o.orderAttributes(n, nil)
+		payload := &OnePayload{Data: n}
+		s.Resources = 1
+		if o.links != nil {
+			applyLinks(o.links, payload.Data)
+		}
+		if o.deriveLinks {
+			deriveRelationshipLinks(payload.Data)
+		}
+		return payload, nil
+	case []*DynamicResource:
+		payload, err := marshalDynamic(m)
+		if err != nil {
+			return nil, err
+		}
+		s.Resources, s.Included = len(payload.Data), len(payload.Included)
+		for _, n := range payload.Data {
+			o.orderAttributes(n, nil)
+		}
+		for _, n := range payload.Included {
+			o.orderAttributes(n, nil)
+		}
+		if o.links != nil {
+			applyLinks(o.links, payload.Data...)
+		}
+		if o.deriveLinks {
+			deriveRelationshipLinks(payload.Data...)
+		}
+		return payload, nil
+	}
+
 	switch vals := reflect.ValueOf(models); vals.Kind() {
 	case reflect.Slice:
 		m, err := convertToSliceInterface(&models)
@@ -38,10 +156,11 @@ func Marshal(models interface{}) (Payloader, error) {
 			return nil, err
 		}
 
-		payload, err := marshalMany(m)
+		payload, deduplicated, err := marshalMany(m, o)
 		if err != nil {
 			return nil, err
 		}
+		s.Resources, s.Included = len(payload.Data), len(payload.Included)
 
 		if linkableModels, isLinkable := models.(Linkable); isLinkable {
 			jl := linkableModels.JSONAPILinks()
@@ -51,8 +170,27 @@ func Marshal(models interface{}) (Payloader, error) {
 			payload.Links = linkableModels.JSONAPILinks()
 		}
 
-		if metableModels, ok := models.(Metable); ok {
-			payload.Meta = metableModels.JSONAPIMeta()
+		meta, err := modelMeta(models)
+		if err != nil {
+			return nil, err
+		}
+		payload.Meta = meta
+		if o.deduplicatedMeta {
+			payload.Meta = withDeduplicated(payload.Meta, deduplicated)
+		}
+
+		if o.links != nil {
+			applyLinks(o.links, payload.Data...)
+			applyLinks(o.links, payload.Included...)
+			if typ := modelTypeName(vals.Type(), &o.tagOptions); typ != "" {
+				if self := o.links.CollectionLink(typ); self != "" {
+					payload.Links = mergeLinks(payload.Links, Links{KeySelfLink: self})
+				}
+			}
+		}
+		if o.deriveLinks {
+			deriveRelationshipLinks(payload.Data...)
+			deriveRelationshipLinks(payload.Included...)
 		}
 
 		return payload, nil
@@ -61,14 +199,100 @@ func Marshal(models interface{}) (Payloader, error) {
 		if reflect.Indirect(vals).Kind() != reflect.Struct {
 			return nil, ErrUnexpectedType
 		}
-		return marshalOne(models)
+
+		payload, err := marshalOne(models, o)
+		if err != nil {
+			return nil, err
+		}
+		if payload.Data != nil {
+			s.Resources = 1
+		}
+		s.Included = len(payload.Included)
+
+		if o.links != nil {
+			applyLinks(o.links, payload.Data)
+			applyLinks(o.links, payload.Included...)
+		}
+		if o.deriveLinks {
+			deriveRelationshipLinks(payload.Data)
+			deriveRelationshipLinks(payload.Included...)
+		}
+
+		return payload, nil
 	default:
 		return nil, ErrUnexpectedType
 	}
 }
 
+func marshalOne(model interface{}, o *marshalState) (*OnePayload, error) {
+	included := make(map[string]*Node)
+
+	rootNode, err := visitModelNode(model, &included, true, o, "")
+	if err != nil {
+		return nil, err
+	}
+	if rootNode != nil {
+		// A resource referring back to the primary data is not included.
+		delete(included, nodeKey(rootNode))
+	}
+	payload := &OnePayload{Data: rootNode}
+
+	payload.Included = orderIncluded(included, o.marshalOptions, rootNode)
+
+	return payload, nil
+}
+
+// marshalMany builds the document of a collection. It also returns the
+// number of resource objects left out of included by deduplication when
+// WithoutIncludedDedup or WithDeduplicatedMeta is set.
+func marshalMany(models []interface{}, o *marshalState) (*ManyPayload, int, error) {
+	payload := &ManyPayload{
+		Data: []*Node{},
+	}
+	included := map[string]*Node{}
+
+	for i, model := range models {
+		node, err := visitModelNode(model, &included, true, o, "")
+		if err != nil {
+			return nil, 0, err
+		}
+		if node == nil {
+			continue
+		}
+		if o.itemDecorator != nil {
+			if err := decorateItem(o.itemDecorator, i, model, node); err != nil {
+				return nil, 0, err
+			}
+		}
+		payload.Data = append(payload.Data, node)
+	}
+
+	var items [][]*Node
+	if o.noIncludedDedup || o.deduplicatedMeta {
+		items = itemIncluded(included, payload.Data, o.marshalOptions)
+	}
+
+	for _, node := range payload.Data {
+		delete(included, nodeKey(node))
+	}
+	payload.Included = orderIncluded(included, o.marshalOptions, payload.Data...)
+
+	if o.noIncludedDedup {
+		payload.Included = []*Node{}
+		for _, nodes := range items {
+			payload.Included = append(payload.Included, nodes...)
+		}
+	}
+
+	deduplicated := -len(payload.Included)
+	for _, nodes := range items {
+		deduplicated += len(nodes)
+	}
+	return payload, deduplicated, nil
+}
+
 func MarshalOnePayloadEmbedded(w io.Writer, model interface{}) error {
-	rootNode, err := visitModelNode(model, nil, false)
+	rootNode, err := visitModelNode(model, nil, false, newMarshalOptions(nil).newState(), "")
 	if err != nil {
 		return err
 	}
@@ -78,22 +302,48 @@ func MarshalOnePayloadEmbedded(w io.Writer, model interface{}) error {
 	return json.NewEncoder(w).Encode(payload)
 }
 
+// visitModelNode builds the Node for model. path is the dotted relationship
+// path from the document's primary data to model, matched against the
+// paths passed to WithInclude.
 func visitModelNode(model interface{}, included *map[string]*Node,
-	sideload bool) (*Node, error) {
+	sideload bool, o *marshalState, path string) (*Node, error) {
 	node := new(Node)
 
 	var er error
+	var deprecated []string
+	var extras reflect.Value
 	value := reflect.ValueOf(model)
-	if value.IsNil() {
+	if model == nil || value.IsNil() {
 		return nil, nil
 	}
 
+	if n, ok := o.identity.lookup(model, o.marshalOptions, path); ok {
+		if _, cycle := o.identity.visiting[model]; cycle && !sideload {
+			// Embedded, the resource would contain itself.
+			return toShallowNode(n), nil
+		}
+		return n, nil
+	}
+	o.identity.enter(model, node)
+
 	modelValue := value.Elem()
 	modelType := value.Type().Elem()
 
+	if err := o.checkMemberNames(modelType); err != nil {
+		return nil, err
+	}
+
+	// With a visitor, related resources are collected separately so that
+	// they can be dropped together with a skipped node.
+	parentIncluded := included
+	if o.visitor != nil && included != nil {
+		local := map[string]*Node{}
+		included = &local
+	}
+
 	for i := 0; i < modelValue.NumField(); i++ {
 		structField := modelValue.Type().Field(i)
-		tag := structField.Tag.Get(annotationJSONAPI)
+		tag := renameReserved(fieldTag(structField, o.tagKey, o.jsonFallback), o.reservedPrefix)
 		if tag == "" {
 			continue
 		}
@@ -104,15 +354,19 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 		args := strings.Split(tag, annotationSeperator)
 
 		if len(args) < 1 {
-			er = ErrBadJSONAPIStructTag
+			er = tagError(modelType, fieldType, tag, "empty tag")
 			break
 		}
 
+		if !inVersion(args, o.version) {
+			continue
+		}
+
 		annotation := args[0]
 
-		if (annotation == annotationClientID && len(args) != 1) ||
-			(annotation != annotationClientID && len(args) < 2) {
-			er = ErrBadJSONAPIStructTag
+		noArgs := annotation == annotationClientID || annotation == annotationExtras
+		if (noArgs && len(args) != 1) || (!noArgs && annotation != annotationPrimary && len(args) < 2) {
+			er = tagError(modelType, fieldType, tag, "wrong number of arguments for "+annotation)
 			break
 		}
 
@@ -127,6 +381,24 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 				kind = fieldType.Type.Kind()
 			}
 
+			if m, ok := textMarshaler(v); ok {
+				text, err := m.MarshalText()
+				if err != nil {
+					er = err
+					break
+				}
+				node.ID = string(text)
+				node.Type = o.primaryType(modelType, args)
+				continue
+			}
+
+			// Named types other than text marshalers are rejected, as
+			// CheckModel does, rather than asserted to their kind.
+			if !isValidIDType(fieldType.Type) {
+				er = idTypeError(modelType, fieldType, tag)
+				break
+			}
+
 			switch kind {
 			case reflect.String:
 				node.ID = v.Interface().(string)
@@ -150,22 +422,23 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 				node.ID = strconv.FormatUint(uint64(v.Interface().(uint32)), 10)
 			case reflect.Uint64:
 				node.ID = strconv.FormatUint(v.Interface().(uint64), 10)
-			default:
-
-				er = ErrBadJSONAPIID
 			}
 
-			if er != nil {
+			node.Type = o.primaryType(modelType, args)
+		} else if annotation == annotationClientID {
+			if fieldValue.Kind() != reflect.String {
+				er = tagError(modelType, fieldType, tag, "client-id field must be a string")
 				break
 			}
-
-			node.Type = args[1]
-		} else if annotation == annotationClientID {
 			clientID := fieldValue.String()
 			if clientID != "" {
 				node.ClientID = clientID
 			}
 		} else if annotation == annotationAttribute {
+			if hasOption(args, annotationWriteOnly) {
+				continue
+			}
+
 			var omitEmpty, iso8601, rfc3339 bool
 
 			if len(args) > 2 {
@@ -177,6 +450,8 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 						iso8601 = true
 					case annotationRFC3339:
 						rfc3339 = true
+					case annotationDeprecated:
+						deprecated = append(deprecated, args[1])
 					}
 				}
 			}
@@ -222,6 +497,16 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 						node.Attributes[args[1]] = tm.Unix()
 					}
 				}
+			} else if isTimeSlice(fieldValue.Type()) {
+				if omitEmpty && fieldValue.Len() == 0 {
+					continue
+				}
+				if fieldValue.IsNil() {
+					node.Attributes[args[1]] = nil
+					continue
+				}
+
+				node.Attributes[args[1]] = marshalTimeSlice(fieldValue, iso8601, rfc3339)
 			} else {
 				// Dealing with a fieldValue that is not a time
 				emptyValue := reflect.Zero(fieldValue.Type())
@@ -231,6 +516,48 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 					continue
 				}
 
+				if _, ok := lookupStringType(fieldValue.Type()); ok {
+					node.Attributes[args[1]] = marshalStringType(fieldValue, args)
+					continue
+				}
+
+				if isEnumType(fieldValue.Type()) {
+					name, err := marshalEnum(fieldValue)
+					if err != nil {
+						er = err
+						break
+					}
+					node.Attributes[args[1]] = name
+					continue
+				}
+
+				if isDecimalType(fieldValue.Type()) {
+					value, err := marshalDecimal(fieldValue, hasOption(args, annotationNumber))
+					if err != nil {
+						er = err
+						break
+					}
+					node.Attributes[args[1]] = value
+					continue
+				}
+
+				if isBytesType(fieldValue.Type()) {
+					node.Attributes[args[1]] = marshalBytes(fieldValue, args)
+					continue
+				}
+
+				if isTextMarshalerType(fieldValue.Type()) {
+					text, ok, err := marshalText(fieldValue)
+					if err != nil {
+						er = err
+						break
+					}
+					if ok {
+						node.Attributes[args[1]] = text
+						continue
+					}
+				}
+
 				strAttr, ok := fieldValue.Interface().(string)
 				if ok {
 					node.Attributes[args[1]] = strAttr
@@ -239,11 +566,68 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 				}
 			}
 		} else if annotation == annotationRelation {
-			var omitEmpty bool
+			var omitEmpty, count bool
 
 			//add support for 'omitempty' struct tag for marshaling as absent
-			if len(args) > 2 {
-				omitEmpty = args[2] == annotationOmitEmpty
+			for _, arg := range args[2:] {
+				switch arg {
+				case annotationOmitEmpty:
+					omitEmpty = true
+				case annotationCount:
+					count = true
+				}
+			}
+
+			// Relations backed by id fields have no related model to visit.
+			if typ, ok := idsRelationType(args, fieldValue.Type()); ok {
+				relationship, err := marshalIDsRelationship(model, fieldValue, args[1], typ, omitEmpty, count)
+				if err != nil {
+					er = err
+					break
+				}
+				if many, ok := relationship.(*RelationshipManyNode); ok && len(many.Data) == 0 {
+					relationship = emptyToMany(many, o.emptyToMany)
+				}
+				if relationship == nil {
+					continue
+				}
+				if node.Relationships == nil {
+					node.Relationships = make(map[string]interface{})
+				}
+				node.Relationships[args[1]] = relationship
+				continue
+			}
+
+			// A related model held by value is unset while it is the zero
+			// value, and visited through a pointer otherwise.
+			if fieldValue.Kind() == reflect.Struct {
+				fieldValue = relatedPointer(fieldValue)
+			}
+
+			relPath := args[1]
+			if path != "" {
+				relPath = path + "." + args[1]
+			}
+			include := o.includes(relPath)
+
+			// Give the model a chance to load a requested relationship
+			// that was left unset.
+			if fieldValue.IsNil() && o.include != nil && include {
+				if resolver, ok := model.(RelationshipResolver); ok {
+					resolved, err := resolveRelationship(resolver, args[1], fieldValue.Type())
+					if err != nil {
+						er = err
+						break
+					}
+					if resolved.IsValid() {
+						fieldValue = resolved
+					}
+				}
+			}
+
+			// Related models indexed by id are sent in the order of the keys.
+			if fieldValue.Kind() == reflect.Map {
+				fieldValue = relatedValues(fieldValue)
 			}
 
 			isSlice := fieldValue.Type().Kind() == reflect.Slice
@@ -267,12 +651,25 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 				relMeta = metableModel.JSONAPIRelationshipMeta(args[1])
 			}
 
+			if count && isSlice {
+				relMeta = withCount(model, args[1], fieldValue.Len(), relMeta)
+			}
+
+			if isSlice {
+				relLinks, relMeta, er = paginateRelationship(model, node, args[1], relLinks, relMeta, o.marshalOptions)
+				if er != nil {
+					break
+				}
+			}
+
 			if isSlice {
 				// to-many relationship
 				relationship, err := visitModelNodeRelationships(
 					fieldValue,
 					included,
 					sideload,
+					o,
+					relPath,
 				)
 				if err != nil {
 					er = err
@@ -281,10 +678,19 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 				relationship.Links = relLinks
 				relationship.Meta = relMeta
 
+				if fieldValue.Len() == 0 {
+					if rel := emptyToMany(relationship, o.emptyToMany); rel != nil {
+						node.Relationships[args[1]] = rel
+					}
+					continue
+				}
+
 				if sideload {
 					shallowNodes := []*Node{}
 					for _, n := range relationship.Data {
-						appendIncluded(included, n)
+						if include {
+							appendIncluded(included, n)
+						}
 						shallowNodes = append(shallowNodes, toShallowNode(n))
 					}
 
@@ -309,14 +715,28 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 					fieldValue.Interface(),
 					included,
 					sideload,
+					o,
+					relPath,
 				)
 				if err != nil {
 					er = err
 					break
 				}
 
+				// The related model was skipped by a NodeVisitor.
+				if relationship == nil {
+					node.Relationships[args[1]] = &RelationshipOneNode{
+						Data:  nil,
+						Links: relLinks,
+						Meta:  relMeta,
+					}
+					continue
+				}
+
 				if sideload {
-					appendIncluded(included, relationship)
+					if include {
+						appendIncluded(included, relationship)
+					}
 					node.Relationships[args[1]] = &RelationshipOneNode{
 						Data:  toShallowNode(relationship),
 						Links: relLinks,
@@ -331,8 +751,14 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 				}
 			}
 
+		} else if annotation == annotationExtras {
+			if !isExtrasType(fieldType.Type) {
+				er = tagError(modelType, fieldType, tag, extrasTypeMsg(fieldType.Type))
+				break
+			}
+			extras = fieldValue
 		} else {
-			er = ErrBadJSONAPIStructTag
+			er = tagError(modelType, fieldType, tag, fmt.Sprintf("unknown annotation %q", annotation))
 			break
 		}
 	}
@@ -341,6 +767,11 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 		return nil, er
 	}
 
+	if err := addComputedAttributes(model, node); err != nil {
+		return nil, err
+	}
+	addExtraAttributes(node, extras)
+
 	if linkableModel, isLinkable := model.(Linkable); isLinkable {
 		jl := linkableModel.JSONAPILinks()
 		if er := jl.validate(); er != nil {
@@ -348,10 +779,173 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 		}
 		node.Links = linkableModel.JSONAPILinks()
 	}
+	if err := applyLinkTemplates(model, node); err != nil {
+		return nil, err
+	}
+
+	meta, err := modelMeta(model)
+	if err != nil {
+		return nil, err
+	}
+	node.Meta = meta
+
+	// List the deprecated attributes the resource object actually carries.
+	var used []string
+	for _, name := range deprecated {
+		if _, ok := node.Attributes[name]; ok {
+			used = append(used, name)
+		}
+	}
+	if len(used) > 0 {
+		if node.Meta == nil {
+			node.Meta = &Meta{}
+		}
+		if _, ok := (*node.Meta)[KeyDeprecatedMeta]; !ok {
+			(*node.Meta)[KeyDeprecatedMeta] = used
+		}
+	}
+
+	if o.transformer != nil {
+		if err := transformAttributes(o.transformer, node); err != nil {
+			return nil, err
+		}
+	}
+	o.orderAttributes(node, modelType)
+
+	if o.visitor != nil {
+		depth := 0
+		if path != "" {
+			depth = strings.Count(path, ".") + 1
+		}
+		if err := o.visitor(model, node, depth, path); err == ErrSkipNode {
+			o.identity.leave(model, o.marshalOptions, path, nil)
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
 
-	if metableModel, ok := model.(Metable); ok {
-		node.Meta = metableModel.JSONAPIMeta()
+		if parentIncluded != nil {
+			for _, n := range *included {
+				appendIncluded(parentIncluded, n)
+			}
+		}
 	}
 
+	o.hooks.nodeVisited(node)
+	o.identity.leave(model, o.marshalOptions, path, node)
+
 	return node, nil
 }
+
+func visitModelNodeRelationships(models reflect.Value, included *map[string]*Node,
+	sideload bool, o *marshalState, path string) (*RelationshipManyNode, error) {
+	nodes := []*Node{}
+
+	for i := 0; i < models.Len(); i++ {
+		n := models.Index(i).Interface()
+
+		node, err := visitModelNode(n, included, sideload, o, path)
+		if err != nil {
+			return nil, err
+		}
+		if node == nil {
+			continue
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	return &RelationshipManyNode{Data: nodes}, nil
+}
+
+// relatedPointer returns a pointer to the related model v, a struct held
+// by value, or a nil pointer if v is the zero value. Addressable models are
+// not copied, so that the identity of shared models is kept.
+func relatedPointer(v reflect.Value) reflect.Value {
+	if v.IsZero() {
+		return reflect.Zero(reflect.PtrTo(v.Type()))
+	}
+	if v.CanAddr() {
+		return v.Addr()
+	}
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	return p
+}
+
+// relatedValues returns the values of m, a map of related models with
+// string keys, as a slice sorted by key.
+func relatedValues(m reflect.Value) reflect.Value {
+	if m.IsNil() {
+		return reflect.Zero(reflect.SliceOf(m.Type().Elem()))
+	}
+	keys := m.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	values := reflect.MakeSlice(reflect.SliceOf(m.Type().Elem()), 0, len(keys))
+	for _, k := range keys {
+		values = reflect.Append(values, m.MapIndex(k))
+	}
+	return values
+}
+
+// textMarshaler returns v as an encoding.TextMarshaler if its type, or a
+// pointer to it, implements the interface.
+func textMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		return m, true
+	}
+	if v.CanAddr() {
+		m, ok := v.Addr().Interface().(encoding.TextMarshaler)
+		return m, ok
+	}
+	return nil, false
+}
+
+func toShallowNode(node *Node) *Node {
+	return &Node{
+		ID:   node.ID,
+		Type: node.Type,
+	}
+}
+
+func appendIncluded(m *map[string]*Node, nodes ...*Node) {
+	included := *m
+
+	for _, n := range nodes {
+		k := fmt.Sprintf("%s,%s", n.Type, n.ID)
+
+		if _, hasNode := included[k]; hasNode {
+			continue
+		}
+
+		included[k] = n
+	}
+}
+
+func nodeMapValues(m *map[string]*Node) []*Node {
+	mp := *m
+	nodes := make([]*Node, len(mp))
+
+	i := 0
+	for _, n := range mp {
+		nodes[i] = n
+		i++
+	}
+
+	return nodes
+}
+
+func convertToSliceInterface(i *interface{}) ([]interface{}, error) {
+	vals := reflect.ValueOf(*i)
+	if vals.Kind() != reflect.Slice {
+		return nil, ErrExpectedSlice
+	}
+	var response []interface{}
+	for x := 0; x < vals.Len(); x++ {
+		response = append(response, vals.Index(x).Interface())
+	}
+	return response, nil
+}
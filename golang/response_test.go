@@ -0,0 +1,181 @@
+package jsonapi
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type respBlog struct {
+	ID    int    `jsonapi:"primary,blogs"`
+	Title string `jsonapi:"attr,title"`
+}
+
+func TestMarshalBytes(t *testing.T) {
+	b, err := MarshalBytes(&respBlog{ID: 1, Title: "Hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"data":{"type":"blogs","id":"1","attributes":{"title":"Hi"}}}`
+	if string(b) != want {
+		t.Errorf("got  %s\nwant %s", b, want)
+	}
+}
+
+func TestMarshalPayloadEncoderOptions(t *testing.T) {
+	blog := &respBlog{ID: 1, Title: "<b>&</b>"}
+
+	var buf bytes.Buffer
+	if err := MarshalPayload(&buf, blog); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(buf.String(), "}\n") || !strings.Contains(buf.String(), `\u003cb\u003e`) {
+		t.Errorf("default output %q should escape HTML and end with a newline", buf.String())
+	}
+
+	buf.Reset()
+	err := MarshalPayload(&buf, blog, WithEscapeHTML(false), WithoutTrailingNewline())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasSuffix(buf.String(), "\n") || !strings.Contains(buf.String(), `"<b>&</b>"`) {
+		t.Errorf("got %q, want unescaped HTML without a trailing newline", buf.String())
+	}
+
+	b, err := MarshalBytes(blog, WithIndent("", "  "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), "{\n  \"data\": {\n    \"type\": \"blogs\"") {
+		t.Errorf("indented output:\n%s", b)
+	}
+}
+
+func TestMarshalBytesDoesNotAliasOptions(t *testing.T) {
+	opts := make([]MarshalOption, 1, 2)
+	opts[0] = WithIndent("", " ")
+	spare := opts[:2]
+	spare[1] = WithEscapeHTML(false)
+
+	if _, err := MarshalBytes(&respBlog{ID: 1}, opts...); err != nil {
+		t.Fatal(err)
+	}
+	o := &marshalOptions{escapeHTML: true}
+	spare[1](o)
+	if o.escapeHTML {
+		t.Error("MarshalBytes overwrote the caller's options slice")
+	}
+}
+
+func TestMarshalRejectsUnexpectedTypes(t *testing.T) {
+	for _, v := range []interface{}{5, "x", respBlog{ID: 1}} {
+		if _, err := MarshalBytes(v); err == nil {
+			t.Errorf("MarshalBytes(%#v) succeeded", v)
+		}
+	}
+}
+
+func TestMarshalNil(t *testing.T) {
+	if _, err := Marshal(nil); err != ErrUnexpectedType {
+		t.Errorf("Marshal(nil) error %v, want ErrUnexpectedType", err)
+	}
+	var buf bytes.Buffer
+	if err := MarshalPayload(&buf, nil); err != ErrUnexpectedType {
+		t.Errorf("MarshalPayload(nil) error %v, want ErrUnexpectedType", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("MarshalPayload(nil) wrote %s", buf.Bytes())
+	}
+}
+
+func TestModelTypeName(t *testing.T) {
+	tests := []struct {
+		t    reflect.Type
+		want string
+	}{
+		{nil, ""},
+		{reflect.TypeOf(respBlog{}), "blogs"},
+		{reflect.TypeOf([]*respBlog{}), "blogs"},
+		{reflect.TypeOf(5), ""},
+	}
+	for _, tt := range tests {
+		if got := modelTypeName(tt.t, nil); got != tt.want {
+			t.Errorf("modelTypeName(%v) = %q, want %q", tt.t, got, tt.want)
+		}
+	}
+}
+
+type valueComment struct {
+	ID     string    `jsonapi:"primary,comments"`
+	Body   string    `jsonapi:"attr,body"`
+	Author reqAuthor `jsonapi:"relation,author"`
+	Editor reqAuthor `jsonapi:"relation,editor,omitempty"`
+}
+
+func TestMarshalValueRelation(t *testing.T) {
+	in := &valueComment{ID: "1", Author: reqAuthor{ID: "9", Name: "Ann"}}
+	doc := marshalDoc(t, in)
+
+	rels := doc["data"].(map[string]interface{})["relationships"].(map[string]interface{})
+	author := rels["author"].(map[string]interface{})["data"].(map[string]interface{})
+	if author["type"] != "people" || author["id"] != "9" {
+		t.Errorf("author linkage %v", author)
+	}
+	// The zero value is no related resource.
+	if _, ok := rels["editor"]; ok {
+		t.Errorf("editor %v, want it omitted", rels["editor"])
+	}
+	if got, want := includedKeys(t, in), []string{"people/9"}; !equalStrings(got, want) {
+		t.Errorf("included %v, want %v", got, want)
+	}
+
+	doc = marshalDoc(t, &valueComment{ID: "2"})
+	rels = doc["data"].(map[string]interface{})["relationships"].(map[string]interface{})
+	if data, ok := rels["author"].(map[string]interface{})["data"]; !ok || data != nil {
+		t.Errorf("zero author %v, want null linkage", rels["author"])
+	}
+
+	if errs := CheckModel(valueComment{}); len(errs) > 0 {
+		t.Errorf("CheckModel: %v", errs)
+	}
+	if err := RoundTrip(in); err != nil {
+		t.Errorf("RoundTrip: %v", err)
+	}
+}
+
+type commentKey string
+
+type mapPost struct {
+	ID       string                    `jsonapi:"primary,posts"`
+	Comments map[commentKey]*reqAuthor `jsonapi:"relation,comments"`
+	Editors  map[string]*reqAuthor     `jsonapi:"relation,editors,omitempty"`
+}
+
+func TestMarshalMapRelation(t *testing.T) {
+	in := &mapPost{ID: "1", Comments: map[commentKey]*reqAuthor{
+		"b": {ID: "b", Name: "Bob"},
+		"a": {ID: "a", Name: "Ann"},
+		"c": {ID: "c"},
+	}}
+	doc := marshalDoc(t, in)
+
+	rels := doc["data"].(map[string]interface{})["relationships"].(map[string]interface{})
+	var ids []string
+	for _, d := range rels["comments"].(map[string]interface{})["data"].([]interface{}) {
+		ids = append(ids, d.(map[string]interface{})["id"].(string))
+	}
+	if want := []string{"a", "b", "c"}; !equalStrings(ids, want) {
+		t.Errorf("linkage ids %v, want them in key order %v", ids, want)
+	}
+	if _, ok := rels["editors"]; ok {
+		t.Errorf("editors %v, want the nil map omitted", rels["editors"])
+	}
+	if got, want := includedKeys(t, in), []string{"people/a", "people/b", "people/c"}; !equalStrings(got, want) {
+		t.Errorf("included %v, want %v", got, want)
+	}
+
+	if errs := CheckModel(mapPost{}); len(errs) > 0 {
+		t.Errorf("CheckModel: %v", errs)
+	}
+}
@@ -0,0 +1,239 @@
+package jsonapi
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// RoundTripError reports the first value that did not survive RoundTrip.
+// Path locates it from the model, e.g. "Author.Tags[2]".
+type RoundTripError struct {
+	Path      string
+	Want, Got interface{}
+}
+
+func (e *RoundTripError) Error() string {
+	want, got := fmt.Sprintf("%#v", e.Want), fmt.Sprintf("%#v", e.Got)
+	if want == got {
+		// Only the types differ, as for a number decoded into an
+		// interface{}.
+		want, got = fmt.Sprintf("%s (%T)", want, e.Want), fmt.Sprintf("%s (%T)", got, e.Got)
+	}
+	return fmt.Sprintf("jsonapi: round trip changed %s: want %s, got %s", e.Path, want, got)
+}
+
+// RoundTrip marshals model, a pointer to a tagged struct or a slice of them,
+// with MarshalPayload, unmarshals the document into a new value of the same
+// type and compares the two. It returns a *RoundTripError for the first
+// field that differs, which makes it a convenient way to catch encoder and
+// decoder asymmetries in tests and fuzzers.
+//
+// Only fields with jsonapi tags are compared, and times are compared with
+// time.Time.Equal since locations are not part of the encoding. Related
+// models are sideloaded and compared as well.
+//
+// opts are passed to MarshalPayload; the document is unmarshaled reading
+// tags the same way, e.g. with the tag key of WithTagKey.
+func RoundTrip(model interface{}, opts ...MarshalOption) error {
+	buf := bytes.NewBuffer(nil)
+	if err := MarshalPayload(buf, model, opts...); err != nil {
+		return err
+	}
+
+	tags := newMarshalOptions(opts).tagOptions
+	sameTags := func(o *unmarshalOptions) {
+		o.tagOptions = tags
+	}
+
+	v := reflect.ValueOf(model)
+	if v.Kind() == reflect.Slice {
+		models, err := UnmarshalManyPayload(buf, v.Type().Elem(), sameTags)
+		if err != nil {
+			return err
+		}
+		if len(models) != v.Len() {
+			return &RoundTripError{Path: "len", Want: v.Len(), Got: len(models)}
+		}
+		for i, m := range models {
+			if err := diffModels(fmt.Sprintf("[%d]", i), v.Index(i), reflect.ValueOf(m),
+				&tags, map[[2]uintptr]bool{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return ErrUnexpectedType
+	}
+	out := reflect.New(v.Type().Elem())
+	if err := UnmarshalPayload(buf, out.Interface(), sameTags); err != nil {
+		return err
+	}
+	return diffModels("", v, out, &tags, map[[2]uintptr]bool{})
+}
+
+// diffModels compares the jsonapi fields of two model pointers, with tags
+// read as set by tags. seen stops the walk on cyclic relations.
+func diffModels(path string, want, got reflect.Value, tags *tagOptions, seen map[[2]uintptr]bool) error {
+	if want.IsNil() || got.IsNil() {
+		if want.IsNil() != got.IsNil() {
+			return &RoundTripError{Path: pathOrRoot(path), Want: want.Interface(), Got: got.Interface()}
+		}
+		return nil
+	}
+	key := [2]uintptr{want.Pointer(), got.Pointer()}
+	if seen[key] {
+		return nil
+	}
+	seen[key] = true
+
+	w, g := want.Elem(), got.Elem()
+	t := w.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := tags.tag(field)
+		if tag == "" || field.PkgPath != "" {
+			continue
+		}
+		fieldPath := joinPath(path, field.Name)
+
+		args := strings.Split(tag, annotationSeperator)
+		if args[0] != annotationRelation {
+			if err := diffValues(fieldPath, w.Field(i), g.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, ok := idsRelationType(args, field.Type); ok {
+			if err := diffValues(fieldPath, w.Field(i), g.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		wf, gf := w.Field(i), g.Field(i)
+		if wf.Kind() == reflect.Map {
+			wf, gf = relatedValues(wf), relatedValues(gf)
+		}
+		if wf.Kind() != reflect.Slice {
+			if err := diffRelated(fieldPath, wf, gf, tags, seen); err != nil {
+				return err
+			}
+			continue
+		}
+		if wf.Len() != gf.Len() {
+			return &RoundTripError{Path: fieldPath + ".len", Want: wf.Len(), Got: gf.Len()}
+		}
+		for j := 0; j < wf.Len(); j++ {
+			if err := diffRelated(fmt.Sprintf("%s[%d]", fieldPath, j), wf.Index(j), gf.Index(j), tags, seen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// diffRelated compares two related models, which may be held in interface
+// fields.
+func diffRelated(path string, want, got reflect.Value, tags *tagOptions, seen map[[2]uintptr]bool) error {
+	if want.Kind() == reflect.Struct {
+		want, got = want.Addr(), got.Addr()
+	}
+	if want.Kind() == reflect.Interface {
+		if want.IsNil() || got.IsNil() {
+			return diffValues(path, want, got)
+		}
+		want, got = want.Elem(), got.Elem()
+		if want.Type() != got.Type() {
+			return &RoundTripError{Path: path, Want: want.Type().String(), Got: got.Type().String()}
+		}
+	}
+	return diffModels(path, want, got, tags, seen)
+}
+
+// diffValues compares attribute values the way reflect.DeepEqual does,
+// except for times.
+func diffValues(path string, want, got reflect.Value) error {
+	mismatch := func() error {
+		return &RoundTripError{Path: path, Want: want.Interface(), Got: got.Interface()}
+	}
+
+	if want.Type() == timeType {
+		if !want.Interface().(time.Time).Equal(got.Interface().(time.Time)) {
+			return mismatch()
+		}
+		return nil
+	}
+
+	switch want.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if want.IsNil() || got.IsNil() {
+			if want.IsNil() != got.IsNil() {
+				return mismatch()
+			}
+			return nil
+		}
+		if want.Kind() == reflect.Interface && want.Elem().Type() != got.Elem().Type() {
+			return mismatch()
+		}
+		return diffValues(path, want.Elem(), got.Elem())
+	case reflect.Slice, reflect.Array:
+		if want.Len() != got.Len() {
+			return mismatch()
+		}
+		for i := 0; i < want.Len(); i++ {
+			if err := diffValues(fmt.Sprintf("%s[%d]", path, i), want.Index(i), got.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		if want.IsNil() != got.IsNil() || want.Len() != got.Len() {
+			return mismatch()
+		}
+		for _, key := range want.MapKeys() {
+			gv := got.MapIndex(key)
+			if !gv.IsValid() {
+				return mismatch()
+			}
+			if err := diffValues(fmt.Sprintf("%s[%v]", path, key), want.MapIndex(key), gv); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		for i := 0; i < want.NumField(); i++ {
+			if want.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			if err := diffValues(joinPath(path, want.Type().Field(i).Name),
+				want.Field(i), got.Field(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !reflect.DeepEqual(want.Interface(), got.Interface()) {
+		return mismatch()
+	}
+	return nil
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "model"
+	}
+	return path
+}
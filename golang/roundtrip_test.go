@@ -0,0 +1,108 @@
+package jsonapi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripNote struct {
+	ID      string            `jsonapi:"primary,notes"`
+	Text    string            `jsonapi:"attr,text"`
+	Created time.Time         `jsonapi:"attr,created"`
+	Extra   interface{}       `jsonapi:"attr,extra"`
+	Parent  *roundTripNote    `jsonapi:"relation,parent"`
+	Replies []*roundTripNote  `jsonapi:"relation,replies"`
+	Labels  map[string]string `jsonapi:"attr,labels"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	created := time.Date(2020, 9, 13, 12, 26, 40, 0, time.FixedZone("CEST", 2*3600))
+	// Both replies refer to the same included note.
+	thread := &roundTripNote{ID: "5", Text: "thread", Created: created, Labels: map[string]string{"k": "v"}}
+	parent := &roundTripNote{ID: "1", Text: "parent", Created: created, Extra: "x",
+		Replies: []*roundTripNote{
+			{ID: "2", Text: "reply", Created: created, Parent: thread},
+			{ID: "3", Text: "other", Created: created, Parent: thread},
+		}}
+
+	if err := RoundTrip(parent); err != nil {
+		t.Errorf("nested model: %v", err)
+	}
+	if err := RoundTrip([]*roundTripNote{parent, {ID: "4", Created: created}}); err != nil {
+		t.Errorf("collection: %v", err)
+	}
+}
+
+func TestRoundTripMismatch(t *testing.T) {
+	created := time.Date(2020, 9, 13, 12, 26, 40, 0, time.UTC)
+	tests := []struct {
+		name  string
+		model interface{}
+		path  string
+	}{
+		// Unix timestamps drop the nanoseconds.
+		{"time", &roundTripNote{ID: "1", Created: created.Add(time.Millisecond)}, "Created"},
+		// Numbers in an interface{} come back as float64.
+		{"interface", &roundTripNote{ID: "1", Created: created, Extra: 1}, "Extra"},
+		{"related", &roundTripNote{ID: "1", Created: created,
+			Replies: []*roundTripNote{{ID: "2", Created: created, Extra: 1}}},
+			"Replies[0].Extra"},
+		{"collection", []*roundTripNote{{ID: "1", Created: created, Extra: int64(2)}}, "[0].Extra"},
+	}
+	for _, tt := range tests {
+		var rtErr *RoundTripError
+		err := RoundTrip(tt.model)
+		if !errors.As(err, &rtErr) {
+			t.Errorf("%s: error %v, want a *RoundTripError", tt.name, err)
+			continue
+		}
+		if rtErr.Path != tt.path {
+			t.Errorf("%s: path %q, want %q", tt.name, rtErr.Path, tt.path)
+		}
+	}
+
+	err := RoundTrip(&roundTripNote{ID: "1", Created: created, Extra: 1})
+	if msg := err.Error(); !strings.Contains(msg, "(int)") || !strings.Contains(msg, "(float64)") {
+		t.Errorf("error %q, want the types spelled out", msg)
+	}
+}
+
+func TestRoundTripUnexpectedType(t *testing.T) {
+	for _, model := range []interface{}{roundTripNote{ID: "1"}, new(string)} {
+		if err := RoundTrip(model); err != ErrUnexpectedType {
+			t.Errorf("%T: error %v, want ErrUnexpectedType", model, err)
+		}
+	}
+}
+
+func TestFuzzEntryPoints(t *testing.T) {
+	valid := `{"data": {"type": "fuzz", "id": "1", "attributes": {"name": "a", "count": 2,
+		"at": "2020-09-13T12:26:40Z", "extra": {"k": "v"}},
+		"relationships": {"parent": {"data": {"type": "fuzz", "id": "1"}}}}}`
+	if FuzzUnmarshalPayload([]byte(valid)) != 1 {
+		t.Error("FuzzUnmarshalPayload rejected a valid document")
+	}
+	if FuzzUnmarshalManyPayload([]byte(`{"data": [`+valid[9:len(valid)-1]+`]}`)) != 1 {
+		t.Error("FuzzUnmarshalManyPayload rejected a valid document")
+	}
+	if FuzzUnmarshalDynamicPayload([]byte(valid)) != 1 {
+		t.Error("FuzzUnmarshalDynamicPayload rejected a valid document")
+	}
+	if FuzzUnmarshalPayload([]byte(`{"data": `)) != 0 {
+		t.Error("FuzzUnmarshalPayload accepted a truncated document")
+	}
+}
+
+func TestRoundTripIgnoredField(t *testing.T) {
+	type cachedNote struct {
+		ID    string `jsonapi:"primary,notes"`
+		Text  string `jsonapi:"attr,text"`
+		Cache string `jsonapi:"-"`
+	}
+	// The ignored field does not come back, and is not compared.
+	if err := RoundTrip(&cachedNote{ID: "1", Text: "t", Cache: "c"}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,395 @@
+package jsonapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// schemaBuilder derives JSON Schema (draft 2020-12, as used by OpenAPI
+// 3.1) descriptions of tagged models. Schemas reference each other through
+// refPrefix, e.g. "#/components/schemas/".
+type schemaBuilder struct {
+	refPrefix string
+	tags      *tagOptions
+	schemas   map[string]interface{}
+	// models maps resource schema names, the resource type names, to
+	// their struct types, so that included sections can list every
+	// resource reachable from a model.
+	models map[string]reflect.Type
+}
+
+// newSchemaBuilder returns a schemaBuilder reading the tags of models as
+// Marshal does with opts.
+func newSchemaBuilder(refPrefix string, opts []MarshalOption) *schemaBuilder {
+	b := &schemaBuilder{
+		refPrefix: refPrefix,
+		tags:      &newMarshalOptions(opts).tagOptions,
+		schemas:   map[string]interface{}{},
+		models:    map[string]reflect.Type{},
+	}
+
+	b.schemas["ResourceIdentifier"] = map[string]interface{}{
+		"type":     "object",
+		"required": []string{"type", "id"},
+		"properties": map[string]interface{}{
+			"type": map[string]interface{}{"type": "string"},
+			"id":   map[string]interface{}{"type": "string"},
+			"meta": b.ref("Meta"),
+		},
+	}
+	b.schemas["Links"] = map[string]interface{}{
+		"type": "object",
+		"additionalProperties": map[string]interface{}{
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "string", "format": "uri-reference"},
+				map[string]interface{}{
+					"type":     "object",
+					"required": []string{"href"},
+					"properties": map[string]interface{}{
+						"href": map[string]interface{}{"type": "string", "format": "uri-reference"},
+						"meta": b.ref("Meta"),
+					},
+				},
+			},
+		},
+	}
+	b.schemas["Meta"] = map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": true,
+	}
+
+	return b
+}
+
+func (b *schemaBuilder) ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": b.refPrefix + name}
+}
+
+// addModel adds the resource object schema of the struct type t, and of
+// every model reachable through its relations, and returns its name: the
+// resource type name. Two structs of the same resource type, or a
+// resource type named like a shared definition, are reported as errors.
+func (b *schemaBuilder) addModel(t reflect.Type) (string, error) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	name := modelTypeName(t, b.tags)
+	if name == "" {
+		return "", fmt.Errorf("jsonapi: %s has no primary annotation", t)
+	}
+	if other, ok := b.models[name]; ok {
+		if other != t {
+			return "", fmt.Errorf("jsonapi: %s and %s both declare resource type %q", other, t, name)
+		}
+		return name, nil
+	}
+	if _, ok := b.schemas[name]; ok {
+		return "", fmt.Errorf("jsonapi: resource type %q of %s clashes with a shared schema", name, t)
+	}
+	b.models[name] = t
+
+	attributes := map[string]interface{}{}
+	relationships := map[string]interface{}{}
+	var required []string
+	var related []reflect.Type
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		args := strings.Split(b.tags.tag(field), annotationSeperator)
+		if len(args) < 2 {
+			continue
+		}
+
+		switch args[0] {
+		case annotationAttribute:
+			var omitEmpty, timeString, deprecated, readOnly, writeOnly, number bool
+			for _, arg := range args[2:] {
+				switch arg {
+				case annotationOmitEmpty:
+					omitEmpty = true
+				case annotationISO8601, annotationRFC3339:
+					timeString = true
+				case annotationDeprecated:
+					deprecated = true
+				case annotationReadOnly:
+					readOnly = true
+				case annotationWriteOnly:
+					writeOnly = true
+				case annotationNumber:
+					number = true
+				}
+			}
+
+			schema := b.valueSchema(field.Type, timeString, map[reflect.Type]bool{})
+			if number && isDecimalType(field.Type) {
+				schema = map[string]interface{}{"type": "number"}
+				if field.Type.Kind() == reflect.Ptr {
+					schema = nullable(schema)
+				}
+			}
+			if hasOption(args, annotationAddrSpec) {
+				schema["format"] = "email"
+			}
+			if hasOption(args, annotationBase64URL) && isBytesType(field.Type) {
+				schema["contentEncoding"] = "base64url"
+			}
+			if deprecated {
+				schema["deprecated"] = true
+			}
+			if readOnly {
+				schema["readOnly"] = true
+			}
+			if writeOnly {
+				schema["writeOnly"] = true
+			}
+			attributes[args[1]] = schema
+
+			// Zero times are left out by the marshaler even without
+			// omitempty, so they cannot be required.
+			if !omitEmpty && !writeOnly && field.Type.Kind() != reflect.Ptr &&
+				field.Type != reflect.TypeOf(time.Time{}) {
+				required = append(required, args[1])
+			}
+		case annotationRelation:
+			var data interface{}
+
+			if field.Type.Kind() == reflect.Slice || field.Type.Kind() == reflect.Map {
+				data = map[string]interface{}{
+					"type":  "array",
+					"items": b.ref("ResourceIdentifier"),
+				}
+			} else {
+				data = map[string]interface{}{
+					"oneOf": []interface{}{
+						b.ref("ResourceIdentifier"),
+						map[string]interface{}{"type": "null"},
+					},
+				}
+			}
+			if rt, ok := relationElemType(field.Type); ok && rt != nil {
+				related = append(related, rt)
+			}
+
+			relationships[args[1]] = map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"data":  data,
+					"links": b.ref("Links"),
+					"meta":  b.ref("Meta"),
+				},
+			}
+		}
+	}
+
+	properties := map[string]interface{}{
+		"type":  map[string]interface{}{"type": "string", "const": modelTypeName(t, b.tags)},
+		"id":    map[string]interface{}{"type": "string"},
+		"links": b.ref("Links"),
+		"meta":  b.ref("Meta"),
+	}
+	if len(attributes) > 0 {
+		attrSchema := map[string]interface{}{
+			"type":       "object",
+			"properties": attributes,
+		}
+		if len(required) > 0 {
+			sort.Strings(required)
+			attrSchema["required"] = required
+		}
+		properties["attributes"] = attrSchema
+	}
+	if len(relationships) > 0 {
+		properties["relationships"] = map[string]interface{}{
+			"type":       "object",
+			"properties": relationships,
+		}
+	}
+
+	b.schemas[name] = map[string]interface{}{
+		"type":       "object",
+		"required":   []string{"type"},
+		"properties": properties,
+	}
+
+	for _, r := range related {
+		if _, err := b.addModel(r); err != nil {
+			return "", err
+		}
+	}
+
+	return name, nil
+}
+
+// reachable returns the names of the resource schemas reachable from the
+// model named name through relations, excluding name itself unless it is
+// related to itself.
+func (b *schemaBuilder) reachable(name string) []string {
+	seen := map[string]bool{}
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			args := strings.Split(b.tags.tag(field), annotationSeperator)
+			if args[0] != annotationRelation {
+				continue
+			}
+			elem, ok := relationElemType(field.Type)
+			if !ok || elem == nil {
+				continue
+			}
+			if n := modelTypeName(elem, b.tags); !seen[n] {
+				seen[n] = true
+				walk(elem)
+			}
+		}
+	}
+	walk(b.models[name])
+
+	names := make([]string, 0, len(seen))
+	for n := range seen {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// valueSchema describes how encoding/json renders a value of type t, which
+// is how the marshaler renders attributes that are not times.
+func (b *schemaBuilder) valueSchema(t reflect.Type, timeString bool,
+	visiting map[reflect.Type]bool) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		return nullable(b.valueSchema(t.Elem(), timeString, visiting))
+	}
+
+	if t == reflect.TypeOf(time.Time{}) {
+		if timeString {
+			return map[string]interface{}{"type": "string", "format": "date-time"}
+		}
+		return map[string]interface{}{"type": "integer", "description": "Unix timestamp"}
+	}
+
+	if isDecimalType(t) {
+		return map[string]interface{}{"type": "string", "format": "decimal"}
+	}
+	if _, ok := stringTypes[t]; ok {
+		if t == urlType {
+			return map[string]interface{}{"type": "string", "format": "uri-reference"}
+		}
+		return map[string]interface{}{"type": "string"}
+	}
+	if names := enumTypeNames(t); names != nil {
+		return map[string]interface{}{"type": "string", "enum": names}
+	}
+
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return map[string]interface{}{}
+	}
+	if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if isBytesType(t) || t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		// Slices of times are formatted per element, like a single time.
+		return map[string]interface{}{
+			"type":  "array",
+			"items": b.valueSchema(t.Elem(), timeString && isTimeSlice(t), visiting),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": b.valueSchema(t.Elem(), false, visiting),
+		}
+	case reflect.Struct:
+		if visiting[t] {
+			// Recursive struct; stop describing it.
+			return map[string]interface{}{"type": "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		properties := map[string]interface{}{}
+		b.structProperties(t, properties, visiting)
+		return map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+		}
+	}
+
+	return map[string]interface{}{}
+}
+
+// structProperties adds the encoding/json properties of t to properties,
+// flattening embedded structs.
+func (b *schemaBuilder) structProperties(t reflect.Type, properties map[string]interface{},
+	visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+
+		ft := field.Type
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.structProperties(ft, properties, visiting)
+				continue
+			}
+		}
+
+		properties[name] = b.valueSchema(field.Type, false, visiting)
+	}
+}
+
+func nullable(s map[string]interface{}) map[string]interface{} {
+	if typ, ok := s["type"].(string); ok {
+		n := make(map[string]interface{}, len(s))
+		for k, v := range s {
+			n[k] = v
+		}
+		n["type"] = []string{typ, "null"}
+		return n
+	}
+	return map[string]interface{}{
+		"oneOf": []interface{}{s, map[string]interface{}{"type": "null"}},
+	}
+}
@@ -0,0 +1,199 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Selection is the tree of members a request asks for, derived from its
+// fields[TYPE] and include parameters by Query.Selection, for data layers
+// to load those members only: GraphQL resolvers, SQL SELECT lists, ORM
+// preloads. The id is always selected and not listed.
+type Selection struct {
+	// Type is the resource type, "" for polymorphic relations.
+	Type string
+	// Attributes lists the requested attributes by member name, in the
+	// order of the struct fields.
+	Attributes []string
+	// Relationships holds the requested relationships by member name:
+	// the selection of the related resources for those on an include
+	// path, nil for those whose linkage only is needed.
+	Relationships map[string]*Selection
+}
+
+// Selection translates q into the selection of members of model, a tagged
+// struct, a pointer to one or a slice of them, the primary data of the
+// request. Include paths that do not follow relation fields of the models
+// are errors; unknown members in fields[TYPE] are ignored. opts, e.g.
+// WithTagKey, name the members as for Marshal.
+func (q *Query) Selection(model interface{}, opts ...MarshalOption) (*Selection, error) {
+	t := relatedStruct(reflect.TypeOf(model))
+	if t == nil {
+		return nil, ErrUnexpectedType
+	}
+
+	tags := &newMarshalOptions(opts).tagOptions
+	root := newSelection(t, q, tags)
+	if q == nil {
+		return root, nil
+	}
+	for _, path := range q.Include {
+		if err := root.include(t, strings.Split(path, "."), q, tags); err != nil {
+			return nil, fmt.Errorf("jsonapi: cannot include %q: %w", path, err)
+		}
+	}
+	return root, nil
+}
+
+// newSelection returns the selection of the members of the struct type t,
+// with tags read as set by tags, allowed by the fields[TYPE] parameter of q.
+func newSelection(t reflect.Type, q *Query, tags *tagOptions) *Selection {
+	s := &Selection{Type: modelTypeName(t, tags), Relationships: map[string]*Selection{}}
+
+	var allowed map[string]bool
+	if q != nil {
+		if fields, ok := q.Fields[s.Type]; ok {
+			allowed = make(map[string]bool, len(fields))
+			for _, f := range fields {
+				allowed[f] = true
+			}
+		}
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		args := strings.Split(tags.tag(t.Field(i)), annotationSeperator)
+		if len(args) < 2 || allowed != nil && !allowed[args[1]] {
+			continue
+		}
+		switch args[0] {
+		case annotationAttribute:
+			if !hasOption(args, annotationWriteOnly) {
+				s.Attributes = append(s.Attributes, args[1])
+			}
+		case annotationRelation:
+			s.Relationships[args[1]] = nil
+		}
+	}
+	return s
+}
+
+// include adds the include path, split into relationship names, starting
+// at s, the selection of the struct type t.
+func (s *Selection) include(t reflect.Type, path []string, q *Query, tags *tagOptions) error {
+	var field reflect.StructField
+	var args []string
+	found := false
+	for i := 0; i < t.NumField() && !found; i++ {
+		field = t.Field(i)
+		args = strings.Split(tags.tag(field), annotationSeperator)
+		found = len(args) > 1 && args[0] == annotationRelation && args[1] == path[0]
+	}
+	if !found {
+		return fmt.Errorf("%s has no relationship %q", t, path[0])
+	}
+
+	related := relatedStruct(field.Type)
+	child := s.Relationships[path[0]]
+	if child == nil {
+		if related != nil {
+			child = newSelection(related, q, tags)
+		} else {
+			// Relations backed by ids, or holding interfaces, tell no
+			// more than the type of the related resources, if that.
+			typ, _ := idsRelationType(args, field.Type)
+			child = &Selection{Type: typ, Relationships: map[string]*Selection{}}
+		}
+		s.Relationships[path[0]] = child
+	}
+
+	if len(path) == 1 {
+		return nil
+	}
+	if related == nil {
+		return fmt.Errorf("the models of %s.%s are not known", t, field.Name)
+	}
+	return child.include(related, path[1:], q, tags)
+}
+
+// relatedStruct returns the struct type behind pointers, slices and maps
+// of t, or nil.
+func relatedStruct(t reflect.Type) reflect.Type {
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Map) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
+
+// Has reports whether the attribute or relationship name is selected.
+func (s *Selection) Has(name string) bool {
+	if _, ok := s.Relationships[name]; ok {
+		return true
+	}
+	for _, a := range s.Attributes {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Paths returns the selected members as dotted paths from the primary
+// data, e.g. "title", "author" and "author.name": attributes, and
+// relationships along with the members of the included ones. Relationships
+// come after attributes, sorted by name.
+func (s *Selection) Paths() []string {
+	var paths []string
+	s.paths("", &paths)
+	return paths
+}
+
+func (s *Selection) paths(prefix string, paths *[]string) {
+	for _, a := range s.Attributes {
+		*paths = append(*paths, prefix+a)
+	}
+	for _, name := range s.relationshipNames() {
+		*paths = append(*paths, prefix+name)
+		if child := s.Relationships[name]; child != nil {
+			child.paths(prefix+name+".", paths)
+		}
+	}
+}
+
+// GraphQL renders s as a GraphQL selection set, e.g.
+// "{ id title author { id name } }". Member names are used as they are,
+// so they must be valid GraphQL names.
+func (s *Selection) GraphQL() string {
+	var b strings.Builder
+	s.graphQL(&b)
+	return b.String()
+}
+
+func (s *Selection) graphQL(b *strings.Builder) {
+	b.WriteString("{ id")
+	for _, a := range s.Attributes {
+		b.WriteString(" " + a)
+	}
+	for _, name := range s.relationshipNames() {
+		b.WriteString(" " + name + " ")
+		if child := s.Relationships[name]; child != nil {
+			child.graphQL(b)
+		} else {
+			b.WriteString("{ id }")
+		}
+	}
+	b.WriteString(" }")
+}
+
+func (s *Selection) relationshipNames() []string {
+	names := make([]string, 0, len(s.Relationships))
+	for name := range s.Relationships {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
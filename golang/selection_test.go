@@ -0,0 +1,116 @@
+package jsonapi
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func parseSelectionQuery(t *testing.T, raw string) *Query {
+	t.Helper()
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := ParseQuery(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func TestQuerySelection(t *testing.T) {
+	q := parseSelectionQuery(t, "include=author,comments&fields[articles]=title,views,author,comments&fields[people]=name")
+	s, err := q.Selection([]*reqArticle{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Selection{
+		Type:       "articles",
+		Attributes: []string{"title", "views"},
+		Relationships: map[string]*Selection{
+			"author":   {Type: "people", Attributes: []string{"name"}, Relationships: map[string]*Selection{}},
+			"comments": {Type: "comments", Attributes: []string{"body"}, Relationships: map[string]*Selection{}},
+		},
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("selection %+v, want %+v", s, want)
+	}
+
+	if got, want := s.Paths(), []string{"title", "views", "author", "author.name", "comments", "comments.body"}; !equalStrings(got, want) {
+		t.Errorf("paths %v, want %v", got, want)
+	}
+	if got, want := s.GraphQL(), "{ id title views author { id name } comments { id body } }"; got != want {
+		t.Errorf("GraphQL %q, want %q", got, want)
+	}
+	if !s.Has("title") || !s.Has("author") || s.Has("score") || s.Has("id") {
+		t.Errorf("Has is wrong for %+v", s)
+	}
+}
+
+func TestQuerySelectionDefaults(t *testing.T) {
+	var q *Query
+	s, err := q.Selection(&reqArticle{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Attributes, []string{"title", "views", "score", "published", "created", "updated"}; !equalStrings(got, want) {
+		t.Errorf("attributes %v, want %v", got, want)
+	}
+	// Relationships not included carry their linkage only.
+	if child, ok := s.Relationships["author"]; !ok || child != nil {
+		t.Errorf("author selection %+v, want linkage only", child)
+	}
+	if got, want := s.GraphQL(), "{ id title views score published created updated author { id } comments { id } }"; got != want {
+		t.Errorf("GraphQL %q, want %q", got, want)
+	}
+
+	// Write-only attributes are never read back.
+	s, err = (&Query{}).Selection(accessUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Has("password") {
+		t.Errorf("selection %+v has a write-only attribute", s)
+	}
+}
+
+func TestQuerySelectionNested(t *testing.T) {
+	q := parseSelectionQuery(t, "include=comments.author,comments&fields[people]=name")
+	s, err := q.Selection(&orderPost{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments := s.Relationships["comments"]
+	if comments == nil || comments.Relationships["author"] == nil || comments.Relationships["author"].Type != "people" {
+		t.Fatalf("selection %+v", s)
+	}
+	if got, want := s.Paths(), []string{"author", "comments", "comments.author"}; !equalStrings(got, want) {
+		t.Errorf("paths %v, want %v", got, want)
+	}
+}
+
+func TestQuerySelectionIDs(t *testing.T) {
+	s, err := (&Query{Include: []string{"editor", "tags"}}).Selection(idsPost{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Relationships["editor"].Type != "people" || s.Relationships["tags"].Type != "tags" {
+		t.Errorf("selection %+v", s)
+	}
+	if _, err := (&Query{Include: []string{"editor.name"}}).Selection(idsPost{}); err == nil {
+		t.Error("expected an error for a path through an ids relation")
+	}
+}
+
+func TestQuerySelectionErrors(t *testing.T) {
+	if _, err := (&Query{}).Selection("articles"); !errors.Is(err, ErrUnexpectedType) {
+		t.Errorf("error %v, want ErrUnexpectedType", err)
+	}
+	for _, path := range []string{"editor", "title", "author.posts"} {
+		if _, err := (&Query{Include: []string{path}}).Selection(&reqArticle{}); err == nil {
+			t.Errorf("%s: expected an error", path)
+		}
+	}
+}
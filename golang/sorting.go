@@ -0,0 +1,131 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// ApplySort sorts models, a slice of tagged structs or struct pointers, in
+// place according to sorts, typically Query.Sort. Sort fields name
+// attributes, or "id" for the primary field. The sort is stable, and nil
+// pointers and nil elements sort first.
+//
+// It is meant for in-memory data sets and test servers; databases should
+// be asked to sort instead. opts, e.g. WithTagKey, name the members as for
+// Marshal.
+func ApplySort(models interface{}, sorts []SortField, opts ...MarshalOption) error {
+	v := reflect.ValueOf(models)
+	if v.Kind() != reflect.Slice {
+		return ErrExpectedSlice
+	}
+
+	elem := v.Type().Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return ErrExpectedSlice
+	}
+
+	tags := &newMarshalOptions(opts).tagOptions
+	indexes := make([][]int, len(sorts))
+	for i, s := range sorts {
+		field, ok := memberField(elem, s.Field, tags)
+		if !ok || !sortable(field.Type) {
+			return fmt.Errorf("jsonapi: cannot sort %s by %q", elem, s.Field)
+		}
+		indexes[i] = field.Index
+	}
+
+	sort.SliceStable(models, func(i, j int) bool {
+		a, b := reflect.Indirect(v.Index(i)), reflect.Indirect(v.Index(j))
+		if !a.IsValid() || !b.IsValid() {
+			return !a.IsValid() && b.IsValid()
+		}
+
+		for k, s := range sorts {
+			c := compareValues(a.FieldByIndex(indexes[k]), b.FieldByIndex(indexes[k]))
+			if c == 0 {
+				continue
+			}
+			if s.Descending {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func sortable(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// compareValues returns -1, 0 or 1 comparing two values of a type accepted
+// by sortable.
+func compareValues(a, b reflect.Value) int {
+	if a.Kind() == reflect.Ptr {
+		switch {
+		case a.IsNil() && b.IsNil():
+			return 0
+		case a.IsNil():
+			return -1
+		case b.IsNil():
+			return 1
+		}
+		a, b = a.Elem(), b.Elem()
+	}
+
+	if a.Type() == timeType {
+		ta, tb := a.Interface().(time.Time), b.Interface().(time.Time)
+		switch {
+		case ta.Before(tb):
+			return -1
+		case ta.After(tb):
+			return 1
+		}
+		return 0
+	}
+
+	var less, greater bool
+	switch a.Kind() {
+	case reflect.String:
+		less, greater = a.String() < b.String(), a.String() > b.String()
+	case reflect.Bool:
+		less, greater = !a.Bool() && b.Bool(), a.Bool() && !b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		less, greater = a.Int() < b.Int(), a.Int() > b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		less, greater = a.Uint() < b.Uint(), a.Uint() > b.Uint()
+	case reflect.Float32, reflect.Float64:
+		less, greater = a.Float() < b.Float(), a.Float() > b.Float()
+	}
+
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,86 @@
+package jsonapi
+
+import (
+	"testing"
+	"time"
+)
+
+type sortTask struct {
+	ID       string     `jsonapi:"primary,tasks"`
+	Title    string     `jsonapi:"attr,title"`
+	Priority int        `jsonapi:"attr,priority"`
+	Due      *time.Time `jsonapi:"attr,due,iso8601"`
+	Done     bool       `jsonapi:"attr,done"`
+	Tags     []string   `jsonapi:"attr,tags"`
+}
+
+func sortTaskIDs(tasks []*sortTask) []string {
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		if task == nil {
+			ids[i] = "<nil>"
+			continue
+		}
+		ids[i] = task.ID
+	}
+	return ids
+}
+
+func TestApplySort(t *testing.T) {
+	day := func(d int) *time.Time {
+		tm := time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+		return &tm
+	}
+
+	tests := []struct {
+		sort string
+		want []string
+	}{
+		{"title", []string{"<nil>", "1", "3", "2", "4"}},
+		{"-priority,title", []string{"<nil>", "3", "2", "1", "4"}},
+		{"due", []string{"<nil>", "4", "2", "1", "3"}},
+		{"-done,id", []string{"<nil>", "2", "1", "3", "4"}},
+	}
+	for _, tt := range tests {
+		tasks := []*sortTask{
+			{ID: "1", Title: "a", Priority: 1, Due: day(3)},
+			{ID: "2", Title: "b", Priority: 2, Due: day(2), Done: true},
+			nil,
+			{ID: "3", Title: "a", Priority: 2, Due: day(4)},
+			{ID: "4", Title: "c"},
+		}
+		if err := ApplySort(tasks, ParseSort(tt.sort)); err != nil {
+			t.Fatalf("%s: %v", tt.sort, err)
+		}
+		if got := sortTaskIDs(tasks); !equalStrings(got, tt.want) {
+			t.Errorf("%s: order %v, want %v", tt.sort, got, tt.want)
+		}
+	}
+}
+
+func TestApplySortValues(t *testing.T) {
+	tasks := []sortTask{{ID: "b"}, {ID: "a"}}
+	if err := ApplySort(tasks, ParseSort("id")); err != nil {
+		t.Fatal(err)
+	}
+	if tasks[0].ID != "a" {
+		t.Errorf("order %v", tasks)
+	}
+}
+
+func TestApplySortErrors(t *testing.T) {
+	tests := []struct {
+		models interface{}
+		sort   string
+	}{
+		{&[]*sortTask{}, "id"},
+		{[]int{1}, "id"},
+		{[]*sortTask{}, "tags"},
+		{[]*sortTask{}, "missing"},
+	}
+	for _, tt := range tests {
+		if err := ApplySort(tt.models, ParseSort(tt.sort)); err == nil {
+			t.Errorf("ApplySort(%T, %q) succeeded", tt.models, tt.sort)
+		}
+	}
+}
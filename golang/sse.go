@@ -0,0 +1,174 @@
+package jsonapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types written by SSEWriter.Created, Updated and Deleted.
+const (
+	SSECreated = "created"
+	SSEUpdated = "updated"
+	SSEDeleted = "deleted"
+)
+
+var errSSEField = errors.New("jsonapi: SSE event type and id cannot contain line breaks")
+
+// SSEWriter streams resource changes as server-sent events: each event
+// has a type, e.g. SSEUpdated, an optional id and a single-resource
+// document as data.
+//
+//	s := jsonapi.NewSSEWriter(w)
+//	go s.KeepAlive(r.Context(), 15*time.Second)
+//	for change := range changes(r.Context(), jsonapi.LastEventID(r)) {
+//		if err := s.Updated(change.Seq, change.Appointment); err != nil {
+//			return
+//		}
+//	}
+//
+// Browsers reconnect on their own and send the id of the last event they
+// got, which LastEventID returns, so that the handler can resume from
+// there. An SSEWriter is safe for concurrent use.
+type SSEWriter struct {
+	mu   sync.Mutex
+	w    io.Writer
+	opts []MarshalOption
+}
+
+// NewSSEWriter returns an SSEWriter writing to w, flushing after each
+// event if w is an http.Flusher. If w is an http.ResponseWriter, the
+// event stream headers are set on it. opts apply as they would to
+// MarshalPayload.
+func NewSSEWriter(w io.Writer, opts ...MarshalOption) *SSEWriter {
+	if rw, ok := w.(http.ResponseWriter); ok {
+		h := rw.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		// Keep proxies such as nginx from buffering the stream.
+		h.Set("X-Accel-Buffering", "no")
+	}
+	return &SSEWriter{w: w, opts: opts}
+}
+
+// Created sends an SSECreated event with the document of model.
+func (s *SSEWriter) Created(id string, model interface{}) error {
+	return s.Send(SSECreated, id, model)
+}
+
+// Updated sends an SSEUpdated event with the document of model.
+func (s *SSEWriter) Updated(id string, model interface{}) error {
+	return s.Send(SSEUpdated, id, model)
+}
+
+// Deleted sends an SSEDeleted event whose document holds the resource
+// identifier of model only.
+func (s *SSEWriter) Deleted(id string, model interface{}) error {
+	payload, err := Marshal(model, s.opts...)
+	if err != nil {
+		return err
+	}
+	one, ok := payload.(*OnePayload)
+	if !ok {
+		return ErrUnexpectedType
+	}
+	data := one.Data
+	if data != nil {
+		data = NewNode(data.Type, data.ID)
+	}
+	return s.Send(SSEDeleted, id, &OnePayload{Data: data})
+}
+
+// Send sends an event of type event, with the id id if not empty, whose
+// data is the document of v: a model, as for MarshalPayload, or a
+// Payloader.
+func (s *SSEWriter) Send(event, id string, v interface{}) error {
+	if strings.ContainsAny(event+id, "\r\n") {
+		return errSSEField
+	}
+
+	var data []byte
+	var err error
+	if p, ok := v.(Payloader); ok {
+		var buf bytes.Buffer
+		if err = encodePayload(&buf, p, newMarshalOptions(s.opts)); err == nil {
+			data = buf.Bytes()
+		}
+	} else {
+		data, err = MarshalBytes(v, s.opts...)
+	}
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if event != "" {
+		fmt.Fprintf(&buf, "event: %s\n", event)
+	}
+	if id != "" {
+		fmt.Fprintf(&buf, "id: %s\n", id)
+	}
+	// Indented documents span several data lines, which the client joins
+	// again with line breaks.
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		fmt.Fprintf(&buf, "data: %s\n", line)
+	}
+	buf.WriteByte('\n')
+	return s.write(buf.Bytes())
+}
+
+// Retry tells the client to wait d before reconnecting.
+func (s *SSEWriter) Retry(d time.Duration) error {
+	return s.write([]byte(fmt.Sprintf("retry: %d\n\n", d.Milliseconds())))
+}
+
+// Heartbeat sends a comment, which clients ignore, to keep the connection
+// from being closed as idle.
+func (s *SSEWriter) Heartbeat() error {
+	return s.write([]byte(": heartbeat\n\n"))
+}
+
+// KeepAlive sends a heartbeat every interval until ctx is done or a write
+// fails, and returns the reason it stopped.
+func (s *SSEWriter) KeepAlive(ctx context.Context, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			if err := s.Heartbeat(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *SSEWriter) write(b []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(b); err != nil {
+		return err
+	}
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// LastEventID returns the id of the last event a reconnecting client got:
+// the Last-Event-ID header, or the lastEventId query parameter used by
+// polyfills that cannot set headers.
+func LastEventID(r *http.Request) string {
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		return id
+	}
+	return r.URL.Query().Get("lastEventId")
+}
@@ -0,0 +1,137 @@
+package jsonapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSEWriter(t *testing.T) {
+	w := httptest.NewRecorder()
+	s := NewSSEWriter(w, WithIndent("", " "))
+	if h := w.Header(); h.Get("Content-Type") != "text/event-stream" || h.Get("Cache-Control") != "no-cache" {
+		t.Errorf("headers %v", h)
+	}
+
+	author := &reqAuthor{ID: "1", Name: "Ann"}
+	if err := s.Created("7", author); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Deleted("", author); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Retry(1500 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Heartbeat(); err != nil {
+		t.Fatal(err)
+	}
+	if !w.Flushed {
+		t.Error("events were not flushed")
+	}
+
+	want := "event: created\nid: 7\n" +
+		"data: {\n" +
+		"data:  \"data\": {\n" +
+		"data:   \"type\": \"people\",\n" +
+		"data:   \"id\": \"1\",\n" +
+		"data:   \"attributes\": {\n" +
+		"data:    \"name\": \"Ann\"\n" +
+		"data:   }\n" +
+		"data:  }\n" +
+		"data: }\n\n" +
+		"event: deleted\n" +
+		"data: {\n" +
+		"data:  \"data\": {\n" +
+		"data:   \"type\": \"people\",\n" +
+		"data:   \"id\": \"1\"\n" +
+		"data:  }\n" +
+		"data: }\n\n" +
+		"retry: 1500\n\n" +
+		": heartbeat\n\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSSEWriterErrors(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewSSEWriter(&buf)
+	for _, tt := range []struct{ event, id string }{{"a\nb", ""}, {"updated", "1\r"}} {
+		if err := s.Send(tt.event, tt.id, &reqAuthor{ID: "1"}); err != errSSEField {
+			t.Errorf("%q %q: error %v, want errSSEField", tt.event, tt.id, err)
+		}
+	}
+	if err := s.Updated("1", 1); err != ErrUnexpectedType {
+		t.Errorf("error %v, want ErrUnexpectedType", err)
+	}
+	if err := s.Deleted("1", []*reqAuthor{{ID: "1"}}); err != ErrUnexpectedType {
+		t.Errorf("error %v, want ErrUnexpectedType", err)
+	}
+	if buf.Len() > 0 {
+		t.Errorf("written %q", buf.String())
+	}
+}
+
+func TestSSEWriterAttributeOrder(t *testing.T) {
+	opt := WithAttributeOrder(AttributeDeclarationOrder)
+	item := &ndjsonItem{ID: "1", Zeta: "z", Alpha: 1}
+	payload, err := Marshal(item, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	s := NewSSEWriter(&buf, opt)
+	if err := s.Updated("1", item); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Send(SSEUpdated, "2", payload); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(buf.String(), `"attributes":{"zeta":"z","alpha":1}`); got != 2 {
+		t.Errorf("events\n%s\nwant both with attributes in field order", buf.String())
+	}
+}
+
+// failingWriter fails every write after the first n.
+type failingWriter struct {
+	n int
+}
+
+func (w *failingWriter) Write(b []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errors.New("closed")
+	}
+	w.n--
+	return len(b), nil
+}
+
+func TestSSEKeepAlive(t *testing.T) {
+	s := NewSSEWriter(&failingWriter{n: 2})
+	if err := s.KeepAlive(context.Background(), time.Millisecond); err == nil || err.Error() != "closed" {
+		t.Errorf("error %v, want the write error", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewSSEWriter(&failingWriter{}).KeepAlive(ctx, time.Hour); err != context.Canceled {
+		t.Errorf("error %v, want context.Canceled", err)
+	}
+}
+
+func TestLastEventID(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/events?lastEventId=3", nil)
+	if got := LastEventID(r); got != "3" {
+		t.Errorf("query: %q", got)
+	}
+	r.Header.Set("Last-Event-ID", "5")
+	if got := LastEventID(r); got != "5" {
+		t.Errorf("header: %q", got)
+	}
+}
@@ -0,0 +1,151 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// errorObjecter is implemented by the errors that know the error object
+// describing them, such as *AttributeError and *RelationshipError.
+type errorObjecter interface {
+	ErrorObject() *ErrorObject
+}
+
+// StatusForError returns the HTTP status code of the response to a request
+// that failed with err:
+//
+//   - 400 Bad Request for documents that cannot be unmarshaled, e.g. with
+//     ErrInvalidType, ErrBadJSONAPIID, ErrNullToMany, an
+//     *UnknownFieldsError, malformed JSON or a *CodecError, and when a
+//     Max* limit other than MaxBodyBytes is exceeded, and for
+//     ErrInvalidIdempotencyKey.
+//   - 401 Unauthorized for webhook bodies failing VerifyEvent.
+//   - 403 Forbidden for writes to read-only attributes and
+//     ErrClientGeneratedID.
+//   - 406 Not Acceptable and 415 Unsupported Media Type for
+//     ErrNotAcceptable and ErrUnsupportedMediaType.
+//   - 413 Payload Too Large for ErrBodyTooLarge.
+//   - 422 Unprocessable Entity for an *AttributeValidationError and
+//     ErrIdempotencyKeyReused.
+//   - 429 Too Many Requests for a *RateLimitError.
+//   - 500 Internal Server Error for everything else, including the
+//     *ModelError of misdeclared models.
+//
+// Errors carrying their own error object, including *ErrorObject itself,
+// get the status of that object. err may be wrapped.
+func StatusForError(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	var obj *ErrorObject
+	var objecter errorObjecter
+	switch {
+	case errors.As(err, &obj):
+		return objectStatus(obj)
+	case errors.As(err, &objecter):
+		return objectStatus(objecter.ErrorObject())
+	}
+
+	var (
+		modelErr      *ModelError
+		validationErr *AttributeValidationError
+		unknownErr    *UnknownFieldsError
+		ptrErr        ErrUnsupportedPtrType
+		syntaxErr     *json.SyntaxError
+		typeErr       *json.UnmarshalTypeError
+		codecErr      *CodecError
+	)
+	switch {
+	case errors.As(err, &modelErr):
+		return http.StatusInternalServerError
+	case errors.As(err, &validationErr), errors.Is(err, ErrIdempotencyKeyReused):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ErrInvalidSignature):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrReadOnly), errors.Is(err, ErrClientGeneratedID):
+		return http.StatusForbidden
+	case errors.Is(err, ErrNotAcceptable):
+		return http.StatusNotAcceptable
+	case errors.Is(err, ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, ErrBodyTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.As(err, &unknownErr), errors.As(err, &ptrErr),
+		errors.As(err, &syntaxErr), errors.As(err, &typeErr),
+		errors.As(err, &codecErr),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, ErrInvalidTime), errors.Is(err, ErrInvalidISO8601),
+		errors.Is(err, ErrInvalidRFC3339), errors.Is(err, ErrInvalidType),
+		errors.Is(err, ErrUnknownFieldNumberType), errors.Is(err, ErrBadJSONAPIID),
+		errors.Is(err, ErrNumberOutOfRange), errors.Is(err, ErrFractionalNumber),
+		errors.Is(err, ErrNullToMany), errors.Is(err, ErrInvalidRelationship),
+		errors.Is(err, ErrUnknownEnumValue), errors.Is(err, ErrInvalidCursor),
+		errors.Is(err, ErrBinaryFormat), errors.Is(err, ErrTooManyIncluded),
+		errors.Is(err, ErrRelationshipFanout), errors.Is(err, ErrMaxDepth),
+		errors.Is(err, ErrInvalidDocument), errors.Is(err, ErrInvalidIdempotencyKey):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// objectStatus returns the status of obj, or 500 if it has none.
+func objectStatus(obj *ErrorObject) int {
+	if status, err := strconv.Atoi(obj.Status); err == nil && status >= 400 {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// ErrorObjectsFor returns the error objects describing err: those it
+// carries, as *AttributeValidationError and *ErrorObject do, or else one
+// with the status of StatusForError. The detail of 5xx errors is left out
+// so that internal problems are not disclosed to clients.
+func ErrorObjectsFor(err error) []*ErrorObject {
+	var (
+		obj           *ErrorObject
+		objecter      errorObjecter
+		validationErr *AttributeValidationError
+		syntaxErr     *json.SyntaxError
+		typeErr       *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &validationErr):
+		return validationErr.Errors
+	case errors.As(err, &obj):
+		return []*ErrorObject{obj}
+	case errors.As(err, &objecter):
+		return []*ErrorObject{objecter.ErrorObject()}
+	case errors.As(err, &syntaxErr):
+		return []*ErrorObject{JSONErrorObject(syntaxErr)}
+	case errors.As(err, &typeErr):
+		return []*ErrorObject{JSONErrorObject(typeErr)}
+	case errors.Is(err, io.EOF):
+		return []*ErrorObject{JSONErrorObject(io.EOF)}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return []*ErrorObject{JSONErrorObject(io.ErrUnexpectedEOF)}
+	}
+
+	status := StatusForError(err)
+	obj = &ErrorObject{
+		Title:  http.StatusText(status),
+		Status: strconv.Itoa(status),
+	}
+	if status < http.StatusInternalServerError {
+		obj.Detail = err.Error()
+	}
+	return []*ErrorObject{obj}
+}
+
+// WriteError writes the error document for err, see ErrorObjectsFor, with
+// the status code of StatusForError, and a Retry-After header for a
+// *RateLimitError.
+func WriteError(w http.ResponseWriter, err error) error {
+	setRetryAfter(w, err)
+	w.Header().Set("Content-Type", MediaType)
+	w.WriteHeader(StatusForError(err))
+	return MarshalErrors(w, ErrorObjectsFor(err))
+}
@@ -0,0 +1,129 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatusForError(t *testing.T) {
+	var syntaxErr error
+	if err := json.Unmarshal([]byte("{"), new(interface{})); err != nil {
+		syntaxErr = err
+	}
+
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, http.StatusOK},
+		{errors.New("boom"), http.StatusInternalServerError},
+		{&ModelError{Model: "x", Msg: "bad", Err: ErrBadJSONAPIStructTag}, http.StatusInternalServerError},
+		{&ErrorObject{Status: "409"}, http.StatusConflict},
+		{&ErrorObject{Status: "200"}, http.StatusInternalServerError},
+		{&ErrorObject{}, http.StatusInternalServerError},
+		{&AttributeError{Type: "users", Attribute: "x", Err: ErrReadOnly}, http.StatusForbidden},
+		{&AttributeError{Type: "users", Attribute: "x", Err: ErrInvalidType}, http.StatusBadRequest},
+		{&AttributeValidationError{}, http.StatusUnprocessableEntity},
+		{&RateLimitError{}, http.StatusTooManyRequests},
+		{ErrIdempotencyKeyReused, http.StatusUnprocessableEntity},
+		{ErrInvalidSignature, http.StatusUnauthorized},
+		{ErrClientGeneratedID, http.StatusForbidden},
+		{ErrNotAcceptable, http.StatusNotAcceptable},
+		{ErrUnsupportedMediaType, http.StatusUnsupportedMediaType},
+		{ErrBodyTooLarge, http.StatusRequestEntityTooLarge},
+		{&UnknownFieldsError{Type: "users"}, http.StatusBadRequest},
+		{syntaxErr, http.StatusBadRequest},
+		{io.ErrUnexpectedEOF, http.StatusBadRequest},
+		{ErrMaxDepth, http.StatusBadRequest},
+		{fmt.Errorf("decoding: %w", ErrUnknownEnumValue), http.StatusBadRequest},
+		{fmt.Errorf("wrapped: %w", &ErrorObject{Status: "404"}), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if got := StatusForError(tt.err); got != tt.want {
+			t.Errorf("StatusForError(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestStatusForUnmarshalErrors(t *testing.T) {
+	for _, doc := range []string{
+		``,
+		`{"data": `,
+		`{"data": {"type": "articles", "id": "1", "attributes": {"views": "many"}}}`,
+		`{"data": {"type": "articles", "id": "1", "attributes": {"created": "yesterday"}}}`,
+	} {
+		err := UnmarshalPayload(strings.NewReader(doc), new(reqArticle))
+		if got := StatusForError(err); got != http.StatusBadRequest {
+			t.Errorf("%q: status %d for %v, want 400", doc, got, err)
+		}
+	}
+}
+
+func TestErrorObjectsFor(t *testing.T) {
+	validation := &AttributeValidationError{Errors: []*ErrorObject{{Code: "a"}, {Code: "b"}}}
+	if objs := ErrorObjectsFor(validation); len(objs) != 2 || objs[1].Code != "b" {
+		t.Errorf("validation error objects %+v", objs)
+	}
+
+	obj := &ErrorObject{Title: "Gone", Status: "410"}
+	if objs := ErrorObjectsFor(fmt.Errorf("x: %w", obj)); len(objs) != 1 || objs[0] != obj {
+		t.Errorf("error object %+v", objs)
+	}
+
+	objs := ErrorObjectsFor(&AttributeError{Type: "users", Attribute: "x", Err: ErrReadOnly})
+	if len(objs) != 1 || objs[0].Status != "403" || objs[0].Source.Pointer != "/data/attributes/x" {
+		t.Errorf("attribute error objects %+v", objs)
+	}
+
+	objs = ErrorObjectsFor(io.ErrUnexpectedEOF)
+	if len(objs) != 1 || objs[0].Status != "400" {
+		t.Errorf("EOF error objects %+v", objs)
+	}
+
+	objs = ErrorObjectsFor(ErrBodyTooLarge)
+	if len(objs) != 1 || objs[0].Status != "413" || objs[0].Detail != ErrBodyTooLarge.Error() {
+		t.Errorf("body too large error objects %+v", objs)
+	}
+
+	// Internal errors are not disclosed.
+	objs = ErrorObjectsFor(errors.New("database password is hunter2"))
+	if len(objs) != 1 || objs[0].Status != "500" || objs[0].Detail != "" || objs[0].Title != "Internal Server Error" {
+		t.Errorf("internal error objects %+v", objs)
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	w := httptest.NewRecorder()
+	if err := WriteError(w, &RateLimitError{RetryAfter: 1500 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "2" ||
+		w.Header().Get("Content-Type") != MediaType {
+		t.Errorf("response %d %v", w.Code, w.Header())
+	}
+
+	var doc struct {
+		Errors []ErrorObject `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Errors) != 1 || doc.Errors[0].Status != "429" {
+		t.Errorf("errors %+v", doc.Errors)
+	}
+
+	w = httptest.NewRecorder()
+	if err := WriteError(w, ErrNotAcceptable); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusNotAcceptable || w.Header().Get("Retry-After") != "" {
+		t.Errorf("response %d %v", w.Code, w.Header())
+	}
+}
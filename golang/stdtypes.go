@@ -0,0 +1,123 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+)
+
+var (
+	urlType         = reflect.TypeOf(url.URL{})
+	mailAddressType = reflect.TypeOf(mail.Address{})
+)
+
+// stringType converts the values of a standard library type to and from
+// the string an attribute of that type holds.
+type stringType struct {
+	// format returns the string for v, a value of the type.
+	format func(v reflect.Value, args []string) string
+	// parse returns a pointer to the value s stands for.
+	parse func(s string, args []string) (reflect.Value, error)
+}
+
+// stringTypes are the standard library types written in their canonical
+// string form rather than the way encoding/json would:
+//
+//   - url.URL as its String, e.g. https://example.com/a?b=c; relative
+//     references are accepted.
+//   - net.IP as its String, e.g. 192.0.2.1 or 2001:db8::1.
+//   - mail.Address as an RFC 5322 address such as "Ann" <ann@example.com>,
+//     or as the bare ann@example.com for attributes tagged with the
+//     addrspec option. Both forms are accepted on unmarshal, and the zero
+//     Address is the empty string.
+//
+// Pointers to them are supported too.
+var stringTypes = map[reflect.Type]stringType{
+	urlType: {
+		format: func(v reflect.Value, args []string) string {
+			u := v.Interface().(url.URL)
+			return u.String()
+		},
+		parse: func(s string, args []string) (reflect.Value, error) {
+			u, err := url.Parse(s)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			return reflect.ValueOf(u), nil
+		},
+	},
+	reflect.TypeOf(net.IP{}): {
+		format: func(v reflect.Value, args []string) string {
+			return v.Interface().(net.IP).String()
+		},
+		parse: func(s string, args []string) (reflect.Value, error) {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return reflect.Value{}, &net.ParseError{Type: "IP address", Text: s}
+			}
+			return reflect.ValueOf(&ip), nil
+		},
+	},
+	mailAddressType: {
+		format: func(v reflect.Value, args []string) string {
+			a := v.Interface().(mail.Address)
+			if a.Address == "" || hasOption(args, annotationAddrSpec) {
+				return a.Address
+			}
+			return a.String()
+		},
+		parse: func(s string, args []string) (reflect.Value, error) {
+			if s == "" {
+				return reflect.New(mailAddressType), nil
+			}
+			a, err := mail.ParseAddress(s)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			return reflect.ValueOf(a), nil
+		},
+	},
+}
+
+// lookupStringType returns the conversions for t, or for the element type
+// of the pointer t.
+func lookupStringType(t reflect.Type) (stringType, bool) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	st, ok := stringTypes[t]
+	return st, ok
+}
+
+// marshalStringType returns the attribute value of v, whose type has
+// conversions in stringTypes: its string, or nil for a nil pointer.
+func marshalStringType(v reflect.Value, args []string) interface{} {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		return nil
+	}
+	st, _ := lookupStringType(v.Type())
+	return st.format(v, args)
+}
+
+// unmarshalStringType parses the attribute value of a field of type t, not
+// a pointer, into a pointer to a value of t.
+func unmarshalStringType(attribute interface{}, t reflect.Type, args []string) (reflect.Value, error) {
+	s, ok := attribute.(string)
+	if !ok {
+		return reflect.Value{}, ErrInvalidType
+	}
+	st, _ := lookupStringType(t)
+	ptr, err := st.parse(s, args)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("%w: %v", ErrInvalidType, err)
+	}
+	return ptr, nil
+}
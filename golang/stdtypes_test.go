@@ -0,0 +1,99 @@
+package jsonapi
+
+import (
+	"errors"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type stdContact struct {
+	ID       string        `jsonapi:"primary,contacts"`
+	Homepage url.URL       `jsonapi:"attr,homepage"`
+	Callback *url.URL      `jsonapi:"attr,callback"`
+	IP       net.IP        `jsonapi:"attr,ip"`
+	Email    mail.Address  `jsonapi:"attr,email"`
+	ReplyTo  *mail.Address `jsonapi:"attr,reply-to,addrspec"`
+}
+
+func TestMarshalStringTypes(t *testing.T) {
+	in := &stdContact{
+		ID:       "1",
+		Homepage: url.URL{Scheme: "https", Host: "example.com", Path: "/a", RawQuery: "b=c"},
+		IP:       net.ParseIP("2001:db8::1"),
+		Email:    mail.Address{Name: "Ann", Address: "ann@example.com"},
+		ReplyTo:  &mail.Address{Name: "Ann", Address: "ann@example.com"},
+	}
+	attrs := marshalDoc(t, in)["data"].(map[string]interface{})["attributes"]
+	want := map[string]interface{}{
+		"homepage": "https://example.com/a?b=c",
+		"callback": nil,
+		"ip":       "2001:db8::1",
+		"email":    `"Ann" <ann@example.com>`,
+		"reply-to": "ann@example.com",
+	}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("attributes %v, want %v", attrs, want)
+	}
+
+	attrs = marshalDoc(t, &stdContact{ID: "1"})["data"].(map[string]interface{})["attributes"]
+	want = map[string]interface{}{"homepage": "", "callback": nil, "ip": nil, "email": "", "reply-to": nil}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("zero attributes %v, want %v", attrs, want)
+	}
+}
+
+func TestUnmarshalStringTypes(t *testing.T) {
+	doc := `{"data": {"type": "contacts", "id": "1", "attributes": {
+		"homepage": "https://example.com/a?b=c", "callback": "/hooks/1", "ip": "192.0.2.1",
+		"email": "Ann <ann@example.com>", "reply-to": "ann@example.com"}}}`
+	out := new(stdContact)
+	if err := UnmarshalPayload(strings.NewReader(doc), out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Homepage.Host != "example.com" || out.Homepage.RawQuery != "b=c" ||
+		out.Callback == nil || out.Callback.Path != "/hooks/1" ||
+		!out.IP.Equal(net.ParseIP("192.0.2.1")) ||
+		out.Email != (mail.Address{Name: "Ann", Address: "ann@example.com"}) ||
+		out.ReplyTo == nil || out.ReplyTo.Address != "ann@example.com" {
+		t.Errorf("unmarshaled %+v", out)
+	}
+
+	out = &stdContact{Email: mail.Address{Address: "old@example.com"}}
+	doc = `{"data": {"type": "contacts", "id": "1", "attributes": {"email": ""}}}`
+	if err := UnmarshalPayload(strings.NewReader(doc), out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Email != (mail.Address{}) {
+		t.Errorf("email %v, want the zero address", out.Email)
+	}
+
+	for _, attrs := range []string{
+		`{"homepage": "%zz"}`,
+		`{"ip": "300.1.1.1"}`,
+		`{"email": "not an address"}`,
+		`{"ip": 1}`,
+	} {
+		doc := `{"data": {"type": "contacts", "id": "1", "attributes": ` + attrs + `}}`
+		if err := UnmarshalPayload(strings.NewReader(doc), new(stdContact)); !errors.Is(err, ErrInvalidType) {
+			t.Errorf("%s: error %v, want ErrInvalidType", attrs, err)
+		}
+	}
+}
+
+func TestAddrSpecCheckModel(t *testing.T) {
+	type bad struct {
+		ID    string `jsonapi:"primary,bad"`
+		Email string `jsonapi:"attr,email,addrspec"`
+	}
+	if errs := CheckModel(&stdContact{}); errs != nil {
+		t.Errorf("CheckModel(stdContact) = %v", errs)
+	}
+	errs := CheckModel(&bad{})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "addrspec option used on non-address field") {
+		t.Errorf("CheckModel(bad) = %v", errs)
+	}
+}
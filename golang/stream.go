@@ -0,0 +1,242 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// StreamDecoder reads the resources of a collection document one at a
+// time, so that documents too large to hold in memory, such as exports
+// with millions of resources, can be imported with bounded memory:
+//
+//	dec := jsonapi.NewStreamDecoder(r)
+//	for {
+//		post := new(Post)
+//		if err := dec.DecodeResource(post); err == io.EOF {
+//			break
+//		} else if err != nil {
+//			return err
+//		}
+//		// store post
+//	}
+//
+// Top-level members other than data are read as they come. When included
+// precedes data in the document it is held in memory and relationships of
+// the resources are resolved against it; otherwise it is only read once
+// the data has been consumed, see Included.
+//
+// The document structure is always read with encoding/json; UseCodec does
+// not apply. UseNumber, InternStrings, DisallowUnknownFields and the Max*
+// limits do, MaxIncludedResources for included only.
+type StreamDecoder struct {
+	dec *json.Decoder
+	o   *unmarshalOptions
+
+	state    decoderState
+	included []*Node
+	index    *map[string]*Node
+	meta     *Meta
+	links    *Links
+}
+
+type decoderState int
+
+const (
+	decoderStart   decoderState = iota
+	decoderInData               // inside the data array
+	decoderMembers              // between top-level members, data consumed
+	decoderDone                 // past the end of the document
+)
+
+// NewStreamDecoder returns a StreamDecoder reading a collection document
+// from r.
+func NewStreamDecoder(r io.Reader, opts ...UnmarshalOption) *StreamDecoder {
+	o := newUnmarshalOptions(opts)
+	dec := json.NewDecoder(o.body(r))
+	// Numbers are kept exact for DecodeResource, as by decodeNodes, and
+	// turned into float64 on the way out without UseNumber.
+	dec.UseNumber()
+	return &StreamDecoder{dec: dec, o: o}
+}
+
+// NextResource returns the next resource object of the data array, or
+// io.EOF once there are no more. null data has no resources.
+func (d *StreamDecoder) NextResource() (*Node, error) {
+	n, err := d.next()
+	if err != nil {
+		return nil, err
+	}
+	return d.o.floatNode(n), nil
+}
+
+// next is NextResource without floatNode.
+func (d *StreamDecoder) next() (*Node, error) {
+	if d.state == decoderStart {
+		if err := d.readMembers(); err != nil {
+			return nil, err
+		}
+	}
+	if d.state != decoderInData {
+		return nil, io.EOF
+	}
+
+	if !d.dec.More() {
+		// Consume the closing bracket.
+		if _, err := d.dec.Token(); err != nil {
+			return nil, err
+		}
+		d.state = decoderMembers
+		return nil, io.EOF
+	}
+
+	n := new(Node)
+	if err := d.dec.Decode(n); err != nil {
+		return nil, err
+	}
+	if d.o.interner != nil {
+		d.o.interner.nodes(n)
+	}
+	return n, nil
+}
+
+// DecodeResource stores the next resource of the data array in model, a
+// pointer to a tagged struct, as UnmarshalManyPayload would. It returns
+// io.EOF once there are no more resources.
+func (d *StreamDecoder) DecodeResource(model interface{}) error {
+	n, err := d.next()
+	if err != nil {
+		return err
+	}
+	return unmarshalNode(n, reflect.ValueOf(model), d.index, d.o, 0)
+}
+
+// Included returns the included resources of the document. Resources of
+// the data array not read yet are skipped, so it is meant to be called
+// after NextResource returned io.EOF, unless included precedes data.
+// NewIncluded indexes them for lookups.
+func (d *StreamDecoder) Included() ([]*Node, error) {
+	if err := d.finish(); err != nil {
+		return nil, err
+	}
+	return d.o.floatNodes(d.included), nil
+}
+
+// Meta returns the top-level meta of the document, reading the rest of it
+// as Included does.
+func (d *StreamDecoder) Meta() (*Meta, error) {
+	if err := d.finish(); err != nil {
+		return nil, err
+	}
+	return d.o.floatMeta(d.meta), nil
+}
+
+// Links returns the top-level links of the document, reading the rest of
+// it as Included does.
+func (d *StreamDecoder) Links() (*Links, error) {
+	if err := d.finish(); err != nil {
+		return nil, err
+	}
+	return d.o.floatLinks(d.links), nil
+}
+
+// finish reads the document up to its end.
+func (d *StreamDecoder) finish() error {
+	for d.state != decoderDone {
+		if d.state == decoderInData {
+			var skipped json.RawMessage
+			for d.dec.More() {
+				if err := d.dec.Decode(&skipped); err != nil {
+					return err
+				}
+			}
+			if _, err := d.dec.Token(); err != nil {
+				return err
+			}
+			d.state = decoderMembers
+		}
+		if err := d.readMembers(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readMembers reads top-level members until the data array is entered or
+// the document ends.
+func (d *StreamDecoder) readMembers() error {
+	if d.state == decoderStart {
+		if err := d.expectDelim('{'); err != nil {
+			return err
+		}
+		d.state = decoderMembers
+	}
+
+	for d.dec.More() {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+
+		switch key {
+		case "data":
+			tok, err := d.dec.Token()
+			if err != nil {
+				return err
+			}
+			if tok == nil {
+				continue
+			}
+			if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+				return fmt.Errorf("%w: data of a streamed document must be an array", ErrExpectedSlice)
+			}
+			d.state = decoderInData
+			return nil
+		case "included":
+			var included []*Node
+			if err := d.dec.Decode(&included); err != nil {
+				return err
+			}
+			if err := d.o.checkIncluded(len(included)); err != nil {
+				return err
+			}
+			if d.o.interner != nil {
+				d.o.interner.nodes(included...)
+			}
+			d.included = included
+			d.index = includedMap(included)
+		case "meta":
+			if err := d.dec.Decode(&d.meta); err != nil {
+				return err
+			}
+		case "links":
+			if err := d.dec.Decode(&d.links); err != nil {
+				return err
+			}
+		default:
+			var skipped json.RawMessage
+			if err := d.dec.Decode(&skipped); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := d.expectDelim('}'); err != nil {
+		return err
+	}
+	d.state = decoderDone
+	return nil
+}
+
+func (d *StreamDecoder) expectDelim(want json.Delim) error {
+	tok, err := d.dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("jsonapi: malformed document: expected %v, got %v", want, tok)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+const streamDoc = `{
+	"jsonapi": {"version": "1.1"},
+	"included": [{"type": "people", "id": "9", "attributes": {"name": "Ann"}}],
+	"data": [
+		{"type": "articles", "id": "1", "attributes": {"title": "a", "views": 1},
+			"relationships": {"author": {"data": {"type": "people", "id": "9"}}}},
+		{"type": "articles", "id": "2", "attributes": {"title": "b", "views": 2}},
+		{"type": "articles", "id": "3", "attributes": {"title": "c", "views": 3}}
+	],
+	"meta": {"total": 3},
+	"links": {"next": "/articles?page=2"}
+}`
+
+func TestStreamDecoder(t *testing.T) {
+	dec := NewStreamDecoder(strings.NewReader(streamDoc))
+	var titles []string
+	for {
+		a := new(reqArticle)
+		err := dec.DecodeResource(a)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		titles = append(titles, a.Title)
+		if a.ID == "1" && (a.Author == nil || a.Author.Name != "Ann") {
+			t.Errorf("author %+v, want the included person", a.Author)
+		}
+	}
+	if !equalStrings(titles, []string{"a", "b", "c"}) {
+		t.Errorf("titles %v", titles)
+	}
+	// EOF sticks.
+	if _, err := dec.NextResource(); err != io.EOF {
+		t.Errorf("error %v after the last resource, want io.EOF", err)
+	}
+
+	meta, err := dec.Meta()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (*meta)["total"] != float64(3) {
+		t.Errorf("meta %v, want float64 numbers without UseNumber", *meta)
+	}
+	links, err := dec.Links()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (*links)["next"] != "/articles?page=2" {
+		t.Errorf("links %v", *links)
+	}
+	included, err := dec.Included()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(included) != 1 || included[0].ID != "9" {
+		t.Errorf("included %v", included)
+	}
+}
+
+func TestStreamDecoderNextResource(t *testing.T) {
+	dec := NewStreamDecoder(strings.NewReader(streamDoc), UseNumber())
+	n, err := dec.NextResource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.ID != "1" || n.Attributes["views"] != json.Number("1") {
+		t.Errorf("node %+v, want json.Number views with UseNumber", n)
+	}
+
+	// Included skips the remaining resources.
+	meta, err := dec.Meta()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (*meta)["total"] != json.Number("3") {
+		t.Errorf("meta %v", *meta)
+	}
+	if _, err := dec.NextResource(); err != io.EOF {
+		t.Errorf("error %v after Meta, want io.EOF", err)
+	}
+}
+
+func TestStreamDecoderIncludedLast(t *testing.T) {
+	doc := `{"data": [{"type": "articles", "id": "1", "relationships": {"author": {"data": {"type": "people", "id": "9"}}}}],
+		"included": [{"type": "people", "id": "9", "attributes": {"name": "Ann"}}]}`
+	dec := NewStreamDecoder(strings.NewReader(doc))
+	a := new(reqArticle)
+	if err := dec.DecodeResource(a); err != nil {
+		t.Fatal(err)
+	}
+	if a.Author == nil || a.Author.ID != "9" || a.Author.Name != "" {
+		t.Errorf("author %+v, want only the linkage before included is read", a.Author)
+	}
+	included, err := dec.Included()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(included) != 1 || included[0].Attributes["name"] != "Ann" {
+		t.Errorf("included %v", included)
+	}
+}
+
+func TestStreamDecoderNullData(t *testing.T) {
+	dec := NewStreamDecoder(strings.NewReader(`{"data": null, "meta": {"n": 0}}`))
+	if _, err := dec.NextResource(); err != io.EOF {
+		t.Errorf("error %v, want io.EOF", err)
+	}
+	if meta, err := dec.Meta(); err != nil || (*meta)["n"] != float64(0) {
+		t.Errorf("meta %v, %v", meta, err)
+	}
+}
+
+func TestStreamDecoderErrors(t *testing.T) {
+	tests := []struct {
+		doc  string
+		opts []UnmarshalOption
+		want error
+	}{
+		{`{"data": {"type": "articles", "id": "1"}}`, nil, ErrExpectedSlice},
+		{`{"included": [{"type": "people", "id": "1"}, {"type": "people", "id": "2"}], "data": []}`,
+			[]UnmarshalOption{MaxIncludedResources(1)}, ErrTooManyIncluded},
+		{`{"data": [{"type": "articles", "id": "1", "attributes": {"title": "` + strings.Repeat("a", 100) + `"}}]}`,
+			[]UnmarshalOption{MaxBodyBytes(64)}, ErrBodyTooLarge},
+	}
+	for _, tt := range tests {
+		dec := NewStreamDecoder(strings.NewReader(tt.doc), tt.opts...)
+		if err := dec.DecodeResource(new(reqArticle)); !errors.Is(err, tt.want) {
+			t.Errorf("%.40s: error %v, want %v", tt.doc, err, tt.want)
+		}
+	}
+
+	for _, doc := range []string{`[]`, `{"data": [1]}`, `{"data": [`} {
+		dec := NewStreamDecoder(strings.NewReader(doc))
+		if _, err := dec.NextResource(); err == nil || err == io.EOF {
+			t.Errorf("%s: error %v", doc, err)
+		}
+	}
+
+	doc := `{"data": [{"type": "articles", "id": "1", "attributes": {"bogus": 1}}]}`
+	err := NewStreamDecoder(strings.NewReader(doc), DisallowUnknownFields()).DecodeResource(new(reqArticle))
+	var unknown *UnknownFieldsError
+	if !errors.As(err, &unknown) {
+		t.Errorf("error %v, want *UnknownFieldsError", err)
+	}
+}
@@ -0,0 +1,29 @@
+package jsonapi
+
+// Fields can be declared differently for some contexts, e.g. an admin API,
+// with a second struct tag:
+//
+//	Start time.Time `jsonapi:"attr,start_time" jsonapi_admin:"attr,raw_start"`
+//	Notes string    `jsonapi:"attr,notes" jsonapi_admin:"-"`
+//	Cost  int       `jsonapi_admin:"attr,cost"`
+//
+// With WithTagKey("jsonapi_admin"), Start is the raw_start attribute, Notes
+// is left out and Cost is the cost attribute; without it, Start is
+// start_time, Notes is notes and Cost is left out. Fields without the
+// second tag keep their jsonapi tag either way.
+
+// WithTagKey makes Marshal read the struct tag key of each field, where
+// present, instead of its jsonapi tag. A key tag of "-" leaves the field
+// out.
+func WithTagKey(key string) MarshalOption {
+	return func(o *marshalOptions) {
+		o.tagKey = key
+	}
+}
+
+// TagKey is the unmarshal counterpart of WithTagKey.
+func TagKey(key string) UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.tagKey = key
+	}
+}
@@ -0,0 +1,146 @@
+package jsonapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type adminAppointment struct {
+	ID    string `jsonapi:"primary,appointments"`
+	Start string `jsonapi:"attr,start_time" jsonapi_admin:"attr,raw_start"`
+	Notes string `jsonapi:"attr,notes" jsonapi_admin:"-"`
+	Cost  int    `jsonapi_admin:"attr,cost"`
+	Room  string `jsonapi:"attr,room"`
+}
+
+func TestWithTagKey(t *testing.T) {
+	in := &adminAppointment{ID: "1", Start: "9:00", Notes: "n", Cost: 5, Room: "A"}
+	tests := []struct {
+		opts []MarshalOption
+		want map[string]interface{}
+	}{
+		{nil, map[string]interface{}{"start_time": "9:00", "notes": "n", "room": "A"}},
+		{[]MarshalOption{WithTagKey("jsonapi_admin")},
+			map[string]interface{}{"raw_start": "9:00", "cost": float64(5), "room": "A"}},
+		{[]MarshalOption{WithTagKey("jsonapi_other")},
+			map[string]interface{}{"start_time": "9:00", "notes": "n", "room": "A"}},
+	}
+	for _, tt := range tests {
+		attrs := marshalDoc(t, in, tt.opts...)["data"].(map[string]interface{})["attributes"]
+		if !reflect.DeepEqual(attrs, tt.want) {
+			t.Errorf("%d options: attributes %v, want %v", len(tt.opts), attrs, tt.want)
+		}
+	}
+
+	b, err := MarshalBytes(in, WithTagKey("jsonapi_admin"), WithAttributeOrder(AttributeDeclarationOrder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"attributes":{"raw_start":"9:00","cost":5,"room":"A"}`) {
+		t.Errorf("document %s, want attributes in field order", b)
+	}
+}
+
+func TestTagKey(t *testing.T) {
+	doc := `{"data": {"type": "appointments", "id": "1", "attributes": {
+		"start_time": "8:00", "raw_start": "9:00", "notes": "n", "cost": 5, "room": "A"}}}`
+
+	tests := []struct {
+		opts []UnmarshalOption
+		want adminAppointment
+	}{
+		{nil, adminAppointment{ID: "1", Start: "8:00", Notes: "n", Room: "A"}},
+		{[]UnmarshalOption{TagKey("jsonapi_admin")}, adminAppointment{ID: "1", Start: "9:00", Cost: 5, Room: "A"}},
+	}
+	for _, tt := range tests {
+		var out adminAppointment
+		if err := UnmarshalPayload(strings.NewReader(doc), &out, tt.opts...); err != nil {
+			t.Fatal(err)
+		}
+		if out != tt.want {
+			t.Errorf("%d options: %+v, want %+v", len(tt.opts), out, tt.want)
+		}
+	}
+
+	cs, err := UnmarshalChangeset(strings.NewReader(doc), new(adminAppointment), TagKey("jsonapi_admin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs.Attributes["raw_start"].Field != "Start" || cs.Attributes["cost"].Field != "Cost" ||
+		cs.Attributes["start_time"].Field != "" || cs.Attributes["notes"].Field != "" {
+		t.Errorf("changeset fields %+v", cs.Attributes)
+	}
+}
+
+func TestFieldTagKey(t *testing.T) {
+	typ := reflect.TypeOf(adminAppointment{})
+	tests := []struct {
+		field, tagKey, want string
+	}{
+		{"Start", "", "attr,start_time"},
+		{"Start", "jsonapi_admin", "attr,raw_start"},
+		{"Notes", "jsonapi_admin", ""},
+		{"Cost", "", ""},
+		{"Cost", "jsonapi_admin", "attr,cost"},
+		{"Room", "jsonapi_admin", "attr,room"},
+	}
+	for _, tt := range tests {
+		field, _ := typ.FieldByName(tt.field)
+		if got := fieldTag(field, tt.tagKey, false); got != tt.want {
+			t.Errorf("%s, key %q: tag %q, want %q", tt.field, tt.tagKey, got, tt.want)
+		}
+	}
+}
+
+func TestTagKeyReaders(t *testing.T) {
+	admin := WithTagKey("jsonapi_admin")
+	in := &adminAppointment{ID: "1", Start: "9:00", Notes: "n", Cost: 5, Room: "A"}
+
+	if _, err := CursorFor(in, "cost"); err == nil {
+		t.Error("CursorFor found cost without the tag key")
+	}
+	c, err := CursorForOptions(in, []MarshalOption{admin}, "cost", "raw_start")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c["cost"] != 5 || c["raw_start"] != "9:00" {
+		t.Errorf("cursor %v", c)
+	}
+
+	s, err := (&Query{}).Selection(in, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"raw_start", "cost", "room"}; !reflect.DeepEqual(s.Attributes, want) {
+		t.Errorf("selected attributes %v, want %v", s.Attributes, want)
+	}
+
+	schema, err := ExportJSONSchema(in, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(schema), `"raw_start"`) || strings.Contains(string(schema), `"notes"`) {
+		t.Errorf("schema %s, want the admin attributes", schema)
+	}
+
+	ts, err := ExportTypeScriptOptions([]MarshalOption{admin}, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(ts), "raw_start") || strings.Contains(string(ts), "notes") {
+		t.Errorf("declarations\n%s\nwant the admin attributes", ts)
+	}
+
+	models := []*adminAppointment{{ID: "1", Cost: 7}, {ID: "2", Cost: 3}}
+	if err := ApplySort(models, ParseSort("cost"), admin); err != nil {
+		t.Fatal(err)
+	}
+	if models[0].ID != "2" {
+		t.Errorf("sorted %v, want by cost", models)
+	}
+
+	if err := RoundTrip(in, admin); err != nil {
+		t.Error(err)
+	}
+}
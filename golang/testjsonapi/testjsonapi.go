@@ -0,0 +1,397 @@
+// Package testjsonapi helps testing handlers that serve JSON:API documents.
+//
+// Documents are compared semantically, not as strings: the order of object
+// members and of included resources does not matter, and numbers compare by
+// value. Expected documents can be built in the test, kept in golden files
+// under testdata, or given as JSON text:
+//
+//	rec := testjsonapi.Serve(handler, "GET", "/posts/1?include=author", nil)
+//	testjsonapi.AssertPayloadEqual(t,
+//		testjsonapi.One(testjsonapi.Resource("posts", "1").
+//			Attr("title", "Hello").
+//			ToOne("author", "people", "9")).
+//			Include(testjsonapi.Resource("people", "9").Attr("name", "Ann")),
+//		rec)
+//	testjsonapi.AssertGolden(t, "post", rec)
+//
+// Golden files are rewritten from the actual documents when the tests run
+// with -update-golden.
+package testjsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	jsonapi "test3"
+)
+
+var update = flag.Bool("update-golden", false, "rewrite testjsonapi golden files")
+
+// AssertPayloadEqual reports a test error listing the differences if the
+// documents want and got are not semantically equal, and returns whether
+// they are. Each may be JSON text as a []byte, string or json.RawMessage,
+// an io.Reader, a *httptest.ResponseRecorder or *http.Response whose body
+// is read, or any other value, such as a payload or a DocumentBuilder,
+// which is encoded with encoding/json.
+func AssertPayloadEqual(t testing.TB, want, got interface{}) bool {
+	t.Helper()
+	diffs, err := Diff(want, got)
+	if err != nil {
+		t.Errorf("testjsonapi: %v", err)
+		return false
+	}
+	if len(diffs) > 0 {
+		t.Errorf("documents differ:\n\t%s", strings.Join(diffs, "\n\t"))
+		return false
+	}
+	return true
+}
+
+// Diff returns the differences between the documents want and got, given
+// as for AssertPayloadEqual, one per line as "PATH: want W, got G", with
+// PATH a JSON pointer into the documents. It is empty when they are
+// semantically equal.
+func Diff(want, got interface{}) ([]string, error) {
+	w, err := decode(want)
+	if err != nil {
+		return nil, fmt.Errorf("want: %w", err)
+	}
+	g, err := decode(got)
+	if err != nil {
+		return nil, fmt.Errorf("got: %w", err)
+	}
+	var diffs []string
+	diff("", w, g, &diffs)
+	return diffs, nil
+}
+
+// AssertGolden compares the document got, given as for AssertPayloadEqual,
+// with the golden file testdata/NAME.golden. With -update-golden, it writes
+// got, indented and with included resources sorted, to the file instead.
+func AssertGolden(t testing.TB, name string, got interface{}) bool {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		doc, err := decode(got)
+		if err != nil {
+			t.Errorf("testjsonapi: got: %v", err)
+			return false
+		}
+		b, err := json.MarshalIndent(doc, "", "  ")
+		if err == nil {
+			err = os.MkdirAll(filepath.Dir(path), 0755)
+		}
+		if err == nil {
+			err = ioutil.WriteFile(path, append(b, '\n'), 0644)
+		}
+		if err != nil {
+			t.Errorf("testjsonapi: %v", err)
+			return false
+		}
+		return true
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Errorf("testjsonapi: %v (run with -update-golden to create it)", err)
+		return false
+	}
+	return AssertPayloadEqual(t, want, got)
+}
+
+// Serve records the response of h to a request with the given method and
+// target. A non-nil body is sent as the request document, encoded as for
+// AssertPayloadEqual, with the JSON:API Content-Type; the request accepts
+// the JSON:API media type.
+func Serve(h http.Handler, method, target string, body interface{}) *httptest.ResponseRecorder {
+	var r io.Reader
+	if body != nil {
+		b, err := encode(body)
+		if err != nil {
+			panic("testjsonapi: " + err.Error())
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req := httptest.NewRequest(method, target, r)
+	req.Header.Set("Accept", jsonapi.MediaType)
+	if body != nil {
+		req.Header.Set("Content-Type", jsonapi.MediaType)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// encode returns the JSON text of the document v.
+func encode(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	case json.RawMessage:
+		return v, nil
+	case *httptest.ResponseRecorder:
+		return v.Body.Bytes(), nil
+	case *http.Response:
+		defer v.Body.Close()
+		return ioutil.ReadAll(v.Body)
+	case io.Reader:
+		return ioutil.ReadAll(v)
+	}
+	return json.Marshal(v)
+}
+
+// decode returns the document v as decoded by encoding/json, with numbers
+// as json.Number and the included resources sorted by type and id.
+func decode(v interface{}) (interface{}, error) {
+	b, err := encode(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid document: %w", err)
+	}
+
+	if m, ok := doc.(map[string]interface{}); ok {
+		if included, ok := m["included"].([]interface{}); ok {
+			sort.SliceStable(included, func(i, j int) bool {
+				return identity(included[i]) < identity(included[j])
+			})
+		}
+	}
+	return doc, nil
+}
+
+func identity(v interface{}) string {
+	m, _ := v.(map[string]interface{})
+	typ, _ := m["type"].(string)
+	id, _ := m["id"].(string)
+	return typ + "," + id
+}
+
+func diff(path string, want, got interface{}, diffs *[]string) {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(w)+len(g))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p := path + "/" + escape(k)
+			wv, wok := w[k]
+			gv, gok := g[k]
+			switch {
+			case !gok:
+				*diffs = append(*diffs, fmt.Sprintf("%s: want %s, got nothing", p, show(wv)))
+			case !wok:
+				*diffs = append(*diffs, fmt.Sprintf("%s: want nothing, got %s", p, show(gv)))
+			default:
+				diff(p, wv, gv, diffs)
+			}
+		}
+		return
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			break
+		}
+		if len(w) != len(g) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: want %d elements, got %d", pathOrRoot(path), len(w), len(g)))
+			return
+		}
+		for i := range w {
+			diff(path+"/"+strconv.Itoa(i), w[i], g[i], diffs)
+		}
+		return
+	case json.Number:
+		if g, ok := got.(json.Number); ok && numbersEqual(w, g) {
+			return
+		}
+	default:
+		if want == got {
+			return
+		}
+	}
+	*diffs = append(*diffs, fmt.Sprintf("%s: want %s, got %s", pathOrRoot(path), show(want), show(got)))
+}
+
+func numbersEqual(a, b json.Number) bool {
+	if a == b {
+		return true
+	}
+	x, errx := strconv.ParseFloat(string(a), 64)
+	y, erry := strconv.ParseFloat(string(b), 64)
+	return errx == nil && erry == nil && x == y
+}
+
+// escape escapes a member name for a JSON pointer.
+func escape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+func show(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// ResourceBuilder builds an expected resource object.
+type ResourceBuilder struct {
+	members map[string]interface{}
+}
+
+// Resource starts the resource object with the given type and id.
+func Resource(typ, id string) *ResourceBuilder {
+	return &ResourceBuilder{members: map[string]interface{}{"type": typ, "id": id}}
+}
+
+// Attr sets the attribute name.
+func (r *ResourceBuilder) Attr(name string, value interface{}) *ResourceBuilder {
+	r.object("attributes")[name] = value
+	return r
+}
+
+// ToOne sets the to-one relationship name to the resource of type typ
+// and the given id, or to null if id is "".
+func (r *ResourceBuilder) ToOne(name, typ, id string) *ResourceBuilder {
+	var data interface{}
+	if id != "" {
+		data = identifier(typ, id)
+	}
+	r.object("relationships")[name] = map[string]interface{}{"data": data}
+	return r
+}
+
+// ToMany sets the to-many relationship name to the resources of type typ
+// with the given ids.
+func (r *ResourceBuilder) ToMany(name, typ string, ids ...string) *ResourceBuilder {
+	data := make([]interface{}, len(ids))
+	for i, id := range ids {
+		data[i] = identifier(typ, id)
+	}
+	r.object("relationships")[name] = map[string]interface{}{"data": data}
+	return r
+}
+
+// Link sets the link name of the resource.
+func (r *ResourceBuilder) Link(name string, value interface{}) *ResourceBuilder {
+	r.object("links")[name] = value
+	return r
+}
+
+// Meta sets the meta member name of the resource.
+func (r *ResourceBuilder) Meta(name string, value interface{}) *ResourceBuilder {
+	r.object("meta")[name] = value
+	return r
+}
+
+// MarshalJSON encodes the resource object.
+func (r *ResourceBuilder) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.members)
+}
+
+func (r *ResourceBuilder) object(member string) map[string]interface{} {
+	m, ok := r.members[member].(map[string]interface{})
+	if !ok {
+		m = map[string]interface{}{}
+		r.members[member] = m
+	}
+	return m
+}
+
+func identifier(typ, id string) map[string]interface{} {
+	return map[string]interface{}{"type": typ, "id": id}
+}
+
+// DocumentBuilder builds an expected document.
+type DocumentBuilder struct {
+	members map[string]interface{}
+}
+
+// One starts a document whose primary data is the resource r, or null if
+// r is nil.
+func One(r *ResourceBuilder) *DocumentBuilder {
+	var data interface{}
+	if r != nil {
+		data = r
+	}
+	return &DocumentBuilder{members: map[string]interface{}{"data": data}}
+}
+
+// Many starts a document whose primary data are the resources rs.
+func Many(rs ...*ResourceBuilder) *DocumentBuilder {
+	if rs == nil {
+		rs = []*ResourceBuilder{}
+	}
+	return &DocumentBuilder{members: map[string]interface{}{"data": rs}}
+}
+
+// Include adds the resources rs to the included resources.
+func (d *DocumentBuilder) Include(rs ...*ResourceBuilder) *DocumentBuilder {
+	included, _ := d.members["included"].([]*ResourceBuilder)
+	d.members["included"] = append(included, rs...)
+	return d
+}
+
+// Link sets the top-level link name.
+func (d *DocumentBuilder) Link(name string, value interface{}) *DocumentBuilder {
+	d.object("links")[name] = value
+	return d
+}
+
+// Meta sets the top-level meta member name.
+func (d *DocumentBuilder) Meta(name string, value interface{}) *DocumentBuilder {
+	d.object("meta")[name] = value
+	return d
+}
+
+// MarshalJSON encodes the document.
+func (d *DocumentBuilder) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.members)
+}
+
+func (d *DocumentBuilder) object(member string) map[string]interface{} {
+	m, ok := d.members[member].(map[string]interface{})
+	if !ok {
+		m = map[string]interface{}{}
+		d.members[member] = m
+	}
+	return m
+}
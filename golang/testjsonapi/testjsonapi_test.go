@@ -0,0 +1,175 @@
+package testjsonapi
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	jsonapi "test3"
+)
+
+// recorder is a testing.TB recording the errors reported to it.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, format)
+}
+
+type post struct {
+	ID     string  `jsonapi:"primary,posts"`
+	Title  string  `jsonapi:"attr,title"`
+	Author *person `jsonapi:"relation,author"`
+}
+
+type person struct {
+	ID   string `jsonapi:"primary,people"`
+	Name string `jsonapi:"attr,name"`
+}
+
+func expectedPost() *DocumentBuilder {
+	return One(Resource("posts", "1").Attr("title", "Hello").ToOne("author", "people", "9")).
+		Include(Resource("people", "9").Attr("name", "Ann"))
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		want, got string
+		diffs     []string
+	}{
+		{`{"a": 1, "b": [1, 2]}`, `{"b": [1, 2.0], "a": 1e0}`, nil},
+		{`{"data": null, "included": [{"type": "b", "id": "1"}, {"type": "a", "id": "2"}]}`,
+			`{"included": [{"type": "a", "id": "2"}, {"type": "b", "id": "1"}], "data": null}`, nil},
+		{`{"a": 1}`, `{"a": 2}`, []string{"/a: want 1, got 2"}},
+		{`{"a": 1}`, `{"b": 1}`, []string{"/a: want 1, got nothing", "/b: want nothing, got 1"}},
+		{`[1, 2]`, `[1]`, []string{"/: want 2 elements, got 1"}},
+		{`{"a/b": {"c~": true}}`, `{"a/b": {"c~": "true"}}`, []string{`/a~1b/c~0: want true, got "true"`}},
+		{`{"a": {}}`, `{"a": []}`, []string{"/a: want {}, got []"}},
+	}
+	for _, tt := range tests {
+		diffs, err := Diff(tt.want, tt.got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(diffs, "\n") != strings.Join(tt.diffs, "\n") {
+			t.Errorf("Diff(%s, %s) = %q, want %q", tt.want, tt.got, diffs, tt.diffs)
+		}
+	}
+
+	if _, err := Diff(`{`, `{}`); err == nil {
+		t.Error("expected an error for an invalid document")
+	}
+}
+
+func TestAssertPayloadEqual(t *testing.T) {
+	payload, err := jsonapi.Marshal(&post{ID: "1", Title: "Hello", Author: &person{ID: "9", Name: "Ann"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !AssertPayloadEqual(t, expectedPost(), payload) {
+		t.Error("payload differs from the built document")
+	}
+
+	r := new(recorder)
+	if AssertPayloadEqual(r, One(nil), expectedPost()) || len(r.errors) != 1 {
+		t.Errorf("errors %v, want one", r.errors)
+	}
+	r = new(recorder)
+	if AssertPayloadEqual(r, "not json", One(nil)) || len(r.errors) != 1 {
+		t.Errorf("errors %v, want one", r.errors)
+	}
+}
+
+func TestBuilders(t *testing.T) {
+	doc := Many(Resource("posts", "1").
+		ToOne("author", "people", "").
+		ToMany("tags", "tags", "a", "b").
+		Link("self", "/posts/1").
+		Meta("rank", 1)).
+		Link("next", "/posts?page=2").
+		Meta("total", 3)
+	want := `{
+		"data": [{
+			"type": "posts", "id": "1",
+			"relationships": {
+				"author": {"data": null},
+				"tags": {"data": [{"type": "tags", "id": "a"}, {"type": "tags", "id": "b"}]}
+			},
+			"links": {"self": "/posts/1"},
+			"meta": {"rank": 1}
+		}],
+		"links": {"next": "/posts?page=2"},
+		"meta": {"total": 3}
+	}`
+	AssertPayloadEqual(t, want, doc)
+	AssertPayloadEqual(t, `{"data": []}`, Many())
+	AssertPayloadEqual(t, `{"data": null}`, One(nil))
+}
+
+func TestServe(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != jsonapi.MediaType || r.Method != "POST" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("Content-Type") != jsonapi.MediaType {
+			http.Error(w, "unsupported", http.StatusUnsupportedMediaType)
+			return
+		}
+		p := new(post)
+		if err := jsonapi.UnmarshalPayload(r.Body, p); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		p.Author = &person{ID: "9", Name: "Ann"}
+		w.Header().Set("Content-Type", jsonapi.MediaType)
+		w.WriteHeader(http.StatusCreated)
+		jsonapi.MarshalPayload(w, p)
+	})
+
+	rec := Serve(h, "POST", "/posts", One(Resource("posts", "1").Attr("title", "Hello")))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	AssertPayloadEqual(t, expectedPost(), rec)
+	AssertGolden(t, "post", rec.Result())
+}
+
+func TestAssertGoldenUpdate(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	r := new(recorder)
+	if AssertGolden(r, "missing", One(nil)) || len(r.errors) != 1 {
+		t.Errorf("errors %v for a missing golden file", r.errors)
+	}
+
+	*update = true
+	defer func() { *update = false }()
+	if !AssertGolden(t, "new", expectedPost()) {
+		t.Fatal("golden file not written")
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "testdata", "new.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	*update = false
+	if !strings.Contains(string(b), "\n  \"data\": {") {
+		t.Errorf("golden file is not indented:\n%s", b)
+	}
+	AssertGolden(t, "new", expectedPost())
+}
@@ -0,0 +1,66 @@
+package jsonapi
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+)
+
+// Attributes of types implementing encoding.TextMarshaler, such as
+// uuid.UUID or custom enums, are sent as their text form, and decoded
+// from a JSON string with encoding.TextUnmarshaler, as primary fields
+// are. The method may have a pointer receiver. Types that also implement
+// json.Marshaler are left to encoding/json, as it would prefer that
+// method, and so are slices and maps of text types.
+
+// isTextMarshalerType reports whether attributes of type t, or of the type
+// t points to, are marshaled through encoding.TextMarshaler.
+func isTextMarshalerType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return false
+	}
+	return t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)
+}
+
+// isTextUnmarshalerType reports whether string attributes are decoded into
+// t, not a pointer type, through encoding.TextUnmarshaler.
+func isTextUnmarshalerType(t reflect.Type) bool {
+	if reflect.PtrTo(t).Implements(jsonUnmarshalerType) {
+		return false
+	}
+	return reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// marshalText returns the text form of v, or nil if v is a nil pointer.
+// ok is false when the method cannot be called because it has a pointer
+// receiver and v is not addressable.
+func marshalText(v reflect.Value) (value interface{}, ok bool, err error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, true, nil
+		}
+		v = v.Elem()
+	}
+	m, ok := textMarshaler(v)
+	if !ok {
+		return nil, false, nil
+	}
+	text, err := m.MarshalText()
+	if err != nil {
+		return nil, true, err
+	}
+	return string(text), true, nil
+}
+
+// unmarshalText parses text into a new value of type t and returns a
+// pointer to it.
+func unmarshalText(text string, t reflect.Type) (reflect.Value, error) {
+	ptr := reflect.New(t)
+	if err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text)); err != nil {
+		return reflect.Value{}, fmt.Errorf("%w: %v", ErrInvalidType, err)
+	}
+	return ptr, nil
+}
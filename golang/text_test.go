@@ -0,0 +1,119 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// textLevel has its text methods on the pointer receiver.
+type textLevel int
+
+func (l *textLevel) MarshalText() ([]byte, error) {
+	return []byte(strings.Repeat("*", int(*l))), nil
+}
+
+func (l *textLevel) UnmarshalText(b []byte) error {
+	if strings.Trim(string(b), "*") != "" {
+		return errors.New("bad level")
+	}
+	*l = textLevel(len(b))
+	return nil
+}
+
+// textJSON implements both interfaces; its json methods win.
+type textJSON struct{ s string }
+
+func (v textJSON) MarshalText() ([]byte, error) { return []byte("text"), nil }
+
+func (v textJSON) MarshalJSON() ([]byte, error) { return []byte(`"json"`), nil }
+
+func (v *textJSON) UnmarshalJSON(b []byte) error { return json.Unmarshal(b, &v.s) }
+
+type textThing struct {
+	ID      string        `jsonapi:"primary,things"`
+	Object  reqObjectID   `jsonapi:"attr,object"`
+	Pointer *reqObjectID  `jsonapi:"attr,pointer"`
+	Level   textLevel     `jsonapi:"attr,level"`
+	JSON    textJSON      `jsonapi:"attr,json"`
+	Objects []reqObjectID `jsonapi:"attr,objects"`
+}
+
+func TestIsTextMarshalerType(t *testing.T) {
+	tests := []struct {
+		typ             reflect.Type
+		marshal, decode bool
+	}{
+		{reflect.TypeOf(reqObjectID{}), true, true},
+		{reflect.TypeOf(&reqObjectID{}), true, false},
+		{reflect.TypeOf(textLevel(0)), true, true},
+		{reflect.TypeOf(textJSON{}), false, false},
+		{reflect.TypeOf([]reqObjectID{}), false, false},
+		{reflect.TypeOf(""), false, false},
+	}
+	for _, tt := range tests {
+		if got := isTextMarshalerType(tt.typ); got != tt.marshal {
+			t.Errorf("isTextMarshalerType(%s) = %v", tt.typ, got)
+		}
+		if got := isTextUnmarshalerType(tt.typ); got != tt.decode {
+			t.Errorf("isTextUnmarshalerType(%s) = %v", tt.typ, got)
+		}
+	}
+}
+
+func TestMarshalTextAttributes(t *testing.T) {
+	in := &textThing{
+		ID:      "1",
+		Object:  reqObjectID{0xde, 0xad, 0xbe, 0xef},
+		Level:   3,
+		JSON:    textJSON{"x"},
+		Objects: []reqObjectID{{1, 2, 3, 4}},
+	}
+	attrs := marshalDoc(t, in)["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+	want := map[string]interface{}{
+		"object":  "deadbeef",
+		"pointer": nil,
+		"level":   "***",
+		"json":    "json",
+		"objects": []interface{}{"01020304"},
+	}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("attributes %v, want %v", attrs, want)
+	}
+
+	in.Pointer = &reqObjectID{0xca, 0xfe, 0xba, 0xbe}
+	attrs = marshalDoc(t, in)["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+	if attrs["pointer"] != "cafebabe" {
+		t.Errorf("pointer attribute %v", attrs["pointer"])
+	}
+}
+
+func TestUnmarshalTextAttributes(t *testing.T) {
+	doc := `{"data": {"type": "things", "id": "1", "attributes": {
+		"object": "deadbeef", "pointer": "cafebabe", "level": "**",
+		"json": "j", "objects": ["01020304"]}}}`
+	out := new(textThing)
+	if err := UnmarshalPayload(strings.NewReader(doc), out); err != nil {
+		t.Fatal(err)
+	}
+	want := &textThing{
+		ID:      "1",
+		Object:  reqObjectID{0xde, 0xad, 0xbe, 0xef},
+		Pointer: &reqObjectID{0xca, 0xfe, 0xba, 0xbe},
+		Level:   2,
+		JSON:    textJSON{"j"},
+		Objects: []reqObjectID{{1, 2, 3, 4}},
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("unmarshaled %+v, want %+v", out, want)
+	}
+
+	for _, attrs := range []string{`{"object": "zz"}`, `{"level": "-"}`, `{"pointer": "00"}`} {
+		doc := `{"data": {"type": "things", "id": "1", "attributes": ` + attrs + `}}`
+		if err := UnmarshalPayload(strings.NewReader(doc), new(textThing)); !errors.Is(err, ErrInvalidType) {
+			t.Errorf("%s: error %v, want ErrInvalidType", attrs, err)
+		}
+	}
+}
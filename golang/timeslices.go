@@ -0,0 +1,78 @@
+package jsonapi
+
+import (
+	"reflect"
+	"time"
+)
+
+// Slices of times, []time.Time and []*time.Time, are formatted element by
+// element with the same options as a single time: iso8601 or rfc3339
+// strings, unix timestamps otherwise. Slices of other types, uuid.UUID or
+// custom types implementing encoding.TextMarshaler included, are left to
+// encoding/json.
+
+// isTimeSlice reports whether t is a slice of time.Time or *time.Time.
+func isTimeSlice(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice {
+		return false
+	}
+	elem := t.Elem()
+	return elem == timeType || elem == reflect.PtrTo(timeType)
+}
+
+// formatTime renders t the way the attribute branch of visitModelNode does.
+func formatTime(t time.Time, iso8601, rfc3339 bool) interface{} {
+	if iso8601 {
+		return t.UTC().Format(iso8601TimeFormat)
+	} else if rfc3339 {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return t.Unix()
+}
+
+// marshalTimeSlice formats each element of v, a slice accepted by
+// isTimeSlice. Nil elements are written as null.
+func marshalTimeSlice(v reflect.Value, iso8601, rfc3339 bool) []interface{} {
+	out := make([]interface{}, v.Len())
+	for i := range out {
+		elem := v.Index(i)
+		if elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				continue
+			}
+			elem = elem.Elem()
+		}
+		out[i] = formatTime(elem.Interface().(time.Time), iso8601, rfc3339)
+	}
+	return out
+}
+
+// handleTimeSlice parses attribute, a JSON array, into a new slice of type t
+// with handleTime, and returns a pointer to it.
+func handleTimeSlice(attribute interface{}, args []string, t reflect.Type) (reflect.Value, error) {
+	values, ok := attribute.([]interface{})
+	if !ok {
+		return reflect.Value{}, ErrInvalidType
+	}
+
+	slice := reflect.MakeSlice(t, len(values), len(values))
+	for i, v := range values {
+		elem := slice.Index(i)
+		if v == nil {
+			if elem.Kind() != reflect.Ptr {
+				return reflect.Value{}, ErrInvalidTime
+			}
+			continue
+		}
+
+		tm, err := handleTime(v, args, elem)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		assign(elem, tm)
+	}
+
+	ptr := reflect.New(t)
+	ptr.Elem().Set(slice)
+	return ptr, nil
+}
@@ -0,0 +1,73 @@
+package jsonapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type timesEvent struct {
+	ID      string       `jsonapi:"primary,events"`
+	Unix    []time.Time  `jsonapi:"attr,unix"`
+	ISO     []time.Time  `jsonapi:"attr,iso,iso8601"`
+	RFC     []*time.Time `jsonapi:"attr,rfc,rfc3339"`
+	Skipped []time.Time  `jsonapi:"attr,skipped,omitempty"`
+}
+
+func TestTimeSlices(t *testing.T) {
+	t1 := time.Date(2020, 9, 13, 12, 26, 40, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	in := &timesEvent{
+		ID:   "1",
+		Unix: []time.Time{t1, t2},
+		ISO:  []time.Time{t1},
+		RFC:  []*time.Time{&t2, nil},
+	}
+
+	attrs := marshalDoc(t, in)["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+	want := map[string]interface{}{
+		"unix": []interface{}{float64(t1.Unix()), float64(t2.Unix())},
+		"iso":  []interface{}{"2020-09-13T12:26:40Z"},
+		"rfc":  []interface{}{"2020-09-13T13:26:40Z", nil},
+	}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("attributes %v, want %v", attrs, want)
+	}
+
+	b, err := MarshalBytes(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(timesEvent)
+	if err := UnmarshalPayload(strings.NewReader(string(b)), out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Unix) != 2 || !out.Unix[0].Equal(t1) || !out.Unix[1].Equal(t2) {
+		t.Errorf("unix %v", out.Unix)
+	}
+	if len(out.ISO) != 1 || !out.ISO[0].Equal(t1) {
+		t.Errorf("iso %v", out.ISO)
+	}
+	if len(out.RFC) != 2 || out.RFC[0] == nil || !out.RFC[0].Equal(t2) || out.RFC[1] != nil {
+		t.Errorf("rfc %v", out.RFC)
+	}
+}
+
+func TestTimeSlicesInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs string
+	}{
+		{"null element", `{"unix": [1600000000, null]}`},
+		{"not an array", `{"unix": 1600000000}`},
+		{"string element", `{"unix": ["yesterday"]}`},
+		{"bad iso8601", `{"iso": ["13/09/2020"]}`},
+	}
+	for _, tt := range tests {
+		doc := `{"data": {"type": "events", "id": "1", "attributes": ` + tt.attrs + `}}`
+		if err := UnmarshalPayload(strings.NewReader(doc), new(timesEvent)); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package jsonapi
+
+import "sort"
+
+// Transformer rewrites attribute values on their way into or out of a
+// document, so that sensitive attributes can be encrypted, tokenized or
+// masked in one place. Marshal passes the value as it will be encoded,
+// e.g. a formatted time string; unmarshaling passes the decoded JSON value
+// before it is stored in the struct field. Use separate transformers for
+// the two directions when they differ, e.g. to encrypt and decrypt.
+type Transformer interface {
+	TransformAttribute(resourceType, name string, v interface{}) (interface{}, error)
+}
+
+// TransformerFunc adapts a function to the Transformer interface.
+type TransformerFunc func(resourceType, name string, v interface{}) (interface{}, error)
+
+// TransformAttribute implements Transformer.
+func (f TransformerFunc) TransformAttribute(resourceType, name string, v interface{}) (interface{}, error) {
+	return f(resourceType, name, v)
+}
+
+// WithTransformer makes Marshal pass every attribute through t.
+func WithTransformer(t Transformer) MarshalOption {
+	return func(o *marshalOptions) {
+		o.transformer = t
+	}
+}
+
+// TransformAttributes makes unmarshaling pass every attribute through t
+// before it is assigned.
+func TransformAttributes(t Transformer) UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.transformer = t
+	}
+}
+
+// transformAttributes applies t to the attributes of node, in name order
+// so that the first error reported is deterministic.
+func transformAttributes(t Transformer, node *Node) error {
+	names := make([]string, 0, len(node.Attributes))
+	for name := range node.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		v, err := t.TransformAttribute(node.Type, name, node.Attributes[name])
+		if err != nil {
+			return err
+		}
+		node.Attributes[name] = v
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package jsonapi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type secretUser struct {
+	ID    string `jsonapi:"primary,users"`
+	Name  string `jsonapi:"attr,name"`
+	Token string `jsonapi:"attr,token"`
+}
+
+// rot13 is a reversible stand-in for encryption.
+func rot13(resourceType, name string, v interface{}) (interface{}, error) {
+	s, ok := v.(string)
+	if !ok || name != "token" {
+		return v, nil
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return 'a' + (r-'a'+13)%26
+		case r >= 'A' && r <= 'Z':
+			return 'A' + (r-'A'+13)%26
+		}
+		return r
+	}, s), nil
+}
+
+func TestTransformer(t *testing.T) {
+	in := &secretUser{ID: "1", Name: "Ann", Token: "secret"}
+	b, err := MarshalBytes(in, WithTransformer(TransformerFunc(rot13)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"token":"frperg"`) || !strings.Contains(string(b), `"name":"Ann"`) {
+		t.Errorf("document %s", b)
+	}
+
+	out := new(secretUser)
+	if err := UnmarshalPayload(strings.NewReader(string(b)), out, TransformAttributes(TransformerFunc(rot13))); err != nil {
+		t.Fatal(err)
+	}
+	if *out != *in {
+		t.Errorf("round trip %+v, want %+v", out, in)
+	}
+}
+
+func TestTransformerErrors(t *testing.T) {
+	boom := errors.New("boom")
+	var seen []string
+	fail := TransformerFunc(func(resourceType, name string, v interface{}) (interface{}, error) {
+		seen = append(seen, resourceType+"."+name)
+		return nil, boom
+	})
+
+	if _, err := Marshal(&secretUser{ID: "1"}, WithTransformer(fail)); err != boom {
+		t.Errorf("marshal error %v, want boom", err)
+	}
+	// Attributes are transformed in name order.
+	if len(seen) != 1 || seen[0] != "users.name" {
+		t.Errorf("transformed %v first", seen)
+	}
+
+	doc := `{"data": {"type": "users", "id": "1", "attributes": {"name": "Ann"}}}`
+	if err := UnmarshalPayload(strings.NewReader(doc), new(secretUser), TransformAttributes(fail)); !errors.Is(err, boom) {
+		t.Errorf("unmarshal error %v, want boom", err)
+	}
+}
@@ -0,0 +1,334 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// tsPreamble declares the types shared by every export of ExportTypeScript.
+const tsPreamble = `// Code generated by jsonapi.ExportTypeScript. DO NOT EDIT.
+
+export type Meta = Record<string, unknown>;
+
+export type Links = Record<string, string | { href: string; meta?: Meta }>;
+
+export interface ResourceIdentifier<T extends string = string> {
+  type: T;
+  id: string;
+  meta?: Meta;
+}
+
+export interface ToOne<T extends string = string> {
+  data: ResourceIdentifier<T> | null;
+  links?: Links;
+  meta?: Meta;
+}
+
+export interface ToMany<T extends string = string> {
+  data: ResourceIdentifier<T>[];
+  links?: Links;
+  meta?: Meta;
+}
+`
+
+// ExportTypeScript returns TypeScript declarations of the resource objects
+// marshaled for models, pointers to tagged structs, and for every model
+// reachable through their relations: one interface per struct, named after
+// it, a Resource union of them all and a Document interface for whole
+// documents. Attribute types follow the Go field types as the marshaler
+// renders them, so frontends can check their use of the API against the Go
+// models.
+func ExportTypeScript(models ...interface{}) ([]byte, error) {
+	return ExportTypeScriptOptions(nil, models...)
+}
+
+// ExportTypeScriptOptions is ExportTypeScript for models marshaled with
+// opts, e.g. WithTagKey, whose members it declares.
+func ExportTypeScriptOptions(opts []MarshalOption, models ...interface{}) ([]byte, error) {
+	e := &tsExporter{
+		tags:   &newMarshalOptions(opts).tagOptions,
+		models: map[string]reflect.Type{},
+	}
+	for _, model := range models {
+		t := reflect.TypeOf(model)
+		if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+			return nil, ErrUnexpectedType
+		}
+		if modelTypeName(t, e.tags) == "" {
+			return nil, fmt.Errorf("jsonapi: %s has no primary annotation", t.Elem())
+		}
+		e.addModel(t.Elem())
+	}
+
+	names := make([]string, 0, len(e.models))
+	for name := range e.models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.WriteString(tsPreamble)
+	for _, name := range names {
+		buf.WriteString("\n")
+		e.writeModel(&buf, e.models[name])
+	}
+
+	fmt.Fprintf(&buf, "\nexport type Resource = %s;\n", strings.Join(names, " | "))
+	buf.WriteString(`
+export interface Document<D extends Resource | Resource[] | null = Resource | Resource[] | null> {
+  data: D;
+  included?: Resource[];
+  links?: Links;
+  meta?: Meta;
+}
+`)
+	return buf.Bytes(), nil
+}
+
+type tsExporter struct {
+	tags   *tagOptions
+	models map[string]reflect.Type
+}
+
+// addModel records the struct type t and the models related to it.
+func (e *tsExporter) addModel(t reflect.Type) {
+	if _, ok := e.models[t.Name()]; ok {
+		return
+	}
+	e.models[t.Name()] = t
+
+	for i := 0; i < t.NumField(); i++ {
+		args := strings.Split(e.tags.tag(t.Field(i)), annotationSeperator)
+		if args[0] != annotationRelation {
+			continue
+		}
+		if elem, ok := relationElemType(t.Field(i).Type); ok && elem != nil {
+			e.addModel(elem)
+		}
+	}
+}
+
+func (e *tsExporter) writeModel(buf *bytes.Buffer, t reflect.Type) {
+	var attributes, relationships []string
+fields:
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		args := strings.Split(e.tags.tag(field), annotationSeperator)
+		if len(args) < 2 {
+			continue
+		}
+
+		switch args[0] {
+		case annotationAttribute:
+			var omitEmpty, timeString, readOnly, number bool
+			for _, arg := range args[2:] {
+				switch arg {
+				case annotationOmitEmpty:
+					omitEmpty = true
+				case annotationISO8601, annotationRFC3339:
+					timeString = true
+				case annotationReadOnly:
+					readOnly = true
+				case annotationNumber:
+					number = true
+				case annotationWriteOnly:
+					// Never written, so not part of the resource object.
+					continue fields
+				}
+			}
+
+			// As in the JSON Schema export, zero times are left out
+			// even without omitempty.
+			optional := omitEmpty || field.Type.Kind() == reflect.Ptr || field.Type == timeType
+			typ := tsType(field.Type, timeString, map[reflect.Type]bool{})
+			if number && isDecimalType(field.Type) {
+				typ = "number"
+				if field.Type.Kind() == reflect.Ptr {
+					typ += " | null"
+				}
+			}
+			member := tsMember(args[1], optional, typ)
+			if readOnly {
+				member = "readonly " + member
+			}
+			attributes = append(attributes, member)
+		case annotationRelation:
+			target := "string"
+			if typ, ok := idsRelationType(args, field.Type); ok {
+				target = tsString(typ)
+			} else if elem, ok := relationElemType(field.Type); ok && elem != nil {
+				target = tsString(modelTypeName(elem, e.tags))
+			}
+
+			kind := "ToOne"
+			if field.Type.Kind() == reflect.Slice || field.Type.Kind() == reflect.Map {
+				kind = "ToMany"
+			}
+			relationships = append(relationships, tsMember(args[1], true,
+				fmt.Sprintf("%s<%s>", kind, target)))
+		}
+	}
+
+	fmt.Fprintf(buf, "export interface %s {\n", t.Name())
+	fmt.Fprintf(buf, "  type: %s;\n", tsString(modelTypeName(t, e.tags)))
+	buf.WriteString("  id: string;\n")
+	if len(attributes) > 0 {
+		buf.WriteString("  attributes: {\n")
+		for _, m := range attributes {
+			fmt.Fprintf(buf, "    %s\n", m)
+		}
+		buf.WriteString("  };\n")
+	}
+	if len(relationships) > 0 {
+		buf.WriteString("  relationships?: {\n")
+		for _, m := range relationships {
+			fmt.Fprintf(buf, "    %s\n", m)
+		}
+		buf.WriteString("  };\n")
+	}
+	buf.WriteString("  links?: Links;\n")
+	buf.WriteString("  meta?: Meta;\n")
+	buf.WriteString("}\n")
+}
+
+// tsType returns the TypeScript type of the JSON encoding/json produces for
+// t, following valueSchema.
+func tsType(t reflect.Type, timeString bool, visiting map[reflect.Type]bool) string {
+	if t.Kind() == reflect.Ptr {
+		return tsType(t.Elem(), timeString, visiting) + " | null"
+	}
+
+	if t == timeType {
+		if timeString {
+			return "string"
+		}
+		return "number"
+	}
+
+	if _, ok := stringTypes[t]; ok || isDecimalType(t) {
+		return "string"
+	}
+	if names := enumTypeNames(t); names != nil {
+		literals := make([]string, len(names))
+		for i, name := range names {
+			literals[i] = strconv.Quote(name)
+		}
+		return strings.Join(literals, " | ")
+	}
+
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return "unknown"
+	}
+	if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		if isBytesType(t) || t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return "string"
+		}
+		elem := tsType(t.Elem(), timeString && isTimeSlice(t), visiting)
+		if strings.Contains(elem, " | ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		return fmt.Sprintf("Record<string, %s>", tsType(t.Elem(), false, visiting))
+	case reflect.Struct:
+		if visiting[t] {
+			// Recursive struct; stop describing it.
+			return "Record<string, unknown>"
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		var members []string
+		tsStructMembers(t, &members, visiting)
+		return "{ " + strings.Join(members, " ") + " }"
+	}
+
+	return "unknown"
+}
+
+// tsStructMembers appends the encoding/json properties of t to members,
+// flattening embedded structs as structProperties does.
+func tsStructMembers(t reflect.Type, members *[]string, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		name := field.Name
+		var omitEmpty bool
+		if tag := field.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			opts := strings.Split(tag, ",")
+			if opts[0] != "" {
+				name = opts[0]
+			}
+			for _, opt := range opts[1:] {
+				omitEmpty = omitEmpty || opt == annotationOmitEmpty
+			}
+		}
+
+		ft := field.Type
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				tsStructMembers(ft, members, visiting)
+				continue
+			}
+		}
+
+		*members = append(*members, tsMember(name, omitEmpty, tsType(field.Type, false, visiting)))
+	}
+}
+
+// tsMember renders a property signature, quoting name unless it is a
+// valid identifier.
+func tsMember(name string, optional bool, typ string) string {
+	key := name
+	if !isTSIdentifier(name) {
+		key = tsString(name)
+	}
+	if optional {
+		key += "?"
+	}
+	return key + ": " + typ + ";"
+}
+
+func isTSIdentifier(s string) bool {
+	for i, r := range s {
+		if r == '_' || r == '$' || unicode.IsLetter(r) || i > 0 && unicode.IsDigit(r) {
+			continue
+		}
+		return false
+	}
+	return s != ""
+}
+
+// tsString returns s as a string literal type.
+func tsString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
@@ -0,0 +1,101 @@
+package jsonapi
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type tsAddress struct {
+	Street string `json:"street"`
+	Zip    string `json:"zip,omitempty"`
+}
+
+type tsPerson struct {
+	ID       string      `jsonapi:"primary,ts-people"`
+	Name     string      `jsonapi:"attr,name"`
+	Home     tsAddress   `jsonapi:"attr,home"`
+	Password string      `jsonapi:"attr,password,writeonly"`
+	Friends  []*tsPerson `jsonapi:"relation,friends"`
+}
+
+type tsPost struct {
+	ID      string          `jsonapi:"primary,ts-posts"`
+	Title   string          `jsonapi:"attr,title"`
+	Body    *string         `jsonapi:"attr,body"`
+	Views   int             `jsonapi:"attr,views,readonly"`
+	Created time.Time       `jsonapi:"attr,created,iso8601"`
+	Updated time.Time       `jsonapi:"attr,updated"`
+	Tags    []string        `jsonapi:"attr,tags,omitempty"`
+	Scores  []*int          `jsonapi:"attr,scores"`
+	Extra   map[string]bool `jsonapi:"attr,extra-data"`
+	Author  *tsPerson       `jsonapi:"relation,author"`
+	Editors []*tsPerson     `jsonapi:"relation,editors"`
+	TagIDs  []string        `jsonapi:"relation,tag-ids,ids=tags"`
+	Subject interface{}     `jsonapi:"relation,subject"`
+}
+
+func TestExportTypeScript(t *testing.T) {
+	b, err := ExportTypeScript(new(tsPost))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(b)
+	if !strings.HasPrefix(src, tsPreamble) {
+		t.Errorf("source does not start with the preamble:\n%s", src)
+	}
+
+	for _, want := range []string{
+		"export interface tsPost {\n  type: \"ts-posts\";\n  id: string;\n  attributes: {\n",
+		"    title: string;\n",
+		"    body?: string | null;\n",
+		"    readonly views: number;\n",
+		"    created?: string;\n",
+		"    updated?: number;\n",
+		"    tags?: string[];\n",
+		"    scores: (number | null)[];\n",
+		"    \"extra-data\": Record<string, boolean>;\n",
+		"  relationships?: {\n    author?: ToOne<\"ts-people\">;\n    editors?: ToMany<\"ts-people\">;\n",
+		"    \"tag-ids\"?: ToMany<\"tags\">;\n",
+		"    subject?: ToOne<string>;\n",
+		"export interface tsPerson {\n  type: \"ts-people\";\n",
+		"    home: { street: string; zip?: string; };\n",
+		"    friends?: ToMany<\"ts-people\">;\n",
+		"export type Resource = tsPerson | tsPost;\n",
+		"export interface Document<",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source lacks %q:\n%s", want, src)
+		}
+	}
+	if strings.Contains(src, "password") {
+		t.Errorf("write-only attribute exported:\n%s", src)
+	}
+}
+
+func TestExportTypeScriptErrors(t *testing.T) {
+	for _, v := range []interface{}{nil, tsPost{}, new(struct{}), new(int)} {
+		if _, err := ExportTypeScript(v); err == nil {
+			t.Errorf("ExportTypeScript(%T) succeeded", v)
+		}
+	}
+}
+
+func TestTSMember(t *testing.T) {
+	tests := []struct {
+		name     string
+		optional bool
+		want     string
+	}{
+		{"title", false, "title: string;"},
+		{"_x$1", true, "_x$1?: string;"},
+		{"first-name", false, `"first-name": string;`},
+		{"1st", false, `"1st": string;`},
+		{"", false, `"": string;`},
+	}
+	for _, tt := range tests {
+		if got := tsMember(tt.name, tt.optional, "string"); got != tt.want {
+			t.Errorf("tsMember(%q, %v) = %q, want %q", tt.name, tt.optional, got, tt.want)
+		}
+	}
+}
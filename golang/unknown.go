@@ -0,0 +1,82 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// UnknownFieldsError is returned when DisallowUnknownFields is set and a
+// resource of Type has members that the target struct does not declare.
+type UnknownFieldsError struct {
+	Type          string
+	Attributes    []string
+	Relationships []string
+}
+
+// Members returns the names of all unknown attributes and relationships.
+func (e *UnknownFieldsError) Members() []string {
+	members := make([]string, 0, len(e.Attributes)+len(e.Relationships))
+	members = append(members, e.Attributes...)
+	return append(members, e.Relationships...)
+}
+
+func (e *UnknownFieldsError) Error() string {
+	return fmt.Sprintf("jsonapi: unknown members in resource of type %q: %s",
+		e.Type, strings.Join(e.Members(), ", "))
+}
+
+// checkUnknownMembers compares the members of data with the attr and
+// relation tags of modelType as unmarshalNode reads them with o. With an
+// extras field, no attribute is unknown.
+func checkUnknownMembers(data *Node, modelType reflect.Type, o *unmarshalOptions) error {
+	attrs, rels, extras := declaredMembers(modelType, o)
+
+	e := &UnknownFieldsError{Type: data.Type}
+	for name := range data.Attributes {
+		if !attrs[name] && !extras {
+			e.Attributes = append(e.Attributes, name)
+		}
+	}
+	for name := range data.Relationships {
+		if !rels[name] {
+			e.Relationships = append(e.Relationships, name)
+		}
+	}
+
+	if len(e.Attributes) == 0 && len(e.Relationships) == 0 {
+		return nil
+	}
+
+	sort.Strings(e.Attributes)
+	sort.Strings(e.Relationships)
+	return e
+}
+
+// declaredMembers returns the attributes and relationships declared by the
+// tags of modelType as unmarshalNode reads them with o, and whether it has
+// an extras field.
+func declaredMembers(modelType reflect.Type, o *unmarshalOptions) (attrs, rels map[string]bool, extras bool) {
+	attrs = map[string]bool{}
+	rels = map[string]bool{}
+
+	for i := 0; i < modelType.NumField(); i++ {
+		tag := renameReserved(fieldTag(modelType.Field(i), o.tagKey, o.jsonFallback), o.reservedPrefix)
+		args := strings.Split(tag, annotationSeperator)
+		if args[0] == annotationExtras {
+			extras = true
+			continue
+		}
+		if len(args) < 2 || !inVersion(args, o.version) {
+			continue
+		}
+		switch args[0] {
+		case annotationAttribute:
+			attrs[args[1]] = true
+		case annotationRelation:
+			rels[args[1]] = true
+		}
+	}
+	return attrs, rels, extras
+}
@@ -0,0 +1,66 @@
+package jsonapi
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDisallowUnknownFields(t *testing.T) {
+	doc := `{"data": {"type": "people", "id": "1",
+		"attributes": {"name": "Ann", "zeta": 1, "age": 30},
+		"relationships": {"friends": {"data": []}}}}`
+
+	if err := UnmarshalPayload(strings.NewReader(doc), new(reqAuthor)); err != nil {
+		t.Fatalf("unknown members should be ignored by default: %v", err)
+	}
+
+	err := UnmarshalPayload(strings.NewReader(doc), new(reqAuthor), DisallowUnknownFields())
+	var unknown *UnknownFieldsError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("error = %v, want *UnknownFieldsError", err)
+	}
+	if unknown.Type != "people" {
+		t.Errorf("Type = %q, want people", unknown.Type)
+	}
+	if want := []string{"age", "zeta", "friends"}; !reflect.DeepEqual(unknown.Members(), want) {
+		t.Errorf("Members() = %v, want %v", unknown.Members(), want)
+	}
+}
+
+func TestDisallowUnknownFieldsAcceptsDeclaredMembers(t *testing.T) {
+	err := UnmarshalPayload(strings.NewReader(reqArticleDoc), new(reqArticle), DisallowUnknownFields())
+	if err != nil {
+		t.Errorf("declared members rejected: %v", err)
+	}
+}
+
+func TestDisallowUnknownFieldsInIncluded(t *testing.T) {
+	doc := `{"data": {"type": "articles", "id": "1",
+		"relationships": {"author": {"data": {"type": "people", "id": "9"}}}},
+		"included": [{"type": "people", "id": "9", "attributes": {"nickname": "A"}}]}`
+
+	var unknown *UnknownFieldsError
+	err := UnmarshalPayload(strings.NewReader(doc), new(reqArticle), DisallowUnknownFields())
+	if !errors.As(err, &unknown) || unknown.Type != "people" {
+		t.Errorf("error = %v, want an unknown member of the included person", err)
+	}
+}
+
+func TestDisallowUnknownFieldsWithExtras(t *testing.T) {
+	doc := `{"data": {"type": "people", "id": "1",
+		"attributes": {"name": "Ann", "zeta": 1},
+		"relationships": {"friends": {"data": []}}}}`
+
+	// Undeclared attributes go to the extras field; relationships are
+	// still checked.
+	err := UnmarshalPayload(strings.NewReader(doc), new(extrasPerson), DisallowUnknownFields())
+	var unknown *UnknownFieldsError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("error = %v, want *UnknownFieldsError", err)
+	}
+	if want := []string{"friends"}; !reflect.DeepEqual(unknown.Members(), want) {
+		t.Errorf("Members() = %v, want %v", unknown.Members(), want)
+	}
+}
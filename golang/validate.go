@@ -0,0 +1,156 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// With ValidateAttributes, the attributes of the primary data are checked
+// against the rules of their validate tag after unmarshaling:
+//
+//	Name   string `jsonapi:"attr,name" validate:"required,max=64"`
+//	Age    int    `jsonapi:"attr,age" validate:"min=0,max=150"`
+//	Status string `jsonapi:"attr,status" validate:"oneof=draft published"`
+//
+// required fails for zero values; min and max bound numbers, and the length
+// of strings (in characters), slices and maps; oneof lists the allowed
+// values separated by spaces. The syntax is that of go-playground/validator,
+// and rules other than these four are left to it.
+
+// ValidateAttributes makes UnmarshalPayload and UnmarshalManyPayload
+// enforce the validate tags of attribute fields, failing with an
+// *AttributeValidationError that lists every failing attribute.
+func ValidateAttributes() UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.validate = true
+	}
+}
+
+// AttributeValidationError holds one 422 error object per attribute that
+// failed its validate tag, with a source pointer to the attribute, e.g.
+// /data/attributes/name. Code holds the failed rule.
+type AttributeValidationError struct {
+	Errors []*ErrorObject
+}
+
+func (e *AttributeValidationError) Error() string {
+	details := make([]string, len(e.Errors))
+	for i, obj := range e.Errors {
+		details[i] = obj.Detail
+	}
+	return "jsonapi: invalid attributes: " + strings.Join(details, "; ")
+}
+
+// validateAttributes checks the attribute fields of model, a pointer to a
+// struct, against their validate tags, adding an error object for each
+// failure with a pointer below prefix, the pointer to the resource object.
+func validateAttributes(model reflect.Value, prefix string, o *unmarshalOptions, objs *[]*ErrorObject) {
+	v := model.Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		rules := t.Field(i).Tag.Get("validate")
+		if rules == "" {
+			continue
+		}
+		args := strings.Split(renameReserved(fieldTag(t.Field(i), o.tagKey, o.jsonFallback), o.reservedPrefix),
+			annotationSeperator)
+		if args[0] != annotationAttribute || len(args) < 2 || !inVersion(args, o.version) {
+			continue
+		}
+
+		for _, rule := range strings.Split(rules, ",") {
+			name, param := rule, ""
+			if eq := strings.IndexByte(rule, '='); eq >= 0 {
+				name, param = rule[:eq], rule[eq+1:]
+			}
+
+			detail := checkRule(v.Field(i), name, param)
+			if detail == "" {
+				continue
+			}
+			*objs = append(*objs, &ErrorObject{
+				Title:  "Invalid " + args[1],
+				Detail: args[1] + " " + detail,
+				Status: strconv.Itoa(http.StatusUnprocessableEntity),
+				Code:   name,
+				Source: &ErrorSource{Pointer: prefix + jsonPointer("attributes", args[1])},
+			})
+			// Report one failure per attribute.
+			break
+		}
+	}
+}
+
+// checkRule returns why v fails the rule name=param, or "" if it passes or
+// the rule is not one of ours.
+func checkRule(v reflect.Value, name, param string) string {
+	if name == "required" {
+		if v.IsZero() {
+			return "is required"
+		}
+		return ""
+	}
+
+	// Other rules do not apply to missing values.
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	switch name {
+	case "min", "max":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return ""
+		}
+		n, isLength, ok := ruleMagnitude(v)
+		if !ok {
+			return ""
+		}
+		what := "must be"
+		if isLength {
+			what = "must have a length"
+		}
+		if name == "min" && n < limit {
+			return fmt.Sprintf("%s at least %s", what, param)
+		}
+		if name == "max" && n > limit {
+			return fmt.Sprintf("%s at most %s", what, param)
+		}
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		for _, allowed := range strings.Fields(param) {
+			if s == allowed {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of %s", strings.Join(strings.Fields(param), ", "))
+	}
+	return ""
+}
+
+// ruleMagnitude returns the number min and max compare: the value of a
+// number, or the length of a string, slice or map, which isLength reports.
+// ok is false for other kinds.
+func ruleMagnitude(v reflect.Value) (n float64, isLength, ok bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false, true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true, true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true, true
+	}
+	return 0, false, false
+}
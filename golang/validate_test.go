@@ -0,0 +1,97 @@
+package jsonapi
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type validatedUser struct {
+	ID     string            `jsonapi:"primary,users"`
+	Name   string            `jsonapi:"attr,name" validate:"required,max=4"`
+	Age    *int              `jsonapi:"attr,age" validate:"min=0,max=150"`
+	Status string            `jsonapi:"attr,status" validate:"oneof=draft published"`
+	Tags   []string          `jsonapi:"attr,tags" validate:"min=1"`
+	Labels map[string]string `jsonapi:"attr,labels" validate:"email"`
+	Nick   string            `validate:"required"`
+}
+
+func TestCheckRule(t *testing.T) {
+	tests := []struct {
+		v           interface{}
+		name, param string
+		want        string
+	}{
+		{"", "required", "", "is required"},
+		{"x", "required", "", ""},
+		{(*int)(nil), "min", "1", ""},
+		{-1, "min", "0", "must be at least 0"},
+		{uint(200), "max", "150", "must be at most 150"},
+		{1.5, "max", "1.5", ""},
+		{"héllo", "max", "4", "must have a length at most 4"},
+		{"héll", "max", "4", ""},
+		{[]int{}, "min", "1", "must have a length at least 1"},
+		{true, "min", "1", ""},
+		{3, "min", "x", ""},
+		{"c", "oneof", "a  b", "must be one of a, b"},
+		{7, "oneof", "6 7", ""},
+		{"x", "email", "", ""},
+	}
+	for _, tt := range tests {
+		if got := checkRule(reflect.ValueOf(tt.v), tt.name, tt.param); got != tt.want {
+			t.Errorf("checkRule(%#v, %s=%s) = %q, want %q", tt.v, tt.name, tt.param, got, tt.want)
+		}
+	}
+}
+
+func TestValidateAttributes(t *testing.T) {
+	doc := `{"data": {"type": "users", "id": "1", "attributes": {"name": "Annabel", "age": -1, "status": "gone", "tags": []}}}`
+	if err := UnmarshalPayload(strings.NewReader(doc), new(validatedUser)); err != nil {
+		t.Fatalf("validate tags are ignored by default: %v", err)
+	}
+
+	err := UnmarshalPayload(strings.NewReader(doc), new(validatedUser), ValidateAttributes())
+	var verr *AttributeValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("error %v, want *AttributeValidationError", err)
+	}
+	want := []struct{ pointer, code string }{
+		{"/data/attributes/name", "max"},
+		{"/data/attributes/age", "min"},
+		{"/data/attributes/status", "oneof"},
+		{"/data/attributes/tags", "min"},
+	}
+	if len(verr.Errors) != len(want) {
+		t.Fatalf("errors %v", err)
+	}
+	for i, w := range want {
+		obj := verr.Errors[i]
+		if obj.Source.Pointer != w.pointer || obj.Code != w.code || obj.Status != "422" {
+			t.Errorf("error %d: %+v, want %s failing %s", i, obj, w.pointer, w.code)
+		}
+	}
+	if !strings.Contains(err.Error(), "name must have a length at most 4; age must be at least 0") {
+		t.Errorf("message %q", err.Error())
+	}
+
+	doc = `{"data": {"type": "users", "id": "1", "attributes": {"name": "Ann", "status": "draft", "tags": ["a"]}}}`
+	if err := UnmarshalPayload(strings.NewReader(doc), new(validatedUser), ValidateAttributes()); err != nil {
+		t.Errorf("valid attributes: %v", err)
+	}
+}
+
+func TestValidateAttributesMany(t *testing.T) {
+	doc := `{"data": [
+		{"type": "users", "id": "1", "attributes": {"name": "Ann", "status": "draft", "tags": ["a"]}},
+		{"type": "users", "id": "2", "attributes": {"status": "draft", "tags": ["a"]}}
+	]}`
+	_, err := UnmarshalManyPayload(strings.NewReader(doc), reflect.TypeOf(new(validatedUser)), ValidateAttributes())
+	var verr *AttributeValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("error %v, want *AttributeValidationError", err)
+	}
+	if len(verr.Errors) != 1 || verr.Errors[0].Source.Pointer != "/data/1/attributes/name" || verr.Errors[0].Code != "required" {
+		t.Errorf("errors %+v", verr.Errors)
+	}
+}
@@ -0,0 +1,178 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ValidationFieldError is the part of go-playground/validator's FieldError
+// used by ValidationErrorObjects. validator.ValidationErrors is a slice of
+// values implementing it, so no import of the validator is needed.
+type ValidationFieldError interface {
+	Tag() string
+	Param() string
+	StructNamespace() string
+}
+
+// ValidationErrorObjects converts the validator.ValidationErrors returned
+// for model into 422 error objects whose source pointer names the offending
+// member, e.g. /data/attributes/email. Code holds the failed validation tag.
+// It returns nil if err is not a slice of ValidationFieldError. opts, e.g.
+// TagKey, name the members as for the UnmarshalPayload that filled model.
+func ValidationErrorObjects(model interface{}, err error, opts ...UnmarshalOption) []*ErrorObject {
+	v := reflect.ValueOf(err)
+	if !v.IsValid() || v.Kind() != reflect.Slice {
+		return nil
+	}
+
+	modelType := reflect.TypeOf(model)
+	for modelType != nil && modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+
+	tags := &newUnmarshalOptions(opts).tagOptions
+	var objs []*ErrorObject
+	for i := 0; i < v.Len(); i++ {
+		fe, ok := v.Index(i).Interface().(ValidationFieldError)
+		if !ok {
+			return nil
+		}
+
+		// The namespace starts with the name of the validated struct.
+		path := strings.Split(fe.StructNamespace(), ".")[1:]
+		pointer, member := validationPointer(modelType, path, tags)
+
+		detail := fmt.Sprintf("%s failed the %q validation", member, fe.Tag())
+		if fe.Param() != "" {
+			detail = fmt.Sprintf("%s failed the %q validation (%s)", member, fe.Tag(), fe.Param())
+		}
+
+		obj := &ErrorObject{
+			Title:  "Invalid " + member,
+			Detail: detail,
+			Status: strconv.Itoa(http.StatusUnprocessableEntity),
+			Code:   fe.Tag(),
+		}
+		if pointer != "" {
+			obj.Source = &ErrorSource{Pointer: pointer}
+		}
+		objs = append(objs, obj)
+	}
+
+	return objs
+}
+
+// validationPointer maps a path of Go field names, starting at a field of
+// the model struct t whose tags are read as set by tags, to a JSON pointer
+// into a request document and the member name. Unknown fields yield an
+// empty pointer.
+func validationPointer(t reflect.Type, path []string, tags *tagOptions) (string, string) {
+	if t == nil || t.Kind() != reflect.Struct || len(path) == 0 {
+		return "", ""
+	}
+
+	name, index := splitIndex(path[0])
+	field, ok := t.FieldByName(name)
+	if !ok {
+		return "", name
+	}
+
+	args := strings.Split(tags.tag(field), annotationSeperator)
+	switch {
+	case args[0] == annotationPrimary:
+		return jsonPointer("data", "id"), "id"
+	case args[0] == annotationRelation && len(args) > 1:
+		return jsonPointer("data", "relationships", args[1]), args[1]
+	case args[0] == annotationAttribute && len(args) > 1:
+		segments := []string{"data", "attributes", args[1]}
+		if index != "" {
+			segments = append(segments, index)
+		}
+		segments = append(segments, jsonFieldPath(field.Type, path[1:])...)
+		return jsonPointer(segments...), args[1]
+	}
+	return "", name
+}
+
+// jsonFieldPath maps Go field names inside an attribute value to the keys
+// encoding/json uses for them.
+func jsonFieldPath(t reflect.Type, path []string) []string {
+	var keys []string
+	for _, p := range path {
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice ||
+			t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		name, index := splitIndex(p)
+		key := name
+
+		if t.Kind() == reflect.Struct {
+			if field, ok := t.FieldByName(name); ok {
+				if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+					key = tag
+				}
+				t = field.Type
+			}
+		}
+
+		keys = append(keys, key)
+		if index != "" {
+			keys = append(keys, index)
+		}
+	}
+	return keys
+}
+
+// splitIndex splits a namespace element such as "Tags[2]" into its name
+// and index.
+func splitIndex(s string) (string, string) {
+	open := strings.IndexByte(s, '[')
+	if open < 0 || !strings.HasSuffix(s, "]") {
+		return s, ""
+	}
+	return s[:open], s[open+1 : len(s)-1]
+}
+
+// jsonPointer builds an RFC 6901 JSON pointer from unescaped segments.
+func jsonPointer(segments ...string) string {
+	r := strings.NewReplacer("~", "~0", "/", "~1")
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteByte('/')
+		b.WriteString(r.Replace(s))
+	}
+	return b.String()
+}
+
+// JSONErrorObject converts the errors encoding/json returns for malformed
+// request documents into a 400 error object. A *json.UnmarshalTypeError
+// gets a source pointer to the offending value, e.g. /data/attributes.
+// Truncated documents are reported too. It returns nil for other errors.
+func JSONErrorObject(err error) *ErrorObject {
+	obj := &ErrorObject{
+		Title:  "Invalid request document",
+		Status: strconv.Itoa(http.StatusBadRequest),
+	}
+
+	switch e := err.(type) {
+	case *json.UnmarshalTypeError:
+		obj.Detail = fmt.Sprintf("expected %s, got %s", e.Type, e.Value)
+		if e.Field != "" {
+			obj.Source = &ErrorSource{Pointer: jsonPointer(strings.Split(e.Field, ".")...)}
+		}
+	case *json.SyntaxError:
+		obj.Detail = fmt.Sprintf("%s at offset %d", e.Error(), e.Offset)
+	default:
+		if err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil
+		}
+		obj.Detail = "the request document is empty or truncated"
+	}
+
+	return obj
+}
@@ -0,0 +1,118 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+)
+
+// fieldError is a stand-in for validator.FieldError.
+type fieldError struct {
+	tag, param, namespace string
+}
+
+func (e fieldError) Tag() string             { return e.tag }
+func (e fieldError) Param() string           { return e.param }
+func (e fieldError) StructNamespace() string { return e.namespace }
+func (e fieldError) Error() string           { return e.namespace + " " + e.tag }
+
+// fieldErrors is a stand-in for validator.ValidationErrors.
+type fieldErrors []fieldError
+
+func (e fieldErrors) Error() string { return "validation failed" }
+
+type validAddress struct {
+	Street string `json:"street_name"`
+}
+
+type validUser struct {
+	ID        string         `jsonapi:"primary,users"`
+	Email     string         `jsonapi:"attr,email"`
+	Tags      []string       `jsonapi:"attr,tags"`
+	Addresses []validAddress `jsonapi:"attr,addresses"`
+	Manager   *orderPerson   `jsonapi:"relation,manager"`
+	Internal  string
+}
+
+func TestValidationErrorObjects(t *testing.T) {
+	err := fieldErrors{
+		{"email", "", "validUser.Email"},
+		{"max", "5", "validUser.Tags[1]"},
+		{"required", "", "validUser.Addresses[0].Street"},
+		{"required", "", "validUser.Manager"},
+		{"required", "", "validUser.ID"},
+		{"len", "3", "validUser.Internal"},
+	}
+	objs := ValidationErrorObjects(&validUser{}, err)
+
+	want := []struct{ pointer, detail string }{
+		{"/data/attributes/email", `email failed the "email" validation`},
+		{"/data/attributes/tags/1", `tags failed the "max" validation (5)`},
+		{"/data/attributes/addresses/0/street_name", `addresses failed the "required" validation`},
+		{"/data/relationships/manager", `manager failed the "required" validation`},
+		{"/data/id", `id failed the "required" validation`},
+		{"", `Internal failed the "len" validation (3)`},
+	}
+	if len(objs) != len(want) {
+		t.Fatalf("got %d error objects, want %d", len(objs), len(want))
+	}
+	for i, w := range want {
+		obj := objs[i]
+		var pointer string
+		if obj.Source != nil {
+			pointer = obj.Source.Pointer
+		}
+		if pointer != w.pointer || obj.Detail != w.detail || obj.Status != "422" || obj.Code != err[i].tag {
+			t.Errorf("object %d: pointer %q, detail %q, status %s, code %s", i, pointer, obj.Detail, obj.Status, obj.Code)
+		}
+	}
+
+	if objs := ValidationErrorObjects(&validUser{}, errors.New("other")); objs != nil {
+		t.Errorf("objects for a plain error: %v", objs)
+	}
+}
+
+func TestJSONPointer(t *testing.T) {
+	if got, want := jsonPointer("data", "attributes", "a/b~c"), "/data/attributes/a~1b~0c"; got != want {
+		t.Errorf("jsonPointer = %q, want %q", got, want)
+	}
+}
+
+func TestJSONErrorObject(t *testing.T) {
+	var v struct {
+		Data struct {
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"data"`
+	}
+	typeErr := json.Unmarshal([]byte(`{"data": {"attributes": 1}}`), &v)
+	syntaxErr := json.Unmarshal([]byte(`{"data": }`), &v)
+
+	tests := []struct {
+		err     error
+		pointer string
+		ok      bool
+	}{
+		{typeErr, "/data/attributes", true},
+		{syntaxErr, "", true},
+		{io.ErrUnexpectedEOF, "", true},
+		{errors.New("other"), "", false},
+	}
+	for _, tt := range tests {
+		obj := JSONErrorObject(tt.err)
+		if (obj != nil) != tt.ok {
+			t.Errorf("JSONErrorObject(%v) = %v", tt.err, obj)
+			continue
+		}
+		if obj == nil {
+			continue
+		}
+		var pointer string
+		if obj.Source != nil {
+			pointer = obj.Source.Pointer
+		}
+		if pointer != tt.pointer || obj.Status != "400" || obj.Detail == "" {
+			t.Errorf("JSONErrorObject(%v) = %+v", tt.err, obj)
+		}
+	}
+}
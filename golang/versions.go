@@ -0,0 +1,126 @@
+package jsonapi
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Attributes and relations can be restricted to a range of API versions
+// with the since and until options, so that one struct serves every
+// version:
+//
+//	Name     string `jsonapi:"attr,name,until=v2"`
+//	FullName string `jsonapi:"attr,full-name,since=v2"`
+//
+// since is the first version the member appears in and until the first
+// version it is gone from. Versions are compared by their dot-separated
+// parts, numerically where both parts are numbers, after dropping a leading
+// "v": v2 < v2.1 < v10. Dates such as 2024-05-01 compare as expected too.
+
+// WithAPIVersion makes Marshal write only the attributes and relationships
+// that exist in version v. Without it, the latest shape is written: every
+// member but those tagged with until.
+func WithAPIVersion(v string) MarshalOption {
+	return func(o *marshalOptions) {
+		o.version = v
+	}
+}
+
+// APIVersion is the unmarshal counterpart of WithAPIVersion: members that
+// do not exist in version v are not stored, and are unknown fields for
+// DisallowUnknownFields.
+func APIVersion(v string) UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.version = v
+	}
+}
+
+// versionOption reports whether opt is the option key=VERSION and returns
+// the version.
+func versionOption(opt, key string) (string, bool) {
+	if strings.HasPrefix(opt, key+"=") {
+		return opt[len(key)+1:], true
+	}
+	return "", false
+}
+
+// isVersionOption reports whether opt is a well-formed since or until
+// option.
+func isVersionOption(opt string) bool {
+	for _, key := range []string{annotationSince, annotationUntil} {
+		if v, ok := versionOption(opt, key); ok && v != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// versionRange returns the since and until versions of the member tagged
+// args, "" when absent.
+func versionRange(args []string) (since, until string) {
+	if len(args) < 3 {
+		return "", ""
+	}
+	for _, opt := range args[2:] {
+		if v, ok := versionOption(opt, annotationSince); ok {
+			since = v
+		}
+		if v, ok := versionOption(opt, annotationUntil); ok {
+			until = v
+		}
+	}
+	return since, until
+}
+
+// versionsOverlap reports whether some version has both the member tagged
+// a and the one tagged b.
+func versionsOverlap(a, b []string) bool {
+	sinceA, untilA := versionRange(a)
+	sinceB, untilB := versionRange(b)
+	return (sinceA == "" || untilB == "" || compareVersions(sinceA, untilB) < 0) &&
+		(sinceB == "" || untilA == "" || compareVersions(sinceB, untilA) < 0)
+}
+
+// inVersion reports whether the member tagged args exists in version, or in
+// the latest version when version is "".
+func inVersion(args []string, version string) bool {
+	since, until := versionRange(args)
+	if version == "" {
+		return until == ""
+	}
+	return (since == "" || compareVersions(version, since) >= 0) &&
+		(until == "" || compareVersions(version, until) < 0)
+}
+
+// compareVersions returns -1, 0 or 1 as a is older than, the same as or
+// newer than b.
+func compareVersions(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(strings.TrimPrefix(a, "v"), "V"), ".")
+	pb := strings.Split(strings.TrimPrefix(strings.TrimPrefix(b, "v"), "V"), ".")
+
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if pa[i] == pb[i] {
+			continue
+		}
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		if errA == nil && errB == nil {
+			if na < nb {
+				return -1
+			}
+			return 1
+		}
+		if pa[i] < pb[i] {
+			return -1
+		}
+		return 1
+	}
+
+	switch {
+	case len(pa) < len(pb):
+		return -1
+	case len(pa) > len(pb):
+		return 1
+	}
+	return 0
+}
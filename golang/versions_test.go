@@ -0,0 +1,182 @@
+package jsonapi
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type versionedUser struct {
+	ID       string         `jsonapi:"primary,users"`
+	Name     string         `jsonapi:"attr,name,until=v2"`
+	FullName string         `jsonapi:"attr,name,since=v2"`
+	Email    string         `jsonapi:"attr,email,since=v1.5"`
+	Manager  *versionedUser `jsonapi:"relation,manager,since=v3"`
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1", "v1", 0},
+		{"v2", "V2", 0},
+		{"v2", "v2.1", -1},
+		{"v2.1", "v10", -1},
+		{"v10", "v9", 1},
+		{"1.2.3", "1.2", 1},
+		{"2024-05-01", "2024-12-01", -1},
+		{"beta", "alpha", 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestInVersion(t *testing.T) {
+	tests := []struct {
+		tag     string
+		version string
+		want    bool
+	}{
+		{"attr,name", "", true},
+		{"attr,name", "v1", true},
+		{"attr,name,until=v2", "", false},
+		{"attr,name,until=v2", "v1.9", true},
+		{"attr,name,until=v2", "v2", false},
+		{"attr,name,since=v2", "", true},
+		{"attr,name,since=v2", "v1", false},
+		{"attr,name,since=v2", "v2", true},
+		{"attr,name,since=v2,until=v3", "v2.5", true},
+		{"attr,name,since=v2,until=v3", "v3", false},
+	}
+	for _, tt := range tests {
+		if got := inVersion(strings.Split(tt.tag, ","), tt.version); got != tt.want {
+			t.Errorf("inVersion(%q, %q) = %v, want %v", tt.tag, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestVersionsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"attr,x", "attr,x", true},
+		{"attr,x,until=v2", "attr,x,since=v2", false},
+		{"attr,x,until=v3", "attr,x,since=v2", true},
+		{"attr,x,since=v1,until=v2", "attr,x,since=v3", false},
+		{"attr,x,since=v1,until=v2", "attr,x", true},
+	}
+	for _, tt := range tests {
+		a, b := strings.Split(tt.a, ","), strings.Split(tt.b, ",")
+		if got := versionsOverlap(a, b); got != tt.want {
+			t.Errorf("versionsOverlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := versionsOverlap(b, a); got != tt.want {
+			t.Errorf("versionsOverlap(%q, %q) = %v, want %v", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestIsVersionOption(t *testing.T) {
+	for opt, want := range map[string]bool{
+		"since=v1": true,
+		"until=2":  true,
+		"since=":   false,
+		"since":    false,
+		"after=v1": false,
+	} {
+		if got := isVersionOption(opt); got != want {
+			t.Errorf("isVersionOption(%q) = %v, want %v", opt, got, want)
+		}
+	}
+}
+
+func TestWithAPIVersion(t *testing.T) {
+	in := &versionedUser{ID: "1", Name: "Ann", FullName: "Ann Lee", Email: "ann@example.com",
+		Manager: &versionedUser{ID: "2"}}
+
+	tests := []struct {
+		opts  []MarshalOption
+		attrs map[string]interface{}
+		rels  bool
+	}{
+		{nil, map[string]interface{}{"name": "Ann Lee", "email": "ann@example.com"}, true},
+		{[]MarshalOption{WithAPIVersion("v1")}, map[string]interface{}{"name": "Ann"}, false},
+		{[]MarshalOption{WithAPIVersion("v1.5")}, map[string]interface{}{"name": "Ann", "email": "ann@example.com"}, false},
+		{[]MarshalOption{WithAPIVersion("v2")}, map[string]interface{}{"name": "Ann Lee", "email": "ann@example.com"}, false},
+		{[]MarshalOption{WithAPIVersion("v3")}, map[string]interface{}{"name": "Ann Lee", "email": "ann@example.com"}, true},
+	}
+	for i, tt := range tests {
+		data := marshalDoc(t, in, tt.opts...)["data"].(map[string]interface{})
+		if !reflect.DeepEqual(data["attributes"], tt.attrs) {
+			t.Errorf("%d: attributes %v, want %v", i, data["attributes"], tt.attrs)
+		}
+		if _, ok := data["relationships"]; ok != tt.rels {
+			t.Errorf("%d: relationships %v", i, data["relationships"])
+		}
+	}
+}
+
+func TestAPIVersion(t *testing.T) {
+	doc := `{"data": {"type": "users", "id": "1", "attributes": {"name": "Ann"}}}`
+
+	out := new(versionedUser)
+	if err := UnmarshalPayload(strings.NewReader(doc), out, APIVersion("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "Ann" || out.FullName != "" {
+		t.Errorf("v1: %+v", out)
+	}
+
+	out = new(versionedUser)
+	if err := UnmarshalPayload(strings.NewReader(doc), out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "" || out.FullName != "Ann" {
+		t.Errorf("latest: %+v", out)
+	}
+
+	// email does not exist before v1.5.
+	doc = `{"data": {"type": "users", "id": "1", "attributes": {"email": "ann@example.com"}}}`
+	err := UnmarshalPayload(strings.NewReader(doc), new(versionedUser), APIVersion("v1"), DisallowUnknownFields())
+	var unknown *UnknownFieldsError
+	if !errors.As(err, &unknown) || !reflect.DeepEqual(unknown.Members(), []string{"email"}) {
+		t.Errorf("v1 email: error %v, want email unknown", err)
+	}
+	if err := UnmarshalPayload(strings.NewReader(doc), new(versionedUser), APIVersion("v2"), DisallowUnknownFields()); err != nil {
+		t.Errorf("v2 email: %v", err)
+	}
+}
+
+func TestCheckModelVersions(t *testing.T) {
+	if errs := CheckModel(&versionedUser{}); errs != nil {
+		t.Errorf("versioned members: %v", errs)
+	}
+
+	type overlapping struct {
+		ID    string `jsonapi:"primary,users"`
+		Name  string `jsonapi:"attr,name,until=v3"`
+		Full  string `jsonapi:"attr,name,since=v2"`
+		Empty string `jsonapi:"attr,empty,since=v2,until=v2"`
+		Bad   string `jsonapi:"attr,bad,since="`
+	}
+	var msgs []string
+	for _, err := range CheckModel(&overlapping{}) {
+		msgs = append(msgs, err.Error())
+	}
+	all := strings.Join(msgs, "\n")
+	for _, want := range []string{
+		`member name "name" is already used by field Name`,
+		"since=v2 is not older than until=v2",
+		`invalid option "since="`,
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("missing %q in:\n%s", want, all)
+		}
+	}
+}
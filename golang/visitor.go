@@ -0,0 +1,25 @@
+package jsonapi
+
+import "errors"
+
+// ErrSkipNode can be returned by a NodeVisitor to leave the visited
+// resource out of the document.
+var ErrSkipNode = errors.New("jsonapi: skip node")
+
+// NodeVisitor is called by Marshal for every model it turns into a
+// resource object, once the object is complete. depth is 0 for primary
+// data and grows by one per relationship followed; path is the dotted
+// relationship path that led to the model, as used by WithInclude.
+//
+// The visitor may modify node, e.g. to add meta or drop attributes.
+// Returning ErrSkipNode removes the resource: it is left out of data or
+// included and relationships pointing at it become empty. Any other error
+// aborts marshaling.
+type NodeVisitor func(model interface{}, node *Node, depth int, path string) error
+
+// WithNodeVisitor makes Marshal call v for every resource object it builds.
+func WithNodeVisitor(v NodeVisitor) MarshalOption {
+	return func(o *marshalOptions) {
+		o.visitor = v
+	}
+}
@@ -0,0 +1,74 @@
+package jsonapi
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWithNodeVisitor(t *testing.T) {
+	post := &orderPost{
+		ID:     "1",
+		Author: &orderPerson{ID: "9"},
+		Comments: []*orderComment{
+			{ID: "a", Author: &orderPerson{ID: "3"}},
+			{ID: "b", Author: &orderPerson{ID: "4"}},
+		},
+	}
+
+	visited := map[string]string{}
+	visitor := func(model interface{}, node *Node, depth int, path string) error {
+		visited[node.Type+"/"+node.ID] = path
+		if depth == 1 && path == "comments" && node.ID == "b" {
+			return ErrSkipNode
+		}
+		if node.Type == "posts" {
+			node.Meta = &Meta{"depth": depth}
+		}
+		return nil
+	}
+
+	doc := marshalDoc(t, post, WithNodeVisitor(visitor))
+	if got, want := includedKeys(t, post, WithNodeVisitor(visitor)), []string{"people/9", "comments/a", "people/3"}; !equalStrings(got, want) {
+		t.Errorf("included %v, want %v", got, want)
+	}
+	if visited["people/3"] != "comments.author" || visited["people/9"] != "author" || visited["posts/1"] != "" {
+		t.Errorf("visited paths %v", visited)
+	}
+
+	data := doc["data"].(map[string]interface{})
+	if meta := data["meta"].(map[string]interface{}); meta["depth"] != float64(0) {
+		t.Errorf("meta %v", meta)
+	}
+	comments := data["relationships"].(map[string]interface{})["comments"].(map[string]interface{})
+	if linkage := comments["data"].([]interface{}); len(linkage) != 1 {
+		t.Errorf("comments linkage %v, want only the kept comment", linkage)
+	}
+}
+
+func TestWithNodeVisitorSkipsToOne(t *testing.T) {
+	skipPeople := func(model interface{}, node *Node, depth int, path string) error {
+		if node.Type == "people" {
+			return ErrSkipNode
+		}
+		return nil
+	}
+	doc := marshalDoc(t, &orderPost{ID: "1", Author: &orderPerson{ID: "9"}}, WithNodeVisitor(skipPeople))
+
+	author := doc["data"].(map[string]interface{})["relationships"].(map[string]interface{})["author"].(map[string]interface{})
+	if data, ok := author["data"]; !ok || data != nil {
+		t.Errorf("author %v, want null linkage", author)
+	}
+	if _, ok := doc["included"]; ok {
+		t.Errorf("included %v, want none", doc["included"])
+	}
+}
+
+func TestWithNodeVisitorError(t *testing.T) {
+	boom := errors.New("boom")
+	_, err := Marshal(&orderPost{ID: "1"}, WithNodeVisitor(func(interface{}, *Node, int, string) error {
+		return boom
+	}))
+	if err != boom {
+		t.Errorf("error %v, want the visitor's", err)
+	}
+}
@@ -0,0 +1,136 @@
+package jsonapi
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// EventSignatureHeader is the HTTP header carrying the signature of a
+// webhook request body, as returned by SignEvent.
+const EventSignatureHeader = "X-JSONAPI-Signature"
+
+// ErrInvalidSignature is returned by VerifyEvent for a body whose
+// signature is missing or does not match.
+var ErrInvalidSignature = errors.New("jsonapi: invalid event signature")
+
+// Event is the envelope of a webhook: the name of what happened, e.g.
+// "appointment.created", when, and the resources concerned as a complete
+// JSON:API document.
+//
+//	e, err := jsonapi.NewEvent("appointment.created", appt)
+//	...
+//	body, signature, err := e.Sign(secret)
+//	...
+//	req.Header.Set(jsonapi.EventSignatureHeader, signature)
+//
+// and on the receiving side:
+//
+//	e, err := jsonapi.VerifyEvent(body, r.Header.Get(jsonapi.EventSignatureHeader), secret)
+//	...
+//	err = e.Unmarshal(&appt)
+type Event struct {
+	// ID identifies the event for deduplication by receivers; optional.
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"event"`
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewEvent returns the event name about models, marshaled as by
+// MarshalBytes with opts, timestamped now.
+func NewEvent(name string, models interface{}, opts ...MarshalOption) (*Event, error) {
+	data, err := MarshalBytes(models, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{Name: name, Timestamp: time.Now().UTC(), Data: data}, nil
+}
+
+// Marshal returns the canonical encoding of e, see CanonicalMarshal.
+func (e *Event) Marshal() ([]byte, error) {
+	b, err := newMarshalOptions(nil).encodeJSON(e)
+	if err != nil {
+		return nil, err
+	}
+	return Canonicalize(b)
+}
+
+// Sign returns the canonical encoding of e, to send as the request body,
+// and its signature with secret, to send in the EventSignatureHeader.
+func (e *Event) Sign(secret []byte) (body []byte, signature string, err error) {
+	if body, err = e.Marshal(); err != nil {
+		return nil, "", err
+	}
+	return body, SignEvent(body, secret), nil
+}
+
+// SignEvent returns the signature of the webhook request body with secret:
+// "sha256=" followed by the hex-encoded HMAC-SHA256 of body.
+func SignEvent(body, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyEvent checks signature, as sent with body, against secret, and
+// returns the event of body if it matches. The body must be the one
+// received, byte for byte. Receivers should reject events with an old
+// Timestamp, or an ID already seen, to prevent replays.
+func VerifyEvent(body []byte, signature string, secret []byte) (*Event, error) {
+	want, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil || !strings.HasPrefix(signature, "sha256=") {
+		return nil, ErrInvalidSignature
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), want) {
+		return nil, ErrInvalidSignature
+	}
+	return UnmarshalEvent(body)
+}
+
+// UnmarshalEvent decodes an event without checking its signature.
+func UnmarshalEvent(body []byte) (*Event, error) {
+	var e Event
+	if err := json.Unmarshal(body, &e); err != nil {
+		return nil, err
+	}
+	if e.Name == "" || len(e.Data) == 0 {
+		return nil, fmt.Errorf("%w: event without name or data", ErrInvalidDocument)
+	}
+	return &e, nil
+}
+
+// Unmarshal decodes the document of e into model, as UnmarshalPayload
+// does, or, if model is a pointer to a slice, as UnmarshalManyPayload
+// does.
+func (e *Event) Unmarshal(model interface{}, opts ...UnmarshalOption) error {
+	v := reflect.ValueOf(model)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return UnmarshalPayload(bytes.NewReader(e.Data), model, opts...)
+	}
+
+	models, err := UnmarshalManyPayload(bytes.NewReader(e.Data), v.Elem().Type().Elem(), opts...)
+	if err != nil {
+		return err
+	}
+	slice := reflect.MakeSlice(v.Elem().Type(), len(models), len(models))
+	for i, m := range models {
+		slice.Index(i).Set(reflect.ValueOf(m))
+	}
+	v.Elem().Set(slice)
+	return nil
+}
+
+// Document parses the document of e, whatever its shape.
+func (e *Event) Document(opts ...UnmarshalOption) (*Document, error) {
+	return Parse(bytes.NewReader(e.Data), opts...)
+}
@@ -0,0 +1,107 @@
+package jsonapi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEventSignAndVerify(t *testing.T) {
+	secret := []byte("s3cret")
+	e, err := NewEvent("people.created", &reqAuthor{ID: "1", Name: "Ann"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.ID = "evt-1"
+	if e.Timestamp.IsZero() || e.Timestamp.Location().String() != "UTC" {
+		t.Errorf("timestamp %v, want now in UTC", e.Timestamp)
+	}
+
+	body, signature, err := e.Sign(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signature != SignEvent(body, secret) || !strings.HasPrefix(signature, "sha256=") {
+		t.Errorf("signature %q", signature)
+	}
+	if canonical, err := Canonicalize(body); err != nil || string(canonical) != string(body) {
+		t.Errorf("body %s is not canonical", body)
+	}
+
+	got, err := VerifyEvent(body, signature, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != "evt-1" || got.Name != "people.created" || !got.Timestamp.Equal(e.Timestamp) {
+		t.Errorf("event %+v, want %+v", got, e)
+	}
+
+	var author reqAuthor
+	if err := got.Unmarshal(&author); err != nil {
+		t.Fatal(err)
+	}
+	if author.ID != "1" || author.Name != "Ann" {
+		t.Errorf("author %+v", author)
+	}
+	doc, err := got.Document()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Data == nil || doc.Data.One == nil || doc.Data.One.ID != "1" {
+		t.Errorf("document data %+v", doc.Data)
+	}
+}
+
+func TestVerifyEventInvalid(t *testing.T) {
+	secret := []byte("s3cret")
+	e, err := NewEvent("people.created", &reqAuthor{ID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, signature, err := e.Sign(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := []byte(strings.Replace(string(body), `"id":"1"`, `"id":"2"`, 1))
+	tests := []struct {
+		name      string
+		body      []byte
+		signature string
+		secret    []byte
+	}{
+		{"missing", body, "", secret},
+		{"no prefix", body, strings.TrimPrefix(signature, "sha256="), secret},
+		{"not hex", body, "sha256=zz", secret},
+		{"other secret", body, signature, []byte("other")},
+		{"tampered body", tampered, signature, secret},
+	}
+	for _, tt := range tests {
+		if _, err := VerifyEvent(tt.body, tt.signature, tt.secret); err != ErrInvalidSignature {
+			t.Errorf("%s: error %v, want ErrInvalidSignature", tt.name, err)
+		}
+	}
+}
+
+func TestUnmarshalEvent(t *testing.T) {
+	for _, body := range []string{`{"data": {"data": null}}`, `{"event": "x"}`} {
+		if _, err := UnmarshalEvent([]byte(body)); !errors.Is(err, ErrInvalidDocument) {
+			t.Errorf("%s: error %v, want ErrInvalidDocument", body, err)
+		}
+	}
+	if _, err := UnmarshalEvent([]byte(`{`)); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+
+	e, err := NewEvent("people.updated", []*reqAuthor{{ID: "1", Name: "Ann"}, {ID: "2", Name: "Bob"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var authors []*reqAuthor
+	if err := e.Unmarshal(&authors); err != nil {
+		t.Fatal(err)
+	}
+	if len(authors) != 2 || authors[1].Name != "Bob" {
+		t.Errorf("authors %v", authors)
+	}
+}
@@ -0,0 +1,173 @@
+// Package ws frames JSON:API documents in WebSocket messages for realtime
+// clients of JSON:API servers. Each message is a JSON object:
+//
+//	{"op": "update", "ref": "42", "document": {"data": {...}}}
+//
+// op says what the message is for, ref lets a reply be matched with its
+// request, and document is a complete JSON:API document, if any.
+//
+// Codec.Read and Codec.Write work with any connection that has the
+// ReadMessage and WriteMessage methods of a gorilla/websocket *Conn. With
+// nhooyr.io/websocket, use Encode and Decode with the bytes of its Read and
+// Write:
+//
+//	b, err := codec.Encode(ws.OpEvent, "", appt)
+//	...
+//	err = conn.Write(ctx, websocket.MessageText, b)
+package ws
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	jsonapi "test3"
+)
+
+// Common operations. Applications may use their own as well.
+const (
+	OpGet         = "get"
+	OpCreate      = "create"
+	OpUpdate      = "update"
+	OpDelete      = "delete"
+	OpSubscribe   = "subscribe"
+	OpUnsubscribe = "unsubscribe"
+	// OpEvent pushes a change from the server.
+	OpEvent = "event"
+	// OpResult answers the request with the same ref.
+	OpResult = "result"
+	// OpError answers the request with the same ref with an errors
+	// document.
+	OpError = "error"
+)
+
+// textMessage is the text frame type of gorilla/websocket.
+const textMessage = 1
+
+// Message is a framed JSON:API document.
+type Message struct {
+	Op       string          `json:"op"`
+	Ref      string          `json:"ref,omitempty"`
+	Document json.RawMessage `json:"document,omitempty"`
+}
+
+// MessageReader is implemented by gorilla/websocket's *Conn.
+type MessageReader interface {
+	ReadMessage() (messageType int, p []byte, err error)
+}
+
+// MessageWriter is implemented by gorilla/websocket's *Conn.
+type MessageWriter interface {
+	WriteMessage(messageType int, data []byte) error
+}
+
+// Codec encodes and decodes messages. The zero value is ready to use.
+type Codec struct {
+	// MarshalOptions apply to the documents of models.
+	MarshalOptions []jsonapi.MarshalOption
+	// UnmarshalOptions apply to Unmarshal.
+	UnmarshalOptions []jsonapi.UnmarshalOption
+}
+
+// Encode returns the message op with the reference ref and the document
+// of v: a model, as for jsonapi.MarshalPayload, a *jsonapi.ErrorObject, a
+// []*jsonapi.ErrorObject or an error for an errors document, a
+// json.RawMessage holding a document, or nil for none.
+func (c Codec) Encode(op, ref string, v interface{}) ([]byte, error) {
+	doc, err := c.document(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	opts := append(c.MarshalOptions[:len(c.MarshalOptions):len(c.MarshalOptions)], jsonapi.WithoutTrailingNewline())
+	if err := jsonapi.EncodePayload(&buf, &Message{Op: op, Ref: ref, Document: doc}, opts...); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c Codec) document(v interface{}) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case json.RawMessage:
+		return v, nil
+	case *jsonapi.ErrorObject:
+		return c.document([]*jsonapi.ErrorObject{v})
+	case []*jsonapi.ErrorObject:
+		if err := jsonapi.MarshalErrors(&buf, v, c.MarshalOptions...); err != nil {
+			return nil, err
+		}
+		return bytes.TrimSpace(buf.Bytes()), nil
+	case error:
+		return c.document(jsonapi.ErrorObjectsFor(v))
+	}
+	return jsonapi.MarshalBytes(v, c.MarshalOptions...)
+}
+
+// Decode decodes a message.
+func (c Codec) Decode(b []byte) (*Message, error) {
+	var m Message
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	if m.Op == "" {
+		return nil, fmt.Errorf("ws: message without op")
+	}
+	return &m, nil
+}
+
+// Unmarshal decodes the document of m into model, as
+// jsonapi.UnmarshalPayload does, or, if model is a pointer to a slice, as
+// jsonapi.UnmarshalManyPayload does.
+func (c Codec) Unmarshal(m *Message, model interface{}) error {
+	if len(m.Document) == 0 {
+		return fmt.Errorf("ws: %s message without document", m.Op)
+	}
+
+	v := reflect.ValueOf(model)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return jsonapi.UnmarshalPayload(bytes.NewReader(m.Document), model, c.UnmarshalOptions...)
+	}
+
+	models, err := jsonapi.UnmarshalManyPayload(bytes.NewReader(m.Document),
+		v.Elem().Type().Elem(), c.UnmarshalOptions...)
+	if err != nil {
+		return err
+	}
+	slice := reflect.MakeSlice(v.Elem().Type(), len(models), len(models))
+	for i, model := range models {
+		slice.Index(i).Set(reflect.ValueOf(model))
+	}
+	v.Elem().Set(slice)
+	return nil
+}
+
+// Errors returns the error objects of m if it holds an errors document.
+func (c Codec) Errors(m *Message) []*jsonapi.ErrorObject {
+	var payload jsonapi.ErrorsPayload
+	if json.Unmarshal(m.Document, &payload) != nil {
+		return nil
+	}
+	return payload.Errors
+}
+
+// Write sends the message encoded by Encode as a text message.
+func (c Codec) Write(conn MessageWriter, op, ref string, v interface{}) error {
+	b, err := c.Encode(op, ref, v)
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(textMessage, b)
+}
+
+// Read receives the next message.
+func (c Codec) Read(conn MessageReader) (*Message, error) {
+	_, b, err := conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	return c.Decode(b)
+}
@@ -0,0 +1,146 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	jsonapi "test3"
+)
+
+type post struct {
+	ID    string `jsonapi:"primary,posts"`
+	Title string `jsonapi:"attr,title"`
+}
+
+// conn is an in-memory connection with the methods of a gorilla/websocket
+// *Conn.
+type conn struct {
+	types    []int
+	messages [][]byte
+}
+
+func (c *conn) WriteMessage(messageType int, data []byte) error {
+	c.types = append(c.types, messageType)
+	c.messages = append(c.messages, data)
+	return nil
+}
+
+func (c *conn) ReadMessage() (int, []byte, error) {
+	if len(c.messages) == 0 {
+		return 0, nil, errors.New("closed")
+	}
+	b := c.messages[0]
+	c.messages = c.messages[1:]
+	return textMessage, b, nil
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	var c Codec
+	conn := new(conn)
+	if err := c.Write(conn, OpUpdate, "42", &post{ID: "1", Title: "Hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Write(conn, OpEvent, "", []*post{{ID: "1"}, {ID: "2"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Write(conn, OpSubscribe, "43", nil); err != nil {
+		t.Fatal(err)
+	}
+	if conn.types[0] != textMessage {
+		t.Errorf("message type %d, want text", conn.types[0])
+	}
+	if got := string(conn.messages[2]); got != `{"op":"subscribe","ref":"43"}` {
+		t.Errorf("message without document %s", got)
+	}
+
+	m, err := c.Read(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Op != OpUpdate || m.Ref != "42" {
+		t.Errorf("message %+v", m)
+	}
+	var p post
+	if err := c.Unmarshal(m, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p != (post{ID: "1", Title: "Hello"}) {
+		t.Errorf("post %+v", p)
+	}
+
+	if m, err = c.Read(conn); err != nil {
+		t.Fatal(err)
+	}
+	var posts []*post
+	if err := c.Unmarshal(m, &posts); err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 || posts[1].ID != "2" {
+		t.Errorf("posts %v", posts)
+	}
+
+	if m, err = c.Read(conn); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Unmarshal(m, &p); err == nil {
+		t.Error("expected an error for a message without document")
+	}
+	if _, err := c.Read(conn); err == nil || err.Error() != "closed" {
+		t.Errorf("error %v, want the connection's", err)
+	}
+}
+
+func TestCodecErrors(t *testing.T) {
+	c := Codec{MarshalOptions: []jsonapi.MarshalOption{jsonapi.WithoutTrailingNewline()}}
+	obj := &jsonapi.ErrorObject{Status: "404", Title: "Not Found"}
+	tests := []struct {
+		name string
+		v    interface{}
+		want []string
+	}{
+		{"object", obj, []string{"404"}},
+		{"objects", []*jsonapi.ErrorObject{obj, {Status: "409"}}, []string{"404", "409"}},
+		{"error", jsonapi.ErrInvalidDocument, []string{"400"}},
+	}
+	for _, tt := range tests {
+		b, err := c.Encode(OpError, "1", tt.v)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		m, err := c.Decode(b)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var statuses []string
+		for _, e := range c.Errors(m) {
+			statuses = append(statuses, e.Status)
+		}
+		if len(statuses) != len(tt.want) || statuses[0] != tt.want[0] || statuses[len(statuses)-1] != tt.want[len(tt.want)-1] {
+			t.Errorf("%s: statuses %v, want %v", tt.name, statuses, tt.want)
+		}
+	}
+
+	// Raw documents are sent as they are.
+	raw := json.RawMessage(`{"meta":{"n":1}}`)
+	b, err := c.Encode(OpResult, "", raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"op":"result","document":{"meta":{"n":1}}}` {
+		t.Errorf("message %s", b)
+	}
+	m, _ := c.Decode(b)
+	if errs := c.Errors(m); errs != nil {
+		t.Errorf("errors %v in a document without errors", errs)
+	}
+
+	if _, err := c.Encode(OpUpdate, "", 1); err != jsonapi.ErrUnexpectedType {
+		t.Errorf("error %v, want ErrUnexpectedType", err)
+	}
+	for _, b := range []string{`{}`, `{"op":`} {
+		if _, err := c.Decode([]byte(b)); err == nil {
+			t.Errorf("Decode(%s): expected an error", b)
+		}
+	}
+}